@@ -11,9 +11,13 @@ import (
 	"github.com/victor-devv/ec2-drift-detector/internal/container"
 )
 
+// Version is set at build time via -ldflags "-X main.Version=..."
+var Version = "dev"
+
 func main() {
 	// Initialize the dependency container
 	c := container.NewContainer()
+	c.Register("version", Version)
 
 	if err := run(c); err != nil {
 		handler, _ := container.Resolve[*errors.ErrorHandler](c, "errorHandler")
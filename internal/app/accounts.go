@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// AccountProvider pairs an AWS account ID with the instance provider for that account
+type AccountProvider struct {
+	AccountID string
+	Provider  service.InstanceProvider
+}
+
+// MultiAccountInstanceProvider aggregates instances from several AWS accounts
+// and tags each instance with the account it was fetched from
+type MultiAccountInstanceProvider struct {
+	accounts []AccountProvider
+	logger   *logging.Logger
+}
+
+// Ensure MultiAccountInstanceProvider implements the service.InstanceProvider interface
+var _ service.InstanceProvider = (*MultiAccountInstanceProvider)(nil)
+
+// NewMultiAccountInstanceProvider creates a provider that fans out over the given accounts
+func NewMultiAccountInstanceProvider(accounts []AccountProvider, logger *logging.Logger) *MultiAccountInstanceProvider {
+	return &MultiAccountInstanceProvider{
+		accounts: accounts,
+		logger:   logger.WithField("component", "multi-account-provider"),
+	}
+}
+
+// GetInstance retrieves an instance by ID from the first account that has it
+func (p *MultiAccountInstanceProvider) GetInstance(ctx context.Context, instanceID string) (*model.Instance, error) {
+	for _, account := range p.accounts {
+		instance, err := account.Provider.GetInstance(ctx, instanceID)
+		if err != nil {
+			if errors.IsNotFoundError(err) {
+				continue
+			}
+			return nil, errors.NewOperationalError(fmt.Sprintf("Failed to get instance %s from account %s", instanceID, account.AccountID), err)
+		}
+
+		instance.AccountID = account.AccountID
+		return instance, nil
+	}
+
+	return nil, errors.NewNotFoundError("EC2 Instance", instanceID)
+}
+
+// ListInstances retrieves the instances of all accounts concurrently
+func (p *MultiAccountInstanceProvider) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	p.logger.Info(fmt.Sprintf("Listing instances across %d accounts", len(p.accounts)))
+
+	perAccount := make([][]*model.Instance, len(p.accounts))
+	errs := make([]error, len(p.accounts))
+
+	var wg sync.WaitGroup
+	for i, account := range p.accounts {
+		wg.Add(1)
+		go func(idx int, account AccountProvider) {
+			defer wg.Done()
+			perAccount[idx], errs[idx] = account.Provider.ListInstances(ctx)
+		}(i, account)
+	}
+	wg.Wait()
+
+	var instances []*model.Instance
+	for i, account := range p.accounts {
+		if errs[i] != nil {
+			return nil, errors.NewOperationalError(fmt.Sprintf("Failed to list instances in account %s", account.AccountID), errs[i])
+		}
+
+		for _, instance := range perAccount[i] {
+			instance.AccountID = account.AccountID
+			instances = append(instances, instance)
+		}
+		p.logger.Info(fmt.Sprintf("Found %d instances in account %s", len(perAccount[i]), account.AccountID))
+	}
+
+	return instances, nil
+}
+
+// ListResources retrieves the orphan-checked resources of all accounts whose provider supports it
+func (p *MultiAccountInstanceProvider) ListResources(ctx context.Context) ([]*model.Resource, error) {
+	var resources []*model.Resource
+	for _, account := range p.accounts {
+		provider, ok := account.Provider.(service.ResourceProvider)
+		if !ok {
+			continue
+		}
+
+		accountResources, err := provider.ListResources(ctx)
+		if err != nil {
+			return nil, errors.NewOperationalError(fmt.Sprintf("Failed to list resources in account %s", account.AccountID), err)
+		}
+
+		for _, resource := range accountResources {
+			resource.AccountID = account.AccountID
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// DescribeSubnets retrieves the subnets with the given IDs from every account whose provider
+// can describe them, since the account a subnet belongs to isn't known up front
+func (p *MultiAccountInstanceProvider) DescribeSubnets(ctx context.Context, subnetIDs []string) (map[string]*model.Subnet, error) {
+	subnets := make(map[string]*model.Subnet, len(subnetIDs))
+	for _, account := range p.accounts {
+		provider, ok := account.Provider.(service.SubnetProvider)
+		if !ok {
+			continue
+		}
+
+		accountSubnets, err := provider.DescribeSubnets(ctx, subnetIDs)
+		if err != nil {
+			return nil, errors.NewOperationalError(fmt.Sprintf("Failed to describe subnets in account %s", account.AccountID), err)
+		}
+		for id, subnet := range accountSubnets {
+			subnets[id] = subnet
+		}
+	}
+
+	return subnets, nil
+}
+
+// GetAccounts returns the accounts the provider fans out over
+func (p *MultiAccountInstanceProvider) GetAccounts() []AccountProvider {
+	return p.accounts
+}
@@ -0,0 +1,87 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+func TestMultiAccountInstanceProvider_TagsInstances(t *testing.T) {
+	provider := app.NewMultiAccountInstanceProvider([]app.AccountProvider{
+		{AccountID: "111111111111", Provider: &mockInstanceProvider{instances: []*model.Instance{
+			model.NewInstance("i-aaa", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS),
+		}}},
+		{AccountID: "222222222222", Provider: &mockInstanceProvider{instances: []*model.Instance{
+			model.NewInstance("i-bbb", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginAWS),
+		}}},
+	}, logging.New())
+
+	instances, err := provider.ListInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	assert.Equal(t, "111111111111", instances[0].AccountID)
+	assert.Equal(t, "222222222222", instances[1].AccountID)
+}
+
+func TestMultiAccountInstanceProvider_PropagatesAccountError(t *testing.T) {
+	provider := app.NewMultiAccountInstanceProvider([]app.AccountProvider{
+		{AccountID: "111111111111", Provider: &mockInstanceProvider{}},
+		{AccountID: "222222222222", Provider: &mockInstanceProvider{err: errors.New("access denied")}},
+	}, logging.New())
+
+	_, err := provider.ListInstances(context.Background())
+	assert.ErrorContains(t, err, "222222222222")
+}
+
+func TestDetectDriftForAll_AggregatesAcrossAccounts(t *testing.T) {
+	awsProvider := app.NewMultiAccountInstanceProvider([]app.AccountProvider{
+		{AccountID: "111111111111", Provider: &mockInstanceProvider{instances: []*model.Instance{
+			model.NewInstance("i-aaa", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS),
+		}}},
+		{AccountID: "222222222222", Provider: &mockInstanceProvider{instances: []*model.Instance{
+			model.NewInstance("i-bbb", map[string]interface{}{"instance_type": "t3.large"}, model.OriginAWS),
+		}}},
+	}, logging.New())
+
+	terraformProvider := &mockInstanceProvider{instances: []*model.Instance{
+		model.NewInstance("i-aaa", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform),
+		model.NewInstance("i-bbb", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginTerraform),
+	}}
+
+	detector := app.NewDriftDetectorService(
+		awsProvider,
+		terraformProvider,
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 2,
+		},
+		logging.New(),
+	)
+
+	results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	byInstance := make(map[string]*model.DriftResult)
+	for _, result := range results {
+		byInstance[result.ResourceID] = result
+	}
+	assert.Equal(t, "111111111111", byInstance["i-aaa"].AccountID)
+	assert.Equal(t, "222222222222", byInstance["i-bbb"].AccountID)
+
+	summaries := model.SummarizeByAccount(results)
+	assert.Equal(t, model.AccountSummary{TotalInstances: 1, DriftedCount: 0}, summaries["111111111111"])
+	assert.Equal(t, model.AccountSummary{TotalInstances: 1, DriftedCount: 1}, summaries["222222222222"])
+}
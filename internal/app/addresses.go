@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// listResourceInstances lists the Terraform instances whose resource address matches the
+// resource filter, then fetches only those instances from AWS. Instances that exist only in
+// AWS have no address and are never checked. Resources renamed by moved blocks also match by
+// their former addresses.
+func (s *DriftDetectorService) listResourceInstances(ctx context.Context) ([]*model.Instance, []*model.Instance, error) {
+	terraformCtx, cancel := providerContext(ctx, s.terraformTimeout)
+	start := s.clock.Now()
+	all, err := s.terraformProvider.ListInstances(terraformCtx)
+	s.recordFetch(ctx, model.OriginTerraform, start)
+	var moves model.AddressMoves
+	if err == nil {
+		moves = s.addressMoves(terraformCtx)
+	}
+	cancel()
+	if err != nil {
+		return nil, nil, errors.NewOperationalError("Failed to list Terraform instances", err)
+	}
+
+	filter := strings.Join(s.resourceFilter, ", ")
+	var terraformInstances []*model.Instance
+	var ids []string
+	for _, instance := range all {
+		if s.matchResourceFilter(instance.ResourceAddress, moves) {
+			terraformInstances = append(terraformInstances, instance)
+			ids = append(ids, instance.ID)
+		}
+	}
+	if len(terraformInstances) == 0 {
+		return nil, nil, errors.NewValidationError(fmt.Sprintf("No Terraform instances match resource %s", filter)).
+			WithContext("resource", filter)
+	}
+	s.logger.Info(fmt.Sprintf("Resource %s matches %d of %d Terraform instances", filter, len(terraformInstances), len(all)))
+
+	awsCtx, cancel := providerContext(ctx, s.awsTimeout)
+	defer cancel()
+	start = s.clock.Now()
+	awsInstances, err := s.awsInstancesByID(awsCtx, ids)
+	s.recordFetch(ctx, model.OriginAWS, start)
+	if err != nil {
+		return nil, nil, errors.NewOperationalError("Failed to list AWS instances", err)
+	}
+
+	return awsInstances, terraformInstances, nil
+}
+
+// matchResourceFilter reports whether a resource address, or an address a moved block renamed
+// it from, matches the resource filter
+func (s *DriftDetectorService) matchResourceFilter(address string, moves model.AddressMoves) bool {
+	if model.MatchAnyResourceAddress(s.resourceFilter, address) {
+		return true
+	}
+	for _, former := range moves.Former(address) {
+		if model.MatchAnyResourceAddress(s.resourceFilter, former) {
+			s.logger.Info(fmt.Sprintf("Resource %s matches %s through its former address %s", address, strings.Join(s.resourceFilter, ", "), former))
+			return true
+		}
+	}
+	return false
+}
+
+// addressMoves returns the moves declared by moved blocks, when the Terraform provider knows
+// them. Failing to read them only leaves renamed resources unmatched by their former addresses.
+func (s *DriftDetectorService) addressMoves(ctx context.Context) model.AddressMoves {
+	provider, ok := s.terraformProvider.(service.AddressMoveProvider)
+	if !ok {
+		return nil
+	}
+	moves, err := provider.AddressMoves(ctx)
+	if err != nil {
+		s.logger.Warn(fmt.Sprintf("Failed to read moved blocks, matching current addresses only: %v", err))
+		return nil
+	}
+
+	applied := make([]string, 0, len(moves))
+	for from, to := range moves {
+		applied = append(applied, from+" -> "+to)
+	}
+	if len(applied) > 0 {
+		sort.Strings(applied)
+		s.logger.Info(fmt.Sprintf("Applying %d moved blocks: %s", len(applied), strings.Join(applied, ", ")))
+	}
+	return moves
+}
+
+// awsInstancesByID fetches the AWS instances with the given IDs, in batches when the provider
+// supports it and otherwise by listing every instance and keeping the requested ones
+func (s *DriftDetectorService) awsInstancesByID(ctx context.Context, ids []string) ([]*model.Instance, error) {
+	if provider, ok := s.awsProvider.(service.InstanceBatchProvider); ok {
+		return provider.ListInstancesByID(ctx, ids)
+	}
+
+	all, err := s.awsProvider.ListInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var instances []*model.Instance
+	for _, instance := range all {
+		if wanted[instance.ID] {
+			instances = append(instances, instance)
+		}
+	}
+	return instances, nil
+}
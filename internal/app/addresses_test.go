@@ -0,0 +1,185 @@
+package app_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	apperrors "github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// batchProvider fetches instances by ID, recording the IDs requested, and fails listing them all
+type batchProvider struct {
+	mockInstanceProvider
+	requested []string
+}
+
+func (m *batchProvider) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	return nil, apperrors.NewOperationalError("listing every instance is not expected", nil)
+}
+
+func (m *batchProvider) ListInstancesByID(ctx context.Context, ids []string) ([]*model.Instance, error) {
+	m.requested = append(m.requested, ids...)
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var instances []*model.Instance
+	for _, instance := range m.instances {
+		if wanted[instance.ID] {
+			instances = append(instances, instance)
+		}
+	}
+	return instances, nil
+}
+
+// addressedInstances returns Terraform instances at the given addresses, keyed by ID, and AWS
+// instances of the same IDs with another instance type, plus one AWS-only instance
+func addressedInstances(addresses map[string]string) (aws, terraform []*model.Instance) {
+	for id, address := range addresses {
+		tf := model.NewInstance(id, map[string]interface{}{"instance_type": "t3.micro"}, model.OriginTerraform)
+		tf.ResourceAddress = address
+		terraform = append(terraform, tf)
+		aws = append(aws, model.NewInstance(id, map[string]interface{}{"instance_type": "t3.large"}, model.OriginAWS))
+	}
+	aws = append(aws, model.NewInstance("i-unmanaged", map[string]interface{}{"instance_type": "t3.large"}, model.OriginAWS))
+	return aws, terraform
+}
+
+func resultAddresses(results []*model.DriftResult) []string {
+	var addresses []string
+	for _, result := range results {
+		addresses = append(addresses, result.ResourceAddress)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func TestDetectDriftForAll_ResourceFilter(t *testing.T) {
+	awsInstances, terraformInstances := addressedInstances(map[string]string{
+		"i-web0": "module.web.aws_instance.app[0]",
+		"i-web1": "module.web.aws_instance.app[1]",
+		"i-app2": "aws_instance.app[2]",
+		"i-app3": "aws_instance.app[3]",
+	})
+	awsProvider := &batchProvider{mockInstanceProvider: mockInstanceProvider{instances: awsInstances}}
+
+	detector := app.NewDriftDetectorService(awsProvider, &mockInstanceProvider{instances: terraformInstances}, &mockRepository{}, nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			ParallelChecks: 2,
+			Timeout:        5 * time.Second,
+			ResourceFilter: []string{"module.web.*", "aws_instance.app[2]"},
+		}, logging.New())
+
+	results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	require.NoError(t, err)
+
+	// Only the matching resources are checked, and only they are fetched from AWS
+	assert.Equal(t, []string{"aws_instance.app[2]", "module.web.aws_instance.app[0]", "module.web.aws_instance.app[1]"}, resultAddresses(results))
+	sort.Strings(awsProvider.requested)
+	assert.Equal(t, []string{"i-app2", "i-web0", "i-web1"}, awsProvider.requested)
+	for _, result := range results {
+		assert.True(t, result.HasDrift)
+	}
+}
+
+func TestDetectDriftForAll_ResourceFilterWithoutBatchFetch(t *testing.T) {
+	awsInstances, terraformInstances := addressedInstances(map[string]string{
+		"i-app0": "aws_instance.app[0]",
+		"i-db0":  "aws_instance.db[0]",
+	})
+
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{instances: awsInstances}, &mockInstanceProvider{instances: terraformInstances}, &mockRepository{}, nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        5 * time.Second,
+			ResourceFilter: []string{"aws_instance.app"},
+		}, logging.New())
+
+	// Listing every AWS instance, the unmatched and AWS-only instances are left out
+	results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aws_instance.app[0]"}, resultAddresses(results))
+
+	// A filter matching nothing is a usage mistake rather than an empty run
+	detector.SetResourceFilter([]string{"module.missing"})
+	_, err = detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	assert.True(t, apperrors.IsValidationError(err))
+}
+
+// movingProvider is a Terraform provider whose configuration declares moved blocks
+type movingProvider struct {
+	mockInstanceProvider
+	moves model.AddressMoves
+}
+
+func (m *movingProvider) AddressMoves(ctx context.Context) (model.AddressMoves, error) {
+	return m.moves, nil
+}
+
+func TestDetectDriftForAll_ResourceFilterFollowsMoves(t *testing.T) {
+	// aws_instance.web was refactored into module.web.aws_instance.this
+	awsInstances, terraformInstances := addressedInstances(map[string]string{
+		"i-web0": "module.web.aws_instance.this[0]",
+		"i-web1": "module.web.aws_instance.this[1]",
+		"i-db0":  "aws_instance.db",
+	})
+	terraformProvider := &movingProvider{
+		mockInstanceProvider: mockInstanceProvider{instances: terraformInstances},
+		moves:                model.AddressMoves{"aws_instance.web": "module.web.aws_instance.this"},
+	}
+
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{instances: awsInstances}, terraformProvider, &mockRepository{}, nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        5 * time.Second,
+			ResourceFilter: []string{"aws_instance.web[1]"},
+		}, logging.New())
+
+	// The former address still selects the moved resource, reported at its current address
+	results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"module.web.aws_instance.this[1]"}, resultAddresses(results))
+
+	detector.SetResourceFilter([]string{"aws_instance.web"})
+	results, err = detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"module.web.aws_instance.this[0]", "module.web.aws_instance.this[1]"}, resultAddresses(results))
+}
+
+func TestDetectDriftForAll_ReportsDuplicateAddresses(t *testing.T) {
+	terraformInstance := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.small"}, model.OriginTerraform)
+	terraformInstance.ResourceAddress = "aws_instance.web"
+	terraformInstance.DuplicateAddresses = []string{"aws_instance.web_imported"}
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.small"}, model.OriginAWS)}},
+		&mockInstanceProvider{instances: []*model.Instance{terraformInstance}},
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 1,
+		},
+		logging.New(),
+	)
+
+	results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].HasDrift)
+	assert.Equal(t, "aws_instance.web", results[0].ResourceAddress)
+	assert.Equal(t, []string{"aws_instance.web_imported"}, results[0].DuplicateAddresses)
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// attachDriftAges sets when each drift of the result first appeared, from the previous results
+// of the instance in the repository, when drift ages are tracked. Without previous results every
+// drift is new; when they can't be read the ages are left unset.
+func (s *DriftDetectorService) attachDriftAges(ctx context.Context, result *model.DriftResult) {
+	if s.driftAgeLookback <= 0 || !result.HasDrift {
+		return
+	}
+
+	history, err := s.repository.GetDriftResultsByInstanceID(ctx, result.ResourceID)
+	if err != nil && !errors.IsNotFoundError(err) {
+		s.logger.Warn(fmt.Sprintf("Failed to read previous results of instance %s, drift ages are unknown: %v", result.ResourceID, err))
+		return
+	}
+	result.SetDriftAges(history, s.driftAgeLookback)
+}
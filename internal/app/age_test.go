@@ -0,0 +1,81 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/repository"
+)
+
+func TestDetectDrift_DriftAges(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	newDetector := func(lookback int, memoize bool) *app.DriftDetectorService {
+		return app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, repository.NewInMemoryDriftRepository(logging.New()), nil, service.DriftDetectorConfig{
+			SourceOfTruth:    model.OriginTerraform,
+			AttributePaths:   []string{"instance_type", "tags"},
+			Timeout:          2 * time.Second,
+			Clock:            fake,
+			DriftAgeLookback: lookback,
+			Memoize:          memoize,
+		}, logging.New())
+	}
+	detect := func(t *testing.T, detector *app.DriftDetectorService, awsType, awsName string) *model.DriftResult {
+		terraform := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro", "tags": map[string]interface{}{"Name": "web"}}, model.OriginTerraform)
+		aws := model.NewInstance("i-1", map[string]interface{}{"instance_type": awsType, "tags": map[string]interface{}{"Name": awsName}}, model.OriginAWS)
+		result, err := detector.DetectDrift(context.Background(), terraform, aws, []string{"instance_type", "tags"})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("drifts age across consecutive runs", func(t *testing.T) {
+		fake.Set(now)
+		detector := newDetector(30, false)
+
+		first := detect(t, detector, "t3.large", "web")
+		assert.Equal(t, now, first.DriftedAttributes["instance_type"].FirstDetected)
+		assert.Zero(t, first.DriftedAttributes["instance_type"].DriftAge)
+
+		fake.Advance(24 * time.Hour)
+		detect(t, detector, "t3.large", "web")
+
+		fake.Advance(24 * time.Hour)
+		third := detect(t, detector, "t3.large", "api")
+		assert.Equal(t, now, third.DriftedAttributes["instance_type"].FirstDetected)
+		assert.Equal(t, 48*time.Hour, third.DriftedAttributes["instance_type"].DriftAge)
+		assert.Equal(t, now.Add(48*time.Hour), third.DriftedAttributes["tags"].FirstDetected)
+
+		// A run without the drift starts it over
+		fake.Advance(24 * time.Hour)
+		detect(t, detector, "t3.micro", "web")
+		fake.Advance(24 * time.Hour)
+		fifth := detect(t, detector, "t3.large", "web")
+		assert.Zero(t, fifth.DriftedAttributes["instance_type"].DriftAge)
+	})
+
+	t.Run("memoized results keep aging", func(t *testing.T) {
+		fake.Set(now)
+		detector := newDetector(30, true)
+
+		detect(t, detector, "t3.large", "web")
+		fake.Advance(6 * time.Hour)
+		second := detect(t, detector, "t3.large", "web")
+		require.True(t, second.Memoized)
+		assert.Equal(t, now, second.DriftedAttributes["instance_type"].FirstDetected)
+		assert.Equal(t, 6*time.Hour, second.DriftedAttributes["instance_type"].DriftAge)
+	})
+
+	t.Run("a zero lookback leaves ages unset", func(t *testing.T) {
+		fake.Set(now)
+		result := detect(t, newDetector(0, false), "t3.large", "web")
+		assert.True(t, result.DriftedAttributes["instance_type"].FirstDetected.IsZero())
+	})
+}
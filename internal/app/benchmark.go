@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// Benchmark checks a random sample of AWS instances at each concurrency level, measuring the
+// wall time, failed and throttled checks and p95 per-instance latency, and suggests settings
+// from the measurements. Checks go through a copy of the detector without reporters and with
+// a repository that discards results, so nothing is saved or reported.
+func (s *DriftDetectorService) Benchmark(ctx context.Context, options service.BenchmarkOptions) (*model.BenchmarkReport, error) {
+	if options.SampleSize < 1 {
+		return nil, errors.NewValidationError("The benchmark sample size must be at least 1")
+	}
+	levels := options.Levels
+	if len(levels) == 0 {
+		levels = service.DefaultBenchmarkLevels
+	}
+	for _, level := range levels {
+		if level < 1 {
+			return nil, errors.NewValidationError(fmt.Sprintf("Benchmark concurrency levels must be at least 1, got %d", level))
+		}
+	}
+	attrs := options.AttributePaths
+	if len(attrs) == 0 {
+		attrs = s.attributePaths
+	}
+
+	awsCtx, cancel := providerContext(ctx, s.awsTimeout)
+	instances, err := s.awsProvider.ListInstances(awsCtx)
+	cancel()
+	if err != nil {
+		return nil, errors.NewOperationalError("Failed to list AWS instances to benchmark", err)
+	}
+	if len(instances) == 0 {
+		return nil, errors.NewOperationalError("AWS has no instances to benchmark", nil)
+	}
+
+	// Load the Terraform state once up front, so that the first level doesn't pay for a cold cache
+	terraformCtx, cancel := providerContext(ctx, s.terraformTimeout)
+	_, err = s.terraformProvider.ListInstances(terraformCtx)
+	cancel()
+	if err != nil {
+		return nil, errors.NewOperationalError("Failed to load Terraform instances to benchmark", err)
+	}
+
+	sample := sampleInstanceIDs(instances, options.SampleSize)
+	report := &model.BenchmarkReport{TotalInstances: len(instances), SampleSize: len(sample)}
+	dry := s.dryRun()
+
+	for _, level := range levels {
+		s.logger.Info(fmt.Sprintf("Benchmarking %d instances with %d parallel checks", len(sample), level))
+		measured := dry.benchmarkLevel(ctx, sample, level, attrs)
+		if err := ctx.Err(); err != nil {
+			return nil, errors.NewOperationalError("Benchmark interrupted", err)
+		}
+		report.Levels = append(report.Levels, measured)
+	}
+
+	report.Recommend()
+	return report, nil
+}
+
+// benchmarkLevel checks the sample with the given number of workers
+func (s *DriftDetectorService) benchmarkLevel(ctx context.Context, sample []string, workers int, attrs []string) model.BenchmarkLevel {
+	measured := model.BenchmarkLevel{ParallelChecks: workers, Instances: len(sample)}
+	latencies := make([]time.Duration, 0, len(sample))
+
+	ids := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				checkStart := time.Now()
+				_, err := s.DetectDriftByID(ctx, id, attrs)
+				latency := time.Since(checkStart)
+
+				mu.Lock()
+				latencies = append(latencies, latency)
+				if err != nil {
+					measured.Errors++
+					if isThrottled(err) {
+						measured.Throttles++
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, id := range sample {
+		select {
+		case ids <- id:
+		case <-ctx.Done():
+		}
+	}
+	close(ids)
+	wg.Wait()
+
+	measured.WallTime = time.Since(start)
+	measured.P95Latency = model.Percentile(latencies, 95)
+	return measured
+}
+
+// dryRun returns a copy of the detector with the same providers and settings that neither
+// saves, reports nor dumps what it checks
+func (s *DriftDetectorService) dryRun() *DriftDetectorService {
+	return NewDriftDetectorService(s.awsProvider, s.terraformProvider, discardRepository{}, nil, service.DriftDetectorConfig{
+		Clock:                        s.clock,
+		SourceOfTruth:                s.sourceOfTruth,
+		AttributePaths:               s.attributePaths,
+		ParallelChecks:               s.parallelChecks,
+		Timeout:                      s.timeout,
+		AWSTimeout:                   s.awsTimeout,
+		TerraformTimeout:             s.terraformTimeout,
+		SourceDeclaredOnly:           s.sourceDeclaredOnly,
+		StaticIPsOnly:                s.staticIPsOnly,
+		CompareOptions:               s.attributeCompareOptions(),
+		Policies:                     s.policies,
+		AllowedInstanceTypes:         s.allowedTypes,
+		EnvironmentTag:               s.environmentTag,
+		SuggestRemediation:           s.suggestRemediation,
+		EnrichNetworkContext:         s.networkContext,
+		CompareTerminationProtection: s.terminationCheck,
+		AllowMismatchedIDs:           s.allowMismatchedIDs,
+	}, s.logger)
+}
+
+// sampleInstanceIDs returns the IDs of up to size instances chosen at random
+func sampleInstanceIDs(instances []*model.Instance, size int) []string {
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.ID)
+	}
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	return ids[:min(size, len(ids))]
+}
+
+// isThrottled reports whether a check failed because AWS throttled its API calls
+func isThrottled(err error) bool {
+	return retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+}
+
+// discardRepository is a repository that keeps nothing, for checks whose results are thrown away
+type discardRepository struct{}
+
+func (discardRepository) SaveDriftResult(ctx context.Context, result *model.DriftResult) error {
+	return nil
+}
+
+func (discardRepository) GetDriftResult(ctx context.Context, id string) (*model.DriftResult, error) {
+	return nil, errors.NewNotFoundError("DriftResult", id)
+}
+
+func (discardRepository) GetDriftResultsByInstanceID(ctx context.Context, instanceID string) ([]*model.DriftResult, error) {
+	return nil, nil
+}
+
+func (discardRepository) ListDriftResults(ctx context.Context) ([]*model.DriftResult, error) {
+	return nil, nil
+}
+
+func (discardRepository) ListDriftResultsWithDrift(ctx context.Context) ([]*model.DriftResult, error) {
+	return nil, nil
+}
+
+// Ensure discardRepository implements the service.DriftRepository interface
+var _ service.DriftRepository = discardRepository{}
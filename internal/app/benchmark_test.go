@@ -0,0 +1,99 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// throttledProvider lists instances but is throttled fetching any of them
+type throttledProvider struct {
+	mockInstanceProvider
+}
+
+func (p *throttledProvider) GetInstance(ctx context.Context, id string) (*model.Instance, error) {
+	return nil, &smithy.GenericAPIError{Code: "RequestLimitExceeded", Message: "Request limit exceeded."}
+}
+
+func benchmarkInstances(origin model.ResourceOrigin) []*model.Instance {
+	var instances []*model.Instance
+	for _, id := range []string{"i-1", "i-2", "i-3", "i-4"} {
+		instances = append(instances, model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro"}, origin))
+	}
+	return instances
+}
+
+func TestBenchmark(t *testing.T) {
+	repo := &mockRepository{}
+	reporter := &mockReporter{}
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: benchmarkInstances(model.OriginAWS)},
+		&mockInstanceProvider{instances: benchmarkInstances(model.OriginTerraform)},
+		repo,
+		[]service.Reporter{reporter},
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+		},
+		logging.New(),
+	)
+
+	report, err := detector.Benchmark(context.Background(), service.BenchmarkOptions{SampleSize: 3, Levels: []int{1, 2}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.TotalInstances)
+	assert.Equal(t, 3, report.SampleSize)
+	require.Len(t, report.Levels, 2)
+	for i, level := range report.Levels {
+		assert.Equal(t, i+1, level.ParallelChecks)
+		assert.Equal(t, 3, level.Instances)
+		assert.Zero(t, level.Errors)
+	}
+	assert.Contains(t, []int{1, 2}, report.ParallelChecks)
+	assert.Positive(t, report.Timeout)
+
+	// Results are discarded
+	assert.Empty(t, repo.saved)
+	assert.Empty(t, reporter.reported)
+}
+
+func TestBenchmark_CountsThrottling(t *testing.T) {
+	detector := app.NewDriftDetectorService(
+		&throttledProvider{mockInstanceProvider{instances: benchmarkInstances(model.OriginAWS)}},
+		&mockInstanceProvider{instances: benchmarkInstances(model.OriginTerraform)},
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{Timeout: 2 * time.Second},
+		logging.New(),
+	)
+
+	report, err := detector.Benchmark(context.Background(), service.BenchmarkOptions{SampleSize: 10, Levels: []int{2}})
+	require.NoError(t, err)
+	require.Len(t, report.Levels, 1)
+	assert.Equal(t, 4, report.Levels[0].Errors)
+	assert.Equal(t, 4, report.Levels[0].Throttles)
+}
+
+func TestBenchmark_InvalidOptions(t *testing.T) {
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, &mockRepository{}, nil,
+		service.DriftDetectorConfig{Timeout: time.Second}, logging.New())
+
+	_, err := detector.Benchmark(context.Background(), service.BenchmarkOptions{})
+	assert.Error(t, err)
+
+	_, err = detector.Benchmark(context.Background(), service.BenchmarkOptions{SampleSize: 5, Levels: []int{0}})
+	assert.Error(t, err)
+
+	// No instances to sample
+	_, err = detector.Benchmark(context.Background(), service.BenchmarkOptions{SampleSize: 5})
+	assert.Error(t, err)
+}
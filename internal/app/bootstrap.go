@@ -14,7 +14,7 @@ func init() {
 	// Register the drift detector service factory function
 	container.RegisterDriftDetectorServiceFactory(func(
 		awsProvider service.InstanceProvider,
-		terraformProvider service.InstanceProvider,
+		terraformProvider service.TerraformProvider,
 		repository service.DriftRepository,
 		reporters []service.Reporter,
 		config service.DriftDetectorConfig,
@@ -31,32 +31,122 @@ func init() {
 	})
 }
 
-func initializeDriftDetector(
+// createAWSProvider creates the AWS provider, fanning out over all configured accounts if any
+func createAWSProvider(
 	ctx context.Context,
 	cfg *config.Config,
 	instanceProviderFactory *factory.InstanceProviderFactory,
-	driftDetectorFactory *factory.DriftDetectorFactory,
-	repository service.DriftRepository,
-	reporters []service.Reporter,
 	c *container.Container,
-) (service.DriftDetectorProvider, error) {
-	awsProvider, err := instanceProviderFactory.CreateAWSProvider(ctx, cfg)
+) (service.InstanceProvider, error) {
+	accounts := cfg.GetAccounts()
+	if len(accounts) == 0 {
+		return instanceProviderFactory.CreateAWSProvider(ctx, cfg)
+	}
+
+	accountProviders := make([]AccountProvider, 0, len(accounts))
+	for _, account := range accounts {
+		provider, err := instanceProviderFactory.CreateAccountAWSProvider(ctx, cfg, account)
+		if err != nil {
+			return nil, err
+		}
+		accountProviders = append(accountProviders, AccountProvider{
+			AccountID: account.AccountID(),
+			Provider:  provider,
+		})
+	}
+
+	logger, _ := container.Resolve[*logging.Logger](c, "logger")
+	return NewMultiAccountInstanceProvider(accountProviders, logger), nil
+}
+
+// InitializeOptions replaces parts of the application that InitializeApplication otherwise
+// builds from the configuration, such as fake providers in tests. Nil fields are created by the
+// container's factories.
+type InitializeOptions struct {
+	// AWSProvider replaces the EC2 (or multi-account) provider, also when CLI flags change the
+	// AWS client configuration
+	AWSProvider service.InstanceProvider
+
+	// TerraformProvider replaces the state, HCL or Terraform Cloud provider
+	TerraformProvider service.TerraformProvider
+
+	// Repository replaces the repository results are saved to
+	Repository service.DriftRepository
+
+	// Reporters replace the reporters of the configured report types
+	Reporters []service.Reporter
+}
+
+// InitializeApplication creates and configures the application based on the configuration
+func InitializeApplication(ctx context.Context, c *container.Container, cfg *config.Config) (*Application, error) {
+	return InitializeApplicationWithOptions(ctx, c, cfg, InitializeOptions{})
+}
+
+// InitializeApplicationWithOptions creates and configures the application like
+// InitializeApplication, using the providers, repository and reporters given in opts
+func InitializeApplicationWithOptions(ctx context.Context, c *container.Container, cfg *config.Config, opts InitializeOptions) (*Application, error) {
+	repository := opts.Repository
+	if repository == nil {
+		repositoryFactory, err := container.Resolve[*factory.RepositoryFactory](c, "repositoryFactory")
+		if err != nil {
+			return nil, err
+		}
+		repository = repositoryFactory.CreateDriftRepository()
+	}
+
+	// Reporters come first so that an unknown report type fails before any provider is created
+	reporters := opts.Reporters
+	if reporters == nil {
+		reporterFactory, err := container.Resolve[*factory.ReporterFactory](c, "reporterFactory")
+		if err != nil {
+			return nil, err
+		}
+		if reporters, err = reporterFactory.CreateReporters(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	var instanceProviderFactory *factory.InstanceProviderFactory
+	if opts.AWSProvider == nil || opts.TerraformProvider == nil {
+		var err error
+		if instanceProviderFactory, err = container.Resolve[*factory.InstanceProviderFactory](c, "instanceProviderFactory"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Let the CLI rebuild the AWS provider when flags change the AWS client configuration
+	awsProviderFactory := container.AWSProviderFactory(func(ctx context.Context, cfg *config.Config) (service.InstanceProvider, error) {
+		return createAWSProvider(ctx, cfg, instanceProviderFactory, c)
+	})
+	if opts.AWSProvider != nil {
+		awsProviderFactory = func(context.Context, *config.Config) (service.InstanceProvider, error) {
+			return opts.AWSProvider, nil
+		}
+	}
+	c.Register("awsProviderFactory", awsProviderFactory)
+
+	awsProvider, err := awsProviderFactory(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	terraformProvider, err := instanceProviderFactory.CreateTerraformProvider(cfg)
+	terraformProvider := opts.TerraformProvider
+	if terraformProvider == nil {
+		if terraformProvider, err = instanceProviderFactory.CreateTerraformProvider(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	driftDetectorFactory, err := container.Resolve[*factory.DriftDetectorFactory](c, "driftDetectorFactory")
 	if err != nil {
 		return nil, err
 	}
-
-	// Get the factory function
 	serviceFactory, err := c.GetDriftDetectorServiceFactory()
 	if err != nil {
 		return nil, err
 	}
 
-	return driftDetectorFactory.CreateDriftDetector(
+	driftDetector, err := driftDetectorFactory.CreateDriftDetector(
 		awsProvider,
 		terraformProvider,
 		repository,
@@ -64,30 +154,6 @@ func initializeDriftDetector(
 		cfg,
 		serviceFactory,
 	)
-}
-
-// InitializeApplication creates and configures the application based on the configuration
-func InitializeApplication(ctx context.Context, c *container.Container, cfg *config.Config) (*Application, error) {
-	instanceProviderFactory, _ := container.Resolve[*factory.InstanceProviderFactory](c, "instanceProviderFactory")
-	driftDetectorFactory, _ := container.Resolve[*factory.DriftDetectorFactory](c, "driftDetectorFactory")
-	reporterFactory, _ := container.Resolve[*factory.ReporterFactory](c, "reporterFactory")
-	repositoryFactory, _ := container.Resolve[*factory.RepositoryFactory](c, "repositoryFactory")
-	repository := repositoryFactory.CreateDriftRepository()
-
-	reporters, err := reporterFactory.CreateReporters(cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	driftDetector, err := initializeDriftDetector(
-		ctx,
-		cfg,
-		instanceProviderFactory,
-		driftDetectorFactory,
-		repository,
-		reporters,
-		c,
-	)
 	if err != nil {
 		return nil, err
 	}
@@ -2,13 +2,21 @@ package app_test
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	apperrors "github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/config"
 	"github.com/victor-devv/ec2-drift-detector/internal/container"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"github.com/victor-devv/ec2-drift-detector/internal/presentation/reporter"
 )
 
 func TestInitializeApplication_ReturnsApp(t *testing.T) {
@@ -45,3 +53,138 @@ func TestInitializeApplication_InvalidConfig(t *testing.T) {
 	_, err := app.InitializeApplication(ctx, c, cfg)
 	assert.Error(t, err)
 }
+
+func TestInitializeApplication_UnknownReporterType(t *testing.T) {
+	c := container.NewContainer()
+
+	cfg := &config.Config{}
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetReporterType("xml")
+
+	// Reporters are created before any provider, so this fails without reaching AWS
+	_, err := app.InitializeApplication(context.Background(), c, cfg)
+	assert.Error(t, err)
+	assert.True(t, apperrors.IsValidationError(err))
+}
+
+// smokeConfig returns a configuration that would reach AWS and a state file unless the
+// providers are replaced
+func smokeConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.SetSourceOfTruth("terraform")
+	cfg.SetAttributes([]string{"instance_type", "tags"})
+	cfg.SetParallelChecks(2)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetReporterType("console")
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("./testdata/missing.tfstate")
+	return cfg
+}
+
+// smokeProviders returns AWS and Terraform providers holding a drifted and an unchanged instance
+func smokeProviders() (*mockInstanceProvider, *mockInstanceProvider) {
+	aws := &mockInstanceProvider{instances: []*model.Instance{
+		model.NewInstance("i-web", map[string]interface{}{"instance_type": "t3.large", "tags": map[string]string{"Name": "web"}}, model.OriginAWS),
+		model.NewInstance("i-api", map[string]interface{}{"instance_type": "t3.micro", "tags": map[string]string{"Name": "api"}}, model.OriginAWS),
+	}}
+	terraform := &mockInstanceProvider{instances: []*model.Instance{
+		model.NewInstance("i-web", map[string]interface{}{"instance_type": "t3.micro", "tags": map[string]string{"Name": "web"}}, model.OriginTerraform),
+		model.NewInstance("i-api", map[string]interface{}{"instance_type": "t3.micro", "tags": map[string]string{"Name": "api"}}, model.OriginTerraform),
+	}}
+	return aws, terraform
+}
+
+func TestInitializeApplicationWithOptions_DetectsWithInjectedDependencies(t *testing.T) {
+	aws, terraform := smokeProviders()
+	repo := &mockRepository{}
+	rep := &mockReporter{}
+	cfg := smokeConfig()
+
+	c := container.NewContainer()
+	application, err := app.InitializeApplicationWithOptions(context.Background(), c, cfg, app.InitializeOptions{
+		AWSProvider:       aws,
+		TerraformProvider: terraform,
+		Repository:        repo,
+		Reporters:         []service.Reporter{rep},
+	})
+	require.NoError(t, err)
+
+	summary, err := application.DriftDetector.RunDriftCheck(context.Background(), cfg.GetAttributes())
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.TotalInstances)
+	assert.Equal(t, 1, summary.DriftedCount)
+
+	require.Len(t, rep.reported, 2)
+	drifted := map[string]*model.DriftResult{}
+	for _, result := range rep.reported {
+		if result.HasDrift {
+			drifted[result.ResourceID] = result
+		}
+	}
+	require.Contains(t, drifted, "i-web")
+	desired, current := drifted["i-web"].DriftedAttributes["instance_type"].DesiredAndCurrent()
+	assert.Equal(t, "t3.micro", desired)
+	assert.Equal(t, "t3.large", current)
+	assert.Len(t, repo.saved, 2)
+
+	// CLI flags that change the AWS client configuration keep the injected provider
+	factory, err := container.Resolve[container.AWSProviderFactory](c, "awsProviderFactory")
+	require.NoError(t, err)
+	provider, err := factory(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Same(t, aws, provider)
+}
+
+func TestInitializeApplicationWithOptions_WritesConfiguredReport(t *testing.T) {
+	aws, terraform := smokeProviders()
+	cfg := smokeConfig()
+	dir := t.TempDir()
+	output := filepath.Join(dir, "report.json")
+	cfg.SetReporterType("json")
+	cfg.SetOutputFile(output)
+
+	// Reporters and the repository still come from the container's factories
+	application, err := app.InitializeApplicationWithOptions(context.Background(), container.NewContainer(), cfg, app.InitializeOptions{
+		AWSProvider:       aws,
+		TerraformProvider: terraform,
+	})
+	require.NoError(t, err)
+
+	_, err = application.DriftDetector.RunDriftCheck(context.Background(), cfg.GetAttributes())
+	require.NoError(t, err)
+
+	// The JSON reporter stamps the file name with the time of the run
+	reports, err := filepath.Glob(filepath.Join(dir, "report_*.json"))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	data, err := os.ReadFile(reports[0])
+	require.NoError(t, err)
+	var report reporter.JSONReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, 2, report.TotalInstances)
+	assert.Equal(t, 1, report.DriftedCount)
+	for _, result := range report.Results {
+		if result.ResourceID == "i-web" {
+			assert.True(t, result.HasDrift)
+			assert.Contains(t, result.DriftedAttributes, "instance_type")
+			assert.NotContains(t, result.DriftedAttributes, "tags")
+		} else {
+			assert.False(t, result.HasDrift, result.ResourceID)
+		}
+	}
+}
+
+func TestInitializeApplicationWithOptions_MissingFactory(t *testing.T) {
+	aws, terraform := smokeProviders()
+
+	// A container without the driftDetectorFactory fails instead of panicking on a nil factory
+	c := container.NewContainer()
+	c.Register("driftDetectorFactory", nil)
+	_, err := app.InitializeApplicationWithOptions(context.Background(), c, smokeConfig(), app.InitializeOptions{
+		AWSProvider:       aws,
+		TerraformProvider: terraform,
+		Repository:        &mockRepository{},
+		Reporters:         []service.Reporter{&mockReporter{}},
+	})
+	assert.ErrorContains(t, err, "driftDetectorFactory")
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// DigestReporter wraps a notification reporter and batches results into periodic digests.
+// Pending results are buffered in a digest store, so they survive a restart when the store
+// is durable.
+type DigestReporter struct {
+	next    service.NotificationReporter
+	store   service.DigestStore
+	options service.DigestOptions
+	clock   clock.Clock
+	logger  *logging.Logger
+}
+
+// Ensure DigestReporter implements the service.Reporter interface
+var _ service.Reporter = (*DigestReporter)(nil)
+
+// NewDigestReporter creates a digest decorator around a notification reporter
+func NewDigestReporter(next service.NotificationReporter, store service.DigestStore, options service.DigestOptions, clk clock.Clock, logger *logging.Logger) *DigestReporter {
+	return &DigestReporter{
+		next:    next,
+		store:   store,
+		options: options,
+		clock:   clock.OrReal(clk),
+		logger:  logger.WithField("component", "digest-reporter").WithField("channel", next.NotificationChannel()),
+	}
+}
+
+// ReportDrift buffers a single drift detection result
+func (r *DigestReporter) ReportDrift(result *model.DriftResult) error {
+	return r.ReportMultipleDrifts([]*model.DriftResult{result})
+}
+
+// ReportMultipleDrifts buffers drifted results and sends the digest once the interval has
+// elapsed or the report has drift as severe as the immediate severity
+func (r *DigestReporter) ReportMultipleDrifts(results []*model.DriftResult) error {
+	ctx := context.Background()
+
+	var drifted []*model.DriftResult
+	severity := ""
+	for _, result := range results {
+		if !result.HasDrift {
+			continue
+		}
+		drifted = append(drifted, result)
+		if highest := result.HighestSeverity(); !model.SeverityAtLeast(severity, highest) {
+			severity = highest
+		}
+	}
+
+	if len(drifted) > 0 {
+		if err := r.store.AppendDigest(ctx, r.key(), drifted, r.clock.Now()); err != nil {
+			return errors.NewOperationalError("Failed to buffer results for digest", err)
+		}
+		r.logger.Debug(fmt.Sprintf("Buffered %d drifted results for digest", len(drifted)))
+	}
+
+	if r.options.ImmediateSeverity != "" && model.SeverityAtLeast(severity, r.options.ImmediateSeverity) {
+		r.logger.Info(fmt.Sprintf("Sending digest right away for %s severity drift (immediate severity %s)", severity, r.options.ImmediateSeverity))
+		return r.Flush(ctx)
+	}
+
+	_, since, err := r.store.PendingDigest(ctx, r.key())
+	if err != nil {
+		return errors.NewOperationalError("Failed to read pending digest", err)
+	}
+	if !since.IsZero() && r.clock.Now().Sub(since) >= r.options.Interval {
+		return r.Flush(ctx)
+	}
+
+	return nil
+}
+
+// Flush sends the pending digest to the wrapped reporter. The buffer is only cleared once
+// the digest has been delivered.
+func (r *DigestReporter) Flush(ctx context.Context) error {
+	pending, _, err := r.store.PendingDigest(ctx, r.key())
+	if err != nil {
+		return errors.NewOperationalError("Failed to read pending digest", err)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	r.logger.Info(fmt.Sprintf("Sending digest with %d drifted results", len(pending)))
+	if err := r.next.ReportMultipleDrifts(pending); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to send digest to %s", r.next.NotificationChannel()), err)
+	}
+
+	if err := r.store.ClearDigest(ctx, r.key()); err != nil {
+		return errors.NewOperationalError("Failed to clear sent digest", err)
+	}
+	return nil
+}
+
+// key returns the digest key for the wrapped reporter
+func (r *DigestReporter) key() string {
+	return r.next.NotificationChannel()
+}
+
+// wrapDigestReporters wraps notification reporters in digests buffered in options.Store, or
+// in the repository without one. Console and file reporters, and all reporters when digests
+// are disabled or nothing can buffer them, pass through.
+func wrapDigestReporters(reporters []service.Reporter, repository service.DriftRepository, options service.DigestOptions, clk clock.Clock, logger *logging.Logger) []service.Reporter {
+	if options.Interval <= 0 && options.ImmediateSeverity == "" {
+		return reporters
+	}
+
+	store := options.Store
+	if store == nil {
+		repoStore, ok := repository.(service.DigestStore)
+		if !ok {
+			logger.Warn("Repository does not support digest buffering; notifications are sent immediately")
+			return reporters
+		}
+		logger.Warn("No digest file configured; pending digests are lost on restart")
+		store = repoStore
+	}
+
+	wrapped := make([]service.Reporter, 0, len(reporters))
+	for _, reporter := range reporters {
+		if notifier, ok := reporter.(service.NotificationReporter); ok {
+			reporter = NewDigestReporter(notifier, store, options, clk, logger)
+		}
+		wrapped = append(wrapped, reporter)
+	}
+	return wrapped
+}
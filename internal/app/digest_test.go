@@ -0,0 +1,133 @@
+package app_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/repository"
+)
+
+// notificationReporter records every batch it is asked to send
+type notificationReporter struct {
+	batches [][]*model.DriftResult
+}
+
+func (m *notificationReporter) ReportDrift(result *model.DriftResult) error {
+	return m.ReportMultipleDrifts([]*model.DriftResult{result})
+}
+
+func (m *notificationReporter) ReportMultipleDrifts(results []*model.DriftResult) error {
+	m.batches = append(m.batches, results)
+	return nil
+}
+
+func (m *notificationReporter) NotificationChannel() string {
+	return "test-channel"
+}
+
+func driftedResults(ids ...string) []*model.DriftResult {
+	results := make([]*model.DriftResult, 0, len(ids))
+	for _, id := range ids {
+		r := model.NewDriftResult(id, model.OriginTerraform)
+		r.AddDriftedAttribute("instance_type", "t2.micro", "t2.large")
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestDigestReporter_BuffersUntilInterval(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 4, 22, 0, 0, 0, 0, time.UTC))
+	path := filepath.Join(t.TempDir(), "digests.json")
+	next := &notificationReporter{}
+	options := service.DigestOptions{Interval: 6 * time.Hour}
+
+	digest := app.NewDigestReporter(next, repository.NewFileDigestStore(path, logging.New()), options, fake, logging.New())
+
+	require.NoError(t, digest.ReportMultipleDrifts(driftedResults("i-1")))
+	fake.Advance(time.Hour)
+	require.NoError(t, digest.ReportMultipleDrifts(driftedResults("i-2")))
+	assert.Empty(t, next.batches)
+
+	// A restarted process picks up the pending digest from the file
+	store := repository.NewFileDigestStore(path, logging.New())
+	digest = app.NewDigestReporter(next, store, options, fake, logging.New())
+	fake.Advance(5 * time.Hour)
+	require.NoError(t, digest.ReportMultipleDrifts(driftedResults("i-3")))
+	require.Len(t, next.batches, 1)
+	assert.Len(t, next.batches[0], 3)
+
+	// The buffer is cleared once the digest is sent
+	pending, _, err := store.PendingDigest(context.Background(), "test-channel")
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestDigestReporter_ImmediateSeverity(t *testing.T) {
+	repo := repository.NewInMemoryDriftRepository(logging.New())
+	next := &notificationReporter{}
+	digest := app.NewDigestReporter(next, repo, service.DigestOptions{Interval: 6 * time.Hour, ImmediateSeverity: model.SeverityHigh}, nil, logging.New())
+
+	// Medium severity drift waits for the interval, however many instances drifted
+	require.NoError(t, digest.ReportMultipleDrifts(driftedResults("i-1", "i-2", "i-3")))
+	assert.Empty(t, next.batches)
+
+	// High severity drift sends the digest with everything buffered so far
+	severe := model.NewDriftResult("i-4", model.OriginTerraform)
+	severe.AddDriftedAttribute("vpc_security_group_ids", []string{"sg-1"}, []string{"sg-1", "sg-open"})
+	require.NoError(t, digest.ReportMultipleDrifts(append(driftedResults("i-5"), severe)))
+	require.Len(t, next.batches, 1)
+	assert.Len(t, next.batches[0], 5)
+}
+
+func TestDriftDetectorService_FlushDigests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "digests.json")
+	awsInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.large"}, model.OriginAWS)
+	tfInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+
+	newDetector := func(reporters ...service.Reporter) service.DriftDetectorProvider {
+		return app.NewDriftDetectorService(
+			&mockInstanceProvider{instances: []*model.Instance{awsInst}},
+			&mockInstanceProvider{instances: []*model.Instance{tfInst}},
+			repository.NewInMemoryDriftRepository(logging.New()),
+			reporters,
+			service.DriftDetectorConfig{
+				SourceOfTruth:  model.OriginTerraform,
+				AttributePaths: []string{"instance_type"},
+				Timeout:        2 * time.Second,
+				ParallelChecks: 1,
+				DigestOptions: service.DigestOptions{
+					Interval: 6 * time.Hour,
+					Store:    repository.NewFileDigestStore(path, logging.New()),
+				},
+			},
+			logging.New(),
+		)
+	}
+
+	// Console reporters bypass the digest, notification reporters are buffered
+	console := &mockReporter{}
+	detector := newDetector(console, &notificationReporter{})
+	require.NoError(t, detector.DetectAndReportDriftForAll(context.Background(), nil))
+	assert.Len(t, console.reported, 1)
+
+	// Another process, as with detect --flush-digests, sends what the first one buffered
+	next := &notificationReporter{}
+	require.NoError(t, newDetector(next).FlushDigests(context.Background()))
+	require.Len(t, next.batches, 1)
+	require.Len(t, next.batches[0], 1)
+	assert.Equal(t, "i-1", next.batches[0][0].ResourceID)
+	assert.Contains(t, next.batches[0][0].DriftedAttributes, "instance_type")
+
+	// Nothing is left to send
+	require.NoError(t, newDetector(next).FlushDigests(context.Background()))
+	assert.Len(t, next.batches, 1)
+}
@@ -3,31 +3,80 @@ package app
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/schedule"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
 	"github.com/victor-devv/ec2-drift-detector/pkg/comparator"
+	"golang.org/x/sync/errgroup"
 )
 
+// errErrorBudgetExceeded cancels a run once too many instance checks have failed
+var errErrorBudgetExceeded = fmt.Errorf("error budget exceeded")
+
 // DriftDetectorService implements the drift detection service
 type DriftDetectorService struct {
 	awsProvider        service.InstanceProvider
-	terraformProvider  service.InstanceProvider
+	terraformProvider  service.TerraformProvider
 	repository         service.DriftRepository
 	reporters          []service.Reporter
+	baseReporters      []service.Reporter
 	logger             *logging.Logger
 	comparator         *comparator.Comparator
+	clock              clock.Clock
 	sourceOfTruth      model.ResourceOrigin
 	attributePaths     []string
 	parallelChecks     int
+	parallelProviders  bool
 	timeout            time.Duration
+	awsTimeout         time.Duration
+	terraformTimeout   time.Duration
 	scheduleExpression string
+	abortAfterErrors   int
+	errorOnEmpty       bool
+	minInstances       int
+	staticIPsOnly      bool
+	sourceDeclaredOnly bool
+	checkOrphans       bool
+	compareOptions     model.CompareOptions
+	comparators        map[string]comparator.EqualFunc
+	comparatorsMu      sync.RWMutex
+	digestOptions      service.DigestOptions
+	policies           []model.Policy
+	allowedTypes       []string
+	environmentTag     string
+	strictAccountCheck bool
+	suggestRemediation bool
+	networkContext     bool
+	terminationCheck   bool
+	memoize            bool
+	memo               comparisonMemo
+	hashPrefilter      bool
+	driftAgeLookback   int
+	includeSnapshots   bool
+	allowMismatchedIDs bool
+	attributeDumper    service.AttributeDumper
+	volatileAttributes []string
+	resourceFilter     []string
 	scheduler          *cron.Cron
+	schedule           cron.Schedule
+	scheduleEntry      cron.EntryID
+
+	// Scheduler state, guarded by statusMu since scheduled runs happen in the background
+	statusMu         sync.RWMutex
+	schedulerRunning bool
+	lastRun          *model.RunSummary
 }
 
 // Ensure DriftDetectorService implements the service.DriftDetectorProvider interface
@@ -36,7 +85,7 @@ var _ service.DriftDetectorProvider = (*DriftDetectorService)(nil)
 // NewDriftDetectorService creates a new drift detector service
 func NewDriftDetectorService(
 	awsProvider service.InstanceProvider,
-	terraformProvider service.InstanceProvider,
+	terraformProvider service.TerraformProvider,
 	repository service.DriftRepository,
 	reporters []service.Reporter,
 	config service.DriftDetectorConfig,
@@ -44,20 +93,49 @@ func NewDriftDetectorService(
 ) *DriftDetectorService {
 	logger = logger.WithField("component", "drift-detector")
 
-	return &DriftDetectorService{
+	s := &DriftDetectorService{
 		awsProvider:        awsProvider,
 		terraformProvider:  terraformProvider,
 		repository:         repository,
-		reporters:          reporters,
 		logger:             logger,
 		comparator:         comparator.NewComparator(),
+		clock:              clock.OrReal(config.Clock),
 		sourceOfTruth:      config.SourceOfTruth,
 		attributePaths:     config.AttributePaths,
 		parallelChecks:     config.ParallelChecks,
+		parallelProviders:  config.ParallelProviders,
 		timeout:            config.Timeout,
+		awsTimeout:         config.AWSTimeout,
+		terraformTimeout:   config.TerraformTimeout,
 		scheduleExpression: config.ScheduleExpression,
+		abortAfterErrors:   config.AbortAfterErrors,
+		errorOnEmpty:       config.ErrorOnEmpty,
+		minInstances:       config.MinInstances,
+		staticIPsOnly:      config.StaticIPsOnly,
+		sourceDeclaredOnly: config.SourceDeclaredOnly,
+		checkOrphans:       config.CheckOrphans,
+		compareOptions:     config.CompareOptions,
+		digestOptions:      config.DigestOptions,
+		policies:           config.Policies,
+		allowedTypes:       config.AllowedInstanceTypes,
+		environmentTag:     config.EnvironmentTag,
+		strictAccountCheck: config.StrictAccountCheck,
+		suggestRemediation: config.SuggestRemediation,
+		networkContext:     config.EnrichNetworkContext,
+		terminationCheck:   config.CompareTerminationProtection,
+		memoize:            config.Memoize,
+		hashPrefilter:      config.HashPrefilter,
+		driftAgeLookback:   config.DriftAgeLookback,
+		includeSnapshots:   config.IncludeSnapshots,
+		allowMismatchedIDs: config.AllowMismatchedIDs,
+		attributeDumper:    config.AttributeDumper,
+		volatileAttributes: config.VolatileAttributes,
+		resourceFilter:     config.ResourceFilter,
 		scheduler:          cron.New(),
 	}
+	s.SetReporters(reporters)
+
+	return s
 }
 
 // DetectAndReportDrift detects and reports drift for a single instance
@@ -82,6 +160,12 @@ func (s *DriftDetectorService) DetectAndReportDrift(ctx context.Context, instanc
 
 // DetectAndReportDriftForAll detects and reports drift for all instances
 func (s *DriftDetectorService) DetectAndReportDriftForAll(ctx context.Context, attributePaths []string) error {
+	_, err := s.detectAndReportDriftForAll(ctx, attributePaths)
+	return err
+}
+
+// detectAndReportDriftForAll detects and reports drift for all instances, returning the results
+func (s *DriftDetectorService) detectAndReportDriftForAll(ctx context.Context, attributePaths []string) ([]*model.DriftResult, error) {
 	s.logger.Info("Detecting and reporting drift for all instances")
 
 	// Use specified attributes or default to configured ones
@@ -93,35 +177,220 @@ func (s *DriftDetectorService) DetectAndReportDriftForAll(ctx context.Context, a
 	// Detect drift
 	results, err := s.DetectDriftForAll(ctx, attrs)
 	if err != nil {
-		return err
+		// Still report what was gathered before the run was aborted
+		if isRunAborted(err) && len(results) > 0 {
+			if reportErr := s.reportMultipleDrifts(results); reportErr != nil {
+				s.logger.Error(fmt.Sprintf("Failed to report partial results: %v", reportErr))
+			}
+		}
+		return results, err
 	}
 
 	// Report drift
-	return s.reportMultipleDrifts(results)
+	if err := s.reportMultipleDrifts(results); err != nil {
+		return results, err
+	}
+
+	if s.checkOrphans {
+		orphans, err := s.DetectOrphans(ctx)
+		if err != nil {
+			return results, err
+		}
+		if err := s.reportOrphans(orphans); err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
 }
 
 // DetectDrift detects drift between two instances for specified attributes
 func (s *DriftDetectorService) DetectDrift(ctx context.Context, source, target *model.Instance, attributePaths []string) (*model.DriftResult, error) {
 	s.logger.Info(fmt.Sprintf("Detecting drift for instance %s", source.ID))
+	if err := s.checkPairing(source, target); err != nil {
+		return nil, err
+	}
+	ctx, runID := ensureRunID(ctx)
 
 	// Create a drift result
-	result := model.NewDriftResult(source.ID, source.Origin)
+	result := model.NewDriftResultAt(source.ID, source.Origin, s.clock.Now())
+	result.AccountID = accountID(source, target)
+	result.Workspace = workspaceName(source, target)
+	result.ResourceAddress = resourceAddress(source, target)
+	result.DuplicateAddresses = duplicateAddresses(source, target)
+	result.SetNames(source.NameTag(), target.NameTag())
+
+	// An explained comparison must run, so it never reuses a memoized result
+	trace := service.CompareTraceFrom(ctx)
+
+	// An instance that hasn't changed on either side since the previous run reuses its result
+	var memo memoRequest
+	if s.memoize && trace == nil {
+		memo = s.newMemoRequest(result, source, target, attributePaths)
+		if reused, ok := s.reuseResult(memo, source, target); ok {
+			s.attachDriftAges(ctx, reused)
+			if err := s.saveResult(ctx, reused); err != nil {
+				return nil, errors.NewOperationalError(fmt.Sprintf("Failed to save drift result for instance %s", source.ID), err)
+			}
+			return reused, nil
+		}
+	}
+
+	// Attributes the source doesn't declare (e.g. AWS defaults) are not drift
+	if s.sourceDeclaredOnly {
+		declared := model.DeclaredAttributes(source, attributePaths)
+		if trace != nil {
+			for _, path := range attributePaths {
+				if !slices.Contains(declared, path) {
+					trace.Skip(path, "not declared by the source (detector.source_declared_only)")
+				}
+			}
+		}
+		attributePaths = declared
+	}
+
+	// Record attributes that could not be compared
+	skipped := model.UnknownAttributes(source, target, attributePaths)
+
+	// Dynamically assigned addresses change on every stop/start and are not compared
+	if s.staticIPsOnly {
+		dynamic := model.DynamicAddressAttributes(source, target, attributePaths)
+		if len(dynamic) > 0 {
+			paths := make([]string, 0, len(attributePaths))
+			for _, path := range attributePaths {
+				if _, ok := dynamic[path]; !ok {
+					paths = append(paths, path)
+				}
+			}
+			attributePaths = paths
+
+			for path, reason := range dynamic {
+				skipped[path] = reason
+				if trace != nil {
+					trace.Skip(path, reason)
+				}
+			}
+		}
+	}
+
+	// Dump what the comparator is about to see
+	s.dumpAttributes(source, target)
 
 	// Compare attributes
-	drifts := model.CompareAttributes(source, target, attributePaths)
+	opts := s.attributeCompareOptions()
+	opts.Trace = trace
+	var drifts map[string]model.AttributeDrift
+	if s.hashPrefilter && trace == nil && model.SameComparedValues(source, target, attributePaths) {
+		// Identical values can't drift, so the attribute by attribute comparison is skipped
+		s.logger.Debug(fmt.Sprintf("Compared attributes of instance %s hash the same on both sides, skipping comparison", source.ID))
+		drifts = make(map[string]model.AttributeDrift)
+	} else {
+		drifts = model.CompareAttributesWithOptions(source, target, attributePaths, opts)
+	}
+	s.compareTerminationProtection(source, target, opts, drifts, skipped)
+	s.compareRegions(source, target, opts, drifts)
 	if len(drifts) > 0 {
 		result.SetDriftedAttributes(drifts)
 		s.logger.Info(fmt.Sprintf("Detected %d drifted attributes for instance %s", len(drifts), source.ID))
+		s.logDrifts(runID, source.ID, drifts)
+	}
+
+	if len(skipped) > 0 {
+		result.SetSkippedAttributes(skipped)
+		s.logger.Info(fmt.Sprintf("Skipped %d attributes for instance %s", len(skipped), source.ID))
+	}
+
+	s.evaluatePolicies(result, source, target)
+	s.attachNetworkContext(ctx, result)
+	s.attachRemediation(result, source, target)
+	s.attachSnapshots(result, source, target)
+	s.attachDriftAges(ctx, result)
+
+	if s.memoize && trace == nil {
+		s.memoizeResult(memo, result)
 	}
 
 	// Store the result
-	if err := s.repository.SaveDriftResult(ctx, result); err != nil {
+	if err := s.saveResult(ctx, result); err != nil {
 		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to save drift result for instance %s", source.ID), err)
 	}
 
 	return result, nil
 }
 
+// saveResult stores a result without its volatile attributes and instance snapshots. The caller
+// keeps the full result for reporting.
+func (s *DriftDetectorService) saveResult(ctx context.Context, result *model.DriftResult) error {
+	return s.repository.SaveDriftResult(ctx, result.WithoutAttributes(s.volatileAttributes).WithoutSnapshots())
+}
+
+// attachSnapshots adds the full attributes of the compared instances to the result when
+// snapshots are included in reports
+func (s *DriftDetectorService) attachSnapshots(result *model.DriftResult, source, target *model.Instance) {
+	if s.includeSnapshots {
+		result.SetSnapshots(source, target)
+	}
+}
+
+// dumpAttributes writes the attributes of the compared instances when a debug dump is configured.
+// Dump failures are logged and never fail the check.
+func (s *DriftDetectorService) dumpAttributes(source, target *model.Instance) {
+	if s.attributeDumper == nil {
+		return
+	}
+
+	awsInstance, terraformInstance := source, target
+	if source.Origin != model.OriginAWS {
+		awsInstance, terraformInstance = target, source
+	}
+
+	if err := s.attributeDumper.DumpAttributes(source.ID, awsInstance, terraformInstance); err != nil {
+		s.logger.Warn(fmt.Sprintf("Failed to dump attributes for instance %s: %v", source.ID, err))
+	}
+}
+
+// DetectOrphans finds AWS volumes, network interfaces and Elastic IPs that are neither managed
+// by Terraform nor attached to an instance Terraform manages
+func (s *DriftDetectorService) DetectOrphans(ctx context.Context) ([]*model.OrphanResult, error) {
+	s.logger.Info("Detecting orphaned resources")
+
+	resourceProvider, ok := s.awsProvider.(service.ResourceProvider)
+	if !ok {
+		return nil, errors.NewValidationError("AWS provider does not support listing resources for orphan detection")
+	}
+	managedProvider, ok := s.terraformProvider.(service.ManagedResourceProvider)
+	if !ok {
+		return nil, errors.NewValidationError("Terraform provider does not support listing managed resources for orphan detection")
+	}
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	awsCtx, awsCancel := providerContext(ctx, s.awsTimeout)
+	defer awsCancel()
+	resources, err := resourceProvider.ListResources(awsCtx)
+	if err != nil {
+		return nil, errors.NewOperationalError("Failed to list AWS resources", err)
+	}
+
+	terraformCtx, terraformCancel := providerContext(ctx, s.terraformTimeout)
+	defer terraformCancel()
+	instances, err := s.terraformProvider.ListInstances(terraformCtx)
+	if err != nil {
+		return nil, errors.NewOperationalError("Failed to list Terraform instances", err)
+	}
+	managed, err := managedProvider.ListManagedResourceIDs(terraformCtx)
+	if err != nil {
+		return nil, errors.NewOperationalError("Failed to list Terraform managed resources", err)
+	}
+
+	orphans := model.FindOrphans(resources, instances, managed, s.clock.Now())
+	s.logger.Info(fmt.Sprintf("Found %d orphaned resources out of %d", len(orphans), len(resources)))
+
+	return orphans, nil
+}
+
 // DetectDriftByID detects drift for an instance by ID
 func (s *DriftDetectorService) DetectDriftByID(ctx context.Context, instanceID string, attributePaths []string) (*model.DriftResult, error) {
 	s.logger.Info(fmt.Sprintf("Detecting drift for instance %s", instanceID))
@@ -140,7 +409,9 @@ func (s *DriftDetectorService) DetectDriftByID(ctx context.Context, instanceID s
 
 	go func() {
 		defer wg.Done()
-		awsInstance, awsErr = s.awsProvider.GetInstance(ctx, instanceID)
+		awsCtx, cancel := providerContext(ctx, s.awsTimeout)
+		defer cancel()
+		awsInstance, awsErr = s.awsProvider.GetInstance(awsCtx, instanceID)
 		if awsErr != nil {
 			s.logger.Error(fmt.Sprintf("Failed to get AWS instance %s: %v", instanceID, awsErr))
 		}
@@ -148,7 +419,9 @@ func (s *DriftDetectorService) DetectDriftByID(ctx context.Context, instanceID s
 
 	go func() {
 		defer wg.Done()
-		terraformInstance, terraformErr = s.terraformProvider.GetInstance(ctx, instanceID)
+		terraformCtx, cancel := providerContext(ctx, s.terraformTimeout)
+		defer cancel()
+		terraformInstance, terraformErr = s.terraformProvider.GetInstance(terraformCtx, instanceID)
 		if terraformErr != nil {
 			s.logger.Error(fmt.Sprintf("Failed to get Terraform instance %s: %v", instanceID, terraformErr))
 		}
@@ -187,11 +460,103 @@ func (s *DriftDetectorService) DetectDriftByID(ctx context.Context, instanceID s
 func (s *DriftDetectorService) DetectDriftForAll(ctx context.Context, attributePaths []string) ([]*model.DriftResult, error) {
 	s.logger.Info("Detecting drift for all instances")
 
+	// Tag every result of this run with the same run ID, and time how long each provider takes
+	ctx, _ = ensureRunID(ctx)
+	ctx, _ = ensureFetchTimer(ctx)
+
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	// Get all instances from both providers
+	// Look up which account and region AWS is read from before comparing anything
+	guard := s.newAccountGuard(ctx)
+
+	// A resource filter needs the Terraform addresses before fetching from AWS, so it doesn't stream
+	if s.parallelProviders && len(s.resourceFilter) == 0 {
+		results, err := s.checkPairs(ctx, attributePaths, 0, func(ctx context.Context, send func(instancePair) bool) error {
+			return s.streamPairs(ctx, guard, send)
+		})
+		return results, err
+	}
+
+	// Get all instances from both providers, or those of the filtered Terraform resources
+	list := s.listAllInstances
+	if len(s.resourceFilter) > 0 {
+		list = s.listResourceInstances
+	}
+	awsInstances, terraformInstances, err := list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty inventory on both sides usually points at a misconfigured state source, region or account
+	if len(awsInstances) == 0 && len(terraformInstances) == 0 {
+		if s.errorOnEmpty {
+			return nil, errors.NewOperationalError("No instances found in AWS or Terraform", nil).
+				WithContext("reason", "no_instances")
+		}
+		s.logger.Warn("No instances found in AWS or Terraform; check the state file, HCL directory, region and account configuration")
+		return []*model.DriftResult{}, nil
+	}
+
+	if err := guard.check(terraformInstances...); err != nil {
+		return nil, err
+	}
+
+	// Map instances by ID for easier lookup; across workspaces, by workspace and ID
+	buckets := s.newWorkspaceBuckets()
+	awsInstanceMap := make(map[string]*model.Instance)
+	terraformInstanceMap := make(map[string]*model.Instance)
+
+	// Get the union of all instance IDs
+	instanceIDs := make(map[string]string)
+	for _, instance := range awsInstances {
+		if key, ok := buckets.key(model.OriginAWS, instance); ok {
+			awsInstanceMap[key] = instance
+			instanceIDs[key] = instance.ID
+		}
+	}
+	for _, instance := range terraformInstances {
+		if key, ok := buckets.key(model.OriginTerraform, instance); ok {
+			terraformInstanceMap[key] = instance
+			instanceIDs[key] = instance.ID
+		}
+	}
+	buckets.report(s.logger)
+
+	results, err := s.checkPairs(ctx, attributePaths, len(instanceIDs), func(ctx context.Context, send func(instancePair) bool) error {
+		for key, id := range instanceIDs {
+			if !send(instancePair{id: id, aws: awsInstanceMap[key], terraform: terraformInstanceMap[key]}) {
+				break
+			}
+		}
+		return nil
+	})
+	return results, err
+}
+
+// interruptedRun fails a run that stopped before every instance was checked, because the caller
+// cancelled it or its time budget ran out. The partial results are still returned alongside.
+func (s *DriftDetectorService) interruptedRun(ctx context.Context, results []*model.DriftResult, total int, incomplete bool) error {
+	var reason, message string
+	switch {
+	case ctx.Err() == context.Canceled:
+		reason, message = "cancelled", "Drift detection cancelled"
+	case ctx.Err() == context.DeadlineExceeded && incomplete:
+		reason, message = "timeout", "Drift detection timed out"
+	default:
+		return nil
+	}
+
+	s.logger.Warn(fmt.Sprintf("%s after %d of %d instances were checked", message, len(results), total))
+	return errors.NewOperationalError(fmt.Sprintf("%s; %d of %d instances checked", message, len(results), total), ctx.Err()).
+		WithContext("reason", reason).
+		WithContext("completed", len(results)).
+		WithContext("total", total)
+}
+
+// listAllInstances lists the instances of both providers concurrently
+func (s *DriftDetectorService) listAllInstances(ctx context.Context) ([]*model.Instance, []*model.Instance, error) {
 	var awsInstances, terraformInstances []*model.Instance
 	var awsErr, terraformErr error
 
@@ -201,7 +566,11 @@ func (s *DriftDetectorService) DetectDriftForAll(ctx context.Context, attributeP
 
 	go func() {
 		defer wg.Done()
-		awsInstances, awsErr = s.awsProvider.ListInstances(ctx)
+		awsCtx, cancel := providerContext(ctx, s.awsTimeout)
+		defer cancel()
+		start := s.clock.Now()
+		awsInstances, awsErr = s.awsProvider.ListInstances(awsCtx)
+		s.recordFetch(ctx, model.OriginAWS, start)
 		if awsErr != nil {
 			s.logger.Error(fmt.Sprintf("Failed to list AWS instances: %v", awsErr))
 		}
@@ -209,7 +578,11 @@ func (s *DriftDetectorService) DetectDriftForAll(ctx context.Context, attributeP
 
 	go func() {
 		defer wg.Done()
-		terraformInstances, terraformErr = s.terraformProvider.ListInstances(ctx)
+		terraformCtx, cancel := providerContext(ctx, s.terraformTimeout)
+		defer cancel()
+		start := s.clock.Now()
+		terraformInstances, terraformErr = s.terraformProvider.ListInstances(terraformCtx)
+		s.recordFetch(ctx, model.OriginTerraform, start)
 		if terraformErr != nil {
 			s.logger.Error(fmt.Sprintf("Failed to list Terraform instances: %v", terraformErr))
 		}
@@ -219,114 +592,123 @@ func (s *DriftDetectorService) DetectDriftForAll(ctx context.Context, attributeP
 
 	// Check for errors
 	if awsErr != nil && terraformErr != nil {
-		return nil, errors.NewOperationalError("Failed to list instances from both providers", nil)
+		return nil, nil, errors.NewOperationalError("Failed to list instances from both providers", nil)
 	}
 
 	if awsErr != nil {
-		return nil, errors.NewOperationalError("Failed to list AWS instances", awsErr)
+		return nil, nil, errors.NewOperationalError("Failed to list AWS instances", awsErr)
 	}
 
 	if terraformErr != nil {
-		return nil, errors.NewOperationalError("Failed to list Terraform instances", terraformErr)
+		return nil, nil, errors.NewOperationalError("Failed to list Terraform instances", terraformErr)
 	}
 
-	// Map instances by ID for easier lookup
-	awsInstanceMap := make(map[string]*model.Instance)
-	terraformInstanceMap := make(map[string]*model.Instance)
-
-	for _, instance := range awsInstances {
-		awsInstanceMap[instance.ID] = instance
+	if err := s.checkInstanceCount(model.OriginAWS, len(awsInstances)); err != nil {
+		return nil, nil, err
 	}
-
-	for _, instance := range terraformInstances {
-		terraformInstanceMap[instance.ID] = instance
+	if err := s.checkInstanceCount(model.OriginTerraform, len(terraformInstances)); err != nil {
+		return nil, nil, err
 	}
 
-	// Get the union of all instance IDs
-	instanceIDs := make(map[string]bool)
-	for id := range awsInstanceMap {
-		instanceIDs[id] = true
-	}
-	for id := range terraformInstanceMap {
-		instanceIDs[id] = true
-	}
+	return awsInstances, terraformInstances, nil
+}
+
+// instancePair is an instance's AWS and Terraform configurations, either of which may be missing
+type instancePair struct {
+	id        string
+	aws       *model.Instance
+	terraform *model.Instance
+}
 
+// checkPairs detects drift for the pairs handed out by produce on a fixed pool of workers.
+// send blocks until a worker is free and returns false once the run has been cancelled. total
+// is the number of pairs produce hands out, or 0 when it isn't known up front. A run cancelled
+// by the caller, or one whose time budget ran out with pairs left unchecked, fails with the
+// partial results.
+func (s *DriftDetectorService) checkPairs(ctx context.Context, attributePaths []string, total int, produce func(ctx context.Context, send func(instancePair) bool) error) ([]*model.DriftResult, error) {
 	// Detect drift for each instance
-	var results []*model.DriftResult
+	results := []*model.DriftResult{}
 	var resultsMutex sync.Mutex
 	var errs []error
 	var errorsMutex sync.Mutex
 
-	// Use a semaphore to limit concurrent operations
-	sem := make(chan struct{}, s.parallelChecks)
-	var wgDrift sync.WaitGroup
-
-	for id := range instanceIDs {
-		wgDrift.Add(1)
-		go func(instanceID string) {
-			defer wgDrift.Done()
-
-			// Acquire semaphore
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			// Get instances from both providers
-			awsInstance := awsInstanceMap[instanceID]
-			terraformInstance := terraformInstanceMap[instanceID]
-
-			// Skip if an instance doesn't exist in one of the providers
-			if awsInstance == nil || terraformInstance == nil {
-				// Create a result indicating the instance only exists in one provider
-				result := model.NewDriftResult(instanceID, s.sourceOfTruth)
-				if awsInstance == nil {
-					result.AddDriftedAttribute("exists", false, true)
-					s.logger.Warn(fmt.Sprintf("Instance %s exists in Terraform but not in AWS", instanceID))
-				} else {
-					result.AddDriftedAttribute("exists", true, false)
-					s.logger.Warn(fmt.Sprintf("Instance %s exists in AWS but not in Terraform", instanceID))
+	// Feed pairs to a fixed pool of workers; each idle worker pulls the next queued pair
+	workers := s.workerCount()
+	work := make(chan instancePair)
+	g, runCtx := errgroup.WithContext(ctx)
+	var skipped atomic.Bool
+
+	for i := 0; i < workers; i++ {
+		g.Go(func() error {
+			for pair := range work {
+				// Stop picking up queued work once the run has been cancelled
+				if runCtx.Err() != nil {
+					skipped.Store(true)
+					return nil
 				}
 
-				// Save the result
-				resultsMutex.Lock()
-				results = append(results, result)
-				resultsMutex.Unlock()
-
-				// Store the result
-				if err := s.repository.SaveDriftResult(ctx, result); err != nil {
-					errorsMutex.Lock()
-					errs = append(errs, err)
-					errorsMutex.Unlock()
+				result, err := s.checkPair(runCtx, pair, attributePaths)
+				if result != nil {
+					resultsMutex.Lock()
+					results = append(results, result)
+					resultsMutex.Unlock()
+				}
+				if err == nil {
+					continue
 				}
 
-				return
-			}
-
-			// Determine source and target based on source of truth
-			var source, target *model.Instance
-			if s.sourceOfTruth == model.OriginAWS {
-				source = awsInstance
-				target = terraformInstance
-			} else {
-				source = terraformInstance
-				target = awsInstance
-			}
-
-			// Detect drift
-			result, err := s.DetectDrift(ctx, source, target, attributePaths)
-			if err != nil {
 				errorsMutex.Lock()
 				errs = append(errs, err)
+				failures := len(errs)
 				errorsMutex.Unlock()
-				return
+
+				// Returning an error cancels runCtx for all other workers
+				if s.abortAfterErrors > 0 && failures >= s.abortAfterErrors {
+					return errErrorBudgetExceeded
+				}
 			}
+			return nil
+		})
+	}
 
-			resultsMutex.Lock()
-			results = append(results, result)
-			resultsMutex.Unlock()
-		}(id)
+	sent, refused := 0, false
+	produceErr := produce(runCtx, func(pair instancePair) bool {
+		select {
+		case work <- pair:
+			sent++
+			return true
+		case <-runCtx.Done():
+			refused = true
+			return false
+		}
+	})
+	close(work)
+	if total == 0 {
+		total = sent
+		if refused {
+			// At least the refused pair was left over
+			total++
+		}
+	}
+
+	if err := g.Wait(); err == errErrorBudgetExceeded {
+		s.logger.Error(fmt.Sprintf("Aborting drift detection: %d instance checks failed (error budget %d)", len(errs), s.abortAfterErrors))
+		return results, errors.NewOperationalError(
+			fmt.Sprintf("Drift detection aborted after %d instance failures (error budget %d); %d of %d instances checked", len(errs), s.abortAfterErrors, len(results), total),
+			errs[len(errs)-1],
+		).WithContext("reason", "error_budget_exceeded").
+			WithContext("failed", len(errs)).
+			WithContext("completed", len(results)).
+			WithContext("total", total)
+	}
+
+	if produceErr != nil {
+		return results, produceErr
 	}
 
-	wgDrift.Wait()
+	if err := s.interruptedRun(ctx, results, total, refused || skipped.Load()); err != nil {
+		return results, err
+	}
 
 	// Check for errors
 	if len(errs) > 0 {
@@ -336,10 +718,432 @@ func (s *DriftDetectorService) DetectDriftForAll(ctx context.Context, attributeP
 	return results, nil
 }
 
+// checkPair detects drift for a pair on a worker. A panic while checking one instance, such as
+// from an attribute value the mappers didn't expect, fails that instance instead of the run.
+func (s *DriftDetectorService) checkPair(ctx context.Context, pair instancePair, attributePaths []string) (result *model.DriftResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error(fmt.Sprintf("Drift check for instance %s panicked: %v", pair.id, r))
+			s.logger.Debug(string(debug.Stack()))
+			result = nil
+			err = errors.NewOperationalError(fmt.Sprintf("Drift check for instance %s panicked: %v", pair.id, r), nil).
+				WithContext("instance_id", pair.id)
+		}
+	}()
+	return s.detectDriftForPair(ctx, pair.id, pair.aws, pair.terraform, attributePaths)
+}
+
+// providerArrival is an instance streamed by a provider, or the end of its stream
+type providerArrival struct {
+	origin   model.ResourceOrigin
+	instance *model.Instance
+	done     bool
+	err      error
+}
+
+// streamPairs streams both providers and sends each instance for checking as soon as it has
+// been seen on both sides, so that AWS pagination and state parsing overlap with comparisons.
+// Once one provider has finished, instances only the other one has are sent as they arrive.
+// Terraform instances pass the account guard before anything is sent.
+func (s *DriftDetectorService) streamPairs(ctx context.Context, guard *accountGuard, send func(instancePair) bool) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		// Stop the other provider when returning early and wait for it to let go
+		cancel()
+		wg.Wait()
+	}()
+
+	arrivals := make(chan providerArrival)
+	start := func(origin model.ResourceOrigin, provider service.InstanceProvider, timeout time.Duration) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			providerCtx, providerCancel := providerContext(streamCtx, timeout)
+			defer providerCancel()
+
+			started := s.clock.Now()
+			err := streamInstances(providerCtx, provider, func(instance *model.Instance) error {
+				select {
+				case arrivals <- providerArrival{origin: origin, instance: instance}:
+					return nil
+				case <-providerCtx.Done():
+					return providerCtx.Err()
+				}
+			})
+			s.recordFetch(ctx, origin, started)
+
+			select {
+			case arrivals <- providerArrival{origin: origin, done: true, err: err}:
+			case <-streamCtx.Done():
+			}
+		}()
+	}
+	start(model.OriginAWS, s.awsProvider, s.awsTimeout)
+	start(model.OriginTerraform, s.terraformProvider, s.terraformTimeout)
+
+	// Instances are keyed by ID; across workspaces, by workspace and ID
+	buckets := s.newWorkspaceBuckets()
+	seen := map[model.ResourceOrigin]map[string]*model.Instance{
+		model.OriginAWS:       {},
+		model.OriginTerraform: {},
+	}
+	finished := make(map[model.ResourceOrigin]bool)
+	counts := make(map[model.ResourceOrigin]int)
+	dispatched := make(map[string]bool)
+	instanceIDs := make(map[string]string)
+
+	pairOf := func(key string) instancePair {
+		return instancePair{id: instanceIDs[key], aws: seen[model.OriginAWS][key], terraform: seen[model.OriginTerraform][key]}
+	}
+
+	for len(finished) < 2 {
+		var arrival providerArrival
+		select {
+		case arrival = <-arrivals:
+		case <-ctx.Done():
+			return errors.NewOperationalError("Drift detection cancelled while listing instances", ctx.Err())
+		}
+
+		other := model.OriginTerraform
+		if arrival.origin == model.OriginTerraform {
+			other = model.OriginAWS
+		}
+
+		if arrival.done {
+			if arrival.err != nil {
+				s.logger.Error(fmt.Sprintf("Failed to list %s instances: %v", arrival.origin.Name(), arrival.err))
+				return errors.NewOperationalError(fmt.Sprintf("Failed to list %s instances", arrival.origin.Name()), arrival.err)
+			}
+			finished[arrival.origin] = true
+
+			// Stop before the other side's instances are reported as missing from this one
+			if err := s.checkInstanceCount(arrival.origin, counts[arrival.origin]); err != nil {
+				return err
+			}
+
+			// Nothing more will arrive from this side to pair the other side's instances with
+			for key := range seen[other] {
+				if dispatched[key] {
+					continue
+				}
+				dispatched[key] = true
+				if !send(pairOf(key)) {
+					return nil
+				}
+			}
+			continue
+		}
+
+		counts[arrival.origin]++
+		if arrival.origin == model.OriginTerraform {
+			if err := guard.check(arrival.instance); err != nil {
+				return err
+			}
+		}
+
+		key, ok := buckets.key(arrival.origin, arrival.instance)
+		if !ok || dispatched[key] {
+			continue
+		}
+		seen[arrival.origin][key] = arrival.instance
+		instanceIDs[key] = arrival.instance.ID
+
+		_, paired := seen[other][key]
+		if paired || finished[other] {
+			dispatched[key] = true
+			if !send(pairOf(key)) {
+				return nil
+			}
+		}
+	}
+	buckets.report(s.logger)
+
+	// An empty inventory on both sides usually points at a misconfigured state source, region or account
+	if len(dispatched) == 0 {
+		if s.errorOnEmpty {
+			return errors.NewOperationalError("No instances found in AWS or Terraform", nil).
+				WithContext("reason", "no_instances")
+		}
+		s.logger.Warn("No instances found in AWS or Terraform; check the state file, HCL directory, region and account configuration")
+	}
+
+	return nil
+}
+
+// streamInstances streams a provider's instances, listing them in full first when the
+// provider can't stream
+func streamInstances(ctx context.Context, provider service.InstanceProvider, emit func(*model.Instance) error) error {
+	if streamer, ok := provider.(service.InstanceStreamer); ok {
+		return streamer.StreamInstances(ctx, emit)
+	}
+
+	instances, err := provider.ListInstances(ctx)
+	if err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		if err := emit(instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// detectDriftForPair detects drift for an instance given its AWS and Terraform configurations,
+// either of which may be missing. A result is returned alongside a storage error when the
+// instance only exists in one provider.
+func (s *DriftDetectorService) detectDriftForPair(ctx context.Context, instanceID string, awsInstance, terraformInstance *model.Instance, attributePaths []string) (*model.DriftResult, error) {
+	// Determine source and target based on source of truth
+	var source, target *model.Instance
+	if s.sourceOfTruth == model.OriginAWS {
+		source = awsInstance
+		target = terraformInstance
+	} else {
+		source = terraformInstance
+		target = awsInstance
+	}
+
+	// Skip if an instance doesn't exist in one of the providers
+	if awsInstance == nil || terraformInstance == nil {
+		// Create a result indicating the instance only exists in one provider
+		result := model.NewDriftResultAt(instanceID, s.sourceOfTruth, s.clock.Now())
+		result.AccountID = accountID(awsInstance, terraformInstance)
+		result.Workspace = workspaceName(awsInstance, terraformInstance)
+		result.ResourceAddress = resourceAddress(awsInstance, terraformInstance)
+		result.DuplicateAddresses = duplicateAddresses(awsInstance, terraformInstance)
+		// Whether each side exists, in the same source and target order as compared values
+		result.AddDriftedAttribute(model.AttributeExists, source != nil, target != nil)
+		if awsInstance == nil {
+			s.logger.Warn(fmt.Sprintf("Instance %s exists in Terraform but not in AWS", instanceID))
+		} else {
+			// With AWS as the source of truth, an instance Terraform doesn't know is unmanaged
+			result.Unmanaged = s.sourceOfTruth == model.OriginAWS
+			s.logger.Warn(fmt.Sprintf("Instance %s exists in AWS but not in Terraform", instanceID))
+			s.evaluatePolicies(result, awsInstance)
+		}
+		s.attachRemediation(result, awsInstance, terraformInstance)
+		s.attachSnapshots(result, source, target)
+		s.attachDriftAges(ctx, result)
+
+		// Store the result
+		return result, s.saveResult(ctx, result)
+	}
+
+	// Detect drift
+	return s.DetectDrift(ctx, source, target, attributePaths)
+}
+
+// logDrifts writes one structured debug line per drifted attribute for auditing
+func (s *DriftDetectorService) logDrifts(runID, instanceID string, drifts map[string]model.AttributeDrift) {
+	if !s.logger.IsDebug() {
+		return
+	}
+
+	paths := make([]string, 0, len(drifts))
+	for path := range drifts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		drift := drifts[path]
+		s.logger.WithFields(map[string]interface{}{
+			"run_id":      runID,
+			"instance_id": instanceID,
+			"attribute":   path,
+			"source":      drift.SourceValue,
+			"target":      drift.TargetValue,
+		}).Debug("Drift detected")
+	}
+}
+
+// attachRemediation attaches the steps that resolve a drifted result when requested
+func (s *DriftDetectorService) attachRemediation(result *model.DriftResult, instances ...*model.Instance) {
+	if !s.suggestRemediation || !result.HasDrift {
+		return
+	}
+
+	var terraformInstance, awsInstance *model.Instance
+	for _, instance := range instances {
+		if instance == nil {
+			continue
+		}
+		if instance.Origin == model.OriginTerraform {
+			terraformInstance = instance
+		} else {
+			awsInstance = instance
+		}
+	}
+	result.Remediation = model.SuggestRemediation(result, terraformInstance, awsInstance)
+}
+
+// evaluatePolicies records the policy violations of the AWS instance among the given instances
+func (s *DriftDetectorService) evaluatePolicies(result *model.DriftResult, instances ...*model.Instance) {
+	policies := s.policies
+	if len(s.allowedTypes) > 0 {
+		policies = append(append([]model.Policy{}, s.policies...), model.AllowedValuesPolicy("instance_type", s.allowedTypes))
+	}
+	if len(policies) == 0 {
+		return
+	}
+
+	for _, instance := range instances {
+		if instance == nil || instance.Origin != model.OriginAWS {
+			continue
+		}
+		violations := model.EvaluatePolicies(instance, policies, s.clock.Now())
+		if len(violations) > 0 {
+			result.SetPolicyViolations(violations)
+			s.logger.Warn(fmt.Sprintf("Instance %s violates %d policies", instance.ID, len(violations)))
+		}
+		return
+	}
+}
+
+// isRunAborted reports whether an error signals a run aborted by the error budget
+func isRunAborted(err error) bool {
+	appErr, ok := err.(*errors.AppError)
+	return ok && appErr.Context["reason"] == "error_budget_exceeded"
+}
+
+// runIDKey is the context key for the ID of the current detection run
+type runIDKey struct{}
+
+// ensureRunID returns the run ID carried by ctx, starting a new run if there is none
+func ensureRunID(ctx context.Context) (context.Context, string) {
+	if runID, ok := ctx.Value(runIDKey{}).(string); ok {
+		return ctx, runID
+	}
+	runID := uuid.New().String()
+	return context.WithValue(ctx, runIDKey{}, runID), runID
+}
+
+// providerContext derives the context for a single provider call. A positive timeout bounds
+// the call on its own; the parent's overall timeout still applies as an upper bound.
+func providerContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// accountID returns the first account ID set on the given instances
+func accountID(instances ...*model.Instance) string {
+	for _, instance := range instances {
+		if instance != nil && instance.AccountID != "" {
+			return instance.AccountID
+		}
+	}
+	return ""
+}
+
+// resourceAddress returns the first Terraform resource address set on the given instances
+func resourceAddress(instances ...*model.Instance) string {
+	for _, instance := range instances {
+		if instance != nil && instance.ResourceAddress != "" {
+			return instance.ResourceAddress
+		}
+	}
+	return ""
+}
+
+// duplicateAddresses returns the addresses of other Terraform resources tracking the same
+// instance as the first of the given instances that has them
+func duplicateAddresses(instances ...*model.Instance) []string {
+	for _, instance := range instances {
+		if instance != nil && len(instance.DuplicateAddresses) > 0 {
+			return instance.DuplicateAddresses
+		}
+	}
+	return nil
+}
+
+// workspaceName returns the first workspace set on the given instances
+func workspaceName(instances ...*model.Instance) string {
+	for _, instance := range instances {
+		if instance != nil && instance.Workspace != "" {
+			return instance.Workspace
+		}
+	}
+	return ""
+}
+
+// workerCount returns the number of instances checked in parallel
+func (s *DriftDetectorService) workerCount() int {
+	if s.parallelChecks < 1 {
+		return 1
+	}
+	return s.parallelChecks
+}
+
 // RunScheduledDriftCheck runs a scheduled drift check
 func (s *DriftDetectorService) RunScheduledDriftCheck(ctx context.Context) error {
 	s.logger.Info("Running scheduled drift check")
-	return s.DetectAndReportDriftForAll(ctx, nil)
+
+	_, err := s.RunDriftCheck(ctx, nil)
+	return err
+}
+
+// RunDriftCheck detects and reports drift for all instances and returns the run summary. The
+// summary is returned even when the run fails, covering the results gathered before the failure.
+func (s *DriftDetectorService) RunDriftCheck(ctx context.Context, attributePaths []string) (*model.RunSummary, error) {
+	startedAt := s.clock.Now()
+	ctx, timer := ensureFetchTimer(ctx)
+	results, err := s.detectAndReportDriftForAll(ctx, attributePaths)
+	if err == nil && s.memoize && len(s.resourceFilter) == 0 {
+		s.memo.retain(results)
+	}
+	summary := model.NewRunSummary(startedAt, s.clock.Now(), results, err)
+	summary.Concurrency = s.workerCount()
+	summary.FetchTimings = timer.snapshot()
+	if provider, ok := s.terraformProvider.(service.StateVersionProvider); ok {
+		summary.TerraformVersion = provider.TerraformVersion()
+	}
+
+	// Track the outcome for status reporting
+	s.statusMu.Lock()
+	s.lastRun = summary
+	s.statusMu.Unlock()
+
+	return summary, err
+}
+
+// CheckProviders verifies that the AWS and Terraform providers are reachable by listing their instances
+func (s *DriftDetectorService) CheckProviders(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if _, err := s.awsProvider.ListInstances(ctx); err != nil {
+		return errors.NewOperationalError("AWS provider is not reachable", err)
+	}
+
+	if _, err := s.terraformProvider.ListInstances(ctx); err != nil {
+		return errors.NewOperationalError("Terraform provider is not reachable", err)
+	}
+
+	return nil
+}
+
+// GetSchedulerStatus returns the scheduler state, next run time and last run summary
+func (s *DriftDetectorService) GetSchedulerStatus() model.SchedulerStatus {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+
+	status := model.SchedulerStatus{
+		Running:            s.schedulerRunning,
+		ScheduleExpression: s.scheduleExpression,
+		LastRun:            s.lastRun,
+	}
+
+	if s.schedulerRunning && s.scheduler != nil {
+		if next := s.scheduler.Entry(s.scheduleEntry).Next; !next.IsZero() {
+			status.NextRun = &next
+			status.UpcomingRuns = append([]time.Time{next}, schedule.NextRuns(s.schedule, next, schedule.PreviewCount-1)...)
+		}
+	}
+
+	return status
 }
 
 // reportDrift reports a single drift detection result
@@ -359,10 +1163,15 @@ func (s *DriftDetectorService) reportDrift(result *model.DriftResult) error {
 
 // reportMultipleDrifts reports multiple drift detection results
 func (s *DriftDetectorService) reportMultipleDrifts(results []*model.DriftResult) error {
+	return s.reportMultipleDriftsTo(s.reporters, results)
+}
+
+// reportMultipleDriftsTo reports multiple drift detection results using the given reporters
+func (s *DriftDetectorService) reportMultipleDriftsTo(reporters []service.Reporter, results []*model.DriftResult) error {
 	s.logger.Info(fmt.Sprintf("Reporting drift for %d instances", len(results)))
 
-	// Report drift using all configured reporters
-	for _, reporter := range s.reporters {
+	// Report drift using all given reporters; each aggregates drift per attribute itself
+	for _, reporter := range reporters {
 		if err := reporter.ReportMultipleDrifts(results); err != nil {
 			s.logger.Error(fmt.Sprintf("Failed to report drift for multiple instances: %v", err))
 			return errors.NewOperationalError("Failed to report drift for multiple instances", err)
@@ -372,6 +1181,76 @@ func (s *DriftDetectorService) reportMultipleDrifts(results []*model.DriftResult
 	return nil
 }
 
+// ReportStoredResults renders results saved in the repository at or after since through the
+// given reporters, without running detection. A zero since reports every stored result.
+func (s *DriftDetectorService) ReportStoredResults(ctx context.Context, since time.Time, reporters []service.Reporter) error {
+	stored, err := s.repository.ListDriftResults(ctx)
+	if err != nil {
+		return errors.NewOperationalError("Failed to list stored drift results", err)
+	}
+
+	results := make([]*model.DriftResult, 0, len(stored))
+	for _, result := range stored {
+		if !result.Timestamp.Before(since) {
+			results = append(results, result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].Timestamp.Equal(results[j].Timestamp) {
+			return results[i].Timestamp.Before(results[j].Timestamp)
+		}
+		return results[i].ResourceID < results[j].ResourceID
+	})
+
+	if len(results) == 0 {
+		s.logger.Warn("No stored drift results found for the requested period")
+	}
+
+	return s.reportMultipleDriftsTo(reporters, results)
+}
+
+// ShowStoredResult renders a stored result in detail through the given reporters. id is a
+// result ID or, when no result has it, an instance ID whose latest result is shown.
+func (s *DriftDetectorService) ShowStoredResult(ctx context.Context, id string, reporters []service.Reporter) error {
+	result, err := s.repository.GetDriftResult(ctx, id)
+	if errors.IsNotFoundError(err) {
+		results, instanceErr := s.repository.GetDriftResultsByInstanceID(ctx, id)
+		if instanceErr != nil && !errors.IsNotFoundError(instanceErr) {
+			return errors.NewOperationalError(fmt.Sprintf("Failed to look up stored results of instance %s", id), instanceErr)
+		}
+		if result = model.LatestResult(results); result == nil {
+			return errors.NewNotFoundError("DriftResult", id)
+		}
+	} else if err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to look up stored result %s", id), err)
+	}
+
+	for _, reporter := range reporters {
+		if err := reporter.ReportDrift(result); err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to report stored result %s: %v", result.ID, err))
+			return errors.NewOperationalError(fmt.Sprintf("Failed to report stored result %s", result.ID), err)
+		}
+	}
+	return nil
+}
+
+// reportOrphans reports orphaned resources to the reporters that support them
+func (s *DriftDetectorService) reportOrphans(orphans []*model.OrphanResult) error {
+	for _, reporter := range s.reporters {
+		orphanReporter, ok := reporter.(service.OrphanReporter)
+		if !ok {
+			continue
+		}
+		if err := orphanReporter.ReportOrphans(orphans); err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to report orphaned resources: %v", err))
+			return errors.NewOperationalError("Failed to report orphaned resources", err)
+		}
+	}
+
+	return nil
+}
+
 // StartScheduler starts the scheduler
 func (s *DriftDetectorService) StartScheduler(ctx context.Context) error {
 	s.logger.Info(fmt.Sprintf("Starting scheduler with expression: %s", s.scheduleExpression))
@@ -380,29 +1259,46 @@ func (s *DriftDetectorService) StartScheduler(ctx context.Context) error {
 		return errors.NewValidationError("Schedule expression cannot be empty")
 	}
 
+	sched, err := schedule.Parse(s.scheduleExpression)
+	if err != nil {
+		return err
+	}
+
 	// Create a new scheduler
-	s.scheduler = cron.New()
+	scheduler := cron.New()
 
-	// Add the scheduled drift check
-	_, err := s.scheduler.AddFunc(s.scheduleExpression, func() {
+	// Add the scheduled drift check, keeping its entry to report when it next fires
+	entry := scheduler.Schedule(sched, cron.FuncJob(func() {
 		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 		defer cancel()
 
 		if err := s.RunScheduledDriftCheck(ctx); err != nil {
 			s.logger.Error(fmt.Sprintf("Scheduled drift check failed: %v", err))
 		}
-	})
-
-	if err != nil {
-		return errors.NewOperationalError("Failed to add scheduled drift check", err)
-	}
+		s.logNextRun()
+	}))
 
 	// Start the scheduler
-	s.scheduler.Start()
+	scheduler.Start()
+
+	s.statusMu.Lock()
+	s.scheduler = scheduler
+	s.schedule = sched
+	s.scheduleEntry = entry
+	s.schedulerRunning = true
+	s.statusMu.Unlock()
 
+	s.logNextRun()
 	return nil
 }
 
+// logNextRun logs when the scheduled drift check next fires
+func (s *DriftDetectorService) logNextRun() {
+	if next := s.GetSchedulerStatus().NextRun; next != nil {
+		s.logger.Info(fmt.Sprintf("Next scheduled drift check at %s", schedule.FormatRun(*next)))
+	}
+}
+
 // StopScheduler stops the scheduler
 func (s *DriftDetectorService) StopScheduler() {
 	s.logger.Info("Stopping scheduler")
@@ -410,6 +1306,10 @@ func (s *DriftDetectorService) StopScheduler() {
 	if s.scheduler != nil {
 		s.scheduler.Stop()
 	}
+
+	s.statusMu.Lock()
+	s.schedulerRunning = false
+	s.statusMu.Unlock()
 }
 
 // SetSourceOfTruth sets the source of truth
@@ -437,6 +1337,89 @@ func (s *DriftDetectorService) SetScheduleExpression(expression string) {
 	s.scheduleExpression = expression
 }
 
+// SetAbortAfterErrors sets the number of instance failures after which a run is aborted
+func (s *DriftDetectorService) SetAbortAfterErrors(abortAfterErrors int) {
+	s.abortAfterErrors = abortAfterErrors
+}
+
+// SetParallelProviders sets whether instances are checked as the providers stream them
+func (s *DriftDetectorService) SetParallelProviders(parallelProviders bool) {
+	s.parallelProviders = parallelProviders
+}
+
+// SetErrorOnEmpty sets whether a run fails when no instances are found
+func (s *DriftDetectorService) SetErrorOnEmpty(errorOnEmpty bool) {
+	s.errorOnEmpty = errorOnEmpty
+}
+
+// SetStaticIPsOnly sets whether address attributes are only compared when statically assigned
+func (s *DriftDetectorService) SetStaticIPsOnly(staticIPsOnly bool) {
+	s.staticIPsOnly = staticIPsOnly
+}
+
+// SetCheckOrphans sets whether orphaned resources are reported after each run
+func (s *DriftDetectorService) SetCheckOrphans(checkOrphans bool) {
+	s.checkOrphans = checkOrphans
+}
+
+// SetCompareOptions sets how attribute values are normalized before comparison
+func (s *DriftDetectorService) SetCompareOptions(opts model.CompareOptions) {
+	s.compareOptions = opts
+}
+
+// RegisterComparator sets the function the values of an attribute are compared with, replacing
+// the default comparison for that path. The source of truth's value is passed first, and the
+// function is only called when both instances have a known value. A nil function restores the
+// default comparison.
+func (s *DriftDetectorService) RegisterComparator(path string, fn func(a, b interface{}) bool) {
+	path = model.CanonicalAttribute(path)
+
+	s.comparatorsMu.Lock()
+	defer s.comparatorsMu.Unlock()
+	if fn == nil {
+		delete(s.comparators, path)
+		return
+	}
+	if s.comparators == nil {
+		s.comparators = make(map[string]comparator.EqualFunc)
+	}
+	s.comparators[path] = fn
+}
+
+// attributeCompareOptions returns the compare options with the registered comparators added
+func (s *DriftDetectorService) attributeCompareOptions() model.CompareOptions {
+	opts := s.compareOptions
+
+	s.comparatorsMu.RLock()
+	defer s.comparatorsMu.RUnlock()
+	if len(s.comparators) == 0 {
+		return opts
+	}
+	opts.Comparators = make(map[string]comparator.EqualFunc, len(s.compareOptions.Comparators)+len(s.comparators))
+	for path, fn := range s.compareOptions.Comparators {
+		opts.Comparators[path] = fn
+	}
+	for path, fn := range s.comparators {
+		opts.Comparators[path] = fn
+	}
+	return opts
+}
+
+// SetAWSTimeout sets the timeout for AWS provider calls
+func (s *DriftDetectorService) SetAWSTimeout(timeout time.Duration) {
+	s.awsTimeout = timeout
+}
+
+// SetTerraformTimeout sets the timeout for Terraform provider calls
+func (s *DriftDetectorService) SetTerraformTimeout(timeout time.Duration) {
+	s.terraformTimeout = timeout
+}
+
+// SetSourceDeclaredOnly sets whether comparison is limited to attributes declared by the source
+func (s *DriftDetectorService) SetSourceDeclaredOnly(sourceDeclaredOnly bool) {
+	s.sourceDeclaredOnly = sourceDeclaredOnly
+}
+
 // GetAttributePaths returns the attribute paths to check
 func (s *DriftDetectorService) GetAttributePaths() []string {
 	return s.attributePaths
@@ -462,8 +1445,247 @@ func (s *DriftDetectorService) GetScheduleExpression() string {
 	return s.scheduleExpression
 }
 
+// GetAbortAfterErrors returns the number of instance failures after which a run is aborted
+func (s *DriftDetectorService) GetAbortAfterErrors() int {
+	return s.abortAfterErrors
+}
+
+// GetParallelProviders returns whether instances are checked as the providers stream them
+func (s *DriftDetectorService) GetParallelProviders() bool {
+	return s.parallelProviders
+}
+
+// GetErrorOnEmpty returns whether a run fails when no instances are found
+func (s *DriftDetectorService) GetErrorOnEmpty() bool {
+	return s.errorOnEmpty
+}
+
+// SetMinInstances sets how many instances each provider must return for a run to proceed
+func (s *DriftDetectorService) SetMinInstances(minInstances int) {
+	s.minInstances = minInstances
+}
+
+// GetMinInstances returns how many instances each provider must return for a run to proceed
+func (s *DriftDetectorService) GetMinInstances() int {
+	return s.minInstances
+}
+
+// GetStaticIPsOnly returns whether address attributes are only compared when statically assigned
+func (s *DriftDetectorService) GetStaticIPsOnly() bool {
+	return s.staticIPsOnly
+}
+
+// GetCheckOrphans returns whether orphaned resources are reported after each run
+func (s *DriftDetectorService) GetCheckOrphans() bool {
+	return s.checkOrphans
+}
+
+// GetCompareOptions returns how attribute values are normalized before comparison
+func (s *DriftDetectorService) GetCompareOptions() model.CompareOptions {
+	return s.compareOptions
+}
+
+// GetAWSTimeout returns the timeout for AWS provider calls
+func (s *DriftDetectorService) GetAWSTimeout() time.Duration {
+	return s.awsTimeout
+}
+
+// GetTerraformTimeout returns the timeout for Terraform provider calls
+func (s *DriftDetectorService) GetTerraformTimeout() time.Duration {
+	return s.terraformTimeout
+}
+
+// GetSourceDeclaredOnly returns whether comparison is limited to attributes declared by the source
+func (s *DriftDetectorService) GetSourceDeclaredOnly() bool {
+	return s.sourceDeclaredOnly
+}
+
 // SetReporters updates the reporters based on the reporter type
 func (s *DriftDetectorService) SetReporters(reporters []service.Reporter) {
 	s.logger.Info("Updating reporters")
-	s.reporters = reporters
+	s.baseReporters = reporters
+	s.reporters = wrapDigestReporters(reporters, s.repository, s.digestOptions, s.clock, s.logger)
+}
+
+// SetStateCacheEnabled sets whether the Terraform provider reuses state parsed by earlier runs
+// while it is unchanged
+func (s *DriftDetectorService) SetStateCacheEnabled(enabled bool) {
+	if cache, ok := s.terraformProvider.(service.StateCache); ok {
+		cache.SetCacheEnabled(enabled)
+	}
+}
+
+// InvalidateStateCache drops state the Terraform provider cached from earlier runs
+func (s *DriftDetectorService) InvalidateStateCache() {
+	if cache, ok := s.terraformProvider.(service.StateCache); ok {
+		cache.InvalidateCache()
+	}
+}
+
+// SetAWSProvider replaces the AWS provider, e.g. after a CLI flag changed the AWS profile
+func (s *DriftDetectorService) SetAWSProvider(provider service.InstanceProvider) {
+	s.logger.Info("Updating AWS provider")
+	s.awsProvider = provider
+}
+
+// SetAttributeDumper sets the dumper that records compared attributes (nil disables dumps)
+func (s *DriftDetectorService) SetAttributeDumper(dumper service.AttributeDumper) {
+	s.attributeDumper = dumper
+}
+
+// SetDigestOptions sets how notification reporters batch results and rewraps the reporters
+func (s *DriftDetectorService) SetDigestOptions(opts service.DigestOptions) {
+	s.digestOptions = opts
+	s.SetReporters(s.baseReporters)
+}
+
+// GetDigestOptions returns how notification reporters batch results
+func (s *DriftDetectorService) GetDigestOptions() service.DigestOptions {
+	return s.digestOptions
+}
+
+// SetPolicies sets the policies evaluated against the live AWS instance
+func (s *DriftDetectorService) SetPolicies(policies []model.Policy) {
+	s.policies = policies
+}
+
+// GetPolicies returns the policies evaluated against the live AWS instance
+func (s *DriftDetectorService) GetPolicies() []model.Policy {
+	return s.policies
+}
+
+// SetAllowedInstanceTypes sets the instance types permitted by policy (empty allows all)
+func (s *DriftDetectorService) SetAllowedInstanceTypes(instanceTypes []string) {
+	s.allowedTypes = instanceTypes
+}
+
+// GetAllowedInstanceTypes returns the instance types permitted by policy
+func (s *DriftDetectorService) GetAllowedInstanceTypes() []string {
+	return s.allowedTypes
+}
+
+// SetEnvironmentTag sets the AWS tag naming the Terraform workspace of an instance
+func (s *DriftDetectorService) SetEnvironmentTag(tag string) {
+	s.environmentTag = tag
+}
+
+// GetEnvironmentTag returns the AWS tag naming the Terraform workspace of an instance
+func (s *DriftDetectorService) GetEnvironmentTag() string {
+	return s.environmentTag
+}
+
+// SetStrictAccountCheck sets whether a state of another AWS account or region fails the run
+func (s *DriftDetectorService) SetStrictAccountCheck(strict bool) {
+	s.strictAccountCheck = strict
+}
+
+// GetStrictAccountCheck returns whether a state of another AWS account or region fails the run
+func (s *DriftDetectorService) GetStrictAccountCheck() bool {
+	return s.strictAccountCheck
+}
+
+// SetSuggestRemediation sets whether drifted results carry suggested remediation steps
+func (s *DriftDetectorService) SetSuggestRemediation(suggest bool) {
+	s.suggestRemediation = suggest
+}
+
+// GetSuggestRemediation returns whether drifted results carry suggested remediation steps
+func (s *DriftDetectorService) GetSuggestRemediation() bool {
+	return s.suggestRemediation
+}
+
+// SetEnrichNetworkContext sets whether subnet_id drift is described with both subnets' VPCs,
+// availability zones and names
+func (s *DriftDetectorService) SetEnrichNetworkContext(enrich bool) {
+	s.networkContext = enrich
+}
+
+// GetEnrichNetworkContext returns whether subnet_id drift is described with both subnets
+func (s *DriftDetectorService) GetEnrichNetworkContext() bool {
+	return s.networkContext
+}
+
+// SetCompareTerminationProtection sets whether AWS's disable_api_termination is compared with
+// Terraform's lifecycle prevent_destroy
+func (s *DriftDetectorService) SetCompareTerminationProtection(compare bool) {
+	s.terminationCheck = compare
+}
+
+// GetCompareTerminationProtection returns whether termination protection is compared
+func (s *DriftDetectorService) GetCompareTerminationProtection() bool {
+	return s.terminationCheck
+}
+
+// SetMemoize sets whether instances that haven't changed on either side since the previous run
+// reuse its result instead of being compared again. Disabling it forgets the memoized results.
+func (s *DriftDetectorService) SetMemoize(memoize bool) {
+	s.memoize = memoize
+	if !memoize {
+		s.memo.reset()
+	}
+}
+
+// GetMemoize returns whether unchanged instances reuse the result of the previous run
+func (s *DriftDetectorService) GetMemoize() bool {
+	return s.memoize
+}
+
+// SetHashPrefilter sets whether instances whose compared attributes hash the same on both sides
+// skip the attribute by attribute comparison
+func (s *DriftDetectorService) SetHashPrefilter(prefilter bool) {
+	s.hashPrefilter = prefilter
+}
+
+// GetHashPrefilter returns whether identical instances are found by hash before comparing
+func (s *DriftDetectorService) GetHashPrefilter() bool {
+	return s.hashPrefilter
+}
+
+// SetDriftAgeLookback sets how many previous results of an instance are searched for when each
+// drift first appeared; 0 stops tracking drift ages
+func (s *DriftDetectorService) SetDriftAgeLookback(lookback int) {
+	s.driftAgeLookback = lookback
+}
+
+// GetDriftAgeLookback returns how many previous results are searched for drift ages
+func (s *DriftDetectorService) GetDriftAgeLookback() int {
+	return s.driftAgeLookback
+}
+
+// SetIncludeSnapshots sets whether results carry the full attributes of the compared instances
+func (s *DriftDetectorService) SetIncludeSnapshots(include bool) {
+	s.includeSnapshots = include
+}
+
+// GetIncludeSnapshots returns whether results carry the full attributes of the compared instances
+func (s *DriftDetectorService) GetIncludeSnapshots() bool {
+	return s.includeSnapshots
+}
+
+// SetResourceFilter limits runs over all instances to the Terraform resources matching the
+// given addresses or globs (empty checks every instance)
+func (s *DriftDetectorService) SetResourceFilter(patterns []string) {
+	s.resourceFilter = patterns
+}
+
+// GetResourceFilter returns the addresses or globs runs over all instances are limited to
+func (s *DriftDetectorService) GetResourceFilter() []string {
+	return s.resourceFilter
+}
+
+// FlushDigests sends all pending notification digests immediately
+func (s *DriftDetectorService) FlushDigests(ctx context.Context) error {
+	s.logger.Info("Flushing pending notification digests")
+
+	for _, reporter := range s.reporters {
+		digest, ok := reporter.(*DigestReporter)
+		if !ok {
+			continue
+		}
+		if err := digest.Flush(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -1,16 +1,28 @@
 package app_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
+	apperrors "github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/repository"
+	"github.com/victor-devv/ec2-drift-detector/internal/presentation/reporter"
 )
 
 type mockInstanceProvider struct {
@@ -29,6 +41,18 @@ func (m *mockInstanceProvider) ListInstances(ctx context.Context) ([]*model.Inst
 	return m.instances, m.err
 }
 
+func (m *mockInstanceProvider) StreamInstances(ctx context.Context, emit func(*model.Instance) error) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, instance := range m.instances {
+		if err := emit(instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type mockRepository struct {
 	saved []*model.DriftResult
 }
@@ -46,6 +70,9 @@ func (m *mockRepository) GetDriftResultsByInstanceID(ctx context.Context, id str
 func (m *mockRepository) ListDriftResults(ctx context.Context) ([]*model.DriftResult, error) {
 	return nil, nil
 }
+func (m *mockRepository) ListDriftResultsWithDrift(ctx context.Context) ([]*model.DriftResult, error) {
+	return nil, nil
+}
 
 type mockReporter struct {
 	reported []*model.DriftResult
@@ -130,3 +157,1579 @@ func TestStartAndStopScheduler(t *testing.T) {
 	assert.NoError(t, err)
 	detector.StopScheduler()
 }
+
+func TestDetectDrift_RecordsSkippedAttributes(t *testing.T) {
+	tfInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type":          "t2.micro",
+		"vpc_security_group_ids": model.UnknownValue{Reason: "unresolved reference to aws_security_group.app"},
+	}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type":          "t2.micro",
+		"vpc_security_group_ids": []string{"sg-123"},
+	}, model.OriginAWS)
+
+	detector := app.NewDriftDetectorService(nil, nil, &mockRepository{}, nil, service.DriftDetectorConfig{}, logging.New())
+
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type", "vpc_security_group_ids"})
+	assert.NoError(t, err)
+	assert.False(t, result.HasDrift)
+	assert.Contains(t, result.SkippedAttributes, "vpc_security_group_ids")
+}
+
+type failingRepository struct {
+	mockRepository
+	mu       sync.Mutex
+	attempts int
+}
+
+func (m *failingRepository) SaveDriftResult(ctx context.Context, result *model.DriftResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts++
+	return errors.New("credentials expired")
+}
+
+func newErrorBudgetDetector(repo service.DriftRepository, instances int, budget int) *app.DriftDetectorService {
+	var awsInstances, tfInstances []*model.Instance
+	for i := 0; i < instances; i++ {
+		id := fmt.Sprintf("i-%03d", i)
+		awsInstances = append(awsInstances, model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS))
+		tfInstances = append(tfInstances, model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform))
+	}
+
+	return app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: awsInstances},
+		&mockInstanceProvider{instances: tfInstances},
+		repo,
+		nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:    model.OriginTerraform,
+			AttributePaths:   []string{"instance_type"},
+			Timeout:          2 * time.Second,
+			ParallelChecks:   2,
+			AbortAfterErrors: budget,
+		},
+		logging.New(),
+	)
+}
+
+func TestDetectDriftForAll_AbortsOnErrorBudget(t *testing.T) {
+	repo := &failingRepository{}
+	detector := newErrorBudgetDetector(repo, 50, 3)
+
+	_, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	assert.ErrorContains(t, err, "aborted after")
+
+	appErr, ok := err.(*apperrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, "error_budget_exceeded", appErr.Context["reason"])
+	assert.Equal(t, 50, appErr.Context["total"])
+
+	// Queued work is not started once the budget is exhausted
+	assert.Less(t, repo.attempts, 50)
+}
+
+func TestDetectDriftForAll_ZeroBudgetKeepsGoing(t *testing.T) {
+	repo := &failingRepository{}
+	detector := newErrorBudgetDetector(repo, 20, 0)
+
+	_, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	assert.ErrorContains(t, err, "Failed to detect drift for 20 instances")
+	assert.Equal(t, 20, repo.attempts)
+}
+
+// cancellingRepository cancels the run once it has saved its first result, standing in for a
+// caller going away mid-run
+type cancellingRepository struct {
+	mockRepository
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+func (r *cancellingRepository) SaveDriftResult(ctx context.Context, result *model.DriftResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancel()
+	return r.mockRepository.SaveDriftResult(ctx, result)
+}
+
+func TestDetectDriftForAll_CallerCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo := &cancellingRepository{cancel: cancel}
+	detector := newErrorBudgetDetector(repo, 50, 0)
+
+	results, err := detector.DetectDriftForAll(ctx, []string{"instance_type"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "Drift detection cancelled")
+	assert.NotEmpty(t, results)
+	assert.Less(t, len(results), 50)
+
+	appErr, ok := err.(*apperrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "cancelled", appErr.Context["reason"])
+	assert.Equal(t, len(results), appErr.Context["completed"])
+}
+
+// stallingRepository holds every save until the run's time budget has run out, standing in for
+// checks too slow to get through all instances in time
+type stallingRepository struct {
+	mockRepository
+	mu sync.Mutex
+}
+
+func (r *stallingRepository) SaveDriftResult(ctx context.Context, result *model.DriftResult) error {
+	<-ctx.Done()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mockRepository.SaveDriftResult(context.Background(), result)
+}
+
+func TestDetectDriftForAll_Timeout(t *testing.T) {
+	detector := newErrorBudgetDetector(&stallingRepository{}, 50, 0)
+	detector.SetTimeout(50 * time.Millisecond)
+
+	results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "Drift detection timed out")
+	assert.Less(t, len(results), 50)
+
+	appErr, ok := err.(*apperrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "timeout", appErr.Context["reason"])
+	assert.Equal(t, len(results), appErr.Context["completed"])
+	assert.Equal(t, 50, appErr.Context["total"])
+}
+
+// panickingRepository panics when saving the result of one instance, standing in for any
+// instance whose check blows up
+type panickingRepository struct {
+	mockRepository
+	mu      sync.Mutex
+	panicOn string
+}
+
+func (r *panickingRepository) SaveDriftResult(ctx context.Context, result *model.DriftResult) error {
+	if result.ResourceID == r.panicOn {
+		panic("unexpected attribute value")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mockRepository.SaveDriftResult(ctx, result)
+}
+
+func TestDetectDriftForAll_RecoversFromPanics(t *testing.T) {
+	var awsInstances, terraformInstances []*model.Instance
+	for _, id := range []string{"i-1", "i-bad", "i-3"} {
+		awsInstances = append(awsInstances, model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS))
+		terraformInstances = append(terraformInstances, model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform))
+	}
+	repo := &panickingRepository{panicOn: "i-bad"}
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: awsInstances},
+		&mockInstanceProvider{instances: terraformInstances},
+		repo,
+		nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 2,
+		},
+		logging.New(),
+	)
+
+	results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	assert.ErrorContains(t, err, "Failed to detect drift for 1 instances")
+	assert.Len(t, results, 2)
+	assert.Len(t, repo.saved, 2)
+}
+
+func TestDetectDriftForAll_NoInstances(t *testing.T) {
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{},
+		&mockInstanceProvider{},
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 1,
+		},
+		logging.New(),
+	)
+
+	results, err := detector.DetectDriftForAll(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	detector.SetErrorOnEmpty(true)
+	results, err = detector.DetectDriftForAll(context.Background(), nil)
+	assert.ErrorContains(t, err, "No instances found")
+	assert.Nil(t, results)
+}
+
+func TestRunDriftCheck_ClassifiesUnmanagedInstances(t *testing.T) {
+	aws := &mockInstanceProvider{instances: []*model.Instance{
+		model.NewInstance("i-managed", map[string]interface{}{"instance_type": "t2.large"}, model.OriginAWS),
+		model.NewInstance("i-unmanaged", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS),
+	}}
+	terraform := &mockInstanceProvider{instances: []*model.Instance{
+		model.NewInstance("i-managed", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform),
+		model.NewInstance("i-gone", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform),
+	}}
+
+	for _, tt := range []struct {
+		sourceOfTruth model.ResourceOrigin
+		unmanaged     bool
+	}{
+		{sourceOfTruth: model.OriginAWS, unmanaged: true},
+		// With Terraform as the source of truth, instances only in AWS stay existence drift
+		{sourceOfTruth: model.OriginTerraform, unmanaged: false},
+	} {
+		t.Run(string(tt.sourceOfTruth), func(t *testing.T) {
+			reporter := &mockReporter{}
+			detector := app.NewDriftDetectorService(aws, terraform, &mockRepository{}, []service.Reporter{reporter},
+				service.DriftDetectorConfig{
+					SourceOfTruth:  tt.sourceOfTruth,
+					AttributePaths: []string{"instance_type"},
+					Timeout:        2 * time.Second,
+					ParallelChecks: 1,
+				},
+				logging.New(),
+			)
+
+			summary, err := detector.RunDriftCheck(context.Background(), nil)
+			require.NoError(t, err)
+
+			results := make(map[string]*model.DriftResult)
+			for _, result := range reporter.reported {
+				results[result.ResourceID] = result
+			}
+			require.Len(t, results, 3)
+
+			// A managed instance with attribute drift, and one missing from AWS, are never unmanaged
+			assert.True(t, results["i-managed"].IsManagedDrift())
+			assert.Contains(t, results["i-managed"].DriftedAttributes, "instance_type")
+			assert.True(t, results["i-gone"].IsManagedDrift())
+
+			unmanaged := results["i-unmanaged"]
+			assert.True(t, unmanaged.HasDrift)
+			assert.Contains(t, unmanaged.DriftedAttributes, model.AttributeExists)
+			assert.Equal(t, tt.unmanaged, unmanaged.Unmanaged)
+
+			if tt.unmanaged {
+				assert.Equal(t, 2, summary.DriftedCount)
+				assert.Equal(t, 1, summary.UnmanagedCount)
+			} else {
+				assert.Equal(t, 3, summary.DriftedCount)
+				assert.Zero(t, summary.UnmanagedCount)
+			}
+		})
+	}
+}
+
+// streamingProvider streams its instances, pausing before the instance at pauseAt until
+// release is closed
+type streamingProvider struct {
+	mockInstanceProvider
+	pauseAt int
+	release <-chan struct{}
+	delay   time.Duration
+}
+
+func (m *streamingProvider) StreamInstances(ctx context.Context, emit func(*model.Instance) error) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, instance := range m.instances {
+		if m.release != nil && i == m.pauseAt {
+			select {
+			case <-m.release:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		time.Sleep(m.delay)
+		if err := emit(instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *streamingProvider) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	var instances []*model.Instance
+	err := m.StreamInstances(ctx, func(instance *model.Instance) error {
+		instances = append(instances, instance)
+		return nil
+	})
+	return instances, err
+}
+
+// firstSaveRepository closes saved once the first result has been stored
+type firstSaveRepository struct {
+	mockRepository
+	mu    sync.Mutex
+	saved chan struct{}
+	count int
+}
+
+func (m *firstSaveRepository) SaveDriftResult(ctx context.Context, result *model.DriftResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.count == 0 {
+		close(m.saved)
+	}
+	m.count++
+	return nil
+}
+
+func streamingInstances(origin model.ResourceOrigin, ids ...string) []*model.Instance {
+	var instances []*model.Instance
+	for _, id := range ids {
+		instances = append(instances, model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro"}, origin))
+	}
+	return instances
+}
+
+func TestDetectDriftForAll_ParallelProvidersOverlap(t *testing.T) {
+	repo := &firstSaveRepository{saved: make(chan struct{})}
+
+	// Terraform stops after its first instance until a comparison has finished, which only
+	// happens if pairing starts before either provider is done
+	awsProvider := &streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: streamingInstances(model.OriginAWS, "i-1", "i-2", "i-3")}}
+	terraformProvider := &streamingProvider{
+		mockInstanceProvider: mockInstanceProvider{instances: streamingInstances(model.OriginTerraform, "i-1", "i-2", "i-4")},
+		pauseAt:              1,
+		release:              repo.saved,
+	}
+
+	detector := app.NewDriftDetectorService(awsProvider, terraformProvider, repo, nil, service.DriftDetectorConfig{
+		SourceOfTruth:     model.OriginTerraform,
+		AttributePaths:    []string{"instance_type"},
+		Timeout:           2 * time.Second,
+		ParallelChecks:    2,
+		ParallelProviders: true,
+	}, logging.New())
+
+	results, err := detector.DetectDriftForAll(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+
+	byID := make(map[string]*model.DriftResult)
+	for _, result := range results {
+		byID[result.ResourceID] = result
+	}
+	assert.False(t, byID["i-1"].HasDrift)
+	assert.Contains(t, byID["i-3"].DriftedAttributes, model.AttributeExists)
+	assert.Contains(t, byID["i-4"].DriftedAttributes, model.AttributeExists)
+}
+
+func TestDetectDriftForAll_ParallelProvidersErrors(t *testing.T) {
+	// A failing provider stops the other one instead of waiting for it to finish
+	blocked := make(chan struct{})
+	defer close(blocked)
+	terraformProvider := &streamingProvider{
+		mockInstanceProvider: mockInstanceProvider{instances: streamingInstances(model.OriginTerraform, "i-1", "i-2")},
+		pauseAt:              1,
+		release:              blocked,
+	}
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{err: errors.New("throttled")},
+		terraformProvider,
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{SourceOfTruth: model.OriginTerraform, Timeout: 5 * time.Second, ParallelChecks: 1, ParallelProviders: true},
+		logging.New(),
+	)
+
+	start := time.Now()
+	_, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	assert.ErrorContains(t, err, "Failed to list AWS instances")
+	assert.Less(t, time.Since(start), time.Second)
+
+	// Empty inventories are handled as when fetching everything first
+	detector = app.NewDriftDetectorService(&streamingProvider{}, &mockInstanceProvider{}, &mockRepository{}, nil,
+		service.DriftDetectorConfig{Timeout: 2 * time.Second, ParallelChecks: 1, ParallelProviders: true, ErrorOnEmpty: true}, logging.New())
+	_, err = detector.DetectDriftForAll(context.Background(), nil)
+	assert.ErrorContains(t, err, "No instances found")
+}
+
+func TestDetectDriftForAll_SuggestRemediation(t *testing.T) {
+	terraformInstance := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginTerraform)
+	terraformInstance.ResourceAddress = "aws_instance.web"
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{
+			model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.large"}, model.OriginAWS),
+			model.NewInstance("i-2", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginAWS),
+		}},
+		&mockInstanceProvider{instances: []*model.Instance{terraformInstance}},
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{SourceOfTruth: model.OriginTerraform, Timeout: 2 * time.Second, ParallelChecks: 1, SuggestRemediation: true},
+		logging.New(),
+	)
+
+	results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	require.NoError(t, err)
+	byID := make(map[string]*model.DriftResult)
+	for _, result := range results {
+		byID[result.ResourceID] = result
+	}
+
+	require.Len(t, byID["i-1"].Remediation, 2)
+	assert.Equal(t, "aws_instance.web", byID["i-1"].ResourceAddress)
+	assert.Equal(t, "terraform apply -target=aws_instance.web", byID["i-1"].Remediation[1].Command)
+	// Only in AWS
+	require.Len(t, byID["i-2"].Remediation, 1)
+	assert.Contains(t, byID["i-2"].Remediation[0].Snippet, `id = "i-2"`)
+
+	// Nothing is suggested unless asked
+	detector.SetSuggestRemediation(false)
+	results, err = detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.Empty(t, result.Remediation)
+	}
+}
+
+// BenchmarkDetectDriftForAll compares fetching all instances before pairing with checking
+// instances as slow providers stream them in
+func BenchmarkDetectDriftForAll(b *testing.B) {
+	var ids []string
+	for i := 0; i < 50; i++ {
+		ids = append(ids, fmt.Sprintf("i-%03d", i))
+	}
+
+	for _, parallelProviders := range []bool{false, true} {
+		b.Run(fmt.Sprintf("parallel_providers=%v", parallelProviders), func(b *testing.B) {
+			detector := app.NewDriftDetectorService(
+				&streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: streamingInstances(model.OriginAWS, ids...)}, delay: 100 * time.Microsecond},
+				&streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: streamingInstances(model.OriginTerraform, ids...)}, delay: 100 * time.Microsecond},
+				&firstSaveRepository{saved: make(chan struct{})},
+				nil,
+				service.DriftDetectorConfig{
+					SourceOfTruth:     model.OriginTerraform,
+					AttributePaths:    []string{"instance_type"},
+					Timeout:           10 * time.Second,
+					ParallelChecks:    4,
+					ParallelProviders: parallelProviders,
+				},
+				logging.New(),
+			)
+
+			for i := 0; i < b.N; i++ {
+				if _, err := detector.DetectDriftForAll(context.Background(), nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// cachingProvider records how the detector drives its state cache
+type cachingProvider struct {
+	mockInstanceProvider
+	enabled     bool
+	invalidated int
+}
+
+func (m *cachingProvider) SetCacheEnabled(enabled bool) { m.enabled = enabled }
+func (m *cachingProvider) InvalidateCache()             { m.invalidated++ }
+
+func TestStateCacheIsForwardedToTerraformProvider(t *testing.T) {
+	terraformProvider := &cachingProvider{}
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{}, terraformProvider, &mockRepository{}, nil, service.DriftDetectorConfig{}, logging.New())
+
+	detector.SetStateCacheEnabled(true)
+	assert.True(t, terraformProvider.enabled)
+	detector.InvalidateStateCache()
+	assert.Equal(t, 1, terraformProvider.invalidated)
+
+	// Providers without a cache are left alone
+	detector = app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, &mockRepository{}, nil, service.DriftDetectorConfig{}, logging.New())
+	detector.SetStateCacheEnabled(true)
+	detector.InvalidateStateCache()
+}
+
+func TestDetectDrift_StaticIPsOnly(t *testing.T) {
+	newPair := func() (*model.Instance, *model.Instance) {
+		tfInst := model.NewInstance("i-123", map[string]interface{}{
+			"private_ip": "10.0.1.10",
+			"public_ip":  "52.1.1.1",
+		}, model.OriginTerraform)
+		awsInst := model.NewInstance("i-123", map[string]interface{}{
+			"private_ip": "10.0.1.99",
+			"public_ip":  "3.3.3.3",
+		}, model.OriginAWS)
+		return tfInst, awsInst
+	}
+	paths := []string{"private_ip", "public_ip"}
+
+	detector := app.NewDriftDetectorService(nil, nil, &mockRepository{}, nil, service.DriftDetectorConfig{StaticIPsOnly: true}, logging.New())
+
+	// Dynamically assigned addresses are skipped
+	tfInst, awsInst := newPair()
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, paths)
+	assert.NoError(t, err)
+	assert.False(t, result.HasDrift)
+	assert.Contains(t, result.SkippedAttributes, "private_ip")
+	assert.Contains(t, result.SkippedAttributes, "public_ip")
+
+	// Statically assigned addresses are compared
+	tfInst, awsInst = newPair()
+	tfInst.MarkStatic("private_ip")
+	tfInst.MarkStatic("public_ip")
+	result, err = detector.DetectDrift(context.Background(), tfInst, awsInst, paths)
+	assert.NoError(t, err)
+	assert.Contains(t, result.DriftedAttributes, "private_ip")
+	assert.Contains(t, result.DriftedAttributes, "public_ip")
+	assert.Empty(t, result.SkippedAttributes)
+
+	// Disabling the policy compares every address
+	detector.SetStaticIPsOnly(false)
+	tfInst, awsInst = newPair()
+	result, err = detector.DetectDrift(context.Background(), tfInst, awsInst, paths)
+	assert.NoError(t, err)
+	assert.Len(t, result.DriftedAttributes, 2)
+}
+
+func TestDetectAndReportDriftForAll_AttributeSummary(t *testing.T) {
+	var awsInstances, tfInstances []*model.Instance
+	for _, id := range []string{"i-1", "i-2"} {
+		tfInstances = append(tfInstances, model.NewInstance(id, map[string]interface{}{"tags": map[string]interface{}{"CostCenter": "finance"}}, model.OriginTerraform))
+		awsInstances = append(awsInstances, model.NewInstance(id, map[string]interface{}{"tags": map[string]interface{}{"CostCenter": "ops"}}, model.OriginAWS))
+	}
+
+	dir := t.TempDir()
+	jsonReporter := reporter.NewJSONReporter(logging.New(), reporter.ReporterOptions{})
+	jsonReporter.SetOutputFile(filepath.Join(dir, "report.json"))
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: awsInstances},
+		&mockInstanceProvider{instances: tfInstances},
+		&mockRepository{},
+		[]service.Reporter{jsonReporter},
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"tags.CostCenter"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 2,
+		},
+		logging.New(),
+	)
+
+	err := detector.DetectAndReportDriftForAll(context.Background(), nil)
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	require.NoError(t, err)
+	var report reporter.JSONReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Len(t, report.Results, 2)
+	assert.Equal(t, []model.AttributeSummary{
+		{Path: "tags.CostCenter", DriftedInstances: 2, SampleValues: []interface{}{"ops"}},
+	}, report.AttributeSummary)
+}
+
+func TestDetectAndReportDriftForAll_CleanReport(t *testing.T) {
+	var awsInstances, tfInstances []*model.Instance
+	for _, id := range []string{"i-1", "i-2", "i-3"} {
+		tfInstances = append(tfInstances, model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform))
+		awsInstances = append(awsInstances, model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS))
+	}
+
+	dir := t.TempDir()
+	jsonReporter := reporter.NewJSONReporter(logging.New(), reporter.ReporterOptions{PrettyPrint: true})
+	jsonReporter.SetOutputFile(filepath.Join(dir, "report.json"))
+	jsonReporter.EnableCleanReport([]string{"instance_type"}, "")
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: awsInstances},
+		&mockInstanceProvider{instances: tfInstances},
+		&mockRepository{},
+		[]service.Reporter{jsonReporter},
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 1,
+		},
+		logging.New(),
+	)
+	require.NoError(t, detector.DetectAndReportDriftForAll(context.Background(), nil))
+
+	data, err := os.ReadFile(filepath.Join(dir, "report_clean.json"))
+	require.NoError(t, err)
+	var clean reporter.CleanReport
+	require.NoError(t, json.Unmarshal(data, &clean))
+
+	// Zero drift, yet every checked instance is listed
+	assert.Equal(t, reporter.CleanReportStatus, clean.Status)
+	assert.Equal(t, 3, clean.TotalInstances)
+	ids := make([]string, 0, len(clean.Instances))
+	for _, instance := range clean.Instances {
+		ids = append(ids, instance.ResourceID)
+	}
+	assert.ElementsMatch(t, []string{"i-1", "i-2", "i-3"}, ids)
+	assert.True(t, clean.Verify(""))
+}
+
+// versionedProvider is a Terraform provider that knows which Terraform wrote its state
+type versionedProvider struct {
+	mockInstanceProvider
+	version string
+}
+
+func (p *versionedProvider) TerraformVersion() string {
+	return p.version
+}
+
+func TestRunDriftCheck_TerraformVersion(t *testing.T) {
+	tfInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS)
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{awsInst}},
+		&versionedProvider{mockInstanceProvider: mockInstanceProvider{instances: []*model.Instance{tfInst}}, version: "1.9.5"},
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 1,
+		},
+		logging.New(),
+	)
+
+	summary, err := detector.RunDriftCheck(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.9.5", summary.TerraformVersion)
+}
+
+// fakeTerraformProvider is a Terraform provider backed by instances in memory, recording how
+// the detector reads them
+type fakeTerraformProvider struct {
+	instances map[string]*model.Instance
+	order     []string
+	gets      []string
+	listed    int
+	streamed  int
+}
+
+func newFakeTerraformProvider(instances ...*model.Instance) *fakeTerraformProvider {
+	p := &fakeTerraformProvider{instances: make(map[string]*model.Instance)}
+	for _, instance := range instances {
+		p.instances[instance.ID] = instance
+		p.order = append(p.order, instance.ID)
+	}
+	return p
+}
+
+func (p *fakeTerraformProvider) GetInstance(ctx context.Context, id string) (*model.Instance, error) {
+	p.gets = append(p.gets, id)
+	if instance, ok := p.instances[id]; ok {
+		return instance, nil
+	}
+	return nil, apperrors.NewNotFoundError("EC2 Instance", id)
+}
+
+func (p *fakeTerraformProvider) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	p.listed++
+	instances := make([]*model.Instance, 0, len(p.order))
+	for _, id := range p.order {
+		instances = append(instances, p.instances[id])
+	}
+	return instances, nil
+}
+
+func (p *fakeTerraformProvider) StreamInstances(ctx context.Context, emit func(*model.Instance) error) error {
+	p.streamed++
+	for _, id := range p.order {
+		if err := emit(p.instances[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDriftDetector_FakeTerraformProvider(t *testing.T) {
+	terraformProvider := newFakeTerraformProvider(
+		model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform),
+		model.NewInstance("i-2", map[string]interface{}{"instance_type": "t3.small"}, model.OriginTerraform),
+	)
+	awsProvider := &mockInstanceProvider{instances: []*model.Instance{
+		model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS),
+		model.NewInstance("i-2", map[string]interface{}{"instance_type": "t3.large"}, model.OriginAWS),
+	}}
+	cfg := service.DriftDetectorConfig{
+		SourceOfTruth:  model.OriginTerraform,
+		AttributePaths: []string{"instance_type"},
+		Timeout:        2 * time.Second,
+		ParallelChecks: 1,
+	}
+
+	detector := app.NewDriftDetectorService(awsProvider, terraformProvider, &mockRepository{}, nil, cfg, logging.New())
+	result, err := detector.DetectDriftByID(context.Background(), "i-1", cfg.AttributePaths)
+	require.NoError(t, err)
+	assert.False(t, result.HasDrift)
+	assert.Equal(t, []string{"i-1"}, terraformProvider.gets)
+
+	results, err := detector.DetectDriftForAll(context.Background(), cfg.AttributePaths)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 1, terraformProvider.listed)
+	byID := make(map[string]*model.DriftResult)
+	for _, result := range results {
+		byID[result.ResourceID] = result
+	}
+	assert.False(t, byID["i-1"].HasDrift)
+	assert.Contains(t, byID["i-2"].DriftedAttributes, "instance_type")
+
+	// Providers are streamed when they are read in parallel
+	cfg.ParallelProviders = true
+	detector = app.NewDriftDetectorService(awsProvider, terraformProvider, &mockRepository{}, nil, cfg, logging.New())
+	results, err = detector.DetectDriftForAll(context.Background(), cfg.AttributePaths)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 1, terraformProvider.streamed)
+
+	// Capabilities the fake doesn't have are reported rather than assumed
+	summary, err := detector.RunDriftCheck(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, summary.TerraformVersion)
+	_, err = detector.DetectOrphans(context.Background())
+	assert.True(t, apperrors.IsValidationError(err))
+}
+
+func TestRegisterComparator_OverridesAttributeComparison(t *testing.T) {
+	newPair := func(id, terraformAMI, awsAMI string) (*model.Instance, *model.Instance) {
+		return model.NewInstance(id, map[string]interface{}{"ami": awsAMI, "instance_type": "t3.micro"}, model.OriginAWS),
+			model.NewInstance(id, map[string]interface{}{"ami": terraformAMI, "instance_type": "t3.small"}, model.OriginTerraform)
+	}
+	awsRebuilt, terraformRebuilt := newPair("i-rebuilt", "ami-0aaa", "ami-0bbb")
+	awsReplaced, terraformReplaced := newPair("i-replaced", "ami-0aaa", "ami-0ccc")
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{awsRebuilt, awsReplaced}},
+		&mockInstanceProvider{instances: []*model.Instance{terraformRebuilt, terraformReplaced}},
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"ami", "instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 1,
+		},
+		logging.New(),
+	)
+
+	// ami-0bbb is a nightly rebuild of ami-0aaa, so instances launched from either are in sync
+	rebuilds := map[string]string{"ami-0bbb": "ami-0aaa"}
+	family := func(ami interface{}) interface{} {
+		if base, ok := rebuilds[ami.(string)]; ok {
+			return base
+		}
+		return ami
+	}
+	var calls []string
+	var mu sync.Mutex
+	detector.RegisterComparator("ami", func(a, b interface{}) bool {
+		mu.Lock()
+		calls = append(calls, fmt.Sprintf("%v=%v", a, b))
+		mu.Unlock()
+		return family(a) == family(b)
+	})
+
+	results, err := detector.DetectDriftForAll(context.Background(), []string{"ami", "instance_type"})
+	require.NoError(t, err)
+	byID := make(map[string]*model.DriftResult)
+	for _, result := range results {
+		byID[result.ResourceID] = result
+	}
+	assert.NotContains(t, byID["i-rebuilt"].DriftedAttributes, "ami")
+	assert.Contains(t, byID["i-replaced"].DriftedAttributes, "ami")
+	// Other attributes keep the default comparison, and the source of truth comes first
+	assert.Contains(t, byID["i-rebuilt"].DriftedAttributes, "instance_type")
+	assert.ElementsMatch(t, []string{"ami-0aaa=ami-0bbb", "ami-0aaa=ami-0ccc"}, calls)
+
+	// A nil function restores the default comparison
+	detector.RegisterComparator("ami", nil)
+	results, err = detector.DetectDriftForAll(context.Background(), []string{"ami"})
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.Contains(t, result.DriftedAttributes, "ami")
+	}
+}
+
+func TestRunScheduledDriftCheck_TracksLastRun(t *testing.T) {
+	tfInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.large"}, model.OriginAWS)
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{awsInst}},
+		&mockInstanceProvider{instances: []*model.Instance{tfInst}},
+		&mockRepository{},
+		[]service.Reporter{&mockReporter{}},
+		service.DriftDetectorConfig{
+			SourceOfTruth:      model.OriginTerraform,
+			AttributePaths:     []string{"instance_type"},
+			Timeout:            2 * time.Second,
+			ParallelChecks:     1,
+			ScheduleExpression: "0 */6 * * *",
+		},
+		logging.New(),
+	)
+
+	assert.Nil(t, detector.GetSchedulerStatus().LastRun)
+
+	assert.NoError(t, detector.RunScheduledDriftCheck(context.Background()))
+	lastRun := detector.GetSchedulerStatus().LastRun
+	assert.NotNil(t, lastRun)
+	assert.True(t, lastRun.Succeeded())
+	assert.Equal(t, 1, lastRun.TotalInstances)
+	assert.Equal(t, 1, lastRun.DriftedCount)
+
+	// Next run is only known while the scheduler is running
+	assert.Nil(t, detector.GetSchedulerStatus().NextRun)
+	assert.NoError(t, detector.StartScheduler(context.Background()))
+	status := detector.GetSchedulerStatus()
+	assert.True(t, status.Running)
+	assert.NotNil(t, status.NextRun)
+	assert.Len(t, status.UpcomingRuns, 3)
+	assert.True(t, status.NextRun.Equal(status.UpcomingRuns[0]))
+	assert.True(t, status.UpcomingRuns[2].After(status.UpcomingRuns[1]))
+	detector.StopScheduler()
+	assert.False(t, detector.GetSchedulerStatus().Running)
+}
+
+func TestDetectDrift_SourceDeclaredOnly(t *testing.T) {
+	tfInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type": "t2.micro",
+		"key_name":      nil,
+	}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type": "t2.micro",
+		"key_name":      "default-key",
+		"monitoring":    "disabled",
+	}, model.OriginAWS)
+	paths := []string{"instance_type", "key_name", "monitoring"}
+
+	detector := app.NewDriftDetectorService(nil, nil, &mockRepository{}, nil, service.DriftDetectorConfig{}, logging.New())
+
+	// Without the flag, AWS-only attributes are drift
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, paths)
+	assert.NoError(t, err)
+	assert.Contains(t, result.DriftedAttributes, "key_name")
+	assert.Contains(t, result.DriftedAttributes, "monitoring")
+
+	// With the flag, only attributes declared by the source are compared
+	detector.SetSourceDeclaredOnly(true)
+	result, err = detector.DetectDrift(context.Background(), tfInst, awsInst, paths)
+	assert.NoError(t, err)
+	assert.False(t, result.HasDrift)
+}
+
+func TestDetectDrift_RecordsNameTag(t *testing.T) {
+	tfInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type": "t2.micro",
+		"tags":          map[string]interface{}{"Name": "web-1"},
+	}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type": "t2.micro",
+		"tags":          map[string]interface{}{"Name": "web-1-old"},
+	}, model.OriginAWS)
+
+	detector := app.NewDriftDetectorService(nil, nil, &mockRepository{}, nil, service.DriftDetectorConfig{}, logging.New())
+
+	// The Name tag is recorded even when tags are not compared
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.False(t, result.HasDrift)
+	assert.Equal(t, "web-1", result.SourceName)
+	assert.Equal(t, "web-1-old", result.TargetName)
+	assert.True(t, result.NameDrifted())
+	assert.Equal(t, "web-1 → web-1-old (i-123)", result.Label())
+}
+
+// slowInstanceProvider returns its instances after a delay, honouring context cancellation
+type slowInstanceProvider struct {
+	mockInstanceProvider
+	delay time.Duration
+}
+
+func (m *slowInstanceProvider) wait(ctx context.Context) error {
+	select {
+	case <-time.After(m.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *slowInstanceProvider) GetInstance(ctx context.Context, id string) (*model.Instance, error) {
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+	return m.mockInstanceProvider.GetInstance(ctx, id)
+}
+
+func (m *slowInstanceProvider) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+	return m.mockInstanceProvider.ListInstances(ctx)
+}
+
+func TestDetectDrift_PerProviderTimeouts(t *testing.T) {
+	awsInst := model.NewInstance("i-123", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS)
+	tfInst := model.NewInstance("i-123", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+
+	newDetector := func(awsTimeout, terraformTimeout time.Duration) *app.DriftDetectorService {
+		return app.NewDriftDetectorService(
+			&mockInstanceProvider{instances: []*model.Instance{awsInst}},
+			&slowInstanceProvider{mockInstanceProvider: mockInstanceProvider{instances: []*model.Instance{tfInst}}, delay: 100 * time.Millisecond},
+			&mockRepository{}, nil,
+			service.DriftDetectorConfig{
+				SourceOfTruth:    model.OriginTerraform,
+				ParallelChecks:   1,
+				Timeout:          time.Second,
+				AWSTimeout:       awsTimeout,
+				TerraformTimeout: terraformTimeout,
+			},
+			logging.New(),
+		)
+	}
+
+	t.Run("slow provider within its own budget", func(t *testing.T) {
+		detector := newDetector(20*time.Millisecond, 500*time.Millisecond)
+
+		_, err := detector.DetectDriftByID(context.Background(), "i-123", []string{"instance_type"})
+		assert.NoError(t, err)
+
+		results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("slow provider over its own budget", func(t *testing.T) {
+		detector := newDetector(20*time.Millisecond, 50*time.Millisecond)
+
+		_, err := detector.DetectDriftByID(context.Background(), "i-123", []string{"instance_type"})
+		assert.Error(t, err)
+	})
+
+	t.Run("overall timeout bounds provider budgets", func(t *testing.T) {
+		detector := newDetector(20*time.Millisecond, 500*time.Millisecond)
+		detector.SetTimeout(50 * time.Millisecond)
+
+		_, err := detector.DetectDriftByID(context.Background(), "i-123", []string{"instance_type"})
+		assert.Error(t, err)
+	})
+}
+
+func TestDetectDrift_UsesClock(t *testing.T) {
+	now := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	fake := clock.NewFake(now)
+
+	tfInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.large"}, model.OriginAWS)
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{awsInst}},
+		&mockInstanceProvider{instances: []*model.Instance{tfInst}},
+		&mockRepository{},
+		[]service.Reporter{&mockReporter{}},
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 1,
+			Clock:          fake,
+		},
+		logging.New(),
+	)
+
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.Equal(t, now, result.Timestamp)
+
+	assert.NoError(t, detector.RunScheduledDriftCheck(context.Background()))
+	lastRun := detector.GetSchedulerStatus().LastRun
+	assert.Equal(t, now, lastRun.StartedAt)
+	assert.Equal(t, now, lastRun.FinishedAt)
+}
+
+// resourceInstanceProvider is an AWS provider that also lists attachable resources
+type resourceInstanceProvider struct {
+	mockInstanceProvider
+	resources []*model.Resource
+}
+
+func (m *resourceInstanceProvider) ListResources(ctx context.Context) ([]*model.Resource, error) {
+	return m.resources, nil
+}
+
+// managedInstanceProvider is a Terraform provider that also reports the resources it manages
+type managedInstanceProvider struct {
+	mockInstanceProvider
+	managed map[string]bool
+}
+
+func (m *managedInstanceProvider) ListManagedResourceIDs(ctx context.Context) (map[string]bool, error) {
+	return m.managed, nil
+}
+
+type orphanReporter struct {
+	mockReporter
+	orphans []*model.OrphanResult
+}
+
+func (m *orphanReporter) ReportOrphans(orphans []*model.OrphanResult) error {
+	m.orphans = orphans
+	return nil
+}
+
+func TestDetectAndReportDriftForAll_ReportsOrphans(t *testing.T) {
+	awsInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS)
+	tfInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+
+	awsProvider := &resourceInstanceProvider{
+		mockInstanceProvider: mockInstanceProvider{instances: []*model.Instance{awsInst}},
+		resources: []*model.Resource{
+			model.NewResource("vol-root", model.ResourceTypeVolume, []string{"i-1"}, nil),
+			model.NewResource("vol-orphan", model.ResourceTypeVolume, nil, nil),
+			model.NewResource("eipalloc-managed", model.ResourceTypeElasticIP, nil, nil),
+		},
+	}
+	tfProvider := &managedInstanceProvider{
+		mockInstanceProvider: mockInstanceProvider{instances: []*model.Instance{tfInst}},
+		managed:              map[string]bool{"eipalloc-managed": true},
+	}
+
+	reporter := &orphanReporter{}
+	detector := app.NewDriftDetectorService(
+		awsProvider,
+		tfProvider,
+		&mockRepository{},
+		[]service.Reporter{reporter},
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 1,
+		},
+		logging.New(),
+	)
+
+	// Orphan detection is opt-in
+	assert.NoError(t, detector.DetectAndReportDriftForAll(context.Background(), nil))
+	assert.Nil(t, reporter.orphans)
+
+	detector.SetCheckOrphans(true)
+	assert.NoError(t, detector.DetectAndReportDriftForAll(context.Background(), nil))
+	assert.Len(t, reporter.orphans, 1)
+	assert.Equal(t, "vol-orphan", reporter.orphans[0].ResourceID)
+	assert.Equal(t, model.ResourceTypeVolume, reporter.orphans[0].ResourceType)
+}
+
+func TestDetectOrphans_RequiresResourceProviders(t *testing.T) {
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{},
+		&mockInstanceProvider{},
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{Timeout: time.Second},
+		logging.New(),
+	)
+
+	_, err := detector.DetectOrphans(context.Background())
+	assert.Error(t, err)
+}
+
+func TestReportStoredResults_RendersThroughJSONReporter(t *testing.T) {
+	repo := repository.NewInMemoryDriftRepository(logging.New())
+	base := time.Date(2024, 4, 22, 12, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"i-old", "i-2", "i-1"} {
+		result := model.NewDriftResultAt(id, model.OriginTerraform, base.Add(time.Duration(i)*time.Hour))
+		if id != "i-2" {
+			result.AddDriftedAttribute("instance_type", "t2.micro", "t2.large")
+		}
+		assert.NoError(t, repo.SaveDriftResult(context.Background(), result))
+	}
+
+	// Reporters configured for detection are not used when re-rendering
+	configured := &mockReporter{}
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{},
+		&mockInstanceProvider{},
+		repo,
+		[]service.Reporter{configured},
+		service.DriftDetectorConfig{Timeout: time.Second},
+		logging.New(),
+	)
+
+	dir := t.TempDir()
+	jsonReporter := reporter.NewJSONReporter(logging.New(), reporter.ReporterOptions{OutputFile: filepath.Join(dir, "report.json"), PrettyPrint: true})
+
+	err := detector.ReportStoredResults(context.Background(), base.Add(30*time.Minute), []service.Reporter{jsonReporter})
+	assert.NoError(t, err)
+	assert.Empty(t, configured.reported)
+
+	files, err := filepath.Glob(filepath.Join(dir, "report*.json"))
+	assert.NoError(t, err)
+	if !assert.Len(t, files, 1) {
+		return
+	}
+
+	data, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+
+	var report reporter.JSONReport
+	assert.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, 2, report.TotalInstances)
+	assert.Equal(t, 1, report.DriftedCount)
+	if assert.Len(t, report.Results, 2) {
+		assert.Equal(t, "i-2", report.Results[0].ResourceID)
+		assert.Equal(t, "i-1", report.Results[1].ResourceID)
+	}
+}
+
+func TestShowStoredResult(t *testing.T) {
+	repo := repository.NewInMemoryDriftRepository(logging.New())
+	base := time.Date(2024, 4, 22, 12, 0, 0, 0, time.UTC)
+
+	var saved []*model.DriftResult
+	for i, id := range []string{"i-1", "i-1", "i-2"} {
+		result := model.NewDriftResultAt(id, model.OriginTerraform, base.Add(time.Duration(i)*time.Hour))
+		assert.NoError(t, repo.SaveDriftResult(context.Background(), result))
+		saved = append(saved, result)
+	}
+
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, repo, nil,
+		service.DriftDetectorConfig{Timeout: time.Second}, logging.New())
+
+	// By result ID
+	shown := &mockReporter{}
+	assert.NoError(t, detector.ShowStoredResult(context.Background(), saved[0].ID, []service.Reporter{shown}))
+	assert.Equal(t, []*model.DriftResult{saved[0]}, shown.reported)
+
+	// By instance ID, the latest result
+	shown = &mockReporter{}
+	assert.NoError(t, detector.ShowStoredResult(context.Background(), "i-1", []service.Reporter{shown}))
+	assert.Equal(t, []*model.DriftResult{saved[1]}, shown.reported)
+
+	err := detector.ShowStoredResult(context.Background(), "i-missing", []service.Reporter{shown})
+	assert.True(t, apperrors.IsNotFoundError(err))
+}
+
+func TestDetectDrift_PolicyViolations(t *testing.T) {
+	now := time.Date(2024, 4, 22, 12, 0, 0, 0, time.UTC)
+	awsInst := model.NewInstance("i-1", map[string]interface{}{
+		"instance_type":           "t2.micro",
+		model.AttributeLaunchTime: now.AddDate(0, 0, -100).Format(time.RFC3339),
+	}, model.OriginAWS)
+	tfInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{awsInst}},
+		&mockInstanceProvider{instances: []*model.Instance{tfInst}},
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 1,
+			Clock:          clock.NewFake(now),
+			Policies:       []model.Policy{{Path: model.AttributeAgeDays, Operator: model.PolicyOperatorLessThan, Value: 90}},
+		},
+		logging.New(),
+	)
+
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.False(t, result.HasDrift)
+	if assert.Len(t, result.PolicyViolations, 1) {
+		assert.Equal(t, 100, result.PolicyViolations[0].Actual)
+	}
+
+	detector.SetPolicies(nil)
+	result, err = detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.Empty(t, result.PolicyViolations)
+}
+
+func TestDetectDrift_AllowedInstanceTypes(t *testing.T) {
+	awsInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "m5.4xlarge"}, model.OriginAWS)
+	tfInst := model.NewInstance("i-1", map[string]interface{}{"instance_type": "m5.4xlarge"}, model.OriginTerraform)
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{awsInst}},
+		&mockInstanceProvider{instances: []*model.Instance{tfInst}},
+		&mockRepository{},
+		nil,
+		service.DriftDetectorConfig{
+			SourceOfTruth:        model.OriginTerraform,
+			AttributePaths:       []string{"instance_type"},
+			Timeout:              2 * time.Second,
+			ParallelChecks:       1,
+			AllowedInstanceTypes: []string{"t3.micro", "t3.small"},
+		},
+		logging.New(),
+	)
+
+	// Terraform and AWS agree, so the disallowed type is a violation but not drift
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.False(t, result.HasDrift)
+	if assert.Len(t, result.PolicyViolations, 1) {
+		assert.Equal(t, "instance_type", result.PolicyViolations[0].Path)
+		assert.Equal(t, "m5.4xlarge", result.PolicyViolations[0].Actual)
+	}
+
+	detector.SetAllowedInstanceTypes([]string{"m5.4xlarge"})
+	result, err = detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.Empty(t, result.PolicyViolations)
+}
+
+func TestDetectDrift_LogsEachDriftedAttribute(t *testing.T) {
+	awsInstances := []*model.Instance{
+		model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.large", "ami": "ami-new"}, model.OriginAWS),
+		model.NewInstance("i-2", map[string]interface{}{"instance_type": "t2.large", "ami": "ami-old"}, model.OriginAWS),
+	}
+	tfInstances := []*model.Instance{
+		model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro", "ami": "ami-old"}, model.OriginTerraform),
+		model.NewInstance("i-2", map[string]interface{}{"instance_type": "t2.micro", "ami": "ami-old"}, model.OriginTerraform),
+	}
+
+	newDetector := func(level logging.LogLevel, buf *bytes.Buffer) *app.DriftDetectorService {
+		return app.NewDriftDetectorService(
+			&mockInstanceProvider{instances: awsInstances},
+			&mockInstanceProvider{instances: tfInstances},
+			&mockRepository{},
+			nil,
+			service.DriftDetectorConfig{
+				SourceOfTruth:  model.OriginTerraform,
+				AttributePaths: []string{"instance_type", "ami"},
+				Timeout:        2 * time.Second,
+				ParallelChecks: 1,
+			},
+			logging.NewLogger(logging.LogConfig{Level: level, Output: buf, JSONFormat: true}),
+		)
+	}
+
+	var buf bytes.Buffer
+	_, err := newDetector(logging.Debug, &buf).DetectDriftForAll(context.Background(), []string{"instance_type", "ami"})
+	assert.NoError(t, err)
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry["@message"] == "Drift detected" {
+			lines = append(lines, entry)
+		}
+	}
+
+	// One line per drifted attribute, all tagged with the same run ID
+	if assert.Len(t, lines, 3) {
+		runID := lines[0]["run_id"]
+		assert.NotEmpty(t, runID)
+		for _, line := range lines {
+			assert.Equal(t, runID, line["run_id"])
+			assert.Equal(t, "debug", line["@level"])
+			assert.Contains(t, line, "instance_id")
+			assert.Contains(t, line, "attribute")
+			assert.Contains(t, line, "source")
+			assert.Contains(t, line, "target")
+		}
+	}
+
+	// Nothing is logged at info level
+	buf.Reset()
+	_, err = newDetector(logging.Info, &buf).DetectDriftForAll(context.Background(), []string{"instance_type", "ami"})
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "Drift detected")
+}
+
+type recordingDumper struct {
+	aws, terraform *model.Instance
+	err            error
+}
+
+func (d *recordingDumper) DumpAttributes(instanceID string, aws, terraform *model.Instance) error {
+	d.aws, d.terraform = aws, terraform
+	return d.err
+}
+
+func TestDetectDrift_DumpsAttributesByProvider(t *testing.T) {
+	tfInst := model.NewInstance("i-123", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-123", map[string]interface{}{"instance_type": "t2.large"}, model.OriginAWS)
+
+	dumper := &recordingDumper{}
+	detector := app.NewDriftDetectorService(nil, nil, &mockRepository{}, nil, service.DriftDetectorConfig{AttributeDumper: dumper}, logging.New())
+
+	_, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.Same(t, awsInst, dumper.aws)
+	assert.Same(t, tfInst, dumper.terraform)
+
+	// With AWS as the source of truth the instances are still filed by provider
+	_, err = detector.DetectDrift(context.Background(), awsInst, tfInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.Same(t, awsInst, dumper.aws)
+	assert.Same(t, tfInst, dumper.terraform)
+
+	// A failing dump doesn't fail the check
+	dumper.err = errors.New("disk full")
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.True(t, result.HasDrift)
+}
+
+func TestDetectDrift_InstanceState(t *testing.T) {
+	tfInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type":              "t3.micro",
+		model.AttributeInstanceState: model.InstanceStateRunning,
+	}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type":              "t3.micro",
+		model.AttributeInstanceState: model.InstanceStateStopped,
+	}, model.OriginAWS)
+
+	detector := app.NewDriftDetectorService(nil, nil, &mockRepository{}, nil, service.DriftDetectorConfig{}, logging.New())
+
+	// A stopped instance that should be running is drift
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{model.AttributeInstanceState})
+	assert.NoError(t, err)
+	assert.True(t, result.HasDrift)
+	assert.Equal(t, model.InstanceStateRunning, result.DriftedAttributes[model.AttributeInstanceState].SourceValue)
+	assert.Equal(t, model.InstanceStateStopped, result.DriftedAttributes[model.AttributeInstanceState].TargetValue)
+
+	// It is only compared when listed in the attributes
+	result, err = detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+	assert.False(t, result.HasDrift)
+
+	awsInst.Attributes[model.AttributeInstanceState] = model.InstanceStateRunning
+	result, err = detector.DetectDrift(context.Background(), tfInst, awsInst, []string{model.AttributeInstanceState})
+	assert.NoError(t, err)
+	assert.False(t, result.HasDrift)
+}
+
+func TestDetectDrift_InstanceLifecycle(t *testing.T) {
+	// Terraform declares an on-demand instance that was recreated as spot
+	tfInst := model.NewInstance("i-123", map[string]interface{}{
+		model.AttributeInstanceLifecycle: model.InstanceLifecycleOnDemand,
+	}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-123", map[string]interface{}{
+		model.AttributeInstanceLifecycle:     model.InstanceLifecycleSpot,
+		model.AttributeSpotInstanceRequestID: "sir-abcd1234",
+	}, model.OriginAWS)
+
+	detector := app.NewDriftDetectorService(nil, nil, &mockRepository{}, nil, service.DriftDetectorConfig{}, logging.New())
+
+	// The lifecycle is compared by value on both sides rather than as a missing attribute
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{model.AttributeInstanceLifecycle})
+	assert.NoError(t, err)
+	assert.True(t, result.HasDrift)
+	assert.Equal(t, model.InstanceLifecycleOnDemand, result.DriftedAttributes[model.AttributeInstanceLifecycle].SourceValue)
+	assert.Equal(t, model.InstanceLifecycleSpot, result.DriftedAttributes[model.AttributeInstanceLifecycle].TargetValue)
+
+	tfInst.Attributes[model.AttributeInstanceLifecycle] = model.InstanceLifecycleSpot
+	result, err = detector.DetectDrift(context.Background(), tfInst, awsInst, []string{model.AttributeInstanceLifecycle})
+	assert.NoError(t, err)
+	assert.False(t, result.HasDrift)
+}
+
+func TestDetectAndReportDrift_StripsVolatileAttributes(t *testing.T) {
+	awsInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type":   "t2.micro",
+		"launch_time":     "2026-10-16T09:00:00Z",
+		"public_dns_name": "ec2-1-2-3-4.compute.amazonaws.com",
+	}, model.OriginAWS)
+	tfInst := model.NewInstance("i-123", map[string]interface{}{
+		"instance_type":   "t2.small",
+		"launch_time":     "2026-10-01T09:00:00Z",
+		"public_dns_name": "ec2-5-6-7-8.compute.amazonaws.com",
+	}, model.OriginTerraform)
+	repo := &mockRepository{}
+	reporter := &mockReporter{}
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{awsInst}},
+		&mockInstanceProvider{instances: []*model.Instance{tfInst}},
+		repo,
+		[]service.Reporter{reporter},
+		service.DriftDetectorConfig{
+			SourceOfTruth:      model.OriginTerraform,
+			AttributePaths:     []string{"instance_type", "launch_time", "public_dns_name"},
+			VolatileAttributes: []string{"launch_time", "public_dns_name"},
+		},
+		logging.New(),
+	)
+
+	err := detector.DetectAndReportDrift(context.Background(), "i-123", nil)
+	assert.NoError(t, err)
+
+	// Reports still show every drift
+	assert.Len(t, reporter.reported, 1)
+	assert.Len(t, reporter.reported[0].DriftedAttributes, 3)
+
+	// The stored result leaves the volatile attributes out
+	assert.Len(t, repo.saved, 1)
+	assert.Contains(t, repo.saved[0].DriftedAttributes, "instance_type")
+	assert.NotContains(t, repo.saved[0].DriftedAttributes, "launch_time")
+	assert.NotContains(t, repo.saved[0].DriftedAttributes, "public_dns_name")
+	assert.True(t, repo.saved[0].HasDrift)
+}
+
+func TestRunDriftCheck_FetchTimings(t *testing.T) {
+	newInstances := func(origin model.ResourceOrigin) []*model.Instance {
+		return []*model.Instance{
+			model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, origin),
+			model.NewInstance("i-2", map[string]interface{}{"instance_type": "t2.micro"}, origin),
+		}
+	}
+	delay := 10 * time.Millisecond
+
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel providers %t", parallel), func(t *testing.T) {
+			detector := app.NewDriftDetectorService(
+				&streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: newInstances(model.OriginAWS)}, delay: delay},
+				&mockInstanceProvider{instances: newInstances(model.OriginTerraform)},
+				&mockRepository{},
+				nil,
+				service.DriftDetectorConfig{
+					SourceOfTruth:     model.OriginTerraform,
+					AttributePaths:    []string{"instance_type"},
+					Timeout:           2 * time.Second,
+					ParallelChecks:    1,
+					ParallelProviders: parallel,
+				},
+				logging.New(),
+			)
+
+			summary, err := detector.RunDriftCheck(context.Background(), nil)
+			require.NoError(t, err)
+			require.NotNil(t, summary.FetchTimings)
+			assert.GreaterOrEqual(t, summary.FetchTimings.AWS, 2*delay)
+			assert.GreaterOrEqual(t, summary.FetchTimings.Terraform, time.Duration(0))
+			assert.Contains(t, summary.String(), "AWS listed in")
+		})
+	}
+}
+
+func TestDetectDriftForAll_IncludeSnapshots(t *testing.T) {
+	detect := func(t *testing.T, include bool) (map[string]*model.DriftResult, *mockRepository) {
+		repo := &mockRepository{}
+		detector := app.NewDriftDetectorService(
+			&mockInstanceProvider{instances: []*model.Instance{
+				model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.large", "ami": "ami-1"}, model.OriginAWS),
+				model.NewInstance("i-2", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS),
+			}},
+			&mockInstanceProvider{instances: []*model.Instance{
+				model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro", "ami": "ami-1"}, model.OriginTerraform),
+			}},
+			repo,
+			nil,
+			service.DriftDetectorConfig{
+				SourceOfTruth:    model.OriginTerraform,
+				AttributePaths:   []string{"instance_type"},
+				Timeout:          2 * time.Second,
+				IncludeSnapshots: include,
+			},
+			logging.New(),
+		)
+
+		results, err := detector.DetectDriftForAll(context.Background(), []string{"instance_type"})
+		require.NoError(t, err)
+		byID := make(map[string]*model.DriftResult)
+		for _, result := range results {
+			byID[result.ResourceID] = result
+		}
+		require.Len(t, byID, 2)
+		return byID, repo
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		results, _ := detect(t, false)
+		for _, result := range results {
+			assert.Nil(t, result.SourceSnapshot)
+			assert.Nil(t, result.TargetSnapshot)
+		}
+		data, err := json.Marshal(results["i-1"])
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "snapshot")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		results, repo := detect(t, true)
+
+		// Snapshots carry every attribute, not just the drifted ones
+		drifted := results["i-1"]
+		require.NotNil(t, drifted.SourceSnapshot)
+		require.NotNil(t, drifted.TargetSnapshot)
+		assert.Equal(t, model.OriginTerraform, drifted.SourceSnapshot.Origin)
+		assert.Equal(t, map[string]interface{}{"instance_type": "t2.micro", "ami": "ami-1"}, drifted.SourceSnapshot.Attributes)
+		assert.Equal(t, model.OriginAWS, drifted.TargetSnapshot.Origin)
+		assert.Equal(t, "t2.large", drifted.TargetSnapshot.Attributes["instance_type"])
+
+		// An instance missing from the source of truth only has its AWS snapshot
+		missing := results["i-2"]
+		assert.Nil(t, missing.SourceSnapshot)
+		require.NotNil(t, missing.TargetSnapshot)
+		assert.Equal(t, model.OriginAWS, missing.TargetSnapshot.Origin)
+
+		// Snapshots are reported but never stored
+		require.Len(t, repo.saved, 2)
+		for _, saved := range repo.saved {
+			assert.Nil(t, saved.SourceSnapshot)
+			assert.Nil(t, saved.TargetSnapshot)
+		}
+	})
+}
+
+func TestDetectDriftForAll_ExistenceFollowsSourceOfTruth(t *testing.T) {
+	// i-2 is only in AWS and i-3 only in Terraform
+	for _, sourceOfTruth := range []model.ResourceOrigin{model.OriginTerraform, model.OriginAWS} {
+		t.Run(string(sourceOfTruth), func(t *testing.T) {
+			awsProvider := &mockInstanceProvider{instances: streamingInstances(model.OriginAWS, "i-1", "i-2")}
+			terraformProvider := &mockInstanceProvider{instances: streamingInstances(model.OriginTerraform, "i-1", "i-3")}
+
+			detector := app.NewDriftDetectorService(awsProvider, terraformProvider, &mockRepository{}, nil, service.DriftDetectorConfig{
+				SourceOfTruth:  sourceOfTruth,
+				AttributePaths: []string{"instance_type"},
+				Timeout:        2 * time.Second,
+				ParallelChecks: 1,
+			}, logging.New())
+
+			results, err := detector.DetectDriftForAll(context.Background(), nil)
+			require.NoError(t, err)
+
+			byID := make(map[string]*model.DriftResult)
+			for _, result := range results {
+				byID[result.ResourceID] = result
+			}
+
+			// The desired value is whether the source of truth has the instance
+			onlyInAWS := byID["i-2"].DriftedAttributes[model.AttributeExists]
+			onlyInTerraform := byID["i-3"].DriftedAttributes[model.AttributeExists]
+			desired, current := onlyInAWS.DesiredAndCurrent()
+			assert.Equal(t, sourceOfTruth == model.OriginAWS, desired)
+			assert.Equal(t, sourceOfTruth == model.OriginTerraform, current)
+			desired, current = onlyInTerraform.DesiredAndCurrent()
+			assert.Equal(t, sourceOfTruth == model.OriginTerraform, desired)
+			assert.Equal(t, sourceOfTruth == model.OriginAWS, current)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// accountGuard compares the AWS account and region the Terraform state points at with the
+// ones the AWS client reads from, so that a state of another account or region isn't reported
+// as every instance having drifted. A nil accountGuard checks nothing.
+type accountGuard struct {
+	accountID string
+	region    string
+	strict    bool
+	checked   bool
+	logger    *logging.Logger
+}
+
+// newAccountGuard looks up the account and region of the AWS provider. It returns nil when
+// the provider can't tell, as with several accounts, or the lookup fails.
+func (s *DriftDetectorService) newAccountGuard(ctx context.Context) *accountGuard {
+	provider, ok := s.awsProvider.(service.IdentityProvider)
+	if !ok {
+		return nil
+	}
+
+	awsCtx, cancel := providerContext(ctx, s.awsTimeout)
+	defer cancel()
+	accountID, region, err := provider.CallerIdentity(awsCtx)
+	if err != nil {
+		s.logger.Warn(fmt.Sprintf("Skipping the account and region check: %v", err))
+		return nil
+	}
+
+	return &accountGuard{accountID: accountID, region: region, strict: s.strictAccountCheck, logger: s.logger}
+}
+
+// check compares the accounts and regions named in the Terraform instances with the AWS
+// client's. It only checks the first instances that name any; on a mismatch it warns, or
+// fails in strict mode.
+func (g *accountGuard) check(instances ...*model.Instance) error {
+	if g == nil || g.checked {
+		return nil
+	}
+	hints := model.InferLocationHints(instances)
+	if hints.IsEmpty() {
+		return nil
+	}
+	g.checked = true
+
+	var mismatches []string
+	if accounts := others(hints.AccountIDs, g.accountID); len(accounts) > 0 {
+		mismatches = append(mismatches, fmt.Sprintf("account %s but AWS credentials belong to %s", strings.Join(accounts, ", "), g.accountID))
+	}
+	if regions := others(hints.Regions, g.region); len(regions) > 0 {
+		mismatches = append(mismatches, fmt.Sprintf("region %s but AWS client reads %s", strings.Join(regions, ", "), g.region))
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("Terraform state names %s", strings.Join(mismatches, "; "))
+	if g.strict {
+		return errors.NewOperationalError(message, nil).WithContext("reason", "account_mismatch")
+	}
+	g.logger.Warn(fmt.Sprintf("!!! %s. Results will likely show every instance as drifted; check the state file and AWS configuration !!!", message))
+	return nil
+}
+
+// others returns the values that differ from want, or none when want is unknown
+func others(values []string, want string) []string {
+	if want == "" {
+		return nil
+	}
+	var differ []string
+	for _, value := range values {
+		if value != want {
+			differ = append(differ, value)
+		}
+	}
+	return differ
+}
+
+// checkInstanceCount fails a run when a provider returned fewer instances than
+// detector.min_instances, which usually means credentials, region or state source point at
+// the wrong place rather than that everything drifted
+func (s *DriftDetectorService) checkInstanceCount(origin model.ResourceOrigin, count int) error {
+	if s.minInstances <= 0 || count >= s.minInstances {
+		return nil
+	}
+	return errors.NewOperationalError(fmt.Sprintf("%s returned %d instances, fewer than detector.min_instances (%d); check the credentials, region and state source", origin.Name(), count, s.minInstances), nil).
+		WithContext("reason", "too_few_instances").
+		WithContext("provider", origin.Name())
+}
@@ -0,0 +1,142 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// identityProvider is an AWS provider reading from a known account and region
+type identityProvider struct {
+	streamingProvider
+	accountID string
+	region    string
+}
+
+func (m *identityProvider) CallerIdentity(ctx context.Context) (string, string, error) {
+	return m.accountID, m.region, nil
+}
+
+func TestDetectDriftForAll_AccountGuard(t *testing.T) {
+	stateInstance := func() *model.Instance {
+		return model.NewInstance("i-1", map[string]interface{}{
+			"instance_type":     "t2.micro",
+			"arn":               "arn:aws:ec2:us-east-1:111111111111:instance/i-1",
+			"availability_zone": "us-east-1a",
+		}, model.OriginTerraform)
+	}
+
+	tests := []struct {
+		name      string
+		accountID string
+		region    string
+		strict    bool
+		warning   string
+		wantErr   bool
+	}{
+		{name: "match", accountID: "111111111111", region: "us-east-1"},
+		{name: "other account", accountID: "222222222222", region: "us-east-1", warning: "Terraform state names account 111111111111 but AWS credentials belong to 222222222222"},
+		{name: "other region", accountID: "111111111111", region: "eu-west-1", warning: "Terraform state names region us-east-1 but AWS client reads eu-west-1"},
+		{name: "strict", accountID: "222222222222", region: "eu-west-1", strict: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		for _, parallelProviders := range []bool{false, true} {
+			t.Run(tt.name, func(t *testing.T) {
+				var buf bytes.Buffer
+				awsProvider := &identityProvider{
+					streamingProvider: streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: []*model.Instance{
+						model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS),
+					}}},
+					accountID: tt.accountID,
+					region:    tt.region,
+				}
+				terraformProvider := &streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: []*model.Instance{stateInstance()}}}
+				repo := &mockRepository{}
+
+				detector := app.NewDriftDetectorService(awsProvider, terraformProvider, repo, nil, service.DriftDetectorConfig{
+					SourceOfTruth:      model.OriginTerraform,
+					AttributePaths:     []string{"instance_type"},
+					Timeout:            2 * time.Second,
+					ParallelChecks:     1,
+					ParallelProviders:  parallelProviders,
+					StrictAccountCheck: tt.strict,
+				}, logging.NewLogger(logging.LogConfig{Level: logging.Info, Output: &buf}))
+
+				results, err := detector.DetectDriftForAll(context.Background(), nil)
+				if tt.wantErr {
+					require.Error(t, err)
+					assert.True(t, errors.IsOperationalError(err))
+					assert.ErrorContains(t, err, "account 111111111111 but AWS credentials belong to 222222222222; region us-east-1 but AWS client reads eu-west-1")
+					assert.Empty(t, results)
+					return
+				}
+				require.NoError(t, err)
+				assert.Len(t, results, 1)
+				if tt.warning != "" {
+					assert.Contains(t, buf.String(), tt.warning)
+				} else {
+					assert.NotContains(t, buf.String(), "Terraform state names")
+				}
+			})
+		}
+	}
+}
+
+func TestDetectDriftForAll_MinInstances(t *testing.T) {
+	terraformInstances := func() []*model.Instance {
+		return []*model.Instance{
+			model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform),
+			model.NewInstance("i-2", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform),
+		}
+	}
+
+	for _, parallelProviders := range []bool{false, true} {
+		config := service.DriftDetectorConfig{
+			SourceOfTruth:     model.OriginTerraform,
+			AttributePaths:    []string{"instance_type"},
+			Timeout:           2 * time.Second,
+			ParallelChecks:    1,
+			ParallelProviders: parallelProviders,
+			MinInstances:      1,
+		}
+
+		// Credentials of an empty account list nothing from AWS
+		repo := &mockRepository{}
+		detector := app.NewDriftDetectorService(
+			&streamingProvider{},
+			&streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: terraformInstances()}},
+			repo, nil, config, logging.New())
+		results, err := detector.DetectDriftForAll(context.Background(), nil)
+		require.Error(t, err)
+		assert.True(t, errors.IsOperationalError(err))
+		assert.ErrorContains(t, err, "AWS returned 0 instances, fewer than detector.min_instances (1)")
+		assert.Empty(t, results)
+		assert.Empty(t, repo.saved, "nothing is compared")
+
+		// A state with fewer instances than expected trips it too
+		detector.SetMinInstances(3)
+		detector.SetAWSProvider(&streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: []*model.Instance{
+			model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS),
+			model.NewInstance("i-2", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS),
+			model.NewInstance("i-3", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginAWS),
+		}}})
+		_, err = detector.DetectDriftForAll(context.Background(), nil)
+		assert.ErrorContains(t, err, "Terraform returned 2 instances")
+
+		// Disabled
+		detector.SetMinInstances(0)
+		results, err = detector.DetectDriftForAll(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Len(t, results, 3)
+	}
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// memoEntry is the result of comparing an instance, with the digests of both sides it was
+// computed from
+type memoEntry struct {
+	sourceDigest string
+	targetDigest string
+	result       *model.DriftResult
+}
+
+// comparisonMemo keeps the last result of each instance so that a scheduled run can reuse it
+// while neither side has changed. Results are only valid for the settings they were computed
+// with, so the memo is emptied whenever those change.
+type comparisonMemo struct {
+	mu       sync.Mutex
+	settings string
+	entries  map[string]memoEntry
+}
+
+// memoRequest identifies a comparison: the instance, the settings it is compared with and the
+// digests of both sides
+type memoRequest struct {
+	key          string
+	settings     string
+	sourceDigest string
+	targetDigest string
+}
+
+// memoKey identifies an instance across runs, within its account and workspace
+func memoKey(result *model.DriftResult) string {
+	return fmt.Sprintf("%s/%s/%s", result.AccountID, result.Workspace, result.ResourceID)
+}
+
+// lookup returns the memoized result of a comparison made with the same settings from
+// instances with the same digests
+func (m *comparisonMemo) lookup(req memoRequest) (*model.DriftResult, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.settings != req.settings || req.sourceDigest == "" || req.targetDigest == "" {
+		return nil, false
+	}
+	entry, ok := m.entries[req.key]
+	if !ok || entry.sourceDigest != req.sourceDigest || entry.targetDigest != req.targetDigest {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// store memoizes the result of a comparison, first dropping every result computed with other
+// settings. It reports how many results were dropped.
+func (m *comparisonMemo) store(req memoRequest, result *model.DriftResult) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dropped := 0
+	if m.settings != req.settings {
+		dropped = len(m.entries)
+		m.settings = req.settings
+		m.entries = make(map[string]memoEntry)
+	}
+	m.entries[req.key] = memoEntry{sourceDigest: req.sourceDigest, targetDigest: req.targetDigest, result: result}
+	return dropped
+}
+
+// reset forgets every memoized result
+func (m *comparisonMemo) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings = ""
+	m.entries = nil
+}
+
+// retain drops the results of instances that are not among the results of a full run, e.g.
+// terminated instances
+func (m *comparisonMemo) retain(results []*model.DriftResult) {
+	keep := make(map[string]bool, len(results))
+	for _, result := range results {
+		keep[memoKey(result)] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.entries {
+		if !keep[key] {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// memoSettings fingerprints the settings a result depends on besides the instances themselves,
+// including the policies it was checked against. Custom comparators are identified by the
+// paths they are registered for.
+func (s *DriftDetectorService) memoSettings(attributePaths []string, opts model.CompareOptions) string {
+	comparators := make([]string, 0, len(opts.Comparators))
+	for path := range opts.Comparators {
+		comparators = append(comparators, path)
+	}
+	slices.Sort(comparators)
+	opts.Comparators = nil
+
+	data, err := json.Marshal(struct {
+		SourceOfTruth      model.ResourceOrigin `json:"source_of_truth"`
+		AttributePaths     []string             `json:"attribute_paths"`
+		CompareOptions     model.CompareOptions `json:"compare_options"`
+		Comparators        []string             `json:"comparators"`
+		SourceDeclaredOnly bool                 `json:"source_declared_only"`
+		StaticIPsOnly      bool                 `json:"static_ips_only"`
+		TerminationCheck   bool                 `json:"termination_check"`
+		SuggestRemediation bool                 `json:"suggest_remediation"`
+		NetworkContext     bool                 `json:"network_context"`
+		IncludeSnapshots   bool                 `json:"include_snapshots"`
+		Policies           []model.Policy       `json:"policies"`
+		AllowedTypes       []string             `json:"allowed_instance_types"`
+	}{
+		SourceOfTruth:      s.sourceOfTruth,
+		AttributePaths:     attributePaths,
+		CompareOptions:     opts,
+		Comparators:        comparators,
+		SourceDeclaredOnly: s.sourceDeclaredOnly,
+		StaticIPsOnly:      s.staticIPsOnly,
+		TerminationCheck:   s.terminationCheck,
+		SuggestRemediation: s.suggestRemediation,
+		NetworkContext:     s.networkContext,
+		IncludeSnapshots:   s.includeSnapshots,
+		Policies:           s.policies,
+		AllowedTypes:       s.allowedTypes,
+	})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// newMemoRequest identifies the comparison of a pair of instances for the memo
+func (s *DriftDetectorService) newMemoRequest(result *model.DriftResult, source, target *model.Instance, attributePaths []string) memoRequest {
+	return memoRequest{
+		key:          memoKey(result),
+		settings:     s.memoSettings(attributePaths, s.attributeCompareOptions()),
+		sourceDigest: model.InstanceDigest(source),
+		targetDigest: model.InstanceDigest(target),
+	}
+}
+
+// reuseResult returns the previous run's result when neither instance changed since. Policies
+// are evaluated again since some, such as age_days, depend on when the run happens, so the
+// previous run's violations are dropped first.
+func (s *DriftDetectorService) reuseResult(req memoRequest, source, target *model.Instance) (*model.DriftResult, bool) {
+	previous, ok := s.memo.lookup(req)
+	if !ok {
+		return nil, false
+	}
+	result := previous.ReusedAt(s.clock.Now())
+	result.SetPolicyViolations(nil)
+	s.evaluatePolicies(result, source, target)
+	s.logger.Info(fmt.Sprintf("Instance %s is unchanged since the previous run, reusing its result", source.ID))
+	return result, true
+}
+
+// memoizeResult keeps the result of a comparison for the next run
+func (s *DriftDetectorService) memoizeResult(req memoRequest, result *model.DriftResult) {
+	if dropped := s.memo.store(req, result); dropped > 0 {
+		s.logger.Info(fmt.Sprintf("Comparison settings changed, discarded %d memoized results", dropped))
+	}
+}
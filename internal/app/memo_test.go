@@ -0,0 +1,184 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+func TestDetectDrift_Memoize(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	newDetector := func(memoize bool) (*app.DriftDetectorService, *clock.Fake, *mockRepository) {
+		fake := clock.NewFake(now)
+		repository := &mockRepository{}
+		detector := app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, repository, nil, service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type", "tags"},
+			Timeout:        2 * time.Second,
+			Clock:          fake,
+			Memoize:        memoize,
+		}, logging.New())
+		return detector, fake, repository
+	}
+	instances := func(awsType string) (*model.Instance, *model.Instance) {
+		terraform := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro", "tags": map[string]interface{}{"Name": "web"}}, model.OriginTerraform)
+		aws := model.NewInstance("i-1", map[string]interface{}{"instance_type": awsType, "tags": map[string]interface{}{"Name": "web"}}, model.OriginAWS)
+		return terraform, aws
+	}
+	detect := func(t *testing.T, detector *app.DriftDetectorService, awsType string, paths []string) *model.DriftResult {
+		terraform, aws := instances(awsType)
+		result, err := detector.DetectDrift(context.Background(), terraform, aws, paths)
+		require.NoError(t, err)
+		return result
+	}
+	paths := []string{"instance_type", "tags"}
+
+	t.Run("unchanged instances reuse the previous result", func(t *testing.T) {
+		detector, fake, repository := newDetector(true)
+		first := detect(t, detector, "t3.large", paths)
+		assert.False(t, first.Memoized)
+
+		fake.Advance(time.Hour)
+		second := detect(t, detector, "t3.large", paths)
+		assert.True(t, second.Memoized)
+		assert.Equal(t, first.DriftedAttributes, second.DriftedAttributes)
+		assert.Equal(t, now.Add(time.Hour), second.Timestamp)
+		assert.NotEqual(t, first.ID, second.ID)
+
+		// Reused results are still stored, as of the run that reused them
+		require.Len(t, repository.saved, 2)
+		assert.Equal(t, second.ID, repository.saved[1].ID)
+	})
+
+	t.Run("a change on either side compares again", func(t *testing.T) {
+		detector, _, _ := newDetector(true)
+		detect(t, detector, "t3.large", paths)
+
+		changed := detect(t, detector, "t3.micro", paths)
+		assert.False(t, changed.Memoized)
+		assert.False(t, changed.HasDrift)
+	})
+
+	t.Run("changed attributes or settings bypass the memo", func(t *testing.T) {
+		detector, _, _ := newDetector(true)
+		detect(t, detector, "t3.large", paths)
+
+		assert.False(t, detect(t, detector, "t3.large", []string{"instance_type"}).Memoized)
+
+		detector.SetCompareOptions(model.CompareOptions{IgnoreTagCase: true})
+		assert.False(t, detect(t, detector, "t3.large", []string{"instance_type"}).Memoized)
+		assert.True(t, detect(t, detector, "t3.large", []string{"instance_type"}).Memoized)
+
+		detector.RegisterComparator("instance_type", func(a, b interface{}) bool { return true })
+		assert.False(t, detect(t, detector, "t3.large", []string{"instance_type"}).Memoized)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		detector, _, _ := newDetector(false)
+		detect(t, detector, "t3.large", paths)
+		assert.False(t, detect(t, detector, "t3.large", paths).Memoized)
+
+		// Turning it off forgets what was memoized
+		detector.SetMemoize(true)
+		detect(t, detector, "t3.large", paths)
+		detector.SetMemoize(false)
+		detector.SetMemoize(true)
+		assert.False(t, detect(t, detector, "t3.large", paths).Memoized)
+	})
+}
+
+func TestDetectDrift_MemoizeReevaluatesPolicies(t *testing.T) {
+	launched := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(launched.AddDate(0, 0, 80))
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, &mockRepository{}, nil, service.DriftDetectorConfig{
+		SourceOfTruth:  model.OriginTerraform,
+		AttributePaths: []string{"instance_type"},
+		Timeout:        2 * time.Second,
+		Clock:          fake,
+		Memoize:        true,
+		Policies:       []model.Policy{{Path: model.AttributeAgeDays, Operator: model.PolicyOperatorLessThan, Value: 90}},
+	}, logging.New())
+
+	terraform := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginTerraform)
+	aws := model.NewInstance("i-1", map[string]interface{}{
+		"instance_type":           "t3.micro",
+		model.AttributeLaunchTime: launched.Format(time.RFC3339),
+	}, model.OriginAWS)
+
+	result, err := detector.DetectDrift(context.Background(), terraform, aws, []string{"instance_type"})
+	require.NoError(t, err)
+	assert.Empty(t, result.PolicyViolations)
+
+	// The instances are unchanged, but the instance has aged past the policy since
+	fake.Advance(20 * 24 * time.Hour)
+	result, err = detector.DetectDrift(context.Background(), terraform, aws, []string{"instance_type"})
+	require.NoError(t, err)
+	assert.True(t, result.Memoized)
+	assert.Len(t, result.PolicyViolations, 1)
+}
+
+func TestDetectDrift_MemoizeClearsResolvedViolations(t *testing.T) {
+	launched := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(launched.AddDate(0, 0, 20))
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, &mockRepository{}, nil, service.DriftDetectorConfig{
+		SourceOfTruth:  model.OriginTerraform,
+		AttributePaths: []string{"instance_type"},
+		Timeout:        2 * time.Second,
+		Clock:          fake,
+		Memoize:        true,
+		Policies:       []model.Policy{{Path: model.AttributeAgeDays, Operator: model.PolicyOperatorGreaterThan, Value: 30}},
+	}, logging.New())
+
+	terraform := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginTerraform)
+	aws := model.NewInstance("i-1", map[string]interface{}{
+		"instance_type":           "t3.micro",
+		model.AttributeLaunchTime: launched.Format(time.RFC3339),
+	}, model.OriginAWS)
+
+	result, err := detector.DetectDrift(context.Background(), terraform, aws, []string{"instance_type"})
+	require.NoError(t, err)
+	assert.Len(t, result.PolicyViolations, 1)
+
+	// The instance has aged into the policy since, so the reused result no longer violates it
+	fake.Advance(20 * 24 * time.Hour)
+	result, err = detector.DetectDrift(context.Background(), terraform, aws, []string{"instance_type"})
+	require.NoError(t, err)
+	assert.True(t, result.Memoized)
+	assert.Empty(t, result.PolicyViolations)
+}
+
+func TestDetectDrift_MemoizePolicyChanges(t *testing.T) {
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, &mockRepository{}, nil, service.DriftDetectorConfig{
+		SourceOfTruth:  model.OriginTerraform,
+		AttributePaths: []string{"instance_type"},
+		Timeout:        2 * time.Second,
+		Memoize:        true,
+	}, logging.New())
+
+	terraform := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginTerraform)
+	aws := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginAWS)
+	detect := func() *model.DriftResult {
+		result, err := detector.DetectDrift(context.Background(), terraform, aws, []string{"instance_type"})
+		require.NoError(t, err)
+		return result
+	}
+
+	detect()
+	assert.True(t, detect().Memoized)
+
+	detector.SetPolicies([]model.Policy{{Path: "instance_type", Operator: model.PolicyOperatorNotEqual, Value: "t3.micro"}})
+	assert.False(t, detect().Memoized)
+	assert.True(t, detect().Memoized)
+
+	detector.SetAllowedInstanceTypes([]string{"t3.small"})
+	assert.False(t, detect().Memoized)
+	assert.True(t, detect().Memoized)
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// attachNetworkContext describes both subnets of a subnet_id drift when enabled, rating a move
+// into another VPC as high severity. When the subnets can't be described the drift is left as
+// a plain ID comparison.
+func (s *DriftDetectorService) attachNetworkContext(ctx context.Context, result *model.DriftResult) {
+	if !s.networkContext {
+		return
+	}
+	drift, ok := result.DriftedAttributes[model.AttributeSubnetID]
+	if !ok {
+		return
+	}
+	provider, ok := s.awsProvider.(service.SubnetProvider)
+	if !ok {
+		s.logger.Debug("AWS provider can't describe subnets, reporting subnet_id drift without network context")
+		return
+	}
+
+	sourceID, _ := drift.SourceValue.(string)
+	targetID, _ := drift.TargetValue.(string)
+	var ids []string
+	for _, id := range []string{sourceID, targetID} {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	awsCtx, cancel := providerContext(ctx, s.awsTimeout)
+	defer cancel()
+	subnets, err := provider.DescribeSubnets(awsCtx, ids)
+	if err != nil {
+		s.logger.Warn(fmt.Sprintf("Failed to describe subnets of instance %s, reporting subnet_id drift by ID only: %v", result.ResourceID, err))
+		return
+	}
+	if subnets[sourceID] == nil && subnets[targetID] == nil {
+		return
+	}
+
+	drift.Network = model.NewNetworkContext(subnets[sourceID], subnets[targetID])
+	if drift.Network.CrossVPC {
+		drift.Severity = model.SeverityHigh
+		s.logger.Warn(fmt.Sprintf("Instance %s moved to another VPC: %s", result.ResourceID, drift.Network))
+	}
+	result.DriftedAttributes[model.AttributeSubnetID] = drift
+}
@@ -0,0 +1,95 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	apperrors "github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// subnetProvider describes the subnets it knows, counting the calls, or fails when err is set
+type subnetProvider struct {
+	mockInstanceProvider
+	subnets map[string]*model.Subnet
+	err     error
+	calls   int
+}
+
+func (m *subnetProvider) DescribeSubnets(ctx context.Context, subnetIDs []string) (map[string]*model.Subnet, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	subnets := make(map[string]*model.Subnet)
+	for _, id := range subnetIDs {
+		if subnet, ok := m.subnets[id]; ok {
+			subnets[id] = subnet
+		}
+	}
+	return subnets, nil
+}
+
+func TestDetectDrift_NetworkContext(t *testing.T) {
+	subnets := map[string]*model.Subnet{
+		"subnet-a": {ID: "subnet-a", VPCID: "vpc-1", AvailabilityZone: "us-east-1a", Name: "app-a"},
+		"subnet-b": {ID: "subnet-b", VPCID: "vpc-1", AvailabilityZone: "us-east-1b", Name: "app-b"},
+		"subnet-c": {ID: "subnet-c", VPCID: "vpc-2", AvailabilityZone: "us-east-1a", Name: "shared-a"},
+	}
+	newDetector := func(provider *subnetProvider, enrich bool) *app.DriftDetectorService {
+		return app.NewDriftDetectorService(provider, &mockInstanceProvider{}, &mockRepository{}, nil, service.DriftDetectorConfig{
+			SourceOfTruth:        model.OriginTerraform,
+			AttributePaths:       []string{"subnet_id"},
+			Timeout:              2 * time.Second,
+			EnrichNetworkContext: enrich,
+		}, logging.New())
+	}
+	detect := func(t *testing.T, detector *app.DriftDetectorService, terraformSubnet, awsSubnet string) model.AttributeDrift {
+		terraform := model.NewInstance("i-1", map[string]interface{}{"subnet_id": terraformSubnet}, model.OriginTerraform)
+		aws := model.NewInstance("i-1", map[string]interface{}{"subnet_id": awsSubnet}, model.OriginAWS)
+		result, err := detector.DetectDrift(context.Background(), terraform, aws, []string{"subnet_id"})
+		require.NoError(t, err)
+		require.Contains(t, result.DriftedAttributes, "subnet_id")
+		return result.DriftedAttributes["subnet_id"]
+	}
+
+	t.Run("cross-VPC move is high severity", func(t *testing.T) {
+		drift := detect(t, newDetector(&subnetProvider{subnets: subnets}, true), "subnet-a", "subnet-c")
+		require.NotNil(t, drift.Network)
+		assert.Equal(t, subnets["subnet-a"], drift.Network.Source)
+		assert.Equal(t, subnets["subnet-c"], drift.Network.Target)
+		assert.True(t, drift.Network.CrossVPC)
+		assert.False(t, drift.Network.CrossAZ)
+		assert.Equal(t, model.SeverityHigh, drift.Severity)
+	})
+
+	t.Run("cross-AZ move within the VPC keeps the attribute's severity", func(t *testing.T) {
+		drift := detect(t, newDetector(&subnetProvider{subnets: subnets}, true), "subnet-a", "subnet-b")
+		require.NotNil(t, drift.Network)
+		assert.False(t, drift.Network.CrossVPC)
+		assert.True(t, drift.Network.CrossAZ)
+		assert.Empty(t, drift.Severity)
+	})
+
+	t.Run("describe failure degrades to plain ID comparison", func(t *testing.T) {
+		provider := &subnetProvider{err: apperrors.NewOperationalError("UnauthorizedOperation", nil)}
+		drift := detect(t, newDetector(provider, true), "subnet-a", "subnet-c")
+		assert.Equal(t, 1, provider.calls)
+		assert.Nil(t, drift.Network)
+		assert.Empty(t, drift.Severity)
+		assert.Equal(t, "subnet-a", drift.SourceValue)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		provider := &subnetProvider{subnets: subnets}
+		drift := detect(t, newDetector(provider, false), "subnet-a", "subnet-c")
+		assert.Zero(t, provider.calls)
+		assert.Nil(t, drift.Network)
+	})
+}
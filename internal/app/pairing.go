@@ -0,0 +1,33 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// checkPairing refuses to compare two instances that don't agree on the key they are paired
+// on, since a wrong pairing would otherwise report the differences between two unrelated
+// instances as drift
+func (s *DriftDetectorService) checkPairing(source, target *model.Instance) error {
+	same, key := model.SameInstance(source, target)
+	if same || s.allowMismatchedIDs {
+		return nil
+	}
+
+	return errors.NewValidationError(fmt.Sprintf("Refusing to compare %s instance %s with %s instance %s: they don't match on %s",
+		source.Origin.Name(), source.ID, target.Origin.Name(), target.ID, key)).
+		WithContext("reason", "mismatched_instances").
+		WithContext("match_key", key).
+		WithContext("source_id", source.ID).
+		WithContext("target_id", target.ID).
+		WithContext("source_name", source.NameTag()).
+		WithContext("target_name", target.NameTag())
+}
+
+// SetAllowMismatchedIDs sets whether instances that don't agree on the key they are paired on
+// are compared anyway
+func (s *DriftDetectorService) SetAllowMismatchedIDs(allow bool) {
+	s.allowMismatchedIDs = allow
+}
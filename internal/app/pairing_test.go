@@ -0,0 +1,58 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+func TestDetectDrift_RefusesMismatchedInstances(t *testing.T) {
+	tfInst := model.NewInstance("i-123", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-456", map[string]interface{}{"instance_type": "t3.large"}, model.OriginAWS)
+
+	repository := &mockRepository{}
+	detector := app.NewDriftDetectorService(nil, nil, repository, nil, service.DriftDetectorConfig{}, logging.New())
+
+	_, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	require.Error(t, err)
+	assert.True(t, errors.IsValidationError(err))
+	assert.ErrorContains(t, err, "Refusing to compare Terraform instance i-123 with AWS instance i-456: they don't match on id")
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "i-123", appErr.Context["source_id"])
+	assert.Equal(t, "i-456", appErr.Context["target_id"])
+	assert.Equal(t, model.MatchKeyID, appErr.Context["match_key"])
+
+	// Comparing two different instances on purpose
+	detector.SetAllowMismatchedIDs(true)
+	result, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	require.NoError(t, err)
+	assert.True(t, result.HasDrift)
+}
+
+func TestDetectDrift_MatchesConfiguredInstancesByName(t *testing.T) {
+	tfInst := model.NewInstance("tf-aws_instance-web", map[string]interface{}{
+		"instance_type": "t3.micro",
+		"tags":          map[string]interface{}{"Name": "web"},
+	}, model.OriginTerraform)
+	awsInst := model.NewInstance("i-456", map[string]interface{}{
+		"instance_type": "t3.micro",
+		"tags":          map[string]interface{}{"Name": "web"},
+	}, model.OriginAWS)
+
+	detector := app.NewDriftDetectorService(nil, nil, &mockRepository{}, nil, service.DriftDetectorConfig{}, logging.New())
+	_, err := detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.NoError(t, err)
+
+	awsInst.Attributes["tags"] = map[string]interface{}{"Name": "api"}
+	_, err = detector.DetectDrift(context.Background(), tfInst, awsInst, []string{"instance_type"})
+	assert.ErrorContains(t, err, "they don't match on tags.Name")
+}
@@ -0,0 +1,102 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// prefilterDetector creates a detector whose comparisons of instance_type are counted
+func prefilterDetector(prefilter bool, compared *atomic.Int64) *app.DriftDetectorService {
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, &mockRepository{}, nil, service.DriftDetectorConfig{
+		SourceOfTruth: model.OriginTerraform,
+		Timeout:       2 * time.Second,
+		HashPrefilter: prefilter,
+	}, logging.New())
+	detector.RegisterComparator("instance_type", func(a, b interface{}) bool {
+		compared.Add(1)
+		return a == b
+	})
+	return detector
+}
+
+func TestDetectDrift_HashPrefilter(t *testing.T) {
+	paths := []string{"instance_type", "tags"}
+	instances := func(awsType string) (*model.Instance, *model.Instance) {
+		terraform := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro", "tags": map[string]interface{}{"Name": "web"}}, model.OriginTerraform)
+		aws := model.NewInstance("i-1", map[string]interface{}{"instance_type": awsType, "tags": map[string]interface{}{"Name": "web"}}, model.OriginAWS)
+		return terraform, aws
+	}
+
+	t.Run("identical instances skip comparison", func(t *testing.T) {
+		var compared atomic.Int64
+		detector := prefilterDetector(true, &compared)
+		terraform, aws := instances("t3.micro")
+
+		result, err := detector.DetectDrift(context.Background(), terraform, aws, paths)
+		require.NoError(t, err)
+		assert.False(t, result.HasDrift)
+		assert.Zero(t, compared.Load())
+	})
+
+	t.Run("a hash mismatch compares for the precise diff", func(t *testing.T) {
+		var compared atomic.Int64
+		detector := prefilterDetector(true, &compared)
+		terraform, aws := instances("t3.large")
+
+		result, err := detector.DetectDrift(context.Background(), terraform, aws, paths)
+		require.NoError(t, err)
+		assert.True(t, result.HasDrift)
+		assert.Equal(t, int64(1), compared.Load())
+		assert.Contains(t, result.DriftedAttributes, "instance_type")
+		assert.NotContains(t, result.DriftedAttributes, "tags")
+	})
+
+	t.Run("disabled compares every instance", func(t *testing.T) {
+		var compared atomic.Int64
+		detector := prefilterDetector(false, &compared)
+		terraform, aws := instances("t3.micro")
+
+		_, err := detector.DetectDrift(context.Background(), terraform, aws, paths)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), compared.Load())
+	})
+}
+
+// BenchmarkDetectDrift_HashPrefilter compares identical instances with many attributes with and
+// without the hash prefilter, reporting how many attributes were compared one by one
+func BenchmarkDetectDrift_HashPrefilter(b *testing.B) {
+	attrs := map[string]interface{}{"instance_type": "t3.micro"}
+	paths := []string{"instance_type"}
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("attribute_%03d", i)
+		attrs[key] = map[string]interface{}{"value": key, "list": []interface{}{"a", "b", "c"}}
+		paths = append(paths, key)
+	}
+	terraform := model.NewInstance("i-1", attrs, model.OriginTerraform)
+	aws := model.NewInstance("i-1", attrs, model.OriginAWS)
+
+	for _, prefilter := range []bool{false, true} {
+		b.Run(fmt.Sprintf("hash_prefilter=%v", prefilter), func(b *testing.B) {
+			var compared atomic.Int64
+			detector := prefilterDetector(prefilter, &compared)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := detector.DetectDrift(context.Background(), terraform, aws, paths); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(compared.Load())/float64(b.N), "compares/op")
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// compareRegions adds a region drift when AWS finds an instance in another region than
+// Terraform places it in. Zone and subnet drifts follow from the move, so the warning names it.
+func (s *DriftDetectorService) compareRegions(source, target *model.Instance, opts model.CompareOptions, drifts map[string]model.AttributeDrift) {
+	drift := model.CompareRegions(source, target, opts)
+	if drift == nil {
+		return
+	}
+	drifts[model.AttributeRegion] = *drift
+	s.logger.Warn(fmt.Sprintf("Instance %s is in region %s in %s but %s in %s", source.ID,
+		model.InstanceRegion(source), source.Origin, model.InstanceRegion(target), target.Origin))
+}
@@ -0,0 +1,48 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+func TestDetectDriftForAll_InstanceInAnotherRegion(t *testing.T) {
+	// Imported from us-west-2, while AWS finds the same ID in us-east-1
+	terraform := model.NewInstance("i-1", map[string]interface{}{
+		"instance_type":     "t3.micro",
+		"arn":               "arn:aws:ec2:us-west-2:123456789012:instance/i-1",
+		"availability_zone": "us-west-2a",
+	}, model.OriginTerraform)
+	aws := model.NewInstance("i-1", map[string]interface{}{
+		"instance_type": "t3.micro",
+		"placement":     map[string]interface{}{"availability_zone": "us-east-1a"},
+	}, model.OriginAWS)
+
+	detector := app.NewDriftDetectorService(
+		&mockInstanceProvider{instances: []*model.Instance{aws}},
+		&mockInstanceProvider{instances: []*model.Instance{terraform}},
+		&mockRepository{}, nil, service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        2 * time.Second,
+			ParallelChecks: 1,
+		}, logging.New())
+
+	results, err := detector.DetectDriftForAll(context.Background(), nil)
+	require.NoError(t, err)
+
+	// One moved instance, not one missing from each side
+	require.Len(t, results, 1)
+	assert.NotContains(t, results[0].DriftedAttributes, model.AttributeExists)
+	require.Contains(t, results[0].DriftedAttributes, model.AttributeRegion)
+	drift := results[0].DriftedAttributes[model.AttributeRegion]
+	assert.Equal(t, "us-west-2", drift.DesiredValue)
+	assert.Equal(t, "us-east-1", drift.CurrentValue)
+}
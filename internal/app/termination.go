@@ -0,0 +1,26 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// compareTerminationProtection adds a termination_protection drift when enabled and AWS's
+// disable_api_termination disagrees with the prevent_destroy of the Terraform resource. When
+// either side is unknown, e.g. because state doesn't record lifecycle, it is skipped instead.
+func (s *DriftDetectorService) compareTerminationProtection(source, target *model.Instance, opts model.CompareOptions, drifts map[string]model.AttributeDrift, skipped map[string]string) {
+	if !s.terminationCheck {
+		return
+	}
+
+	drift, reason := model.CompareTerminationProtection(source, target, opts)
+	if reason != "" {
+		skipped[model.AttributeTerminationProtection] = reason
+		return
+	}
+	if drift != nil {
+		drifts[model.AttributeTerminationProtection] = *drift
+		s.logger.Warn(fmt.Sprintf("Instance %s termination protection differs: %s", source.ID, drift.Protection.Note))
+	}
+}
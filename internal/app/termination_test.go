@@ -0,0 +1,58 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+func TestDetectDrift_TerminationProtection(t *testing.T) {
+	newDetector := func(compare bool) *app.DriftDetectorService {
+		return app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, &mockRepository{}, nil, service.DriftDetectorConfig{
+			SourceOfTruth:                model.OriginTerraform,
+			AttributePaths:               []string{"instance_type"},
+			Timeout:                      2 * time.Second,
+			CompareTerminationProtection: compare,
+		}, logging.New())
+	}
+	detect := func(t *testing.T, detector *app.DriftDetectorService, preventDestroy interface{}, disableAPITermination bool) *model.DriftResult {
+		terraform := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro", model.AttributePreventDestroy: preventDestroy}, model.OriginTerraform)
+		aws := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro", model.AttributeDisableAPITermination: disableAPITermination}, model.OriginAWS)
+		result, err := detector.DetectDrift(context.Background(), terraform, aws, []string{"instance_type"})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("mismatch is drift with a note", func(t *testing.T) {
+		result := detect(t, newDetector(true), true, false)
+		assert.True(t, result.HasDrift)
+		drift, ok := result.DriftedAttributes[model.AttributeTerminationProtection]
+		require.True(t, ok)
+		require.NotNil(t, drift.Protection)
+		assert.NotEmpty(t, drift.Protection.Note)
+	})
+
+	t.Run("matching protections are not drift", func(t *testing.T) {
+		result := detect(t, newDetector(true), true, true)
+		assert.False(t, result.HasDrift)
+	})
+
+	t.Run("prevent_destroy from state is skipped", func(t *testing.T) {
+		result := detect(t, newDetector(true), model.UnknownValue{Reason: "lifecycle prevent_destroy is not recorded in Terraform state"}, true)
+		assert.False(t, result.HasDrift)
+		assert.Equal(t, "lifecycle prevent_destroy is not recorded in Terraform state", result.SkippedAttributes[model.AttributeTerminationProtection])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		result := detect(t, newDetector(false), true, false)
+		assert.False(t, result.HasDrift)
+		assert.Empty(t, result.SkippedAttributes)
+	})
+}
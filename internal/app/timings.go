@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// fetchTimerKey is the context key carrying the fetch timer of a run
+type fetchTimerKey struct{}
+
+// fetchTimer records how long each provider took to list its instances during a run. The
+// providers are listed concurrently, so recording is locked.
+type fetchTimer struct {
+	mu       sync.Mutex
+	timings  model.FetchTimings
+	recorded bool
+}
+
+// ensureFetchTimer returns the fetch timer carried by ctx, adding one if there is none
+func ensureFetchTimer(ctx context.Context) (context.Context, *fetchTimer) {
+	if timer, ok := ctx.Value(fetchTimerKey{}).(*fetchTimer); ok {
+		return ctx, timer
+	}
+	timer := &fetchTimer{}
+	return context.WithValue(ctx, fetchTimerKey{}, timer), timer
+}
+
+// record sets how long a provider took to list its instances
+func (t *fetchTimer) record(origin model.ResourceOrigin, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if origin == model.OriginAWS {
+		t.timings.AWS = elapsed
+	} else {
+		t.timings.Terraform = elapsed
+	}
+	t.recorded = true
+}
+
+// snapshot returns the timings recorded so far, or nil when no provider was listed
+func (t *fetchTimer) snapshot() *model.FetchTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.recorded {
+		return nil
+	}
+	timings := t.timings
+	return &timings
+}
+
+// recordFetch records and logs how long a provider took to list its instances since start,
+// whether or not the listing succeeded, so that a slow failure is still visible
+func (s *DriftDetectorService) recordFetch(ctx context.Context, origin model.ResourceOrigin, start time.Time) {
+	elapsed := s.clock.Now().Sub(start)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	if timer, ok := ctx.Value(fetchTimerKey{}).(*fetchTimer); ok {
+		timer.record(origin, elapsed)
+	}
+
+	verb := "listed"
+	if origin == model.OriginTerraform {
+		verb = "parsed"
+	}
+	s.logger.Info(fmt.Sprintf("%s instances %s in %s", origin.Name(), verb, elapsed.Round(time.Millisecond)))
+}
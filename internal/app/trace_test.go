@@ -0,0 +1,44 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+func TestDetectDrift_Trace(t *testing.T) {
+	detector := app.NewDriftDetectorService(&mockInstanceProvider{}, &mockInstanceProvider{}, &mockRepository{}, nil, service.DriftDetectorConfig{
+		SourceOfTruth:      model.OriginTerraform,
+		AttributePaths:     []string{"instance_type", "ebs_optimized"},
+		Timeout:            2 * time.Second,
+		Memoize:            true,
+		SourceDeclaredOnly: true,
+	}, logging.New())
+	terraform := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, model.OriginTerraform)
+	aws := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t3.large", "ebs_optimized": true}, model.OriginAWS)
+	paths := []string{"instance_type", "ebs_optimized"}
+
+	_, err := detector.DetectDrift(context.Background(), terraform, aws, paths)
+	require.NoError(t, err)
+
+	// A traced comparison runs even though the instance is memoized
+	trace := model.NewCompareTrace()
+	result, err := detector.DetectDrift(service.WithCompareTrace(context.Background(), trace), terraform, aws, paths)
+	require.NoError(t, err)
+	assert.False(t, result.Memoized)
+
+	records := trace.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "ebs_optimized", records[0].Path)
+	assert.Equal(t, model.TraceSkipped, records[0].Verdict)
+	assert.Contains(t, records[0].Reason, "detector.source_declared_only")
+	assert.Equal(t, "instance_type", records[1].Path)
+	assert.Equal(t, model.TraceDrifted, records[1].Verdict)
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// workspaceBuckets assigns instances to the Terraform workspace they are compared within:
+// Terraform instances by the workspace they were read from, AWS instances by the value of
+// their environment tag. Instances are only paired within a workspace. A nil
+// workspaceBuckets pairs instances by ID alone.
+type workspaceBuckets struct {
+	tag        string
+	workspaces map[string]bool
+
+	// found records the workspaces each instance ID was seen in by each provider
+	found map[string]map[workspaceSighting]bool
+
+	// untracked counts AWS instances tagged with none of the workspaces
+	untracked int
+}
+
+// workspaceSighting is a workspace a provider placed an instance in
+type workspaceSighting struct {
+	workspace string
+	origin    model.ResourceOrigin
+}
+
+// newWorkspaceBuckets returns the buckets of the workspaces the Terraform provider reads, or
+// nil when it reads a single state
+func (s *DriftDetectorService) newWorkspaceBuckets() *workspaceBuckets {
+	provider, ok := s.terraformProvider.(service.WorkspaceProvider)
+	if !ok {
+		return nil
+	}
+	workspaces := provider.Workspaces()
+	if len(workspaces) == 0 {
+		return nil
+	}
+
+	buckets := &workspaceBuckets{
+		tag:        s.environmentTag,
+		workspaces: make(map[string]bool, len(workspaces)),
+		found:      make(map[string]map[workspaceSighting]bool),
+	}
+	for _, workspace := range workspaces {
+		buckets.workspaces[workspace] = true
+	}
+	return buckets
+}
+
+// key returns the key an instance is paired by, and false when it belongs to none of the
+// workspaces. AWS instances are annotated with the workspace they were assigned to.
+func (b *workspaceBuckets) key(origin model.ResourceOrigin, instance *model.Instance) (string, bool) {
+	if b == nil {
+		return instance.ID, true
+	}
+
+	workspace := instance.Workspace
+	if origin == model.OriginAWS {
+		workspace, _ = instance.Tag(b.tag)
+	}
+
+	if b.found[instance.ID] == nil {
+		b.found[instance.ID] = make(map[workspaceSighting]bool)
+	}
+	b.found[instance.ID][workspaceSighting{workspace: workspace, origin: origin}] = true
+
+	if !b.workspaces[workspace] {
+		if origin == model.OriginAWS {
+			b.untracked++
+		}
+		return "", false
+	}
+
+	instance.Workspace = workspace
+	return workspace + "/" + instance.ID, true
+}
+
+// report warns about instance IDs seen in more than one workspace, which are compared
+// separately within each of them, and logs how many AWS instances were left out
+func (b *workspaceBuckets) report(logger *logging.Logger) {
+	if b == nil {
+		return
+	}
+
+	ids := make([]string, 0, len(b.found))
+	for id, sightings := range b.found {
+		workspaces := make(map[string]bool)
+		for sighting := range sightings {
+			workspaces[sighting.workspace] = true
+		}
+		if len(workspaces) > 1 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		places := make([]string, 0, len(b.found[id]))
+		for sighting := range b.found[id] {
+			places = append(places, b.describe(sighting))
+		}
+		sort.Strings(places)
+		logger.Warn(fmt.Sprintf("Instance %s belongs to several workspaces (%s); it is only compared within each of them", id, strings.Join(places, ", ")))
+	}
+
+	if b.untracked > 0 {
+		logger.Info(fmt.Sprintf("Skipped %d AWS instances whose %s tag names none of the Terraform workspaces", b.untracked, b.tag))
+	}
+}
+
+// describe names where an instance was seen, for collision warnings
+func (b *workspaceBuckets) describe(sighting workspaceSighting) string {
+	if sighting.origin == model.OriginTerraform {
+		return fmt.Sprintf("Terraform workspace %s", sighting.workspace)
+	}
+	if sighting.workspace == "" {
+		return fmt.Sprintf("no %s tag in AWS", b.tag)
+	}
+	return fmt.Sprintf("%s=%s in AWS", b.tag, sighting.workspace)
+}
@@ -0,0 +1,90 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+)
+
+// workspaceProvider serves Terraform instances read from several workspaces
+type workspaceProvider struct {
+	streamingProvider
+	workspaces []string
+}
+
+func (m *workspaceProvider) Workspaces() []string { return m.workspaces }
+
+func workspaceInstance(id, workspace string) *model.Instance {
+	instance := model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+	instance.Workspace = workspace
+	return instance
+}
+
+func taggedInstance(id, environment string) *model.Instance {
+	tags := map[string]string{}
+	if environment != "" {
+		tags["Env"] = environment
+	}
+	return model.NewInstance(id, map[string]interface{}{"instance_type": "t2.micro", "tags": tags}, model.OriginAWS)
+}
+
+func TestDetectDriftForAll_Workspaces(t *testing.T) {
+	for _, parallelProviders := range []bool{false, true} {
+		var buf bytes.Buffer
+		awsProvider := &streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: []*model.Instance{
+			taggedInstance("i-web", "dev"),
+			taggedInstance("i-api", "dev"), // managed in prod
+			taggedInstance("i-db", "prod"),
+			taggedInstance("i-qa", "qa"), // not a compared workspace
+			taggedInstance("i-untagged", ""),
+		}}}
+		terraformProvider := &workspaceProvider{
+			streamingProvider: streamingProvider{mockInstanceProvider: mockInstanceProvider{instances: []*model.Instance{
+				workspaceInstance("i-web", "dev"),
+				workspaceInstance("i-db", "dev"), // also managed in prod
+				workspaceInstance("i-api", "prod"),
+				workspaceInstance("i-db", "prod"),
+			}}},
+			workspaces: []string{"dev", "prod"},
+		}
+
+		detector := app.NewDriftDetectorService(awsProvider, terraformProvider, &mockRepository{}, nil, service.DriftDetectorConfig{
+			SourceOfTruth:     model.OriginTerraform,
+			AttributePaths:    []string{"instance_type"},
+			Timeout:           2 * time.Second,
+			ParallelChecks:    1,
+			ParallelProviders: parallelProviders,
+			EnvironmentTag:    "Env",
+		}, logging.NewLogger(logging.LogConfig{Level: logging.Info, Output: &buf}))
+
+		results, err := detector.DetectDriftForAll(context.Background(), nil)
+		require.NoError(t, err)
+
+		// Instances are only paired within their workspace
+		byKey := make(map[string]*model.DriftResult)
+		for _, result := range results {
+			byKey[result.Workspace+"/"+result.ResourceID] = result
+		}
+		assert.Len(t, byKey, 5)
+		assert.False(t, byKey["dev/i-web"].HasDrift)
+		assert.False(t, byKey["prod/i-db"].HasDrift)
+		// Only in AWS in dev, only in Terraform, the source of truth, in prod
+		assert.Equal(t, false, byKey["dev/i-api"].DriftedAttributes[model.AttributeExists].SourceValue)
+		assert.Equal(t, true, byKey["prod/i-api"].DriftedAttributes[model.AttributeExists].SourceValue)
+		assert.Equal(t, true, byKey["dev/i-db"].DriftedAttributes[model.AttributeExists].SourceValue)
+
+		logs := buf.String()
+		assert.Contains(t, logs, "Instance i-api belongs to several workspaces (Env=dev in AWS, Terraform workspace prod)")
+		assert.Contains(t, logs, "Instance i-db belongs to several workspaces (Env=prod in AWS, Terraform workspace dev, Terraform workspace prod)")
+		assert.NotContains(t, logs, "Instance i-web belongs")
+		assert.Contains(t, logs, "Skipped 2 AWS instances whose Env tag names none of the Terraform workspaces")
+	}
+}
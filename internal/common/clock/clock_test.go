@@ -0,0 +1,32 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReal_Now(t *testing.T) {
+	before := time.Now()
+	now := Real().Now()
+	assert.False(t, now.Before(before))
+}
+
+func TestOrReal(t *testing.T) {
+	fake := NewFake(time.Unix(0, 0))
+	assert.Equal(t, fake, OrReal(fake))
+	assert.Equal(t, Real(), OrReal(nil))
+}
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	fake := NewFake(start)
+	assert.Equal(t, start, fake.Now())
+
+	fake.Advance(time.Hour)
+	assert.Equal(t, start.Add(time.Hour), fake.Now())
+
+	fake.Set(start)
+	assert.Equal(t, start, fake.Now())
+}
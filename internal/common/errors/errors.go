@@ -19,6 +19,9 @@ const (
 
 	// NotFoundError represents a resource not found error
 	NotFoundError ErrorType = "NOT_FOUND_ERROR"
+
+	// DriftDetectedError represents a run that completed but found drift or policy violations
+	DriftDetectedError ErrorType = "DRIFT_DETECTED"
 )
 
 // AppError represents an application-specific error with contextual information
@@ -92,6 +95,15 @@ func NewNotFoundError(resourceType string, identifier string) *AppError {
 	}
 }
 
+// NewDriftDetectedError creates a new drift detected error
+func NewDriftDetectedError(message string) *AppError {
+	return &AppError{
+		Type:    DriftDetectedError,
+		Message: message,
+		Context: make(map[string]interface{}),
+	}
+}
+
 // IsSystemError checks if an error is a system error
 func IsSystemError(err error) bool {
 	if appErr, ok := err.(*AppError); ok {
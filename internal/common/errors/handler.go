@@ -2,13 +2,20 @@ package errors
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime/debug"
+	"sync"
 )
 
 // ErrorHandler defines how to handle different types of errors
 type ErrorHandler struct {
 	logger Logger
+
+	mu     sync.RWMutex
+	format ErrorFormat
+	out    io.Writer
+	exit   func(code int)
 }
 
 // Logger defines the minimal logging interface required by ErrorHandler
@@ -23,9 +30,26 @@ type Logger interface {
 func NewErrorHandler(logger Logger) *ErrorHandler {
 	return &ErrorHandler{
 		logger: logger,
+		format: FormatText,
+		out:    os.Stderr,
+		exit:   os.Exit,
 	}
 }
 
+// SetFormat sets how HandleWithExit reports errors
+func (h *ErrorHandler) SetFormat(format ErrorFormat) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.format = format
+}
+
+// Format returns how HandleWithExit reports errors
+func (h *ErrorHandler) Format() ErrorFormat {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.format
+}
+
 // Handle handles an error based on its type
 func (h *ErrorHandler) Handle(err error) {
 	if err == nil {
@@ -49,6 +73,8 @@ func (h *ErrorHandler) Handle(err error) {
 		h.handleValidationError(appErr)
 	case NotFoundError:
 		h.handleNotFoundError(appErr)
+	case DriftDetectedError:
+		h.handleDriftDetectedError(appErr)
 	default:
 		h.handleOperationalError(appErr)
 	}
@@ -56,14 +82,19 @@ func (h *ErrorHandler) Handle(err error) {
 
 // handleSystemError handles system errors by logging and panicking
 func (h *ErrorHandler) handleSystemError(err *AppError) {
-	stackTrace := string(debug.Stack())
-	h.logger.Error(fmt.Sprintf("SYSTEM ERROR: %s (cause: %v)", err.Message, err.Cause))
-	h.logger.Error(fmt.Sprintf("Stack trace: %s", stackTrace))
+	h.logSystemError(err)
 
 	// System errors should cause application to panic
 	panic(fmt.Sprintf("System error: %s", err.Error()))
 }
 
+// logSystemError logs a system error with its stack trace
+func (h *ErrorHandler) logSystemError(err *AppError) {
+	stackTrace := string(debug.Stack())
+	h.logger.Error(fmt.Sprintf("SYSTEM ERROR: %s (cause: %v)", err.Message, err.Cause))
+	h.logger.Error(fmt.Sprintf("Stack trace: %s", stackTrace))
+}
+
 // handleOperationalError handles operational errors by logging
 func (h *ErrorHandler) handleOperationalError(err *AppError) {
 	h.logger.Error(fmt.Sprintf("OPERATIONAL ERROR: %s (cause: %v)", err.Message, err.Cause))
@@ -94,18 +125,37 @@ func (h *ErrorHandler) handleNotFoundError(err *AppError) {
 	}
 }
 
-// HandleWithExit handles an error and exits the program with the appropriate exit code if needed
+// handleDriftDetectedError handles runs that found drift
+func (h *ErrorHandler) handleDriftDetectedError(err *AppError) {
+	h.logger.Warn(fmt.Sprintf("DRIFT DETECTED: %s", err.Message))
+}
+
+// HandleWithExit reports an error and exits with the exit code for its type: 1 for operational,
+// 2 for drift detected, 3 for not found, 4 for validation and 5 for system errors. In JSON format a single error
+// report is written to stderr instead of log lines.
 func (h *ErrorHandler) HandleWithExit(err error) {
 	if err == nil {
 		return
 	}
 
-	h.Handle(err)
-
-	// Exit with non-zero status code for system errors
-	if IsSystemError(err) {
-		os.Exit(1)
+	h.mu.RLock()
+	format, out, exit := h.format, h.out, h.exit
+	h.mu.RUnlock()
+
+	appErr := toAppError(err)
+	switch {
+	case format == FormatJSON:
+		if writeErr := writeReport(out, appErr); writeErr != nil {
+			h.logger.Error(fmt.Sprintf("Failed to write error report: %v", writeErr))
+		}
+	case appErr.Type == SystemError:
+		// Exit with the system error code rather than panicking
+		h.logSystemError(appErr)
+	default:
+		h.Handle(appErr)
 	}
+
+	exit(ExitCode(appErr))
 }
 
 // MustHandle handles an error and panics if it's a system error
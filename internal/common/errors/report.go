@@ -0,0 +1,109 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+)
+
+// ErrorFormat controls how HandleWithExit reports an error before exiting
+type ErrorFormat string
+
+const (
+	// FormatText logs errors as human-readable messages
+	FormatText ErrorFormat = "text"
+
+	// FormatJSON writes a single JSON error report for automation
+	FormatJSON ErrorFormat = "json"
+)
+
+// Exit codes per error type
+const (
+	ExitCodeOperational = 1
+	ExitCodeDrift       = 2
+	ExitCodeNotFound    = 3
+	ExitCodeValidation  = 4
+	ExitCodeSystem      = 5
+)
+
+// ParseErrorFormat parses an error format name
+func ParseErrorFormat(format string) (ErrorFormat, error) {
+	switch ErrorFormat(format) {
+	case FormatText, FormatJSON:
+		return ErrorFormat(format), nil
+	}
+	return "", NewValidationError(fmt.Sprintf("Invalid error format %q (expected text or json)", format))
+}
+
+// ErrorReport is the machine-readable form of an error
+type ErrorReport struct {
+	Type      ErrorType              `json:"type"`
+	Message   string                 `json:"message"`
+	Cause     string                 `json:"cause,omitempty"`
+	Context   map[string]interface{} `json:"context"`
+	Retryable bool                   `json:"retryable"`
+	ExitCode  int                    `json:"exit_code"`
+}
+
+// NewErrorReport builds the report for an error. Errors that are not AppErrors are reported
+// as operational errors.
+func NewErrorReport(err error) ErrorReport {
+	appErr := toAppError(err)
+
+	report := ErrorReport{
+		Type:      appErr.Type,
+		Message:   appErr.Message,
+		Context:   appErr.Context,
+		Retryable: appErr.Type == OperationalError,
+		ExitCode:  ExitCode(appErr),
+	}
+	if appErr.Cause != nil {
+		report.Cause = appErr.Cause.Error()
+	}
+	if report.Context == nil {
+		report.Context = map[string]interface{}{}
+	}
+
+	return report
+}
+
+// ExitCode returns the process exit code for an error
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	switch toAppError(err).Type {
+	case ValidationError:
+		return ExitCodeValidation
+	case NotFoundError:
+		return ExitCodeNotFound
+	case SystemError:
+		return ExitCodeSystem
+	case DriftDetectedError:
+		return ExitCodeDrift
+	default:
+		return ExitCodeOperational
+	}
+}
+
+// toAppError returns the AppError in err's chain, wrapping other errors as operational errors
+func toAppError(err error) *AppError {
+	var appErr *AppError
+	if stderrors.As(err, &appErr) {
+		return appErr
+	}
+
+	if stderrors.Is(err, context.DeadlineExceeded) || stderrors.Is(err, context.Canceled) {
+		return NewOperationalError("Operation cancelled or timed out", err)
+	}
+	return NewOperationalError(err.Error(), nil)
+}
+
+// writeReport writes the JSON error report for err to w
+func writeReport(w io.Writer, err error) error {
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(NewErrorReport(err))
+}
@@ -0,0 +1,112 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, ExitCode(nil))
+	assert.Equal(t, ExitCodeOperational, ExitCode(NewOperationalError("AWS call failed", nil)))
+	assert.Equal(t, ExitCodeNotFound, ExitCode(NewNotFoundError("Instance", "i-123")))
+	assert.Equal(t, ExitCodeValidation, ExitCode(NewValidationError("Invalid config")))
+	assert.Equal(t, ExitCodeSystem, ExitCode(NewSystemError("Failed to load configuration", nil)))
+	assert.Equal(t, ExitCodeDrift, ExitCode(NewDriftDetectedError("2 of 5 instances drifted")))
+	assert.Equal(t, ExitCodeOperational, ExitCode(errors.New("plain error")))
+
+	// Wrapped application errors keep their type
+	wrapped := fmt.Errorf("running detect: %w", NewValidationError("Invalid config"))
+	assert.Equal(t, ExitCodeValidation, ExitCode(wrapped))
+}
+
+func TestNewErrorReport(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		errType   ErrorType
+		retryable bool
+		exitCode  int
+	}{
+		{"operational", NewOperationalError("Failed to list instances", errors.New("throttled")), OperationalError, true, 1},
+		{"not found", NewNotFoundError("Instance", "i-123"), NotFoundError, false, 3},
+		{"validation", NewValidationError("AWS region cannot be empty"), ValidationError, false, 4},
+		{"system", NewSystemError("Failed to load configuration", errors.New("permission denied")), SystemError, false, 5},
+		{"plain", errors.New("state file missing"), OperationalError, true, 1},
+		{"timeout", context.DeadlineExceeded, OperationalError, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewErrorReport(tt.err)
+			assert.Equal(t, tt.errType, report.Type)
+			assert.Equal(t, tt.retryable, report.Retryable)
+			assert.Equal(t, tt.exitCode, report.ExitCode)
+			assert.NotEmpty(t, report.Message)
+			assert.NotNil(t, report.Context)
+		})
+	}
+
+	report := NewErrorReport(NewNotFoundError("Instance", "i-123"))
+	assert.Equal(t, "i-123", report.Context["identifier"])
+
+	report = NewErrorReport(NewOperationalError("Failed to list instances", errors.New("throttled")))
+	assert.Equal(t, "throttled", report.Cause)
+}
+
+func TestHandleWithExit_JSON(t *testing.T) {
+	logger := new(MockLogger)
+	handler := NewErrorHandler(logger)
+	handler.SetFormat(FormatJSON)
+
+	var out bytes.Buffer
+	var exitCode int
+	handler.out = &out
+	handler.exit = func(code int) { exitCode = code }
+
+	handler.HandleWithExit(NewValidationError("Reporter type must be 'json', 'console', or 'both'").WithContext("key", "reporter.type"))
+
+	assert.Equal(t, ExitCodeValidation, exitCode)
+
+	var report map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, "VALIDATION_ERROR", report["type"])
+	assert.Equal(t, "Reporter type must be 'json', 'console', or 'both'", report["message"])
+	assert.Equal(t, map[string]interface{}{"key": "reporter.type"}, report["context"])
+	assert.Equal(t, false, report["retryable"])
+	assert.Equal(t, float64(4), report["exit_code"])
+
+	// JSON output replaces the log lines
+	logger.AssertNotCalled(t, "Error", mock.Anything, mock.Anything)
+	logger.AssertNotCalled(t, "Warn", mock.Anything, mock.Anything)
+}
+
+func TestHandleWithExit_TextSystemErrorExits(t *testing.T) {
+	logger := new(MockLogger)
+	logger.On("Error", mock.Anything, mock.Anything).Return()
+	handler := NewErrorHandler(logger)
+
+	var exitCode int
+	handler.exit = func(code int) { exitCode = code }
+
+	// System errors exit with their code instead of panicking
+	assert.NotPanics(t, func() {
+		handler.HandleWithExit(NewSystemError("Failed to load configuration", nil))
+	})
+	assert.Equal(t, ExitCodeSystem, exitCode)
+}
+
+func TestParseErrorFormat(t *testing.T) {
+	format, err := ParseErrorFormat("json")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatJSON, format)
+
+	_, err = ParseErrorFormat("yaml")
+	assert.True(t, IsValidationError(err))
+}
@@ -0,0 +1,48 @@
+// Package schedule parses drift check schedule expressions and previews when they fire
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// PreviewCount is the number of upcoming runs shown in schedule previews
+const PreviewCount = 3
+
+// runLayout formats run times to the minute with the zone abbreviation
+const runLayout = "Mon 2006-01-02 15:04 MST"
+
+// Parse parses a standard five-field cron expression or descriptor such as @hourly, the
+// syntax the scheduler accepts
+func Parse(expression string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid schedule expression %q: %v", expression, err))
+	}
+	return schedule, nil
+}
+
+// NextRuns returns the next n times the schedule fires after from, in from's location. Like the
+// scheduler, runs at wall-clock times skipped by a DST change are dropped and runs in a
+// repeated hour fire twice.
+func NextRuns(schedule cron.Schedule, from time.Time, n int) []time.Time {
+	runs := make([]time.Time, 0, n)
+	next := from
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+	}
+	return runs
+}
+
+// FormatRun formats a run time in its own location followed by UTC, e.g.
+// "Sun 2025-03-30 06:00 BST (Sun 2025-03-30 05:00 UTC)"
+func FormatRun(t time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.Format(runLayout), t.UTC().Format(runLayout))
+}
@@ -0,0 +1,87 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+func loadLondon(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func TestParse(t *testing.T) {
+	_, err := Parse("0 */6 * * *")
+	assert.NoError(t, err)
+
+	_, err = Parse("@hourly")
+	assert.NoError(t, err)
+
+	_, err = Parse("0 */6 * *")
+	assert.True(t, errors.IsValidationError(err))
+	assert.ErrorContains(t, err, `Invalid schedule expression "0 */6 * *"`)
+}
+
+func TestNextRuns(t *testing.T) {
+	schedule, err := Parse("0 */6 * * *")
+	require.NoError(t, err)
+
+	from := time.Date(2025, 5, 1, 7, 15, 0, 0, time.UTC)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC),
+	}, NextRuns(schedule, from, PreviewCount))
+
+	// A run exactly at from is not upcoming
+	assert.Equal(t, time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC), NextRuns(schedule, from.Add(4*time.Hour+45*time.Minute), 1)[0])
+}
+
+func TestNextRuns_SpringForward(t *testing.T) {
+	london := loadLondon(t)
+
+	// Every six hours keeps its wall-clock times, so the gap across the change is five hours
+	schedule, err := Parse("0 */6 * * *")
+	require.NoError(t, err)
+	runs := NextRuns(schedule, time.Date(2025, 3, 29, 20, 0, 0, 0, london), PreviewCount)
+	require.Len(t, runs, 3)
+	assert.Equal(t, "Sun 2025-03-30 00:00 GMT (Sun 2025-03-30 00:00 UTC)", FormatRun(runs[0]))
+	assert.Equal(t, "Sun 2025-03-30 06:00 BST (Sun 2025-03-30 05:00 UTC)", FormatRun(runs[1]))
+	assert.Equal(t, 5*time.Hour, runs[1].Sub(runs[0]))
+
+	// 01:30 doesn't exist on the day clocks go forward, so that run is skipped
+	schedule, err = Parse("30 1 * * *")
+	require.NoError(t, err)
+	runs = NextRuns(schedule, time.Date(2025, 3, 29, 12, 0, 0, 0, london), 1)
+	assert.Equal(t, "Mon 2025-03-31 01:30 BST (Mon 2025-03-31 00:30 UTC)", FormatRun(runs[0]))
+}
+
+func TestNextRuns_FallBack(t *testing.T) {
+	london := loadLondon(t)
+
+	// 01:30 happens twice on the day clocks go back, and the scheduler fires both times
+	schedule, err := Parse("30 1 * * *")
+	require.NoError(t, err)
+	runs := NextRuns(schedule, time.Date(2025, 10, 25, 12, 0, 0, 0, london), PreviewCount)
+	require.Len(t, runs, 3)
+	assert.Equal(t, "Sun 2025-10-26 01:30 BST (Sun 2025-10-26 00:30 UTC)", FormatRun(runs[0]))
+	assert.Equal(t, "Sun 2025-10-26 01:30 GMT (Sun 2025-10-26 01:30 UTC)", FormatRun(runs[1]))
+	assert.Equal(t, "Mon 2025-10-27 01:30 GMT (Mon 2025-10-27 01:30 UTC)", FormatRun(runs[2]))
+}
+
+func TestFormatRun(t *testing.T) {
+	run := time.Date(2025, 5, 1, 18, 0, 0, 0, time.FixedZone("WAT", 3600))
+	assert.Equal(t, "Thu 2025-05-01 18:00 WAT (Thu 2025-05-01 17:00 UTC)", FormatRun(run))
+
+	// The UTC date is shown when it differs
+	run = time.Date(2025, 5, 1, 0, 30, 0, 0, time.FixedZone("WAT", 3600))
+	assert.Equal(t, "Thu 2025-05-01 00:30 WAT (Wed 2025-04-30 23:30 UTC)", FormatRun(run))
+}
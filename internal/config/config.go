@@ -1,11 +1,17 @@
 package config
 
 import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/presentation/reporter"
 )
 
 // Config holds all application configuration
@@ -16,15 +22,46 @@ type Config struct {
 	terraform terraformConfig
 	detector  detectorConfig
 	reporter  reporterConfig
+	server    serverConfig
+	accounts  []AccountConfig
+
+	// references maps keys whose values were resolved from SSM or Secrets Manager references
+	// to the references
+	references map[string]string
 
 	mu sync.RWMutex
 }
 
+// ReporterConfig describes one reporter of the reporters list
+type ReporterConfig struct {
+	Type        string
+	OutputFile  string
+	PrettyPrint bool
+}
+
+// AccountConfig describes an AWS account to scan by assuming a role in it
+type AccountConfig struct {
+	RoleARN string
+	Region  string
+}
+
+// AccountID extracts the account ID from the role ARN (arn:aws:iam::<account-id>:role/<name>)
+func (a AccountConfig) AccountID() string {
+	parts := strings.Split(a.RoleARN, ":")
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[4]
+}
+
 type appConfig struct {
 	env                string
 	logLevel           logging.LogLevel
 	jsonLogs           bool
 	scheduleExpression string
+
+	// shutdownGracePeriod is how long a run may keep going after a shutdown signal
+	shutdownGracePeriod time.Duration
 }
 
 type awsConfig struct {
@@ -33,25 +70,119 @@ type awsConfig struct {
 	secretAccessKey string
 	profile         string
 	endpoint        string
+
+	// source is where AWS instances are read from: EC2 or the items AWS Config recorded
+	source string
+	asOf   string
 }
 
 type terraformConfig struct {
-	stateFile string
-	hclDir    string
-	useHCL    bool
+	stateFile      string
+	hclDir         string
+	useHCL         bool
+	sopsAgeKeyFile string
+	tfcWorkspace   string
+	tfcToken       string
+	tfcAddress     string
+	httpUsername   string
+	httpPassword   string
+	httpToken      string
+	s3Region       string
+	s3PathStyle    bool
+	includeTainted bool
+	resolveSSMAMI  bool
+	cacheState     bool
+	readRetries    int
+
+	// allowUnsupportedState parses states newer than the supported versions best-effort
+	allowUnsupportedState bool
+
+	workspaces         []string
+	workspaceKeyPrefix string
 }
 
 type detectorConfig struct {
-	attributes     []string
-	sourceOfTruth  string
-	parallelChecks int
-	timeoutSeconds int
+	attributes         []string
+	sourceOfTruth      string
+	parallelChecks     int
+	maxParallelChecks  int
+	timeout            time.Duration
+	awsTimeoutSeconds  int
+	tfTimeoutSeconds   int
+	abortAfterErrors   int
+	errorOnEmpty       bool
+	minInstances       int
+	parallelProviders  bool
+	staticIPsOnly      bool
+	sourceDeclaredOnly bool
+	checkOrphans       bool
+	emptyEqualsAbsent  bool
+	strictPresence     []string
+	trimTagValues      bool
+	ignoreTagCase      bool
+	policies           []model.Policy
+	allowedTypes       []string
+	environmentTag     string
+	strictAccountCheck bool
+	suggestRemediation bool
+	networkContext     bool
+	terminationCheck   bool
+	memoize            bool
+	hashPrefilter      bool
+	driftAgeLookback   int
+	storeValues        string
+	volatileAttributes []string
+	attributePresets   map[string][]string
+	resourceFilter     []string
+	storeValuesMax     int
+	userDataHash       bool
+	userDataDiff       bool
+	useTagsAll         bool
+	debugDumpDir       string
+	debugDumpMax       int
+}
+
+type serverConfig struct {
+	healthPort               int
+	readinessIntervalMinutes int
 }
 
 type reporterConfig struct {
-	typeVal     string
-	outputFile  string
-	prettyPrint bool
+	// list holds the reporters configured under reporters; without one, typeVal,
+	// outputFile and prettyPrint describe the only reporter
+	list []ReporterConfig
+
+	typeVal        string
+	outputFile     string
+	prettyPrint    bool
+	digestInterval time.Duration
+	digestSeverity string
+	digestFile     string
+	timeout        time.Duration
+
+	// maxBytes caps the size of JSON reports, dropping the results that don't fit; 0 never truncates
+	maxBytes int
+
+	// includeSnapshots adds the full attributes of both compared instances to each result
+	includeSnapshots bool
+
+	teamsWebhookURL   string
+	teamsMaxInstances int
+	teamsReportURL    string
+
+	httpMaxRetries int
+	httpProxyURL   string
+
+	jsonMaxResultsPerFile int
+	jsonCleanReport       bool
+	jsonSigningKey        string
+
+	consoleTemplate  string
+	markdownTemplate string
+	timezone         string
+
+	// severityColors overrides the colors of drifted attributes in console reports by severity
+	severityColors map[string]string
 }
 
 // ------- App Getters/Setters -------
@@ -103,6 +234,18 @@ func (c *Config) SetScheduleExpression(expr string) {
 	c.app.scheduleExpression = expr
 }
 
+func (c *Config) GetShutdownGracePeriod() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.app.shutdownGracePeriod
+}
+
+func (c *Config) SetShutdownGracePeriod(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.app.shutdownGracePeriod = d
+}
+
 // ------- AWS Getters/Setters -------
 func (c *Config) GetAWSRegion() string {
 	c.mu.RLock()
@@ -164,6 +307,43 @@ func (c *Config) SetAWSEndpoint(endpoint string) {
 	c.aws.endpoint = endpoint
 }
 
+func (c *Config) GetAWSSource() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.aws.source
+}
+
+func (c *Config) SetAWSSource(source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aws.source = source
+}
+
+// GetAWSAsOf returns the point in time AWS Config items are read at, zero for the latest
+func (c *Config) GetAWSAsOf() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	asOf, _ := parseAsOf(c.aws.asOf)
+	return asOf
+}
+
+func (c *Config) SetAWSAsOf(asOf string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aws.asOf = asOf
+}
+
+// parseAsOf parses an RFC 3339 timestamp or a date, which is read as midnight UTC
+func parseAsOf(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if asOf, err := time.Parse(time.RFC3339, value); err == nil {
+		return asOf, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
 // ------- Terraform Getters/Setters -------
 func (c *Config) GetStateFile() string {
 	c.mu.RLock()
@@ -201,6 +381,198 @@ func (c *Config) SetHCLDir(val string) {
 	c.terraform.hclDir = val
 }
 
+func (c *Config) GetSOPSAgeKeyFile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.sopsAgeKeyFile
+}
+
+func (c *Config) SetSOPSAgeKeyFile(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.sopsAgeKeyFile = val
+}
+
+func (c *Config) GetTFCWorkspace() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.tfcWorkspace
+}
+
+func (c *Config) SetTFCWorkspace(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.tfcWorkspace = val
+}
+
+func (c *Config) GetTFCToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.tfcToken
+}
+
+func (c *Config) SetTFCToken(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.tfcToken = val
+}
+
+func (c *Config) GetTFCAddress() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.tfcAddress
+}
+
+func (c *Config) SetTFCAddress(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.tfcAddress = val
+}
+
+func (c *Config) GetStateHTTPUsername() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.httpUsername
+}
+
+func (c *Config) SetStateHTTPUsername(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.httpUsername = val
+}
+
+func (c *Config) GetStateHTTPPassword() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.httpPassword
+}
+
+func (c *Config) SetStateHTTPPassword(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.httpPassword = val
+}
+
+func (c *Config) GetStateHTTPToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.httpToken
+}
+
+func (c *Config) SetStateHTTPToken(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.httpToken = val
+}
+
+func (c *Config) GetStateS3Region() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.s3Region
+}
+
+func (c *Config) SetStateS3Region(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.s3Region = val
+}
+
+func (c *Config) GetStateS3UsePathStyle() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.s3PathStyle
+}
+
+func (c *Config) SetStateS3UsePathStyle(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.s3PathStyle = val
+}
+
+func (c *Config) GetIncludeTainted() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.includeTainted
+}
+
+func (c *Config) SetIncludeTainted(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.includeTainted = val
+}
+
+func (c *Config) GetAllowUnsupportedState() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.allowUnsupportedState
+}
+
+func (c *Config) SetAllowUnsupportedState(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.allowUnsupportedState = val
+}
+
+func (c *Config) GetResolveSSMAMI() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.resolveSSMAMI
+}
+
+func (c *Config) SetResolveSSMAMI(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.resolveSSMAMI = val
+}
+
+func (c *Config) GetCacheState() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.cacheState
+}
+
+func (c *Config) SetCacheState(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.cacheState = val
+}
+
+func (c *Config) GetStateReadRetries() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.readRetries
+}
+
+func (c *Config) SetStateReadRetries(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.readRetries = val
+}
+
+func (c *Config) GetWorkspaces() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.workspaces
+}
+
+func (c *Config) SetWorkspaces(val []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.workspaces = val
+}
+
+func (c *Config) GetWorkspaceKeyPrefix() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.terraform.workspaceKeyPrefix
+}
+
+func (c *Config) SetWorkspaceKeyPrefix(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.terraform.workspaceKeyPrefix = val
+}
+
 // ------- Detector Getters/Setters -------
 func (c *Config) GetSourceOfTruth() string {
 	c.mu.RLock()
@@ -232,103 +604,985 @@ func (c *Config) GetParallelChecks() int {
 	return c.detector.parallelChecks
 }
 
+// SetParallelChecks sets the number of parallel checks, capped at the max parallel checks
 func (c *Config) SetParallelChecks(val int) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.detector.maxParallelChecks > 0 && val > c.detector.maxParallelChecks {
+		val = c.detector.maxParallelChecks
+	}
 	c.detector.parallelChecks = val
 }
 
+func (c *Config) GetMaxParallelChecks() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.maxParallelChecks
+}
+
+// SetMaxParallelChecks sets the cap on parallel checks, lowering the current value if needed.
+// 0 disables the cap.
+func (c *Config) SetMaxParallelChecks(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.maxParallelChecks = val
+	if val > 0 && c.detector.parallelChecks > val {
+		c.detector.parallelChecks = val
+	}
+}
+
+// DefaultParallelChecks is the number of parallel checks used when none is configured: two per
+// CPU, since checks mostly wait on AWS, up to 16
+func DefaultParallelChecks() int {
+	return min(2*runtime.NumCPU(), maxAutoParallelChecks)
+}
+
 func (c *Config) GetTimeout() time.Duration {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return time.Duration(c.detector.timeoutSeconds) * time.Second
+	return c.detector.timeout
 }
 
 func (c *Config) SetTimeout(d time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.detector.timeoutSeconds = int(d.Seconds())
+	c.detector.timeout = d
 }
 
-// ------- Reporter Getters/Setters -------
-func (c *Config) GetReporterType() string {
+func (c *Config) GetAWSTimeout() time.Duration {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.reporter.typeVal
+	return time.Duration(c.detector.awsTimeoutSeconds) * time.Second
 }
 
-func (c *Config) SetReporterType(val string) {
+func (c *Config) SetAWSTimeout(d time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.reporter.typeVal = val
+	c.detector.awsTimeoutSeconds = int(d.Seconds())
 }
 
-func (c *Config) GetOutputFile() string {
+func (c *Config) GetTerraformTimeout() time.Duration {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.reporter.outputFile
+	return time.Duration(c.detector.tfTimeoutSeconds) * time.Second
 }
 
-func (c *Config) SetOutputFile(val string) {
+func (c *Config) SetTerraformTimeout(d time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.reporter.outputFile = val
+	c.detector.tfTimeoutSeconds = int(d.Seconds())
 }
 
-func (c *Config) GetPrettyPrint() bool {
+func (c *Config) GetAbortAfterErrors() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.reporter.prettyPrint
+	return c.detector.abortAfterErrors
 }
 
-func (c *Config) SetPrettyPrint(val bool) {
+func (c *Config) SetAbortAfterErrors(val int) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.reporter.prettyPrint = val
+	c.detector.abortAfterErrors = val
 }
 
-// ------- Validation -------
-func (c *Config) Validate() error {
+func (c *Config) GetParallelProviders() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	return c.detector.parallelProviders
+}
 
-	if c.aws.region == "" {
-		return errors.NewValidationError("AWS region cannot be empty")
-	}
+func (c *Config) SetParallelProviders(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.parallelProviders = val
+}
 
-	if c.terraform.useHCL {
-		if c.terraform.hclDir == "" {
-			return errors.NewValidationError("Terraform HCL directory cannot be empty when UseHCL is true")
-		}
-	} else {
-		if c.terraform.stateFile == "" {
-			return errors.NewValidationError("Terraform state file cannot be empty when UseHCL is false")
-		}
-	}
+func (c *Config) GetErrorOnEmpty() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.errorOnEmpty
+}
 
-	if len(c.detector.attributes) == 0 {
-		return errors.NewValidationError("At least one attribute must be specified for drift detection")
-	}
+func (c *Config) SetErrorOnEmpty(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.errorOnEmpty = val
+}
 
-	if c.detector.sourceOfTruth != "aws" && c.detector.sourceOfTruth != "terraform" {
-		return errors.NewValidationError("Source of truth must be either 'aws' or 'terraform'")
-	}
+func (c *Config) GetStaticIPsOnly() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.staticIPsOnly
+}
 
-	if c.detector.parallelChecks <= 0 {
-		return errors.NewValidationError("Parallel checks must be greater than 0")
-	}
+func (c *Config) SetStaticIPsOnly(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.staticIPsOnly = val
+}
 
-	if c.detector.timeoutSeconds <= 0 {
-		return errors.NewValidationError("Timeout seconds must be greater than 0")
-	}
+func (c *Config) GetSourceDeclaredOnly() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.sourceDeclaredOnly
+}
 
-	if c.reporter.typeVal != ReporterTypeConsole && c.reporter.typeVal != ReporterTypeJSON && c.reporter.typeVal != ReporterTypeBoth {
-		return errors.NewValidationError("Reporter type must be 'json', 'console', or 'both'")
-	}
+func (c *Config) SetSourceDeclaredOnly(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.sourceDeclaredOnly = val
+}
 
-	// if (c.reporter.typeVal == ReporterTypeJSON || c.reporter.typeVal == ReporterTypeBoth) && c.reporter.outputFile == "" {
-	// 	return errors.NewValidationError("Output file must be specified for JSON reporter")
-	// }
+func (c *Config) GetCheckOrphans() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.checkOrphans
+}
+
+func (c *Config) SetCheckOrphans(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.checkOrphans = val
+}
+
+func (c *Config) GetEmptyEqualsAbsent() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.emptyEqualsAbsent
+}
+
+func (c *Config) SetEmptyEqualsAbsent(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.emptyEqualsAbsent = val
+}
+
+func (c *Config) GetStrictPresencePaths() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.strictPresence
+}
+
+func (c *Config) SetStrictPresencePaths(val []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.strictPresence = val
+}
+
+func (c *Config) GetVolatileAttributes() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.volatileAttributes
+}
+
+func (c *Config) SetVolatileAttributes(val []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.volatileAttributes = val
+}
+
+func (c *Config) GetAttributePresets() map[string][]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.attributePresets
+}
+
+func (c *Config) SetAttributePresets(val map[string][]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.attributePresets = val
+}
+
+// AttributePreset returns the attribute paths of a named preset: detector.attribute_presets
+// overrides and extends the built-in presets
+func (c *Config) AttributePreset(name string) ([]string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if paths, ok := c.detector.attributePresets[name]; ok {
+		return append([]string(nil), paths...), true
+	}
+	return model.AttributePreset(name)
+}
+
+// AttributePresetNames returns the names of the built-in and configured presets, sorted
+func (c *Config) AttributePresetNames() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := model.AttributePresetNames()
+	for name := range c.detector.attributePresets {
+		if _, builtIn := model.AttributePreset(name); !builtIn {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Config) GetResourceFilter() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.resourceFilter
+}
+
+func (c *Config) SetResourceFilter(val []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.resourceFilter = val
+}
+
+func (c *Config) GetTrimTagValues() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.trimTagValues
+}
+
+func (c *Config) SetTrimTagValues(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.trimTagValues = val
+}
+
+func (c *Config) GetIgnoreTagCase() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.ignoreTagCase
+}
+
+func (c *Config) SetIgnoreTagCase(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.ignoreTagCase = val
+}
+
+func (c *Config) GetPolicies() []model.Policy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.policies
+}
+
+func (c *Config) SetPolicies(val []model.Policy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.policies = val
+}
+
+func (c *Config) GetAllowedInstanceTypes() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.allowedTypes
+}
+
+func (c *Config) SetAllowedInstanceTypes(val []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.allowedTypes = val
+}
+
+func (c *Config) GetEnvironmentTag() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.environmentTag
+}
+
+func (c *Config) SetEnvironmentTag(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.environmentTag = val
+}
+
+func (c *Config) GetMinInstances() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.minInstances
+}
+
+func (c *Config) SetMinInstances(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.minInstances = val
+}
+
+func (c *Config) GetStrictAccountCheck() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.strictAccountCheck
+}
+
+func (c *Config) SetStrictAccountCheck(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.strictAccountCheck = val
+}
+
+func (c *Config) GetSuggestRemediation() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.suggestRemediation
+}
+
+func (c *Config) SetSuggestRemediation(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.suggestRemediation = val
+}
+
+func (c *Config) GetEnrichNetworkContext() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.networkContext
+}
+
+func (c *Config) SetEnrichNetworkContext(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.networkContext = val
+}
+
+func (c *Config) GetCompareTerminationProtection() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.terminationCheck
+}
+
+func (c *Config) SetCompareTerminationProtection(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.terminationCheck = val
+}
+
+func (c *Config) GetMemoize() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.memoize
+}
+
+func (c *Config) SetMemoize(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.memoize = val
+}
+
+func (c *Config) GetHashPrefilter() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.hashPrefilter
+}
+
+func (c *Config) SetHashPrefilter(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.hashPrefilter = val
+}
+
+func (c *Config) GetDriftAgeLookback() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.driftAgeLookback
+}
+
+func (c *Config) SetDriftAgeLookback(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.driftAgeLookback = val
+}
+
+func (c *Config) GetStoreValues() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.storeValues
+}
+
+func (c *Config) SetStoreValues(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.storeValues = val
+}
+
+func (c *Config) GetStoreValuesMaxBytes() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.storeValuesMax
+}
+
+func (c *Config) SetStoreValuesMaxBytes(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.storeValuesMax = val
+}
+
+func (c *Config) GetUserDataHash() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.userDataHash
+}
+
+func (c *Config) SetUserDataHash(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.userDataHash = val
+}
+
+func (c *Config) GetUserDataDiff() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.userDataDiff
+}
+
+func (c *Config) SetUserDataDiff(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.userDataDiff = val
+}
+
+func (c *Config) GetDebugDumpDir() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.debugDumpDir
+}
+
+func (c *Config) SetDebugDumpDir(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.debugDumpDir = val
+}
+
+func (c *Config) GetDebugDumpMaxInstances() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.debugDumpMax
+}
+
+func (c *Config) SetDebugDumpMaxInstances(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.debugDumpMax = val
+}
+
+func (c *Config) GetUseTagsAll() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.detector.useTagsAll
+}
+
+func (c *Config) SetUseTagsAll(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detector.useTagsAll = val
+}
+
+// ------- Reporter Getters/Setters -------
+
+// GetReporters returns the configured reporters. Without a reporters list, the flat
+// reporter.type, reporter.output_file and reporter.pretty_print keys describe a single
+// reporter, or a console and a JSON reporter for the "both" type.
+func (c *Config) GetReporters() []ReporterConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.reporter.list) > 0 {
+		return append([]ReporterConfig(nil), c.reporter.list...)
+	}
+
+	single := ReporterConfig{Type: c.reporter.typeVal, OutputFile: c.reporter.outputFile, PrettyPrint: c.reporter.prettyPrint}
+	if single.Type != ReporterTypeBoth {
+		return []ReporterConfig{single}
+	}
+	console, json := single, single
+	console.Type, json.Type = ReporterTypeConsole, ReporterTypeJSON
+	return []ReporterConfig{console, json}
+}
+
+// SetReporters sets the reporters list; an empty list falls back to the flat reporter keys
+func (c *Config) SetReporters(reporters []ReporterConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.list = reporters
+}
+
+func (c *Config) GetReporterType() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.typeVal
+}
+
+func (c *Config) SetReporterType(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.typeVal = val
+}
+
+func (c *Config) GetOutputFile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.outputFile
+}
+
+func (c *Config) SetOutputFile(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.outputFile = val
+}
+
+func (c *Config) GetPrettyPrint() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.prettyPrint
+}
+
+func (c *Config) SetPrettyPrint(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.prettyPrint = val
+}
+
+func (c *Config) GetJSONCleanReport() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.jsonCleanReport
+}
+
+func (c *Config) SetJSONCleanReport(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.jsonCleanReport = val
+}
+
+func (c *Config) GetJSONSigningKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.jsonSigningKey
+}
+
+func (c *Config) SetJSONSigningKey(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.jsonSigningKey = val
+}
+
+func (c *Config) GetReporterTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.timeout
+}
+
+func (c *Config) SetReporterTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.timeout = d
+}
+
+func (c *Config) GetIncludeSnapshots() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.includeSnapshots
+}
+
+func (c *Config) SetIncludeSnapshots(val bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.includeSnapshots = val
+}
+
+func (c *Config) GetReporterMaxBytes() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.maxBytes
+}
+
+func (c *Config) SetReporterMaxBytes(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.maxBytes = val
+}
+
+func (c *Config) GetDigestInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.digestInterval
+}
+
+func (c *Config) SetDigestInterval(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.digestInterval = d
+}
+
+func (c *Config) GetDigestImmediateSeverity() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.digestSeverity
+}
+
+func (c *Config) SetDigestImmediateSeverity(severity string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.digestSeverity = strings.ToLower(strings.TrimSpace(severity))
+}
+
+func (c *Config) GetDigestFile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.digestFile
+}
+
+func (c *Config) SetDigestFile(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.digestFile = path
+}
+
+func (c *Config) GetTeamsWebhookURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.teamsWebhookURL
+}
+
+func (c *Config) SetTeamsWebhookURL(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.teamsWebhookURL = val
+}
+
+func (c *Config) GetTeamsMaxInstances() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.teamsMaxInstances
+}
+
+func (c *Config) SetTeamsMaxInstances(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.teamsMaxInstances = val
+}
+
+func (c *Config) GetTeamsReportURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.teamsReportURL
+}
+
+func (c *Config) SetTeamsReportURL(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.teamsReportURL = val
+}
+
+func (c *Config) GetHTTPMaxRetries() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.httpMaxRetries
+}
+
+func (c *Config) SetHTTPMaxRetries(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.httpMaxRetries = val
+}
+
+func (c *Config) GetHTTPProxyURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.httpProxyURL
+}
+
+func (c *Config) SetHTTPProxyURL(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.httpProxyURL = val
+}
+
+func (c *Config) GetConsoleTemplate() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.consoleTemplate
+}
+
+func (c *Config) SetConsoleTemplate(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.consoleTemplate = val
+}
+
+func (c *Config) GetSeverityColors() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.severityColors
+}
+
+func (c *Config) SetSeverityColors(val map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.severityColors = val
+}
+
+func (c *Config) GetMarkdownTemplate() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.markdownTemplate
+}
+
+func (c *Config) SetMarkdownTemplate(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.markdownTemplate = val
+}
+
+func (c *Config) GetJSONMaxResultsPerFile() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.jsonMaxResultsPerFile
+}
+
+func (c *Config) SetJSONMaxResultsPerFile(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.jsonMaxResultsPerFile = val
+}
+
+func (c *Config) GetTimezone() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reporter.timezone
+}
+
+func (c *Config) SetTimezone(val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reporter.timezone = val
+}
+
+// ------- Server Getters/Setters -------
+func (c *Config) GetHealthPort() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.server.healthPort
+}
+
+func (c *Config) SetHealthPort(val int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.server.healthPort = val
+}
+
+func (c *Config) GetReadinessInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return time.Duration(c.server.readinessIntervalMinutes) * time.Minute
+}
+
+func (c *Config) SetReadinessInterval(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.server.readinessIntervalMinutes = int(d.Minutes())
+}
+
+// ------- Accounts Getters/Setters -------
+func (c *Config) GetAccounts() []AccountConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.accounts
+}
+
+func (c *Config) SetAccounts(accounts []AccountConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accounts = accounts
+}
+
+// ------- Validation -------
+func (c *Config) Validate() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.aws.region == "" {
+		return errors.NewValidationError("AWS region cannot be empty")
+	}
+
+	switch c.aws.source {
+	case "", AWSSourceEC2, AWSSourceConfig:
+	default:
+		return errors.NewValidationError("AWS source must be either 'ec2' or 'config'")
+	}
+
+	if c.aws.asOf != "" {
+		if c.aws.source != AWSSourceConfig {
+			return errors.NewValidationError("AWS as-of time can only be set with the 'config' AWS source")
+		}
+		if _, err := parseAsOf(c.aws.asOf); err != nil {
+			return errors.NewValidationError(fmt.Sprintf("Invalid AWS as-of time %q (expected RFC 3339, e.g. 2024-05-01T12:00:00Z, or a date)", c.aws.asOf))
+		}
+	}
+
+	for name, paths := range c.detector.attributePresets {
+		if len(paths) == 0 {
+			return errors.NewValidationError(fmt.Sprintf("Attribute preset %q must name at least one attribute", name))
+		}
+	}
+
+	if c.terraform.useHCL {
+		if c.terraform.hclDir == "" {
+			return errors.NewValidationError("Terraform HCL directory cannot be empty when UseHCL is true")
+		}
+	} else if c.terraform.tfcWorkspace != "" {
+		if c.terraform.tfcToken == "" {
+			return errors.NewValidationError("Terraform Cloud token cannot be empty when a TFC workspace is set")
+		}
+	} else {
+		if c.terraform.stateFile == "" {
+			return errors.NewValidationError("Terraform state file cannot be empty when UseHCL is false")
+		}
+	}
+
+	if len(c.terraform.workspaces) > 0 {
+		if c.terraform.useHCL || c.terraform.tfcWorkspace != "" {
+			return errors.NewValidationError("Terraform workspaces can only be read from a state file backend, not from HCL or Terraform Cloud")
+		}
+		if c.detector.environmentTag == "" {
+			return errors.NewValidationError("Environment tag cannot be empty when Terraform workspaces are set")
+		}
+	}
+
+	if len(c.detector.attributes) == 0 {
+		return errors.NewValidationError("At least one attribute must be specified for drift detection")
+	}
+
+	if c.detector.sourceOfTruth != "aws" && c.detector.sourceOfTruth != "terraform" {
+		return errors.NewValidationError("Source of truth must be either 'aws' or 'terraform'")
+	}
+
+	if c.detector.parallelChecks <= 0 {
+		return errors.NewValidationError("Parallel checks must be greater than 0")
+	}
+
+	if c.detector.maxParallelChecks < 0 {
+		return errors.NewValidationError("Max parallel checks cannot be negative")
+	}
+
+	if c.detector.maxParallelChecks > 0 && c.detector.parallelChecks > c.detector.maxParallelChecks {
+		return errors.NewValidationError(fmt.Sprintf("Parallel checks (%d) cannot exceed max parallel checks (%d)", c.detector.parallelChecks, c.detector.maxParallelChecks))
+	}
+
+	if c.detector.timeout <= 0 {
+		return errors.NewValidationError("Timeout must be greater than 0")
+	}
+
+	if c.detector.awsTimeoutSeconds < 0 || c.detector.tfTimeoutSeconds < 0 {
+		return errors.NewValidationError("Provider timeouts cannot be negative")
+	}
+
+	if c.detector.abortAfterErrors < 0 {
+		return errors.NewValidationError("Abort after errors cannot be negative")
+	}
+
+	if c.detector.driftAgeLookback < 0 {
+		return errors.NewValidationError("Drift age lookback cannot be negative")
+	}
+
+	if c.detector.minInstances < 0 {
+		return errors.NewValidationError("Minimum instances cannot be negative")
+	}
+
+	if !model.IsValidStoreValuesMode(c.detector.storeValues) {
+		return errors.NewValidationError("Store values must be 'full', 'truncated', or 'hash'")
+	}
+
+	if c.terraform.readRetries < 0 {
+		return errors.NewValidationError("Terraform state read retries cannot be negative")
+	}
+
+	if c.detector.storeValuesMax < 0 {
+		return errors.NewValidationError("Store values max bytes cannot be negative")
+	}
+
+	if c.detector.debugDumpMax < 0 {
+		return errors.NewValidationError("Debug dump max instances cannot be negative")
+	}
+
+	for i, policy := range c.detector.policies {
+		if policy.Path == "" {
+			return errors.NewValidationError(fmt.Sprintf("Policy %d must have a path", i))
+		}
+		if !model.IsValidPolicyOperator(policy.Operator) {
+			return errors.NewValidationError(fmt.Sprintf("Policy %d has invalid operator %q (expected lt, lte, gt, gte, eq, ne or in)", i, policy.Operator))
+		}
+	}
+
+	switch c.reporter.typeVal {
+	case ReporterTypeConsole, ReporterTypeJSON, ReporterTypeBoth, ReporterTypeTeams, ReporterTypeMarkdown, ReporterTypeMetrics:
+	default:
+		return errors.NewValidationError("Reporter type must be 'json', 'console', 'both', 'teams', 'markdown', or 'metricsfile'")
+	}
+
+	// Template overrides are parsed now so a broken template can't fail a run mid-report
+	if err := reporter.ValidateTemplateFile(reporter.TemplateConsole, c.reporter.consoleTemplate); err != nil {
+		return err
+	}
+	if err := reporter.ValidateTemplateFile(reporter.TemplateMarkdown, c.reporter.markdownTemplate); err != nil {
+		return err
+	}
+	if err := reporter.ValidateSeverityColors(c.reporter.severityColors); err != nil {
+		return err
+	}
+	if _, err := reporter.LoadLocation(c.reporter.timezone); err != nil {
+		return err
+	}
+
+	if err := c.validateReporterList(); err != nil {
+		return err
+	}
+
+	if c.usesReporter(ReporterTypeTeams) && c.reporter.teamsWebhookURL == "" {
+		return errors.NewValidationError("Teams webhook URL must be specified for the teams reporter")
+	}
+
+	if len(c.reporter.list) == 0 && c.reporter.typeVal == ReporterTypeMetrics && c.reporter.outputFile == "" {
+		return errors.NewValidationError("Output file must be specified for the metricsfile reporter")
+	}
+
+	if c.reporter.teamsMaxInstances < 0 || c.reporter.httpMaxRetries < 0 {
+		return errors.NewValidationError("Teams max instances and HTTP max retries cannot be negative")
+	}
+
+	if c.reporter.timeout < 0 {
+		return errors.NewValidationError("Reporter timeout cannot be negative")
+	}
+
+	if c.reporter.maxBytes < 0 {
+		return errors.NewValidationError("Reporter max bytes cannot be negative")
+	}
+
+	if c.reporter.jsonMaxResultsPerFile < 0 {
+		return errors.NewValidationError("JSON max results per file cannot be negative")
+	}
+
+	if c.app.shutdownGracePeriod < 0 {
+		return errors.NewValidationError("Shutdown grace period cannot be negative")
+	}
+
+	if c.reporter.digestInterval < 0 {
+		return errors.NewValidationError("Digest interval cannot be negative")
+	}
+
+	if c.reporter.digestSeverity != "" && !model.IsSeverity(c.reporter.digestSeverity) {
+		return errors.NewValidationError(fmt.Sprintf("Invalid reporter.digest_immediate_severity %q: expected %s, %s or %s", c.reporter.digestSeverity, model.SeverityHigh, model.SeverityMedium, model.SeverityLow))
+	}
+
+	// if (c.reporter.typeVal == ReporterTypeJSON || c.reporter.typeVal == ReporterTypeBoth) && c.reporter.outputFile == "" {
+	// 	return errors.NewValidationError("Output file must be specified for JSON reporter")
+	// }
+
+	if c.server.healthPort < 0 || c.server.healthPort > 65535 {
+		return errors.NewValidationError("Health port must be between 0 and 65535")
+	}
+
+	if c.server.healthPort > 0 && c.server.readinessIntervalMinutes <= 0 {
+		return errors.NewValidationError("Readiness interval must be greater than 0 when the health port is set")
+	}
+
+	for i, account := range c.accounts {
+		if account.AccountID() == "" {
+			return errors.NewValidationError(fmt.Sprintf("Account %d must have a valid role ARN", i))
+		}
+	}
 
 	if c.app.scheduleExpression != "" && len(c.app.scheduleExpression) < 9 {
 		return errors.NewValidationError("Invalid schedule expression format")
@@ -336,3 +1590,42 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// validateReporterList checks the type of each entry of the reporters list and that no two
+// reporters write to the same file
+func (c *Config) validateReporterList() error {
+	writers := make(map[string]int)
+	for i, rc := range c.reporter.list {
+		switch rc.Type {
+		case ReporterTypeConsole, ReporterTypeJSON, ReporterTypeMarkdown, ReporterTypeTeams, ReporterTypeMetrics:
+		default:
+			return errors.NewValidationError(fmt.Sprintf("Reporter %d has invalid type %q (expected console, json, markdown, teams or metricsfile)", i, rc.Type))
+		}
+		if rc.Type == ReporterTypeMetrics && rc.OutputFile == "" {
+			return errors.NewValidationError(fmt.Sprintf("Reporter %d must specify an output file for the metricsfile reporter", i))
+		}
+
+		// Console and Teams reports never go to the output file
+		if rc.OutputFile == "" || rc.Type == ReporterTypeConsole || rc.Type == ReporterTypeTeams {
+			continue
+		}
+		if other, ok := writers[rc.OutputFile]; ok {
+			return errors.NewValidationError(fmt.Sprintf("Reporters %d and %d both write to %s", other, i, rc.OutputFile))
+		}
+		writers[rc.OutputFile] = i
+	}
+	return nil
+}
+
+// usesReporter reports whether a reporter of the given type is configured
+func (c *Config) usesReporter(reporterType string) bool {
+	if len(c.reporter.list) == 0 {
+		return c.reporter.typeVal == reporterType
+	}
+	for _, rc := range c.reporter.list {
+		if rc.Type == reporterType {
+			return true
+		}
+	}
+	return false
+}
@@ -1,12 +1,17 @@
 package config_test
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/config"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 )
 
 func TestConfigAccessors(t *testing.T) {
@@ -73,7 +78,347 @@ func TestConfigValidation(t *testing.T) {
 	err := cfg.Validate()
 	assert.NoError(t, err)
 
+	cfg.SetPolicies([]model.Policy{{Path: "age_days", Operator: "older_than", Value: 90}})
+	err = cfg.Validate()
+	assert.ErrorContains(t, err, "invalid operator")
+
+	cfg.SetPolicies([]model.Policy{{Path: "age_days", Operator: "lt", Value: 90}})
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SetReporterType(config.ReporterTypeTeams)
+	assert.ErrorContains(t, cfg.Validate(), "Teams webhook URL must be specified")
+
+	cfg.SetTeamsWebhookURL("https://example.webhook.office.com/webhookb2/abc")
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SetShutdownGracePeriod(-time.Second)
+	assert.ErrorContains(t, cfg.Validate(), "Shutdown grace period cannot be negative")
+	cfg.SetShutdownGracePeriod(25 * time.Second)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SetReporterMaxBytes(-1)
+	assert.ErrorContains(t, cfg.Validate(), "Reporter max bytes cannot be negative")
+	cfg.SetReporterMaxBytes(1 << 20)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SetDriftAgeLookback(-1)
+	assert.ErrorContains(t, cfg.Validate(), "Drift age lookback cannot be negative")
+	cfg.SetDriftAgeLookback(30)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SetSeverityColors(map[string]string{"high": "orange"})
+	assert.ErrorContains(t, cfg.Validate(), `Invalid color "orange"`)
+	cfg.SetSeverityColors(map[string]string{"high": "magenta", "low": "none"})
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SetDigestImmediateSeverity("critical")
+	assert.ErrorContains(t, cfg.Validate(), `Invalid reporter.digest_immediate_severity "critical"`)
+	cfg.SetDigestImmediateSeverity(" High ")
+	assert.Equal(t, "high", cfg.GetDigestImmediateSeverity())
+	assert.NoError(t, cfg.Validate())
+
+	// A Terraform Cloud workspace replaces the state file but needs a token
+	cfg.SetStateFile("")
+	cfg.SetTFCWorkspace("ws-123")
+	assert.ErrorContains(t, cfg.Validate(), "Terraform Cloud token cannot be empty")
+	cfg.SetTFCToken("token")
+	assert.NoError(t, cfg.Validate())
+
+	// Template overrides are checked when the configuration is validated
+	cfg.SetReporterType(config.ReporterTypeMarkdown)
+	assert.NoError(t, cfg.Validate())
+	badTemplate := filepath.Join(t.TempDir(), "report.md.tmpl")
+	assert.NoError(t, os.WriteFile(badTemplate, []byte("{{range .Results}}{{.Missing}}{{end}}"), 0600))
+	cfg.SetMarkdownTemplate(badTemplate)
+	assert.ErrorContains(t, cfg.Validate(), "Invalid markdown template")
+	cfg.SetMarkdownTemplate("")
+
+	// Report time zones must be IANA names
+	cfg.SetTimezone("Europe/Berlin")
+	assert.NoError(t, cfg.Validate())
+	cfg.SetTimezone("CEST+2")
+	assert.ErrorContains(t, cfg.Validate(), `Invalid reporter.timezone "CEST+2"`)
+	cfg.SetTimezone("")
+
+	// Workspaces are read from a state file backend and matched by an environment tag
+	cfg.SetWorkspaces([]string{"dev", "prod"})
+	assert.ErrorContains(t, cfg.Validate(), "Terraform workspaces can only be read from a state file backend")
+	cfg.SetTFCWorkspace("")
+	cfg.SetStateFile("terraform.tfstate")
+	assert.ErrorContains(t, cfg.Validate(), "Environment tag cannot be empty")
+	cfg.SetEnvironmentTag("Environment")
+	assert.NoError(t, cfg.Validate())
+
+	// A point in time is only read from AWS Config
+	cfg.SetAWSAsOf("2024-05-01T12:00:00Z")
+	assert.ErrorContains(t, cfg.Validate(), "AWS as-of time can only be set with the 'config' AWS source")
+	cfg.SetAWSSource(config.AWSSourceConfig)
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), cfg.GetAWSAsOf())
+	cfg.SetAWSAsOf("yesterday")
+	assert.ErrorContains(t, cfg.Validate(), `Invalid AWS as-of time "yesterday"`)
+	cfg.SetAWSAsOf("")
+	cfg.SetAWSSource("cloudtrail")
+	assert.ErrorContains(t, cfg.Validate(), "AWS source must be either")
+	cfg.SetAWSSource(config.AWSSourceEC2)
+
 	cfg.SetSourceOfTruth("invalid")
 	err = cfg.Validate()
 	assert.ErrorContains(t, err, "Source of truth must be either")
 }
+
+func TestAccountConfig_AccountID(t *testing.T) {
+	account := config.AccountConfig{RoleARN: "arn:aws:iam::123456789012:role/drift-detector", Region: "us-east-1"}
+	assert.Equal(t, "123456789012", account.AccountID())
+
+	invalid := config.AccountConfig{RoleARN: "drift-detector"}
+	assert.Equal(t, "", invalid.AccountID())
+
+	cfg := &config.Config{}
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("terraform")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(10 * time.Second)
+	cfg.SetReporterType(config.ReporterTypeConsole)
+	cfg.SetAccounts([]config.AccountConfig{account, invalid})
+
+	assert.ErrorContains(t, cfg.Validate(), "valid role ARN")
+}
+
+func TestConfigLoader_UpdateConfigAWSProfile(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(10 * time.Second)
+	cfg.SetReporterType(config.ReporterTypeConsole)
+	cfg.SetAWSProfile("default")
+
+	loader := config.NewConfigLoader(logging.New(), ".")
+	assert.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{"aws-profile": "staging"}))
+	assert.Equal(t, "staging", cfg.GetAWSProfile())
+}
+
+func TestConfigLoader_UpdateConfigAttributes(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(10 * time.Second)
+	cfg.SetReporterType(config.ReporterTypeConsole)
+
+	loader := config.NewConfigLoader(logging.New(), ".")
+	assert.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{
+		"attributes":      []string{" ami", "", "instance_type", "ami", "lifecycle", "instance_lifecycle"},
+		"parallel-checks": 8,
+	}))
+	assert.Equal(t, []string{"ami", "instance_type", "instance_lifecycle"}, cfg.GetAttributes())
+	assert.Equal(t, 8, cfg.GetParallelChecks())
+
+	// 0 keeps the configured value
+	assert.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{"parallel-checks": 0}))
+	assert.Equal(t, 8, cfg.GetParallelChecks())
+
+	assert.ErrorContains(t, loader.UpdateConfig(cfg, map[string]interface{}{"attributes": []string{" ", ""}}), "at least one attribute")
+	assert.ErrorContains(t, loader.UpdateConfig(cfg, map[string]interface{}{"parallel-checks": -1}), "cannot be negative")
+}
+
+func TestConfigLoader_UpdateConfigAttributesPreset(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(10 * time.Second)
+	cfg.SetReporterType(config.ReporterTypeConsole)
+
+	loader := config.NewConfigLoader(logging.New(), ".")
+
+	// A preset replaces the configured attributes
+	assert.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{"attributes-preset": []string{"security"}}))
+	assert.Equal(t, []string{"vpc_security_group_ids", "metadata_options", "iam_instance_profile", "associate_public_ip_address"}, cfg.GetAttributes())
+
+	// and merges with explicit attributes, dropping repeats
+	assert.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{
+		"attributes":        []string{"ami", "tags"},
+		"attributes-preset": []string{"compute", "tags"},
+	}))
+	assert.Equal(t, []string{"ami", "tags", "instance_type", "ebs_optimized", "root_block_device", "ebs_block_device"}, cfg.GetAttributes())
+
+	// Configured presets override the built-in ones and add new names
+	cfg.SetAttributePresets(map[string][]string{"tags": {"tags", "tags_all"}, "network": {"subnet_id", "private_ip"}})
+	assert.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{"attributes-preset": []string{"tags", "network"}}))
+	assert.Equal(t, []string{"tags", "tags_all", "subnet_id", "private_ip"}, cfg.GetAttributes())
+
+	err := loader.UpdateConfig(cfg, map[string]interface{}{"attributes-preset": []string{"storage"}})
+	assert.ErrorContains(t, err, `Unknown attribute preset "storage" (available: compute, network, security, tags)`)
+	assert.ErrorContains(t, loader.UpdateConfig(cfg, map[string]interface{}{"attributes-preset": []string{" "}}), "at least one preset")
+
+	cfg.SetAttributePresets(map[string][]string{"empty": {}})
+	assert.ErrorContains(t, cfg.Validate(), `Attribute preset "empty" must name at least one attribute`)
+}
+
+func TestConfigLoader_UpdateConfigTimeout(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(10 * time.Second)
+	cfg.SetReporterType(config.ReporterTypeConsole)
+
+	loader := config.NewConfigLoader(logging.New(), ".")
+	assert.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{"timeout": 2 * time.Minute}))
+	assert.Equal(t, 2*time.Minute, cfg.GetTimeout())
+
+	// 0 keeps the configured value
+	assert.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{"timeout": time.Duration(0)}))
+	assert.Equal(t, 2*time.Minute, cfg.GetTimeout())
+
+	assert.ErrorContains(t, loader.UpdateConfig(cfg, map[string]interface{}{"timeout": -time.Second}), "cannot be negative")
+
+	// Timeouts that aren't whole seconds are kept as given
+	assert.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{"timeout": 1500 * time.Millisecond}))
+	assert.Equal(t, 1500*time.Millisecond, cfg.GetTimeout())
+	cfg.SetTimeout(500 * time.Millisecond)
+	assert.Equal(t, 500*time.Millisecond, cfg.GetTimeout())
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_ParallelChecksCap(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetTimeout(10 * time.Second)
+	cfg.SetReporterType(config.ReporterTypeConsole)
+
+	cfg.SetMaxParallelChecks(16)
+	cfg.SetParallelChecks(200)
+	assert.Equal(t, 16, cfg.GetParallelChecks())
+	assert.NoError(t, cfg.Validate())
+
+	// Lowering the cap lowers the current value
+	cfg.SetMaxParallelChecks(4)
+	assert.Equal(t, 4, cfg.GetParallelChecks())
+
+	// 0 disables the cap
+	cfg.SetMaxParallelChecks(0)
+	cfg.SetParallelChecks(200)
+	assert.Equal(t, 200, cfg.GetParallelChecks())
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SetMaxParallelChecks(-1)
+	assert.ErrorContains(t, cfg.Validate(), "Max parallel checks cannot be negative")
+
+	assert.GreaterOrEqual(t, config.DefaultParallelChecks(), 1)
+	assert.LessOrEqual(t, config.DefaultParallelChecks(), 16)
+}
+
+func TestConfigLoader_ParallelChecksDefaultAndCap(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("terraform:\n  state_file: terraform.tfstate\n"), 0600))
+
+	var logs bytes.Buffer
+	loader := config.NewConfigLoader(logging.NewLogger(logging.LogConfig{Level: logging.Info, Output: &logs}), dir)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+
+	// Unset parallel_checks scales with the CPU count
+	assert.Equal(t, config.DefaultParallelChecks(), cfg.GetParallelChecks())
+	assert.Equal(t, 32, cfg.GetMaxParallelChecks())
+
+	// A flag above the cap is lowered with a warning
+	require.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{"parallel-checks": 200}))
+	assert.Equal(t, 32, cfg.GetParallelChecks())
+	assert.Contains(t, logs.String(), "Parallel checks 200 exceeds detector.max_parallel_checks, using 32")
+}
+
+func TestConfigLoader_Reporters(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`terraform:
+  state_file: terraform.tfstate
+reporter:
+  pretty_print: false
+reporters:
+  - type: console
+  - type: json
+    output_file: out/drift.json
+    pretty: true
+  - type: markdown
+    output_file: out/drift.md
+`), 0600))
+
+	loader := config.NewConfigLoader(logging.New(), dir)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []config.ReporterConfig{
+		{Type: config.ReporterTypeConsole},
+		{Type: config.ReporterTypeJSON, OutputFile: "out/drift.json", PrettyPrint: true},
+		{Type: config.ReporterTypeMarkdown, OutputFile: "out/drift.md"},
+	}, cfg.GetReporters())
+
+	// --output and --output-file stand for a single reporter
+	require.NoError(t, loader.UpdateConfig(cfg, map[string]interface{}{"output": "json", "output-file": "report.json"}))
+	assert.Equal(t, []config.ReporterConfig{{Type: config.ReporterTypeJSON, OutputFile: "report.json"}}, cfg.GetReporters())
+}
+
+func TestConfig_ReportersFromFlatKeys(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetReporterType(config.ReporterTypeMarkdown)
+	cfg.SetOutputFile("drift.md")
+	cfg.SetPrettyPrint(true)
+	assert.Equal(t, []config.ReporterConfig{{Type: config.ReporterTypeMarkdown, OutputFile: "drift.md", PrettyPrint: true}}, cfg.GetReporters())
+
+	cfg.SetReporterType(config.ReporterTypeBoth)
+	cfg.SetOutputFile("drift.json")
+	assert.Equal(t, []config.ReporterConfig{
+		{Type: config.ReporterTypeConsole, OutputFile: "drift.json", PrettyPrint: true},
+		{Type: config.ReporterTypeJSON, OutputFile: "drift.json", PrettyPrint: true},
+	}, cfg.GetReporters())
+}
+
+func TestConfigValidation_Reporters(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(10 * time.Second)
+	cfg.SetReporterType(config.ReporterTypeConsole)
+
+	// Console reports go to stdout, so only file reporters conflict
+	cfg.SetReporters([]config.ReporterConfig{
+		{Type: config.ReporterTypeConsole, OutputFile: "drift.json"},
+		{Type: config.ReporterTypeJSON, OutputFile: "drift.json"},
+	})
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SetReporters([]config.ReporterConfig{
+		{Type: config.ReporterTypeJSON, OutputFile: "drift.out"},
+		{Type: config.ReporterTypeMarkdown, OutputFile: "drift.out"},
+	})
+	assert.ErrorContains(t, cfg.Validate(), "Reporters 0 and 1 both write to drift.out")
+
+	cfg.SetReporters([]config.ReporterConfig{{Type: "csv", OutputFile: "drift.csv"}})
+	assert.ErrorContains(t, cfg.Validate(), `invalid type "csv"`)
+
+	cfg.SetReporters([]config.ReporterConfig{{Type: config.ReporterTypeConsole}, {Type: config.ReporterTypeTeams}})
+	assert.ErrorContains(t, cfg.Validate(), "Teams webhook URL")
+
+	cfg.SetReporters([]config.ReporterConfig{{Type: config.ReporterTypeMetrics}})
+	assert.ErrorContains(t, cfg.Validate(), "Reporter 0 must specify an output file")
+
+	cfg.SetReporters([]config.ReporterConfig{{Type: config.ReporterTypeMetrics, OutputFile: "ec2_drift.prom"}})
+	assert.NoError(t, cfg.Validate())
+}
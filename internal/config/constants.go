@@ -6,7 +6,18 @@ const (
 	ReporterTypeConsole  = "console"
 	ReporterTypeJSON     = "json"
 	ReporterTypeBoth     = "both"
+	ReporterTypeTeams    = "teams"
+	ReporterTypeMarkdown = "markdown"
+	ReporterTypeMetrics  = "metricsfile"
+	AWSSourceEC2         = "ec2"
+	AWSSourceConfig      = "config"
 	cronEvery6Hours      = "0 */6 * * *"
 	aWSDefaultRegion     = "eu-north-1"
 	defaultSourceOfTruth = "terraform"
+
+	// maxAutoParallelChecks bounds the parallel checks derived from the CPU count
+	maxAutoParallelChecks = 16
+
+	// defaultMaxParallelChecks caps configured parallel checks to stay within AWS API rate limits
+	defaultMaxParallelChecks = 32
 )
@@ -0,0 +1,322 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// keyKind is the value type of a configuration key
+type keyKind string
+
+const (
+	kindString   keyKind = "string"
+	kindInt      keyKind = "int"
+	kindBool     keyKind = "bool"
+	kindList     keyKind = "list"
+	kindDuration keyKind = "duration"
+)
+
+// configKey describes a configuration key that can be edited from the CLI
+type configKey struct {
+	kind   keyKind
+	secret bool
+}
+
+// configSchema returns the keys that can be written to a configuration file: every key of the
+// configuration reference outside lists that holds a single value or a list of values
+var configSchema = sync.OnceValue(func() map[string]configKey {
+	keys := make(map[string]configKey)
+	for _, entry := range Schema() {
+		if entry.Editable {
+			keys[entry.Key] = configKey{kind: keyKind(entry.Type), secret: entry.Secret}
+		}
+	}
+	return keys
+})
+
+// ConfigKeys returns the configuration keys that can be edited, sorted
+func ConfigKeys() []string {
+	schema := configSchema()
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ConfigChange describes an edit made to a configuration file
+type ConfigChange struct {
+	File     string
+	Key      string
+	OldValue string
+	NewValue string
+
+	// NotSet reports that an unset key wasn't in the file, which was left as it was
+	NotSet bool
+}
+
+// Diff renders the change in unified diff style
+func (c *ConfigChange) Diff() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", c.File, c.File)
+	if c.OldValue != "" {
+		fmt.Fprintf(&b, "- %s: %s\n", c.Key, c.OldValue)
+	}
+	if c.NewValue != "" {
+		fmt.Fprintf(&b, "+ %s: %s\n", c.Key, c.NewValue)
+	}
+	return b.String()
+}
+
+// SetValue validates a key/value pair against the schema and writes it to the configuration file.
+// An empty file targets the configuration file that was loaded.
+func (l *ConfigLoader) SetValue(file, key, value string, allowSecret bool) (*ConfigChange, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	schema, ok := configSchema()[key]
+	if !ok {
+		return nil, errors.NewValidationError(fmt.Sprintf("Unknown configuration key %q", key))
+	}
+
+	// References name where the secret is kept rather than holding it, except for the AWS
+	// credentials references are read with
+	reference := IsSecretReference(value) && !strings.HasPrefix(key, "aws.")
+	if schema.secret && !allowSecret && !reference {
+		envVar := "DRIFT_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		return nil, errors.NewValidationError(fmt.Sprintf("Refusing to write secret %s in plaintext; set %s instead or pass --allow-secret", key, envVar))
+	}
+
+	valueNode, err := newValueNode(schema.kind, value)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid value for %s: %v", key, err))
+	}
+
+	return l.editFile(file, key, func(parent *yaml.Node, name string) {
+		setMappingValue(parent, name, valueNode)
+	}, nodeString(valueNode), true)
+}
+
+// UnsetValue removes a key from the configuration file so the default or environment value
+// applies. A key that isn't in the file leaves the file untouched and is reported as not set.
+func (l *ConfigLoader) UnsetValue(file, key string) (*ConfigChange, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := configSchema()[key]; !ok {
+		return nil, errors.NewValidationError(fmt.Sprintf("Unknown configuration key %q", key))
+	}
+
+	return l.editFile(file, key, removeMappingValue, "", false)
+}
+
+// editFile applies an edit to the YAML document of a configuration file, validates the
+// result and saves it. Editing the yaml.Node tree keeps comments and key order intact. With
+// create, missing sections are added for the key; otherwise a key that isn't in the file is
+// reported as not set without writing it.
+func (l *ConfigLoader) editFile(file, key string, edit func(parent *yaml.Node, name string), newValue string, create bool) (*ConfigChange, error) {
+	if file == "" {
+		file = l.viper.ConfigFileUsed()
+	}
+	if file == "" {
+		return nil, errors.NewValidationError("No configuration file loaded; use --file to choose one")
+	}
+
+	mode := os.FileMode(0644)
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to read configuration file: %s", file), err)
+	}
+	if info, statErr := os.Stat(file); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to parse configuration file: %s", file), err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	// Settings live in a mapping at the root; an explicitly empty document starts one
+	root := doc.Content[0]
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		root.Kind, root.Tag, root.Value = yaml.MappingNode, "", ""
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.NewValidationError(fmt.Sprintf("Configuration file %s must hold a mapping of settings at its root, found %s", file, nodeKindName(root))).
+			WithContext("file", file)
+	}
+
+	// Walk to the parent mapping, creating sections as needed
+	parts := strings.Split(key, ".")
+	name := parts[len(parts)-1]
+	parent := root
+	for _, part := range parts[:len(parts)-1] {
+		child := mappingValue(parent, part)
+		if child == nil || child.Kind != yaml.MappingNode {
+			if !create {
+				return &ConfigChange{File: file, Key: key, NotSet: true}, nil
+			}
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			setMappingValue(parent, part, child)
+		}
+		parent = child
+	}
+
+	change := &ConfigChange{File: file, Key: key, NewValue: newValue}
+	old := mappingValue(parent, name)
+	if old == nil && !create {
+		change.NotSet = true
+		return change, nil
+	}
+	if old != nil {
+		change.OldValue = nodeString(old)
+	}
+
+	edit(parent, name)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to encode configuration file: %s", file), err)
+	}
+	encoder.Close()
+
+	if err := validateConfigData(buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(file, buf.Bytes(), mode); err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to write configuration file: %s", file), err)
+	}
+
+	l.logger.Info(fmt.Sprintf("Updated %s in configuration file %s", key, file))
+	return change, nil
+}
+
+// nodeKindName describes the kind of a YAML node for error messages
+func nodeKindName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.ScalarNode:
+		return fmt.Sprintf("the value %q", node.Value)
+	case yaml.AliasNode:
+		return "an alias"
+	}
+	return "no mapping"
+}
+
+// validateConfigData loads YAML configuration the same way Load does and validates it
+func validateConfigData(data []byte) error {
+	v := viper.New()
+	setViperDefaults(v)
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return errors.NewOperationalError("Failed to read updated configuration", err)
+	}
+	bindViperEnv(v)
+
+	var raw rawConfig
+	if err := v.Unmarshal(&raw); err != nil {
+		return errors.NewValidationError(fmt.Sprintf("Invalid configuration: %v", err))
+	}
+
+	cfg := &Config{}
+	applyRawToConfig(raw, cfg)
+	return cfg.Validate()
+}
+
+// newValueNode parses a CLI value into a YAML node of the given kind
+func newValueNode(kind keyKind, value string) (*yaml.Node, error) {
+	switch kind {
+	case kindInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("expected an integer, got %q", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}, nil
+	case kindDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("expected a duration such as 30m or 6h, got %q", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	case kindBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+	case kindList:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+			}
+		}
+		if len(node.Content) == 0 {
+			return nil, fmt.Errorf("expected a comma-separated list")
+		}
+		return node, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	}
+}
+
+// nodeString renders a YAML value node for display
+func nodeString(node *yaml.Node) string {
+	if node.Kind == yaml.SequenceNode {
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			items = append(items, item.Value)
+		}
+		return strings.Join(items, ",")
+	}
+	return node.Value
+}
+
+// mappingValue returns the value node for a key in a mapping node
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets or appends a key in a mapping node, keeping comments on the key
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			old := mapping.Content[i+1]
+			value.LineComment = old.LineComment
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// removeMappingValue removes a key from a mapping node
+func removeMappingValue(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/config"
+)
+
+const editorTestConfig = `# Drift detector settings
+aws:
+  region: us-east-1 # primary region
+
+terraform:
+  state_file: terraform.tfstate
+
+detector:
+  source_of_truth: terraform
+  attributes:
+    - instance_type
+`
+
+func writeEditorConfig(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(editorTestConfig), 0600))
+	return file
+}
+
+func TestConfigLoader_SetValue(t *testing.T) {
+	file := writeEditorConfig(t)
+	loader := config.NewConfigLoader(logging.New(), "")
+
+	change, err := loader.SetValue(file, "detector.attributes", "instance_type, ami,tags", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "instance_type", change.OldValue)
+	assert.Equal(t, "instance_type,ami,tags", change.NewValue)
+	assert.Contains(t, change.Diff(), "+ detector.attributes: instance_type,ami,tags")
+
+	_, err = loader.SetValue(file, "reporter.pretty_print", "false", false)
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "# Drift detector settings")
+	assert.Contains(t, string(data), "region: us-east-1 # primary region")
+	assert.Contains(t, string(data), "    - ami\n")
+	assert.Contains(t, string(data), "reporter:\n  pretty_print: false")
+
+	info, err := os.Stat(file)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestConfigLoader_SetValueRejectsInvalid(t *testing.T) {
+	file := writeEditorConfig(t)
+	loader := config.NewConfigLoader(logging.New(), "")
+
+	_, err := loader.SetValue(file, "detector.unknown", "1", false)
+	assert.ErrorContains(t, err, "Unknown configuration key")
+
+	_, err = loader.SetValue(file, "detector.parallel_checks", "many", false)
+	assert.ErrorContains(t, err, "expected an integer")
+
+	_, err = loader.SetValue(file, "detector.source_of_truth", "git", false)
+	assert.ErrorContains(t, err, "Source of truth")
+
+	_, err = loader.SetValue(file, "aws.secret_access_key", "s3cr3t", false)
+	assert.ErrorContains(t, err, "DRIFT_AWS_SECRET_ACCESS_KEY")
+
+	_, err = loader.SetValue(file, "aws.secret_access_key", "ssm:/drift/aws-secret", false)
+	assert.ErrorContains(t, err, "DRIFT_AWS_SECRET_ACCESS_KEY")
+
+	_, err = loader.SetValue(file, "aws.secret_access_key", "s3cr3t", true)
+	assert.NoError(t, err)
+
+	// References to SSM parameters and Secrets Manager secrets aren't secrets themselves
+	_, err = loader.SetValue(file, "reporter.teams.webhook_url", "ssm:/drift/teams-webhook", false)
+	assert.NoError(t, err)
+
+	// Rejected edits leave the file untouched
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "source_of_truth: terraform")
+}
+
+func TestConfigLoader_SetValueRequiresMappingRoot(t *testing.T) {
+	loader := config.NewConfigLoader(logging.New(), "")
+	dir := t.TempDir()
+
+	for name, contents := range map[string]string{
+		"list.yaml":   "- instance_type\n- ami\n",
+		"scalar.yaml": "terraform.tfstate\n",
+	} {
+		file := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(file, []byte(contents), 0600))
+
+		_, err := loader.SetValue(file, "detector.parallel_checks", "4", false)
+		assert.True(t, errors.IsValidationError(err))
+		assert.ErrorContains(t, err, "must hold a mapping of settings at its root")
+
+		// The file is left untouched
+		data, readErr := os.ReadFile(file)
+		assert.NoError(t, readErr)
+		assert.Equal(t, contents, string(data))
+	}
+
+	// An explicitly empty document starts a mapping
+	file := filepath.Join(dir, "empty.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("---\n"), 0600))
+	_, err := loader.SetValue(file, "terraform.state_file", "terraform.tfstate", false)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "terraform:\n  state_file: terraform.tfstate")
+}
+
+func TestConfigLoader_UnsetValue(t *testing.T) {
+	file := writeEditorConfig(t)
+	loader := config.NewConfigLoader(logging.New(), "")
+
+	change, err := loader.UnsetValue(file, "detector.source_of_truth")
+	assert.NoError(t, err)
+	assert.Equal(t, "terraform", change.OldValue)
+	assert.Contains(t, change.Diff(), "- detector.source_of_truth: terraform")
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "source_of_truth")
+
+	_, err = loader.UnsetValue("", "detector.attributes")
+	assert.ErrorContains(t, err, "--file")
+}
+
+func TestConfigLoader_UnsetValueNotSet(t *testing.T) {
+	file := writeEditorConfig(t)
+	loader := config.NewConfigLoader(logging.New(), "")
+
+	// Neither a key in a missing section nor a missing key in a section adds anything
+	for _, key := range []string{"reporter.teams.webhook_url", "detector.memoize"} {
+		change, err := loader.UnsetValue(file, key)
+		assert.NoError(t, err)
+		assert.True(t, change.NotSet, key)
+		assert.Empty(t, change.OldValue, key)
+	}
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, editorTestConfig, string(data))
+
+	// A file that doesn't exist isn't created
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	change, err := loader.UnsetValue(missing, "aws.region")
+	assert.NoError(t, err)
+	assert.True(t, change.NotSet)
+	assert.NoFileExists(t, missing)
+}
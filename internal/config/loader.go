@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 )
 
 // ConfigLoader is responsible for loading application configuration
@@ -21,51 +23,179 @@ type ConfigLoader struct {
 	logger    *logging.Logger
 	configDir string
 	mu        sync.Mutex
+
+	// secretResolverFactory creates the resolver of ssm: and secretsmanager: references, whose
+	// values are cached in secretCache for the lifetime of the loader
+	secretResolverFactory SecretResolverFactory
+	secretCache           map[string]string
 }
 
 type rawConfig struct {
 	App struct {
-		Env                string `mapstructure:"env"`
-		LogLevel           string `mapstructure:"log_level"`
-		JSONLogs           bool   `mapstructure:"json_logs"`
-		ScheduleExpression string `mapstructure:"schedule_expression"`
+		Env                 string        `mapstructure:"env" desc:"Deployment environment name, shown in logs"`
+		LogLevel            string        `mapstructure:"log_level" desc:"Log level" constraint:"DEBUG, INFO, WARN or ERROR"`
+		JSONLogs            bool          `mapstructure:"json_logs" desc:"Write logs as JSON lines"`
+		ScheduleExpression  string        `mapstructure:"schedule_expression" desc:"Cron expression for scheduled checks in server mode" constraint:"cron expression"`
+		ShutdownGracePeriod time.Duration `mapstructure:"shutdown_grace_period" desc:"How long a run may finish reporting after SIGTERM or SIGINT" constraint:">= 0"`
 	} `mapstructure:"app"`
 
 	AWS struct {
-		Region          string `mapstructure:"region"`
-		AccessKeyID     string `mapstructure:"access_key_id"`
-		SecretAccessKey string `mapstructure:"secret_access_key"`
-		Profile         string `mapstructure:"profile"`
-		Endpoint        string `mapstructure:"endpoint"`
+		Region          string `mapstructure:"region" desc:"AWS region to list instances in" constraint:"required"`
+		AccessKeyID     string `mapstructure:"access_key_id" desc:"Static access key ID (defaults to the SDK credential chain)" secret:"true"`
+		SecretAccessKey string `mapstructure:"secret_access_key" desc:"Static secret access key" secret:"true"`
+		Profile         string `mapstructure:"profile" desc:"Shared config profile to load credentials from"`
+		Endpoint        string `mapstructure:"endpoint" desc:"Custom EC2 endpoint, e.g. http://localhost:4566 for LocalStack"`
+		Source          string `mapstructure:"source" desc:"Where AWS instances are read from: live EC2 or the configuration items AWS Config recorded" constraint:"ec2 or config"`
+		AsOf            string `mapstructure:"as_of" desc:"Point in time to read AWS Config items at, including instances deleted since (empty reads the latest)" constraint:"RFC 3339 timestamp or date; config source only"`
 	} `mapstructure:"aws"`
 
 	Terraform struct {
-		StateFile string `mapstructure:"state_file"`
-		HCLDir    string `mapstructure:"hcl_dir"`
-		UseHCL    bool   `mapstructure:"use_hcl"`
+		StateFile      string `mapstructure:"state_file" desc:"Terraform state to compare: a local path, s3://bucket/key, gs://bucket/object or an http(s) URL" constraint:"required unless terraform.use_hcl or terraform.tfc_workspace is set"`
+		HCLDir         string `mapstructure:"hcl_dir" desc:"Directory of Terraform HCL files to compare instead of a state; with a state, its moved blocks let --resource match former addresses" constraint:"required when terraform.use_hcl is set"`
+		UseHCL         bool   `mapstructure:"use_hcl" desc:"Read instances from terraform.hcl_dir instead of a state"`
+		SOPSAgeKeyFile string `mapstructure:"sops_age_key_file" desc:"Age key for SOPS-encrypted state copies (falls back to SOPS_AGE_KEY and SOPS_AGE_KEY_FILE)"`
+		TFCWorkspace   string `mapstructure:"tfc_workspace" desc:"Terraform Cloud workspace whose current state is compared instead of terraform.state_file"`
+		TFCToken       string `mapstructure:"tfc_token" desc:"Terraform Cloud API token" constraint:"required when terraform.tfc_workspace is set" secret:"true"`
+		TFCAddress     string `mapstructure:"tfc_address" desc:"Terraform Cloud or Enterprise address"`
+		HTTPUsername   string `mapstructure:"http_username" desc:"Username for an http(s) state backend"`
+		HTTPPassword   string `mapstructure:"http_password" desc:"Password for an http(s) state backend" secret:"true"`
+		HTTPToken      string `mapstructure:"http_token" desc:"Bearer token for an http(s) state backend" secret:"true"`
+		S3Region       string `mapstructure:"s3_region" desc:"Region of the S3 state bucket (defaults to aws.region)"`
+		S3PathStyle    bool   `mapstructure:"s3_use_path_style" desc:"Address the S3 state bucket path-style, e.g. for S3-compatible stores; always on with a custom aws.endpoint"`
+		IncludeTainted bool   `mapstructure:"include_tainted" desc:"Compare tainted instances, which the next apply replaces; deposed objects are always skipped"`
+		ResolveSSMAMI  bool   `mapstructure:"resolve_ssm_ami" desc:"Look up AMIs that HCL reads from SSM parameters so they are compared instead of reported as unknown"`
+		CacheState     bool   `mapstructure:"cache_state" desc:"Reuse instances parsed from a state file while it is unchanged (--no-cache disables)"`
+		ReadRetries    int    `mapstructure:"read_retries" desc:"Retries, with backoff, of a local state file read failing with a transient IO error such as on EFS or NFS" constraint:">= 0"`
+
+		AllowUnsupportedState bool `mapstructure:"allow_unsupported_state" desc:"Parse states written by a newer Terraform best-effort with a warning instead of failing"`
+
+		Workspaces         []string `mapstructure:"workspaces" desc:"Workspaces of the state_file backend to compare, each against the AWS instances whose detector.environment_tag names it" constraint:"state file backends only"`
+		WorkspaceKeyPrefix string   `mapstructure:"workspace_key_prefix" desc:"The S3 backend's workspace_key_prefix"`
 	} `mapstructure:"terraform"`
 
 	Detector struct {
-		Attributes     []string `mapstructure:"attributes"`
-		SourceOfTruth  string   `mapstructure:"source_of_truth"`
-		ParallelChecks int      `mapstructure:"parallel_checks"`
-		TimeoutSeconds int      `mapstructure:"timeout_seconds"`
+		Attributes         []string `mapstructure:"attributes" desc:"Attributes to compare" constraint:"at least one"`
+		SourceOfTruth      string   `mapstructure:"source_of_truth" desc:"Side whose values are desired" constraint:"aws or terraform"`
+		ParallelChecks     int      `mapstructure:"parallel_checks" desc:"Instances checked concurrently (0 uses two per CPU, up to 16)" constraint:">= 0, lowered to detector.max_parallel_checks"`
+		MaxParallelChecks  int      `mapstructure:"max_parallel_checks" desc:"Cap on parallel_checks to stay within AWS API rate limits (0 disables the cap)" constraint:">= 0"`
+		TimeoutSeconds     int      `mapstructure:"timeout_seconds" desc:"Time budget of a run in seconds" constraint:"> 0"`
+		AWSTimeoutSeconds  int      `mapstructure:"aws_timeout_seconds" desc:"Per-call budget for AWS in seconds (0 uses timeout_seconds)" constraint:">= 0"`
+		TFTimeoutSeconds   int      `mapstructure:"terraform_timeout_seconds" desc:"Per-call budget for reading Terraform in seconds (0 uses timeout_seconds)" constraint:">= 0"`
+		AbortAfterErrors   int      `mapstructure:"abort_after_errors" desc:"Abort a run after this many instance failures (0 keeps going)" constraint:">= 0"`
+		ErrorOnEmpty       bool     `mapstructure:"error_on_empty" desc:"Fail the run when neither AWS nor Terraform returns any instances"`
+		MinInstances       int      `mapstructure:"min_instances" desc:"Fail the run when AWS or Terraform returns fewer instances (0 disables)" constraint:">= 0"`
+		ParallelProviders  bool     `mapstructure:"parallel_providers" desc:"Check instances as AWS and Terraform stream them in"`
+		StaticIPsOnly      bool     `mapstructure:"static_ips_only" desc:"Compare private_ip and public_ip only when declared in Terraform or bound to an EIP"`
+		SourceDeclaredOnly bool     `mapstructure:"source_declared_only" desc:"Only compare attributes the source of truth declares"`
+		CheckOrphans       bool     `mapstructure:"check_orphans" desc:"Report volumes, ENIs and EIPs no Terraform instance references (state files only)"`
+		EmptyEqualsAbsent  bool     `mapstructure:"empty_equals_absent" desc:"Treat empty strings, lists and maps as equal to a missing attribute"`
+		StrictPresence     []string `mapstructure:"strict_presence_paths" desc:"Paths where empty and missing values still differ"`
+		TrimTagValues      bool     `mapstructure:"trim_tag_values" desc:"Ignore leading and trailing whitespace in tag values"`
+		IgnoreTagCase      bool     `mapstructure:"ignore_tag_case" desc:"Compare tag values case-insensitively"`
+
+		Policies     []model.Policy `mapstructure:"policies" desc:"Assertions on the live AWS instance, each with a path, an operator and a value, reported as violations rather than drift" constraint:"operator is lt, lte, gt, gte, eq, ne or in"`
+		AllowedTypes []string       `mapstructure:"allowed_instance_types" desc:"Instance types allowed in AWS; others are reported as policy violations"`
+
+		EnvironmentTag     string `mapstructure:"environment_tag" desc:"AWS tag naming the Terraform workspace of an instance" constraint:"required with terraform.workspaces"`
+		StrictAccountCheck bool   `mapstructure:"strict_account_check" desc:"Fail instead of warning when the state names another AWS account or region than the client's"`
+		SuggestRemediation bool   `mapstructure:"suggest_remediation" desc:"Suggest Terraform or AWS CLI commands and HCL changes for each drift"`
+
+		EnrichNetworkContext bool `mapstructure:"enrich_network_context" desc:"Describe both subnets of a subnet_id drift and rate moves into another VPC high severity"`
+
+		Memoize bool `mapstructure:"memoize" desc:"Reuse the previous run's result for instances unchanged on both sides, unless the compared attributes or comparison settings changed"`
+
+		HashPrefilter bool `mapstructure:"hash_prefilter" desc:"Hash the compared attributes of both instances first and only compare them attribute by attribute when the hashes differ"`
+
+		DriftAgeLookback int `mapstructure:"drift_age_lookback" desc:"Previous results of an instance searched for when each drift first appeared, reported as its age (0 disables)" constraint:">= 0"`
+
+		CompareTerminationProtection bool `mapstructure:"compare_termination_protection" desc:"Flag instances whose AWS disable_api_termination disagrees with Terraform's lifecycle prevent_destroy" constraint:"needs HCL configuration as the Terraform source"`
+
+		StoreValues         string `mapstructure:"store_values" desc:"How drifted values are stored: in full, truncated at store_values_max_bytes, or as a hash" constraint:"full, truncated or hash"`
+		StoreValuesMaxBytes int    `mapstructure:"store_values_max_bytes" desc:"Size past which stored values are truncated" constraint:">= 0"`
+		UserDataHash        bool   `mapstructure:"user_data_hash" desc:"Compare user_data by a hash of the normalized script and report only digests and lengths"`
+		UserDataDiff        bool   `mapstructure:"user_data_diff" desc:"Add a unified diff of differing user_data scripts"`
+
+		VolatileAttributes []string `mapstructure:"volatile_attributes" desc:"Attributes left out of stored results so that history stays stable"`
+
+		AttributePresets map[string][]string `mapstructure:"attribute_presets" desc:"Named attribute bundles for --attributes-preset, overriding the built-in security, compute and tags presets of the same name" constraint:"at least one attribute each"`
+
+		DebugDumpDir          string `mapstructure:"debug_dump_dir" desc:"Directory to write the compared attributes of each instance to as JSON"`
+		DebugDumpMaxInstances int    `mapstructure:"debug_dump_max_instances" desc:"Instances dumped at most per process" constraint:">= 0"`
+
+		Tags struct {
+			UseTagsAll bool `mapstructure:"use_tags_all" desc:"Compare Terraform's tags_all, which includes provider default_tags, when the state has it"`
+		} `mapstructure:"tags"`
 	} `mapstructure:"detector"`
 
 	Reporter struct {
-		Type        string `mapstructure:"type"`
-		OutputFile  string `mapstructure:"output_file"`
-		PrettyPrint bool   `mapstructure:"pretty_print"`
+		Type        string `mapstructure:"type" desc:"Reporter to write reports with" constraint:"console, json, both, markdown, teams or metricsfile"`
+		OutputFile  string `mapstructure:"output_file" desc:"File the json, markdown and metricsfile reporters write to (empty writes to stdout; required for metricsfile)"`
+		PrettyPrint bool   `mapstructure:"pretty_print" desc:"Indent JSON reports"`
+
+		Timeout  time.Duration `mapstructure:"timeout" desc:"Give up delivering a report after this long (0s never gives up)" constraint:">= 0"`
+		MaxBytes int           `mapstructure:"max_bytes" desc:"Cap on JSON report files; larger reports keep their summary and the results that fit (0 never truncates)" constraint:">= 0"`
+
+		IncludeSnapshots bool `mapstructure:"include_snapshots" desc:"Add the full AWS and Terraform attributes of each compared instance to JSON reports"`
+
+		DigestInterval          time.Duration `mapstructure:"digest_interval" desc:"Batch notification reporters into digests sent at this interval (0s sends immediately)" constraint:">= 0"`
+		DigestImmediateSeverity string        `mapstructure:"digest_immediate_severity" desc:"Send the digest right away when a report has drift of this severity or higher (empty disables)" constraint:"high, medium or low"`
+		DigestFile              string        `mapstructure:"digest_file" desc:"File pending digests are buffered in, so they survive restarts and detect --flush-digests can send them (empty keeps them in memory)"`
+
+		Teams struct {
+			WebhookURL   string `mapstructure:"webhook_url" desc:"Teams incoming webhook" constraint:"required for the teams reporter" secret:"true"`
+			MaxInstances int    `mapstructure:"max_instances" desc:"Drifted instances detailed in the card; the rest are counted" constraint:">= 0"`
+			ReportURL    string `mapstructure:"report_url" desc:"Link to the full report from the card"`
+		} `mapstructure:"teams"`
+
+		HTTP struct {
+			MaxRetries int    `mapstructure:"max_retries" desc:"Retries for webhook reporters on network errors, 429 and 5xx" constraint:">= 0"`
+			ProxyURL   string `mapstructure:"proxy_url" desc:"Proxy for webhook reporters (defaults to HTTPS_PROXY and HTTP_PROXY)"`
+		} `mapstructure:"http"`
+
+		JSON struct {
+			MaxResultsPerFile int    `mapstructure:"max_results_per_file" desc:"Split larger JSON reports into numbered files plus a manifest (0 writes a single file)" constraint:">= 0"`
+			CleanReport       bool   `mapstructure:"clean_report" desc:"Confirm runs without drift in a clean report listing the instances checked and attributes compared"`
+			SigningKey        string `mapstructure:"signing_key" desc:"HMAC-SHA256 key that signs clean reports" secret:"true"`
+		} `mapstructure:"json"`
+
+		Console struct {
+			Template string `mapstructure:"template" desc:"Template file overriding the built-in console summary" constraint:"must parse as a text/template"`
+
+			SeverityColors map[string]string `mapstructure:"severity_colors" desc:"Colors of drifted attributes by severity, overriding high: red, medium: yellow and low: dim (NO_COLOR disables colors)" constraint:"high, medium or low mapped to red, green, yellow, blue, magenta, cyan, white, bold, dim or none"`
+		} `mapstructure:"console"`
+
+		Markdown struct {
+			Template string `mapstructure:"template" desc:"Template file overriding the built-in Markdown report" constraint:"must parse as a text/template"`
+		} `mapstructure:"markdown"`
+
+		Timezone string `mapstructure:"timezone" desc:"Time zone for console and Markdown timestamps (empty uses the system zone)" constraint:"IANA time zone name"`
 	} `mapstructure:"reporter"`
+
+	Server struct {
+		HealthPort               int `mapstructure:"health_port" desc:"Port serving /healthz, /readyz and /status in server mode (0 disables)" constraint:"0 to 65535"`
+		ReadinessIntervalMinutes int `mapstructure:"readiness_interval_minutes" desc:"How long a readiness check result is cached, in minutes" constraint:"> 0 when server.health_port is set"`
+	} `mapstructure:"server"`
+
+	Accounts []struct {
+		RoleARN string `mapstructure:"role_arn" desc:"Role to assume in the account" constraint:"IAM role ARN"`
+		Region  string `mapstructure:"region" desc:"Region to scan in the account (defaults to aws.region)"`
+	} `mapstructure:"accounts" desc:"AWS accounts to scan in one run by assuming a role in each"`
+
+	Reporters []struct {
+		Type       string `mapstructure:"type" desc:"Reporter type" constraint:"console, json, markdown, teams or metricsfile"`
+		OutputFile string `mapstructure:"output_file" desc:"File the reporter writes to" constraint:"unique across reporters"`
+		Pretty     *bool  `mapstructure:"pretty" desc:"Indent the report (defaults to reporter.pretty_print)"`
+	} `mapstructure:"reporters" desc:"Reporters to run, each with its own output file, in place of reporter.type and reporter.output_file"`
 }
 
 // NewConfigLoader creates a new config loader
 func NewConfigLoader(logger *logging.Logger, configDir string) *ConfigLoader {
 	return &ConfigLoader{
-		viper:     viper.New(),
-		config:    &Config{},
-		logger:    logger,
-		configDir: configDir,
+		viper:       viper.New(),
+		config:      &Config{},
+		logger:      logger,
+		configDir:   configDir,
+		secretCache: make(map[string]string),
 	}
 }
 
@@ -99,6 +229,10 @@ func (l *ConfigLoader) Load() (*Config, error) {
 		return nil, errors.NewSystemError("Failed to unmarshal configuration", err)
 	}
 	applyRawToConfig(raw, l.config)
+	if err := l.applyReferences(&raw); err != nil {
+		return nil, err
+	}
+	l.warnIfParallelChecksCapped(l.config, raw.Detector.ParallelChecks)
 
 	// Set up logging based on configuration
 	logging.ConfigureLogger(logging.LogConfig{
@@ -113,13 +247,18 @@ func (l *ConfigLoader) Load() (*Config, error) {
 
 // setDefaults sets default configuration values
 func (l *ConfigLoader) setDefaults() {
-	v := l.viper
+	setViperDefaults(l.viper)
+}
+
+// setViperDefaults sets default configuration values on a viper instance
+func setViperDefaults(v *viper.Viper) {
 
 	// App defaults
 	v.SetDefault("app.env", AppEnvDev)
 	v.SetDefault("app.log_level", LogLevelInfo)
 	v.SetDefault("app.json_logs", false)
 	v.SetDefault("app.schedule_expression", cronEvery6Hours) // Run every 6 hours by default
+	v.SetDefault("app.shutdown_grace_period", "25s")         // Inside the default Kubernetes termination grace period
 
 	// AWS defaults
 	v.SetDefault("aws.region", aWSDefaultRegion)
@@ -127,22 +266,94 @@ func (l *ConfigLoader) setDefaults() {
 	v.SetDefault("aws.secret_access_key", "")
 	v.SetDefault("aws.profile", "")
 	v.SetDefault("aws.endpoint", "")
+	v.SetDefault("aws.source", AWSSourceEC2)
+	v.SetDefault("aws.as_of", "")
 
 	// Terraform defaults
 	v.SetDefault("terraform.state_file", "")
 	v.SetDefault("terraform.hcl_dir", "")
 	v.SetDefault("terraform.use_hcl", false)
+	v.SetDefault("terraform.sops_age_key_file", "")
+	v.SetDefault("terraform.tfc_workspace", "")
+	v.SetDefault("terraform.tfc_token", "")
+	v.SetDefault("terraform.tfc_address", "https://app.terraform.io")
+	v.SetDefault("terraform.http_username", "")
+	v.SetDefault("terraform.http_password", "")
+	v.SetDefault("terraform.http_token", "")
+	v.SetDefault("terraform.s3_region", "")
+	v.SetDefault("terraform.s3_use_path_style", false)
+	v.SetDefault("terraform.include_tainted", false)
+	v.SetDefault("terraform.resolve_ssm_ami", false)
+	v.SetDefault("terraform.cache_state", true)
+	v.SetDefault("terraform.read_retries", 3)
+	v.SetDefault("terraform.allow_unsupported_state", false)
+	v.SetDefault("terraform.workspaces", []string{})
+	v.SetDefault("terraform.workspace_key_prefix", "env:")
 
 	// DriftDetection defaults
-	v.SetDefault("detector.attributes", []string{"instance_type", "ami", "vpc_security_group_ids", "tags"})
+	v.SetDefault("detector.attributes", []string{"instance_type", "ami", "vpc_security_group_ids", "tags", "associate_public_ip_address"})
 	v.SetDefault("detector.source_of_truth", defaultSourceOfTruth)
-	v.SetDefault("detector.parallel_checks", 5)
+	v.SetDefault("detector.parallel_checks", 0) // 0 derives it from the CPU count
+	v.SetDefault("detector.max_parallel_checks", defaultMaxParallelChecks)
 	v.SetDefault("detector.timeout_seconds", 60)
+	v.SetDefault("detector.aws_timeout_seconds", 0)
+	v.SetDefault("detector.terraform_timeout_seconds", 0)
+	v.SetDefault("detector.abort_after_errors", 0)
+	v.SetDefault("detector.error_on_empty", false)
+	v.SetDefault("detector.min_instances", 0)
+	v.SetDefault("detector.parallel_providers", false)
+	v.SetDefault("detector.static_ips_only", true)
+	v.SetDefault("detector.source_declared_only", false)
+	v.SetDefault("detector.check_orphans", false)
+	v.SetDefault("detector.empty_equals_absent", true)
+	v.SetDefault("detector.strict_presence_paths", []string{})
+	v.SetDefault("detector.trim_tag_values", false)
+	v.SetDefault("detector.ignore_tag_case", false)
+	v.SetDefault("detector.allowed_instance_types", []string{})
+	v.SetDefault("detector.environment_tag", "Environment")
+	v.SetDefault("detector.strict_account_check", false)
+	v.SetDefault("detector.suggest_remediation", false)
+	v.SetDefault("detector.enrich_network_context", false)
+	v.SetDefault("detector.compare_termination_protection", false)
+	v.SetDefault("detector.memoize", false)
+	v.SetDefault("detector.hash_prefilter", false)
+	v.SetDefault("detector.drift_age_lookback", 30)
+	v.SetDefault("detector.store_values", "full")
+	v.SetDefault("detector.store_values_max_bytes", 256)
+	v.SetDefault("detector.user_data_hash", true)
+	v.SetDefault("detector.volatile_attributes", []string{"launch_time", "public_dns_name"})
+	v.SetDefault("detector.attribute_presets", map[string][]string{})
+	v.SetDefault("detector.user_data_diff", false)
+	v.SetDefault("detector.debug_dump_dir", "")
+	v.SetDefault("detector.debug_dump_max_instances", 20)
+	v.SetDefault("detector.tags.use_tags_all", true)
 
 	// Reporter defaults
 	v.SetDefault("reporter.type", ReporterTypeConsole)
 	v.SetDefault("reporter.output_file", "")
 	v.SetDefault("reporter.pretty_print", true)
+	v.SetDefault("reporter.timeout", "0s") // 0s leaves report delivery unbounded
+	v.SetDefault("reporter.max_bytes", 0)  // 0 never truncates reports
+	v.SetDefault("reporter.include_snapshots", false)
+	v.SetDefault("reporter.digest_interval", "0s")
+	v.SetDefault("reporter.digest_immediate_severity", "")
+	v.SetDefault("reporter.digest_file", filepath.Join(getUserHomeDir(), ".drift-detector", "digests.json"))
+	v.SetDefault("reporter.teams.webhook_url", "")
+	v.SetDefault("reporter.teams.max_instances", 10)
+	v.SetDefault("reporter.teams.report_url", "")
+	v.SetDefault("reporter.http.max_retries", 3)
+	v.SetDefault("reporter.http.proxy_url", "")
+	v.SetDefault("reporter.json.max_results_per_file", 0) // 0 writes a single file
+	v.SetDefault("reporter.json.clean_report", false)
+	v.SetDefault("reporter.json.signing_key", "")
+	v.SetDefault("reporter.console.template", "")
+	v.SetDefault("reporter.console.severity_colors", map[string]string{})
+	v.SetDefault("reporter.markdown.template", "")
+	v.SetDefault("reporter.timezone", "") // empty uses the system time zone
+
+	// Server defaults
+	v.SetDefault("server.health_port", 0)
+	v.SetDefault("server.readiness_interval_minutes", 5)
 }
 
 // loadFromFile loads configuration from file
@@ -266,7 +477,11 @@ func findEnvrcFile(startDir string) (string, error) {
 
 // loadFromEnv loads configuration from environment variables
 func (l *ConfigLoader) loadFromEnv() {
-	v := l.viper
+	bindViperEnv(l.viper)
+}
+
+// bindViperEnv binds DRIFT_ prefixed environment variables to a viper instance
+func bindViperEnv(v *viper.Viper) {
 
 	// Set environment variable prefix
 	v.SetEnvPrefix("DRIFT")
@@ -278,6 +493,11 @@ func (l *ConfigLoader) loadFromEnv() {
 	v.AutomaticEnv()
 }
 
+// ConfigFileUsed returns the path of the configuration file that was loaded, if any
+func (l *ConfigLoader) ConfigFileUsed() string {
+	return l.viper.ConfigFileUsed()
+}
+
 // UpdateConfig updates the configuration with command-line flags
 func (l *ConfigLoader) UpdateConfig(cfg *Config, cliOpts map[string]interface{}) error {
 	l.mu.Lock()
@@ -296,16 +516,52 @@ func (l *ConfigLoader) UpdateConfig(cfg *Config, cliOpts map[string]interface{})
 				l.logger.SetLogLevel(cfg.app.logLevel)
 			}
 		case "attributes":
-			if attrs, ok := value.([]string); ok && len(attrs) > 0 {
+			if attrs, ok := value.([]string); ok {
+				attrs = normalizeAttributes(attrs)
+				if len(attrs) == 0 {
+					return errors.NewValidationError("--attributes must name at least one attribute")
+				}
 				cfg.SetAttributes(attrs)
 			}
+		case "attributes-preset":
+			// Expanded below, once --attributes has been applied
+		case "resource":
+			if patterns, ok := value.([]string); ok {
+				var filter []string
+				for _, pattern := range patterns {
+					if pattern = strings.TrimSpace(pattern); pattern != "" {
+						filter = append(filter, pattern)
+					}
+				}
+				if len(filter) == 0 {
+					return errors.NewValidationError("--resource must name at least one resource address")
+				}
+				cfg.SetResourceFilter(filter)
+			}
 		case "source-of-truth":
 			if sourceOfTruth, ok := value.(string); ok && sourceOfTruth != "" {
 				cfg.SetSourceOfTruth(sourceOfTruth)
 			}
 		case "parallel-checks":
-			if parallelChecks, ok := value.(int); ok && parallelChecks > 0 {
-				cfg.SetParallelChecks(parallelChecks)
+			if parallelChecks, ok := value.(int); ok {
+				if parallelChecks < 0 {
+					return errors.NewValidationError("--parallel-checks cannot be negative")
+				}
+				// 0 keeps the configured value
+				if parallelChecks > 0 {
+					l.warnIfParallelChecksCapped(cfg, parallelChecks)
+					cfg.SetParallelChecks(parallelChecks)
+				}
+			}
+		case "timeout":
+			if timeout, ok := value.(time.Duration); ok {
+				if timeout < 0 {
+					return errors.NewValidationError("--timeout cannot be negative")
+				}
+				// 0 keeps the configured value
+				if timeout > 0 {
+					cfg.SetTimeout(timeout)
+				}
 			}
 		case "state-file":
 			if stateFile, ok := value.(string); ok && stateFile != "" {
@@ -317,18 +573,65 @@ func (l *ConfigLoader) UpdateConfig(cfg *Config, cliOpts map[string]interface{})
 				cfg.SetHCLDir(hclDir)
 				cfg.SetUseHCL(true)
 			}
+		case "include-tainted":
+			if includeTainted, err := strconv.ParseBool(fmt.Sprint(value)); err == nil {
+				cfg.SetIncludeTainted(includeTainted)
+			}
+		case "resolve-ssm-ami":
+			if resolveSSMAMI, err := strconv.ParseBool(fmt.Sprint(value)); err == nil {
+				cfg.SetResolveSSMAMI(resolveSSMAMI)
+			}
+		case "no-cache":
+			if noCache, err := strconv.ParseBool(fmt.Sprint(value)); err == nil && noCache {
+				cfg.SetCacheState(false)
+			}
 		case "output":
+			// --output and --output-file describe a single reporter in place of the reporters list
 			if reporterType, ok := value.(string); ok && reporterType != "" {
 				cfg.SetReporterType(reporterType)
+				cfg.SetReporters(nil)
 			}
 		case "output-file":
 			if outputFile, ok := value.(string); ok && outputFile != "" {
 				cfg.SetOutputFile(outputFile)
+				cfg.SetReporters(nil)
 			}
 		case "aws-region":
 			if region, ok := value.(string); ok && region != "" {
 				cfg.SetAWSRegion(region)
 			}
+		case "aws-profile":
+			if profile, ok := value.(string); ok && profile != "" {
+				cfg.SetAWSProfile(profile)
+			}
+		case "aws-source":
+			if source, ok := value.(string); ok && source != "" {
+				cfg.SetAWSSource(source)
+			}
+		case "as-of":
+			if asOf, ok := value.(string); ok && asOf != "" {
+				cfg.SetAWSAsOf(asOf)
+			}
+		case "parallel-providers":
+			if parallelProviders, err := strconv.ParseBool(fmt.Sprint(value)); err == nil {
+				cfg.SetParallelProviders(parallelProviders)
+			}
+		case "error-on-empty":
+			if errorOnEmpty, err := strconv.ParseBool(fmt.Sprint(value)); err == nil {
+				cfg.SetErrorOnEmpty(errorOnEmpty)
+			}
+		case "user-data-diff":
+			if userDataDiff, err := strconv.ParseBool(fmt.Sprint(value)); err == nil {
+				cfg.SetUserDataDiff(userDataDiff)
+			}
+		case "suggest-remediation":
+			if suggestRemediation, err := strconv.ParseBool(fmt.Sprint(value)); err == nil {
+				cfg.SetSuggestRemediation(suggestRemediation)
+			}
+		case "debug-dump-dir":
+			if dir, ok := value.(string); ok && dir != "" {
+				cfg.SetDebugDumpDir(dir)
+			}
 		case "schedule-expression":
 			if expr, ok := value.(string); ok && expr != "" {
 				cfg.SetScheduleExpression(expr)
@@ -336,6 +639,18 @@ func (l *ConfigLoader) UpdateConfig(cfg *Config, cliOpts map[string]interface{})
 		}
 	}
 
+	if names, ok := cliOpts["attributes-preset"].([]string); ok {
+		attrs, err := expandAttributePresets(cfg, names)
+		if err != nil {
+			return err
+		}
+		// Presets merge with explicit --attributes and replace the configured ones otherwise
+		if _, explicit := cliOpts["attributes"]; explicit {
+			attrs = append(append([]string(nil), cfg.GetAttributes()...), attrs...)
+		}
+		cfg.SetAttributes(normalizeAttributes(attrs))
+	}
+
 	// Validate the updated configuration
 	if err := cfg.Validate(); err != nil {
 		return err
@@ -344,6 +659,50 @@ func (l *ConfigLoader) UpdateConfig(cfg *Config, cliOpts map[string]interface{})
 	return nil
 }
 
+// expandAttributePresets returns the attribute paths of the named presets
+func expandAttributePresets(cfg *Config, names []string) ([]string, error) {
+	var attrs []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		paths, ok := cfg.AttributePreset(name)
+		if !ok {
+			return nil, errors.NewValidationError(fmt.Sprintf("Unknown attribute preset %q (available: %s)", name, strings.Join(cfg.AttributePresetNames(), ", ")))
+		}
+		attrs = append(attrs, paths...)
+	}
+	if len(attrs) == 0 {
+		return nil, errors.NewValidationError("--attributes-preset must name at least one preset")
+	}
+	return attrs, nil
+}
+
+// warnIfParallelChecksCapped warns that requested parallel checks are lowered to
+// detector.max_parallel_checks
+func (l *ConfigLoader) warnIfParallelChecksCapped(cfg *Config, requested int) {
+	if max := cfg.GetMaxParallelChecks(); max > 0 && requested > max {
+		l.logger.Warn(fmt.Sprintf("Parallel checks %d exceeds detector.max_parallel_checks, using %d", requested, max))
+	}
+}
+
+// normalizeAttributes trims attribute paths, resolves aliases such as lifecycle and drops
+// empty and repeated entries, keeping the first occurrence of each
+func normalizeAttributes(attrs []string) []string {
+	seen := make(map[string]bool, len(attrs))
+	normalized := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		attr = model.CanonicalAttribute(strings.TrimSpace(attr))
+		if attr == "" || seen[attr] {
+			continue
+		}
+		seen[attr] = true
+		normalized = append(normalized, attr)
+	}
+	return normalized
+}
+
 // getUserHomeDir returns the current user's home directory
 func getUserHomeDir() string {
 	homeDir, err := os.UserHomeDir()
@@ -378,6 +737,10 @@ func (l *ConfigLoader) ReloadConfig() (*Config, error) {
 		return nil, errors.NewSystemError("Failed to unmarshal configuration", err)
 	}
 	applyRawToConfig(raw, l.config)
+	if err := l.applyReferences(&raw); err != nil {
+		return nil, err
+	}
+	l.warnIfParallelChecksCapped(l.config, raw.Detector.ParallelChecks)
 
 	if err := l.config.Validate(); err != nil {
 		return nil, err
@@ -386,28 +749,143 @@ func (l *ConfigLoader) ReloadConfig() (*Config, error) {
 	return l.config, nil
 }
 
+// applyReferences resolves the references in raw with the AWS settings just applied and applies
+// the configuration again with the resolved values
+func (l *ConfigLoader) applyReferences(raw *rawConfig) error {
+	resolved, err := l.resolveReferences(raw, l.config)
+	if err != nil {
+		return err
+	}
+	if resolved {
+		applyRawToConfig(*raw, l.config)
+	}
+	return nil
+}
+
 func applyRawToConfig(raw rawConfig, c *Config) {
 	c.SetEnv(raw.App.Env)
 	c.SetLogLevel(logging.LogLevel(strings.ToUpper(raw.App.LogLevel)))
 	c.SetJSONLogs(raw.App.JSONLogs)
 	c.SetScheduleExpression(raw.App.ScheduleExpression)
+	c.SetShutdownGracePeriod(raw.App.ShutdownGracePeriod)
 
 	c.SetAWSRegion(raw.AWS.Region)
 	c.SetAWSAccessKeyID(raw.AWS.AccessKeyID)
 	c.SetAWSSecretAccessKey(raw.AWS.SecretAccessKey)
 	c.SetAWSProfile(raw.AWS.Profile)
 	c.SetAWSEndpoint(raw.AWS.Endpoint)
+	c.SetAWSSource(raw.AWS.Source)
+	c.SetAWSAsOf(raw.AWS.AsOf)
 
 	c.SetStateFile(raw.Terraform.StateFile)
 	c.SetHCLDir(raw.Terraform.HCLDir)
 	c.SetUseHCL(raw.Terraform.UseHCL)
-
-	c.SetAttributes(raw.Detector.Attributes)
+	c.SetSOPSAgeKeyFile(raw.Terraform.SOPSAgeKeyFile)
+	c.SetTFCWorkspace(raw.Terraform.TFCWorkspace)
+	c.SetTFCToken(raw.Terraform.TFCToken)
+	c.SetTFCAddress(raw.Terraform.TFCAddress)
+	c.SetStateHTTPUsername(raw.Terraform.HTTPUsername)
+	c.SetStateHTTPPassword(raw.Terraform.HTTPPassword)
+	c.SetStateHTTPToken(raw.Terraform.HTTPToken)
+	c.SetStateS3Region(raw.Terraform.S3Region)
+	c.SetStateS3UsePathStyle(raw.Terraform.S3PathStyle)
+	c.SetIncludeTainted(raw.Terraform.IncludeTainted)
+	c.SetResolveSSMAMI(raw.Terraform.ResolveSSMAMI)
+	c.SetCacheState(raw.Terraform.CacheState)
+	c.SetStateReadRetries(raw.Terraform.ReadRetries)
+	c.SetAllowUnsupportedState(raw.Terraform.AllowUnsupportedState)
+	c.SetWorkspaces(raw.Terraform.Workspaces)
+	c.SetWorkspaceKeyPrefix(raw.Terraform.WorkspaceKeyPrefix)
+
+	c.SetAttributes(normalizeAttributes(raw.Detector.Attributes))
 	c.SetSourceOfTruth(raw.Detector.SourceOfTruth)
-	c.SetParallelChecks(raw.Detector.ParallelChecks)
+	c.SetMaxParallelChecks(raw.Detector.MaxParallelChecks)
+	if raw.Detector.ParallelChecks == 0 {
+		c.SetParallelChecks(DefaultParallelChecks())
+	} else {
+		c.SetParallelChecks(raw.Detector.ParallelChecks)
+	}
 	c.SetTimeout(time.Duration(raw.Detector.TimeoutSeconds) * time.Second)
+	c.SetAWSTimeout(time.Duration(raw.Detector.AWSTimeoutSeconds) * time.Second)
+	c.SetTerraformTimeout(time.Duration(raw.Detector.TFTimeoutSeconds) * time.Second)
+	c.SetAbortAfterErrors(raw.Detector.AbortAfterErrors)
+	c.SetErrorOnEmpty(raw.Detector.ErrorOnEmpty)
+	c.SetMinInstances(raw.Detector.MinInstances)
+	c.SetParallelProviders(raw.Detector.ParallelProviders)
+	c.SetStaticIPsOnly(raw.Detector.StaticIPsOnly)
+	c.SetSourceDeclaredOnly(raw.Detector.SourceDeclaredOnly)
+	c.SetCheckOrphans(raw.Detector.CheckOrphans)
+	c.SetEmptyEqualsAbsent(raw.Detector.EmptyEqualsAbsent)
+	c.SetStrictPresencePaths(raw.Detector.StrictPresence)
+	c.SetTrimTagValues(raw.Detector.TrimTagValues)
+	c.SetIgnoreTagCase(raw.Detector.IgnoreTagCase)
+	c.SetPolicies(raw.Detector.Policies)
+	c.SetAllowedInstanceTypes(raw.Detector.AllowedTypes)
+	c.SetEnvironmentTag(raw.Detector.EnvironmentTag)
+	c.SetStrictAccountCheck(raw.Detector.StrictAccountCheck)
+	c.SetSuggestRemediation(raw.Detector.SuggestRemediation)
+	c.SetEnrichNetworkContext(raw.Detector.EnrichNetworkContext)
+	c.SetCompareTerminationProtection(raw.Detector.CompareTerminationProtection)
+	c.SetMemoize(raw.Detector.Memoize)
+	c.SetHashPrefilter(raw.Detector.HashPrefilter)
+	c.SetDriftAgeLookback(raw.Detector.DriftAgeLookback)
+	c.SetStoreValues(raw.Detector.StoreValues)
+	c.SetStoreValuesMaxBytes(raw.Detector.StoreValuesMaxBytes)
+	c.SetUserDataHash(raw.Detector.UserDataHash)
+	c.SetUserDataDiff(raw.Detector.UserDataDiff)
+	c.SetVolatileAttributes(raw.Detector.VolatileAttributes)
+	presets := make(map[string][]string, len(raw.Detector.AttributePresets))
+	for name, paths := range raw.Detector.AttributePresets {
+		presets[name] = normalizeAttributes(paths)
+	}
+	c.SetAttributePresets(presets)
+	c.SetDebugDumpDir(raw.Detector.DebugDumpDir)
+	c.SetDebugDumpMaxInstances(raw.Detector.DebugDumpMaxInstances)
+	c.SetUseTagsAll(raw.Detector.Tags.UseTagsAll)
 
 	c.SetReporterType(raw.Reporter.Type)
 	c.SetOutputFile(raw.Reporter.OutputFile)
 	c.SetPrettyPrint(raw.Reporter.PrettyPrint)
+	c.SetReporterTimeout(raw.Reporter.Timeout)
+	c.SetReporterMaxBytes(raw.Reporter.MaxBytes)
+	c.SetIncludeSnapshots(raw.Reporter.IncludeSnapshots)
+	c.SetDigestInterval(raw.Reporter.DigestInterval)
+	c.SetDigestImmediateSeverity(raw.Reporter.DigestImmediateSeverity)
+	c.SetDigestFile(raw.Reporter.DigestFile)
+	c.SetTeamsWebhookURL(raw.Reporter.Teams.WebhookURL)
+	c.SetTeamsMaxInstances(raw.Reporter.Teams.MaxInstances)
+	c.SetTeamsReportURL(raw.Reporter.Teams.ReportURL)
+	c.SetHTTPMaxRetries(raw.Reporter.HTTP.MaxRetries)
+	c.SetHTTPProxyURL(raw.Reporter.HTTP.ProxyURL)
+	c.SetJSONMaxResultsPerFile(raw.Reporter.JSON.MaxResultsPerFile)
+	c.SetJSONCleanReport(raw.Reporter.JSON.CleanReport)
+	c.SetJSONSigningKey(raw.Reporter.JSON.SigningKey)
+	c.SetConsoleTemplate(raw.Reporter.Console.Template)
+	c.SetSeverityColors(raw.Reporter.Console.SeverityColors)
+	c.SetMarkdownTemplate(raw.Reporter.Markdown.Template)
+	c.SetTimezone(raw.Reporter.Timezone)
+
+	c.SetHealthPort(raw.Server.HealthPort)
+	c.SetReadinessInterval(time.Duration(raw.Server.ReadinessIntervalMinutes) * time.Minute)
+
+	accounts := make([]AccountConfig, 0, len(raw.Accounts))
+	for _, account := range raw.Accounts {
+		region := account.Region
+		if region == "" {
+			region = raw.AWS.Region
+		}
+		accounts = append(accounts, AccountConfig{RoleARN: account.RoleARN, Region: region})
+	}
+	c.SetAccounts(accounts)
+
+	// Reporters inherit reporter.pretty_print unless they set pretty themselves
+	reporters := make([]ReporterConfig, 0, len(raw.Reporters))
+	for _, rc := range raw.Reporters {
+		pretty := raw.Reporter.PrettyPrint
+		if rc.Pretty != nil {
+			pretty = *rc.Pretty
+		}
+		reporters = append(reporters, ReporterConfig{Type: rc.Type, OutputFile: rc.OutputFile, PrettyPrint: pretty})
+	}
+	c.SetReporters(reporters)
 }
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// Prefixes of configuration values that reference a secret instead of holding it, e.g.
+// ssm:/drift/teams-webhook or secretsmanager:drift/prod/webhook
+const (
+	ReferencePrefixSSM            = "ssm:"
+	ReferencePrefixSecretsManager = "secretsmanager:"
+)
+
+// referenceTimeout bounds resolving all references of a configuration
+const referenceTimeout = 30 * time.Second
+
+// SecretResolver reads the values configuration references point to
+type SecretResolver interface {
+	// GetParameter returns the decrypted value of an SSM parameter
+	GetParameter(ctx context.Context, name string) (string, error)
+
+	// GetSecretValue returns the string value of a Secrets Manager secret
+	GetSecretValue(ctx context.Context, secretID string) (string, error)
+}
+
+// SecretResolverFactory creates a resolver with the AWS settings of a loaded configuration
+type SecretResolverFactory func(ctx context.Context, cfg *Config) (SecretResolver, error)
+
+// IsSecretReference reports whether a configuration value references an SSM parameter or a
+// Secrets Manager secret
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(value, ReferencePrefixSSM) || strings.HasPrefix(value, ReferencePrefixSecretsManager)
+}
+
+// SetSecretResolverFactory sets how the resolver of configuration references is created. It is
+// only created when a loaded configuration holds references.
+func (l *ConfigLoader) SetSecretResolverFactory(factory SecretResolverFactory) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.secretResolverFactory = factory
+}
+
+// referenceField is a string configuration value holding a reference
+type referenceField struct {
+	key   string
+	value reflect.Value
+}
+
+// resolveReferences replaces the references among the string values of raw with the values
+// they point to, using the AWS settings already applied to cfg, and records which keys were
+// references so that they can be redacted. Resolved values are cached for the lifetime of the
+// loader. It reports whether any reference was resolved.
+func (l *ConfigLoader) resolveReferences(raw *rawConfig, cfg *Config) (bool, error) {
+	var fields []referenceField
+	collectReferences(reflect.ValueOf(raw).Elem(), "", &fields)
+	if len(fields) == 0 {
+		cfg.setSecretReferences(nil)
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), referenceTimeout)
+	defer cancel()
+
+	var resolver SecretResolver
+	references := make(map[string]string, len(fields))
+	for _, field := range fields {
+		reference := field.value.String()
+
+		// The AWS settings configure the client references are read with
+		if strings.HasPrefix(field.key, "aws.") {
+			return false, errors.NewValidationError(fmt.Sprintf("%s cannot reference %s: AWS settings configure the client that reads references", field.key, reference)).
+				WithContext("key", field.key).WithContext("reference", reference)
+		}
+
+		value, ok := l.secretCache[reference]
+		if !ok {
+			if resolver == nil {
+				var err error
+				if resolver, err = l.secretResolver(ctx, cfg); err != nil {
+					return false, referenceError(field.key, reference, err)
+				}
+			}
+
+			var err error
+			if value, err = readReference(ctx, resolver, reference); err != nil {
+				return false, referenceError(field.key, reference, err)
+			}
+			l.secretCache[reference] = value
+		}
+
+		field.value.SetString(value)
+		references[field.key] = reference
+	}
+
+	cfg.setSecretReferences(references)
+	l.logger.Info(fmt.Sprintf("Resolved %d configuration references", len(references)))
+	return true, nil
+}
+
+// secretResolver creates the resolver of configuration references
+func (l *ConfigLoader) secretResolver(ctx context.Context, cfg *Config) (SecretResolver, error) {
+	if l.secretResolverFactory == nil {
+		return nil, stderrors.New("references are not supported here")
+	}
+	return l.secretResolverFactory(ctx, cfg)
+}
+
+// readReference reads the value a reference points to
+func readReference(ctx context.Context, resolver SecretResolver, reference string) (string, error) {
+	if name, ok := strings.CutPrefix(reference, ReferencePrefixSSM); ok {
+		if name == "" {
+			return "", stderrors.New("the parameter name is empty")
+		}
+		return resolver.GetParameter(ctx, name)
+	}
+
+	secretID := strings.TrimPrefix(reference, ReferencePrefixSecretsManager)
+	if secretID == "" {
+		return "", stderrors.New("the secret ID is empty")
+	}
+	return resolver.GetSecretValue(ctx, secretID)
+}
+
+// referenceError is the validation error of a reference that couldn't be resolved, naming the
+// key and the reference
+func referenceError(key, reference string, err error) error {
+	reason := err.Error()
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		reason = appErr.Message
+	}
+	return errors.NewValidationError(fmt.Sprintf("Failed to resolve %s from %s: %s", key, reference, reason)).
+		WithContext("key", key).WithContext("reference", reference)
+}
+
+// collectReferences appends the string values under v that hold references, keyed like the
+// configuration file, e.g. reporter.teams.webhook_url or reporters[1].output_file
+func collectReferences(v reflect.Value, key string, fields *[]referenceField) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			collectReferences(v.Elem(), key, fields)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := t.Field(i).Tag.Get("mapstructure")
+			if name == "" {
+				continue
+			}
+			if key != "" {
+				name = key + "." + name
+			}
+			collectReferences(v.Field(i), name, fields)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectReferences(v.Index(i), fmt.Sprintf("%s[%d]", key, i), fields)
+		}
+	case reflect.String:
+		if IsSecretReference(v.String()) {
+			*fields = append(*fields, referenceField{key: key, value: v})
+		}
+	}
+}
+
+// SecretReferences returns the keys whose values were resolved from references, mapped to the
+// references
+func (c *Config) SecretReferences() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	references := make(map[string]string, len(c.references))
+	for key, reference := range c.references {
+		references[key] = reference
+	}
+	return references
+}
+
+// SecretReferenceKeys returns the keys whose values were resolved from references, sorted
+func (c *Config) SecretReferenceKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]string, 0, len(c.references))
+	for key := range c.references {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Redacted returns value for display, or the reference it was resolved from when the key
+// was a reference, so that resolved secrets are never shown
+func (c *Config) Redacted(key, value string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if reference, ok := c.references[key]; ok {
+		return fmt.Sprintf("<resolved from %s>", reference)
+	}
+	return value
+}
+
+func (c *Config) setSecretReferences(references map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.references = references
+}
@@ -0,0 +1,152 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/config"
+)
+
+// fakeSecretResolver serves parameters and secrets from maps and counts the reads
+type fakeSecretResolver struct {
+	parameters map[string]string
+	secrets    map[string]string
+	reads      int
+}
+
+func (r *fakeSecretResolver) GetParameter(_ context.Context, name string) (string, error) {
+	r.reads++
+	value, ok := r.parameters[name]
+	if !ok {
+		return "", errors.NewNotFoundError("SSM parameter", name)
+	}
+	return value, nil
+}
+
+func (r *fakeSecretResolver) GetSecretValue(_ context.Context, secretID string) (string, error) {
+	r.reads++
+	value, ok := r.secrets[secretID]
+	if !ok {
+		return "", errors.NewNotFoundError("Secrets Manager secret", secretID)
+	}
+	return value, nil
+}
+
+// referenceLoader returns a loader for a config file with contents, resolving references
+// with resolver
+func referenceLoader(t *testing.T, contents string, resolver config.SecretResolver) *config.ConfigLoader {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(contents), 0600))
+
+	loader := config.NewConfigLoader(logging.New(), dir)
+	if resolver != nil {
+		loader.SetSecretResolverFactory(func(context.Context, *config.Config) (config.SecretResolver, error) {
+			return resolver, nil
+		})
+	}
+	return loader
+}
+
+func TestIsSecretReference(t *testing.T) {
+	assert.True(t, config.IsSecretReference("ssm:/drift/teams-webhook"))
+	assert.True(t, config.IsSecretReference("secretsmanager:drift/prod/webhook"))
+	assert.False(t, config.IsSecretReference("https://example.webhook.office.com/hook"))
+	assert.False(t, config.IsSecretReference("terraform.tfstate"))
+}
+
+func TestConfigLoader_ResolvesReferences(t *testing.T) {
+	resolver := &fakeSecretResolver{
+		parameters: map[string]string{"/drift/teams-webhook": "https://example.webhook.office.com/hook"},
+		secrets:    map[string]string{"drift/prod/tfc": "tfc-token"},
+	}
+	loader := referenceLoader(t, `terraform:
+  tfc_workspace: prod
+  tfc_token: secretsmanager:drift/prod/tfc
+reporter:
+  teams:
+    webhook_url: ssm:/drift/teams-webhook
+detector:
+  attributes:
+    - instance_type
+`, resolver)
+
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.webhook.office.com/hook", cfg.GetTeamsWebhookURL())
+	assert.Equal(t, "tfc-token", cfg.GetTFCToken())
+	assert.Equal(t, []string{"instance_type"}, cfg.GetAttributes())
+	assert.Equal(t, map[string]string{
+		"reporter.teams.webhook_url": "ssm:/drift/teams-webhook",
+		"terraform.tfc_token":        "secretsmanager:drift/prod/tfc",
+	}, cfg.SecretReferences())
+	assert.Equal(t, []string{"reporter.teams.webhook_url", "terraform.tfc_token"}, cfg.SecretReferenceKeys())
+
+	// Resolved values are redacted for display, plain values are not
+	assert.Equal(t, "<resolved from ssm:/drift/teams-webhook>", cfg.Redacted("reporter.teams.webhook_url", cfg.GetTeamsWebhookURL()))
+	assert.Equal(t, "prod", cfg.Redacted("terraform.tfc_workspace", cfg.GetTFCWorkspace()))
+
+	// Reloading reuses the values read for the process
+	_, err = loader.ReloadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 2, resolver.reads)
+}
+
+func TestConfigLoader_ReferenceFailures(t *testing.T) {
+	t.Run("missing parameter names the key and the reference", func(t *testing.T) {
+		loader := referenceLoader(t, `terraform:
+  state_file: terraform.tfstate
+reporter:
+  teams:
+    webhook_url: ssm:/drift/missing
+`, &fakeSecretResolver{})
+
+		_, err := loader.Load()
+		require.Error(t, err)
+		assert.True(t, errors.IsValidationError(err))
+		assert.ErrorContains(t, err, "reporter.teams.webhook_url")
+		assert.ErrorContains(t, err, "ssm:/drift/missing")
+		assert.ErrorContains(t, err, "SSM parameter with ID '/drift/missing' not found")
+	})
+
+	t.Run("references in list entries are keyed by index", func(t *testing.T) {
+		loader := referenceLoader(t, `terraform:
+  state_file: terraform.tfstate
+reporters:
+  - type: console
+  - type: json
+    output_file: secretsmanager:drift/missing
+`, &fakeSecretResolver{})
+
+		_, err := loader.Load()
+		assert.ErrorContains(t, err, "reporters[1].output_file")
+	})
+
+	t.Run("AWS settings cannot be references", func(t *testing.T) {
+		resolver := &fakeSecretResolver{parameters: map[string]string{"/drift/key": "secret"}}
+		loader := referenceLoader(t, `aws:
+  secret_access_key: ssm:/drift/key
+terraform:
+  state_file: terraform.tfstate
+`, resolver)
+
+		_, err := loader.Load()
+		assert.ErrorContains(t, err, "aws.secret_access_key cannot reference ssm:/drift/key")
+		assert.Zero(t, resolver.reads)
+	})
+
+	t.Run("without a resolver", func(t *testing.T) {
+		loader := referenceLoader(t, `terraform:
+  state_file: ssm:/drift/state
+`, nil)
+
+		_, err := loader.Load()
+		assert.True(t, errors.IsValidationError(err))
+		assert.ErrorContains(t, err, "terraform.state_file")
+	})
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// Configuration reference formats
+const (
+	SchemaFormatMarkdown = "markdown"
+	SchemaFormatJSON     = "json"
+)
+
+// kindObjectList is the type of keys holding a list of objects, such as accounts
+const kindObjectList keyKind = "object list"
+
+// kindListMap is the type of keys holding named lists, such as detector.attribute_presets
+const kindListMap keyKind = "map of lists"
+
+// kindMap is the type of keys holding named values, such as reporter.console.severity_colors
+const kindMap keyKind = "map"
+
+// SchemaEntry documents a configuration key
+type SchemaEntry struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+
+	// Default is the value used when the key is not set; nil when there is none
+	Default interface{} `json:"default"`
+
+	// EnvVar is the environment variable that sets the key; keys inside lists have none
+	EnvVar string `json:"env_var,omitempty"`
+
+	Description string `json:"description"`
+	Constraints string `json:"constraints,omitempty"`
+
+	// Secret keys should be set through the environment rather than written to a file
+	Secret bool `json:"secret,omitempty"`
+
+	// Editable reports whether `config set` can write the key
+	Editable bool `json:"editable"`
+}
+
+// Schema describes every configuration key: the keys and types come from the mapstructure tags
+// of the raw configuration, descriptions and constraints from its desc and constraint tags,
+// secrets from its secret tags, and defaults from the registered viper defaults. The keys
+// `config set` can write are derived from the same entries. Entries are in declaration order; the fields of
+// objects in a list follow the list as key[].field.
+func Schema() []SchemaEntry {
+	defaults := viper.New()
+	setViperDefaults(defaults)
+
+	var entries []SchemaEntry
+	walkSchema(reflect.TypeOf(rawConfig{}), "", false, defaults, &entries)
+	return entries
+}
+
+// walkSchema appends an entry per leaf key of a configuration struct, descending into sections
+// and into the objects of lists
+func walkSchema(t reflect.Type, prefix string, inList bool, defaults *viper.Viper, entries *[]SchemaEntry) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			walkSchema(fieldType, key, inList, defaults, entries)
+			continue
+		}
+
+		kind := schemaKind(fieldType)
+		entry := SchemaEntry{
+			Key:         key,
+			Type:        string(kind),
+			Description: field.Tag.Get("desc"),
+			Constraints: field.Tag.Get("constraint"),
+		}
+		if !inList {
+			entry.Default = defaults.Get(key)
+			if kind != kindObjectList && kind != kindListMap && kind != kindMap {
+				entry.EnvVar = envVarName(key)
+			}
+			entry.Editable = isEditableKind(kind)
+			entry.Secret = field.Tag.Get("secret") == "true"
+		}
+		*entries = append(*entries, entry)
+
+		if kind == kindObjectList {
+			walkSchema(fieldType.Elem(), key+"[]", true, defaults, entries)
+		}
+	}
+}
+
+// isEditableKind reports whether `config set` can write keys of a kind from a single value
+func isEditableKind(kind keyKind) bool {
+	switch kind {
+	case kindString, kindInt, kindBool, kindList, kindDuration:
+		return true
+	}
+	return false
+}
+
+// schemaKind names the type of a configuration value
+func schemaKind(t reflect.Type) keyKind {
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)):
+		return kindDuration
+	case t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Slice:
+		return kindListMap
+	case t.Kind() == reflect.Map:
+		return kindMap
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+		return kindObjectList
+	case t.Kind() == reflect.Slice:
+		return kindList
+	case t.Kind() == reflect.Bool:
+		return kindBool
+	case t.Kind() == reflect.Int:
+		return kindInt
+	}
+	return kindString
+}
+
+// envVarName returns the environment variable bound to a key, e.g. DRIFT_APP_LOG_LEVEL
+func envVarName(key string) string {
+	return "DRIFT_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// WriteSchema writes the configuration reference in the given format: Markdown tables for
+// the README or JSON for tooling
+func WriteSchema(w io.Writer, format string) error {
+	entries := Schema()
+	switch format {
+	case SchemaFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	case SchemaFormatMarkdown:
+		return writeSchemaMarkdown(w, entries)
+	}
+	return errors.NewValidationError(fmt.Sprintf("Unsupported schema format %q (supported: %s, %s)", format, SchemaFormatMarkdown, SchemaFormatJSON))
+}
+
+// writeSchemaMarkdown writes the reference as a table per top-level section
+func writeSchemaMarkdown(w io.Writer, entries []SchemaEntry) error {
+	var b strings.Builder
+	b.WriteString("# Configuration reference\n\n")
+	b.WriteString("Keys are set in config.yaml or, outside lists, through the environment variable shown. Generated by `drift-detector config schema`.\n")
+
+	section := ""
+	for _, entry := range entries {
+		top, _, _ := strings.Cut(entry.Key, ".")
+		if top = strings.TrimSuffix(top, "[]"); top != section {
+			section = top
+			fmt.Fprintf(&b, "\n## %s\n\n", section)
+			b.WriteString("| Key | Type | Default | Environment variable | Description | Constraints |\n")
+			b.WriteString("|-----|------|---------|----------------------|-------------|-------------|\n")
+		}
+
+		kind := entry.Type
+		if entry.Secret {
+			kind += " (secret)"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s |\n",
+			entry.Key, kind, code(formatDefault(entry.Default)), code(entry.EnvVar),
+			markdownCell(entry.Description), markdownCell(entry.Constraints))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatDefault renders a default value for the Markdown reference
+func formatDefault(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	case []string:
+		return "[" + strings.Join(v, ", ") + "]"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// code wraps a non-empty value in backticks
+func code(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "`" + markdownCell(value) + "`"
+}
+
+// markdownCell escapes pipes so that a value stays in its table cell
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
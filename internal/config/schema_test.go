@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apperrors "github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/config"
+)
+
+func TestSchema_DescribesEveryKey(t *testing.T) {
+	entries := make(map[string]config.SchemaEntry)
+	for _, entry := range config.Schema() {
+		entries[entry.Key] = entry
+
+		// Every key is documented, and every key outside lists has a default
+		assert.NotEmpty(t, entry.Description, "%s has no desc tag", entry.Key)
+		if !strings.Contains(entry.Key, "[]") && entry.Type != "object list" {
+			assert.NotNil(t, entry.Default, "%s has no default", entry.Key)
+		}
+	}
+
+	// config set writes exactly the keys the reference marks editable
+	var editable []string
+	for key, entry := range entries {
+		if entry.Editable {
+			editable = append(editable, key)
+		}
+	}
+	assert.ElementsMatch(t, editable, config.ConfigKeys())
+	assert.Contains(t, config.ConfigKeys(), "reporter.teams.webhook_url")
+	assert.NotContains(t, config.ConfigKeys(), "accounts")
+	assert.NotContains(t, config.ConfigKeys(), "reporter.console.severity_colors")
+
+	timeout := entries["detector.timeout_seconds"]
+	assert.Equal(t, "int", timeout.Type)
+	assert.EqualValues(t, 60, timeout.Default)
+	assert.Equal(t, "DRIFT_DETECTOR_TIMEOUT_SECONDS", timeout.EnvVar)
+	assert.Equal(t, "> 0", timeout.Constraints)
+
+	assert.Equal(t, "duration", entries["reporter.timeout"].Type)
+	assert.Equal(t, "list", entries["detector.attributes"].Type)
+	assert.True(t, entries["terraform.tfc_token"].Secret)
+	assert.Equal(t, "DRIFT_REPORTER_JSON_SIGNING_KEY", entries["reporter.json.signing_key"].EnvVar)
+
+	// Objects in lists are documented field by field, without environment variables
+	assert.Equal(t, "object list", entries["accounts"].Type)
+	assert.Empty(t, entries["accounts"].EnvVar)
+	roleARN, ok := entries["accounts[].role_arn"]
+	require.True(t, ok)
+	assert.Empty(t, roleARN.EnvVar)
+	assert.Nil(t, roleARN.Default)
+	assert.False(t, roleARN.Editable)
+}
+
+func TestWriteSchema(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, config.WriteSchema(&out, config.SchemaFormatJSON))
+	var entries []config.SchemaEntry
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entries))
+	assert.Len(t, entries, len(config.Schema()))
+	assert.Equal(t, "app.env", entries[0].Key)
+
+	out.Reset()
+	require.NoError(t, config.WriteSchema(&out, config.SchemaFormatMarkdown))
+	markdown := out.String()
+	assert.Contains(t, markdown, "## detector\n")
+	assert.Contains(t, markdown, "| `reporter.type` | string | `console` | `DRIFT_REPORTER_TYPE` | Reporter to write reports with | console, json, both, markdown, teams or metricsfile |")
+	assert.Contains(t, markdown, "| `aws.secret_access_key` | string (secret) |")
+	assert.Equal(t, 1, strings.Count(markdown, "## accounts\n"))
+
+	err := config.WriteSchema(&out, "yaml")
+	assert.True(t, apperrors.IsValidationError(err))
+}
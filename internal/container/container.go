@@ -21,13 +21,16 @@ type CLIHandlerProvider interface {
 // DriftDetectorServiceFactory is a function type that creates a drift detector service
 type DriftDetectorServiceFactory func(
 	awsProvider service.InstanceProvider,
-	terraformProvider service.InstanceProvider,
+	terraformProvider service.TerraformProvider,
 	repository service.DriftRepository,
 	reporters []service.Reporter,
 	config service.DriftDetectorConfig,
 	logger *logging.Logger,
 ) service.DriftDetectorProvider
 
+// AWSProviderFactory is a function type that creates the AWS instance provider for a configuration
+type AWSProviderFactory func(ctx context.Context, cfg *config.Config) (service.InstanceProvider, error)
+
 // Container manages dependencies in a type-safe registry
 type Container struct {
 	registry map[string]any
@@ -41,10 +44,13 @@ func NewContainer() *Container {
 	logger := logging.New()
 	c.Register("logger", logger)
 	c.Register("errorHandler", errors.NewErrorHandler(logger))
-	c.Register("configLoader", config.NewConfigLoader(logger, "."))
+	instanceProviderFactory := factory.NewInstanceProviderFactory(logger)
+	configLoader := config.NewConfigLoader(logger, ".")
+	configLoader.SetSecretResolverFactory(instanceProviderFactory.CreateSecretResolver)
+	c.Register("configLoader", configLoader)
 	c.Register("driftDetectorServiceFactory", func(
 		awsProvider service.InstanceProvider,
-		terraformProvider service.InstanceProvider,
+		terraformProvider service.TerraformProvider,
 		repository service.DriftRepository,
 		reporters []service.Reporter,
 		config service.DriftDetectorConfig,
@@ -54,7 +60,7 @@ func NewContainer() *Container {
 		// but we register the factory here to avoid circular imports
 		return nil // This will be replaced when resolving
 	})
-	c.Register("instanceProviderFactory", factory.NewInstanceProviderFactory(logger))
+	c.Register("instanceProviderFactory", instanceProviderFactory)
 	c.Register("driftDetectorFactory", factory.NewDriftDetectorFactory(logger))
 	c.Register("reporterFactory", factory.NewReporterFactory(logger))
 	c.Register("repositoryFactory", factory.NewRepositoryFactory(logger))
@@ -107,7 +113,18 @@ func (c *Container) GetDriftDetectorServiceFactory() (DriftDetectorServiceFactor
 func (c *Container) GetCLIHandler(ctx context.Context, application service.DriftDetectorProvider, cfg *config.Config) CLIHandlerProvider {
 	logger, _ := Resolve[*logging.Logger](c, "logger")
 	configLoader, _ := Resolve[*config.ConfigLoader](c, "configLoader")
-	return cli.NewHandler(ctx, application, configLoader, cfg, logger)
+
+	options := cli.DefaultHandlerOptions()
+	if errorHandler, err := Resolve[*errors.ErrorHandler](c, "errorHandler"); err == nil {
+		options.ErrorHandler = errorHandler
+	}
+	if version, err := Resolve[string](c, "version"); err == nil {
+		options.Version = version
+	}
+	if awsProviderFactory, err := Resolve[AWSProviderFactory](c, "awsProviderFactory"); err == nil {
+		options.AWSProviderFactory = awsProviderFactory
+	}
+	return cli.NewHandlerWithOptions(ctx, application, configLoader, cfg, logger, options)
 }
 
 var (
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// MatchResourceAddress reports whether a Terraform resource address matches a pattern. The
+// pattern is an address or a glob where * matches any run of characters and ? any single
+// one; brackets are literal, so aws_instance.app[2] matches itself. Like terraform -target, a
+// pattern naming a resource or module also matches what is inside it: aws_instance.app
+// matches aws_instance.app[2] and module.web matches module.web.aws_instance.app.
+func MatchResourceAddress(pattern, address string) bool {
+	if pattern == "" || address == "" {
+		return false
+	}
+	for _, prefix := range addressPrefixes(address) {
+		if globMatch(pattern, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAnyResourceAddress reports whether a resource address matches any of the patterns
+func MatchAnyResourceAddress(patterns []string, address string) bool {
+	for _, pattern := range patterns {
+		if MatchResourceAddress(pattern, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddressMoves maps the former Terraform addresses of moved resources and modules to the
+// addresses they were moved to, as moved blocks declare them. Moving a resource or module moves
+// everything in it: after moving aws_instance.web to module.web.aws_instance.this,
+// aws_instance.web[0] is module.web.aws_instance.this[0].
+type AddressMoves map[string]string
+
+// Current returns the address a resource ends up at after following the moves, or the address
+// itself when it wasn't moved
+func (m AddressMoves) Current(address string) string {
+	seen := make(map[string]bool)
+	for !seen[address] {
+		seen[address] = true
+		moved := false
+		for _, prefix := range addressPrefixes(address) {
+			if to, ok := m[prefix]; ok {
+				address = to + address[len(prefix):]
+				moved = true
+				break
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return address
+}
+
+// Former returns the addresses a resource was known by before the moves, following chained
+// moves, sorted
+func (m AddressMoves) Former(address string) []string {
+	seen := map[string]bool{address: true}
+	var former []string
+	for queue := []string{address}; len(queue) > 0; queue = queue[1:] {
+		current := queue[0]
+		for _, prefix := range addressPrefixes(current) {
+			for from, to := range m {
+				if to != prefix {
+					continue
+				}
+				previous := from + current[len(prefix):]
+				if !seen[previous] {
+					seen[previous] = true
+					former = append(former, previous)
+					queue = append(queue, previous)
+				}
+			}
+		}
+	}
+	sort.Strings(former)
+	return former
+}
+
+// addressPrefixes returns the address and each prefix of it that ends before a step or an
+// index key, so module.web.aws_instance.app[0] yields module, module.web,
+// module.web.aws_instance, module.web.aws_instance.app and the full address. Dots and
+// brackets inside quoted index keys such as ["a.b"] don't end a prefix.
+func addressPrefixes(address string) []string {
+	var prefixes []string
+	depth, quoted := 0, false
+	for i, r := range address {
+		switch {
+		case quoted:
+			if r == '"' {
+				quoted = false
+			}
+		case r == '"':
+			quoted = true
+		case r == '[':
+			if depth == 0 && i > 0 {
+				prefixes = append(prefixes, address[:i])
+			}
+			depth++
+		case r == ']':
+			depth--
+		case r == '.' && depth == 0 && i > 0:
+			prefixes = append(prefixes, address[:i])
+		}
+	}
+	return append(prefixes, address)
+}
+
+// globMatch reports whether s matches a pattern where * matches any run of characters and ?
+// any single character
+func globMatch(pattern, s string) bool {
+	if !strings.ContainsAny(pattern, "*?") {
+		return pattern == s
+	}
+
+	p, str := []rune(pattern), []rune(s)
+	pi, si := 0, 0
+	star, resume := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]):
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			// Match nothing for now, backtracking to consume one more character on a mismatch
+			star, resume = pi, si
+			pi++
+		case star >= 0:
+			resume++
+			pi, si = star+1, resume
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchResourceAddress(t *testing.T) {
+	tests := []struct {
+		pattern string
+		address string
+		match   bool
+	}{
+		// Exact addresses, with and without index keys
+		{"aws_instance.app", "aws_instance.app", true},
+		{"aws_instance.app[2]", "aws_instance.app[2]", true},
+		{"aws_instance.app[2]", "aws_instance.app[20]", false},
+		{"aws_instance.app[2]", "aws_instance.app[1]", false},
+		{`aws_instance.app["web"]`, `aws_instance.app["web"]`, true},
+
+		// A resource or module contains its instances and resources
+		{"aws_instance.app", "aws_instance.app[2]", true},
+		{"aws_instance.app", `aws_instance.app["web"]`, true},
+		{"aws_instance.app", "aws_instance.application", false},
+		{"module.web", "module.web.aws_instance.app", true},
+		{"module.web", "module.web[0].aws_instance.app", true},
+		{"module.web", "module.webserver.aws_instance.app", false},
+		{"module.web.aws_instance.app", "module.web.aws_instance.app[1]", true},
+
+		// Globs
+		{"module.web.*", "module.web.aws_instance.app[0]", true},
+		{"module.web.*", "module.api.aws_instance.app", false},
+		{"aws_instance.app[*]", "aws_instance.app[2]", true},
+		{"aws_instance.app[*]", "aws_instance.app", false},
+		{"aws_instance.app[?]", "aws_instance.app[7]", true},
+		{"aws_instance.app[?]", "aws_instance.app[12]", false},
+		{"*.aws_instance.app", "module.web.aws_instance.app", true},
+		{"aws_instance.*", "module.web.aws_instance.app", false},
+
+		// Dots inside quoted keys don't split the address
+		{`aws_instance.app["a.b"]`, `aws_instance.app["a.b"]`, true},
+		{"aws_instance.app", `aws_instance.app["a.b"]`, true},
+		{`aws_instance.app["a`, `aws_instance.app["a.b"]`, false},
+
+		// Nothing matches an empty pattern or address
+		{"", "aws_instance.app", false},
+		{"*", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.address, func(t *testing.T) {
+			assert.Equal(t, tt.match, MatchResourceAddress(tt.pattern, tt.address))
+		})
+	}
+}
+
+func TestMatchAnyResourceAddress(t *testing.T) {
+	patterns := []string{"module.web.*", "aws_instance.db[1]"}
+	assert.True(t, MatchAnyResourceAddress(patterns, "module.web.aws_instance.app"))
+	assert.True(t, MatchAnyResourceAddress(patterns, "aws_instance.db[1]"))
+	assert.False(t, MatchAnyResourceAddress(patterns, "aws_instance.db[0]"))
+	assert.False(t, MatchAnyResourceAddress(nil, "aws_instance.db[0]"))
+}
+
+func TestAddressMoves(t *testing.T) {
+	moves := AddressMoves{
+		"aws_instance.web":   "module.web.aws_instance.this",
+		"aws_instance.old":   "aws_instance.web",
+		"module.legacy":      "module.app",
+		"aws_instance.loop":  "aws_instance.loop2",
+		"aws_instance.loop2": "aws_instance.loop",
+	}
+
+	assert.Equal(t, "module.web.aws_instance.this", moves.Current("aws_instance.web"))
+	assert.Equal(t, "module.web.aws_instance.this[0]", moves.Current("aws_instance.web[0]"))
+	assert.Equal(t, "module.web.aws_instance.this", moves.Current("aws_instance.old"), "moves chain")
+	assert.Equal(t, "module.app.aws_instance.db", moves.Current("module.legacy.aws_instance.db"))
+	assert.Equal(t, "aws_instance.webserver", moves.Current("aws_instance.webserver"))
+	assert.NotEmpty(t, moves.Current("aws_instance.loop"), "cycles end")
+
+	assert.Equal(t, []string{"aws_instance.old[0]", "aws_instance.web[0]"}, moves.Former("module.web.aws_instance.this[0]"))
+	assert.Equal(t, []string{"module.legacy.aws_instance.db"}, moves.Former("module.app.aws_instance.db"))
+	assert.Empty(t, moves.Former("aws_instance.db"))
+	assert.Equal(t, []string{"aws_instance.loop2"}, moves.Former("aws_instance.loop"))
+
+	var none AddressMoves
+	assert.Equal(t, "aws_instance.web", none.Current("aws_instance.web"))
+	assert.Empty(t, none.Former("aws_instance.web"))
+}
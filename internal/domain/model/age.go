@@ -0,0 +1,99 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SetDriftAges sets when each drifted attribute first appeared in the uninterrupted run of
+// results leading up to this one, and how long it has drifted since. history holds previous
+// results of the instance in any order; only those of the same account and workspace taken
+// before this result count, and at most lookback of them are searched. A drift that already
+// carried its first detection in a previous result continues from there, so that its age
+// keeps growing past the lookback. Drifts that weren't in the latest previous result are new.
+func (r *DriftResult) SetDriftAges(history []*DriftResult, lookback int) {
+	if len(r.DriftedAttributes) == 0 {
+		return
+	}
+
+	previous := make([]*DriftResult, 0, len(history))
+	for _, result := range history {
+		if result == nil || result.ID == r.ID || !result.Timestamp.Before(r.Timestamp) {
+			continue
+		}
+		if result.AccountID != r.AccountID || result.Workspace != r.Workspace {
+			continue
+		}
+		previous = append(previous, result)
+	}
+
+	// Newest first
+	sort.SliceStable(previous, func(i, j int) bool {
+		return previous[i].Timestamp.After(previous[j].Timestamp)
+	})
+	if lookback >= 0 && len(previous) > lookback {
+		previous = previous[:lookback]
+	}
+
+	for path, drift := range r.DriftedAttributes {
+		drift.FirstDetected = firstDetected(path, r.Timestamp, previous)
+		drift.DriftAge = r.Timestamp.Sub(drift.FirstDetected)
+		r.DriftedAttributes[path] = drift
+	}
+}
+
+// firstDetected walks back through previous results, newest first, while they have drift at
+// path, and returns the earliest detection found
+func firstDetected(path string, detected time.Time, previous []*DriftResult) time.Time {
+	for _, result := range previous {
+		drift, ok := result.DriftedAttributes[path]
+		if !ok {
+			break
+		}
+		if !drift.FirstDetected.IsZero() && drift.FirstDetected.Before(result.Timestamp) {
+			return drift.FirstDetected
+		}
+		detected = result.Timestamp
+	}
+	return detected
+}
+
+// OldestDrift returns the drifted attribute that has drifted the longest, or false when no
+// drift carries an age
+func (r *DriftResult) OldestDrift() (AttributeDrift, bool) {
+	var oldest AttributeDrift
+	found := false
+	for _, drift := range r.DriftedAttributes {
+		if drift.FirstDetected.IsZero() {
+			continue
+		}
+		if !found || drift.FirstDetected.Before(oldest.FirstDetected) {
+			oldest, found = drift, true
+		}
+	}
+	return oldest, found
+}
+
+// AgeLabel describes how long the attribute has drifted, e.g. "45d", "6h" or "new", or is
+// empty when the age isn't tracked
+func (d AttributeDrift) AgeLabel() string {
+	if d.FirstDetected.IsZero() {
+		return ""
+	}
+	return FormatDriftAge(d.DriftAge)
+}
+
+// FormatDriftAge renders a drift age in whole days, hours or minutes, and ages under a minute
+// as "new"
+func FormatDriftAge(age time.Duration) string {
+	switch {
+	case age >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	case age >= time.Hour:
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	case age >= time.Minute:
+		return fmt.Sprintf("%dm", int(age/time.Minute))
+	}
+	return "new"
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriftResult_SetDriftAges(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// resultAt builds a result of i-1 on the given day with drift at paths
+	resultAt := func(days int, paths ...string) *DriftResult {
+		result := NewDriftResultAt("i-1", OriginTerraform, start.Add(time.Duration(days)*day))
+		for _, path := range paths {
+			result.AddDriftedAttribute(path, "a", "b")
+		}
+		return result
+	}
+
+	history := []*DriftResult{
+		resultAt(3, "instance_type", "ami"),
+		resultAt(0, "instance_type"),
+		resultAt(1, "instance_type"),
+		resultAt(2, "instance_type"),
+	}
+	// A clean run interrupts the ami drift
+	history = append(history, resultAt(4, "instance_type"))
+
+	current := resultAt(5, "instance_type", "ami", "tags")
+	current.SetDriftAges(history, 30)
+
+	drift := current.DriftedAttributes["instance_type"]
+	assert.Equal(t, start, drift.FirstDetected)
+	assert.Equal(t, 5*day, drift.DriftAge)
+	assert.Equal(t, "5d", drift.AgeLabel())
+
+	// ami drifted before, but not in the previous run
+	assert.Equal(t, current.Timestamp, current.DriftedAttributes["ami"].FirstDetected)
+	assert.Equal(t, "new", current.DriftedAttributes["ami"].AgeLabel())
+	assert.Zero(t, current.DriftedAttributes["tags"].DriftAge)
+
+	oldest, ok := current.OldestDrift()
+	require.True(t, ok)
+	assert.Equal(t, "instance_type", oldest.Path)
+
+	t.Run("the lookback bounds the results searched", func(t *testing.T) {
+		current := resultAt(5, "instance_type")
+		current.SetDriftAges(history, 2)
+		assert.Equal(t, start.Add(3*day), current.DriftedAttributes["instance_type"].FirstDetected)
+	})
+
+	t.Run("ages carried by previous results continue past the lookback", func(t *testing.T) {
+		previous := resultAt(4, "instance_type")
+		previous.SetDriftAges(history, 30)
+
+		current := resultAt(5, "instance_type")
+		current.SetDriftAges([]*DriftResult{previous}, 1)
+		assert.Equal(t, start, current.DriftedAttributes["instance_type"].FirstDetected)
+	})
+
+	t.Run("other workspaces and later results don't count", func(t *testing.T) {
+		other := resultAt(4, "instance_type")
+		other.Workspace = "staging"
+
+		current := resultAt(5, "instance_type")
+		current.SetDriftAges([]*DriftResult{other, resultAt(6, "instance_type")}, 30)
+		assert.Equal(t, current.Timestamp, current.DriftedAttributes["instance_type"].FirstDetected)
+	})
+}
+
+func TestAttributeDrift_AgeLabel(t *testing.T) {
+	assert.Empty(t, AttributeDrift{}.AgeLabel())
+
+	detected := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, "45d", AttributeDrift{FirstDetected: detected, DriftAge: 45*24*time.Hour + 3*time.Hour}.AgeLabel())
+	assert.Equal(t, "6h", AttributeDrift{FirstDetected: detected, DriftAge: 6*time.Hour + 59*time.Minute}.AgeLabel())
+	assert.Equal(t, "30m", AttributeDrift{FirstDetected: detected, DriftAge: 30 * time.Minute}.AgeLabel())
+	assert.Equal(t, "new", AttributeDrift{FirstDetected: detected, DriftAge: 0}.AgeLabel())
+}
@@ -1,12 +1,14 @@
 package model
 
 import (
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/victor-devv/ec2-drift-detector/pkg/comparator"
 )
@@ -21,12 +23,95 @@ const (
 	OriginTerraform ResourceOrigin = "terraform"
 )
 
+// Name returns the display name of the provider the origin stands for
+func (o ResourceOrigin) Name() string {
+	if o == OriginAWS {
+		return "AWS"
+	}
+	return "Terraform"
+}
+
+// Other returns the origin on the other side of a comparison
+func (o ResourceOrigin) Other() ResourceOrigin {
+	if o == OriginAWS {
+		return OriginTerraform
+	}
+	return OriginAWS
+}
+
+// UnknownValue marks an attribute whose value cannot be determined without evaluating
+// the full Terraform configuration (e.g. dynamic blocks or references to other resources)
+type UnknownValue struct {
+	Reason string `json:"unknown_reason"`
+}
+
+// IsUnknown reports whether a value is an UnknownValue
+func IsUnknown(value interface{}) bool {
+	_, ok := value.(UnknownValue)
+	return ok
+}
+
 // Instance represents an EC2 instance configuration with attributes
 type Instance struct {
 	ID           string                 `json:"id"`
 	InstanceType string                 `json:"instance_type"`
 	Attributes   map[string]interface{} `json:"attributes"`
 	Origin       ResourceOrigin         `json:"origin"`
+
+	// AccountID is the AWS account the instance was fetched from, if known
+	AccountID string `json:"account_id,omitempty"`
+
+	// Workspace is the Terraform workspace the instance is compared within, set when comparing
+	// multiple workspaces
+	Workspace string `json:"workspace,omitempty"`
+
+	// ResourceAddress is the Terraform address of the instance, e.g. module.app.aws_instance.web[0],
+	// set on instances read from Terraform
+	ResourceAddress string `json:"resource_address,omitempty"`
+
+	// DuplicateAddresses are the addresses of other Terraform resources in the same state that
+	// track this instance ID, usually left behind by a botched import. Only the resource at
+	// ResourceAddress is compared.
+	DuplicateAddresses []string `json:"duplicate_addresses,omitempty"`
+
+	// StaticAttributes marks attributes whose values are explicitly assigned in the
+	// configuration rather than allocated by AWS (e.g. a fixed private_ip or an EIP)
+	StaticAttributes map[string]bool `json:"static_attributes,omitempty"`
+
+	// AttributeSources maps attributes to the attribute their value was read from when the
+	// two differ, e.g. tags read from Terraform's tags_all
+	AttributeSources map[string]string `json:"attribute_sources,omitempty"`
+}
+
+// AddressAttributes are the IP address attributes AWS usually assigns dynamically
+var AddressAttributes = []string{"private_ip", "public_ip"}
+
+// MarkStatic marks an attribute as explicitly assigned
+func (i *Instance) MarkStatic(path string) {
+	if i.StaticAttributes == nil {
+		i.StaticAttributes = make(map[string]bool)
+	}
+	i.StaticAttributes[path] = true
+}
+
+// IsStatic reports whether an attribute is explicitly assigned
+func (i *Instance) IsStatic(path string) bool {
+	return i.StaticAttributes[path]
+}
+
+// SetAttributeSource records that an attribute's value was read from another attribute
+func (i *Instance) SetAttributeSource(path, from string) {
+	if i.AttributeSources == nil {
+		i.AttributeSources = make(map[string]string)
+	}
+	i.AttributeSources[path] = from
+}
+
+// AttributeSource returns the attribute a path's value was read from, looking at the top-level
+// attribute for nested paths, or "" when it was read from the path itself
+func (i *Instance) AttributeSource(path string) string {
+	top, _, _ := strings.Cut(path, ".")
+	return i.AttributeSources[top]
 }
 
 // NewInstance creates a new instance with the given ID and attributes
@@ -112,6 +197,10 @@ func GetNestedValue(data map[string]interface{}, path string) (interface{}, bool
 			}
 			current = curr[index]
 
+		case UnknownValue:
+			// Nothing below an unknown value can be resolved, so the whole subtree is unknown
+			return curr, true
+
 		default:
 			return nil, false
 		}
@@ -123,66 +212,306 @@ func GetNestedValue(data map[string]interface{}, path string) (interface{}, bool
 // CompareAttributes compares attributes between two instances using specified paths
 // Returns a map of drifted attributes with both values
 func CompareAttributes(source, target *Instance, attributePaths []string) map[string]AttributeDrift {
+	return CompareAttributesWithOptions(source, target, attributePaths, CompareOptions{})
+}
+
+// CompareOptions controls how attribute values are normalized before comparison
+type CompareOptions struct {
+	// EmptyEqualsAbsent treats empty strings, lists and maps as equal to nil or missing values
+	EmptyEqualsAbsent bool
+
+	// StrictPresencePaths are attribute paths (and their children) where an empty value
+	// still differs from a missing one
+	StrictPresencePaths []string
+
+	// TrimTagValues ignores leading and trailing whitespace in tag values
+	TrimTagValues bool
+
+	// IgnoreTagCase compares tag values case-insensitively
+	IgnoreTagCase bool
+
+	// StoreValues controls how source and target values are kept on drifted attributes:
+	// full (default), truncated or hash
+	StoreValues string
+
+	// StoreValuesMaxBytes caps serialized values in truncated mode (0 uses the default)
+	StoreValuesMaxBytes int
+
+	// UserDataHash compares user data by a hash of the normalized script and reports only the
+	// digests and lengths on a mismatch
+	UserDataHash bool
+
+	// UserDataDiff adds a unified diff of the normalized scripts to user data drift
+	UserDataDiff bool
+
+	// Comparators replace the default comparison of the attributes at exact paths. They are
+	// only called when both instances have a known value for the path.
+	Comparators map[string]comparator.EqualFunc
+
+	// Trace collects how each attribute was compared, for explaining verdicts (nil disables)
+	Trace *CompareTrace `json:"-"`
+}
+
+// newDrift builds a drifted attribute, storing its sanitized values according to the options
+func (o CompareOptions) newDrift(path string, source, target interface{}) AttributeDrift {
+	drift := NewAttributeDrift(path, source, target)
+	drift.SourceValue = storeValue(drift.SourceValue, o.StoreValues, o.StoreValuesMaxBytes)
+	drift.TargetValue = storeValue(drift.TargetValue, o.StoreValues, o.StoreValuesMaxBytes)
+	drift.labelValues()
+	return drift
+}
+
+// emptyEqualsAbsent reports whether empty and missing values are equal at the given path
+func (o CompareOptions) emptyEqualsAbsent(path string) bool {
+	if !o.EmptyEqualsAbsent {
+		return false
+	}
+	for _, strict := range o.StrictPresencePaths {
+		if path == strict || strings.HasPrefix(path, strict+".") {
+			return false
+		}
+	}
+	return true
+}
+
+// isTagPath reports whether the path refers to the tags map or a single tag
+func isTagPath(path string) bool {
+	return path == "tags" || strings.HasPrefix(path, "tags.")
+}
+
+// CompareAttributesWithOptions compares attributes between two instances, normalizing values
+// according to the given options
+func CompareAttributesWithOptions(source, target *Instance, attributePaths []string, opts CompareOptions) map[string]AttributeDrift {
 	result := make(map[string]AttributeDrift)
 	var wg sync.WaitGroup
 	resultMutex := sync.Mutex{}
 
+	if opts.Trace != nil {
+		opts.Trace.begin(source, target, opts)
+	}
+
 	for _, path := range attributePaths {
 		wg.Add(1)
 		go func(attrPath string) {
 			defer wg.Done()
 
-			sourceVal, sourceExists := source.GetAttribute(attrPath)
-			targetVal, targetExists := target.GetAttribute(attrPath)
+			drift, drifted := opts.compareAttribute(source, target, attrPath)
+			if drifted {
+				resultMutex.Lock()
+				result[attrPath] = drift
+				resultMutex.Unlock()
+			}
+		}(path)
+	}
+
+	wg.Wait()
+
+	// Record where the Terraform side's value came from when it wasn't the path itself
+	for path, drift := range result {
+		drift.TerraformAttribute = terraformAttributeSource(source, target, path)
+		result[path] = drift
+	}
+	return result
+}
+
+// compareAttribute compares the attribute at a path, recording how it was decided when the
+// options carry a trace
+func (o CompareOptions) compareAttribute(source, target *Instance, path string) (AttributeDrift, bool) {
+	sourceVal, sourceExists := source.GetAttribute(path)
+	targetVal, targetExists := target.GetAttribute(path)
+
+	var trace *AttributeTrace
+	if o.Trace != nil {
+		trace = newAttributeTrace(source, target, path, sourceVal, sourceExists, targetVal, targetExists)
+		defer func() { o.Trace.record(*trace) }()
+	}
+
+	// Check for existence in both sources
+	if !sourceExists && !targetExists {
+		trace.decide(ComparerPresence, false)
+		return AttributeDrift{}, false
+	}
+
+	// Values that cannot be determined statically are skipped rather than reported as drift
+	for _, val := range []interface{}{sourceVal, targetVal} {
+		if unknown, ok := val.(UnknownValue); ok {
+			trace.skip(unknown.Reason)
+			return AttributeDrift{}, false
+		}
+	}
 
-			// Check for existence in both sources
-			if !sourceExists && !targetExists {
-				return
+	if equal, ok := o.Comparators[path]; ok && equal != nil && sourceExists && targetExists {
+		drifted := !equal(sourceVal, targetVal)
+		trace.decide(ComparerCustom, drifted)
+		if drifted {
+			return o.newDrift(path, sourceVal, targetVal), true
+		}
+		return AttributeDrift{}, false
+	}
+
+	if o.UserDataHash && path == AttributeUserData {
+		trace.normalize("decoded from base64, line endings and trailing whitespace normalized, compared by hash")
+		trace.normalized(parseUserData(sourceVal).digest(), parseUserData(targetVal).digest())
+		drift, drifted := o.compareUserData(path, sourceVal, targetVal)
+		trace.decide(ComparerUserData, drifted)
+		return drift, drifted
+	}
+
+	// Terraform records empty tags/lists where AWS omits the key entirely
+	emptyEqualsAbsent := o.emptyEqualsAbsent(path)
+	if emptyEqualsAbsent && comparator.IsEmpty(sourceVal) && comparator.IsEmpty(targetVal) {
+		trace.normalize("empty values equal absent ones")
+		trace.decide(ComparerPresence, false)
+		return AttributeDrift{}, false
+	}
+
+	if !sourceExists || !targetExists {
+		trace.decide(ComparerPresence, true)
+		return o.newDrift(path, sourceVal, targetVal), true
+	}
+
+	// If both values exist, compare them
+	if reflect.DeepEqual(sourceVal, targetVal) {
+		trace.decide(ComparerDeepEqual, false)
+		return AttributeDrift{}, false
+	}
+
+	if unorderedAttributes[path] {
+		if equal, ok := sameSet(sourceVal, targetVal); ok {
+			trace.normalize("compared as a set, ignoring order")
+			trace.decide(ComparerSet, !equal)
+			if equal {
+				return AttributeDrift{}, false
 			}
+			return o.newDrift(path, sourceVal, targetVal), true
+		}
+	}
 
-			if !sourceExists || !targetExists {
-				resultMutex.Lock()
-				result[attrPath] = AttributeDrift{
-					Path:        attrPath,
-					SourceValue: sourceVal,
-					TargetValue: targetVal,
-					Changed:     true,
-				}
-				resultMutex.Unlock()
-				return
+	if isTagPath(path) {
+		comp := comparator.NewComparator()
+		comp.EmptyEqualsAbsent = emptyEqualsAbsent
+		comp.TrimWhitespace = o.TrimTagValues
+		comp.IgnoreCase = o.IgnoreTagCase
+		if trace != nil {
+			if emptyEqualsAbsent {
+				trace.normalize("empty tags equal absent ones")
+			}
+			if o.TrimTagValues {
+				trace.normalize("leading and trailing whitespace trimmed from tag values")
+			}
+			if o.IgnoreTagCase {
+				trace.normalize("tag values compared case-insensitively")
+			}
+			if o.TrimTagValues || o.IgnoreTagCase {
+				trace.normalized(normalizeTags(sourceVal, o.TrimTagValues, o.IgnoreTagCase), normalizeTags(targetVal, o.TrimTagValues, o.IgnoreTagCase))
 			}
+		}
+		drifted := len(comp.CompareDeep(sourceVal, targetVal)) > 0
+		trace.decide(ComparerTags, drifted)
+		if drifted {
+			return o.newDrift(path, sourceVal, targetVal), true
+		}
+		return AttributeDrift{}, false
+	}
+
+	trace.decide(ComparerDeepEqual, true)
+	return o.newDrift(path, sourceVal, targetVal), true
+}
+
+// terraformAttributeSource returns the attribute the Terraform instance's value for path was
+// read from, if it differs from the path
+func terraformAttributeSource(source, target *Instance, path string) string {
+	for _, instance := range []*Instance{source, target} {
+		if instance.Origin == OriginTerraform {
+			return instance.AttributeSource(path)
+		}
+	}
+	return ""
+}
 
-			// If both values exist, compare them
-			if !reflect.DeepEqual(sourceVal, targetVal) {
-				if attrPath == "tags" {
-					comp := comparator.NewComparator()
-					tagDrifts := comp.CompareDeep(sourceVal, targetVal)
-					if len(tagDrifts) > 0 {
-						resultMutex.Lock()
-						result[attrPath] = AttributeDrift{
-							Path:        attrPath,
-							SourceValue: sourceVal,
-							TargetValue: targetVal,
-							Changed:     true,
-						}
-						resultMutex.Unlock()
-					}
-				} else {
-					resultMutex.Lock()
-					result[attrPath] = AttributeDrift{
-						Path:        attrPath,
-						SourceValue: sourceVal,
-						TargetValue: targetVal,
-						Changed:     true,
-					}
-					resultMutex.Unlock()
-				}
+// UnknownAttributes returns the attribute paths that cannot be compared because either
+// instance holds an unknown value for them, mapped to the reason they are unknown
+func UnknownAttributes(source, target *Instance, attributePaths []string) map[string]string {
+	result := make(map[string]string)
 
+	for _, path := range attributePaths {
+		for _, instance := range []*Instance{source, target} {
+			val, _ := instance.GetAttribute(path)
+			if unknown, ok := val.(UnknownValue); ok {
+				result[path] = unknown.Reason
+				break
 			}
-		}(path)
+		}
+	}
+
+	return result
+}
+
+// NameTag returns the value of the instance's Name tag, or an empty string if it has none
+func (i *Instance) NameTag() string {
+	if i == nil {
+		return ""
+	}
+	val, ok := i.GetAttribute("tags.Name")
+	if !ok || val == nil {
+		return ""
+	}
+	if name, ok := val.(string); ok {
+		return name
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// Tag returns the value of the named tag
+func (i *Instance) Tag(key string) (string, bool) {
+	if i == nil {
+		return "", false
+	}
+
+	switch tags := i.Attributes["tags"].(type) {
+	case map[string]string:
+		value, ok := tags[key]
+		return value, ok
+	case map[string]interface{}:
+		value, ok := tags[key]
+		if !ok || value == nil {
+			return "", false
+		}
+		return fmt.Sprintf("%v", value), true
+	}
+	return "", false
+}
+
+// DeclaredAttributes returns the attribute paths that hold a non-nil value in the instance
+func DeclaredAttributes(instance *Instance, attributePaths []string) []string {
+	declared := make([]string, 0, len(attributePaths))
+	for _, path := range attributePaths {
+		if val, ok := instance.GetAttribute(path); ok && val != nil {
+			declared = append(declared, path)
+		}
+	}
+	return declared
+}
+
+// DynamicAddressAttributes returns the requested address attributes that neither instance
+// assigns statically, mapped to the reason they are not compared
+func DynamicAddressAttributes(source, target *Instance, attributePaths []string) map[string]string {
+	result := make(map[string]string)
+
+	for _, path := range attributePaths {
+		for _, address := range AddressAttributes {
+			if path != address || source.IsStatic(path) || target.IsStatic(path) {
+				continue
+			}
+
+			if path == "public_ip" {
+				result[path] = "public_ip is not bound to an Elastic IP"
+			} else {
+				result[path] = "private_ip is not declared in the Terraform configuration"
+			}
+		}
 	}
 
-	wg.Wait()
 	return result
 }
 
@@ -192,6 +521,85 @@ type AttributeDrift struct {
 	SourceValue interface{} `json:"source_value"`
 	TargetValue interface{} `json:"target_value"`
 	Changed     bool        `json:"changed"`
+
+	// DesiredValue is the value the source of truth has and CurrentValue the value on the other
+	// side, e.g. what AWS should have and has when Terraform is the source of truth. They repeat
+	// SourceValue and TargetValue under names that don't depend on the configured source of
+	// truth; reporters prefer them.
+	DesiredValue interface{} `json:"desired_value"`
+	CurrentValue interface{} `json:"current_value"`
+
+	// Diff is a unified diff of the values, for attributes compared by hash
+	Diff string `json:"diff,omitempty"`
+
+	// TerraformAttribute is the Terraform attribute the value was compared from when it differs
+	// from the path, e.g. tags_all for tags
+	TerraformAttribute string `json:"terraform_attribute,omitempty"`
+
+	// SourceValueType and TargetValueType are the original Go types of values that had to be
+	// converted to JSON-safe types or truncated
+	SourceValueType string `json:"source_value_type,omitempty"`
+	TargetValueType string `json:"target_value_type,omitempty"`
+
+	// Severity overrides the severity the attribute is reported with, e.g. high for a subnet
+	// move into another VPC
+	Severity string `json:"severity,omitempty"`
+
+	// Network describes the subnets on either side of a subnet_id drift, when network context
+	// enrichment is enabled
+	Network *NetworkContext `json:"network,omitempty"`
+
+	// Protection compares disable_api_termination with prevent_destroy on a
+	// termination_protection drift, noting what the mismatch leaves unprotected
+	Protection *TerminationProtection `json:"protection,omitempty"`
+
+	// FirstDetected is the earliest result of the uninterrupted run of results with this drift,
+	// and DriftAge how long before this result that was, see DriftResult.SetDriftAges. Both are
+	// unset when drift age isn't tracked.
+	FirstDetected time.Time     `json:"first_detected,omitzero"`
+	DriftAge      time.Duration `json:"drift_age,omitempty"`
+}
+
+// NewAttributeDrift builds a drifted attribute with its values converted to JSON-safe types,
+// so that parser values such as map[interface{}]interface{} marshal and render in reports
+func NewAttributeDrift(path string, source, target interface{}) AttributeDrift {
+	drift := AttributeDrift{Path: path, Changed: true}
+	drift.SourceValue, drift.SourceValueType = comparator.Sanitize(source, 0)
+	drift.TargetValue, drift.TargetValueType = comparator.Sanitize(target, 0)
+	drift.labelValues()
+	return drift
+}
+
+// labelValues sets the desired and current values from the source and target values
+func (d *AttributeDrift) labelValues() {
+	d.DesiredValue, d.CurrentValue = d.SourceValue, d.TargetValue
+}
+
+// DesiredAndCurrent returns the desired and current values, falling back to the source and
+// target values for drifts built without them, e.g. by embedders
+func (d AttributeDrift) DesiredAndCurrent() (desired, current interface{}) {
+	desired, current = d.DesiredValue, d.CurrentValue
+	if desired == nil {
+		desired = d.SourceValue
+	}
+	if current == nil {
+		current = d.TargetValue
+	}
+	return desired, current
+}
+
+// DiffEntry returns the drift as a comparator diff entry from the desired to the current value,
+// for rendering with a comparator.DiffFormatter
+func (d AttributeDrift) DiffEntry() comparator.DiffEntry {
+	desired, current := d.DesiredAndCurrent()
+	return comparator.DiffEntry{
+		Path:            d.Path,
+		SourceValue:     desired,
+		TargetValue:     current,
+		Changed:         d.Changed,
+		SourceValueType: d.SourceValueType,
+		TargetValueType: d.TargetValueType,
+	}
 }
 
 // NestedCompare implements deep comparison of nested attributes using goroutines
@@ -210,12 +618,7 @@ func NestedCompare(source, target map[string]interface{}, basePath string, maxDe
 
 		targetVal, exists := target[key]
 		if !exists {
-			result.Store(path, AttributeDrift{
-				Path:        path,
-				SourceValue: sourceVal,
-				TargetValue: nil,
-				Changed:     true,
-			})
+			result.Store(path, NewAttributeDrift(path, sourceVal, nil))
 			continue
 		}
 
@@ -227,12 +630,7 @@ func NestedCompare(source, target map[string]interface{}, basePath string, maxDe
 			wg.Add(1)
 			go NestedCompare(sourceMap, targetMap, path, maxDepth-1, result, wg)
 		} else if !reflect.DeepEqual(sourceVal, targetVal) {
-			result.Store(path, AttributeDrift{
-				Path:        path,
-				SourceValue: sourceVal,
-				TargetValue: targetVal,
-				Changed:     true,
-			})
+			result.Store(path, NewAttributeDrift(path, sourceVal, targetVal))
 		}
 	}
 
@@ -244,12 +642,7 @@ func NestedCompare(source, target map[string]interface{}, basePath string, maxDe
 		}
 
 		if _, exists := source[key]; !exists {
-			result.Store(path, AttributeDrift{
-				Path:        path,
-				SourceValue: nil,
-				TargetValue: targetVal,
-				Changed:     true,
-			})
+			result.Store(path, NewAttributeDrift(path, nil, targetVal))
 		}
 	}
 }
@@ -303,13 +696,14 @@ type EBSVolume struct {
 	Tags       map[string]string
 }
 
-// FromAWSInstance converts AWS SDK EC2 instance to our domain model
+// FromAWSInstance converts AWS SDK EC2 instance to our domain model. Fields the SDK leaves
+// nil, such as the public IP of a private instance, are converted to empty values.
 func FromAWSInstance(instance types.Instance) EC2Instance {
 	sgs := make([]SecurityGroup, 0, len(instance.SecurityGroups))
 	for _, sg := range instance.SecurityGroups {
 		sgs = append(sgs, SecurityGroup{
-			GroupID:   *sg.GroupId,
-			GroupName: *sg.GroupName,
+			GroupID:   aws.ToString(sg.GroupId),
+			GroupName: aws.ToString(sg.GroupName),
 		})
 	}
 
@@ -320,25 +714,25 @@ func FromAWSInstance(instance types.Instance) EC2Instance {
 		}
 	}
 
-	var launchTime *time.Time
-	if instance.LaunchTime != nil {
-		launchTime = instance.LaunchTime
+	var state string
+	if instance.State != nil {
+		state = string(instance.State.Name)
 	}
 
 	return EC2Instance{
-		ID:               *instance.InstanceId,
+		ID:               aws.ToString(instance.InstanceId),
 		InstanceType:     string(instance.InstanceType),
-		AMI:              *instance.ImageId,
-		VPCID:            *instance.VpcId,
-		SubnetID:         *instance.SubnetId,
+		AMI:              aws.ToString(instance.ImageId),
+		VPCID:            aws.ToString(instance.VpcId),
+		SubnetID:         aws.ToString(instance.SubnetId),
 		SecurityGroups:   sgs,
 		Tags:             tags,
-		State:            string(instance.State.Name),
-		LaunchTime:       launchTime,
-		PrivateDNSName:   *instance.PrivateDnsName,
-		PrivateIPAddress: *instance.PrivateIpAddress,
-		PublicDNSName:    *instance.PublicDnsName,
-		PublicIPAddress:  *instance.PublicIpAddress,
+		State:            state,
+		LaunchTime:       instance.LaunchTime,
+		PrivateDNSName:   aws.ToString(instance.PrivateDnsName),
+		PrivateIPAddress: aws.ToString(instance.PrivateIpAddress),
+		PublicDNSName:    aws.ToString(instance.PublicDnsName),
+		PublicIPAddress:  aws.ToString(instance.PublicIpAddress),
 		Architecture:     string(instance.Architecture),
 		RootDeviceType:   string(instance.RootDeviceType),
 	}
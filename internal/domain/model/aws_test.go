@@ -1,10 +1,17 @@
 package model
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/pkg/comparator"
 )
 
 func TestNewInstance(t *testing.T) {
@@ -246,3 +253,245 @@ func TestNestedCompare(t *testing.T) {
 	require.NotContains(t, drifts, "level1.a")
 	require.Contains(t, drifts, "level3")
 }
+
+func TestCompareAttributes_SkipsUnknownValues(t *testing.T) {
+	source := NewInstance("i-12345", map[string]interface{}{
+		"instance_type":          "t2.micro",
+		"vpc_security_group_ids": UnknownValue{Reason: "unresolved reference to aws_security_group.app"},
+		"ebs_block_device":       UnknownValue{Reason: "generated by dynamic \"ebs_block_device\" block"},
+	}, OriginTerraform)
+	target := NewInstance("i-12345", map[string]interface{}{
+		"instance_type":          "t2.small",
+		"vpc_security_group_ids": []string{"sg-12345"},
+	}, OriginAWS)
+
+	paths := []string{"instance_type", "vpc_security_group_ids", "ebs_block_device"}
+
+	drifts := CompareAttributes(source, target, paths)
+	require.Len(t, drifts, 1)
+	require.Contains(t, drifts, "instance_type")
+
+	skipped := UnknownAttributes(source, target, paths)
+	require.Len(t, skipped, 2)
+	require.Contains(t, skipped["vpc_security_group_ids"], "aws_security_group.app")
+	require.Contains(t, skipped["ebs_block_device"], "dynamic")
+}
+
+func TestCompareAttributes_EmptyEqualsAbsent(t *testing.T) {
+	source := NewInstance("i-12345", map[string]interface{}{
+		"tags":                   map[string]interface{}{},
+		"vpc_security_group_ids": []interface{}{},
+		"key_name":               "",
+	}, OriginTerraform)
+	target := NewInstance("i-12345", map[string]interface{}{}, OriginAWS)
+	paths := []string{"tags", "vpc_security_group_ids", "key_name"}
+
+	// Without normalization empty and missing values drift
+	require.Len(t, CompareAttributes(source, target, paths), 3)
+
+	opts := CompareOptions{EmptyEqualsAbsent: true}
+	require.Empty(t, CompareAttributesWithOptions(source, target, paths, opts))
+
+	// A one-element list still drifts from an empty one
+	target = NewInstance("i-12345", map[string]interface{}{
+		"vpc_security_group_ids": []string{"sg-123"},
+	}, OriginAWS)
+	drifts := CompareAttributesWithOptions(source, target, paths, opts)
+	require.Len(t, drifts, 1)
+	require.Contains(t, drifts, "vpc_security_group_ids")
+
+	// Strict presence paths keep empty and missing apart
+	opts.StrictPresencePaths = []string{"tags"}
+	drifts = CompareAttributesWithOptions(source, target, paths, opts)
+	require.Len(t, drifts, 2)
+	require.Contains(t, drifts, "tags")
+}
+
+func TestCompareAttributes_TagValueNormalization(t *testing.T) {
+	source := NewInstance("i-1", map[string]interface{}{
+		"tags": map[string]interface{}{"Environment": "prod", "Team": "Platform"},
+	}, OriginTerraform)
+	target := NewInstance("i-1", map[string]interface{}{
+		"tags": map[string]interface{}{"Environment": " Prod ", "Team": "platform"},
+	}, OriginAWS)
+	paths := []string{"tags", "tags.Environment"}
+
+	t.Run("disabled", func(t *testing.T) {
+		drifts := CompareAttributesWithOptions(source, target, paths, CompareOptions{})
+		require.Contains(t, drifts, "tags")
+		require.Contains(t, drifts, "tags.Environment")
+	})
+
+	t.Run("trim only", func(t *testing.T) {
+		drifts := CompareAttributesWithOptions(source, target, paths, CompareOptions{TrimTagValues: true})
+		require.Contains(t, drifts, "tags")
+		require.Contains(t, drifts, "tags.Environment")
+	})
+
+	t.Run("trim and ignore case", func(t *testing.T) {
+		drifts := CompareAttributesWithOptions(source, target, paths, CompareOptions{TrimTagValues: true, IgnoreTagCase: true})
+		require.Empty(t, drifts)
+	})
+
+	t.Run("keys are not normalized", func(t *testing.T) {
+		renamed := NewInstance("i-1", map[string]interface{}{
+			"tags": map[string]interface{}{"environment": "prod", "Team": "Platform"},
+		}, OriginAWS)
+		drifts := CompareAttributesWithOptions(source, renamed, []string{"tags"}, CompareOptions{TrimTagValues: true, IgnoreTagCase: true})
+		require.Contains(t, drifts, "tags")
+	})
+
+	t.Run("other attributes are not normalized", func(t *testing.T) {
+		a := NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, OriginTerraform)
+		b := NewInstance("i-1", map[string]interface{}{"instance_type": "T2.micro "}, OriginAWS)
+		drifts := CompareAttributesWithOptions(a, b, []string{"instance_type"}, CompareOptions{TrimTagValues: true, IgnoreTagCase: true})
+		require.Contains(t, drifts, "instance_type")
+	})
+}
+
+func TestCompareAttributes_StoreValues(t *testing.T) {
+	longSource := strings.Repeat("a", 100)
+	longTarget := strings.Repeat("b", 100)
+	source := NewInstance("i-1", map[string]interface{}{
+		"user_data":     longSource,
+		"instance_type": "t2.micro",
+		"tags":          map[string]interface{}{"Name": "web"},
+	}, OriginTerraform)
+	target := NewInstance("i-1", map[string]interface{}{
+		"user_data":     longTarget,
+		"instance_type": "t2.large",
+		"tags":          map[string]interface{}{"Name": "web-old"},
+	}, OriginAWS)
+	paths := []string{"user_data", "instance_type", "tags"}
+
+	// Full keeps the values unchanged
+	drifts := CompareAttributesWithOptions(source, target, paths, CompareOptions{})
+	require.Equal(t, longSource, drifts["user_data"].SourceValue)
+
+	// Truncated caps long values and keeps short ones
+	drifts = CompareAttributesWithOptions(source, target, paths, CompareOptions{StoreValues: StoreValuesTruncated, StoreValuesMaxBytes: 10})
+	truncated, ok := drifts["user_data"].SourceValue.(TruncatedValue)
+	require.True(t, ok)
+	require.Equal(t, strings.Repeat("a", 10), truncated.Value)
+	require.Equal(t, 100, truncated.Size)
+	require.Equal(t, "aaaaaaaaaa… [truncated, 100 bytes]", fmt.Sprintf("%v", truncated))
+	require.Equal(t, "t2.micro", drifts["instance_type"].SourceValue)
+
+	// Hash replaces every value with its digest and type
+	drifts = CompareAttributesWithOptions(source, target, paths, CompareOptions{StoreValues: StoreValuesHash})
+	hashed, ok := drifts["tags"].TargetValue.(HashedValue)
+	require.True(t, ok)
+	require.Equal(t, "map[string]interface {}", hashed.Type)
+	require.Len(t, hashed.SHA256, 64)
+	require.NotEqual(t, drifts["tags"].SourceValue, drifts["tags"].TargetValue)
+
+	// Equal values hash identically
+	again := CompareAttributesWithOptions(source, target, paths, CompareOptions{StoreValues: StoreValuesHash})
+	require.Equal(t, drifts["tags"].TargetValue, again["tags"].TargetValue)
+
+	// Stored forms serialize to JSON
+	data, err := json.Marshal(drifts["tags"])
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"sha256":"`)
+}
+
+func TestCompareAttributes_DesiredAndCurrentValues(t *testing.T) {
+	terraform := NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, OriginTerraform)
+	aws := NewInstance("i-1", map[string]interface{}{"instance_type": "t2.large"}, OriginAWS)
+
+	// The desired value is the source of truth's, whichever side that is
+	drift := CompareAttributes(terraform, aws, []string{"instance_type"})["instance_type"]
+	require.Equal(t, "t2.micro", drift.DesiredValue)
+	require.Equal(t, "t2.large", drift.CurrentValue)
+	drift = CompareAttributes(aws, terraform, []string{"instance_type"})["instance_type"]
+	require.Equal(t, "t2.large", drift.DesiredValue)
+	require.Equal(t, "t2.micro", drift.CurrentValue)
+
+	// Stored forms are labeled too
+	drifts := CompareAttributesWithOptions(terraform, aws, []string{"instance_type"}, CompareOptions{StoreValues: StoreValuesHash})
+	require.Equal(t, drifts["instance_type"].SourceValue, drifts["instance_type"].DesiredValue)
+	require.IsType(t, HashedValue{}, drifts["instance_type"].CurrentValue)
+
+	data, err := json.Marshal(drift)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"desired_value":"t2.large","current_value":"t2.micro"`)
+
+	// Drifts built without the labels fall back to the source and target values
+	desired, current := AttributeDrift{Path: "ami", SourceValue: "ami-1", TargetValue: "ami-2"}.DesiredAndCurrent()
+	require.Equal(t, "ami-1", desired)
+	require.Equal(t, "ami-2", current)
+
+	// Diff entries run from the desired to the current value
+	entry := drift.DiffEntry()
+	require.Equal(t, "instance_type", entry.Path)
+	require.Equal(t, "t2.large", entry.SourceValue)
+	require.Equal(t, "t2.micro", entry.TargetValue)
+	require.Equal(t, "instance_type: t2.large => t2.micro", comparator.PlainFormatter{}.FormatDiff(entry))
+}
+
+func TestStoreValue_TruncatesOnRuneBoundary(t *testing.T) {
+	val := storeValue("héllo wörld", StoreValuesTruncated, 2)
+	truncated, ok := val.(TruncatedValue)
+	require.True(t, ok)
+	require.Equal(t, "h", truncated.Value)
+}
+
+func TestCompareAttributes_SanitizesValues(t *testing.T) {
+	source := NewInstance("i-1", map[string]interface{}{
+		"metadata_options": map[interface{}]interface{}{"http_tokens": "required", 1: true},
+		"instance_type":    "t2.micro",
+	}, OriginTerraform)
+	target := NewInstance("i-1", map[string]interface{}{
+		"metadata_options": map[string]interface{}{"http_tokens": "optional"},
+		"instance_type":    "t2.large",
+	}, OriginAWS)
+
+	drifts := CompareAttributesWithOptions(source, target, []string{"metadata_options", "instance_type"}, CompareOptions{})
+
+	// Parser maps are converted to string-keyed maps, recording their original type
+	drift := drifts["metadata_options"]
+	require.Equal(t, map[string]interface{}{"http_tokens": "required", "1": true}, drift.SourceValue)
+	require.Equal(t, "map[interface {}]interface {}", drift.SourceValueType)
+	require.Empty(t, drift.TargetValueType)
+
+	// Values already JSON-safe are kept as-is
+	require.Equal(t, "t2.micro", drifts["instance_type"].SourceValue)
+	require.Empty(t, drifts["instance_type"].SourceValueType)
+
+	data, err := json.Marshal(drift)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"source_value_type":"map[interface {}]interface {}"`)
+}
+
+func TestFromAWSInstance_NilFields(t *testing.T) {
+	// Every pointer field nil, then each one set to its zero value in turn so that nested
+	// pointers are nil too
+	instances := []types.Instance{{}, {
+		SecurityGroups: []types.GroupIdentifier{{}},
+		Tags:           []types.Tag{{}},
+	}}
+	fields := reflect.TypeOf(types.Instance{})
+	for i := 0; i < fields.NumField(); i++ {
+		if fields.Field(i).Type.Kind() != reflect.Ptr {
+			continue
+		}
+		var instance types.Instance
+		field := reflect.ValueOf(&instance).Elem().Field(i)
+		field.Set(reflect.New(field.Type().Elem()))
+		instances = append(instances, instance)
+	}
+
+	for _, instance := range instances {
+		require.NotPanics(t, func() { FromAWSInstance(instance) })
+	}
+
+	converted := FromAWSInstance(types.Instance{
+		InstanceId:     aws.String("i-1"),
+		State:          &types.InstanceState{Name: types.InstanceStateNameRunning},
+		SecurityGroups: []types.GroupIdentifier{{GroupId: aws.String("sg-1")}},
+	})
+	require.Equal(t, "i-1", converted.ID)
+	require.Equal(t, "running", converted.State)
+	require.Empty(t, converted.PublicIPAddress)
+	require.Equal(t, []SecurityGroup{{GroupID: "sg-1"}}, converted.SecurityGroups)
+}
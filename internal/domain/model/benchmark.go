@@ -0,0 +1,112 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// benchmarkTolerance is how much slower than the fastest level a lower concurrency level may be
+// and still be recommended, since the extra API pressure of the faster one buys little
+const benchmarkTolerance = 1.1
+
+// Minimum suggested timeouts, so that a small or fast sample doesn't suggest budgets that the
+// next slow API call exceeds
+const (
+	minSuggestedAWSTimeout = 5 * time.Second
+	minSuggestedTimeout    = 60 * time.Second
+)
+
+// BenchmarkLevel is the outcome of checking the benchmark sample at one concurrency level
+type BenchmarkLevel struct {
+	ParallelChecks int           `json:"parallel_checks"`
+	Instances      int           `json:"instances"`
+	WallTime       time.Duration `json:"wall_time"`
+	Errors         int           `json:"errors"`
+	Throttles      int           `json:"throttles"`
+	P95Latency     time.Duration `json:"p95_latency"`
+}
+
+// BenchmarkReport is the outcome of a benchmark: the sample checked, each concurrency level's
+// measurements and the settings suggested from them
+type BenchmarkReport struct {
+	TotalInstances int              `json:"total_instances"`
+	SampleSize     int              `json:"sample_size"`
+	Levels         []BenchmarkLevel `json:"levels"`
+
+	// Suggested detector settings: parallel_checks, aws_timeout_seconds and timeout_seconds
+	ParallelChecks int           `json:"parallel_checks"`
+	AWSTimeout     time.Duration `json:"aws_timeout"`
+	Timeout        time.Duration `json:"timeout"`
+}
+
+// Recommend suggests the settings from the measured levels. Levels that were throttled or
+// failed more checks than the best level are ruled out; of the rest, the lowest concurrency
+// within 10% of the fastest wins. The AWS timeout allows three times its p95 latency and the
+// run timeout twice its wall time scaled up to every instance.
+func (r *BenchmarkReport) Recommend() {
+	if len(r.Levels) == 0 {
+		return
+	}
+
+	minThrottles, minErrors := math.MaxInt, math.MaxInt
+	for _, level := range r.Levels {
+		minThrottles = min(minThrottles, level.Throttles)
+	}
+	var candidates []BenchmarkLevel
+	for _, level := range r.Levels {
+		if level.Throttles == minThrottles {
+			minErrors = min(minErrors, level.Errors)
+			candidates = append(candidates, level)
+		}
+	}
+
+	fastest := time.Duration(math.MaxInt64)
+	for _, level := range candidates {
+		if level.Errors == minErrors {
+			fastest = min(fastest, level.WallTime)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ParallelChecks < candidates[j].ParallelChecks
+	})
+	var best BenchmarkLevel
+	for _, level := range candidates {
+		if level.Errors == minErrors && float64(level.WallTime) <= float64(fastest)*benchmarkTolerance {
+			best = level
+			break
+		}
+	}
+
+	r.ParallelChecks = best.ParallelChecks
+	r.AWSTimeout = max(roundUpToSecond(3*best.P95Latency), minSuggestedAWSTimeout)
+
+	run := best.WallTime
+	if best.Instances > 0 && r.TotalInstances > best.Instances {
+		run = run * time.Duration(r.TotalInstances) / time.Duration(best.Instances)
+	}
+	r.Timeout = max(roundUpToSecond(2*run), minSuggestedTimeout)
+}
+
+// Percentile returns the nearest-rank percentile (0-100) of the given durations, or 0 when
+// there are none
+func Percentile(durations []time.Duration, percentile float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1]
+}
+
+// roundUpToSecond rounds a duration up to a whole number of seconds, as timeouts are configured
+func roundUpToSecond(d time.Duration) time.Duration {
+	if rounded := d.Truncate(time.Second); rounded < d {
+		return rounded + time.Second
+	}
+	return d
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	var durations []time.Duration
+	for i := 20; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, 19*time.Millisecond, Percentile(durations, 95))
+	assert.Equal(t, 10*time.Millisecond, Percentile(durations, 50))
+	assert.Equal(t, time.Millisecond, Percentile(durations, 0))
+	assert.Equal(t, 20*time.Millisecond, Percentile(durations, 100))
+	assert.Zero(t, Percentile(nil, 95))
+
+	// The input is left unsorted
+	assert.Equal(t, 20*time.Millisecond, durations[0])
+}
+
+func TestBenchmarkReport_Recommend(t *testing.T) {
+	report := &BenchmarkReport{
+		TotalInstances: 500,
+		SampleSize:     50,
+		Levels: []BenchmarkLevel{
+			{ParallelChecks: 2, Instances: 50, WallTime: 20 * time.Second, P95Latency: 900 * time.Millisecond},
+			{ParallelChecks: 5, Instances: 50, WallTime: 8500 * time.Millisecond, P95Latency: 1800 * time.Millisecond},
+			{ParallelChecks: 10, Instances: 50, WallTime: 8 * time.Second, P95Latency: 2 * time.Second},
+			{ParallelChecks: 20, Instances: 50, WallTime: 6 * time.Second, Errors: 3, Throttles: 3, P95Latency: 4 * time.Second},
+		},
+	}
+	report.Recommend()
+
+	// 20 was throttled; 5 is within 10% of 10 and puts less pressure on the API
+	assert.Equal(t, 5, report.ParallelChecks)
+	assert.Equal(t, 6*time.Second, report.AWSTimeout)
+	assert.Equal(t, 170*time.Second, report.Timeout)
+}
+
+func TestBenchmarkReport_RecommendMinimums(t *testing.T) {
+	report := &BenchmarkReport{
+		TotalInstances: 2,
+		SampleSize:     2,
+		Levels: []BenchmarkLevel{
+			{ParallelChecks: 2, Instances: 2, WallTime: 100 * time.Millisecond, Throttles: 1, Errors: 1},
+			{ParallelChecks: 5, Instances: 2, WallTime: 50 * time.Millisecond, Throttles: 2, Errors: 2},
+		},
+	}
+	report.Recommend()
+
+	// Every level was throttled, so the least throttled one is suggested
+	assert.Equal(t, 2, report.ParallelChecks)
+	assert.Equal(t, minSuggestedAWSTimeout, report.AWSTimeout)
+	assert.Equal(t, minSuggestedTimeout, report.Timeout)
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Block device attributes, as Terraform names them
+const (
+	AttributeRootBlockDevice = "root_block_device"
+	AttributeEBSBlockDevice  = "ebs_block_device"
+)
+
+// blockDeviceFields are the block device settings both EC2 and Terraform report. EC2 only
+// describes the attachment of each volume, so sizes and types aren't compared.
+var blockDeviceFields = []string{"device_name", "volume_id", "delete_on_termination"}
+
+// NormalizeBlockDevices reduces block devices to the settings both EC2 and Terraform report,
+// ordered by device name since Terraform keeps ebs_block_device as an unordered set
+func NormalizeBlockDevices(devices []map[string]interface{}) []interface{} {
+	normalized := make([]map[string]interface{}, 0, len(devices))
+	for _, device := range devices {
+		fields := make(map[string]interface{}, len(blockDeviceFields))
+		for _, field := range blockDeviceFields {
+			if value, ok := device[field]; ok && value != nil {
+				fields[field] = value
+			}
+		}
+		normalized = append(normalized, fields)
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return fmt.Sprint(normalized[i]["device_name"]) < fmt.Sprint(normalized[j]["device_name"])
+	})
+
+	result := make([]interface{}, 0, len(normalized))
+	for _, device := range normalized {
+		result = append(result, device)
+	}
+	return result
+}
+
+// SplitBlockDevices separates an instance's block devices into its root device, the one
+// attached as rootDeviceName, and the additional EBS devices, both normalized to the settings
+// Terraform's root_block_device and ebs_block_device blocks are compared on
+func SplitBlockDevices(devices []map[string]interface{}, rootDeviceName string) (root, ebs []interface{}) {
+	var rootDevices, ebsDevices []map[string]interface{}
+	for _, device := range devices {
+		if rootDeviceName != "" && device["device_name"] == rootDeviceName {
+			rootDevices = append(rootDevices, device)
+		} else {
+			ebsDevices = append(ebsDevices, device)
+		}
+	}
+	return NormalizeBlockDevices(rootDevices), NormalizeBlockDevices(ebsDevices)
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeBlockDevices(t *testing.T) {
+	devices := []map[string]interface{}{
+		{"device_name": "/dev/sdg", "volume_id": "vol-2", "volume_size": float64(20), "delete_on_termination": true},
+		{"device_name": "/dev/sdf", "volume_id": "vol-1", "volume_type": "gp3", "kms_key_id": nil},
+	}
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"device_name": "/dev/sdf", "volume_id": "vol-1"},
+		map[string]interface{}{"device_name": "/dev/sdg", "volume_id": "vol-2", "delete_on_termination": true},
+	}, NormalizeBlockDevices(devices))
+	assert.Equal(t, []interface{}{}, NormalizeBlockDevices(nil))
+}
+
+func TestSplitBlockDevices(t *testing.T) {
+	devices := []map[string]interface{}{
+		{"device_name": "/dev/sdf", "volume_id": "vol-data", "delete_on_termination": false},
+		{"device_name": "/dev/xvda", "volume_id": "vol-root", "delete_on_termination": true},
+	}
+
+	root, ebs := SplitBlockDevices(devices, "/dev/xvda")
+	assert.Equal(t, []interface{}{map[string]interface{}{"device_name": "/dev/xvda", "volume_id": "vol-root", "delete_on_termination": true}}, root)
+	assert.Equal(t, []interface{}{map[string]interface{}{"device_name": "/dev/sdf", "volume_id": "vol-data", "delete_on_termination": false}}, ebs)
+
+	// Without a root device name every device is an additional one
+	root, ebs = SplitBlockDevices(devices, "")
+	assert.Empty(t, root)
+	assert.Len(t, ebs, 2)
+}
+
+func TestCompareBlockDevices_RootAndSecondary(t *testing.T) {
+	awsDevices := []map[string]interface{}{
+		{"device_name": "/dev/xvda", "volume_id": "vol-root", "delete_on_termination": true},
+		{"device_name": "/dev/sdf", "volume_id": "vol-data", "delete_on_termination": true},
+	}
+	root, ebs := SplitBlockDevices(awsDevices, "/dev/xvda")
+	aws := NewInstance("i-1", map[string]interface{}{AttributeRootBlockDevice: root, AttributeEBSBlockDevice: ebs}, OriginAWS)
+
+	terraform := func(rootDeleteOnTermination, dataDeleteOnTermination bool) *Instance {
+		return NewInstance("i-1", map[string]interface{}{
+			AttributeRootBlockDevice: NormalizeBlockDevices([]map[string]interface{}{
+				{"device_name": "/dev/xvda", "volume_id": "vol-root", "delete_on_termination": rootDeleteOnTermination, "volume_size": float64(8)},
+			}),
+			AttributeEBSBlockDevice: NormalizeBlockDevices([]map[string]interface{}{
+				{"device_name": "/dev/sdf", "volume_id": "vol-data", "delete_on_termination": dataDeleteOnTermination, "volume_type": "gp3"},
+			}),
+		}, OriginTerraform)
+	}
+	paths := []string{AttributeRootBlockDevice, AttributeEBSBlockDevice}
+
+	// Sizes and types EC2 doesn't report don't count as drift
+	assert.Empty(t, CompareAttributes(terraform(true, true), aws, paths))
+
+	drifts := CompareAttributes(terraform(false, true), aws, paths)
+	assert.Contains(t, drifts, AttributeRootBlockDevice)
+	assert.NotContains(t, drifts, AttributeEBSBlockDevice)
+
+	drifts = CompareAttributes(terraform(true, false), aws, paths)
+	assert.NotContains(t, drifts, AttributeRootBlockDevice)
+	assert.Contains(t, drifts, AttributeEBSBlockDevice)
+
+	// Nested paths resolve into the normalized devices
+	drifts = CompareAttributes(terraform(false, true), aws, []string{"root_block_device.0.delete_on_termination", "ebs_block_device.0.delete_on_termination"})
+	assert.Equal(t, []string{"root_block_device.0.delete_on_termination"}, driftPaths(drifts))
+}
+
+func driftPaths(drifts map[string]AttributeDrift) []string {
+	var paths []string
+	for path := range drifts {
+		paths = append(paths, path)
+	}
+	return paths
+}
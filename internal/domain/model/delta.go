@@ -0,0 +1,116 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// DriftDelta describes how the drift of an instance changed between two results
+type DriftDelta struct {
+	ResourceID string `json:"resource_id"`
+
+	// Added lists attributes that drift in the new result but did not in the old one
+	Added []AttributeDrift `json:"added,omitempty"`
+
+	// Removed lists attributes that drifted in the old result and no longer do
+	Removed []AttributeDrift `json:"removed,omitempty"`
+
+	// Changed lists attributes that drift in both results with different values
+	Changed []AttributeChange `json:"changed,omitempty"`
+}
+
+// AttributeChange is an attribute that drifted in both results with different values
+type AttributeChange struct {
+	Path string         `json:"path"`
+	Old  AttributeDrift `json:"old"`
+	New  AttributeDrift `json:"new"`
+}
+
+// HasMaterialChange reports whether any attribute started, stopped or changed drifting. Only
+// the drifted values count: a different diff or Terraform attribute annotation on the same
+// values is not a change.
+func (d DriftDelta) HasMaterialChange() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+// DiffDriftResults compares two results for the same instance. A nil old result (the first run)
+// reports every drift in the new result as added, and a nil new result reports every old drift
+// as removed.
+//
+// When an instance exists in only one provider its attributes are not compared, so a result
+// with existence drift says nothing about the other attributes. Moving into existence drift
+// only reports the exists attribute as added; the old attribute drifts are not reported as
+// removed. Moving out of it reports exists as removed and the new attribute drifts as added.
+func DiffDriftResults(old, new *DriftResult) DriftDelta {
+	var delta DriftDelta
+	switch {
+	case new != nil:
+		delta.ResourceID = new.ResourceID
+	case old != nil:
+		delta.ResourceID = old.ResourceID
+	}
+
+	oldDrifts := driftedAttributes(old)
+	newDrifts := driftedAttributes(new)
+
+	_, newMissing := newDrifts[AttributeExists]
+	for path, drift := range newDrifts {
+		oldDrift, ok := oldDrifts[path]
+		switch {
+		case !ok:
+			delta.Added = append(delta.Added, drift)
+		case !sameDriftValues(oldDrift, drift):
+			delta.Changed = append(delta.Changed, AttributeChange{Path: path, Old: oldDrift, New: drift})
+		}
+	}
+
+	for path, drift := range oldDrifts {
+		if _, ok := newDrifts[path]; ok {
+			continue
+		}
+		// Attributes aren't compared while the instance is missing from a provider
+		if newMissing && path != AttributeExists {
+			continue
+		}
+		delta.Removed = append(delta.Removed, drift)
+	}
+
+	sort.Slice(delta.Added, func(i, j int) bool { return delta.Added[i].Path < delta.Added[j].Path })
+	sort.Slice(delta.Removed, func(i, j int) bool { return delta.Removed[i].Path < delta.Removed[j].Path })
+	sort.Slice(delta.Changed, func(i, j int) bool { return delta.Changed[i].Path < delta.Changed[j].Path })
+
+	return delta
+}
+
+// driftedAttributes returns the drifted attributes of a result, keyed by path
+func driftedAttributes(result *DriftResult) map[string]AttributeDrift {
+	if result == nil {
+		return nil
+	}
+
+	drifts := make(map[string]AttributeDrift, len(result.DriftedAttributes))
+	for path, drift := range result.DriftedAttributes {
+		if drift.Path == "" {
+			drift.Path = path
+		}
+		drifts[path] = drift
+	}
+	return drifts
+}
+
+// sameDriftValues reports whether two drifts have the same source and target values
+func sameDriftValues(a, b AttributeDrift) bool {
+	return sameValue(a.SourceValue, b.SourceValue) && sameValue(a.TargetValue, b.TargetValue)
+}
+
+// sameValue compares values by their JSON encoding, so a result loaded from storage (where
+// numbers are float64 and lists are []interface{}) matches one built in memory
+func sameValue(a, b interface{}) bool {
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(aData) == string(bData)
+}
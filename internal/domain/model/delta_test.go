@@ -0,0 +1,158 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// driftResult builds a result with the given drifted attributes as [path, source, target] triples
+func driftResult(drifts ...[3]interface{}) *DriftResult {
+	result := NewDriftResult("i-123", OriginTerraform)
+	for _, drift := range drifts {
+		result.AddDriftedAttribute(drift[0].(string), drift[1], drift[2])
+	}
+	return result
+}
+
+func missingFromAWS() *DriftResult {
+	return driftResult([3]interface{}{AttributeExists, false, true})
+}
+
+func paths(drifts []AttributeDrift) []string {
+	var out []string
+	for _, drift := range drifts {
+		out = append(out, drift.Path)
+	}
+	return out
+}
+
+func changedPaths(changes []AttributeChange) []string {
+	var out []string
+	for _, change := range changes {
+		out = append(out, change.Path)
+	}
+	return out
+}
+
+func TestDiffDriftResults(t *testing.T) {
+	instanceType := [3]interface{}{"instance_type", "t2.micro", "t2.large"}
+	instanceTypeXL := [3]interface{}{"instance_type", "t2.micro", "t2.xlarge"}
+	ami := [3]interface{}{"ami", "ami-1", "ami-2"}
+	tags := [3]interface{}{"tags.Env", "prod", "dev"}
+
+	tests := []struct {
+		name     string
+		old, new *DriftResult
+		added    []string
+		removed  []string
+		changed  []string
+		material bool
+	}{
+		{name: "both nil"},
+		{name: "first run without drift", new: driftResult()},
+		{name: "first run with drift", new: driftResult(instanceType, ami), added: []string{"ami", "instance_type"}, material: true},
+		{name: "no new result", old: driftResult(instanceType), removed: []string{"instance_type"}, material: true},
+		{name: "no drift in either", old: driftResult(), new: driftResult()},
+		{name: "unchanged drift", old: driftResult(instanceType), new: driftResult(instanceType)},
+		{name: "drift resolved", old: driftResult(instanceType, ami), new: driftResult(ami), removed: []string{"instance_type"}, material: true},
+		{name: "all drift resolved", old: driftResult(instanceType), new: driftResult(), removed: []string{"instance_type"}, material: true},
+		{name: "new drift", old: driftResult(ami), new: driftResult(ami, tags), added: []string{"tags.Env"}, material: true},
+		{name: "value changed", old: driftResult(instanceType), new: driftResult(instanceTypeXL), changed: []string{"instance_type"}, material: true},
+		{
+			name:     "added, removed and changed",
+			old:      driftResult(instanceType, ami),
+			new:      driftResult(instanceTypeXL, tags),
+			added:    []string{"tags.Env"},
+			removed:  []string{"ami"},
+			changed:  []string{"instance_type"},
+			material: true,
+		},
+		{name: "first run missing from AWS", new: missingFromAWS(), added: []string{AttributeExists}, material: true},
+		{name: "still missing", old: missingFromAWS(), new: missingFromAWS()},
+		{
+			name:     "instance went missing",
+			old:      driftResult(instanceType, ami),
+			new:      missingFromAWS(),
+			added:    []string{AttributeExists},
+			material: true,
+		},
+		{
+			name:     "instance came back with drift",
+			old:      missingFromAWS(),
+			new:      driftResult(instanceType),
+			added:    []string{"instance_type"},
+			removed:  []string{AttributeExists},
+			material: true,
+		},
+		{name: "instance came back without drift", old: missingFromAWS(), new: driftResult(), removed: []string{AttributeExists}, material: true},
+		{
+			name:     "moved to the other provider",
+			old:      missingFromAWS(),
+			new:      driftResult([3]interface{}{AttributeExists, true, false}),
+			changed:  []string{AttributeExists},
+			material: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := DiffDriftResults(tt.old, tt.new)
+			assert.Equal(t, tt.added, paths(delta.Added), "added")
+			assert.Equal(t, tt.removed, paths(delta.Removed), "removed")
+			assert.Equal(t, tt.changed, changedPaths(delta.Changed), "changed")
+			assert.Equal(t, tt.material, delta.HasMaterialChange())
+		})
+	}
+}
+
+func TestDiffDriftResults_ResourceID(t *testing.T) {
+	old := driftResult()
+	old.ResourceID = "i-old"
+	new := driftResult()
+	new.ResourceID = "i-new"
+
+	assert.Equal(t, "i-new", DiffDriftResults(old, new).ResourceID)
+	assert.Equal(t, "i-old", DiffDriftResults(old, nil).ResourceID)
+	assert.Equal(t, "i-new", DiffDriftResults(nil, new).ResourceID)
+	assert.Empty(t, DiffDriftResults(nil, nil).ResourceID)
+}
+
+func TestDiffDriftResults_ChangeCarriesBothDrifts(t *testing.T) {
+	old := driftResult([3]interface{}{"instance_type", "t2.micro", "t2.large"})
+	new := driftResult([3]interface{}{"instance_type", "t2.small", "t2.large"})
+
+	delta := DiffDriftResults(old, new)
+	require.Len(t, delta.Changed, 1)
+	assert.Equal(t, "t2.micro", delta.Changed[0].Old.SourceValue)
+	assert.Equal(t, "t2.small", delta.Changed[0].New.SourceValue)
+}
+
+func TestDiffDriftResults_StoredResult(t *testing.T) {
+	current := driftResult(
+		[3]interface{}{"vpc_security_group_ids", []string{"sg-1"}, []string{"sg-1", "sg-2"}},
+		[3]interface{}{"root_block_device.0.volume_size", 8, 20},
+	)
+
+	// A result read back from JSON storage has float64 numbers and []interface{} lists
+	data, err := json.Marshal(current)
+	require.NoError(t, err)
+	var stored DriftResult
+	require.NoError(t, json.Unmarshal(data, &stored))
+
+	delta := DiffDriftResults(&stored, current)
+	assert.False(t, delta.HasMaterialChange(), "%+v", delta)
+}
+
+func TestDiffDriftResults_IgnoresAnnotations(t *testing.T) {
+	old := driftResult([3]interface{}{"tags.Env", "prod", "dev"})
+	new := driftResult([3]interface{}{"tags.Env", "prod", "dev"})
+	drift := new.DriftedAttributes["tags.Env"]
+	drift.TerraformAttribute = "tags_all"
+	drift.Diff = "-prod\n+dev\n"
+	new.DriftedAttributes["tags.Env"] = drift
+
+	assert.False(t, DiffDriftResults(old, new).HasMaterialChange())
+}
@@ -1,18 +1,43 @@
 package model
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// AttributeExists is the drifted attribute recorded when an instance exists in only one provider
+const AttributeExists = "exists"
+
 // DriftResult represents the result of a drift detection operation
 type DriftResult struct {
-	// ID is a unique identifier for the drift detection result
+	// ID is a unique identifier for the drift detection result. IDs of the same instance sort
+	// chronologically, see NewResultID.
 	ID           string `json:"id"`
 	ResourceID   string `json:"resource_id"`
 	ResourceType string `json:"resource_type"`
 
+	// AccountID is the AWS account the resource belongs to, set when scanning multiple accounts
+	AccountID string `json:"account_id,omitempty"`
+
+	// Workspace is the Terraform workspace the resource was compared within, set when comparing
+	// multiple workspaces
+	Workspace string `json:"workspace,omitempty"`
+
+	// ResourceAddress is the Terraform address of the resource, when Terraform has it
+	ResourceAddress string `json:"resource_address,omitempty"`
+
+	// DuplicateAddresses are other Terraform resources that track the same instance ID and
+	// weren't compared; the state needs fixing, e.g. with terraform state rm
+	DuplicateAddresses []string `json:"duplicate_addresses,omitempty"`
+
+	// SourceName and TargetName are the Name tags of the compared instances
+	SourceName string `json:"source_name,omitempty"`
+	TargetName string `json:"target_name,omitempty"`
+
 	// SourceType indicates which configuration is considered the source of truth
 	SourceType ResourceOrigin `json:"source_type"`
 
@@ -22,44 +47,311 @@ type DriftResult struct {
 	// HasDrift indicates whether any drift was detected
 	HasDrift bool `json:"has_drift"`
 
+	// Unmanaged reports that the instance exists in AWS, the source of truth, but no Terraform
+	// resource manages it. Its existence drift is counted apart from the attribute drift of
+	// managed instances, see IsManagedDrift.
+	Unmanaged bool `json:"unmanaged,omitempty"`
+
 	// DriftedAttributes contains information about all detected drifts
 	DriftedAttributes map[string]AttributeDrift `json:"drifted_attributes,omitempty"`
+
+	// SkippedAttributes maps attributes that could not be compared to the reason they were skipped
+	SkippedAttributes map[string]string `json:"skipped_attributes,omitempty"`
+
+	// PolicyViolations lists the policies the live instance does not satisfy. Violations are
+	// reported alongside drift but do not set HasDrift.
+	PolicyViolations []PolicyViolation `json:"policy_violations,omitempty"`
+
+	// Remediation suggests how to resolve the drift, when requested. Nothing runs the steps.
+	Remediation []RemediationStep `json:"remediation,omitempty"`
+
+	// SourceSnapshot and TargetSnapshot are the full attributes of the compared instances, when
+	// reporter.include_snapshots is set. They are reported but never stored.
+	SourceSnapshot *InstanceSnapshot `json:"source_snapshot,omitempty"`
+	TargetSnapshot *InstanceSnapshot `json:"target_snapshot,omitempty"`
+
+	// Memoized reports that neither instance changed since the previous run, so the result of
+	// that run was reused instead of comparing again
+	Memoized bool `json:"memoized,omitempty"`
 }
 
 // NewDriftResult creates a new drift detection result
 func NewDriftResult(instanceID string, sourceType ResourceOrigin) *DriftResult {
+	return NewDriftResultAt(instanceID, sourceType, time.Now())
+}
+
+// NewDriftResultAt creates a new drift detection result timestamped at the given time
+func NewDriftResultAt(instanceID string, sourceType ResourceOrigin, timestamp time.Time) *DriftResult {
 	return &DriftResult{
-		ID:                generateUUID(),
+		ID:                NewResultID(instanceID, timestamp),
 		ResourceID:        instanceID,
 		ResourceType:      "aws_instance",
 		SourceType:        sourceType,
-		Timestamp:         time.Now(),
+		Timestamp:         timestamp,
 		DriftedAttributes: make(map[string]AttributeDrift),
 	}
 }
 
 // AddDriftedAttribute adds a drifted attribute to the result
 func (r *DriftResult) AddDriftedAttribute(path string, source, target interface{}) {
-	r.DriftedAttributes[path] = AttributeDrift{
-		Path:        path,
-		SourceValue: source,
-		TargetValue: target,
-		Changed:     true,
-	}
+	r.DriftedAttributes[path] = NewAttributeDrift(path, source, target)
 	r.HasDrift = true
 }
 
+// IsManagedDrift reports whether the result is drift of an instance Terraform manages, rather
+// than an unmanaged instance
+func (r *DriftResult) IsManagedDrift() bool {
+	return r.HasDrift && !r.Unmanaged
+}
+
+// ValueLabels returns the labels of the desired and current values, named after the provider
+// each comes from, e.g. "Terraform Value" and "AWS Value" when Terraform is the source of truth.
+// Generic source and target labels read the opposite way between teams that configure the
+// source of truth differently.
+func (r *DriftResult) ValueLabels() (desired, current string) {
+	return r.SourceType.Name() + " Value", r.SourceType.Other().Name() + " Value"
+}
+
 // SetDriftedAttributes sets the complete map of drifted attributes
 func (r *DriftResult) SetDriftedAttributes(drifts map[string]AttributeDrift) {
 	r.DriftedAttributes = drifts
 	r.HasDrift = len(drifts) > 0
 }
 
-// generateUUID generates a simple UUID for the drift result
-func generateUUID() string {
+// WithoutAttributes returns a copy of the result without the drifted and skipped attributes at
+// the given paths or below them. HasDrift is recomputed from the drifts left, so that a result
+// whose only drift is in a stripped attribute is stored as clean.
+func (r *DriftResult) WithoutAttributes(paths []string) *DriftResult {
+	if len(paths) == 0 {
+		return r
+	}
+
+	stripped := *r
+	stripped.DriftedAttributes = make(map[string]AttributeDrift, len(r.DriftedAttributes))
+	for path, drift := range r.DriftedAttributes {
+		if !underAnyPath(path, paths) {
+			stripped.DriftedAttributes[path] = drift
+		}
+	}
+	stripped.HasDrift = len(stripped.DriftedAttributes) > 0
+
+	var skipped map[string]string
+	for path, reason := range r.SkippedAttributes {
+		if underAnyPath(path, paths) {
+			continue
+		}
+		if skipped == nil {
+			skipped = make(map[string]string)
+		}
+		skipped[path] = reason
+	}
+	stripped.SkippedAttributes = skipped
+	return &stripped
+}
+
+// underAnyPath reports whether path is one of the given paths or below one of them
+func underAnyPath(path string, paths []string) bool {
+	for _, parent := range paths {
+		if path == parent || strings.HasPrefix(path, parent+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSkippedAttributes sets the attributes that were skipped during comparison
+func (r *DriftResult) SetSkippedAttributes(skipped map[string]string) {
+	if len(skipped) == 0 {
+		r.SkippedAttributes = nil
+		return
+	}
+	r.SkippedAttributes = skipped
+}
+
+// SetPolicyViolations sets the policy violations found for the instance
+func (r *DriftResult) SetPolicyViolations(violations []PolicyViolation) {
+	if len(violations) == 0 {
+		r.PolicyViolations = nil
+		return
+	}
+	r.PolicyViolations = violations
+}
+
+// HasPolicyViolations reports whether the instance violates any policy
+func (r *DriftResult) HasPolicyViolations() bool {
+	return len(r.PolicyViolations) > 0
+}
+
+// SetNames records the Name tags of the source and target instances
+func (r *DriftResult) SetNames(source, target string) {
+	r.SourceName = source
+	r.TargetName = target
+}
+
+// NameDrifted reports whether the instance was renamed away from its source Name tag
+func (r *DriftResult) NameDrifted() bool {
+	return r.SourceName != "" && r.SourceName != r.TargetName
+}
+
+// Name returns the instance name, preferring the source of truth
+func (r *DriftResult) Name() string {
+	if r.SourceName != "" {
+		return r.SourceName
+	}
+	return r.TargetName
+}
+
+// Label returns a display label for the instance, e.g. "web-1 (i-123)" or
+// "web-1 → web-1-old (i-123)" when the Name tag drifted
+func (r *DriftResult) Label() string {
+	switch {
+	case r.NameDrifted():
+		target := r.TargetName
+		if target == "" {
+			target = "<none>"
+		}
+		return fmt.Sprintf("%s → %s (%s)", r.SourceName, target, r.ResourceID)
+	case r.Name() != "":
+		return fmt.Sprintf("%s (%s)", r.Name(), r.ResourceID)
+	default:
+		return r.ResourceID
+	}
+}
+
+// AccountSummary aggregates drift results for a single AWS account
+type AccountSummary struct {
+	TotalInstances int `json:"total_instances"`
+	DriftedCount   int `json:"drifted_count"`
+}
+
+// SummarizeByAccount aggregates results per account ID
+// Returns nil when none of the results are tagged with an account
+func SummarizeByAccount(results []*DriftResult) map[string]AccountSummary {
+	var summaries map[string]AccountSummary
+
+	for _, result := range results {
+		if result.AccountID == "" {
+			continue
+		}
+		if summaries == nil {
+			summaries = make(map[string]AccountSummary)
+		}
+
+		summary := summaries[result.AccountID]
+		summary.TotalInstances++
+		if result.HasDrift {
+			summary.DriftedCount++
+		}
+		summaries[result.AccountID] = summary
+	}
+
+	return summaries
+}
+
+// WorkspaceSummary aggregates drift results for a single Terraform workspace
+type WorkspaceSummary struct {
+	TotalInstances int `json:"total_instances"`
+	DriftedCount   int `json:"drifted_count"`
+}
+
+// SummarizeByWorkspace aggregates results per Terraform workspace
+// Returns nil when none of the results are tagged with a workspace
+func SummarizeByWorkspace(results []*DriftResult) map[string]WorkspaceSummary {
+	var summaries map[string]WorkspaceSummary
+
+	for _, result := range results {
+		if result.Workspace == "" {
+			continue
+		}
+		if summaries == nil {
+			summaries = make(map[string]WorkspaceSummary)
+		}
+
+		summary := summaries[result.Workspace]
+		summary.TotalInstances++
+		if result.HasDrift {
+			summary.DriftedCount++
+		}
+		summaries[result.Workspace] = summary
+	}
+
+	return summaries
+}
+
+// AttributeSummary aggregates drift on a single attribute across a fleet of instances
+type AttributeSummary struct {
+	Path             string        `json:"path"`
+	DriftedInstances int           `json:"drifted_instances"`
+	SampleValues     []interface{} `json:"sample_values"`
+}
+
+// AttributeSampleSize is the number of distinct drifted values reports sample per attribute
+// in their attribute summary
+const AttributeSampleSize = 5
+
+// SummarizeAttributes counts how many instances drifted on each attribute path and collects
+// up to maxSamples distinct target values per path. The most common drift comes first.
+func SummarizeAttributes(results []*DriftResult, maxSamples int) []AttributeSummary {
+	byPath := make(map[string]*AttributeSummary)
+	seen := make(map[string]map[string]bool)
+
+	for _, result := range results {
+		for path, drift := range result.DriftedAttributes {
+			summary, ok := byPath[path]
+			if !ok {
+				summary = &AttributeSummary{Path: path, SampleValues: []interface{}{}}
+				byPath[path] = summary
+				seen[path] = make(map[string]bool)
+			}
+			summary.DriftedInstances++
+
+			key := fmt.Sprintf("%v", drift.TargetValue)
+			if len(summary.SampleValues) < maxSamples && !seen[path][key] {
+				seen[path][key] = true
+				summary.SampleValues = append(summary.SampleValues, drift.TargetValue)
+			}
+		}
+	}
+
+	summaries := make([]AttributeSummary, 0, len(byPath))
+	for _, summary := range byPath {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].DriftedInstances != summaries[j].DriftedInstances {
+			return summaries[i].DriftedInstances > summaries[j].DriftedInstances
+		}
+		return summaries[i].Path < summaries[j].Path
+	})
+
+	return summaries
+}
+
+// NewResultID returns a drift result ID of the form <instance ID>-<Unix nanoseconds>-<random>,
+// e.g. i-0abc-1714000000000000000-1a2b3c4d. The timestamp is zero padded, so that the IDs of an
+// instance sort in the order its results were taken, and the random suffix keeps results taken
+// at the same time apart.
+func NewResultID(instanceID string, timestamp time.Time) string {
+	var nanos int64
+	if !timestamp.IsZero() && timestamp.Unix() > 0 {
+		nanos = timestamp.UnixNano()
+	}
 	id, err := uuid.NewRandom()
 	if err != nil {
 		panic(err)
 	}
-	return id.String()
+	return fmt.Sprintf("%s-%019d-%s", instanceID, nanos, id.String()[:8])
+}
+
+// LatestResult returns the most recent of an instance's results, the one with the greater ID
+// among results taken at the same time, or nil when there are none
+func LatestResult(results []*DriftResult) *DriftResult {
+	var latest *DriftResult
+	for _, result := range results {
+		if latest == nil || result.Timestamp.After(latest.Timestamp) ||
+			result.Timestamp.Equal(latest.Timestamp) && result.ID > latest.ID {
+			latest = result
+		}
+	}
+	return latest
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -79,12 +80,111 @@ func TestSetDriftedAttributes(t *testing.T) {
 	assert.Empty(t, result.DriftedAttributes)
 }
 
-func TestGenerateUUID(t *testing.T) {
-	// Test the UUID generation function
-	uuid1 := generateUUID()
-	uuid2 := generateUUID()
+func TestNewResultID(t *testing.T) {
+	earlier := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	later := earlier.Add(time.Millisecond)
 
-	assert.NotEmpty(t, uuid1)
-	assert.NotEmpty(t, uuid2)
-	assert.NotEqual(t, uuid1, uuid2)
+	id1 := NewResultID("i-12345", earlier)
+	id2 := NewResultID("i-12345", earlier)
+	id3 := NewResultID("i-12345", later)
+
+	assert.True(t, strings.HasPrefix(id1, "i-12345-1713802845000000000-"))
+	assert.NotEqual(t, id1, id2)
+	assert.Less(t, id1, id3)
+	assert.Less(t, id2, id3)
+
+	// Results stamped later by the repository still get an ID
+	assert.True(t, strings.HasPrefix(NewResultID("i-12345", time.Time{}), "i-12345-0000000000000000000-"))
+}
+
+func TestLatestResult(t *testing.T) {
+	base := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	first := NewDriftResultAt("i-12345", OriginTerraform, base)
+	second := NewDriftResultAt("i-12345", OriginTerraform, base.Add(time.Hour))
+	third := NewDriftResultAt("i-12345", OriginTerraform, base.Add(time.Hour))
+	third.ID = second.ID + "z"
+
+	assert.Nil(t, LatestResult(nil))
+	assert.Same(t, second, LatestResult([]*DriftResult{second, first}))
+	assert.Same(t, third, LatestResult([]*DriftResult{first, third, second}))
+}
+
+func TestSummarizeAttributes(t *testing.T) {
+	var results []*DriftResult
+	for i, costCenter := range []string{"ops", "ops", "eng", "data"} {
+		r := NewDriftResult(string(rune('a'+i)), OriginTerraform)
+		r.AddDriftedAttribute("tags.CostCenter", "finance", costCenter)
+		results = append(results, r)
+	}
+	results[0].AddDriftedAttribute("instance_type", "t2.micro", "t2.large")
+	results = append(results, NewDriftResult("clean", OriginTerraform))
+
+	summary := SummarizeAttributes(results, 2)
+	assert.Len(t, summary, 2)
+
+	// Most common drift comes first, with distinct samples capped
+	assert.Equal(t, "tags.CostCenter", summary[0].Path)
+	assert.Equal(t, 4, summary[0].DriftedInstances)
+	assert.Len(t, summary[0].SampleValues, 2)
+	assert.ElementsMatch(t, []interface{}{"ops", "eng"}, summary[0].SampleValues)
+
+	assert.Equal(t, "instance_type", summary[1].Path)
+	assert.Equal(t, 1, summary[1].DriftedInstances)
+	assert.Equal(t, []interface{}{"t2.large"}, summary[1].SampleValues)
+
+	assert.Empty(t, SummarizeAttributes(nil, 5))
+}
+
+func TestDriftResult_Label(t *testing.T) {
+	r := NewDriftResult("i-123", OriginTerraform)
+	assert.Equal(t, "i-123", r.Label())
+
+	r.SetNames("web-1", "web-1")
+	assert.False(t, r.NameDrifted())
+	assert.Equal(t, "web-1 (i-123)", r.Label())
+
+	r.SetNames("web-1", "web-1-old")
+	assert.True(t, r.NameDrifted())
+	assert.Equal(t, "web-1 → web-1-old (i-123)", r.Label())
+
+	// A name only set in the target is not drift from the source of truth
+	r.SetNames("", "web-1")
+	assert.False(t, r.NameDrifted())
+	assert.Equal(t, "web-1 (i-123)", r.Label())
+}
+
+func TestDriftResult_WithoutAttributes(t *testing.T) {
+	result := NewDriftResult("i-123", OriginTerraform)
+	result.AddDriftedAttribute("launch_time", "2026-10-01T09:00:00Z", "2026-10-16T09:00:00Z")
+	result.AddDriftedAttribute("tags.LastSeen", "monday", "tuesday")
+	result.SetSkippedAttributes(map[string]string{"public_dns_name": "unknown value"})
+
+	// Stripping every drifted attribute stores the result as clean
+	stripped := result.WithoutAttributes([]string{"launch_time", "tags.LastSeen", "public_dns_name"})
+	assert.False(t, stripped.HasDrift)
+	assert.Empty(t, stripped.DriftedAttributes)
+	assert.Nil(t, stripped.SkippedAttributes)
+	assert.Equal(t, result.ID, stripped.ID)
+
+	// Paths strip the attributes below them, and the original is left untouched
+	result.AddDriftedAttribute("instance_type", "t2.micro", "t2.small")
+	stripped = result.WithoutAttributes([]string{"tags"})
+	assert.True(t, stripped.HasDrift)
+	assert.NotContains(t, stripped.DriftedAttributes, "tags.LastSeen")
+	assert.Contains(t, stripped.DriftedAttributes, "launch_time")
+	assert.Contains(t, stripped.SkippedAttributes, "public_dns_name")
+	assert.Len(t, result.DriftedAttributes, 3)
+
+	// No paths keep the result as it is
+	assert.Same(t, result, result.WithoutAttributes(nil))
+}
+
+func TestDriftResult_ValueLabels(t *testing.T) {
+	desired, current := NewDriftResult("i-1", OriginTerraform).ValueLabels()
+	assert.Equal(t, "Terraform Value", desired)
+	assert.Equal(t, "AWS Value", current)
+
+	desired, current = NewDriftResult("i-1", OriginAWS).ValueLabels()
+	assert.Equal(t, "AWS Value", desired)
+	assert.Equal(t, "Terraform Value", current)
 }
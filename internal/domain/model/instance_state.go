@@ -0,0 +1,23 @@
+package model
+
+// AttributeInstanceState is the normalized run state of an instance, e.g. running or stopped
+const AttributeInstanceState = "instance_state"
+
+// Instance states that can be declared as the desired state
+const (
+	InstanceStateRunning = "running"
+	InstanceStateStopped = "stopped"
+)
+
+// NormalizeInstanceState maps the transitional EC2 states to the state they settle in, so an
+// instance caught while starting or stopping isn't reported as drifted
+func NormalizeInstanceState(state string) string {
+	switch state {
+	case "pending":
+		return InstanceStateRunning
+	case "stopping":
+		return InstanceStateStopped
+	default:
+		return state
+	}
+}
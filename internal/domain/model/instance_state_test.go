@@ -0,0 +1,15 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeInstanceState(t *testing.T) {
+	assert.Equal(t, InstanceStateRunning, NormalizeInstanceState("pending"))
+	assert.Equal(t, InstanceStateRunning, NormalizeInstanceState("running"))
+	assert.Equal(t, InstanceStateStopped, NormalizeInstanceState("stopping"))
+	assert.Equal(t, InstanceStateStopped, NormalizeInstanceState("stopped"))
+	assert.Equal(t, "shutting-down", NormalizeInstanceState("shutting-down"))
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// Purchasing lifecycle attributes
+const (
+	// AttributeInstanceLifecycle is how the instance was purchased: spot or on-demand
+	AttributeInstanceLifecycle = "instance_lifecycle"
+
+	// AttributeSpotInstanceRequestID is the spot request that launched the instance, if any
+	AttributeSpotInstanceRequestID = "spot_instance_request_id"
+)
+
+// Instance lifecycles
+const (
+	InstanceLifecycleSpot     = "spot"
+	InstanceLifecycleOnDemand = "on-demand"
+)
+
+// NormalizeInstanceLifecycle maps the lifecycle EC2 and Terraform report to a comparable value.
+// Both leave it empty for on-demand instances.
+func NormalizeInstanceLifecycle(lifecycle string) string {
+	lifecycle = strings.ToLower(strings.TrimSpace(lifecycle))
+	if lifecycle == "" {
+		return InstanceLifecycleOnDemand
+	}
+	return lifecycle
+}
+
+// attributeAliases maps other names users may give a compared attribute to the attribute
+var attributeAliases = map[string]string{
+	"lifecycle":               AttributeInstanceLifecycle,
+	"instance_market_options": AttributeInstanceLifecycle,
+	"market_type":             AttributeInstanceLifecycle,
+	"placement.tenancy":       AttributeTenancy,
+	"placement.host_id":       AttributeHostID,
+	"placement.affinity":      AttributeAffinity,
+	"has_public_ip":           AttributeAssociatePublicIPAddress,
+}
+
+// CanonicalAttribute returns the attribute an alias stands for, or the path itself
+func CanonicalAttribute(path string) string {
+	if canonical, ok := attributeAliases[path]; ok {
+		return canonical
+	}
+	return path
+}
+
+// AttributeAliases returns the aliases that stand for an attribute, sorted
+func AttributeAliases(path string) []string {
+	var aliases []string
+	for alias, canonical := range attributeAliases {
+		if canonical == path {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
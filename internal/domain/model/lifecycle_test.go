@@ -0,0 +1,20 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeInstanceLifecycle(t *testing.T) {
+	assert.Equal(t, InstanceLifecycleOnDemand, NormalizeInstanceLifecycle(""))
+	assert.Equal(t, InstanceLifecycleSpot, NormalizeInstanceLifecycle("spot"))
+	assert.Equal(t, InstanceLifecycleSpot, NormalizeInstanceLifecycle(" Spot "))
+	assert.Equal(t, "capacity-block", NormalizeInstanceLifecycle("capacity-block"))
+}
+
+func TestCanonicalAttribute(t *testing.T) {
+	assert.Equal(t, AttributeInstanceLifecycle, CanonicalAttribute("lifecycle"))
+	assert.Equal(t, AttributeInstanceLifecycle, CanonicalAttribute("instance_market_options"))
+	assert.Equal(t, "instance_type", CanonicalAttribute("instance_type"))
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AttributeRegion is the path a pair of instances found in different regions is reported at
+const AttributeRegion = "region"
+
+// LocationHints are the AWS accounts and regions a set of instances appears to live in, read
+// from the ARNs and availability zones among their attributes
+type LocationHints struct {
+	AccountIDs []string
+	Regions    []string
+}
+
+// accountIDPattern matches the account ID field of an ARN
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// availabilityZonePattern captures the region of an availability zone, e.g. us-east-1 from
+// us-east-1a or us-west-2 from the local zone us-west-2-lax-1a
+var availabilityZonePattern = regexp.MustCompile(`^([a-z]{2}(?:-gov|-iso[a-z]*)?-[a-z]+-\d+)`)
+
+// InferLocationHints collects the account IDs and regions named by the instances' ARNs and
+// availability zones. Both lists are sorted and may be empty when nothing identifies them.
+func InferLocationHints(instances []*Instance) LocationHints {
+	accounts := make(map[string]bool)
+	regions := make(map[string]bool)
+
+	for _, instance := range instances {
+		if instance == nil {
+			continue
+		}
+		for key, value := range instance.Attributes {
+			for _, text := range stringValues(value) {
+				if strings.HasPrefix(text, "arn:") {
+					collectARN(text, accounts, regions)
+				} else if key == "availability_zone" {
+					if match := availabilityZonePattern.FindStringSubmatch(text); match != nil {
+						regions[match[1]] = true
+					}
+				}
+			}
+		}
+	}
+
+	return LocationHints{AccountIDs: sortedKeys(accounts), Regions: sortedKeys(regions)}
+}
+
+// IsEmpty reports whether no account or region was found
+func (h LocationHints) IsEmpty() bool {
+	return len(h.AccountIDs) == 0 && len(h.Regions) == 0
+}
+
+// regionPaths are the attributes that place an instance in a region, in order of preference.
+// Terraform records the ARN and availability zone; AWS reports the zone under placement.
+var regionPaths = []string{"arn", "availability_zone", "placement.availability_zone"}
+
+// InstanceRegion returns the region an instance's ARN or availability zone places it in, or an
+// empty string when neither is known
+func InstanceRegion(instance *Instance) string {
+	for _, path := range regionPaths {
+		value, ok := instance.GetAttribute(path)
+		text, isString := value.(string)
+		if !ok || !isString || text == "" {
+			continue
+		}
+		if path == "arn" {
+			regions := make(map[string]bool)
+			collectARN(text, make(map[string]bool), regions)
+			if keys := sortedKeys(regions); len(keys) == 1 {
+				return keys[0]
+			}
+		} else if match := availabilityZonePattern.FindStringSubmatch(text); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// CompareRegions returns a region drift when both instances can be placed in a region and the
+// regions differ. An ID found in another region than Terraform expects usually comes from
+// importing the instance of another region, and is reported as moved rather than as one
+// instance missing from AWS and another missing from Terraform.
+func CompareRegions(source, target *Instance, opts CompareOptions) *AttributeDrift {
+	sourceRegion, targetRegion := InstanceRegion(source), InstanceRegion(target)
+	if sourceRegion == "" || targetRegion == "" || sourceRegion == targetRegion {
+		return nil
+	}
+	drift := opts.newDrift(AttributeRegion, sourceRegion, targetRegion)
+	return &drift
+}
+
+// collectARN records the region and account of an ARN (arn:partition:service:region:account:resource).
+// Global resources such as IAM have no region, and AWS managed resources no account.
+func collectARN(arn string, accounts, regions map[string]bool) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 {
+		return
+	}
+	if parts[3] != "" {
+		regions[parts[3]] = true
+	}
+	if accountIDPattern.MatchString(parts[4]) {
+		accounts[parts[4]] = true
+	}
+}
+
+// stringValues returns a value's strings: the value itself or the strings in a list
+func stringValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
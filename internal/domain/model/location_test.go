@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferLocationHints(t *testing.T) {
+	instances := []*Instance{
+		NewInstance("i-1", map[string]interface{}{
+			"arn":               "arn:aws:ec2:us-east-1:123456789012:instance/i-1",
+			"availability_zone": "us-east-1a",
+			// Global and AWS managed resources name no region or account
+			"iam_instance_profile": "arn:aws:iam::123456789012:instance-profile/web",
+			"policy_arns":          []interface{}{"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+		}, OriginTerraform),
+		NewInstance("i-2", map[string]interface{}{
+			"availability_zone": "us-west-2-lax-1a",
+			"instance_type":     "t2.micro",
+		}, OriginTerraform),
+		nil,
+	}
+
+	hints := InferLocationHints(instances)
+	assert.Equal(t, []string{"123456789012"}, hints.AccountIDs)
+	assert.Equal(t, []string{"us-east-1", "us-west-2"}, hints.Regions)
+	assert.False(t, hints.IsEmpty())
+
+	hints = InferLocationHints([]*Instance{NewInstance("i-3", map[string]interface{}{"instance_type": "t2.micro"}, OriginTerraform)})
+	assert.True(t, hints.IsEmpty())
+}
+
+func TestInstanceRegion(t *testing.T) {
+	terraform := NewInstance("i-1", map[string]interface{}{
+		"arn":               "arn:aws:ec2:us-west-2:123456789012:instance/i-1",
+		"availability_zone": "us-west-2a",
+	}, OriginTerraform)
+	aws := NewInstance("i-1", map[string]interface{}{
+		"placement": map[string]interface{}{"availability_zone": "us-east-1b"},
+	}, OriginAWS)
+
+	assert.Equal(t, "us-west-2", InstanceRegion(terraform))
+	assert.Equal(t, "us-east-1", InstanceRegion(aws))
+	assert.Empty(t, InstanceRegion(NewInstance("i-2", map[string]interface{}{}, OriginAWS)))
+
+	drift := CompareRegions(terraform, aws, CompareOptions{})
+	if assert.NotNil(t, drift) {
+		assert.Equal(t, AttributeRegion, drift.Path)
+		assert.Equal(t, "us-west-2", drift.SourceValue)
+		assert.Equal(t, "us-east-1", drift.TargetValue)
+	}
+
+	// Nothing is reported when the regions agree or one is unknown
+	aws.Attributes["placement"] = map[string]interface{}{"availability_zone": "us-west-2c"}
+	assert.Nil(t, CompareRegions(terraform, aws, CompareOptions{}))
+	assert.Nil(t, CompareRegions(terraform, NewInstance("i-1", map[string]interface{}{}, OriginAWS), CompareOptions{}))
+}
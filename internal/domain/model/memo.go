@@ -0,0 +1,77 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/pkg/comparator"
+)
+
+// InstanceDigest returns a SHA256 of everything comparison reads from an instance: its
+// attributes and the metadata that changes how they are compared or reported. Instances with
+// the same digest compare the same way. A missing instance has an empty digest.
+func InstanceDigest(instance *Instance) string {
+	if instance == nil {
+		return ""
+	}
+
+	// Values are made JSON-safe without truncation, so that a change past the cap still counts
+	attributes := make(map[string]interface{}, len(instance.Attributes))
+	for key, value := range instance.Attributes {
+		attributes[key], _ = comparator.Sanitize(value, math.MaxInt)
+	}
+
+	// Maps marshal with sorted keys, so equal instances always serialize the same
+	data, err := json.Marshal(struct {
+		ID                 string                 `json:"id"`
+		Origin             ResourceOrigin         `json:"origin"`
+		AccountID          string                 `json:"account_id"`
+		Workspace          string                 `json:"workspace"`
+		ResourceAddress    string                 `json:"resource_address"`
+		DuplicateAddresses []string               `json:"duplicate_addresses"`
+		StaticAttributes   map[string]bool        `json:"static_attributes"`
+		AttributeSources   map[string]string      `json:"attribute_sources"`
+		Attributes         map[string]interface{} `json:"attributes"`
+	}{
+		ID:                 instance.ID,
+		Origin:             instance.Origin,
+		AccountID:          instance.AccountID,
+		Workspace:          instance.Workspace,
+		ResourceAddress:    instance.ResourceAddress,
+		DuplicateAddresses: instance.DuplicateAddresses,
+		StaticAttributes:   instance.StaticAttributes,
+		AttributeSources:   instance.AttributeSources,
+		Attributes:         attributes,
+	})
+	if err != nil {
+		// Sanitized values always marshal; an instance that doesn't is never reused
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ReusedAt returns a copy of a memoized result as of a later run: it gets a new ID and
+// timestamp and is marked memoized. Drifted and skipped attributes are copied so that enriching
+// the copy leaves the memoized result untouched.
+func (r *DriftResult) ReusedAt(timestamp time.Time) *DriftResult {
+	reused := *r
+	reused.ID = NewResultID(r.ResourceID, timestamp)
+	reused.Timestamp = timestamp
+	reused.Memoized = true
+
+	reused.DriftedAttributes = make(map[string]AttributeDrift, len(r.DriftedAttributes))
+	for path, drift := range r.DriftedAttributes {
+		reused.DriftedAttributes[path] = drift
+	}
+	if r.SkippedAttributes != nil {
+		reused.SkippedAttributes = make(map[string]string, len(r.SkippedAttributes))
+		for path, reason := range r.SkippedAttributes {
+			reused.SkippedAttributes[path] = reason
+		}
+	}
+	return &reused
+}
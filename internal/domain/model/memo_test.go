@@ -0,0 +1,52 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceDigest(t *testing.T) {
+	newInstance := func(instanceType string) *Instance {
+		return NewInstance("i-1", map[string]interface{}{
+			"instance_type": instanceType,
+			"tags":          map[string]interface{}{"Name": "web", "Env": "prod"},
+			"parsed":        map[interface{}]interface{}{"key": "value"},
+		}, OriginAWS)
+	}
+
+	digest := InstanceDigest(newInstance("t3.micro"))
+	assert.Len(t, digest, 64)
+	assert.Equal(t, digest, InstanceDigest(newInstance("t3.micro")))
+	assert.NotEqual(t, digest, InstanceDigest(newInstance("t3.large")))
+
+	// Metadata that changes how attributes are compared is part of the digest
+	static := newInstance("t3.micro")
+	static.MarkStatic("private_ip")
+	assert.NotEqual(t, digest, InstanceDigest(static))
+
+	assert.Empty(t, InstanceDigest(nil))
+}
+
+func TestDriftResult_ReusedAt(t *testing.T) {
+	first := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	result := NewDriftResultAt("i-1", OriginTerraform, first)
+	result.AddDriftedAttribute("instance_type", "t3.micro", "t3.large")
+	result.SetSkippedAttributes(map[string]string{"ami": "unknown"})
+
+	reused := result.ReusedAt(first.Add(time.Hour))
+	assert.True(t, reused.Memoized)
+	assert.False(t, result.Memoized)
+	assert.Equal(t, first.Add(time.Hour), reused.Timestamp)
+	assert.NotEqual(t, result.ID, reused.ID)
+	assert.True(t, strings.HasPrefix(reused.ID, "i-1-"))
+	assert.Equal(t, result.DriftedAttributes, reused.DriftedAttributes)
+
+	// The reused copy can be enriched without touching the memoized result
+	reused.DriftedAttributes["tags"] = AttributeDrift{Path: "tags"}
+	reused.SkippedAttributes["tags"] = "unknown"
+	assert.NotContains(t, result.DriftedAttributes, "tags")
+	assert.NotContains(t, result.SkippedAttributes, "tags")
+}
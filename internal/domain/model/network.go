@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AttributeSubnetID is the subnet the instance is launched in
+const AttributeSubnetID = "subnet_id"
+
+// Subnet describes where a subnet places the instances launched in it
+type Subnet struct {
+	ID               string `json:"subnet_id"`
+	VPCID            string `json:"vpc_id,omitempty"`
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	Name             string `json:"name,omitempty"`
+}
+
+// String describes the subnet, e.g. "subnet-1 (app-a) in vpc-1, eu-west-1a"
+func (s *Subnet) String() string {
+	label := s.ID
+	if s.Name != "" {
+		label += fmt.Sprintf(" (%s)", s.Name)
+	}
+	var placement []string
+	if s.VPCID != "" {
+		placement = append(placement, s.VPCID)
+	}
+	if s.AvailabilityZone != "" {
+		placement = append(placement, s.AvailabilityZone)
+	}
+	if len(placement) > 0 {
+		label += " in " + strings.Join(placement, ", ")
+	}
+	return label
+}
+
+// NetworkContext puts a subnet_id drift in terms of the subnets on either side: moving to a
+// subnet in another availability zone changes which zonal resources the instance shares a
+// failure domain with, and moving to another VPC changes what it can route to at all
+type NetworkContext struct {
+	Source *Subnet `json:"source,omitempty"`
+	Target *Subnet `json:"target,omitempty"`
+
+	// CrossVPC and CrossAZ report whether the subnets are in different VPCs or availability
+	// zones, when both subnets could be described
+	CrossVPC bool `json:"cross_vpc"`
+	CrossAZ  bool `json:"cross_az"`
+}
+
+// NewNetworkContext compares the source and target subnets of a subnet_id drift. Either may
+// be nil when it couldn't be described.
+func NewNetworkContext(source, target *Subnet) *NetworkContext {
+	network := &NetworkContext{Source: source, Target: target}
+	if source == nil || target == nil {
+		return network
+	}
+	network.CrossVPC = source.VPCID != "" && target.VPCID != "" && source.VPCID != target.VPCID
+	network.CrossAZ = source.AvailabilityZone != "" && target.AvailabilityZone != "" && source.AvailabilityZone != target.AvailabilityZone
+	return network
+}
+
+// String describes the move for reports, e.g. "moved to another VPC: subnet-1 in vpc-1,
+// eu-west-1a -> subnet-2 in vpc-2, eu-west-1b; routes, peering and security groups of vpc-1
+// no longer apply"
+func (n *NetworkContext) String() string {
+	describe := func(subnet *Subnet) string {
+		if subnet == nil {
+			return "unknown subnet"
+		}
+		return subnet.String()
+	}
+	moves := fmt.Sprintf("%s -> %s", describe(n.Source), describe(n.Target))
+
+	switch {
+	case n.Source == nil || n.Target == nil:
+		return "moved: " + moves
+	case n.CrossVPC:
+		return fmt.Sprintf("moved to another VPC: %s; routes, peering and security groups of %s no longer apply", moves, n.Source.VPCID)
+	case n.CrossAZ:
+		return fmt.Sprintf("moved to another availability zone: %s; zonal resources such as EBS volumes stay in %s", moves, n.Source.AvailabilityZone)
+	}
+	return fmt.Sprintf("moved within the VPC: %s", moves)
+}
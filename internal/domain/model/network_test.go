@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNetworkContext(t *testing.T) {
+	appA := &Subnet{ID: "subnet-a", VPCID: "vpc-1", AvailabilityZone: "eu-west-1a", Name: "app-a"}
+	appB := &Subnet{ID: "subnet-b", VPCID: "vpc-1", AvailabilityZone: "eu-west-1b"}
+	shared := &Subnet{ID: "subnet-c", VPCID: "vpc-2", AvailabilityZone: "eu-west-1a"}
+
+	network := NewNetworkContext(appA, shared)
+	assert.True(t, network.CrossVPC)
+	assert.False(t, network.CrossAZ)
+	assert.Equal(t, "moved to another VPC: subnet-a (app-a) in vpc-1, eu-west-1a -> subnet-c in vpc-2, eu-west-1a; routes, peering and security groups of vpc-1 no longer apply", network.String())
+
+	network = NewNetworkContext(appA, appB)
+	assert.False(t, network.CrossVPC)
+	assert.True(t, network.CrossAZ)
+	assert.Equal(t, "moved to another availability zone: subnet-a (app-a) in vpc-1, eu-west-1a -> subnet-b in vpc-1, eu-west-1b; zonal resources such as EBS volumes stay in eu-west-1a", network.String())
+
+	// A subnet that couldn't be described doesn't count as a move across VPCs or zones
+	network = NewNetworkContext(appA, nil)
+	assert.False(t, network.CrossVPC)
+	assert.False(t, network.CrossAZ)
+	assert.Equal(t, "moved: subnet-a (app-a) in vpc-1, eu-west-1a -> unknown subnet", network.String())
+}
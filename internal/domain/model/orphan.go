@@ -0,0 +1,123 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Resource types checked for orphans
+const (
+	ResourceTypeVolume           = "aws_ebs_volume"
+	ResourceTypeNetworkInterface = "aws_network_interface"
+	ResourceTypeElasticIP        = "aws_eip"
+)
+
+// Resource represents a non-instance AWS resource that can be attached to instances
+type Resource struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+
+	// AccountID is the AWS account the resource belongs to, set when scanning multiple accounts
+	AccountID string `json:"account_id,omitempty"`
+
+	// AttachedInstanceIDs lists the instances the resource is attached or associated to
+	AttachedInstanceIDs []string `json:"attached_instance_ids,omitempty"`
+
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// NewResource creates a new resource with the given ID, type and attributes
+func NewResource(id, resourceType string, attachedInstanceIDs []string, attrs map[string]interface{}) *Resource {
+	if attrs == nil {
+		attrs = make(map[string]interface{})
+	}
+	return &Resource{
+		ID:                  id,
+		Type:                resourceType,
+		AttachedInstanceIDs: attachedInstanceIDs,
+		Attributes:          attrs,
+	}
+}
+
+// GetAttribute retrieves an attribute value by path
+func (r *Resource) GetAttribute(path string) (interface{}, bool) {
+	return GetNestedValue(r.Attributes, path)
+}
+
+// NameTag returns the value of the resource's Name tag, or an empty string if it has none
+func (r *Resource) NameTag() string {
+	val, ok := r.GetAttribute("tags.Name")
+	if !ok || val == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// OrphanResult describes an AWS resource that no Terraform instance references
+type OrphanResult struct {
+	ResourceID   string `json:"resource_id"`
+	ResourceType string `json:"resource_type"`
+	Name         string `json:"name,omitempty"`
+
+	// AccountID is the AWS account the resource belongs to, set when scanning multiple accounts
+	AccountID string `json:"account_id,omitempty"`
+
+	// Reason explains why the resource is considered orphaned
+	Reason string `json:"reason"`
+
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Timestamp  time.Time              `json:"timestamp"`
+}
+
+// FindOrphans returns the resources that are neither managed directly by Terraform nor attached
+// to an instance Terraform knows about, sorted by type and ID
+func FindOrphans(resources []*Resource, instances []*Instance, managedIDs map[string]bool, timestamp time.Time) []*OrphanResult {
+	known := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		known[instance.ID] = true
+	}
+
+	var orphans []*OrphanResult
+	for _, resource := range resources {
+		if managedIDs[resource.ID] {
+			continue
+		}
+
+		var reason string
+		if len(resource.AttachedInstanceIDs) == 0 {
+			reason = "not attached to any instance"
+		} else {
+			var unmanaged []string
+			for _, id := range resource.AttachedInstanceIDs {
+				if !known[id] {
+					unmanaged = append(unmanaged, id)
+				}
+			}
+			if len(unmanaged) < len(resource.AttachedInstanceIDs) {
+				continue
+			}
+			reason = fmt.Sprintf("attached to instance not managed by Terraform: %s", strings.Join(unmanaged, ", "))
+		}
+
+		orphans = append(orphans, &OrphanResult{
+			ResourceID:   resource.ID,
+			ResourceType: resource.Type,
+			Name:         resource.NameTag(),
+			AccountID:    resource.AccountID,
+			Reason:       reason,
+			Attributes:   resource.Attributes,
+			Timestamp:    timestamp,
+		})
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].ResourceType != orphans[j].ResourceType {
+			return orphans[i].ResourceType < orphans[j].ResourceType
+		}
+		return orphans[i].ResourceID < orphans[j].ResourceID
+	})
+
+	return orphans
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindOrphans(t *testing.T) {
+	now := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	instances := []*Instance{NewInstance("i-managed", nil, OriginTerraform)}
+
+	resources := []*Resource{
+		NewResource("vol-attached", ResourceTypeVolume, []string{"i-managed"}, nil),
+		NewResource("vol-loose", ResourceTypeVolume, nil, map[string]interface{}{"tags": map[string]string{"Name": "scratch"}}),
+		NewResource("vol-managed", ResourceTypeVolume, nil, nil),
+		NewResource("eni-foreign", ResourceTypeNetworkInterface, []string{"i-console"}, nil),
+		NewResource("eipalloc-1", ResourceTypeElasticIP, nil, nil),
+	}
+
+	orphans := FindOrphans(resources, instances, map[string]bool{"vol-managed": true}, now)
+
+	ids := make([]string, 0, len(orphans))
+	for _, orphan := range orphans {
+		ids = append(ids, orphan.ResourceID)
+		assert.Equal(t, now, orphan.Timestamp)
+	}
+	assert.Equal(t, []string{"vol-loose", "eipalloc-1", "eni-foreign"}, ids)
+
+	assert.Equal(t, "scratch", orphans[0].Name)
+	assert.Equal(t, "not attached to any instance", orphans[0].Reason)
+	assert.Contains(t, orphans[2].Reason, "i-console")
+}
@@ -0,0 +1,37 @@
+package model
+
+import "strings"
+
+// PseudoIDPrefix starts the IDs given to instances of Terraform configurations, whose real ID
+// is only known once Terraform applies them
+const PseudoIDPrefix = "tf-"
+
+// Keys instances are paired on
+const (
+	MatchKeyID      = "id"
+	MatchKeyNameTag = "tags.Name"
+)
+
+// IsPseudoID reports whether id was made up for a configured instance rather than assigned by EC2
+func IsPseudoID(id string) bool {
+	return strings.HasPrefix(id, PseudoIDPrefix)
+}
+
+// MatchKey returns the key two instances compared with each other must agree on: their IDs,
+// or their Name tags when either only has a pseudo-ID
+func MatchKey(source, target *Instance) string {
+	if IsPseudoID(source.ID) || IsPseudoID(target.ID) {
+		return MatchKeyNameTag
+	}
+	return MatchKeyID
+}
+
+// SameInstance reports whether two instances agree on the key they are paired on, and the key
+func SameInstance(source, target *Instance) (bool, string) {
+	key := MatchKey(source, target)
+	if key == MatchKeyNameTag {
+		name := source.NameTag()
+		return name != "" && name == target.NameTag(), key
+	}
+	return source.ID == target.ID, key
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameInstance(t *testing.T) {
+	named := func(id, name string, origin ResourceOrigin) *Instance {
+		return NewInstance(id, map[string]interface{}{"tags": map[string]interface{}{"Name": name}}, origin)
+	}
+
+	same, key := SameInstance(named("i-1", "web", OriginTerraform), named("i-1", "api", OriginAWS))
+	assert.True(t, same)
+	assert.Equal(t, MatchKeyID, key)
+
+	same, key = SameInstance(named("i-1", "web", OriginTerraform), named("i-2", "web", OriginAWS))
+	assert.False(t, same)
+	assert.Equal(t, MatchKeyID, key)
+
+	// A configured instance has no ID yet, so it can only be matched by name
+	same, key = SameInstance(named("tf-aws_instance-web", "web", OriginTerraform), named("i-1", "web", OriginAWS))
+	assert.True(t, same)
+	assert.Equal(t, MatchKeyNameTag, key)
+
+	same, _ = SameInstance(named("tf-aws_instance-web", "web", OriginTerraform), named("i-1", "api", OriginAWS))
+	assert.False(t, same)
+
+	same, _ = SameInstance(named("tf-aws_instance-web", "", OriginTerraform), named("i-1", "", OriginAWS))
+	assert.False(t, same, "instances without a name can't be matched by it")
+}
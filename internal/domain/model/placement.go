@@ -0,0 +1,36 @@
+package model
+
+import "strings"
+
+// Placement attributes, compared at the top level where Terraform's aws_instance declares them
+const (
+	// AttributeTenancy is where the instance runs: default, dedicated or host
+	AttributeTenancy = "tenancy"
+
+	// AttributeHostID is the dedicated host the instance runs on
+	AttributeHostID = "host_id"
+
+	// AttributeAffinity is whether a stopped instance restarts on the same dedicated host (host)
+	// or any available one (default)
+	AttributeAffinity = "affinity"
+)
+
+// Instance tenancies
+const (
+	TenancyDefault   = "default"
+	TenancyDedicated = "dedicated"
+	TenancyHost      = "host"
+)
+
+// AffinityDefault is the affinity of an instance launched on a dedicated host without one
+const AffinityDefault = "default"
+
+// NormalizeTenancy maps the tenancy EC2 and Terraform report to a comparable value. Shared
+// tenancy is reported as default or not at all.
+func NormalizeTenancy(tenancy string) string {
+	tenancy = strings.ToLower(strings.TrimSpace(tenancy))
+	if tenancy == "" {
+		return TenancyDefault
+	}
+	return tenancy
+}
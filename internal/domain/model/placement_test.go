@@ -0,0 +1,48 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTenancy(t *testing.T) {
+	assert.Equal(t, TenancyDefault, NormalizeTenancy(""))
+	assert.Equal(t, TenancyHost, NormalizeTenancy(" Host "))
+	assert.Equal(t, TenancyDedicated, NormalizeTenancy("dedicated"))
+}
+
+func TestCompareAttributes_DedicatedHostMoved(t *testing.T) {
+	paths := []string{CanonicalAttribute("placement.host_id"), AttributeTenancy, AttributeAffinity}
+	source := NewInstance("i-1", map[string]interface{}{
+		AttributeTenancy:  TenancyHost,
+		AttributeHostID:   "h-0123456789abcdef0",
+		AttributeAffinity: AffinityDefault,
+	}, OriginTerraform)
+
+	// Same host
+	target := NewInstance("i-1", map[string]interface{}{
+		AttributeTenancy:  TenancyHost,
+		AttributeHostID:   "h-0123456789abcdef0",
+		AttributeAffinity: AffinityDefault,
+	}, OriginAWS)
+	assert.Empty(t, CompareAttributes(source, target, paths))
+
+	// Moved to another host and pinned to it
+	target = NewInstance("i-1", map[string]interface{}{
+		AttributeTenancy:  TenancyHost,
+		AttributeHostID:   "h-0fedcba9876543210",
+		AttributeAffinity: "host",
+	}, OriginAWS)
+	drifts := CompareAttributes(source, target, paths)
+	assert.Len(t, drifts, 2)
+	assert.Equal(t, "h-0123456789abcdef0", drifts[AttributeHostID].SourceValue)
+	assert.Equal(t, "h-0fedcba9876543210", drifts[AttributeHostID].TargetValue)
+	assert.Equal(t, "host", drifts[AttributeAffinity].TargetValue)
+
+	// Moved off the dedicated host onto shared tenancy
+	target = NewInstance("i-1", map[string]interface{}{AttributeTenancy: TenancyDefault}, OriginAWS)
+	drifts = CompareAttributes(source, target, paths)
+	assert.Len(t, drifts, 3)
+	assert.Nil(t, drifts[AttributeHostID].TargetValue)
+}
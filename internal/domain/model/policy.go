@@ -0,0 +1,210 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy operators
+const (
+	PolicyOperatorLessThan       = "lt"
+	PolicyOperatorLessOrEqual    = "lte"
+	PolicyOperatorGreaterThan    = "gt"
+	PolicyOperatorGreaterOrEqual = "gte"
+	PolicyOperatorEqual          = "eq"
+	PolicyOperatorNotEqual       = "ne"
+	PolicyOperatorIn             = "in"
+)
+
+// Derived attributes computed from other instance attributes before policies are evaluated
+const (
+	// AttributeLaunchTime is the instance launch time in RFC3339 format
+	AttributeLaunchTime = "launch_time"
+
+	// AttributeAgeDays is the number of whole days since the instance was launched
+	AttributeAgeDays = "age_days"
+)
+
+// Policy asserts that an attribute of the live AWS instance satisfies a condition, e.g.
+// {path: age_days, operator: lt, value: 90}. A failed assertion is a policy violation, not drift.
+type Policy struct {
+	Path     string      `json:"path" yaml:"path"`
+	Operator string      `json:"operator" yaml:"operator"`
+	Value    interface{} `json:"value" yaml:"value"`
+}
+
+// PolicyViolation describes a policy the instance does not satisfy
+type PolicyViolation struct {
+	Path     string      `json:"path"`
+	Operator string      `json:"operator"`
+	Expected interface{} `json:"expected"`
+	Actual   interface{} `json:"actual"`
+}
+
+// String returns a human-readable description of the violation
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("%s is %v, expected %s %v", v.Path, v.Actual, v.Operator, v.Expected)
+}
+
+// IsValidPolicyOperator reports whether the operator is supported
+func IsValidPolicyOperator(operator string) bool {
+	switch operator {
+	case PolicyOperatorLessThan, PolicyOperatorLessOrEqual,
+		PolicyOperatorGreaterThan, PolicyOperatorGreaterOrEqual,
+		PolicyOperatorEqual, PolicyOperatorNotEqual, PolicyOperatorIn:
+		return true
+	}
+	return false
+}
+
+// AllowedValuesPolicy returns a policy requiring the attribute to be one of the allowed values
+func AllowedValuesPolicy(path string, allowed []string) Policy {
+	return Policy{Path: path, Operator: PolicyOperatorIn, Value: allowed}
+}
+
+// derivedAttributes synthesizes computed attributes from an instance at a point in time
+var derivedAttributes = map[string]func(instance *Instance, now time.Time) (interface{}, bool){
+	AttributeAgeDays: ageDays,
+}
+
+// ageDays computes the number of whole days since the instance's launch time
+func ageDays(instance *Instance, now time.Time) (interface{}, bool) {
+	val, ok := instance.GetAttribute(AttributeLaunchTime)
+	if !ok {
+		return nil, false
+	}
+
+	var launched time.Time
+	switch v := val.(type) {
+	case time.Time:
+		launched = v
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, false
+		}
+		launched = t
+	default:
+		return nil, false
+	}
+
+	return int(now.Sub(launched).Hours() / 24), true
+}
+
+// policyAttribute returns the value of an attribute, computing derived attributes on demand
+func policyAttribute(instance *Instance, path string, now time.Time) (interface{}, bool) {
+	if derive, ok := derivedAttributes[path]; ok {
+		return derive(instance, now)
+	}
+	return instance.GetAttribute(path)
+}
+
+// EvaluatePolicies checks the instance against the policies and returns the violations.
+// Policies on attributes the instance doesn't have are skipped.
+func EvaluatePolicies(instance *Instance, policies []Policy, now time.Time) []PolicyViolation {
+	if instance == nil {
+		return nil
+	}
+
+	var violations []PolicyViolation
+	for _, policy := range policies {
+		actual, ok := policyAttribute(instance, policy.Path, now)
+		if !ok || actual == nil {
+			continue
+		}
+
+		if !satisfies(actual, policy.Operator, policy.Value) {
+			violations = append(violations, PolicyViolation{
+				Path:     policy.Path,
+				Operator: policy.Operator,
+				Expected: policy.Value,
+				Actual:   actual,
+			})
+		}
+	}
+
+	return violations
+}
+
+// satisfies reports whether "actual operator expected" holds. Values are compared numerically
+// when both are numbers, otherwise as strings.
+func satisfies(actual interface{}, operator string, expected interface{}) bool {
+	if operator == PolicyOperatorIn {
+		return contains(expected, actual)
+	}
+
+	a, aIsNum := toFloat(actual)
+	e, eIsNum := toFloat(expected)
+
+	var cmp int
+	if aIsNum && eIsNum {
+		switch {
+		case a < e:
+			cmp = -1
+		case a > e:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(fmt.Sprintf("%v", actual), fmt.Sprintf("%v", expected))
+	}
+
+	switch operator {
+	case PolicyOperatorLessThan:
+		return cmp < 0
+	case PolicyOperatorLessOrEqual:
+		return cmp <= 0
+	case PolicyOperatorGreaterThan:
+		return cmp > 0
+	case PolicyOperatorGreaterOrEqual:
+		return cmp >= 0
+	case PolicyOperatorEqual:
+		return cmp == 0
+	case PolicyOperatorNotEqual:
+		return cmp != 0
+	}
+	return true
+}
+
+// contains reports whether the list holds a value equal to val
+func contains(list interface{}, val interface{}) bool {
+	var items []interface{}
+	switch l := list.(type) {
+	case []string:
+		for _, item := range l {
+			items = append(items, item)
+		}
+	case []interface{}:
+		items = l
+	default:
+		items = []interface{}{l}
+	}
+
+	for _, item := range items {
+		if satisfies(val, PolicyOperatorEqual, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat converts numeric values, and strings holding numbers, to float64
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
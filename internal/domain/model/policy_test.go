@@ -0,0 +1,75 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluatePolicies_AgeDays(t *testing.T) {
+	now := time.Date(2024, 4, 22, 12, 0, 0, 0, time.UTC)
+	policies := []Policy{{Path: AttributeAgeDays, Operator: PolicyOperatorLessThan, Value: 90}}
+
+	fresh := NewInstance("i-fresh", map[string]interface{}{
+		AttributeLaunchTime: now.AddDate(0, 0, -10).Format(time.RFC3339),
+	}, OriginAWS)
+	assert.Empty(t, EvaluatePolicies(fresh, policies, now))
+
+	stale := NewInstance("i-stale", map[string]interface{}{
+		AttributeLaunchTime: now.AddDate(0, 0, -120).Format(time.RFC3339),
+	}, OriginAWS)
+	violations := EvaluatePolicies(stale, policies, now)
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, AttributeAgeDays, violations[0].Path)
+		assert.Equal(t, 120, violations[0].Actual)
+		assert.Equal(t, "age_days is 120, expected lt 90", violations[0].String())
+	}
+
+	// Instances without a launch time (e.g. Terraform) are not evaluated
+	assert.Empty(t, EvaluatePolicies(NewInstance("i-tf", nil, OriginTerraform), policies, now))
+}
+
+func TestEvaluatePolicies_Operators(t *testing.T) {
+	now := time.Now()
+	instance := NewInstance("i-1", map[string]interface{}{
+		"instance_type": "t2.micro",
+		"root_size":     int32(8),
+	}, OriginAWS)
+
+	tests := []struct {
+		policy   Policy
+		violates bool
+	}{
+		{Policy{Path: "root_size", Operator: PolicyOperatorGreaterOrEqual, Value: 8}, false},
+		{Policy{Path: "root_size", Operator: PolicyOperatorGreaterThan, Value: "8"}, true},
+		{Policy{Path: "root_size", Operator: PolicyOperatorLessOrEqual, Value: 8.0}, false},
+		{Policy{Path: "instance_type", Operator: PolicyOperatorEqual, Value: "t2.micro"}, false},
+		{Policy{Path: "instance_type", Operator: PolicyOperatorNotEqual, Value: "t2.micro"}, true},
+		{Policy{Path: "missing", Operator: PolicyOperatorEqual, Value: "x"}, false},
+	}
+
+	for _, tt := range tests {
+		violations := EvaluatePolicies(instance, []Policy{tt.policy}, now)
+		assert.Equal(t, tt.violates, len(violations) > 0, "%s %s %v", tt.policy.Path, tt.policy.Operator, tt.policy.Value)
+	}
+}
+
+func TestEvaluatePolicies_AllowedValues(t *testing.T) {
+	now := time.Now()
+	allowed := NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, OriginAWS)
+	disallowed := NewInstance("i-2", map[string]interface{}{"instance_type": "m5.4xlarge"}, OriginAWS)
+
+	policy := AllowedValuesPolicy("instance_type", []string{"t3.micro", "t3.small"})
+	assert.Empty(t, EvaluatePolicies(allowed, []Policy{policy}, now))
+
+	violations := EvaluatePolicies(disallowed, []Policy{policy}, now)
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, "instance_type is m5.4xlarge, expected in [t3.micro t3.small]", violations[0].String())
+	}
+
+	// Lists decoded from YAML config are []interface{}
+	fromConfig := Policy{Path: "instance_type", Operator: PolicyOperatorIn, Value: []interface{}{"t3.micro"}}
+	assert.Empty(t, EvaluatePolicies(allowed, []Policy{fromConfig}, now))
+	assert.Len(t, EvaluatePolicies(disallowed, []Policy{fromConfig}, now), 1)
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// ComparedDigest returns a SHA256 of the values comparison reads from an instance at the given
+// paths, including whether each path is present. Two instances with the same digest have equal
+// values at every path, so comparing them attribute by attribute can only find them equal. The
+// digest is empty when a value can't be hashed, which never matches.
+func ComparedDigest(instance *Instance, attributePaths []string) string {
+	paths := append([]string(nil), attributePaths...)
+	sort.Strings(paths)
+
+	hash := sha256.New()
+	encoder := json.NewEncoder(hash)
+	for _, path := range paths {
+		value, found := instance.GetAttribute(path)
+
+		// Maps encode with sorted keys, so equal values always hash the same
+		if err := encoder.Encode([]interface{}{path, found, value}); err != nil {
+			return ""
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// SameComparedValues reports whether two instances hash the same at the given paths, in which
+// case none of those attributes drifted
+func SameComparedValues(source, target *Instance, attributePaths []string) bool {
+	sourceDigest := ComparedDigest(source, attributePaths)
+	return sourceDigest != "" && sourceDigest == ComparedDigest(target, attributePaths)
+}
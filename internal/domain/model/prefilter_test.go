@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameComparedValues(t *testing.T) {
+	paths := []string{"tags", "instance_type", "subnet_id"}
+	terraform := NewInstance("i-1", map[string]interface{}{
+		"instance_type": "t3.micro",
+		"tags":          map[string]interface{}{"Name": "web", "Team": "core"},
+		"ami":           "ami-1",
+	}, OriginTerraform)
+
+	// Origins, uncompared attributes and map order don't matter
+	aws := NewInstance("i-1", map[string]interface{}{
+		"instance_type": "t3.micro",
+		"tags":          map[string]interface{}{"Team": "core", "Name": "web"},
+		"ami":           "ami-2",
+	}, OriginAWS)
+	assert.True(t, SameComparedValues(terraform, aws, paths))
+	assert.False(t, SameComparedValues(terraform, aws, append(paths, "ami")))
+
+	// Path order doesn't matter either
+	assert.Equal(t, ComparedDigest(terraform, paths), ComparedDigest(terraform, []string{"subnet_id", "tags", "instance_type"}))
+
+	// An empty value differs from an absent one
+	aws.Attributes["subnet_id"] = ""
+	assert.False(t, SameComparedValues(terraform, aws, paths))
+
+	// Values that can't be hashed never match
+	terraform.Attributes["subnet_id"] = func() {}
+	aws.Attributes["subnet_id"] = func() {}
+	assert.Empty(t, ComparedDigest(terraform, paths))
+	assert.False(t, SameComparedValues(terraform, aws, paths))
+}
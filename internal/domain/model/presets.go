@@ -0,0 +1,30 @@
+package model
+
+import "sort"
+
+// attributePresets are named bundles of attribute paths, selected with --attributes-preset
+// instead of listing the paths one by one
+var attributePresets = map[string][]string{
+	"security": {"vpc_security_group_ids", "metadata_options", "iam_instance_profile", AttributeAssociatePublicIPAddress},
+	"compute":  {"instance_type", "ami", "ebs_optimized", AttributeRootBlockDevice, AttributeEBSBlockDevice},
+	"tags":     {"tags"},
+}
+
+// AttributePreset returns the attribute paths of a built-in preset
+func AttributePreset(name string) ([]string, bool) {
+	paths, ok := attributePresets[name]
+	if !ok {
+		return nil, false
+	}
+	return append([]string(nil), paths...), true
+}
+
+// AttributePresetNames returns the names of the built-in presets, sorted
+func AttributePresetNames() []string {
+	names := make([]string, 0, len(attributePresets))
+	for name := range attributePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributePreset(t *testing.T) {
+	paths, ok := AttributePreset("security")
+	require.True(t, ok)
+	require.Equal(t, []string{"vpc_security_group_ids", "metadata_options", "iam_instance_profile", "associate_public_ip_address"}, paths)
+
+	// Callers get their own copy
+	paths[0] = "changed"
+	paths, _ = AttributePreset("security")
+	require.Equal(t, "vpc_security_group_ids", paths[0])
+
+	_, ok = AttributePreset("storage")
+	require.False(t, ok)
+
+	require.Equal(t, []string{"compute", "security", "tags"}, AttributePresetNames())
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AttributeSecondaryPrivateIPs are the private IPv4 addresses of the instance's primary network
+// interface other than its primary address, as aws_instance declares them. Addresses added to or
+// removed from the interface outside Terraform drift here.
+const AttributeSecondaryPrivateIPs = "secondary_private_ips"
+
+// unorderedAttributes are list attributes compared as sets: AWS returns their elements in no
+// particular order and Terraform keeps them as sets
+var unorderedAttributes = map[string]bool{
+	AttributeSecondaryPrivateIPs: true,
+}
+
+// SecondaryPrivateIPs returns the addresses of a network interface that aren't its primary
+// address, sorted
+func SecondaryPrivateIPs(addresses []string, primary string) []string {
+	secondary := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if address != primary {
+			secondary = append(secondary, address)
+		}
+	}
+	sort.Strings(secondary)
+	return secondary
+}
+
+// sameSet reports whether two lists hold the same elements regardless of order and
+// duplicates. ok is false when either value isn't a list.
+func sameSet(a, b interface{}) (equal bool, ok bool) {
+	setA, okA := elementSet(a)
+	setB, okB := elementSet(b)
+	if !okA || !okB {
+		return false, false
+	}
+	if len(setA) != len(setB) {
+		return false, true
+	}
+	for element := range setA {
+		if !setB[element] {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// elementSet returns the string forms of the elements of a list
+func elementSet(value interface{}) (map[string]bool, bool) {
+	switch list := value.(type) {
+	case []string:
+		set := make(map[string]bool, len(list))
+		for _, element := range list {
+			set[element] = true
+		}
+		return set, true
+	case []interface{}:
+		set := make(map[string]bool, len(list))
+		for _, element := range list {
+			set[fmt.Sprintf("%v", element)] = true
+		}
+		return set, true
+	}
+	return nil, false
+}
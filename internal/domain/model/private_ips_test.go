@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecondaryPrivateIPs(t *testing.T) {
+	assert.Equal(t, []string{"10.0.1.11", "10.0.1.12"}, SecondaryPrivateIPs([]string{"10.0.1.12", "10.0.1.10", "10.0.1.11"}, "10.0.1.10"))
+	assert.Empty(t, SecondaryPrivateIPs([]string{"10.0.1.10"}, "10.0.1.10"))
+}
+
+func TestCompareAttributes_SecondaryPrivateIPs(t *testing.T) {
+	paths := []string{AttributeSecondaryPrivateIPs}
+	source := NewInstance("i-1", map[string]interface{}{
+		AttributeSecondaryPrivateIPs: []interface{}{"10.0.1.12", "10.0.1.11"},
+	}, OriginTerraform)
+	compare := func(addresses ...string) map[string]AttributeDrift {
+		target := NewInstance("i-1", map[string]interface{}{AttributeSecondaryPrivateIPs: addresses}, OriginAWS)
+		return CompareAttributes(source, target, paths)
+	}
+
+	// The order AWS lists the addresses in doesn't matter
+	assert.Empty(t, compare("10.0.1.11", "10.0.1.12"))
+
+	// An address assigned outside Terraform
+	assert.Contains(t, compare("10.0.1.11", "10.0.1.12", "10.0.1.13"), AttributeSecondaryPrivateIPs)
+
+	// An address unassigned outside Terraform
+	assert.Contains(t, compare("10.0.1.11"), AttributeSecondaryPrivateIPs)
+}
@@ -0,0 +1,34 @@
+package model
+
+// Public addressing attributes
+const (
+	// AttributeAssociatePublicIPAddress is whether the instance was launched with a public IPv4
+	// address, as aws_instance declares it. AWS doesn't report the setting itself, so it is
+	// derived from the address the primary network interface holds.
+	AttributeAssociatePublicIPAddress = "associate_public_ip_address"
+
+	// AttributeHasPublicIP is whether the instance currently has a public IPv4 address of any
+	// kind, including an Elastic IP
+	AttributeHasPublicIP = "has_public_ip"
+)
+
+// PublicIPOwnerAmazon is the owner EC2 reports for public addresses it assigned at launch, as
+// opposed to the account that owns an associated Elastic IP
+const PublicIPOwnerAmazon = "amazon"
+
+// PublicIPIntent returns the associate_public_ip_address an instance was evidently launched
+// with. publicIP is the instance's public address and ipOwner the owner of the address on its
+// primary network interface, when known. An instance that isn't running without a public
+// address may have released the one it was launched with, so its intent is unknown.
+func PublicIPIntent(publicIP, ipOwner, state string) interface{} {
+	if ipOwner != "" {
+		return ipOwner == PublicIPOwnerAmazon
+	}
+	if publicIP != "" {
+		return true
+	}
+	if state != "" && state != InstanceStateRunning {
+		return UnknownValue{Reason: "public IP released while the instance is not running"}
+	}
+	return false
+}
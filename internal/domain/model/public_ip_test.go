@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicIPIntent(t *testing.T) {
+	// An address EC2 assigned at launch
+	assert.Equal(t, true, PublicIPIntent("203.0.113.10", PublicIPOwnerAmazon, InstanceStateRunning))
+	assert.Equal(t, true, PublicIPIntent("203.0.113.10", "", InstanceStateRunning))
+
+	// An Elastic IP is associated separately from associate_public_ip_address
+	assert.Equal(t, false, PublicIPIntent("198.51.100.7", "123456789012", InstanceStateRunning))
+	assert.Equal(t, false, PublicIPIntent("198.51.100.7", "123456789012", InstanceStateStopped))
+
+	assert.Equal(t, false, PublicIPIntent("", "", InstanceStateRunning))
+
+	// A stopped instance has released the address it may have been launched with
+	assert.True(t, IsUnknown(PublicIPIntent("", "", InstanceStateStopped)))
+}
+
+func TestCompareAttributes_PublicIPOfStoppedInstance(t *testing.T) {
+	paths := []string{CanonicalAttribute("has_public_ip")}
+	source := NewInstance("i-1", map[string]interface{}{AttributeAssociatePublicIPAddress: true}, OriginTerraform)
+
+	running := NewInstance("i-1", map[string]interface{}{
+		AttributeAssociatePublicIPAddress: PublicIPIntent("", "", InstanceStateRunning),
+	}, OriginAWS)
+	assert.Contains(t, CompareAttributes(source, running, paths), AttributeAssociatePublicIPAddress)
+
+	stopped := NewInstance("i-1", map[string]interface{}{
+		AttributeAssociatePublicIPAddress: PublicIPIntent("", "", InstanceStateStopped),
+	}, OriginAWS)
+	assert.Empty(t, CompareAttributes(source, stopped, paths))
+	assert.Contains(t, UnknownAttributes(source, stopped, paths), AttributeAssociatePublicIPAddress)
+}
@@ -0,0 +1,313 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RemediationStep is a suggested step towards resolving an instance's drift: a command to run,
+// a configuration snippet to apply, or both. Steps are only suggestions; nothing runs them.
+type RemediationStep struct {
+	Description string `json:"description"`
+	Command     string `json:"command,omitempty"`
+	Snippet     string `json:"snippet,omitempty"`
+}
+
+// SuggestRemediation returns the steps that bring the side that isn't the source of truth in
+// line with it. When Terraform is the truth, tag-only drift is fixed with the AWS CLI and any
+// other drift with a targeted apply; when AWS is the truth, the configuration is updated with
+// the live values. Either instance may be nil when it exists on one side only.
+func SuggestRemediation(result *DriftResult, terraform, aws *Instance) []RemediationStep {
+	if result == nil || !result.HasDrift {
+		return nil
+	}
+
+	if result.SourceType == OriginAWS {
+		return suggestConfigurationUpdate(result, terraform, aws)
+	}
+	return suggestApply(result, terraform, aws)
+}
+
+// suggestApply restores AWS from the Terraform configuration
+func suggestApply(result *DriftResult, terraform, aws *Instance) []RemediationStep {
+	address := result.ResourceAddress
+
+	if terraform == nil {
+		return []RemediationStep{{
+			Description: fmt.Sprintf("Instance %s is not managed by Terraform; import it into the configuration (replace NAME) or terminate it", result.ResourceID),
+			Snippet:     importBlock(resourceLabel(address), result.ResourceID),
+		}}
+	}
+
+	if aws != nil && onlyTagsDrifted(result) {
+		if steps := suggestTagCommands(result, terraform, aws); len(steps) > 0 {
+			return steps
+		}
+	}
+
+	description := fmt.Sprintf("Review and apply the Terraform configuration of %s", address)
+	if aws == nil {
+		description = fmt.Sprintf("Instance %s no longer exists in AWS; applying %s recreates it", result.ResourceID, address)
+	}
+	if address == "" {
+		description = fmt.Sprintf("The Terraform address of instance %s is unknown; review and apply the whole configuration", result.ResourceID)
+	}
+
+	return []RemediationStep{
+		{Description: description, Command: "terraform plan" + targetFlag(address)},
+		{Description: "Apply the planned changes", Command: "terraform apply" + targetFlag(address)},
+	}
+}
+
+// suggestConfigurationUpdate updates the Terraform configuration from AWS
+func suggestConfigurationUpdate(result *DriftResult, terraform, aws *Instance) []RemediationStep {
+	address := result.ResourceAddress
+
+	if terraform == nil {
+		return []RemediationStep{{
+			Description: fmt.Sprintf("Instance %s is not in Terraform; add it to the configuration (replace NAME) and import it", result.ResourceID),
+			Snippet:     importBlock(resourceLabel(address), result.ResourceID),
+		}}
+	}
+	if aws == nil {
+		if address == "" {
+			address = resourceLabel(address)
+		}
+		return []RemediationStep{{
+			Description: fmt.Sprintf("Instance %s no longer exists in AWS; remove it from the configuration and the state", result.ResourceID),
+			Command:     "terraform state rm " + shellQuote(address),
+		}}
+	}
+
+	// Dotted paths are updated through their top-level attribute, e.g. tags.Name through tags
+	roots := make(map[string]bool)
+	for path := range result.DriftedAttributes {
+		if path == AttributeExists || path == AttributeTerminationProtection {
+			continue
+		}
+		roots[rootAttribute(path)] = true
+	}
+
+	var body strings.Builder
+	for _, attribute := range sortedKeys(roots) {
+		value, ok := aws.Attributes[attribute]
+		if !ok || value == nil {
+			fmt.Fprintf(&body, "  # %s is not set in AWS; remove it\n", attribute)
+			continue
+		}
+		fmt.Fprintf(&body, "  %s = %s\n", attribute, hclValue(value, "  "))
+	}
+
+	// Termination protection is matched by the lifecycle meta-argument rather than an attribute
+	if drift, ok := result.DriftedAttributes[AttributeTerminationProtection]; ok && drift.Protection != nil {
+		fmt.Fprintf(&body, "  lifecycle {\n    prevent_destroy = %v\n  }\n", drift.Protection.DisableAPITermination)
+	}
+
+	name := resourceLabel(address)
+	return []RemediationStep{
+		{
+			Description: fmt.Sprintf("Update %s in the Terraform configuration with the values in AWS", name),
+			Snippet:     fmt.Sprintf("resource %q %q {\n%s}", "aws_instance", strings.TrimPrefix(name, "aws_instance."), body.String()),
+		},
+		{Description: "Confirm that Terraform no longer plans changes", Command: "terraform plan" + targetFlag(address)},
+	}
+}
+
+// suggestTagCommands returns the AWS CLI commands that set the tags Terraform declares and
+// remove the ones it doesn't. Only the drifted tags are touched.
+func suggestTagCommands(result *DriftResult, terraform, aws *Instance) []RemediationStep {
+	want := stringMap(terraform.Attributes["tags"])
+	have := stringMap(aws.Attributes["tags"])
+
+	keys := make(map[string]bool)
+	for path := range result.DriftedAttributes {
+		if key := strings.TrimPrefix(path, "tags."); key != path {
+			keys[key] = true
+			continue
+		}
+		for key := range want {
+			keys[key] = true
+		}
+		for key := range have {
+			keys[key] = true
+		}
+	}
+
+	var create, remove []string
+	for _, key := range sortedKeys(keys) {
+		value, declared := want[key]
+		current, live := have[key]
+		switch {
+		case declared && (!live || current != value):
+			create = append(create, shellQuote(shorthand("Key", key)+","+shorthand("Value", value)))
+		case !declared && live:
+			remove = append(remove, shellQuote(shorthand("Key", key)))
+		}
+	}
+
+	var steps []RemediationStep
+	if len(create) > 0 {
+		steps = append(steps, RemediationStep{
+			Description: "Set the tags declared in Terraform",
+			Command:     fmt.Sprintf("aws ec2 create-tags --resources %s --tags %s", result.ResourceID, strings.Join(create, " ")),
+		})
+	}
+	if len(remove) > 0 {
+		steps = append(steps, RemediationStep{
+			Description: "Remove the tags Terraform doesn't declare",
+			Command:     fmt.Sprintf("aws ec2 delete-tags --resources %s --tags %s", result.ResourceID, strings.Join(remove, " ")),
+		})
+	}
+	return steps
+}
+
+// onlyTagsDrifted reports whether tags are the only drifted attributes
+func onlyTagsDrifted(result *DriftResult) bool {
+	for path := range result.DriftedAttributes {
+		if !isTagPath(path) {
+			return false
+		}
+	}
+	return len(result.DriftedAttributes) > 0
+}
+
+// importBlock returns a Terraform import block for an instance
+func importBlock(to, id string) string {
+	return fmt.Sprintf("import {\n  to = %s\n  id = %q\n}", to, id)
+}
+
+// resourceLabel returns the resource's address without module path and index, or a
+// placeholder when the address is unknown
+func resourceLabel(address string) string {
+	if address == "" {
+		return "aws_instance.NAME"
+	}
+	if i := strings.LastIndex(address, "aws_instance."); i >= 0 {
+		address = address[i:]
+	}
+	if i := strings.Index(address, "["); i >= 0 {
+		address = address[:i]
+	}
+	return address
+}
+
+// targetFlag returns the -target option of a resource, or nothing when its address is unknown
+func targetFlag(address string) string {
+	if address == "" {
+		return ""
+	}
+	return " -target=" + shellQuote(address)
+}
+
+// rootAttribute returns the top-level attribute of a path
+func rootAttribute(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// stringMap returns a map of strings, such as tags, from either form attributes hold them in
+func stringMap(value interface{}) map[string]string {
+	switch v := value.(type) {
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		values := make(map[string]string, len(v))
+		for key, item := range v {
+			if item != nil {
+				values[key] = fmt.Sprintf("%v", item)
+			}
+		}
+		return values
+	}
+	return map[string]string{}
+}
+
+// shorthand formats a field of the AWS CLI shorthand syntax, double quoting values that would
+// otherwise be split
+func shorthand(field, value string) string {
+	if strings.ContainsAny(value, `,=[]{}"' `) || value == "" {
+		value = `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	}
+	return field + "=" + value
+}
+
+// shellQuote single quotes an argument when the shell would otherwise interpret it
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,@", r))
+	}) < 0 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// hclValue renders a value as an HCL expression, nesting maps at the given indentation
+func hclValue(value interface{}, indent string) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case bool, int, int32, int64, float64:
+		return fmt.Sprintf("%v", v)
+	case []string:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, strconv.Quote(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, hclValue(item, indent))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []map[string]interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, hclValue(item, indent))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]string:
+		values := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			values[key] = item
+		}
+		return hclValue(values, indent)
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return "{}"
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s  %s = %s\n", indent, hclKey(key), hclValue(v[key], indent+"  "))
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	}
+	return strconv.Quote(fmt.Sprintf("%v", value))
+}
+
+// hclKey quotes map keys that aren't valid identifiers
+func hclKey(key string) string {
+	for i, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || i > 0 && (r >= '0' && r <= '9' || r == '-')) {
+			return strconv.Quote(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func remediationResult(sourceType ResourceOrigin, drifts map[string]AttributeDrift) *DriftResult {
+	result := NewDriftResult("i-1", sourceType)
+	result.ResourceAddress = `module.app.aws_instance.web["blue"]`
+	result.SetDriftedAttributes(drifts)
+	return result
+}
+
+func TestSuggestRemediation_TerraformApply(t *testing.T) {
+	terraform := NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, OriginTerraform)
+	aws := NewInstance("i-1", map[string]interface{}{"instance_type": "t3.large"}, OriginAWS)
+	result := remediationResult(OriginTerraform, map[string]AttributeDrift{"instance_type": {Path: "instance_type"}})
+
+	steps := SuggestRemediation(result, terraform, aws)
+	require.Len(t, steps, 2)
+	assert.Equal(t, `terraform plan -target='module.app.aws_instance.web["blue"]'`, steps[0].Command)
+	assert.Equal(t, `terraform apply -target='module.app.aws_instance.web["blue"]'`, steps[1].Command)
+
+	// Without an address the whole configuration is applied
+	result.ResourceAddress = ""
+	steps = SuggestRemediation(result, terraform, aws)
+	assert.Equal(t, "terraform plan", steps[0].Command)
+
+	// Nothing to do without drift
+	assert.Empty(t, SuggestRemediation(NewDriftResult("i-1", OriginTerraform), terraform, aws))
+}
+
+func TestSuggestRemediation_TagCommands(t *testing.T) {
+	terraform := NewInstance("i-1", map[string]interface{}{"tags": map[string]interface{}{"Name": "web", "Env": "prod", "Owner": "team a"}}, OriginTerraform)
+	aws := NewInstance("i-1", map[string]interface{}{"tags": map[string]string{"Name": "web", "Env": "dev", "Temp": "yes"}}, OriginAWS)
+
+	result := remediationResult(OriginTerraform, map[string]AttributeDrift{"tags": {Path: "tags"}})
+	steps := SuggestRemediation(result, terraform, aws)
+	require.Len(t, steps, 2)
+	assert.Equal(t, `aws ec2 create-tags --resources i-1 --tags Key=Env,Value=prod 'Key=Owner,Value="team a"'`, steps[0].Command)
+	assert.Equal(t, "aws ec2 delete-tags --resources i-1 --tags Key=Temp", steps[1].Command)
+
+	// Only the drifted tag is touched
+	result = remediationResult(OriginTerraform, map[string]AttributeDrift{"tags.Env": {Path: "tags.Env"}})
+	steps = SuggestRemediation(result, terraform, aws)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "aws ec2 create-tags --resources i-1 --tags Key=Env,Value=prod", steps[0].Command)
+}
+
+func TestSuggestRemediation_ConfigurationUpdate(t *testing.T) {
+	terraform := NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, OriginTerraform)
+	aws := NewInstance("i-1", map[string]interface{}{
+		"instance_type":          "t3.large",
+		"vpc_security_group_ids": []string{"sg-1", "sg-2"},
+		"tags":                   map[string]string{"Name": "web", "cost-center": "42"},
+	}, OriginAWS)
+	result := remediationResult(OriginAWS, map[string]AttributeDrift{
+		"instance_type":          {Path: "instance_type"},
+		"vpc_security_group_ids": {Path: "vpc_security_group_ids"},
+		"tags.Name":              {Path: "tags.Name"},
+		"key_name":               {Path: "key_name"},
+	})
+
+	steps := SuggestRemediation(result, terraform, aws)
+	require.Len(t, steps, 2)
+	assert.Equal(t, `resource "aws_instance" "web" {
+  instance_type = "t3.large"
+  # key_name is not set in AWS; remove it
+  tags = {
+    Name = "web"
+    cost-center = "42"
+  }
+  vpc_security_group_ids = ["sg-1", "sg-2"]
+}`, steps[0].Snippet)
+	assert.Equal(t, `terraform plan -target='module.app.aws_instance.web["blue"]'`, steps[1].Command)
+}
+
+func TestSuggestRemediation_OneSided(t *testing.T) {
+	terraform := NewInstance("i-1", map[string]interface{}{}, OriginTerraform)
+	aws := NewInstance("i-1", map[string]interface{}{}, OriginAWS)
+	exists := map[string]AttributeDrift{AttributeExists: {Path: AttributeExists}}
+
+	// Only in AWS
+	result := remediationResult(OriginTerraform, exists)
+	result.ResourceAddress = ""
+	steps := SuggestRemediation(result, nil, aws)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "import {\n  to = aws_instance.NAME\n  id = \"i-1\"\n}", steps[0].Snippet)
+
+	// Only in Terraform
+	result = remediationResult(OriginTerraform, exists)
+	steps = SuggestRemediation(result, terraform, nil)
+	assert.Equal(t, `terraform apply -target='module.app.aws_instance.web["blue"]'`, steps[1].Command)
+
+	result = remediationResult(OriginAWS, exists)
+	steps = SuggestRemediation(result, terraform, nil)
+	require.Len(t, steps, 1)
+	assert.Equal(t, `terraform state rm 'module.app.aws_instance.web["blue"]'`, steps[0].Command)
+}
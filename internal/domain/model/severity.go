@@ -0,0 +1,77 @@
+package model
+
+import "strings"
+
+// Drift severities, from the most to the least severe. SeverityHigh is drift that changes access
+// to the instance or what it runs; tag drift is low and any other drift medium.
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// attributeSeverities rates attributes whose drift changes access to the instance or what it
+// runs as high, and tag drift as low. Any other attribute is medium.
+var attributeSeverities = map[string]string{
+	"vpc_security_group_ids":      SeverityHigh,
+	"security_groups":             SeverityHigh,
+	"iam_instance_profile":        SeverityHigh,
+	"ami":                         SeverityHigh,
+	"associate_public_ip_address": SeverityHigh,
+	"public_ip":                   SeverityHigh,
+	"key_name":                    SeverityHigh,
+	"metadata_options":            SeverityHigh,
+	"user_data":                   SeverityHigh,
+	"tags":                        SeverityLow,
+	"tags_all":                    SeverityLow,
+}
+
+// severityRanks orders the severities; unknown severities rank 0
+var severityRanks = map[string]int{
+	SeverityLow:    1,
+	SeverityMedium: 2,
+	SeverityHigh:   3,
+}
+
+// IsSeverity reports whether severity is high, medium or low
+func IsSeverity(severity string) bool {
+	_, ok := severityRanks[severity]
+	return ok
+}
+
+// SeverityAtLeast reports whether severity is as severe as threshold or more
+func SeverityAtLeast(severity, threshold string) bool {
+	return IsSeverity(severity) && severityRanks[severity] >= severityRanks[threshold]
+}
+
+// DriftSeverity rates drift of an attribute path by its top-level attribute
+func DriftSeverity(path string) string {
+	root := path
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		root = path[:i]
+	}
+	if severity, ok := attributeSeverities[root]; ok {
+		return severity
+	}
+	return SeverityMedium
+}
+
+// AttributeSeverity rates a drifted attribute: the severity the drift carries, e.g. high for a
+// subnet move into another VPC, or else the severity of its path
+func AttributeSeverity(path string, drift AttributeDrift) string {
+	if drift.Severity != "" {
+		return drift.Severity
+	}
+	return DriftSeverity(path)
+}
+
+// HighestSeverity returns the severity of the most severe drifted attribute, or "" without drift
+func (r *DriftResult) HighestSeverity() string {
+	highest := ""
+	for path, drift := range r.DriftedAttributes {
+		if severity := AttributeSeverity(path, drift); severityRanks[severity] > severityRanks[highest] {
+			highest = severity
+		}
+	}
+	return highest
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriftSeverity(t *testing.T) {
+	assert.Equal(t, SeverityHigh, DriftSeverity("vpc_security_group_ids"))
+	assert.Equal(t, SeverityHigh, DriftSeverity("metadata_options.http_tokens"))
+	assert.Equal(t, SeverityLow, DriftSeverity("tags.Name"))
+	assert.Equal(t, SeverityMedium, DriftSeverity("ebs_block_device[0].volume_size"))
+	assert.Equal(t, SeverityMedium, DriftSeverity("instance_type"))
+
+	// Drifts rate themselves when they carry a severity, e.g. a subnet move into another VPC
+	assert.Equal(t, SeverityHigh, AttributeSeverity("ami", AttributeDrift{}))
+	assert.Equal(t, SeverityLow, AttributeSeverity("tags.Name", AttributeDrift{}))
+	assert.Equal(t, SeverityHigh, AttributeSeverity("subnet_id", AttributeDrift{Severity: SeverityHigh}))
+}
+
+func TestDriftResult_HighestSeverity(t *testing.T) {
+	result := NewDriftResult("i-1", OriginTerraform)
+	assert.Equal(t, "", result.HighestSeverity())
+
+	result.AddDriftedAttribute("tags.Name", "web", "web-old")
+	assert.Equal(t, SeverityLow, result.HighestSeverity())
+
+	result.AddDriftedAttribute("instance_type", "t2.micro", "t2.large")
+	result.AddDriftedAttribute("ami", "ami-1", "ami-2")
+	assert.Equal(t, SeverityHigh, result.HighestSeverity())
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	assert.True(t, SeverityAtLeast(SeverityHigh, SeverityMedium))
+	assert.True(t, SeverityAtLeast(SeverityMedium, SeverityMedium))
+	assert.False(t, SeverityAtLeast(SeverityLow, SeverityMedium))
+	assert.False(t, SeverityAtLeast("", SeverityLow))
+	assert.False(t, IsSeverity("critical"))
+}
@@ -0,0 +1,42 @@
+package model
+
+import "github.com/victor-devv/ec2-drift-detector/pkg/comparator"
+
+// InstanceSnapshot is the full set of attributes an instance was compared with, for debugging
+// a drift beyond the attributes that differ
+type InstanceSnapshot struct {
+	Origin     ResourceOrigin         `json:"origin"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// NewInstanceSnapshot captures the attributes of an instance as JSON-safe values, each capped at
+// comparator.DefaultMaxValueBytes. A missing instance has no snapshot.
+func NewInstanceSnapshot(instance *Instance) *InstanceSnapshot {
+	if instance == nil {
+		return nil
+	}
+	attributes := make(map[string]interface{}, len(instance.Attributes))
+	for key, value := range instance.Attributes {
+		attributes[key], _ = comparator.Sanitize(value, 0)
+	}
+	return &InstanceSnapshot{Origin: instance.Origin, Attributes: attributes}
+}
+
+// SetSnapshots captures the attributes of the compared instances; either may be nil when the
+// instance only exists on one side
+func (r *DriftResult) SetSnapshots(source, target *Instance) {
+	r.SourceSnapshot = NewInstanceSnapshot(source)
+	r.TargetSnapshot = NewInstanceSnapshot(target)
+}
+
+// WithoutSnapshots returns a copy of the result without instance snapshots, or the result itself
+// when it has none
+func (r *DriftResult) WithoutSnapshots() *DriftResult {
+	if r.SourceSnapshot == nil && r.TargetSnapshot == nil {
+		return r
+	}
+	stripped := *r
+	stripped.SourceSnapshot = nil
+	stripped.TargetSnapshot = nil
+	return &stripped
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// RunSummary describes the outcome of a single drift detection run
+type RunSummary struct {
+	StartedAt            time.Time `json:"started_at"`
+	FinishedAt           time.Time `json:"finished_at"`
+	TotalInstances       int       `json:"total_instances"`
+	DriftedCount         int       `json:"drifted_count"`
+	DriftedAttributes    int       `json:"drifted_attributes"`
+	PolicyViolationCount int       `json:"policy_violation_count"`
+
+	// UnmanagedCount is the number of instances in AWS no Terraform resource manages. They are
+	// left out of DriftedCount and DriftedAttributes.
+	UnmanagedCount int `json:"unmanaged_count"`
+
+	// Concurrency is the number of instances checked in parallel
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// TerraformVersion is the Terraform version that wrote the state compared, when known
+	TerraformVersion string `json:"terraform_version,omitempty"`
+
+	// FetchTimings is how long each provider took to list its instances, when the run got that far
+	FetchTimings *FetchTimings `json:"fetch_timings,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// FetchTimings is how long the AWS listing and the Terraform state parse each took in a run.
+// When providers are streamed, each covers the provider's whole stream, including time spent
+// waiting for free workers.
+type FetchTimings struct {
+	AWS       time.Duration `json:"aws"`
+	Terraform time.Duration `json:"terraform"`
+}
+
+// String describes the timings, e.g. "AWS listed in 1.2s, Terraform parsed in 300ms"
+func (t FetchTimings) String() string {
+	return fmt.Sprintf("AWS listed in %s, Terraform parsed in %s", t.AWS.Round(time.Millisecond), t.Terraform.Round(time.Millisecond))
+}
+
+// NewRunSummary summarizes the results of a run between startedAt and finishedAt
+func NewRunSummary(startedAt, finishedAt time.Time, results []*DriftResult, err error) *RunSummary {
+	summary := &RunSummary{
+		StartedAt:      startedAt,
+		FinishedAt:     finishedAt,
+		TotalInstances: len(results),
+	}
+
+	for _, result := range results {
+		if result.Unmanaged {
+			summary.UnmanagedCount++
+		} else if result.HasDrift {
+			summary.DriftedCount++
+			summary.DriftedAttributes += len(result.DriftedAttributes)
+		}
+		if result.HasPolicyViolations() {
+			summary.PolicyViolationCount++
+		}
+	}
+
+	if err != nil {
+		summary.Error = err.Error()
+	}
+
+	return summary
+}
+
+// Succeeded reports whether the run completed without an error
+func (s *RunSummary) Succeeded() bool {
+	return s.Error == ""
+}
+
+// HasFindings reports whether the run found drift, unmanaged instances or policy violations
+func (s *RunSummary) HasFindings() bool {
+	return s.DriftedCount > 0 || s.UnmanagedCount > 0 || s.PolicyViolationCount > 0
+}
+
+// String returns a one-line summary of the run
+func (s *RunSummary) String() string {
+	line := fmt.Sprintf("Drift check finished in %s: %d instances checked, %d drifted, %d with policy violations",
+		s.FinishedAt.Sub(s.StartedAt).Round(time.Millisecond), s.TotalInstances, s.DriftedCount, s.PolicyViolationCount)
+	if s.UnmanagedCount > 0 {
+		line += fmt.Sprintf(", %d unmanaged", s.UnmanagedCount)
+	}
+	if s.Concurrency > 0 {
+		line += fmt.Sprintf(", %d parallel checks", s.Concurrency)
+	}
+	if s.TerraformVersion != "" {
+		line += fmt.Sprintf(", state written by Terraform %s", s.TerraformVersion)
+	}
+	if s.FetchTimings != nil {
+		line += ", " + s.FetchTimings.String()
+	}
+	if s.Error != "" {
+		line += fmt.Sprintf(" (error: %s)", s.Error)
+	}
+	return line
+}
+
+// SummaryLine returns a terse final line for CI logs, e.g.
+// "DRIFT: 12/200 instances drifted (36 attributes), 3 unmanaged"
+func (s *RunSummary) SummaryLine() string {
+	status := "DRIFT"
+	if s.DriftedCount == 0 && s.UnmanagedCount == 0 {
+		status = "NO DRIFT"
+	}
+
+	attributes := "attributes"
+	if s.DriftedAttributes == 1 {
+		attributes = "attribute"
+	}
+
+	line := fmt.Sprintf("%s: %d/%d instances drifted (%d %s)", status, s.DriftedCount, s.TotalInstances, s.DriftedAttributes, attributes)
+	if s.UnmanagedCount > 0 {
+		line += fmt.Sprintf(", %d unmanaged", s.UnmanagedCount)
+	}
+	if s.PolicyViolationCount > 0 {
+		line += fmt.Sprintf(", %d with policy violations", s.PolicyViolationCount)
+	}
+	if s.Error != "" {
+		line += " [run failed, counts are partial]"
+	}
+	return line
+}
+
+// SchedulerStatus describes the state of the drift check scheduler
+type SchedulerStatus struct {
+	Running            bool        `json:"running"`
+	ScheduleExpression string      `json:"schedule_expression"`
+	NextRun            *time.Time  `json:"next_run,omitempty"`
+	UpcomingRuns       []time.Time `json:"upcoming_runs,omitempty"`
+	LastRun            *RunSummary `json:"last_run,omitempty"`
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSummary_SummaryLine(t *testing.T) {
+	drifted := NewDriftResult("i-1", OriginTerraform)
+	drifted.AddDriftedAttribute("instance_type", "t2.micro", "t2.large")
+	drifted.AddDriftedAttribute("ami", "ami-1", "ami-2")
+	single := NewDriftResult("i-2", OriginTerraform)
+	single.AddDriftedAttribute("tags.Env", "prod", "dev")
+	violating := NewDriftResult("i-3", OriginTerraform)
+	violating.SetPolicyViolations([]PolicyViolation{{Path: "age_days", Operator: "lt", Expected: 90, Actual: 120}})
+	clean := NewDriftResult("i-4", OriginTerraform)
+	unmanaged := NewDriftResult("i-5", OriginAWS)
+	unmanaged.AddDriftedAttribute(AttributeExists, true, false)
+	unmanaged.Unmanaged = true
+
+	now := time.Now()
+	tests := []struct {
+		name    string
+		results []*DriftResult
+		err     error
+		want    string
+	}{
+		{name: "drift", results: []*DriftResult{drifted, single, clean}, want: "DRIFT: 2/3 instances drifted (3 attributes)"},
+		{name: "single attribute", results: []*DriftResult{single, clean}, want: "DRIFT: 1/2 instances drifted (1 attribute)"},
+		{name: "no drift", results: []*DriftResult{clean}, want: "NO DRIFT: 0/1 instances drifted (0 attributes)"},
+		{name: "no instances", want: "NO DRIFT: 0/0 instances drifted (0 attributes)"},
+		{name: "unmanaged", results: []*DriftResult{drifted, unmanaged, clean}, want: "DRIFT: 1/3 instances drifted (2 attributes), 1 unmanaged"},
+		{name: "only unmanaged", results: []*DriftResult{unmanaged, clean}, want: "DRIFT: 0/2 instances drifted (0 attributes), 1 unmanaged"},
+		{
+			name:    "policy violations",
+			results: []*DriftResult{drifted, violating},
+			want:    "DRIFT: 1/2 instances drifted (2 attributes), 1 with policy violations",
+		},
+		{
+			name:    "failed run",
+			results: []*DriftResult{single},
+			err:     errors.New("aborted"),
+			want:    "DRIFT: 1/1 instances drifted (1 attribute) [run failed, counts are partial]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewRunSummary(now, now, tt.results, tt.err).SummaryLine())
+		})
+	}
+}
+
+func TestRunSummary_StringConcurrency(t *testing.T) {
+	now := time.Now()
+	summary := NewRunSummary(now, now.Add(1500*time.Millisecond), []*DriftResult{NewDriftResult("i-1", OriginTerraform)}, nil)
+	assert.Equal(t, "Drift check finished in 1.5s: 1 instances checked, 0 drifted, 0 with policy violations", summary.String())
+
+	summary.Concurrency = 8
+	assert.Equal(t, "Drift check finished in 1.5s: 1 instances checked, 0 drifted, 0 with policy violations, 8 parallel checks", summary.String())
+
+	summary.TerraformVersion = "1.9.5"
+	assert.Equal(t, "Drift check finished in 1.5s: 1 instances checked, 0 drifted, 0 with policy violations, 8 parallel checks, state written by Terraform 1.9.5", summary.String())
+
+	summary.FetchTimings = &FetchTimings{AWS: 1200 * time.Millisecond, Terraform: 300 * time.Millisecond}
+	assert.Equal(t, "Drift check finished in 1.5s: 1 instances checked, 0 drifted, 0 with policy violations, 8 parallel checks, state written by Terraform 1.9.5, AWS listed in 1.2s, Terraform parsed in 300ms", summary.String())
+}
+
+func TestRunSummary_Unmanaged(t *testing.T) {
+	drifted := NewDriftResult("i-1", OriginAWS)
+	drifted.AddDriftedAttribute("instance_type", "t2.micro", "t2.large")
+	unmanaged := NewDriftResult("i-2", OriginAWS)
+	unmanaged.AddDriftedAttribute(AttributeExists, true, false)
+	unmanaged.Unmanaged = true
+
+	// Unmanaged instances are counted apart from managed instances with drift
+	assert.True(t, drifted.IsManagedDrift())
+	assert.False(t, unmanaged.IsManagedDrift())
+
+	now := time.Now()
+	summary := NewRunSummary(now, now, []*DriftResult{drifted, unmanaged}, nil)
+	assert.Equal(t, 1, summary.DriftedCount)
+	assert.Equal(t, 1, summary.DriftedAttributes)
+	assert.Equal(t, 1, summary.UnmanagedCount)
+	assert.Equal(t, "Drift check finished in 0s: 2 instances checked, 1 drifted, 0 with policy violations, 1 unmanaged", summary.String())
+
+	// An unmanaged instance alone is a finding
+	assert.True(t, NewRunSummary(now, now, []*DriftResult{unmanaged}, nil).HasFindings())
+}
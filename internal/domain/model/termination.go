@@ -0,0 +1,117 @@
+package model
+
+// Termination protection attributes
+const (
+	// AttributeDisableAPITermination is whether EC2 refuses to terminate the instance through
+	// the API, as aws_instance declares it. DescribeInstances doesn't report it, so AWS
+	// instances only carry it when it is fetched.
+	AttributeDisableAPITermination = "disable_api_termination"
+
+	// AttributePreventDestroy is the lifecycle prevent_destroy of the Terraform resource. It is
+	// a Terraform meta-argument that state doesn't record, so only configurations have it.
+	AttributePreventDestroy = "prevent_destroy"
+
+	// AttributeTerminationProtection is the path drift between the two protections is reported
+	// at, since it compares different attributes on either side
+	AttributeTerminationProtection = "termination_protection"
+)
+
+// TerminationProtection compares the protections AWS and Terraform put on an instance. They
+// are often conflated but guard against different things: disable_api_termination makes EC2
+// refuse TerminateInstances from any caller, while prevent_destroy only makes Terraform refuse
+// plans that destroy the resource.
+type TerminationProtection struct {
+	DisableAPITermination bool `json:"disable_api_termination"`
+	PreventDestroy        bool `json:"prevent_destroy"`
+
+	// Note explains what the mismatch leaves unprotected
+	Note string `json:"note"`
+}
+
+// NewTerminationProtection compares the protection flags of an instance
+func NewTerminationProtection(disableAPITermination, preventDestroy bool) *TerminationProtection {
+	protection := &TerminationProtection{
+		DisableAPITermination: disableAPITermination,
+		PreventDestroy:        preventDestroy,
+	}
+	switch {
+	case preventDestroy && !disableAPITermination:
+		protection.Note = "prevent_destroy only stops Terraform from destroying the instance; without disable_api_termination it can still be terminated from the console, CLI or API"
+	case disableAPITermination && !preventDestroy:
+		protection.Note = "disable_api_termination stops every caller from terminating the instance, but without prevent_destroy Terraform still plans to destroy it and fails at apply"
+	}
+	return protection
+}
+
+// Matches reports whether both protections are set or neither is
+func (p *TerminationProtection) Matches() bool {
+	return p.DisableAPITermination == p.PreventDestroy
+}
+
+// CompareTerminationProtection compares AWS's disable_api_termination with Terraform's
+// prevent_destroy. It returns the drift when they disagree, or the reason they couldn't be
+// compared. A configuration without a lifecycle block doesn't prevent destroy.
+func CompareTerminationProtection(source, target *Instance, opts CompareOptions) (*AttributeDrift, string) {
+	awsInstance, terraformInstance := source, target
+	if source.Origin != OriginAWS {
+		awsInstance, terraformInstance = target, source
+	}
+	awsValue, awsFound := awsInstance.GetAttribute(AttributeDisableAPITermination)
+	terraformValue, terraformFound := terraformInstance.GetAttribute(AttributePreventDestroy)
+
+	var trace *AttributeTrace
+	if opts.Trace != nil {
+		trace = &AttributeTrace{Path: AttributeTerminationProtection}
+		trace.SourcePath, trace.TargetPath = AttributeDisableAPITermination, "lifecycle."+AttributePreventDestroy
+		trace.SourceFound, trace.TargetFound = awsFound, terraformFound
+		trace.SourceValue, trace.TargetValue = awsValue, terraformValue
+		if source.Origin != OriginAWS {
+			trace.SourcePath, trace.TargetPath = trace.TargetPath, trace.SourcePath
+			trace.SourceFound, trace.TargetFound = trace.TargetFound, trace.SourceFound
+			trace.SourceValue, trace.TargetValue = trace.TargetValue, trace.SourceValue
+		}
+		defer func() { opts.Trace.record(*trace) }()
+	}
+	skip := func(reason string) (*AttributeDrift, string) {
+		trace.skip(reason)
+		return nil, reason
+	}
+
+	if !awsFound {
+		return skip("disable_api_termination was not fetched from AWS")
+	}
+	if unknown, isUnknown := awsValue.(UnknownValue); isUnknown {
+		return skip(unknown.Reason)
+	}
+	disableAPITermination, ok := awsValue.(bool)
+	if !ok {
+		return skip("disable_api_termination is not a boolean")
+	}
+
+	preventDestroy := false
+	if terraformFound {
+		if unknown, isUnknown := terraformValue.(UnknownValue); isUnknown {
+			return skip(unknown.Reason)
+		}
+		if preventDestroy, ok = terraformValue.(bool); !ok {
+			return skip("prevent_destroy is not a boolean")
+		}
+	} else {
+		trace.normalize("a resource without lifecycle prevent_destroy doesn't prevent destroy")
+	}
+
+	protection := NewTerminationProtection(disableAPITermination, preventDestroy)
+	trace.decide(ComparerProtection, !protection.Matches())
+	if protection.Matches() {
+		return nil, ""
+	}
+
+	sourceValue, targetValue := disableAPITermination, preventDestroy
+	if source.Origin != OriginAWS {
+		sourceValue, targetValue = preventDestroy, disableAPITermination
+	}
+	drift := opts.newDrift(AttributeTerminationProtection, sourceValue, targetValue)
+	drift.TerraformAttribute = "lifecycle." + AttributePreventDestroy
+	drift.Protection = protection
+	return &drift, ""
+}
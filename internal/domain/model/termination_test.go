@@ -0,0 +1,75 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareTerminationProtection(t *testing.T) {
+	instances := func(awsAttrs, terraformAttrs map[string]interface{}) (*Instance, *Instance) {
+		return NewInstance("i-1", terraformAttrs, OriginTerraform), NewInstance("i-1", awsAttrs, OriginAWS)
+	}
+
+	t.Run("matching protections", func(t *testing.T) {
+		for _, protected := range []bool{true, false} {
+			terraform, aws := instances(
+				map[string]interface{}{AttributeDisableAPITermination: protected},
+				map[string]interface{}{AttributePreventDestroy: protected},
+			)
+			drift, reason := CompareTerminationProtection(terraform, aws, CompareOptions{})
+			assert.Nil(t, drift)
+			assert.Empty(t, reason)
+		}
+	})
+
+	t.Run("prevent_destroy without disable_api_termination", func(t *testing.T) {
+		terraform, aws := instances(
+			map[string]interface{}{AttributeDisableAPITermination: false},
+			map[string]interface{}{AttributePreventDestroy: true},
+		)
+		drift, reason := CompareTerminationProtection(terraform, aws, CompareOptions{})
+		assert.Empty(t, reason)
+		require.NotNil(t, drift)
+		assert.Equal(t, AttributeTerminationProtection, drift.Path)
+		assert.Equal(t, "lifecycle.prevent_destroy", drift.TerraformAttribute)
+		assert.Equal(t, true, drift.DesiredValue)
+		assert.Equal(t, false, drift.CurrentValue)
+		require.NotNil(t, drift.Protection)
+		assert.False(t, drift.Protection.DisableAPITermination)
+		assert.True(t, drift.Protection.PreventDestroy)
+		assert.Contains(t, drift.Protection.Note, "can still be terminated from the console, CLI or API")
+	})
+
+	t.Run("disable_api_termination without prevent_destroy, AWS as source of truth", func(t *testing.T) {
+		terraform, aws := instances(
+			map[string]interface{}{AttributeDisableAPITermination: true},
+			map[string]interface{}{},
+		)
+		drift, reason := CompareTerminationProtection(aws, terraform, CompareOptions{})
+		assert.Empty(t, reason)
+		require.NotNil(t, drift)
+		assert.Equal(t, true, drift.SourceValue)
+		assert.Equal(t, false, drift.TargetValue)
+		assert.Contains(t, drift.Protection.Note, "Terraform still plans to destroy it")
+	})
+
+	t.Run("unknown sides are skipped", func(t *testing.T) {
+		terraform, aws := instances(
+			map[string]interface{}{},
+			map[string]interface{}{AttributePreventDestroy: true},
+		)
+		drift, reason := CompareTerminationProtection(terraform, aws, CompareOptions{})
+		assert.Nil(t, drift)
+		assert.Equal(t, "disable_api_termination was not fetched from AWS", reason)
+
+		terraform, aws = instances(
+			map[string]interface{}{AttributeDisableAPITermination: true},
+			map[string]interface{}{AttributePreventDestroy: UnknownValue{Reason: "not in state"}},
+		)
+		drift, reason = CompareTerminationProtection(terraform, aws, CompareOptions{})
+		assert.Nil(t, drift)
+		assert.Equal(t, "not in state", reason)
+	})
+}
@@ -0,0 +1,248 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/victor-devv/ec2-drift-detector/pkg/comparator"
+)
+
+// Comparison verdicts of a traced attribute
+const (
+	TraceEqual   = "equal"
+	TraceDrifted = "drifted"
+	TraceSkipped = "skipped"
+)
+
+// Comparers a traced attribute can be decided by
+const (
+	ComparerPresence   = "presence"
+	ComparerCustom     = "custom comparator"
+	ComparerUserData   = "user data hash"
+	ComparerTags       = "tag comparator"
+	ComparerDeepEqual  = "deep equal"
+	ComparerProtection = "termination protection"
+	ComparerSet        = "set comparator"
+)
+
+// AttributeTrace records how an attribute was compared, so that a disputed verdict can be
+// explained: where each side's value came from, how it was normalized and what compared it
+type AttributeTrace struct {
+	Path string `json:"path"`
+
+	// Aliases are the other names the attribute can be configured as
+	Aliases []string `json:"aliases,omitempty"`
+
+	// SourcePath and TargetPath are the attributes each side's value was read from, e.g.
+	// tags_all for tags
+	SourcePath  string `json:"source_path"`
+	TargetPath  string `json:"target_path"`
+	SourceFound bool   `json:"source_found"`
+	TargetFound bool   `json:"target_found"`
+
+	// SourceValue and TargetValue are the values as the providers reported them
+	SourceValue interface{} `json:"source_value"`
+	TargetValue interface{} `json:"target_value"`
+
+	// SourceNormalized and TargetNormalized are the values actually compared, when
+	// normalization changed them
+	SourceNormalized interface{} `json:"source_normalized,omitempty"`
+	TargetNormalized interface{} `json:"target_normalized,omitempty"`
+
+	// Normalizations describes each normalization applied before comparing
+	Normalizations []string `json:"normalizations,omitempty"`
+
+	Comparer string `json:"comparer,omitempty"`
+	Verdict  string `json:"verdict"`
+
+	// Reason explains a skipped attribute
+	Reason string `json:"reason,omitempty"`
+}
+
+// TraceOptions are the comparison options a trace was recorded with
+type TraceOptions struct {
+	EmptyEqualsAbsent   bool     `json:"empty_equals_absent"`
+	StrictPresencePaths []string `json:"strict_presence_paths,omitempty"`
+	TrimTagValues       bool     `json:"trim_tag_values"`
+	IgnoreTagCase       bool     `json:"ignore_tag_case"`
+	UserDataHash        bool     `json:"user_data_hash"`
+
+	// Comparators are the paths compared by custom comparators
+	Comparators []string `json:"comparators,omitempty"`
+}
+
+// CompareTrace collects a record per compared attribute when set on CompareOptions. Attributes
+// are compared concurrently, so recording is locked.
+type CompareTrace struct {
+	mu           sync.Mutex
+	sourceOrigin ResourceOrigin
+	targetOrigin ResourceOrigin
+	options      TraceOptions
+	records      map[string]AttributeTrace
+}
+
+// NewCompareTrace creates an empty trace
+func NewCompareTrace() *CompareTrace {
+	return &CompareTrace{records: make(map[string]AttributeTrace)}
+}
+
+// Origins returns the providers of the source and target instances
+func (t *CompareTrace) Origins() (source, target ResourceOrigin) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sourceOrigin, t.targetOrigin
+}
+
+// Options returns the comparison options the trace was recorded with
+func (t *CompareTrace) Options() TraceOptions {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.options
+}
+
+// Records returns the traced attributes sorted by path
+func (t *CompareTrace) Records() []AttributeTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	records := make([]AttributeTrace, 0, len(t.records))
+	for _, record := range t.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Path < records[j].Path })
+	return records
+}
+
+// Skip records an attribute that was left out before comparison, e.g. one the source doesn't
+// declare
+func (t *CompareTrace) Skip(path, reason string) {
+	t.record(AttributeTrace{Path: path, Aliases: AttributeAliases(path), Verdict: TraceSkipped, Reason: reason})
+}
+
+// begin records the instances and options of a comparison
+func (t *CompareTrace) begin(source, target *Instance, opts CompareOptions) {
+	comparators := make([]string, 0, len(opts.Comparators))
+	for path := range opts.Comparators {
+		comparators = append(comparators, path)
+	}
+	sort.Strings(comparators)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sourceOrigin, t.targetOrigin = source.Origin, target.Origin
+	t.options = TraceOptions{
+		EmptyEqualsAbsent:   opts.EmptyEqualsAbsent,
+		StrictPresencePaths: opts.StrictPresencePaths,
+		TrimTagValues:       opts.TrimTagValues,
+		IgnoreTagCase:       opts.IgnoreTagCase,
+		UserDataHash:        opts.UserDataHash,
+		Comparators:         comparators,
+	}
+}
+
+// record keeps the trace of an attribute, replacing an earlier one for the same path
+func (t *CompareTrace) record(record AttributeTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.records[record.Path] = record
+}
+
+// newAttributeTrace starts the trace of an attribute from the values read on each side
+func newAttributeTrace(source, target *Instance, path string, sourceVal interface{}, sourceFound bool, targetVal interface{}, targetFound bool) *AttributeTrace {
+	record := &AttributeTrace{
+		Path:        path,
+		Aliases:     AttributeAliases(path),
+		SourcePath:  resolvedPath(source, path),
+		TargetPath:  resolvedPath(target, path),
+		SourceFound: sourceFound,
+		TargetFound: targetFound,
+	}
+	record.SourceValue, _ = comparator.Sanitize(sourceVal, 0)
+	record.TargetValue, _ = comparator.Sanitize(targetVal, 0)
+	return record
+}
+
+// resolvedPath returns the attribute an instance's value for path was read from
+func resolvedPath(instance *Instance, path string) string {
+	from := instance.AttributeSource(path)
+	if from == "" {
+		return path
+	}
+	if _, rest, nested := strings.Cut(path, "."); nested {
+		return from + "." + rest
+	}
+	return from
+}
+
+// The methods below are no-ops on a nil trace, so comparison code can call them unconditionally
+
+// normalize notes a normalization applied before comparing
+func (r *AttributeTrace) normalize(description string) {
+	if r != nil {
+		r.Normalizations = append(r.Normalizations, description)
+	}
+}
+
+// normalized sets the values actually compared
+func (r *AttributeTrace) normalized(source, target interface{}) {
+	if r != nil {
+		r.SourceNormalized, _ = comparator.Sanitize(source, 0)
+		r.TargetNormalized, _ = comparator.Sanitize(target, 0)
+	}
+}
+
+// decide sets the comparer and the verdict
+func (r *AttributeTrace) decide(comparer string, drifted bool) {
+	if r == nil {
+		return
+	}
+	r.Comparer = comparer
+	r.Verdict = TraceEqual
+	if drifted {
+		r.Verdict = TraceDrifted
+	}
+}
+
+// skip marks the attribute as not compared
+func (r *AttributeTrace) skip(reason string) {
+	if r != nil {
+		r.Verdict = TraceSkipped
+		r.Reason = reason
+	}
+}
+
+// normalizeTags applies the tag value normalizations of the options to a tags map or a single
+// tag value, for showing what the tag comparator compared
+func normalizeTags(value interface{}, trim, ignoreCase bool) interface{} {
+	normalize := func(s string) string {
+		if trim {
+			s = strings.TrimSpace(s)
+		}
+		if ignoreCase {
+			s = strings.ToLower(s)
+		}
+		return s
+	}
+
+	switch v := value.(type) {
+	case string:
+		return normalize(v)
+	case map[string]string:
+		tags := make(map[string]interface{}, len(v))
+		for key, tag := range v {
+			tags[key] = normalize(tag)
+		}
+		return tags
+	case map[string]interface{}:
+		tags := make(map[string]interface{}, len(v))
+		for key, tag := range v {
+			if s, ok := tag.(string); ok {
+				tags[key] = normalize(s)
+			} else {
+				tags[key] = tag
+			}
+		}
+		return tags
+	}
+	return value
+}
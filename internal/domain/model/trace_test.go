@@ -0,0 +1,97 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareTrace(t *testing.T) {
+	source := NewInstance("i-1", map[string]interface{}{
+		"instance_type":            "t3.micro",
+		"tags":                     map[string]interface{}{"Name": " Web "},
+		AttributeInstanceLifecycle: "spot",
+		"ami":                      UnknownValue{Reason: "ami is read from a data source"},
+	}, OriginTerraform)
+	source.SetAttributeSource("tags", "tags_all")
+	target := NewInstance("i-1", map[string]interface{}{
+		"instance_type":            "t3.small",
+		"tags":                     map[string]interface{}{"Name": "web"},
+		AttributeInstanceLifecycle: "spot",
+		"ami":                      "ami-123",
+	}, OriginAWS)
+
+	trace := NewCompareTrace()
+	opts := CompareOptions{TrimTagValues: true, IgnoreTagCase: true, Trace: trace}
+	drifts := CompareAttributesWithOptions(source, target, []string{"ami", "instance_type", "tags", AttributeInstanceLifecycle, "subnet_id"}, opts)
+	assert.Len(t, drifts, 1)
+	trace.Skip("vpc_security_group_ids", "not declared by the source")
+
+	sourceOrigin, targetOrigin := trace.Origins()
+	assert.Equal(t, OriginTerraform, sourceOrigin)
+	assert.Equal(t, OriginAWS, targetOrigin)
+	assert.True(t, trace.Options().TrimTagValues)
+
+	records := trace.Records()
+	require.Len(t, records, 6)
+	byPath := make(map[string]AttributeTrace, len(records))
+	for _, record := range records {
+		byPath[record.Path] = record
+	}
+	assert.Equal(t, "ami", records[0].Path, "records are sorted by path")
+
+	ami := byPath["ami"]
+	assert.Equal(t, TraceSkipped, ami.Verdict)
+	assert.Equal(t, "ami is read from a data source", ami.Reason)
+
+	instanceType := byPath["instance_type"]
+	assert.Equal(t, TraceDrifted, instanceType.Verdict)
+	assert.Equal(t, ComparerDeepEqual, instanceType.Comparer)
+	assert.Equal(t, "t3.micro", instanceType.SourceValue)
+	assert.Equal(t, "t3.small", instanceType.TargetValue)
+
+	tags := byPath["tags"]
+	assert.Equal(t, TraceEqual, tags.Verdict)
+	assert.Equal(t, ComparerTags, tags.Comparer)
+	assert.Equal(t, "tags_all", tags.SourcePath)
+	assert.Equal(t, "tags", tags.TargetPath)
+	assert.Equal(t, map[string]interface{}{"Name": "web"}, tags.SourceNormalized)
+	assert.Len(t, tags.Normalizations, 2)
+
+	lifecycle := byPath[AttributeInstanceLifecycle]
+	assert.Equal(t, TraceEqual, lifecycle.Verdict)
+	assert.Equal(t, []string{"instance_market_options", "lifecycle", "market_type"}, lifecycle.Aliases)
+
+	subnet := byPath["subnet_id"]
+	assert.Equal(t, TraceEqual, subnet.Verdict)
+	assert.Equal(t, ComparerPresence, subnet.Comparer)
+	assert.False(t, subnet.SourceFound)
+
+	assert.Equal(t, TraceSkipped, byPath["vpc_security_group_ids"].Verdict)
+}
+
+func TestCompareTrace_UserData(t *testing.T) {
+	source := NewInstance("i-1", map[string]interface{}{AttributeUserData: "#!/bin/bash\r\necho hi  \n"}, OriginTerraform)
+	target := NewInstance("i-1", map[string]interface{}{AttributeUserData: "#!/bin/bash\necho hi\n"}, OriginAWS)
+
+	trace := NewCompareTrace()
+	drifts := CompareAttributesWithOptions(source, target, []string{AttributeUserData}, CompareOptions{UserDataHash: true, Trace: trace})
+	assert.Empty(t, drifts)
+
+	records := trace.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, ComparerUserData, records[0].Comparer)
+	assert.Equal(t, TraceEqual, records[0].Verdict)
+	assert.NotEmpty(t, records[0].SourceNormalized)
+	assert.Equal(t, records[0].SourceNormalized, records[0].TargetNormalized)
+}
+
+func TestCompareTrace_Nil(t *testing.T) {
+	source := NewInstance("i-1", map[string]interface{}{"instance_type": "t3.micro"}, OriginTerraform)
+	target := NewInstance("i-1", map[string]interface{}{"instance_type": "t3.small"}, OriginAWS)
+
+	// Comparing without a trace is unaffected
+	drifts := CompareAttributesWithOptions(source, target, []string{"instance_type"}, CompareOptions{})
+	assert.Len(t, drifts, 1)
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// AttributeUserData is the instance user data script
+const AttributeUserData = "user_data"
+
+// sha1HexPattern matches the SHA1 digest Terraform state records in place of user_data
+var sha1HexPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// UserDataDigest stands in for a user data script on a drifted attribute so reports don't
+// carry whole scripts
+type UserDataDigest struct {
+	Algorithm string `json:"algorithm"`
+	Digest    string `json:"digest"`
+
+	// Length is the size of the normalized script in bytes, unknown when only a digest was recorded
+	Length int `json:"length,omitempty"`
+}
+
+// String returns a short form of the digest with the script length
+func (d UserDataDigest) String() string {
+	digest := d.Digest
+	if len(digest) > 12 {
+		digest = digest[:12]
+	}
+	if d.Length == 0 {
+		return fmt.Sprintf("%s:%s", d.Algorithm, digest)
+	}
+	return fmt.Sprintf("%s:%s (%d bytes)", d.Algorithm, digest, d.Length)
+}
+
+// userDataScript is a user data value prepared for comparison
+type userDataScript struct {
+	raw        string
+	normalized string
+
+	// sha1 is set when the value is a digest recorded by Terraform rather than the script itself
+	sha1 string
+}
+
+// parseUserData decodes base64 user data (as returned by EC2) and normalizes line endings and
+// trailing whitespace, so formatting differences don't count as drift
+func parseUserData(val interface{}) userDataScript {
+	if val == nil {
+		return userDataScript{}
+	}
+
+	text, ok := val.(string)
+	if !ok {
+		text = serializeValue(val)
+	}
+
+	if sha1HexPattern.MatchString(text) {
+		return userDataScript{sha1: text}
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(text); err == nil && len(decoded) > 0 && utf8.Valid(decoded) {
+		text = string(decoded)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+
+	return userDataScript{
+		raw:        text,
+		normalized: strings.TrimRight(strings.Join(lines, "\n"), "\n"),
+	}
+}
+
+// digest returns the SHA256 of the normalized script, or the recorded SHA1
+func (s userDataScript) digest() interface{} {
+	if s.sha1 != "" {
+		return UserDataDigest{Algorithm: "sha1", Digest: s.sha1}
+	}
+	if s.raw == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(s.normalized))
+	return UserDataDigest{Algorithm: "sha256", Digest: hex.EncodeToString(sum[:]), Length: len(s.normalized)}
+}
+
+// sha1Matches reports whether the raw script hashes to the given SHA1 digest
+func (s userDataScript) sha1Matches(digest string) bool {
+	sum := sha1.Sum([]byte(s.raw))
+	return hex.EncodeToString(sum[:]) == digest
+}
+
+// compareUserData compares user data by content hash and reports whether the scripts differ.
+// On a mismatch the drift carries digests and lengths instead of the scripts, plus a unified
+// diff when UserDataDiff is set.
+func (o CompareOptions) compareUserData(path string, sourceVal, targetVal interface{}) (AttributeDrift, bool) {
+	source := parseUserData(sourceVal)
+	target := parseUserData(targetVal)
+
+	switch {
+	case source.sha1 != "" && target.sha1 != "":
+		if source.sha1 == target.sha1 {
+			return AttributeDrift{}, false
+		}
+	case source.sha1 != "":
+		if target.sha1Matches(source.sha1) {
+			return AttributeDrift{}, false
+		}
+	case target.sha1 != "":
+		if source.sha1Matches(target.sha1) {
+			return AttributeDrift{}, false
+		}
+	default:
+		if source.normalized == target.normalized {
+			return AttributeDrift{}, false
+		}
+	}
+
+	drift := AttributeDrift{
+		Path:        path,
+		SourceValue: source.digest(),
+		TargetValue: target.digest(),
+		Changed:     true,
+	}
+	drift.labelValues()
+
+	// A recorded digest has no script to diff against
+	if o.UserDataDiff && source.sha1 == "" && target.sha1 == "" {
+		drift.Diff, _ = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(source.normalized + "\n"),
+			B:        difflib.SplitLines(target.normalized + "\n"),
+			FromFile: "source",
+			ToFile:   "target",
+			Context:  3,
+		})
+	}
+
+	return drift, true
+}
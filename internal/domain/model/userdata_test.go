@@ -0,0 +1,59 @@
+package model
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const nginxUserData = "#!/bin/bash\nyum install -y nginx\nsystemctl start nginx\n"
+
+func userDataInstances(source, target interface{}) (*Instance, *Instance) {
+	return NewInstance("i-1", map[string]interface{}{AttributeUserData: source}, OriginTerraform),
+		NewInstance("i-1", map[string]interface{}{AttributeUserData: target}, OriginAWS)
+}
+
+func TestCompareUserData_Identical(t *testing.T) {
+	opts := CompareOptions{UserDataHash: true}
+
+	// EC2 returns base64 and scripts may differ in line endings and trailing whitespace
+	encoded := base64.StdEncoding.EncodeToString([]byte(nginxUserData))
+	source, target := userDataInstances("#!/bin/bash  \r\nyum install -y nginx\r\nsystemctl start nginx", encoded)
+	assert.Empty(t, CompareAttributesWithOptions(source, target, []string{AttributeUserData}, opts))
+
+	// Terraform state records a SHA1 of the script instead of the script itself
+	sum := sha1.Sum([]byte(nginxUserData))
+	source, target = userDataInstances(hex.EncodeToString(sum[:]), encoded)
+	assert.Empty(t, CompareAttributesWithOptions(source, target, []string{AttributeUserData}, opts))
+}
+
+func TestCompareUserData_Differing(t *testing.T) {
+	changed := "#!/bin/bash\nyum install -y httpd\nsystemctl start httpd\n"
+	source, target := userDataInstances(nginxUserData, changed)
+
+	drifts := CompareAttributesWithOptions(source, target, []string{AttributeUserData}, CompareOptions{UserDataHash: true})
+	drift, ok := drifts[AttributeUserData]
+	assert.True(t, ok)
+	assert.Empty(t, drift.Diff)
+
+	sourceDigest, ok := drift.SourceValue.(UserDataDigest)
+	assert.True(t, ok)
+	assert.Equal(t, "sha256", sourceDigest.Algorithm)
+	assert.Equal(t, len(nginxUserData)-1, sourceDigest.Length)
+	assert.Equal(t, len(changed)-1, drift.TargetValue.(UserDataDigest).Length)
+	assert.NotEqual(t, sourceDigest.Digest, drift.TargetValue.(UserDataDigest).Digest)
+
+	// The diff is only included when asked for
+	drifts = CompareAttributesWithOptions(source, target, []string{AttributeUserData}, CompareOptions{UserDataHash: true, UserDataDiff: true})
+	diff := drifts[AttributeUserData].Diff
+	assert.Contains(t, diff, "--- source")
+	assert.Contains(t, diff, "-yum install -y nginx")
+	assert.Contains(t, diff, "+yum install -y httpd")
+
+	// Without hashing the full scripts are reported
+	drifts = CompareAttributesWithOptions(source, target, []string{AttributeUserData}, CompareOptions{})
+	assert.Equal(t, nginxUserData, drifts[AttributeUserData].SourceValue)
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Modes for storing source and target values on drifted attributes
+const (
+	// StoreValuesFull keeps the complete values
+	StoreValuesFull = "full"
+
+	// StoreValuesTruncated caps serialized values at a maximum size
+	StoreValuesTruncated = "truncated"
+
+	// StoreValuesHash keeps only a SHA256 of each value and its type
+	StoreValuesHash = "hash"
+)
+
+// DefaultStoreValuesMaxBytes is the size truncated values are capped at when none is configured
+const DefaultStoreValuesMaxBytes = 256
+
+// IsValidStoreValuesMode reports whether the mode is supported
+func IsValidStoreValuesMode(mode string) bool {
+	switch mode {
+	case "", StoreValuesFull, StoreValuesTruncated, StoreValuesHash:
+		return true
+	}
+	return false
+}
+
+// TruncatedValue is a value whose serialized form exceeded the configured size
+type TruncatedValue struct {
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Size      int    `json:"size"`
+	Truncated bool   `json:"truncated"`
+}
+
+// String returns the kept prefix with a truncation marker
+func (v TruncatedValue) String() string {
+	return fmt.Sprintf("%s… [truncated, %d bytes]", v.Value, v.Size)
+}
+
+// HashedValue is a value replaced by its SHA256 digest
+type HashedValue struct {
+	Type   string `json:"type"`
+	SHA256 string `json:"sha256"`
+}
+
+// String returns a short form of the digest with the value's type
+func (v HashedValue) String() string {
+	digest := v.SHA256
+	if len(digest) > 12 {
+		digest = digest[:12]
+	}
+	return fmt.Sprintf("sha256:%s (%s)", digest, v.Type)
+}
+
+// storeValue returns the form of a value kept on an AttributeDrift for the given mode
+func storeValue(val interface{}, mode string, maxBytes int) interface{} {
+	if val == nil {
+		return nil
+	}
+
+	switch mode {
+	case StoreValuesTruncated:
+		if maxBytes <= 0 {
+			maxBytes = DefaultStoreValuesMaxBytes
+		}
+		serialized := serializeValue(val)
+		if len(serialized) <= maxBytes {
+			return val
+		}
+		// Cut on a rune boundary so the kept prefix stays valid UTF-8
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(serialized[cut]) {
+			cut--
+		}
+		return TruncatedValue{
+			Type:      fmt.Sprintf("%T", val),
+			Value:     serialized[:cut],
+			Size:      len(serialized),
+			Truncated: true,
+		}
+	case StoreValuesHash:
+		sum := sha256.Sum256([]byte(serializeValue(val)))
+		return HashedValue{
+			Type:   fmt.Sprintf("%T", val),
+			SHA256: hex.EncodeToString(sum[:]),
+		}
+	default:
+		return val
+	}
+}
+
+// serializeValue returns a stable string form of a value. Strings are used as-is, other values
+// are JSON encoded (map keys are sorted).
+func serializeValue(val interface{}) string {
+	if s, ok := val.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(data)
+}
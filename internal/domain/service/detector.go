@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 )
 
@@ -16,6 +17,96 @@ type InstanceProvider interface {
 	ListInstances(ctx context.Context) ([]*model.Instance, error)
 }
 
+// InstanceStreamer is implemented by providers that can hand out instances as they are read,
+// so that comparisons can start before the whole inventory has been fetched
+type InstanceStreamer interface {
+	// StreamInstances calls emit for each instance; an error from emit stops the stream
+	StreamInstances(ctx context.Context, emit func(*model.Instance) error) error
+}
+
+// TerraformProvider defines the interface for reading instance configurations from Terraform.
+// Implementations read state or HCL; what only some sources can do, such as caching state or
+// reporting the Terraform version, is left to the optional interfaces below.
+type TerraformProvider interface {
+	InstanceProvider
+	InstanceStreamer
+}
+
+// InstanceBatchProvider is implemented by providers that can fetch a set of instances by ID
+// without listing every instance
+type InstanceBatchProvider interface {
+	// ListInstancesByID retrieves the instances with the given IDs; IDs that don't exist are
+	// left out rather than failing the call
+	ListInstancesByID(ctx context.Context, instanceIDs []string) ([]*model.Instance, error)
+}
+
+// StateCache is implemented by providers that keep parsed state between runs
+type StateCache interface {
+	// SetCacheEnabled sets whether parsed state is reused while it is unchanged
+	SetCacheEnabled(enabled bool)
+
+	// InvalidateCache drops the cached state so the next run parses it again
+	InvalidateCache()
+}
+
+// WorkspaceProvider is implemented by providers that read several Terraform workspaces, each
+// compared against the AWS instances tagged with its name
+type WorkspaceProvider interface {
+	// Workspaces returns the workspaces read, or nil when a single state is read
+	Workspaces() []string
+}
+
+// AddressMoveProvider is implemented by Terraform providers that know which resources moved
+// blocks renamed
+type AddressMoveProvider interface {
+	// AddressMoves returns the former addresses of moved resources and modules mapped to their
+	// current ones
+	AddressMoves(ctx context.Context) (model.AddressMoves, error)
+}
+
+// IdentityProvider is implemented by AWS providers that can tell which account and region
+// they read instances from
+type IdentityProvider interface {
+	// CallerIdentity returns the account ID of the credentials in use and the region
+	CallerIdentity(ctx context.Context) (accountID string, region string, err error)
+}
+
+// StateVersionProvider is implemented by Terraform providers that can tell which Terraform
+// version wrote the state they read
+type StateVersionProvider interface {
+	// TerraformVersion returns the terraform_version of the last state read, or "" when unknown
+	TerraformVersion() string
+}
+
+// ResourceProvider is implemented by providers that can list the non-instance resources
+// checked for orphans (volumes, network interfaces, Elastic IPs)
+type ResourceProvider interface {
+	// ListResources retrieves all resources that can be attached to instances
+	ListResources(ctx context.Context) ([]*model.Resource, error)
+}
+
+// SubnetProvider is implemented by providers that can describe subnets, so that subnet drift
+// can be reported with the VPCs and availability zones involved
+type SubnetProvider interface {
+	// DescribeSubnets returns the subnets with the given IDs keyed by ID; IDs that don't exist
+	// are left out rather than failing the call
+	DescribeSubnets(ctx context.Context, subnetIDs []string) (map[string]*model.Subnet, error)
+}
+
+// ManagedResourceProvider is implemented by providers that know which non-instance
+// resources they manage directly
+type ManagedResourceProvider interface {
+	// ListManagedResourceIDs returns the IDs of the resources the provider manages
+	ListManagedResourceIDs(ctx context.Context) (map[string]bool, error)
+}
+
+// AttributeDumper records the attributes each provider produced for an instance, to help
+// troubleshoot false drift
+type AttributeDumper interface {
+	// DumpAttributes records the attributes of the AWS and Terraform instances, either of which may be nil
+	DumpAttributes(instanceID string, aws, terraform *model.Instance) error
+}
+
 // DriftDetector defines the interface for detecting drift between instances
 type DriftDetector interface {
 	// DetectDrift detects drift between two instances for specified attributes
@@ -41,6 +132,22 @@ type DriftRepository interface {
 
 	// ListDriftResults retrieves all drift detection results
 	ListDriftResults(ctx context.Context) ([]*model.DriftResult, error)
+
+	// ListDriftResultsWithDrift retrieves the most recent result of each instance whose most
+	// recent result has drift
+	ListDriftResultsWithDrift(ctx context.Context) ([]*model.DriftResult, error)
+}
+
+// DigestStore is implemented by repositories that can buffer results for notification digests
+type DigestStore interface {
+	// AppendDigest buffers results for the digest with the given key
+	AppendDigest(ctx context.Context, key string, results []*model.DriftResult, at time.Time) error
+
+	// PendingDigest returns the buffered results for a digest and when the oldest was buffered
+	PendingDigest(ctx context.Context, key string) ([]*model.DriftResult, time.Time, error)
+
+	// ClearDigest discards the buffered results for a digest
+	ClearDigest(ctx context.Context, key string) error
 }
 
 // Reporter defines the interface for reporting drift detection results
@@ -64,6 +171,50 @@ type DriftService interface {
 	RunScheduledDriftCheck(ctx context.Context) error
 }
 
+// NotificationReporter is implemented by reporters that push results to an external channel
+// (chat, email, paging). Notification reporters are wrapped in a digest when one is configured.
+type NotificationReporter interface {
+	Reporter
+
+	// NotificationChannel returns a stable name for the channel, used as the digest key
+	NotificationChannel() string
+}
+
+// DigestOptions controls how notification reporters batch results
+type DigestOptions struct {
+	// Interval is how long results are buffered before a digest is sent (0 disables digests)
+	Interval time.Duration
+
+	// ImmediateSeverity sends the digest right away once a report has drift of this severity
+	// or higher: high, medium or low ("" disables)
+	ImmediateSeverity string
+
+	// Store buffers pending results between digests. Without one they are buffered in the
+	// repository, when it can, and lost on restart.
+	Store DigestStore
+}
+
+// DefaultBenchmarkLevels are the concurrency levels a benchmark measures when none are given
+var DefaultBenchmarkLevels = []int{2, 5, 10, 20}
+
+// BenchmarkOptions controls a benchmark run
+type BenchmarkOptions struct {
+	// SampleSize is the number of randomly chosen instances checked at each level
+	SampleSize int
+
+	// Levels are the numbers of instances checked in parallel, measured in order
+	Levels []int
+
+	// AttributePaths are the attributes compared; empty compares the configured ones
+	AttributePaths []string
+}
+
+// OrphanReporter is implemented by reporters that render orphaned resources
+type OrphanReporter interface {
+	// ReportOrphans reports resources that no Terraform instance references
+	ReportOrphans(orphans []*model.OrphanResult) error
+}
+
 // DriftDetectorProvider defines the interface for a drift detector service
 type DriftDetectorProvider interface {
 	// DetectDrift detects drift between two instances for specified attributes
@@ -84,26 +235,102 @@ type DriftDetectorProvider interface {
 	// RunScheduledDriftCheck runs a scheduled drift check
 	RunScheduledDriftCheck(ctx context.Context) error
 
+	// RunDriftCheck detects and reports drift for all instances and returns the run summary
+	RunDriftCheck(ctx context.Context, attributePaths []string) (*model.RunSummary, error)
+
+	// FlushDigests sends all pending notification digests immediately
+	FlushDigests(ctx context.Context) error
+
+	// ReportStoredResults renders stored results saved at or after since through the given reporters
+	ReportStoredResults(ctx context.Context, since time.Time, reporters []Reporter) error
+
+	// ShowStoredResult renders a stored result in detail through the given reporters: the
+	// result with the given ID, or the latest result of the instance with that ID
+	ShowStoredResult(ctx context.Context, id string, reporters []Reporter) error
+
+	// Benchmark checks a random sample of instances at several concurrency levels and suggests
+	// settings from the measurements. Results are discarded, never saved or reported.
+	Benchmark(ctx context.Context, options BenchmarkOptions) (*model.BenchmarkReport, error)
+
 	// StartScheduler starts the scheduler
 	StartScheduler(ctx context.Context) error
 
 	// StopScheduler stops the scheduler
 	StopScheduler()
 
+	// GetSchedulerStatus returns the scheduler state, next run time and last run summary
+	GetSchedulerStatus() model.SchedulerStatus
+
+	// CheckProviders verifies that the AWS and Terraform providers are reachable
+	CheckProviders(ctx context.Context) error
+
 	// Configuration setters
 	SetSourceOfTruth(sourceOfTruth model.ResourceOrigin)
 	SetAttributePaths(attributePaths []string)
 	SetParallelChecks(parallelChecks int)
 	SetTimeout(timeout time.Duration)
+	SetAWSTimeout(timeout time.Duration)
+	SetTerraformTimeout(timeout time.Duration)
 	SetScheduleExpression(expression string)
+	SetAbortAfterErrors(abortAfterErrors int)
+	SetParallelProviders(parallelProviders bool)
+	SetErrorOnEmpty(errorOnEmpty bool)
+	SetMinInstances(minInstances int)
+	SetStaticIPsOnly(staticIPsOnly bool)
+	SetSourceDeclaredOnly(sourceDeclaredOnly bool)
+	SetCheckOrphans(checkOrphans bool)
+	SetCompareOptions(opts model.CompareOptions)
+	RegisterComparator(path string, fn func(a, b interface{}) bool)
+	SetDigestOptions(opts DigestOptions)
+	SetPolicies(policies []model.Policy)
+	SetAllowedInstanceTypes(instanceTypes []string)
+	SetEnvironmentTag(tag string)
+	SetStrictAccountCheck(strict bool)
+	SetSuggestRemediation(suggest bool)
+	SetEnrichNetworkContext(enrich bool)
+	SetCompareTerminationProtection(compare bool)
+	SetMemoize(memoize bool)
+	SetHashPrefilter(prefilter bool)
+	SetDriftAgeLookback(lookback int)
+	SetIncludeSnapshots(include bool)
+	SetResourceFilter(patterns []string)
 	SetReporters(reporters []Reporter)
+	SetAWSProvider(provider InstanceProvider)
+	SetAttributeDumper(dumper AttributeDumper)
+	SetStateCacheEnabled(enabled bool)
+
+	// InvalidateStateCache drops state the Terraform provider cached from earlier runs
+	InvalidateStateCache()
 
 	// Configuration getters
 	GetAttributePaths() []string
 	GetSourceOfTruth() model.ResourceOrigin
 	GetParallelChecks() int
 	GetTimeout() time.Duration
+	GetAWSTimeout() time.Duration
+	GetTerraformTimeout() time.Duration
 	GetScheduleExpression() string
+	GetAbortAfterErrors() int
+	GetParallelProviders() bool
+	GetErrorOnEmpty() bool
+	GetMinInstances() int
+	GetStaticIPsOnly() bool
+	GetSourceDeclaredOnly() bool
+	GetCheckOrphans() bool
+	GetCompareOptions() model.CompareOptions
+	GetDigestOptions() DigestOptions
+	GetPolicies() []model.Policy
+	GetAllowedInstanceTypes() []string
+	GetEnvironmentTag() string
+	GetStrictAccountCheck() bool
+	GetSuggestRemediation() bool
+	GetEnrichNetworkContext() bool
+	GetCompareTerminationProtection() bool
+	GetMemoize() bool
+	GetHashPrefilter() bool
+	GetDriftAgeLookback() int
+	GetIncludeSnapshots() bool
+	GetResourceFilter() []string
 }
 
 // DriftDetectorConfig holds the configuration for drift detector services
@@ -113,4 +340,95 @@ type DriftDetectorConfig struct {
 	ParallelChecks     int
 	Timeout            time.Duration
 	ScheduleExpression string
+
+	// Clock provides timestamps for results and run summaries (nil uses the system clock)
+	Clock clock.Clock
+
+	// AWSTimeout and TerraformTimeout bound each provider call within Timeout (0 uses Timeout)
+	AWSTimeout       time.Duration
+	TerraformTimeout time.Duration
+
+	// AbortAfterErrors cancels a run once this many instance checks have failed (0 disables)
+	AbortAfterErrors int
+
+	// ParallelProviders checks instances as both providers stream them in, instead of
+	// fetching every instance before pairing
+	ParallelProviders bool
+
+	// ErrorOnEmpty fails a run when neither provider returns any instances
+	ErrorOnEmpty bool
+
+	// MinInstances fails a run when either provider returns fewer instances (0 disables)
+	MinInstances int
+
+	// StaticIPsOnly compares private_ip and public_ip only when they are statically assigned
+	StaticIPsOnly bool
+
+	// SourceDeclaredOnly limits comparison to attributes the source instance declares
+	SourceDeclaredOnly bool
+
+	// CheckOrphans reports AWS volumes, network interfaces and Elastic IPs no Terraform instance references
+	CheckOrphans bool
+
+	// CompareOptions controls how attribute values are normalized before comparison
+	CompareOptions model.CompareOptions
+
+	// DigestOptions controls how notification reporters batch results
+	DigestOptions DigestOptions
+
+	// Policies are assertions on the live AWS instance reported as violations rather than drift
+	Policies []model.Policy
+
+	// AllowedInstanceTypes flags instances of any other type as a policy violation (empty allows all)
+	AllowedInstanceTypes []string
+
+	// EnvironmentTag is the AWS tag naming the Terraform workspace an instance belongs to when
+	// the Terraform provider reads several workspaces
+	EnvironmentTag string
+
+	// StrictAccountCheck fails a run whose Terraform state names another AWS account or region
+	// than the AWS client's, instead of warning
+	StrictAccountCheck bool
+
+	// SuggestRemediation attaches suggested commands or configuration changes to drifted results
+	SuggestRemediation bool
+
+	// EnrichNetworkContext describes both subnets of a subnet_id drift and rates moves into
+	// another VPC as high severity
+	EnrichNetworkContext bool
+
+	// CompareTerminationProtection reports instances whose AWS disable_api_termination
+	// disagrees with the lifecycle prevent_destroy of their Terraform resource
+	CompareTerminationProtection bool
+
+	// Memoize reuses the previous run's result for instances that haven't changed on either
+	// side, as long as the attributes and comparison settings are the same
+	Memoize bool
+
+	// HashPrefilter compares a hash of each instance's compared attributes first, and only
+	// compares attribute by attribute when the hashes differ
+	HashPrefilter bool
+
+	// DriftAgeLookback is how many previous results of an instance are searched for when each
+	// of its drifts first appeared; 0 leaves drift ages unset
+	DriftAgeLookback int
+
+	// IncludeSnapshots adds the full attributes of the compared instances to results for
+	// reporting; stored results never carry them
+	IncludeSnapshots bool
+
+	// AllowMismatchedIDs compares instances that don't agree on the key they are paired on,
+	// for callers that deliberately compare two different instances such as two states
+	AllowMismatchedIDs bool
+
+	// ResourceFilter limits runs over all instances to the Terraform resources whose addresses
+	// match one of these addresses or globs (empty checks every instance)
+	ResourceFilter []string
+
+	// VolatileAttributes are left out of results before they are saved, after comparison and
+	// reporting, so that values that change on their own don't churn the stored history
+	VolatileAttributes []string
+
+	// AttributeDumper writes the attributes of each checked instance before comparison (nil disables)
+	AttributeDumper AttributeDumper
 }
@@ -47,6 +47,9 @@ func (m *mockRepository) GetDriftResultsByInstanceID(ctx context.Context, id str
 func (m *mockRepository) ListDriftResults(ctx context.Context) ([]*model.DriftResult, error) {
 	return nil, nil
 }
+func (m *mockRepository) ListDriftResultsWithDrift(ctx context.Context) ([]*model.DriftResult, error) {
+	return nil, nil
+}
 
 type mockReporter struct{}
 
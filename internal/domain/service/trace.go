@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// compareTraceKey is the context key carrying a comparison trace
+type compareTraceKey struct{}
+
+// WithCompareTrace returns a context that makes drift detection record how each attribute is
+// compared into trace
+func WithCompareTrace(ctx context.Context, trace *model.CompareTrace) context.Context {
+	return context.WithValue(ctx, compareTraceKey{}, trace)
+}
+
+// CompareTraceFrom returns the comparison trace carried by ctx, or nil when none is
+func CompareTraceFrom(ctx context.Context) *model.CompareTrace {
+	trace, _ := ctx.Value(compareTraceKey{}).(*model.CompareTrace)
+	return trace
+}
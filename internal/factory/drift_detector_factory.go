@@ -7,6 +7,7 @@ import (
 	"github.com/victor-devv/ec2-drift-detector/internal/config"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/dump"
 )
 
 // DriftDetectorFactory creates drift detector services
@@ -24,13 +25,13 @@ func NewDriftDetectorFactory(logger *logging.Logger) *DriftDetectorFactory {
 // CreateDriftDetector creates a drift detector service based on configuration
 func (f *DriftDetectorFactory) CreateDriftDetector(
 	awsProvider service.InstanceProvider,
-	terraformProvider service.InstanceProvider,
+	terraformProvider service.TerraformProvider,
 	repository service.DriftRepository,
 	reporters []service.Reporter,
 	cfg *config.Config,
 	serviceFactory func(
 		awsProvider service.InstanceProvider,
-		terraformProvider service.InstanceProvider,
+		terraformProvider service.TerraformProvider,
 		repository service.DriftRepository,
 		reporters []service.Reporter,
 		config service.DriftDetectorConfig,
@@ -48,7 +49,44 @@ func (f *DriftDetectorFactory) CreateDriftDetector(
 		AttributePaths:     cfg.GetAttributes(),
 		ParallelChecks:     cfg.GetParallelChecks(),
 		Timeout:            cfg.GetTimeout(),
+		AWSTimeout:         cfg.GetAWSTimeout(),
+		TerraformTimeout:   cfg.GetTerraformTimeout(),
 		ScheduleExpression: cfg.GetScheduleExpression(),
+		AbortAfterErrors:   cfg.GetAbortAfterErrors(),
+		ErrorOnEmpty:       cfg.GetErrorOnEmpty(),
+		MinInstances:       cfg.GetMinInstances(),
+		ParallelProviders:  cfg.GetParallelProviders(),
+		StaticIPsOnly:      cfg.GetStaticIPsOnly(),
+		SourceDeclaredOnly: cfg.GetSourceDeclaredOnly(),
+		CheckOrphans:       cfg.GetCheckOrphans(),
+		AttributeDumper:    f.CreateAttributeDumper(cfg),
+		CompareOptions: model.CompareOptions{
+			EmptyEqualsAbsent:   cfg.GetEmptyEqualsAbsent(),
+			StrictPresencePaths: cfg.GetStrictPresencePaths(),
+			TrimTagValues:       cfg.GetTrimTagValues(),
+			IgnoreTagCase:       cfg.GetIgnoreTagCase(),
+			StoreValues:         cfg.GetStoreValues(),
+			StoreValuesMaxBytes: cfg.GetStoreValuesMaxBytes(),
+			UserDataHash:        cfg.GetUserDataHash(),
+			UserDataDiff:        cfg.GetUserDataDiff(),
+		},
+		Policies:                     cfg.GetPolicies(),
+		AllowedInstanceTypes:         cfg.GetAllowedInstanceTypes(),
+		EnvironmentTag:               cfg.GetEnvironmentTag(),
+		StrictAccountCheck:           cfg.GetStrictAccountCheck(),
+		SuggestRemediation:           cfg.GetSuggestRemediation(),
+		EnrichNetworkContext:         cfg.GetEnrichNetworkContext(),
+		CompareTerminationProtection: cfg.GetCompareTerminationProtection(),
+		Memoize:                      cfg.GetMemoize(),
+		HashPrefilter:                cfg.GetHashPrefilter(),
+		DriftAgeLookback:             cfg.GetDriftAgeLookback(),
+		IncludeSnapshots:             cfg.GetIncludeSnapshots(),
+		VolatileAttributes:           cfg.GetVolatileAttributes(),
+		DigestOptions: service.DigestOptions{
+			Interval:          cfg.GetDigestInterval(),
+			ImmediateSeverity: cfg.GetDigestImmediateSeverity(),
+			Store:             NewRepositoryFactory(f.logger).CreateDigestStore(cfg),
+		},
 	}
 
 	f.logger.Debug("Drift detector configuration:")
@@ -56,7 +94,35 @@ func (f *DriftDetectorFactory) CreateDriftDetector(
 	f.logger.Debug("  - Attribute paths: %v", detectorConfig.AttributePaths)
 	f.logger.Debug("  - Parallel checks: %d", detectorConfig.ParallelChecks)
 	f.logger.Debug("  - Timeout: %s", detectorConfig.Timeout)
+	f.logger.Debug("  - AWS timeout: %s", detectorConfig.AWSTimeout)
+	f.logger.Debug("  - Terraform timeout: %s", detectorConfig.TerraformTimeout)
 	f.logger.Debug("  - Schedule expression: %s", detectorConfig.ScheduleExpression)
+	f.logger.Debug("  - Abort after errors: %d", detectorConfig.AbortAfterErrors)
+	f.logger.Debug("  - Error on empty: %v", detectorConfig.ErrorOnEmpty)
+	f.logger.Debug("  - Minimum instances: %d", detectorConfig.MinInstances)
+	f.logger.Debug("  - Parallel providers: %v", detectorConfig.ParallelProviders)
+	f.logger.Debug("  - Static IPs only: %v", detectorConfig.StaticIPsOnly)
+	f.logger.Debug("  - Source declared only: %v", detectorConfig.SourceDeclaredOnly)
+	f.logger.Debug("  - Check orphans: %v", detectorConfig.CheckOrphans)
+	f.logger.Debug("  - Empty equals absent: %v", detectorConfig.CompareOptions.EmptyEqualsAbsent)
+	f.logger.Debug("  - Strict presence paths: %v", detectorConfig.CompareOptions.StrictPresencePaths)
+	f.logger.Debug("  - Store values: %s", detectorConfig.CompareOptions.StoreValues)
+	f.logger.Debug("  - User data: hash=%v, diff=%v", detectorConfig.CompareOptions.UserDataHash, detectorConfig.CompareOptions.UserDataDiff)
+	f.logger.Debug("  - Policies: %d", len(detectorConfig.Policies))
+	f.logger.Debug("  - Allowed instance types: %v", detectorConfig.AllowedInstanceTypes)
+	f.logger.Debug("  - Environment tag: %s", detectorConfig.EnvironmentTag)
+	f.logger.Debug("  - Strict account check: %v", detectorConfig.StrictAccountCheck)
+	f.logger.Debug("  - Suggest remediation: %v", detectorConfig.SuggestRemediation)
+	f.logger.Debug("  - Enrich network context: %v", detectorConfig.EnrichNetworkContext)
+	f.logger.Debug("  - Compare termination protection: %v", detectorConfig.CompareTerminationProtection)
+	f.logger.Debug("  - Memoize: %v", detectorConfig.Memoize)
+	f.logger.Debug("  - Hash prefilter: %v", detectorConfig.HashPrefilter)
+	f.logger.Debug("  - Drift age lookback: %d", detectorConfig.DriftAgeLookback)
+	f.logger.Debug("  - Include snapshots: %v", detectorConfig.IncludeSnapshots)
+	f.logger.Debug("  - Volatile attributes: %v", detectorConfig.VolatileAttributes)
+	f.logger.Debug("  - Tag value normalization: trim=%v, ignore case=%v", detectorConfig.CompareOptions.TrimTagValues, detectorConfig.CompareOptions.IgnoreTagCase)
+	f.logger.Debug("  - Digest interval: %s", detectorConfig.DigestOptions.Interval)
+	f.logger.Debug("  - Debug dump dir: %s", cfg.GetDebugDumpDir())
 
 	driftDetector := serviceFactory(
 		awsProvider,
@@ -74,13 +140,13 @@ func (f *DriftDetectorFactory) CreateDriftDetector(
 // CreateDriftDetectorWithCustomConfig creates a drift detector with a custom configuration
 func (f *DriftDetectorFactory) CreateDriftDetectorWithCustomConfig(
 	awsProvider service.InstanceProvider,
-	terraformProvider service.InstanceProvider,
+	terraformProvider service.TerraformProvider,
 	repository service.DriftRepository,
 	reporters []service.Reporter,
 	cfg service.DriftDetectorConfig,
 	serviceFactory func(
 		awsProvider service.InstanceProvider,
-		terraformProvider service.InstanceProvider,
+		terraformProvider service.TerraformProvider,
 		repository service.DriftRepository,
 		reporters []service.Reporter,
 		config service.DriftDetectorConfig,
@@ -105,3 +171,19 @@ func (f *DriftDetectorFactory) CreateDriftDetectorWithCustomConfig(
 	f.logger.Info("Drift detector created successfully")
 	return driftDetector, nil
 }
+
+// CreateAttributeDumper creates the debug dumper for the compared attributes, or returns nil
+// when no dump directory is configured
+func (f *DriftDetectorFactory) CreateAttributeDumper(cfg *config.Config) service.AttributeDumper {
+	if cfg.GetDebugDumpDir() == "" {
+		return nil
+	}
+
+	maxInstances := cfg.GetDebugDumpMaxInstances()
+	if maxInstances <= 0 {
+		maxInstances = dump.DefaultMaxInstances
+	}
+
+	f.logger.Info(fmt.Sprintf("Dumping compared attributes of up to %d instances to %s", maxInstances, cfg.GetDebugDumpDir()))
+	return dump.NewAttributeDumper(cfg.GetDebugDumpDir(), maxInstances, nil, f.logger)
+}
@@ -33,6 +33,11 @@ func (m *mockInstanceProvider) ListInstances(ctx context.Context) ([]*model.Inst
 	return args.Get(0).([]*model.Instance), args.Error(1)
 }
 
+func (m *mockInstanceProvider) StreamInstances(ctx context.Context, emit func(*model.Instance) error) error {
+	args := m.Called(ctx, emit)
+	return args.Error(0)
+}
+
 type mockDriftRepository struct {
 	mock.Mock
 }
@@ -55,6 +60,11 @@ func (m *mockDriftRepository) ListDriftResults(ctx context.Context) ([]*model.Dr
 	return args.Get(0).([]*model.DriftResult), args.Error(1)
 }
 
+func (m *mockDriftRepository) ListDriftResultsWithDrift(ctx context.Context) ([]*model.DriftResult, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.DriftResult), args.Error(1)
+}
+
 func (m *mockDriftRepository) GetDriftResultsByInstanceID(ctx context.Context, instanceID string) ([]*model.DriftResult, error) {
 	args := m.Called(ctx, instanceID)
 	return args.Get(0).([]*model.DriftResult), args.Error(1)
@@ -114,6 +124,11 @@ func (m *mockDriftDetector) RunScheduledDriftCheck(ctx context.Context) error {
 	return args.Error(0)
 }
 
+func (m *mockDriftDetector) RunDriftCheck(ctx context.Context, attributePaths []string) (*model.RunSummary, error) {
+	args := m.Called(ctx, attributePaths)
+	return args.Get(0).(*model.RunSummary), args.Error(1)
+}
+
 func (m *mockDriftDetector) StartScheduler(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -143,6 +158,195 @@ func (m *mockDriftDetector) SetScheduleExpression(expression string) {
 	m.Called(expression)
 }
 
+func (m *mockDriftDetector) SetAbortAfterErrors(abortAfterErrors int) {
+	m.Called(abortAfterErrors)
+}
+
+func (m *mockDriftDetector) SetParallelProviders(parallelProviders bool) {
+	m.Called(parallelProviders)
+}
+
+func (m *mockDriftDetector) SetErrorOnEmpty(errorOnEmpty bool) {
+	m.Called(errorOnEmpty)
+}
+
+func (m *mockDriftDetector) SetMinInstances(minInstances int) {
+	m.Called(minInstances)
+}
+
+func (m *mockDriftDetector) SetStaticIPsOnly(staticIPsOnly bool) {
+	m.Called(staticIPsOnly)
+}
+
+func (m *mockDriftDetector) GetSchedulerStatus() model.SchedulerStatus {
+	args := m.Called()
+	return args.Get(0).(model.SchedulerStatus)
+}
+
+func (m *mockDriftDetector) CheckProviders(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *mockDriftDetector) SetAWSTimeout(timeout time.Duration) {
+	m.Called(timeout)
+}
+
+func (m *mockDriftDetector) SetTerraformTimeout(timeout time.Duration) {
+	m.Called(timeout)
+}
+
+func (m *mockDriftDetector) SetCheckOrphans(checkOrphans bool) {
+	m.Called(checkOrphans)
+}
+
+func (m *mockDriftDetector) SetCompareOptions(opts model.CompareOptions) {
+	m.Called(opts)
+}
+
+func (m *mockDriftDetector) RegisterComparator(path string, fn func(a, b interface{}) bool) {
+	m.Called(path, fn)
+}
+
+func (m *mockDriftDetector) SetDigestOptions(opts service.DigestOptions) {
+	m.Called(opts)
+}
+
+func (m *mockDriftDetector) SetPolicies(policies []model.Policy) {
+	m.Called(policies)
+}
+
+func (m *mockDriftDetector) GetPolicies() []model.Policy {
+	args := m.Called()
+	return args.Get(0).([]model.Policy)
+}
+
+func (m *mockDriftDetector) SetAllowedInstanceTypes(instanceTypes []string) {
+	m.Called(instanceTypes)
+}
+
+func (m *mockDriftDetector) GetAllowedInstanceTypes() []string {
+	args := m.Called()
+	return args.Get(0).([]string)
+}
+
+func (m *mockDriftDetector) SetEnvironmentTag(tag string) {
+	m.Called(tag)
+}
+
+func (m *mockDriftDetector) GetEnvironmentTag() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *mockDriftDetector) SetStrictAccountCheck(strict bool) {
+	m.Called(strict)
+}
+
+func (m *mockDriftDetector) GetStrictAccountCheck() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) SetSuggestRemediation(suggest bool) {
+	m.Called(suggest)
+}
+
+func (m *mockDriftDetector) GetSuggestRemediation() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) SetEnrichNetworkContext(enrich bool) {
+	m.Called(enrich)
+}
+
+func (m *mockDriftDetector) GetEnrichNetworkContext() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) SetCompareTerminationProtection(compare bool) {
+	m.Called(compare)
+}
+
+func (m *mockDriftDetector) GetCompareTerminationProtection() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) SetMemoize(memoize bool) {
+	m.Called(memoize)
+}
+
+func (m *mockDriftDetector) GetMemoize() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) SetHashPrefilter(prefilter bool) {
+	m.Called(prefilter)
+}
+
+func (m *mockDriftDetector) GetHashPrefilter() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) SetDriftAgeLookback(lookback int) {
+	m.Called(lookback)
+}
+
+func (m *mockDriftDetector) GetDriftAgeLookback() int {
+	args := m.Called()
+	return args.Int(0)
+}
+
+func (m *mockDriftDetector) SetIncludeSnapshots(include bool) {
+	m.Called(include)
+}
+
+func (m *mockDriftDetector) GetIncludeSnapshots() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) SetResourceFilter(patterns []string) {
+	m.Called(patterns)
+}
+
+func (m *mockDriftDetector) GetResourceFilter() []string {
+	args := m.Called()
+	return args.Get(0).([]string)
+}
+
+func (m *mockDriftDetector) FlushDigests(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *mockDriftDetector) Benchmark(ctx context.Context, options service.BenchmarkOptions) (*model.BenchmarkReport, error) {
+	args := m.Called(ctx, options)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.BenchmarkReport), args.Error(1)
+}
+
+func (m *mockDriftDetector) ReportStoredResults(ctx context.Context, since time.Time, reporters []service.Reporter) error {
+	args := m.Called(ctx, since, reporters)
+	return args.Error(0)
+}
+
+func (m *mockDriftDetector) ShowStoredResult(ctx context.Context, id string, reporters []service.Reporter) error {
+	args := m.Called(ctx, id, reporters)
+	return args.Error(0)
+}
+
+func (m *mockDriftDetector) SetSourceDeclaredOnly(sourceDeclaredOnly bool) {
+	m.Called(sourceDeclaredOnly)
+}
+
 func (m *mockDriftDetector) GetAttributePaths() []string {
 	args := m.Called()
 	return args.Get(0).([]string)
@@ -168,10 +372,81 @@ func (m *mockDriftDetector) GetScheduleExpression() string {
 	return args.String(0)
 }
 
+func (m *mockDriftDetector) GetAbortAfterErrors() int {
+	args := m.Called()
+	return args.Int(0)
+}
+
+func (m *mockDriftDetector) GetParallelProviders() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) GetErrorOnEmpty() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) GetMinInstances() int {
+	args := m.Called()
+	return args.Int(0)
+}
+
+func (m *mockDriftDetector) GetStaticIPsOnly() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) GetAWSTimeout() time.Duration {
+	args := m.Called()
+	return args.Get(0).(time.Duration)
+}
+
+func (m *mockDriftDetector) GetTerraformTimeout() time.Duration {
+	args := m.Called()
+	return args.Get(0).(time.Duration)
+}
+
+func (m *mockDriftDetector) GetCheckOrphans() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *mockDriftDetector) GetCompareOptions() model.CompareOptions {
+	args := m.Called()
+	return args.Get(0).(model.CompareOptions)
+}
+
+func (m *mockDriftDetector) GetDigestOptions() service.DigestOptions {
+	args := m.Called()
+	return args.Get(0).(service.DigestOptions)
+}
+
+func (m *mockDriftDetector) GetSourceDeclaredOnly() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
 func (m *mockDriftDetector) SetReporters(reporters []service.Reporter) {
 	m.Called(reporters)
 }
 
+func (m *mockDriftDetector) SetAWSProvider(provider service.InstanceProvider) {
+	m.Called(provider)
+}
+
+func (m *mockDriftDetector) SetAttributeDumper(dumper service.AttributeDumper) {
+	m.Called(dumper)
+}
+
+func (m *mockDriftDetector) SetStateCacheEnabled(enabled bool) {
+	m.Called(enabled)
+}
+
+func (m *mockDriftDetector) InvalidateStateCache() {
+	m.Called()
+}
+
 func TestNewDriftDetectorFactory(t *testing.T) {
 	logger := logging.New()
 
@@ -198,7 +473,7 @@ func TestCreateDriftDetector_Success(t *testing.T) {
 
 	serviceFactory := func(
 		awsProvider service.InstanceProvider,
-		terraformProvider service.InstanceProvider,
+		terraformProvider service.TerraformProvider,
 		repository service.DriftRepository,
 		reporters []service.Reporter,
 		config service.DriftDetectorConfig,
@@ -278,7 +553,7 @@ func TestCreateDriftDetectorWithCustomConfig_Success(t *testing.T) {
 
 	serviceFactory := func(
 		awsProvider service.InstanceProvider,
-		terraformProvider service.InstanceProvider,
+		terraformProvider service.TerraformProvider,
 		repository service.DriftRepository,
 		reporters []service.Reporter,
 		config service.DriftDetectorConfig,
@@ -2,10 +2,15 @@ package factory
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/config"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
 	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/aws"
 	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/terraform"
@@ -14,6 +19,12 @@ import (
 // InstanceProviderFactory creates instance providers
 type InstanceProviderFactory struct {
 	logger *logging.Logger
+
+	// secrets is the reader of SSM parameters and Secrets Manager secrets for secretsConfig,
+	// shared by configuration references and AMIs HCL reads from SSM parameters
+	mu            sync.Mutex
+	secrets       *aws.SecretReader
+	secretsConfig aws.ClientConfig
 }
 
 // NewInstanceProviderFactory creates a new instance provider factory
@@ -25,37 +36,182 @@ func NewInstanceProviderFactory(logger *logging.Logger) *InstanceProviderFactory
 
 // CreateAWSProvider creates an AWS instance provider
 func (f *InstanceProviderFactory) CreateAWSProvider(ctx context.Context, cfg *config.Config) (service.InstanceProvider, error) {
+	if cfg.GetAWSSource() == config.AWSSourceConfig {
+		return f.createConfigProvider(ctx, cfg, f.AWSClientConfig(cfg), f.logger)
+	}
+
 	// Create AWS client
-	env := cfg.GetEnv()
-	awsClient, err := aws.NewClient(context.Background(), aws.ClientConfig{
-		Region:        cfg.GetAWSRegion(),
-		Profile:       cfg.GetAWSProfile(),
-		Endpoint:      cfg.GetAWSEndpoint(),
-		AccessKey:     cfg.GetAWSAccessKeyID(),
-		SecretKey:     cfg.GetAWSSecretAccessKey(),
-		UseLocalstack: strings.ToLower(env) == "dev" || strings.ToLower(env) == "development",
-	}, f.logger)
+	awsClient, err := aws.NewClient(context.Background(), f.AWSClientConfig(cfg), f.logger)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create EC2 service
 	ec2Service := aws.NewEC2Service(f.logger, awsClient)
+	ec2Service.SetFetchUserData(slices.Contains(cfg.GetAttributes(), model.AttributeUserData))
+	ec2Service.SetFetchTerminationProtection(fetchTerminationProtection(cfg))
 	f.logger.Info("AWS provider initialized")
 	return ec2Service, nil
 }
 
-// CreateTerraformProvider creates a Terraform instance provider
-func (f *InstanceProviderFactory) CreateTerraformProvider(cfg *config.Config) (service.InstanceProvider, error) {
-	// Create Terraform client
-	terraformClient, err := terraform.NewClient(terraform.ClientConfig{
-		StateFile: cfg.GetStateFile(),
-		HCLDir:    cfg.GetHCLDir(),
-		UseHCL:    cfg.GetUseHCL(),
-	}, f.logger)
+// CreateAccountAWSProvider creates an AWS instance provider that assumes the account's role
+func (f *InstanceProviderFactory) CreateAccountAWSProvider(ctx context.Context, cfg *config.Config, account config.AccountConfig) (service.InstanceProvider, error) {
+	if cfg.GetAWSSource() == config.AWSSourceConfig {
+		return f.createConfigProvider(ctx, cfg, f.AccountAWSClientConfig(cfg, account), f.logger.WithField("account_id", account.AccountID()))
+	}
+
+	awsClient, err := aws.NewClient(ctx, f.AccountAWSClientConfig(cfg, account), f.logger.WithField("account_id", account.AccountID()))
+	if err != nil {
+		return nil, err
+	}
+
+	ec2Service := aws.NewEC2Service(f.logger, awsClient)
+	ec2Service.SetFetchUserData(slices.Contains(cfg.GetAttributes(), model.AttributeUserData))
+	ec2Service.SetFetchTerminationProtection(fetchTerminationProtection(cfg))
+	f.logger.Info(fmt.Sprintf("AWS provider initialized for account %s in %s", account.AccountID(), account.Region))
+	return ec2Service, nil
+}
+
+// createConfigProvider creates an AWS instance provider reading the configuration items AWS
+// Config recorded, at the configured point in time
+func (f *InstanceProviderFactory) createConfigProvider(ctx context.Context, cfg *config.Config, clientConfig aws.ClientConfig, logger *logging.Logger) (service.InstanceProvider, error) {
+	awsConfig, err := aws.LoadConfig(ctx, clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	configService := aws.NewConfigService(logger, awsConfig, awsServiceEndpoint(clientConfig))
+	configService.SetAsOf(cfg.GetAWSAsOf())
+	logger.Info(fmt.Sprintf("AWS Config provider initialized for %s", awsConfig.Region))
+	return configService, nil
+}
+
+// CreateSecretResolver creates the resolver of ssm: and secretsmanager: references in
+// configuration values, with the configured AWS credentials and region
+func (f *InstanceProviderFactory) CreateSecretResolver(ctx context.Context, cfg *config.Config) (config.SecretResolver, error) {
+	return f.secretReader(ctx, cfg)
+}
+
+// secretReader returns the reader of SSM parameters and Secrets Manager secrets for the
+// configured AWS settings, creating it when they changed
+func (f *InstanceProviderFactory) secretReader(ctx context.Context, cfg *config.Config) (*aws.SecretReader, error) {
+	clientConfig := f.AWSClientConfig(cfg)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.secrets != nil && f.secretsConfig == clientConfig {
+		return f.secrets, nil
+	}
+
+	awsConfig, err := aws.LoadConfig(ctx, clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	f.secrets = aws.NewSecretReader(awsConfig, awsServiceEndpoint(clientConfig))
+	f.secretsConfig = clientConfig
+	return f.secrets, nil
+}
+
+// fetchTerminationProtection reports whether AWS instances need disable_api_termination, which
+// is only fetched when it is compared as an attribute or against prevent_destroy
+func fetchTerminationProtection(cfg *config.Config) bool {
+	return cfg.GetCompareTerminationProtection() || slices.Contains(cfg.GetAttributes(), model.AttributeDisableAPITermination)
+}
+
+// AWSClientConfig builds the AWS client configuration from the application configuration
+func (f *InstanceProviderFactory) AWSClientConfig(cfg *config.Config) aws.ClientConfig {
+	env := strings.ToLower(cfg.GetEnv())
+	return aws.ClientConfig{
+		Region:        cfg.GetAWSRegion(),
+		Profile:       cfg.GetAWSProfile(),
+		Endpoint:      cfg.GetAWSEndpoint(),
+		AccessKey:     cfg.GetAWSAccessKeyID(),
+		SecretKey:     cfg.GetAWSSecretAccessKey(),
+		UseLocalstack: env == "dev" || env == "development",
+	}
+}
+
+// AccountAWSClientConfig builds the AWS client configuration for an account, assuming its role
+func (f *InstanceProviderFactory) AccountAWSClientConfig(cfg *config.Config, account config.AccountConfig) aws.ClientConfig {
+	clientConfig := f.AWSClientConfig(cfg)
+	clientConfig.Region = account.Region
+	clientConfig.RoleARN = account.RoleARN
+	return clientConfig
+}
+
+// awsServiceEndpoint returns the endpoint for AWS services other than EC2, such as S3 and SSM,
+// empty for the regional AWS endpoint
+func awsServiceEndpoint(clientConfig aws.ClientConfig) string {
+	if clientConfig.Endpoint == "" && clientConfig.UseLocalstack {
+		return aws.DefaultLocalstackEndpoint
+	}
+	return clientConfig.Endpoint
+}
+
+// CreateTerraformProvider creates a Terraform provider, reading HCL when configured to and
+// Terraform state otherwise
+func (f *InstanceProviderFactory) CreateTerraformProvider(cfg *config.Config) (service.TerraformProvider, error) {
+	clientConfig := terraform.ClientConfig{
+		StateFile:          cfg.GetStateFile(),
+		HCLDir:             cfg.GetHCLDir(),
+		SOPSAgeKeyFile:     cfg.GetSOPSAgeKeyFile(),
+		UseTagsAll:         cfg.GetUseTagsAll(),
+		IncludeTainted:     cfg.GetIncludeTainted(),
+		AllowUnsupported:   cfg.GetAllowUnsupportedState(),
+		CacheState:         cfg.GetCacheState(),
+		ReadRetries:        cfg.GetStateReadRetries(),
+		Workspaces:         cfg.GetWorkspaces(),
+		WorkspaceKeyPrefix: cfg.GetWorkspaceKeyPrefix(),
+		TFCWorkspace:       cfg.GetTFCWorkspace(),
+		TFCToken:           cfg.GetTFCToken(),
+		TFCAddress:         cfg.GetTFCAddress(),
+		HTTPAuth: terraform.HTTPStateAuth{
+			Username: cfg.GetStateHTTPUsername(),
+			Password: cfg.GetStateHTTPPassword(),
+			Token:    cfg.GetStateHTTPToken(),
+		},
+	}
+
+	// State in S3 is read with the same AWS credentials as EC2
+	if !cfg.GetUseHCL() && cfg.GetTFCWorkspace() == "" && terraform.StateScheme(cfg.GetStateFile()) == terraform.SchemeS3 {
+		awsClientConfig := f.AWSClientConfig(cfg)
+		awsConfig, err := aws.LoadConfig(context.Background(), awsClientConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		clientConfig.S3Config = awsConfig
+		if region := cfg.GetStateS3Region(); region != "" {
+			clientConfig.S3Config.Region = region
+		}
+		clientConfig.S3Endpoint = awsServiceEndpoint(awsClientConfig)
+		clientConfig.S3UsePathStyle = cfg.GetStateS3UsePathStyle()
+	}
+
+	// AMIs read from SSM parameters are looked up in the region instances are scanned in, with
+	// the reader configuration references are resolved with
+	if cfg.GetUseHCL() && cfg.GetResolveSSMAMI() {
+		secrets, err := f.secretReader(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		if secrets.Region() == "" {
+			return nil, errors.NewValidationError("terraform.resolve_ssm_ami needs an AWS region to look up AMI parameters in; set aws.region or AWS_REGION")
+		}
+		clientConfig.ParameterResolver = secrets
+	}
+
+	var provider service.TerraformProvider
+	var err error
+	if cfg.GetUseHCL() {
+		provider, err = terraform.NewHCLProvider(clientConfig, f.logger)
+	} else {
+		provider, err = terraform.NewStateProvider(clientConfig, f.logger)
+	}
 	if err != nil {
 		return nil, err
 	}
 	f.logger.Info("Terraform provider initialized")
-	return terraformClient, nil
+	return provider, nil
 }
@@ -2,12 +2,17 @@ package factory_test
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/config"
 	"github.com/victor-devv/ec2-drift-detector/internal/factory"
+	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/aws"
+	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/terraform"
 )
 
 func newMockConfig() *config.Config {
@@ -49,6 +54,32 @@ func TestCreateTerraformProvider_Success(t *testing.T) {
 	assert.NotNil(t, provider)
 }
 
+func TestCreateTerraformProvider_SelectsSource(t *testing.T) {
+	f := factory.NewInstanceProviderFactory(logging.New())
+	cfg := newMockConfig()
+
+	provider, err := f.CreateTerraformProvider(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &terraform.StateProvider{}, provider)
+
+	cfg.SetUseHCL(true)
+	provider, err = f.CreateTerraformProvider(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &terraform.HCLProvider{}, provider)
+}
+
+func TestCreateAWSProvider_SelectsConfigSource(t *testing.T) {
+	f := factory.NewInstanceProviderFactory(logging.New())
+	cfg := newMockConfig()
+	cfg.SetAWSSource(config.AWSSourceConfig)
+	cfg.SetAWSAsOf("2024-05-01T12:00:00Z")
+
+	// AWS Config is only called once instances are listed
+	provider, err := f.CreateAWSProvider(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &aws.ConfigService{}, provider)
+}
+
 func TestCreateAWSProvider_InvalidRegion(t *testing.T) {
 	logger := logging.New()
 	f := factory.NewInstanceProviderFactory(logger)
@@ -69,3 +100,57 @@ func TestCreateTerraformProvider_InvalidStateFile(t *testing.T) {
 	_, err := f.CreateTerraformProvider(cfg)
 	assert.Error(t, err)
 }
+
+func TestAWSClientConfig_Profile(t *testing.T) {
+	f := factory.NewInstanceProviderFactory(logging.New())
+	cfg := newMockConfig()
+	cfg.SetAWSProfile("staging")
+
+	clientConfig := f.AWSClientConfig(cfg)
+	assert.Equal(t, "staging", clientConfig.Profile)
+	assert.Equal(t, "eu-north-1", clientConfig.Region)
+	assert.True(t, clientConfig.UseLocalstack)
+
+	account := config.AccountConfig{RoleARN: "arn:aws:iam::123456789012:role/drift-detector", Region: "us-east-1"}
+	accountConfig := f.AccountAWSClientConfig(cfg, account)
+	assert.Equal(t, "staging", accountConfig.Profile)
+	assert.Equal(t, "us-east-1", accountConfig.Region)
+	assert.Equal(t, account.RoleARN, accountConfig.RoleARN)
+}
+
+func TestCreateSecretResolver_Shared(t *testing.T) {
+	f := factory.NewInstanceProviderFactory(logging.New())
+	cfg := newMockConfig()
+
+	first, err := f.CreateSecretResolver(context.Background(), cfg)
+	require.NoError(t, err)
+	second, err := f.CreateSecretResolver(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// Changed AWS settings get a reader of their own
+	cfg.SetAWSRegion("eu-west-1")
+	third, err := f.CreateSecretResolver(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.NotSame(t, first, third)
+}
+
+func TestCreateTerraformProvider_ResolveSSMAMIRequiresRegion(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+
+	f := factory.NewInstanceProviderFactory(logging.New())
+	cfg := newMockConfig()
+	cfg.SetAWSRegion("")
+	cfg.SetUseHCL(true)
+	cfg.SetResolveSSMAMI(true)
+
+	_, err := f.CreateTerraformProvider(cfg)
+	assert.True(t, errors.IsValidationError(err))
+	assert.ErrorContains(t, err, "needs an AWS region")
+
+	cfg.SetAWSRegion("eu-north-1")
+	_, err = f.CreateTerraformProvider(cfg)
+	assert.NoError(t, err)
+}
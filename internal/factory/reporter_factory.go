@@ -1,6 +1,9 @@
 package factory
 
 import (
+	"fmt"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/config"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
@@ -19,30 +22,156 @@ func NewReporterFactory(logger *logging.Logger) *ReporterFactory {
 	}
 }
 
+// CreateReporters creates the configured reporters, each with its own output file
 func (f *ReporterFactory) CreateReporters(cfg *config.Config) ([]service.Reporter, error) {
 	var reporters []service.Reporter
+	for _, rc := range cfg.GetReporters() {
+		r, err := f.CreateReporter(cfg, rc)
+		if err != nil {
+			return nil, err
+		}
+		reporters = append(reporters, r)
+	}
+	f.logger.Info("Reporters created successfully")
+	return reporters, nil
+}
 
-	reporterType := cfg.GetReporterType()
-
-	switch reporterType {
+// CreateReporter creates the reporter an entry of the reporters list describes
+func (f *ReporterFactory) CreateReporter(cfg *config.Config, rc config.ReporterConfig) (service.Reporter, error) {
+	opts := f.ReporterOptionsFor(cfg, rc)
+	switch rc.Type {
 	case config.ReporterTypeConsole:
-		reporters = append(reporters, reporter.NewConsoleReporter(f.logger))
+		return f.templatedConsoleReporter(cfg, opts)
 	case config.ReporterTypeJSON:
-		reporters = append(reporters, reporter.NewJSONReporter(f.logger, cfg.GetOutputFile()))
-	case config.ReporterTypeBoth:
-		reporters = append(reporters, reporter.NewConsoleReporter(f.logger))
-		reporters = append(reporters, reporter.NewJSONReporter(f.logger, cfg.GetOutputFile()))
+		return f.configuredJSONReporter(cfg, opts), nil
+	case config.ReporterTypeMarkdown:
+		return f.markdownReporter(cfg, opts)
+	case config.ReporterTypeTeams:
+		return f.teamsReporter(cfg, opts)
+	case config.ReporterTypeMetrics:
+		return reporter.NewMetricsFileReporter(f.logger, opts), nil
+	default:
+		return nil, errors.NewValidationError(fmt.Sprintf("Unsupported reporter type %q (supported: console, json, markdown, teams, metricsfile)", rc.Type))
+	}
+}
+
+// ReporterOptions returns the options shared by every reporter: reporter.output_file,
+// reporter.pretty_print and reporter.timeout
+func (f *ReporterFactory) ReporterOptions(cfg *config.Config) reporter.ReporterOptions {
+	return reporter.ReporterOptions{
+		OutputFile:  cfg.GetOutputFile(),
+		PrettyPrint: cfg.GetPrettyPrint(),
+		Timeout:     cfg.GetReporterTimeout(),
+	}
+}
+
+// ReporterOptionsFor returns the options of an entry of the reporters list: its own output
+// file and pretty printing, and the shared reporter.timeout
+func (f *ReporterFactory) ReporterOptionsFor(cfg *config.Config, rc config.ReporterConfig) reporter.ReporterOptions {
+	return reporter.ReporterOptions{
+		OutputFile:  rc.OutputFile,
+		PrettyPrint: rc.PrettyPrint,
+		Timeout:     cfg.GetReporterTimeout(),
 	}
-	f.logger.Info("Reporters created successfully")
-	return reporters, nil
 }
 
 // CreateConsoleReporter creates a console reporter
 func (f *ReporterFactory) CreateConsoleReporter(logger *logging.Logger) service.Reporter {
-	return reporter.NewConsoleReporter(logger)
+	return reporter.NewConsoleReporter(logger, reporter.ReporterOptions{})
+}
+
+// CreateTemplatedConsoleReporter creates a console reporter rendering run reports with
+// reporter.console.template, or the built-in template when it is unset, with timestamps in
+// reporter.timezone
+func (f *ReporterFactory) CreateTemplatedConsoleReporter(cfg *config.Config) (service.Reporter, error) {
+	return f.templatedConsoleReporter(cfg, f.ReporterOptions(cfg))
+}
+
+// templatedConsoleReporter creates a templated console reporter with the given options
+func (f *ReporterFactory) templatedConsoleReporter(cfg *config.Config, opts reporter.ReporterOptions) (service.Reporter, error) {
+	tmpl, err := reporter.LoadTemplate(reporter.TemplateConsole, cfg.GetConsoleTemplate())
+	if err != nil {
+		return nil, err
+	}
+	loc, err := reporter.LoadLocation(cfg.GetTimezone())
+	if err != nil {
+		return nil, err
+	}
+
+	console := reporter.NewConsoleReporterWithTemplate(f.logger, opts, tmpl)
+	console.SetLocation(loc)
+	console.SetSeverityColors(cfg.GetSeverityColors())
+	return console, nil
+}
+
+// CreateMarkdownReporter creates a Markdown reporter rendering reporter.markdown.template, or the
+// built-in template when it is unset, to the output file with timestamps in reporter.timezone
+func (f *ReporterFactory) CreateMarkdownReporter(cfg *config.Config) (service.Reporter, error) {
+	return f.markdownReporter(cfg, f.ReporterOptions(cfg))
+}
+
+// markdownReporter creates a Markdown reporter with the given options
+func (f *ReporterFactory) markdownReporter(cfg *config.Config, opts reporter.ReporterOptions) (service.Reporter, error) {
+	tmpl, err := reporter.LoadTemplate(reporter.TemplateMarkdown, cfg.GetMarkdownTemplate())
+	if err != nil {
+		return nil, err
+	}
+	loc, err := reporter.LoadLocation(cfg.GetTimezone())
+	if err != nil {
+		return nil, err
+	}
+
+	markdown := reporter.NewMarkdownReporter(f.logger, opts, tmpl)
+	markdown.SetLocation(loc)
+	return markdown, nil
 }
 
 // CreateJSONReporter creates a JSON reporter
 func (f *ReporterFactory) CreateJSONReporter(logger *logging.Logger, outputFile string) service.Reporter {
-	return reporter.NewJSONReporter(logger, outputFile)
+	return reporter.NewJSONReporter(logger, reporter.ReporterOptions{OutputFile: outputFile, PrettyPrint: true})
+}
+
+// CreateConfiguredJSONReporter creates a JSON reporter writing to the output file, split across
+// several files above reporter.json.max_results_per_file results and truncated past
+// reporter.max_bytes, and confirming runs without drift with a clean report when
+// reporter.json.clean_report is set
+func (f *ReporterFactory) CreateConfiguredJSONReporter(cfg *config.Config) service.Reporter {
+	return f.configuredJSONReporter(cfg, f.ReporterOptions(cfg))
+}
+
+// configuredJSONReporter creates a configured JSON reporter with the given options
+func (f *ReporterFactory) configuredJSONReporter(cfg *config.Config, opts reporter.ReporterOptions) service.Reporter {
+	json := reporter.NewJSONReporter(f.logger, opts)
+	json.SetMaxResultsPerFile(cfg.GetJSONMaxResultsPerFile())
+	json.SetMaxBytes(cfg.GetReporterMaxBytes())
+	if cfg.GetJSONCleanReport() {
+		json.EnableCleanReport(cfg.GetAttributes(), cfg.GetJSONSigningKey())
+	}
+	return json
+}
+
+// CreateTeamsReporter creates a Teams reporter that posts through the shared HTTP sender
+func (f *ReporterFactory) CreateTeamsReporter(cfg *config.Config) (service.Reporter, error) {
+	return f.teamsReporter(cfg, f.ReporterOptions(cfg))
+}
+
+// teamsReporter creates a Teams reporter with the given options
+func (f *ReporterFactory) teamsReporter(cfg *config.Config, opts reporter.ReporterOptions) (service.Reporter, error) {
+	sender, err := f.CreateHTTPSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return reporter.NewTeamsReporter(f.logger, opts, sender, reporter.TeamsOptions{
+		WebhookURL:   cfg.GetTeamsWebhookURL(),
+		MaxInstances: cfg.GetTeamsMaxInstances(),
+		ReportURL:    cfg.GetTeamsReportURL(),
+	}), nil
+}
+
+// CreateHTTPSender creates the HTTP sender used by webhook reporters
+func (f *ReporterFactory) CreateHTTPSender(cfg *config.Config) (*reporter.HTTPSender, error) {
+	return reporter.NewHTTPSender(f.logger, reporter.HTTPSenderOptions{
+		MaxRetries: cfg.GetHTTPMaxRetries(),
+		ProxyURL:   cfg.GetHTTPProxyURL(),
+	})
 }
@@ -1,12 +1,16 @@
 package factory_test
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	apperrors "github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/config"
 	"github.com/victor-devv/ec2-drift-detector/internal/factory"
+	"github.com/victor-devv/ec2-drift-detector/internal/presentation/reporter"
 )
 
 func newTestConfig(reporterType, outputFile string) *config.Config {
@@ -30,6 +34,18 @@ func TestCreateJSONReporter(t *testing.T) {
 	assert.NotNil(t, r)
 }
 
+func TestCreateConfiguredJSONReporter(t *testing.T) {
+	logger := logging.New()
+	factory := factory.NewReporterFactory(logger)
+	cfg := newTestConfig("json", "report.json")
+	cfg.SetJSONMaxResultsPerFile(500)
+
+	r := factory.CreateConfiguredJSONReporter(cfg)
+	json, ok := r.(*reporter.JSONReporter)
+	assert.True(t, ok)
+	assert.Equal(t, 500, json.GetMaxResultsPerFile())
+}
+
 func TestCreateReporters_ConsoleOnly(t *testing.T) {
 	logger := logging.New()
 	factory := factory.NewReporterFactory(logger)
@@ -57,7 +73,20 @@ func TestCreateReporters_Both(t *testing.T) {
 
 	reporters, err := factory.CreateReporters(cfg)
 	assert.NoError(t, err)
-	assert.Len(t, reporters, 2)
+	if assert.Len(t, reporters, 2) {
+		assert.IsType(t, &reporter.ConsoleReporter{}, reporters[0])
+		assert.IsType(t, &reporter.JSONReporter{}, reporters[1])
+	}
+}
+
+func TestCreateReporters_UnknownType(t *testing.T) {
+	logger := logging.New()
+	factory := factory.NewReporterFactory(logger)
+	cfg := newTestConfig("xml", "report.xml")
+
+	_, err := factory.CreateReporters(cfg)
+	assert.Error(t, err)
+	assert.True(t, apperrors.IsValidationError(err))
 }
 
 func TestCreateReporters_JSONMissingFile(t *testing.T) {
@@ -69,3 +98,87 @@ func TestCreateReporters_JSONMissingFile(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, reporters, 1)
 }
+
+func TestCreateReporters_Markdown(t *testing.T) {
+	logger := logging.New()
+	factory := factory.NewReporterFactory(logger)
+	cfg := newTestConfig("markdown", "report.md")
+
+	reporters, err := factory.CreateReporters(cfg)
+	assert.NoError(t, err)
+	assert.Len(t, reporters, 1)
+
+	// A template override that doesn't parse fails reporter creation
+	cfg.SetMarkdownTemplate(filepath.Join(t.TempDir(), "missing.tmpl"))
+	_, err = factory.CreateReporters(cfg)
+	assert.Error(t, err)
+}
+
+func TestCreateReporters_PropagatesOptions(t *testing.T) {
+	type optionsReporter interface {
+		Options() reporter.ReporterOptions
+	}
+
+	for _, reporterType := range []string{"console", "json", "both", "markdown", "teams"} {
+		t.Run(reporterType, func(t *testing.T) {
+			logger := logging.New()
+			factory := factory.NewReporterFactory(logger)
+			cfg := newTestConfig(reporterType, "report.out")
+			cfg.SetPrettyPrint(false)
+			cfg.SetReporterTimeout(45 * time.Second)
+			cfg.SetTeamsWebhookURL("https://example.webhook.office.com/webhook")
+
+			reporters, err := factory.CreateReporters(cfg)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, reporters)
+
+			for _, r := range reporters {
+				options, ok := r.(optionsReporter)
+				if !assert.True(t, ok, "%T doesn't expose its options", r) {
+					continue
+				}
+				assert.False(t, options.Options().PrettyPrint)
+				assert.Equal(t, 45*time.Second, options.Options().Timeout)
+				// The JSON reporter appends a timestamp to the file name
+				assert.Contains(t, options.Options().OutputFile, "report")
+			}
+		})
+	}
+}
+
+func TestCreateReporters_List(t *testing.T) {
+	type optionsReporter interface {
+		Options() reporter.ReporterOptions
+	}
+
+	logger := logging.New()
+	factory := factory.NewReporterFactory(logger)
+	cfg := newTestConfig("console", "ignored.json")
+	cfg.SetReporterTimeout(30 * time.Second)
+	cfg.SetReporters([]config.ReporterConfig{
+		{Type: config.ReporterTypeConsole},
+		{Type: config.ReporterTypeJSON, OutputFile: "out/drift.json", PrettyPrint: true},
+		{Type: config.ReporterTypeMarkdown, OutputFile: "out/drift.md"},
+	})
+
+	reporters, err := factory.CreateReporters(cfg)
+	assert.NoError(t, err)
+	if !assert.Len(t, reporters, 3) {
+		return
+	}
+	assert.IsType(t, &reporter.ConsoleReporter{}, reporters[0])
+	assert.IsType(t, &reporter.JSONReporter{}, reporters[1])
+	assert.IsType(t, &reporter.MarkdownReporter{}, reporters[2])
+
+	json := reporters[1].(optionsReporter).Options()
+	assert.Contains(t, json.OutputFile, "out/drift")
+	assert.True(t, json.PrettyPrint)
+	assert.Equal(t, 30*time.Second, json.Timeout)
+	markdown := reporters[2].(optionsReporter).Options()
+	assert.Equal(t, "out/drift.md", markdown.OutputFile)
+	assert.False(t, markdown.PrettyPrint)
+
+	cfg.SetReporters([]config.ReporterConfig{{Type: "csv"}})
+	_, err = factory.CreateReporters(cfg)
+	assert.Error(t, err)
+}
@@ -56,6 +56,21 @@ func (f *RepositoryFactory) CreateHistoricalDriftRepository(cfg *config.Config)
 	return f.CreateDriftRepositoryWithConfig(cfg)
 }
 
+// CreateDigestStore creates the store notification digests are buffered in: the file at
+// reporter.digest_file, or nil to buffer them in the drift repository when no file is set
+// or digests are disabled
+func (f *RepositoryFactory) CreateDigestStore(cfg *config.Config) service.DigestStore {
+	if cfg.GetDigestInterval() <= 0 && cfg.GetDigestImmediateSeverity() == "" {
+		return nil
+	}
+	path := cfg.GetDigestFile()
+	if path == "" {
+		return nil
+	}
+	f.logger.Debug(fmt.Sprintf("Buffering notification digests in %s", path))
+	return repository.NewFileDigestStore(path, f.logger)
+}
+
 // GetRepositoryStats returns statistics about the repository
 // Useful for monitoring and debugging
 func (f *RepositoryFactory) GetRepositoryStats(repo service.DriftRepository) map[string]interface{} {
@@ -7,14 +7,20 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 )
 
+// DefaultLocalstackEndpoint is used in development when no endpoint is configured
+const DefaultLocalstackEndpoint = "http://localhost:4566"
+
 // Client encapsulates AWS SDK client for EC2 operations
 type Client struct {
 	EC2Client *ec2.Client
+	STSClient *sts.Client
 	logger    *logging.Logger
 	region    string
 	endpoint  string
@@ -27,6 +33,7 @@ type ClientConfig struct {
 	AccessKey     string
 	SecretKey     string
 	Endpoint      string
+	RoleARN       string
 	UseLocalstack bool
 }
 
@@ -34,48 +41,38 @@ type ClientConfig struct {
 func NewClient(ctx context.Context, cfg ClientConfig, logger *logging.Logger) (*Client, error) {
 	logger = logger.WithField("component", "aws-client")
 
-	// Start with default AWS SDK configuration options
-	var optFns []func(*config.LoadOptions) error
-
-	// Apply AWS region if specified
-	if cfg.Region != "" {
-		optFns = append(optFns, config.WithRegion(cfg.Region))
-	}
-
-	if cfg.AccessKey != "" && cfg.SecretKey != "" {
-		optFns = append(optFns, config.WithCredentialsProvider(
-			credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, ""),
-		))
-	}
-
-	// Apply AWS profile if specified
-	if cfg.Profile != "" {
-		optFns = append(optFns, config.WithSharedConfigProfile(cfg.Profile))
-	}
-
-	// Load AWS SDK configuration
-	awsConfig, err := config.LoadDefaultConfig(ctx, optFns...)
+	awsConfig, err := LoadConfig(ctx, cfg)
 	if err != nil {
-		return nil, errors.NewSystemError("Failed to load AWS configuration", err)
+		return nil, err
+	}
+	if cfg.RoleARN != "" {
+		logger.Info(fmt.Sprintf("Assuming role %s", cfg.RoleARN))
 	}
 
 	client := &Client{
 		logger: logger,
 		region: cfg.Region,
 	}
+	if client.region == "" {
+		client.region = awsConfig.Region
+	}
 
 	// Set custom endpoint for LocalStack if dev
 	ec2Options := []func(*ec2.Options){}
+	stsOptions := []func(*sts.Options){}
 
 	if cfg.UseLocalstack {
 		if cfg.Endpoint == "" {
-			cfg.Endpoint = "http://localhost:4566"
+			cfg.Endpoint = DefaultLocalstackEndpoint
 		}
 		client.endpoint = cfg.Endpoint
 		ec2Options = append(ec2Options, func(o *ec2.Options) {
 			o.BaseEndpoint = aws.String(cfg.Endpoint)
 			o.Region = cfg.Region
 		})
+		stsOptions = append(stsOptions, func(o *sts.Options) {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		})
 		logger.Info(fmt.Sprintf("Using LocalStack endpoint: %s", cfg.Endpoint))
 	} else if cfg.Endpoint != "" {
 		client.endpoint = cfg.Endpoint
@@ -83,12 +80,18 @@ func NewClient(ctx context.Context, cfg ClientConfig, logger *logging.Logger) (*
 			o.BaseEndpoint = aws.String(cfg.Endpoint)
 			o.Region = cfg.Region
 		})
+		stsOptions = append(stsOptions, func(o *sts.Options) {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		})
 		logger.Info(fmt.Sprintf("Using custom endpoint: %s", cfg.Endpoint))
 	}
 
 	// Create EC2 client
 	client.EC2Client = ec2.NewFromConfig(awsConfig, ec2Options...)
 
+	// STS tells which account the credentials belong to
+	client.STSClient = sts.NewFromConfig(awsConfig, stsOptions...)
+
 	// Test connection to AWS
 	if err := client.testConnection(ctx); err != nil {
 		return nil, err
@@ -110,6 +113,15 @@ func (c *Client) testConnection(ctx context.Context) error {
 	return nil
 }
 
+// GetCallerAccountID returns the AWS account the client's credentials belong to
+func (c *Client) GetCallerAccountID(ctx context.Context) (string, error) {
+	identity, err := c.STSClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", errors.NewOperationalError("Failed to look up the AWS account of the configured credentials", err)
+	}
+	return aws.ToString(identity.Account), nil
+}
+
 // GetRegion returns the AWS region
 func (c *Client) GetRegion() string {
 	return c.region
@@ -119,3 +131,44 @@ func (c *Client) GetRegion() string {
 func (c *Client) GetEndpoint() string {
 	return c.endpoint
 }
+
+// LoadConfig loads the AWS SDK configuration (region, credentials and assumed role) for the
+// client configuration, for callers that talk to AWS services other than EC2
+func LoadConfig(ctx context.Context, cfg ClientConfig) (aws.Config, error) {
+	// Start with default AWS SDK configuration options
+	var optFns []func(*config.LoadOptions) error
+
+	// Apply AWS region if specified
+	if cfg.Region != "" {
+		optFns = append(optFns, config.WithRegion(cfg.Region))
+	}
+
+	if cfg.AccessKey != "" && cfg.SecretKey != "" {
+		optFns = append(optFns, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, ""),
+		))
+	}
+
+	// Apply AWS profile if specified
+	if cfg.Profile != "" {
+		optFns = append(optFns, config.WithSharedConfigProfile(cfg.Profile))
+	}
+
+	// Load AWS SDK configuration
+	awsConfig, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return aws.Config{}, errors.NewSystemError("Failed to load AWS configuration", err)
+	}
+
+	// Assume the configured role so the client operates in the target account
+	if cfg.RoleARN != "" {
+		stsClient := sts.NewFromConfig(awsConfig, func(o *sts.Options) {
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+		})
+		awsConfig.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, cfg.RoleARN))
+	}
+
+	return awsConfig, nil
+}
@@ -0,0 +1,268 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/configservice"
+	configtypes "github.com/aws/aws-sdk-go-v2/service/configservice/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// configSelectExpression selects the current configuration item of every recorded instance
+const configSelectExpression = "SELECT resourceId, configuration WHERE resourceType = '" + string(configtypes.ResourceTypeInstance) + "'"
+
+// configPageSize is the number of results requested per AWS Config page, the API's maximum
+const configPageSize = 100
+
+// ConfigService reads instances from the configuration items AWS Config recorded rather than
+// from DescribeInstances. Without a point in time it reads the latest item of each recorded
+// instance; with one it reads the item recorded at that time, which includes instances that
+// have been deleted since.
+type ConfigService struct {
+	client *configservice.Client
+	logger *logging.Logger
+	region string
+	signed bool
+
+	// asOf is the point in time instances are read at; zero reads the latest items
+	asOf time.Time
+}
+
+// configurationItem is the part of an AWS Config configuration item that is used
+type configurationItem struct {
+	ResourceID string `json:"resourceId"`
+
+	// Configuration is the instance as DescribeInstances returns it
+	Configuration json.RawMessage `json:"configuration"`
+}
+
+// NewConfigService creates a service reading the instances AWS Config recorded in cfg.Region,
+// authenticating with cfg.Credentials. A custom endpoint (e.g. LocalStack) replaces the
+// regional AWS Config endpoint.
+func NewConfigService(logger *logging.Logger, cfg aws.Config, endpoint string) *ConfigService {
+	endpoint = strings.TrimRight(endpoint, "/")
+
+	return &ConfigService{
+		client: configservice.NewFromConfig(cfg, func(o *configservice.Options) {
+			if endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+			}
+		}),
+		logger: logger.WithField("component", "aws-config"),
+		region: cfg.Region,
+		signed: cfg.Credentials != nil,
+	}
+}
+
+// SetAsOf sets the point in time instances are read at; zero reads the latest items
+func (s *ConfigService) SetAsOf(asOf time.Time) {
+	s.asOf = asOf
+}
+
+// GetInstance retrieves the recorded configuration of an instance
+func (s *ConfigService) GetInstance(ctx context.Context, instanceID string) (*model.Instance, error) {
+	s.logger.Info(fmt.Sprintf("Retrieving recorded configuration of EC2 instance: %s", instanceID))
+
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	item, err := s.recordedItem(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, errors.NewNotFoundError("EC2 Instance", instanceID)
+	}
+	return mapConfigurationItem(*item)
+}
+
+// ListInstances retrieves the recorded configuration of all instances
+func (s *ConfigService) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	var items []configurationItem
+	var err error
+	if s.asOf.IsZero() {
+		s.logger.Info("Listing EC2 instances recorded by AWS Config")
+		items, err = s.selectItems(ctx)
+	} else {
+		s.logger.Info(fmt.Sprintf("Listing EC2 instances recorded by AWS Config as of %s", s.asOf.UTC().Format(time.RFC3339)))
+		items, err = s.historyItems(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]*model.Instance, 0, len(items))
+	for _, item := range items {
+		instance, err := mapConfigurationItem(item)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, instance)
+	}
+
+	s.logger.Info(fmt.Sprintf("Found %d recorded EC2 instances", len(instances)))
+	return instances, nil
+}
+
+// check reports why AWS Config can't be read: without credentials there is nothing to sign
+// the requests with
+func (s *ConfigService) check() error {
+	if !s.signed {
+		return errors.NewValidationError("AWS credentials are required to read AWS Config")
+	}
+	return nil
+}
+
+// selectItems queries the latest configuration item of every recorded instance
+func (s *ConfigService) selectItems(ctx context.Context) ([]configurationItem, error) {
+	var items []configurationItem
+	paginator := configservice.NewSelectResourceConfigPaginator(s.client, &configservice.SelectResourceConfigInput{
+		Expression: aws.String(configSelectExpression),
+		Limit:      configPageSize,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, s.requestError("SelectResourceConfig", "", err)
+		}
+
+		// Each result is a JSON document with the selected fields
+		for _, result := range page.Results {
+			var item configurationItem
+			if err := json.Unmarshal([]byte(result), &item); err != nil {
+				return nil, errors.NewOperationalError("Invalid AWS Config query result", err)
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// historyItems reads the configuration item each instance had at the point in time. Deleted
+// instances are listed too, so instances that have since been terminated are still found;
+// those that didn't exist yet or had already been deleted then are left out.
+func (s *ConfigService) historyItems(ctx context.Context) ([]configurationItem, error) {
+	var items []configurationItem
+	paginator := configservice.NewListDiscoveredResourcesPaginator(s.client, &configservice.ListDiscoveredResourcesInput{
+		ResourceType:            configtypes.ResourceTypeInstance,
+		IncludeDeletedResources: true,
+		Limit:                   configPageSize,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, s.requestError("ListDiscoveredResources", "", err)
+		}
+
+		for _, resource := range page.ResourceIdentifiers {
+			item, err := s.recordedItem(ctx, aws.ToString(resource.ResourceId))
+			if err != nil {
+				return nil, err
+			}
+			if item != nil {
+				items = append(items, *item)
+			}
+		}
+	}
+	return items, nil
+}
+
+// recordedItem returns the configuration item of an instance at the point in time, or nil when
+// the instance didn't exist then or its configuration wasn't recorded
+func (s *ConfigService) recordedItem(ctx context.Context, instanceID string) (*configurationItem, error) {
+	input := &configservice.GetResourceConfigHistoryInput{
+		ResourceType: configtypes.ResourceTypeInstance,
+		ResourceId:   aws.String(instanceID),
+		Limit:        1,
+	}
+	if !s.asOf.IsZero() {
+		input.LaterTime = aws.Time(s.asOf)
+	}
+
+	history, err := s.client.GetResourceConfigHistory(ctx, input)
+	if err != nil {
+		err = s.requestError("GetResourceConfigHistory", instanceID, err)
+		if errors.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(history.ConfigurationItems) == 0 {
+		return nil, nil
+	}
+
+	item := history.ConfigurationItems[0]
+	switch item.ConfigurationItemStatus {
+	case configtypes.ConfigurationItemStatusResourceDeleted, configtypes.ConfigurationItemStatusResourceDeletedNotRecorded:
+		return nil, nil
+	case configtypes.ConfigurationItemStatusResourceNotRecorded:
+		s.logger.Warn(fmt.Sprintf("AWS Config did not record the configuration of instance %s", instanceID))
+		return nil, nil
+	}
+
+	return &configurationItem{
+		ResourceID:    aws.ToString(item.ResourceId),
+		Configuration: json.RawMessage(aws.ToString(item.Configuration)),
+	}, nil
+}
+
+// requestError turns a failed AWS Config request into an error naming the likely cause
+func (s *ConfigService) requestError(action, instanceID string, err error) error {
+	code := ""
+	var apiErr smithy.APIError
+	if stderrors.As(err, &apiErr) {
+		code = apiErr.ErrorCode()
+	}
+	status := 0
+	var respErr *awshttp.ResponseError
+	if stderrors.As(err, &respErr) {
+		status = respErr.HTTPStatusCode()
+	}
+
+	switch {
+	case code == "ResourceNotDiscoveredException":
+		return errors.NewNotFoundError("Recorded EC2 Instance", instanceID)
+	case code == "NoAvailableConfigurationRecorderException":
+		return errors.NewValidationError(fmt.Sprintf("AWS Config has no configuration recorder in region %s; aws.source config needs one recording %s", s.region, configtypes.ResourceTypeInstance))
+	case status == http.StatusForbidden || code == "AccessDeniedException":
+		return errors.NewOperationalError(fmt.Sprintf("Access denied calling AWS Config %s; config:%s is required", action, action), err)
+	case status != 0:
+		return errors.NewOperationalError(fmt.Sprintf("AWS Config %s failed with HTTP %d", action, status), err)
+	}
+	return errors.NewOperationalError(fmt.Sprintf("Failed to reach AWS Config for %s", action), err)
+}
+
+// mapConfigurationItem maps a recorded configuration item to our domain model. AWS Config
+// records the instance in the DescribeInstances shape with camelCase keys, which decode into
+// the SDK type, so the attributes are derived exactly as for live instances. What
+// DescribeInstanceAttribute returns isn't recorded and is left unknown rather than compared.
+func mapConfigurationItem(item configurationItem) (*model.Instance, error) {
+	var recorded types.Instance
+	if err := json.Unmarshal(item.Configuration, &recorded); err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Invalid configuration recorded by AWS Config for instance %s", item.ResourceID), err)
+	}
+	if recorded.InstanceId == nil && item.ResourceID != "" {
+		recorded.InstanceId = aws.String(item.ResourceID)
+	}
+
+	instance := mapToInstance(recorded)
+	instance.Attributes[model.AttributeUserData] = model.UnknownValue{Reason: "AWS Config does not record user data"}
+	instance.Attributes[model.AttributeDisableAPITermination] = model.UnknownValue{Reason: "AWS Config does not record disable_api_termination"}
+	return instance, nil
+}
@@ -0,0 +1,178 @@
+package aws_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	awsinfra "github.com/victor-devv/ec2-drift-detector/internal/infrastructure/aws"
+)
+
+// recordedInstance renders the configuration AWS Config records for an instance
+func recordedInstance(id, instanceType string) string {
+	return `{"instanceId":"` + id + `","imageId":"ami-0123456789abcdef0","instanceType":"` + instanceType + `",` +
+		`"launchTime":"2024-04-01T09:30:00.000Z","placement":{"availabilityZone":"us-east-1a","tenancy":"default"},` +
+		`"privateIpAddress":"10.0.1.10","subnetId":"subnet-1","vpcId":"vpc-1",` +
+		`"securityGroups":[{"groupName":"web","groupId":"sg-1"}],"state":{"code":16,"name":"running"},` +
+		`"tags":[{"key":"Name","value":"` + id + `"}],` +
+		`"networkInterfaces":[{"attachment":{"deviceIndex":0},"privateIpAddresses":[{"primary":true,"privateIpAddress":"10.0.1.10"},{"primary":false,"privateIpAddress":"10.0.1.11"}]}]}`
+}
+
+// fakeConfig serves AWS Config actions from handlers keyed by the action name
+func fakeConfig(t *testing.T, handlers map[string]func(request map[string]interface{}) (int, interface{})) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Authorization"), "/config/aws4_request")
+
+		var request map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "StarlingDoveService.")
+		handler, ok := handlers[action]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		status, response := handler(request)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(status)
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+}
+
+func newConfigService(endpoint string) *awsinfra.ConfigService {
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("test", "secret", ""),
+		Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+	}
+	return awsinfra.NewConfigService(logging.New(), cfg, endpoint)
+}
+
+func TestConfigService_ListInstances(t *testing.T) {
+	server := fakeConfig(t, map[string]func(map[string]interface{}) (int, interface{}){
+		"SelectResourceConfig": func(request map[string]interface{}) (int, interface{}) {
+			assert.Contains(t, request["Expression"], "resourceType = 'AWS::EC2::Instance'")
+			if request["NextToken"] == nil {
+				return http.StatusOK, map[string]interface{}{
+					"Results":   []string{`{"resourceId":"i-web","configuration":` + recordedInstance("i-web", "t3.micro") + `}`},
+					"NextToken": "page-2",
+				}
+			}
+			return http.StatusOK, map[string]interface{}{
+				"Results": []string{`{"resourceId":"i-api","configuration":` + recordedInstance("i-api", "t3.large") + `}`},
+			}
+		},
+	})
+	defer server.Close()
+
+	instances, err := newConfigService(server.URL).ListInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	web := instances[0]
+	assert.Equal(t, "i-web", web.ID)
+	assert.Equal(t, model.OriginAWS, web.Origin)
+	assert.Equal(t, "t3.micro", web.Attributes["instance_type"])
+	assert.Equal(t, "ami-0123456789abcdef0", web.Attributes["ami"])
+	assert.Equal(t, []string{"sg-1"}, web.Attributes["vpc_security_group_ids"])
+	assert.Equal(t, map[string]string{"Name": "i-web"}, web.Attributes["tags"])
+	assert.Equal(t, []string{"10.0.1.11"}, web.Attributes[model.AttributeSecondaryPrivateIPs])
+	assert.Equal(t, model.InstanceStateRunning, web.Attributes[model.AttributeInstanceState])
+	assert.Equal(t, "2024-04-01T09:30:00Z", web.Attributes[model.AttributeLaunchTime])
+
+	// Attributes DescribeInstances doesn't return aren't recorded either
+	assert.True(t, model.IsUnknown(web.Attributes[model.AttributeUserData]))
+	assert.True(t, model.IsUnknown(web.Attributes[model.AttributeDisableAPITermination]))
+
+	assert.Equal(t, "t3.large", instances[1].Attributes["instance_type"])
+}
+
+func TestConfigService_ListInstancesAsOf(t *testing.T) {
+	asOf := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	history := map[string]map[string]interface{}{
+		// Terminated since, but running at the point in time
+		"i-gone": {"resourceId": "i-gone", "configurationItemStatus": "OK", "configuration": recordedInstance("i-gone", "t3.micro")},
+		"i-old":  {"resourceId": "i-old", "configurationItemStatus": "ResourceDeleted"},
+		"i-live": {"resourceId": "i-live", "configurationItemStatus": "ResourceDiscovered", "configuration": recordedInstance("i-live", "t3.small")},
+	}
+
+	server := fakeConfig(t, map[string]func(map[string]interface{}) (int, interface{}){
+		"ListDiscoveredResources": func(request map[string]interface{}) (int, interface{}) {
+			assert.Equal(t, true, request["includeDeletedResources"])
+			return http.StatusOK, map[string]interface{}{
+				"resourceIdentifiers": []map[string]string{{"resourceId": "i-gone"}, {"resourceId": "i-old"}, {"resourceId": "i-live"}, {"resourceId": "i-new"}},
+			}
+		},
+		"GetResourceConfigHistory": func(request map[string]interface{}) (int, interface{}) {
+			assert.Equal(t, float64(asOf.Unix()), request["laterTime"])
+			item, ok := history[request["resourceId"].(string)]
+			if !ok {
+				// Discovered after the point in time
+				return http.StatusOK, map[string]interface{}{"configurationItems": []interface{}{}}
+			}
+			return http.StatusOK, map[string]interface{}{"configurationItems": []interface{}{item}}
+		},
+	})
+	defer server.Close()
+
+	service := newConfigService(server.URL)
+	service.SetAsOf(asOf)
+	instances, err := service.ListInstances(context.Background())
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.ID)
+	}
+	assert.Equal(t, []string{"i-gone", "i-live"}, ids)
+	assert.Equal(t, "t3.micro", instances[0].Attributes["instance_type"])
+}
+
+func TestConfigService_GetInstance(t *testing.T) {
+	server := fakeConfig(t, map[string]func(map[string]interface{}) (int, interface{}){
+		"GetResourceConfigHistory": func(request map[string]interface{}) (int, interface{}) {
+			assert.NotContains(t, request, "laterTime")
+			if request["resourceId"] == "i-web" {
+				return http.StatusOK, map[string]interface{}{"configurationItems": []interface{}{
+					map[string]interface{}{"resourceId": "i-web", "configurationItemStatus": "OK", "configuration": recordedInstance("i-web", "t3.micro")},
+				}}
+			}
+			return http.StatusBadRequest, map[string]string{
+				"__type":  "com.amazonaws.starlingdoveservice#ResourceNotDiscoveredException",
+				"message": "Resource i-missing of resourceType:AWS::EC2::Instance is unknown or has not been discovered",
+			}
+		},
+	})
+	defer server.Close()
+
+	service := newConfigService(server.URL)
+	instance, err := service.GetInstance(context.Background(), "i-web")
+	require.NoError(t, err)
+	assert.Equal(t, "t3.micro", instance.Attributes["instance_type"])
+
+	_, err = service.GetInstance(context.Background(), "i-missing")
+	assert.True(t, errors.IsNotFoundError(err))
+}
+
+func TestConfigService_NoRecorder(t *testing.T) {
+	server := fakeConfig(t, map[string]func(map[string]interface{}) (int, interface{}){
+		"SelectResourceConfig": func(request map[string]interface{}) (int, interface{}) {
+			return http.StatusBadRequest, map[string]string{"__type": "NoAvailableConfigurationRecorderException"}
+		},
+	})
+	defer server.Close()
+
+	_, err := newConfigService(server.URL).ListInstances(context.Background())
+	assert.ErrorContains(t, err, "AWS Config has no configuration recorder in region us-east-1")
+}
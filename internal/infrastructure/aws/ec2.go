@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
@@ -12,10 +14,24 @@ import (
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 )
 
+// instanceIDFilterBatch is the most instance IDs looked up per DescribeInstances filter
+const instanceIDFilterBatch = 200
+
 // EC2Service handles AWS EC2 operations
 type EC2Service struct {
 	client *Client
 	logger *logging.Logger
+
+	// fetchUserData fetches each instance's user data, which DescribeInstances doesn't return
+	fetchUserData bool
+
+	// fetchTerminationProtection fetches each instance's disable_api_termination, which
+	// DescribeInstances doesn't return either
+	fetchTerminationProtection bool
+
+	// subnets caches described subnets, which rarely change, across checks and runs
+	subnetsMu sync.Mutex
+	subnets   map[string]*model.Subnet
 }
 
 // NewEC2Service creates a new EC2 service
@@ -26,6 +42,16 @@ func NewEC2Service(logger *logging.Logger, client *Client) *EC2Service {
 	}
 }
 
+// CallerIdentity returns the AWS account of the configured credentials and the region
+// instances are listed in
+func (s *EC2Service) CallerIdentity(ctx context.Context) (string, string, error) {
+	accountID, err := s.client.GetCallerAccountID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return accountID, s.client.GetRegion(), nil
+}
+
 // GetInstance retrieves instance configuration by ID
 func (s *EC2Service) GetInstance(ctx context.Context, instanceID string) (*model.Instance, error) {
 	s.logger.Info(fmt.Sprintf("Retrieving EC2 instance: %s", instanceID))
@@ -44,7 +70,10 @@ func (s *EC2Service) GetInstance(ctx context.Context, instanceID string) (*model
 	}
 
 	// Map the EC2 instance to our domain model
-	instance := s.mapToInstance(resp.Reservations[0].Instances[0])
+	instance := mapToInstance(resp.Reservations[0].Instances[0])
+	if err := s.attachInstanceAttributes(ctx, instance); err != nil {
+		return nil, err
+	}
 	return instance, nil
 }
 
@@ -53,6 +82,21 @@ func (s *EC2Service) ListInstances(ctx context.Context) ([]*model.Instance, erro
 	s.logger.Info("Listing all EC2 instances")
 
 	var instances []*model.Instance
+	err := s.StreamInstances(ctx, func(instance *model.Instance) error {
+		instances = append(instances, instance)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Found %d EC2 instances", len(instances)))
+	return instances, nil
+}
+
+// StreamInstances emits each instance as its DescribeInstances page arrives, so that callers
+// can start working before the last page has been fetched
+func (s *EC2Service) StreamInstances(ctx context.Context, emit func(*model.Instance) error) error {
 	var nextToken *string
 
 	// Paginate through all instances
@@ -61,7 +105,7 @@ func (s *EC2Service) ListInstances(ctx context.Context) ([]*model.Instance, erro
 			NextToken: nextToken,
 		})
 		if err != nil {
-			return nil, errors.NewOperationalError("Failed to list EC2 instances", err)
+			return errors.NewOperationalError("Failed to list EC2 instances", err)
 		}
 
 		// Process each reservation and instance
@@ -72,18 +116,65 @@ func (s *EC2Service) ListInstances(ctx context.Context) ([]*model.Instance, erro
 					continue
 				}
 
-				instances = append(instances, s.mapToInstance(inst))
+				instance := mapToInstance(inst)
+				if err := s.attachInstanceAttributes(ctx, instance); err != nil {
+					return err
+				}
+				if err := emit(instance); err != nil {
+					return err
+				}
 			}
 		}
 
 		// Check if there are more instances
 		nextToken = resp.NextToken
 		if nextToken == nil {
-			break
+			return nil
 		}
 	}
+}
 
-	s.logger.Info(fmt.Sprintf("Found %d EC2 instances", len(instances)))
+// ListInstancesByID retrieves the instances with the given IDs in batches. IDs are matched with
+// an instance-id filter rather than InstanceIds, so that IDs that no longer exist are left out
+// instead of failing their batch.
+func (s *EC2Service) ListInstancesByID(ctx context.Context, instanceIDs []string) ([]*model.Instance, error) {
+	s.logger.Info(fmt.Sprintf("Retrieving %d EC2 instances by ID", len(instanceIDs)))
+
+	var instances []*model.Instance
+	for start := 0; start < len(instanceIDs); start += instanceIDFilterBatch {
+		batch := instanceIDs[start:min(start+instanceIDFilterBatch, len(instanceIDs))]
+		var nextToken *string
+		for {
+			resp, err := s.client.EC2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+				Filters:   []types.Filter{{Name: aws.String("instance-id"), Values: batch}},
+				NextToken: nextToken,
+			})
+			if err != nil {
+				return nil, errors.NewOperationalError("Failed to retrieve EC2 instances by ID", err)
+			}
+
+			for _, reservation := range resp.Reservations {
+				for _, inst := range reservation.Instances {
+					if inst.State != nil && inst.State.Name == types.InstanceStateNameTerminated {
+						continue
+					}
+
+					instance := mapToInstance(inst)
+					if err := s.attachInstanceAttributes(ctx, instance); err != nil {
+						return nil, err
+					}
+					instances = append(instances, instance)
+				}
+			}
+
+			nextToken = resp.NextToken
+			if nextToken == nil {
+				break
+			}
+		}
+	}
+
+	s.logger.Info(fmt.Sprintf("Found %d of %d EC2 instances", len(instances), len(instanceIDs)))
 	return instances, nil
 }
 
@@ -173,8 +264,68 @@ func (s *EC2Service) ListInstancesParallel(ctx context.Context, maxConcurrency i
 	return validInstances, nil
 }
 
+// SetFetchUserData sets whether instances are fetched with their user data. It costs an extra
+// API call per instance, so it is only enabled when user_data is compared.
+func (s *EC2Service) SetFetchUserData(fetch bool) {
+	s.fetchUserData = fetch
+}
+
+// SetFetchTerminationProtection sets whether instances are fetched with disable_api_termination.
+// Like user data it costs an extra API call per instance.
+func (s *EC2Service) SetFetchTerminationProtection(fetch bool) {
+	s.fetchTerminationProtection = fetch
+}
+
+// attachInstanceAttributes adds the attributes DescribeInstances doesn't return and that are
+// fetched one instance at a time
+func (s *EC2Service) attachInstanceAttributes(ctx context.Context, instance *model.Instance) error {
+	if err := s.attachUserData(ctx, instance); err != nil {
+		return err
+	}
+	return s.attachTerminationProtection(ctx, instance)
+}
+
+// attachTerminationProtection adds whether the instance refuses API termination as the
+// disable_api_termination attribute
+func (s *EC2Service) attachTerminationProtection(ctx context.Context, instance *model.Instance) error {
+	if !s.fetchTerminationProtection || instance.ID == "" {
+		return nil
+	}
+
+	resp, err := s.client.EC2Client.DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+		InstanceId: &instance.ID,
+		Attribute:  types.InstanceAttributeNameDisableApiTermination,
+	})
+	if err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to retrieve termination protection for instance %s", instance.ID), err)
+	}
+
+	instance.Attributes[model.AttributeDisableAPITermination] = resp.DisableApiTermination != nil && aws.ToBool(resp.DisableApiTermination.Value)
+	return nil
+}
+
+// attachUserData adds the instance's base64 encoded user data as the user_data attribute
+func (s *EC2Service) attachUserData(ctx context.Context, instance *model.Instance) error {
+	if !s.fetchUserData || instance.ID == "" {
+		return nil
+	}
+
+	resp, err := s.client.EC2Client.DescribeInstanceAttribute(ctx, &ec2.DescribeInstanceAttributeInput{
+		InstanceId: &instance.ID,
+		Attribute:  types.InstanceAttributeNameUserData,
+	})
+	if err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to retrieve user data for instance %s", instance.ID), err)
+	}
+
+	if resp.UserData != nil && resp.UserData.Value != nil {
+		instance.Attributes[model.AttributeUserData] = *resp.UserData.Value
+	}
+	return nil
+}
+
 // mapToInstance maps an EC2 instance to our domain model
-func (s *EC2Service) mapToInstance(instance types.Instance) *model.Instance {
+func mapToInstance(instance types.Instance) *model.Instance {
 	attrs := make(map[string]interface{})
 
 	// Only add non-nil values
@@ -198,7 +349,24 @@ func (s *EC2Service) mapToInstance(instance types.Instance) *model.Instance {
 		if instance.Placement.Tenancy != "" {
 			placement["tenancy"] = string(instance.Placement.Tenancy)
 		}
+		if instance.Placement.HostId != nil {
+			placement["host_id"] = *instance.Placement.HostId
+		}
+		if instance.Placement.Affinity != nil {
+			placement["affinity"] = *instance.Placement.Affinity
+		}
 		attrs["placement"] = placement
+
+		// Terraform declares the placement at the top level of aws_instance
+		if instance.Placement.HostId != nil && *instance.Placement.HostId != "" {
+			attrs[model.AttributeHostID] = *instance.Placement.HostId
+		}
+		if instance.Placement.Affinity != nil && *instance.Placement.Affinity != "" {
+			attrs[model.AttributeAffinity] = *instance.Placement.Affinity
+		}
+		attrs[model.AttributeTenancy] = model.NormalizeTenancy(string(instance.Placement.Tenancy))
+	} else {
+		attrs[model.AttributeTenancy] = model.TenancyDefault
 	}
 
 	if len(instance.SecurityGroups) > 0 {
@@ -238,6 +406,10 @@ func (s *EC2Service) mapToInstance(instance types.Instance) *model.Instance {
 		attrs["key_name"] = *instance.KeyName
 	}
 
+	if instance.LaunchTime != nil {
+		attrs[model.AttributeLaunchTime] = instance.LaunchTime.UTC().Format(time.RFC3339)
+	}
+
 	if instance.EbsOptimized != nil {
 		attrs["ebs_optimized"] = *instance.EbsOptimized
 	}
@@ -282,6 +454,15 @@ func (s *EC2Service) mapToInstance(instance types.Instance) *model.Instance {
 		}
 
 		attrs["block_device_mappings"] = blockDevices
+
+		// Terraform declares the root volume and additional EBS volumes in separate blocks,
+		// told apart by the instance's root device name
+		rootDeviceName := ""
+		if instance.RootDeviceName != nil {
+			rootDeviceName = *instance.RootDeviceName
+			attrs["root_device_name"] = rootDeviceName
+		}
+		attrs[model.AttributeRootBlockDevice], attrs[model.AttributeEBSBlockDevice] = model.SplitBlockDevices(ebsDevices(instance.BlockDeviceMappings), rootDeviceName)
 	}
 
 	if len(instance.Tags) > 0 {
@@ -312,12 +493,52 @@ func (s *EC2Service) mapToInstance(instance types.Instance) *model.Instance {
 		}
 
 		attrs["state"] = stateMap
+		attrs[model.AttributeInstanceState] = model.NormalizeInstanceState(string(instance.State.Name))
 	}
 
 	if instance.Monitoring != nil {
 		attrs["monitoring"] = string(instance.Monitoring.State)
 	}
 
+	var publicIP, ipOwner, state string
+	if instance.PublicIpAddress != nil {
+		publicIP = *instance.PublicIpAddress
+	}
+	if instance.State != nil {
+		state = model.NormalizeInstanceState(string(instance.State.Name))
+	}
+	for _, eni := range instance.NetworkInterfaces {
+		primary := eni.Attachment != nil && eni.Attachment.DeviceIndex != nil && *eni.Attachment.DeviceIndex == 0
+		if !primary {
+			continue
+		}
+		if eni.Association != nil && eni.Association.IpOwnerId != nil {
+			ipOwner = *eni.Association.IpOwnerId
+		}
+
+		// secondary_private_ips only covers the primary interface
+		var addresses []string
+		var primaryAddress string
+		for _, address := range eni.PrivateIpAddresses {
+			if address.PrivateIpAddress == nil {
+				continue
+			}
+			if address.Primary != nil && *address.Primary {
+				primaryAddress = *address.PrivateIpAddress
+			}
+			addresses = append(addresses, *address.PrivateIpAddress)
+		}
+		attrs[model.AttributeSecondaryPrivateIPs] = model.SecondaryPrivateIPs(addresses, primaryAddress)
+	}
+	attrs[model.AttributeHasPublicIP] = publicIP != ""
+	attrs[model.AttributeAssociatePublicIPAddress] = model.PublicIPIntent(publicIP, ipOwner, state)
+
+	// On-demand instances have no lifecycle, so both sides always have a value to compare
+	attrs[model.AttributeInstanceLifecycle] = model.NormalizeInstanceLifecycle(string(instance.InstanceLifecycle))
+	if instance.SpotInstanceRequestId != nil {
+		attrs[model.AttributeSpotInstanceRequestID] = *instance.SpotInstanceRequestId
+	}
+
 	// Create the instance with the extracted attributes
 	var instanceID string
 	if instance.InstanceId != nil {
@@ -326,3 +547,27 @@ func (s *EC2Service) mapToInstance(instance types.Instance) *model.Instance {
 
 	return model.NewInstance(instanceID, attrs, model.OriginAWS)
 }
+
+// ebsDevices returns the device name, volume and delete-on-termination setting of each EBS
+// volume attached to an instance
+func ebsDevices(mappings []types.InstanceBlockDeviceMapping) []map[string]interface{} {
+	devices := make([]map[string]interface{}, 0, len(mappings))
+	for _, mapping := range mappings {
+		if mapping.Ebs == nil {
+			continue
+		}
+
+		device := make(map[string]interface{})
+		if mapping.DeviceName != nil {
+			device["device_name"] = *mapping.DeviceName
+		}
+		if mapping.Ebs.VolumeId != nil {
+			device["volume_id"] = *mapping.Ebs.VolumeId
+		}
+		if mapping.Ebs.DeleteOnTermination != nil {
+			device["delete_on_termination"] = *mapping.Ebs.DeleteOnTermination
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
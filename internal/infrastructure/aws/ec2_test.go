@@ -2,10 +2,15 @@ package aws_test
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 	awsinfra "github.com/victor-devv/ec2-drift-detector/internal/infrastructure/aws"
 )
 
@@ -68,3 +73,259 @@ func TestEC2Service_ListInstances_SingleInstance(t *testing.T) {
 	_, err = svc.ListInstances(context.Background())
 	assert.NoError(t, err)
 }
+
+// publicIPInstance renders an instance of a DescribeInstances response with an optional public
+// address on its primary network interface
+func publicIPInstance(id, state, publicIP, ipOwner string) string {
+	var address, association string
+	if publicIP != "" {
+		address = fmt.Sprintf("<ipAddress>%s</ipAddress>", publicIP)
+		association = fmt.Sprintf("<association><publicIp>%s</publicIp><ipOwnerId>%s</ipOwnerId></association>", publicIP, ipOwner)
+	}
+	return fmt.Sprintf(`<item><instanceId>%s</instanceId><instanceType>t3.micro</instanceType><instanceState><code>0</code><name>%s</name></instanceState>%s<networkInterfaceSet><item><networkInterfaceId>eni-%s</networkInterfaceId><attachment><deviceIndex>0</deviceIndex></attachment>%s</item></networkInterfaceSet></item>`,
+		id, state, address, id, association)
+}
+
+func TestEC2Service_PublicIPIntent(t *testing.T) {
+	instances := publicIPInstance("i-auto", "running", "203.0.113.10", model.PublicIPOwnerAmazon) +
+		publicIPInstance("i-eip", "running", "198.51.100.7", "123456789012") +
+		publicIPInstance("i-private", "running", "", "") +
+		publicIPInstance("i-stopped", "stopped", "", "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.Form.Get("Action") {
+		case "DescribeRegions":
+			fmt.Fprintf(w, `<DescribeRegionsResponse %s><requestId>1</requestId><regionInfo><item><regionName>us-east-1</regionName></item></regionInfo></DescribeRegionsResponse>`, ec2Namespace)
+		case "DescribeInstances":
+			fmt.Fprintf(w, `<DescribeInstancesResponse %s><requestId>1</requestId><reservationSet><item><reservationId>r-1</reservationId><instancesSet>%s</instancesSet></item></reservationSet></DescribeInstancesResponse>`, ec2Namespace, instances)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	listed, err := newFakeEC2Service(t, server.URL).ListInstances(context.Background())
+	require.NoError(t, err)
+	byID := make(map[string]*model.Instance)
+	for _, instance := range listed {
+		byID[instance.ID] = instance
+	}
+	require.Len(t, byID, 4)
+
+	// Terraform declares a public IP for every instance
+	paths := []string{model.CanonicalAttribute("has_public_ip")}
+	compare := func(id string) map[string]model.AttributeDrift {
+		terraform := model.NewInstance(id, map[string]interface{}{model.AttributeAssociatePublicIPAddress: true}, model.OriginTerraform)
+		return model.CompareAttributes(terraform, byID[id], paths)
+	}
+
+	assert.Empty(t, compare("i-auto"))
+	assert.Equal(t, true, byID["i-auto"].Attributes[model.AttributeHasPublicIP])
+
+	// An Elastic IP doesn't stand in for associate_public_ip_address
+	assert.Contains(t, compare("i-eip"), model.AttributeAssociatePublicIPAddress)
+	assert.Equal(t, true, byID["i-eip"].Attributes[model.AttributeHasPublicIP])
+
+	assert.Contains(t, compare("i-private"), model.AttributeAssociatePublicIPAddress)
+
+	// The stopped instance released its address, so its intent is unknown rather than drifted
+	assert.Empty(t, compare("i-stopped"))
+	assert.True(t, model.IsUnknown(byID["i-stopped"].Attributes[model.AttributeAssociatePublicIPAddress]))
+	assert.Equal(t, false, byID["i-stopped"].Attributes[model.AttributeHasPublicIP])
+}
+
+func TestEC2Service_SecondaryPrivateIPs(t *testing.T) {
+	instances := `<item><instanceId>i-multi</instanceId><instanceType>t3.micro</instanceType><instanceState><code>16</code><name>running</name></instanceState><privateIpAddress>10.0.1.10</privateIpAddress>` +
+		`<networkInterfaceSet><item><networkInterfaceId>eni-multi</networkInterfaceId><attachment><deviceIndex>0</deviceIndex></attachment><privateIpAddressesSet>` +
+		`<item><privateIpAddress>10.0.1.12</privateIpAddress><primary>false</primary></item>` +
+		`<item><privateIpAddress>10.0.1.10</privateIpAddress><primary>true</primary></item>` +
+		`<item><privateIpAddress>10.0.1.11</privateIpAddress><primary>false</primary></item>` +
+		`</privateIpAddressesSet></item>` +
+		// Addresses of other interfaces aren't declared by aws_instance
+		`<item><networkInterfaceId>eni-other</networkInterfaceId><attachment><deviceIndex>1</deviceIndex></attachment><privateIpAddressesSet>` +
+		`<item><privateIpAddress>10.0.2.10</privateIpAddress><primary>true</primary></item>` +
+		`<item><privateIpAddress>10.0.2.11</privateIpAddress><primary>false</primary></item>` +
+		`</privateIpAddressesSet></item></networkInterfaceSet></item>` +
+		publicIPInstance("i-single", "running", "", "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.Form.Get("Action") {
+		case "DescribeRegions":
+			fmt.Fprintf(w, `<DescribeRegionsResponse %s><requestId>1</requestId><regionInfo><item><regionName>us-east-1</regionName></item></regionInfo></DescribeRegionsResponse>`, ec2Namespace)
+		case "DescribeInstances":
+			fmt.Fprintf(w, `<DescribeInstancesResponse %s><requestId>1</requestId><reservationSet><item><reservationId>r-1</reservationId><instancesSet>%s</instancesSet></item></reservationSet></DescribeInstancesResponse>`, ec2Namespace, instances)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	listed, err := newFakeEC2Service(t, server.URL).ListInstances(context.Background())
+	require.NoError(t, err)
+	byID := make(map[string]*model.Instance)
+	for _, instance := range listed {
+		byID[instance.ID] = instance
+	}
+	require.Len(t, byID, 2)
+
+	assert.Equal(t, []string{"10.0.1.11", "10.0.1.12"}, byID["i-multi"].Attributes[model.AttributeSecondaryPrivateIPs])
+	assert.Equal(t, []string{}, byID["i-single"].Attributes[model.AttributeSecondaryPrivateIPs])
+}
+
+func TestEC2Service_BlockDevices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.Form.Get("Action") {
+		case "DescribeRegions":
+			fmt.Fprintf(w, `<DescribeRegionsResponse %s><requestId>1</requestId><regionInfo><item><regionName>us-east-1</regionName></item></regionInfo></DescribeRegionsResponse>`, ec2Namespace)
+		case "DescribeInstances":
+			fmt.Fprintf(w, `<DescribeInstancesResponse %s><requestId>1</requestId><reservationSet><item><reservationId>r-1</reservationId><instancesSet><item>`+
+				`<instanceId>i-1</instanceId><instanceType>t3.micro</instanceType><instanceState><code>16</code><name>running</name></instanceState>`+
+				`<rootDeviceName>/dev/xvda</rootDeviceName><blockDeviceMapping>`+
+				`<item><deviceName>/dev/sdg</deviceName><ebs><volumeId>vol-data2</volumeId><deleteOnTermination>false</deleteOnTermination></ebs></item>`+
+				`<item><deviceName>/dev/xvda</deviceName><ebs><volumeId>vol-root</volumeId><deleteOnTermination>true</deleteOnTermination></ebs></item>`+
+				`<item><deviceName>/dev/sdf</deviceName><ebs><volumeId>vol-data1</volumeId><deleteOnTermination>true</deleteOnTermination></ebs></item>`+
+				`</blockDeviceMapping></item></instancesSet></item></reservationSet></DescribeInstancesResponse>`, ec2Namespace)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	listed, err := newFakeEC2Service(t, server.URL).ListInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	attrs := listed[0].Attributes
+
+	assert.Equal(t, "/dev/xvda", attrs["root_device_name"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"device_name": "/dev/xvda", "volume_id": "vol-root", "delete_on_termination": true},
+	}, attrs[model.AttributeRootBlockDevice])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"device_name": "/dev/sdf", "volume_id": "vol-data1", "delete_on_termination": true},
+		map[string]interface{}{"device_name": "/dev/sdg", "volume_id": "vol-data2", "delete_on_termination": false},
+	}, attrs[model.AttributeEBSBlockDevice])
+	assert.Len(t, attrs["block_device_mappings"], 3)
+}
+
+func TestEC2Service_ListInstancesByID(t *testing.T) {
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.Form.Get("Action") {
+		case "DescribeRegions":
+			fmt.Fprintf(w, `<DescribeRegionsResponse %s><requestId>1</requestId><regionInfo><item><regionName>us-east-1</regionName></item></regionInfo></DescribeRegionsResponse>`, ec2Namespace)
+		case "DescribeInstances":
+			assert.Equal(t, "instance-id", r.Form.Get("Filter.1.Name"))
+			values := 0
+			for r.Form.Has(fmt.Sprintf("Filter.1.Value.%d", values+1)) {
+				values++
+			}
+			batches = append(batches, values)
+
+			// Only the first ID of each batch still exists
+			fmt.Fprintf(w, `<DescribeInstancesResponse %s><requestId>1</requestId><reservationSet><item><reservationId>r-1</reservationId><instancesSet>%s</instancesSet></item></reservationSet></DescribeInstancesResponse>`,
+				ec2Namespace, publicIPInstance(r.Form.Get("Filter.1.Value.1"), "running", "", ""))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	ids := make([]string, 450)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("i-%03d", i)
+	}
+
+	instances, err := newFakeEC2Service(t, server.URL).ListInstancesByID(context.Background(), ids)
+	require.NoError(t, err)
+	assert.Equal(t, []int{200, 200, 50}, batches)
+	require.Len(t, instances, 3)
+	assert.Equal(t, "i-000", instances[0].ID)
+	assert.Equal(t, "i-200", instances[1].ID)
+	assert.Equal(t, "i-400", instances[2].ID)
+}
+
+func TestEC2Service_MapsSparseInstances(t *testing.T) {
+	// Instances missing every optional field, and with empty nested structures
+	instances := `<item><instanceId>i-bare</instanceId></item>` +
+		`<item><instanceId>i-empty</instanceId><placement/><instanceState/><monitoring/><iamInstanceProfile/>` +
+		`<groupSet><item/></groupSet><tagSet><item/></tagSet><blockDeviceMapping><item/><item><ebs/></item></blockDeviceMapping>` +
+		`<networkInterfaceSet><item><attachment/><association/></item></networkInterfaceSet></item>` +
+		`<item/>`
+	var userData []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.Form.Get("Action") {
+		case "DescribeRegions":
+			fmt.Fprintf(w, `<DescribeRegionsResponse %s><requestId>1</requestId><regionInfo><item><regionName>us-east-1</regionName></item></regionInfo></DescribeRegionsResponse>`, ec2Namespace)
+		case "DescribeInstances":
+			fmt.Fprintf(w, `<DescribeInstancesResponse %s><requestId>1</requestId><reservationSet><item><reservationId>r-1</reservationId><instancesSet>%s</instancesSet></item></reservationSet></DescribeInstancesResponse>`, ec2Namespace, instances)
+		case "DescribeInstanceAttribute":
+			userData = append(userData, r.Form.Get("InstanceId"))
+			fmt.Fprintf(w, `<DescribeInstanceAttributeResponse %s><requestId>1</requestId><instanceId>%s</instanceId></DescribeInstanceAttributeResponse>`, ec2Namespace, r.Form.Get("InstanceId"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	service := newFakeEC2Service(t, server.URL)
+	service.SetFetchUserData(true)
+	listed, err := service.ListInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, listed, 3)
+
+	assert.Equal(t, "i-bare", listed[0].ID)
+	assert.Equal(t, false, listed[0].Attributes[model.AttributeHasPublicIP])
+	assert.Equal(t, model.TenancyDefault, listed[0].Attributes[model.AttributeTenancy])
+	assert.NotContains(t, listed[0].Attributes, model.AttributeInstanceState)
+	assert.Equal(t, "i-empty", listed[1].ID)
+	assert.Empty(t, listed[2].ID)
+
+	// User data is only looked up for instances with an ID
+	assert.Equal(t, []string{"i-bare", "i-empty"}, userData)
+}
+
+func TestEC2Service_TerminationProtection(t *testing.T) {
+	protected := map[string]bool{"i-protected": true}
+	var attributes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.Form.Get("Action") {
+		case "DescribeRegions":
+			fmt.Fprintf(w, `<DescribeRegionsResponse %s><requestId>1</requestId><regionInfo><item><regionName>us-east-1</regionName></item></regionInfo></DescribeRegionsResponse>`, ec2Namespace)
+		case "DescribeInstances":
+			fmt.Fprintf(w, `<DescribeInstancesResponse %s><requestId>1</requestId><reservationSet><item><reservationId>r-1</reservationId><instancesSet>%s%s</instancesSet></item></reservationSet></DescribeInstancesResponse>`,
+				ec2Namespace, `<item><instanceId>i-protected</instanceId></item>`, `<item><instanceId>i-open</instanceId></item>`)
+		case "DescribeInstanceAttribute":
+			attributes = append(attributes, r.Form.Get("Attribute"))
+			fmt.Fprintf(w, `<DescribeInstanceAttributeResponse %s><requestId>1</requestId><instanceId>%s</instanceId><disableApiTermination><value>%v</value></disableApiTermination></DescribeInstanceAttributeResponse>`,
+				ec2Namespace, r.Form.Get("InstanceId"), protected[r.Form.Get("InstanceId")])
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	service := newFakeEC2Service(t, server.URL)
+	listed, err := service.ListInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.NotContains(t, listed[0].Attributes, model.AttributeDisableAPITermination)
+	assert.Empty(t, attributes)
+
+	service.SetFetchTerminationProtection(true)
+	listed, err = service.ListInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, true, listed[0].Attributes[model.AttributeDisableAPITermination])
+	assert.Equal(t, false, listed[1].Attributes[model.AttributeDisableAPITermination])
+	assert.Equal(t, []string{"disableApiTermination", "disableApiTermination"}, attributes)
+}
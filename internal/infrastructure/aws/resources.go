@@ -0,0 +1,223 @@
+package aws
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// ListResources retrieves the EBS volumes, network interfaces and Elastic IPs that can be
+// attached to instances, for orphan detection
+func (s *EC2Service) ListResources(ctx context.Context) ([]*model.Resource, error) {
+	s.logger.Info("Listing EBS volumes, network interfaces and Elastic IPs")
+
+	// Volumes are skipped rather than failing the run when the role lacks ec2:DescribeVolumes
+	volumes, err := s.listVolumes(ctx)
+	if err != nil {
+		if !isAccessDenied(err) {
+			return nil, err
+		}
+		s.logger.Warn(fmt.Sprintf("Skipping EBS volumes, permission denied: %v", err))
+	}
+
+	interfaces, err := s.listNetworkInterfaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.listAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*model.Resource, 0, len(volumes)+len(interfaces)+len(addresses))
+	resources = append(resources, volumes...)
+	resources = append(resources, interfaces...)
+	resources = append(resources, addresses...)
+
+	s.logger.Info(fmt.Sprintf("Found %d volumes, %d network interfaces and %d Elastic IPs", len(volumes), len(interfaces), len(addresses)))
+	return resources, nil
+}
+
+// isAccessDenied reports whether an AWS call failed because the credentials lack permission
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if !stderrors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "UnauthorizedOperation", "AccessDenied", "AccessDeniedException":
+		return true
+	}
+	return false
+}
+
+// listVolumes retrieves all EBS volumes
+func (s *EC2Service) listVolumes(ctx context.Context) ([]*model.Resource, error) {
+	var resources []*model.Resource
+	var nextToken *string
+
+	for {
+		resp, err := s.client.EC2Client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, errors.NewOperationalError("Failed to list EBS volumes", err)
+		}
+
+		for _, volume := range resp.Volumes {
+			if volume.VolumeId == nil {
+				continue
+			}
+			resources = append(resources, mapVolume(volume))
+		}
+
+		nextToken = resp.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	return resources, nil
+}
+
+// listNetworkInterfaces retrieves all network interfaces, skipping those managed by AWS services
+func (s *EC2Service) listNetworkInterfaces(ctx context.Context) ([]*model.Resource, error) {
+	var resources []*model.Resource
+	var nextToken *string
+
+	for {
+		resp, err := s.client.EC2Client.DescribeNetworkInterfaces(ctx, &ec2.DescribeNetworkInterfacesInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, errors.NewOperationalError("Failed to list network interfaces", err)
+		}
+
+		for _, eni := range resp.NetworkInterfaces {
+			// Interfaces created by load balancers, NAT gateways, Lambda etc. are not ours to manage
+			if eni.NetworkInterfaceId == nil || (eni.RequesterManaged != nil && *eni.RequesterManaged) {
+				continue
+			}
+			resources = append(resources, mapNetworkInterface(eni))
+		}
+
+		nextToken = resp.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	return resources, nil
+}
+
+// listAddresses retrieves all Elastic IPs
+func (s *EC2Service) listAddresses(ctx context.Context) ([]*model.Resource, error) {
+	resp, err := s.client.EC2Client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{})
+	if err != nil {
+		return nil, errors.NewOperationalError("Failed to list Elastic IPs", err)
+	}
+
+	resources := make([]*model.Resource, 0, len(resp.Addresses))
+	for _, address := range resp.Addresses {
+		if address.AllocationId == nil {
+			continue
+		}
+		resources = append(resources, mapAddress(address))
+	}
+
+	return resources, nil
+}
+
+// mapVolume maps an EBS volume to a domain model resource
+func mapVolume(volume types.Volume) *model.Resource {
+	attrs := map[string]interface{}{
+		"state":       string(volume.State),
+		"volume_type": string(volume.VolumeType),
+	}
+	if volume.Size != nil {
+		attrs["size"] = *volume.Size
+	}
+	if volume.AvailabilityZone != nil {
+		attrs["availability_zone"] = *volume.AvailabilityZone
+	}
+	if tags := mapTags(volume.Tags); len(tags) > 0 {
+		attrs["tags"] = tags
+	}
+
+	var attached []string
+	for _, attachment := range volume.Attachments {
+		if attachment.InstanceId != nil {
+			attached = append(attached, *attachment.InstanceId)
+		}
+	}
+
+	return model.NewResource(*volume.VolumeId, model.ResourceTypeVolume, attached, attrs)
+}
+
+// mapNetworkInterface maps a network interface to a domain model resource
+func mapNetworkInterface(eni types.NetworkInterface) *model.Resource {
+	attrs := map[string]interface{}{
+		"status":         string(eni.Status),
+		"interface_type": string(eni.InterfaceType),
+	}
+	if eni.SubnetId != nil {
+		attrs["subnet_id"] = *eni.SubnetId
+	}
+	if eni.PrivateIpAddress != nil {
+		attrs["private_ip"] = *eni.PrivateIpAddress
+	}
+	if eni.Description != nil && *eni.Description != "" {
+		attrs["description"] = *eni.Description
+	}
+	if tags := mapTags(eni.TagSet); len(tags) > 0 {
+		attrs["tags"] = tags
+	}
+
+	var attached []string
+	if eni.Attachment != nil && eni.Attachment.InstanceId != nil {
+		attached = append(attached, *eni.Attachment.InstanceId)
+	}
+
+	return model.NewResource(*eni.NetworkInterfaceId, model.ResourceTypeNetworkInterface, attached, attrs)
+}
+
+// mapAddress maps an Elastic IP to a domain model resource
+func mapAddress(address types.Address) *model.Resource {
+	attrs := map[string]interface{}{
+		"domain": string(address.Domain),
+	}
+	if address.PublicIp != nil {
+		attrs["public_ip"] = *address.PublicIp
+	}
+	if address.NetworkInterfaceId != nil {
+		attrs["network_interface_id"] = *address.NetworkInterfaceId
+	}
+	if tags := mapTags(address.Tags); len(tags) > 0 {
+		attrs["tags"] = tags
+	}
+
+	var attached []string
+	if address.InstanceId != nil && *address.InstanceId != "" {
+		attached = append(attached, *address.InstanceId)
+	}
+
+	return model.NewResource(*address.AllocationId, model.ResourceTypeElasticIP, attached, attrs)
+}
+
+// mapTags converts EC2 tags to a map
+func mapTags(tags []types.Tag) map[string]string {
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if tag.Key != nil && tag.Value != nil {
+			result[*tag.Key] = *tag.Value
+		}
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package aws_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	awsinfra "github.com/victor-devv/ec2-drift-detector/internal/infrastructure/aws"
+)
+
+const ec2Namespace = `xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"`
+
+// fakeEC2 answers the EC2 query API with canned responses, denying DescribeVolumes when asked
+func fakeEC2(t *testing.T, denyVolumes bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		action := r.Form.Get("Action")
+
+		w.Header().Set("Content-Type", "text/xml")
+		switch action {
+		case "DescribeRegions":
+			fmt.Fprintf(w, `<DescribeRegionsResponse %s><requestId>1</requestId><regionInfo><item><regionName>us-east-1</regionName></item></regionInfo></DescribeRegionsResponse>`, ec2Namespace)
+		case "DescribeInstances":
+			fmt.Fprintf(w, `<DescribeInstancesResponse %s><requestId>1</requestId><reservationSet><item><reservationId>r-1</reservationId><instancesSet><item><instanceId>i-1</instanceId><instanceType>t2.large</instanceType><instanceState><code>16</code><name>running</name></instanceState></item></instancesSet></item></reservationSet></DescribeInstancesResponse>`, ec2Namespace)
+		case "DescribeVolumes":
+			if denyVolumes {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `<Response><Errors><Error><Code>UnauthorizedOperation</Code><Message>You are not authorized to perform this operation.</Message></Error></Errors><RequestID>1</RequestID></Response>`)
+				return
+			}
+			fmt.Fprintf(w, `<DescribeVolumesResponse %s><requestId>1</requestId><volumeSet><item><volumeId>vol-1</volumeId><size>8</size><status>available</status></item></volumeSet></DescribeVolumesResponse>`, ec2Namespace)
+		case "DescribeNetworkInterfaces":
+			fmt.Fprintf(w, `<DescribeNetworkInterfacesResponse %s><requestId>1</requestId><networkInterfaceSet/></DescribeNetworkInterfacesResponse>`, ec2Namespace)
+		case "DescribeAddresses":
+			fmt.Fprintf(w, `<DescribeAddressesResponse %s><requestId>1</requestId><addressesSet/></DescribeAddressesResponse>`, ec2Namespace)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func newFakeEC2Service(t *testing.T, endpoint string) *awsinfra.EC2Service {
+	logger := logging.New()
+	client, err := awsinfra.NewClient(context.Background(), awsinfra.ClientConfig{
+		Region:        "us-east-1",
+		AccessKey:     "test",
+		SecretKey:     "secret",
+		UseLocalstack: true,
+		Endpoint:      endpoint,
+	}, logger)
+	require.NoError(t, err)
+	return awsinfra.NewEC2Service(logger, client)
+}
+
+func TestListResources_VolumesAccessDenied(t *testing.T) {
+	server := fakeEC2(t, true)
+	defer server.Close()
+	svc := newFakeEC2Service(t, server.URL)
+
+	// Volumes are skipped, the rest of the resources are still listed
+	resources, err := svc.ListResources(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, resources)
+
+	// Instance-level drift detection is unaffected
+	instances, err := svc.ListInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+
+	terraform := model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)
+	drifts := model.CompareAttributes(terraform, instances[0], []string{"instance_type"})
+	assert.Contains(t, drifts, "instance_type")
+}
+
+func TestListResources_Volumes(t *testing.T) {
+	server := fakeEC2(t, false)
+	defer server.Close()
+	svc := newFakeEC2Service(t, server.URL)
+
+	resources, err := svc.ListResources(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	assert.Equal(t, "vol-1", resources[0].ID)
+}
@@ -0,0 +1,133 @@
+package aws
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/smithy-go"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// secretService describes a service secrets are read from, for error messages
+type secretService struct {
+	name         string
+	resourceType string
+	permission   string
+}
+
+var (
+	ssmService            = secretService{name: "ssm", resourceType: "SSM parameter", permission: "ssm:GetParameter"}
+	secretsManagerService = secretService{name: "secretsmanager", resourceType: "Secrets Manager secret", permission: "secretsmanager:GetSecretValue"}
+)
+
+// SecretReader reads SSM parameters and Secrets Manager secrets with the AWS SDK. It resolves
+// ssm: and secretsmanager: references in configuration values and the SSM parameters HCL reads
+// AMIs from.
+type SecretReader struct {
+	ssm            *ssm.Client
+	secretsManager *secretsmanager.Client
+	region         string
+	signed         bool
+}
+
+// NewSecretReader creates a reader for parameters and secrets in cfg.Region, authenticating
+// with cfg.Credentials. A custom endpoint (e.g. LocalStack) replaces the regional endpoints of
+// both services.
+func NewSecretReader(cfg aws.Config, endpoint string) *SecretReader {
+	endpoint = strings.TrimRight(endpoint, "/")
+
+	return &SecretReader{
+		ssm: ssm.NewFromConfig(cfg, func(o *ssm.Options) {
+			if endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+			}
+		}),
+		secretsManager: secretsmanager.NewFromConfig(cfg, func(o *secretsmanager.Options) {
+			if endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+			}
+		}),
+		region: cfg.Region,
+		signed: cfg.Credentials != nil,
+	}
+}
+
+// Region returns the region parameters and secrets are read in, empty when none is configured
+func (r *SecretReader) Region() string {
+	return r.region
+}
+
+// GetParameter returns the value of an SSM parameter, decrypting SecureString parameters
+func (r *SecretReader) GetParameter(ctx context.Context, name string) (string, error) {
+	if err := r.check(name); err != nil {
+		return "", err
+	}
+
+	out, err := r.ssm.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name), WithDecryption: aws.Bool(true)})
+	if err != nil {
+		return "", r.requestError(ssmService, name, err)
+	}
+	if out.Parameter == nil {
+		return "", errors.NewOperationalError(fmt.Sprintf("Invalid ssm response reading %s", name), nil)
+	}
+	return aws.ToString(out.Parameter.Value), nil
+}
+
+// GetSecretValue returns the string value of a Secrets Manager secret
+func (r *SecretReader) GetSecretValue(ctx context.Context, secretID string) (string, error) {
+	if err := r.check(secretID); err != nil {
+		return "", err
+	}
+
+	out, err := r.secretsManager.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil {
+		return "", r.requestError(secretsManagerService, secretID, err)
+	}
+	if out.SecretString == nil {
+		return "", errors.NewValidationError(fmt.Sprintf("Secret %s has no string value; binary secrets are not supported", secretID))
+	}
+	return *out.SecretString, nil
+}
+
+// check reports why name can't be read: without credentials or a region there is nothing to
+// sign the request with or send it to
+func (r *SecretReader) check(name string) error {
+	if !r.signed {
+		return errors.NewValidationError(fmt.Sprintf("AWS credentials are required to read %s", name))
+	}
+	if r.region == "" {
+		return errors.NewValidationError(fmt.Sprintf("An AWS region is required to read %s; set aws.region or AWS_REGION", name))
+	}
+	return nil
+}
+
+// requestError turns a failed request into an error naming the likely cause
+func (r *SecretReader) requestError(svc secretService, name string, err error) error {
+	code := ""
+	var apiErr smithy.APIError
+	if stderrors.As(err, &apiErr) {
+		code = apiErr.ErrorCode()
+	}
+	status := 0
+	var respErr *awshttp.ResponseError
+	if stderrors.As(err, &respErr) {
+		status = respErr.HTTPStatusCode()
+	}
+
+	switch {
+	case code == "ParameterNotFound" || code == "ResourceNotFoundException":
+		return errors.NewNotFoundError(svc.resourceType, name).WithContext("region", r.region)
+	case status == http.StatusForbidden || code == "AccessDeniedException" || code == "AccessDenied":
+		return errors.NewOperationalError(fmt.Sprintf("Access denied reading %s; %s is required", name, svc.permission), err)
+	case status != 0:
+		return errors.NewOperationalError(fmt.Sprintf("Reading %s from %s failed with HTTP %d", name, svc.name, status), err)
+	}
+	return errors.NewOperationalError(fmt.Sprintf("Failed to reach %s for %s", svc.name, name), err)
+}
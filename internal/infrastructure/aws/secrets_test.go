@@ -0,0 +1,105 @@
+package aws_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	awsinfra "github.com/victor-devv/ec2-drift-detector/internal/infrastructure/aws"
+)
+
+// fakeSecrets serves SSM and Secrets Manager actions from handlers keyed by the X-Amz-Target
+func fakeSecrets(t *testing.T, handlers map[string]func(request map[string]interface{}) (int, interface{})) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Authorization"), "AWS4-HMAC-SHA256")
+
+		var request map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		handler, ok := handlers[r.Header.Get("X-Amz-Target")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		status, response := handler(request)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(status)
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+}
+
+func newSecretReader(endpoint string) *awsinfra.SecretReader {
+	cfg := aws.Config{Region: "us-east-1", Credentials: credentials.NewStaticCredentialsProvider("test", "secret", "")}
+	return awsinfra.NewSecretReader(cfg, endpoint)
+}
+
+func TestSecretReader_GetParameter(t *testing.T) {
+	server := fakeSecrets(t, map[string]func(map[string]interface{}) (int, interface{}){
+		"AmazonSSM.GetParameter": func(request map[string]interface{}) (int, interface{}) {
+			assert.Equal(t, true, request["WithDecryption"])
+			if request["Name"] != "/drift/teams-webhook" {
+				return http.StatusBadRequest, map[string]string{"__type": "ParameterNotFound"}
+			}
+			return http.StatusOK, map[string]interface{}{"Parameter": map[string]string{"Name": "/drift/teams-webhook", "Value": "https://example.com/hook"}}
+		},
+	})
+	defer server.Close()
+
+	reader := newSecretReader(server.URL)
+	value, err := reader.GetParameter(context.Background(), "/drift/teams-webhook")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", value)
+
+	_, err = reader.GetParameter(context.Background(), "/drift/missing")
+	assert.True(t, errors.IsNotFoundError(err))
+	assert.ErrorContains(t, err, "SSM parameter with ID '/drift/missing' not found")
+}
+
+func TestSecretReader_GetSecretValue(t *testing.T) {
+	server := fakeSecrets(t, map[string]func(map[string]interface{}) (int, interface{}){
+		"secretsmanager.GetSecretValue": func(request map[string]interface{}) (int, interface{}) {
+			switch request["SecretId"] {
+			case "drift/prod/webhook":
+				return http.StatusOK, map[string]string{"SecretString": "https://example.com/hook"}
+			case "drift/prod/binary":
+				return http.StatusOK, map[string]string{"SecretBinary": "AAEC"}
+			case "drift/prod/denied":
+				return http.StatusBadRequest, map[string]string{"__type": "AccessDeniedException", "message": "not authorized"}
+			}
+			return http.StatusBadRequest, map[string]string{"__type": "ResourceNotFoundException"}
+		},
+	})
+	defer server.Close()
+
+	reader := newSecretReader(server.URL)
+	value, err := reader.GetSecretValue(context.Background(), "drift/prod/webhook")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", value)
+
+	_, err = reader.GetSecretValue(context.Background(), "drift/prod/binary")
+	assert.ErrorContains(t, err, "binary secrets are not supported")
+
+	_, err = reader.GetSecretValue(context.Background(), "drift/prod/denied")
+	assert.ErrorContains(t, err, "secretsmanager:GetSecretValue is required")
+
+	_, err = reader.GetSecretValue(context.Background(), "drift/prod/missing")
+	assert.True(t, errors.IsNotFoundError(err))
+}
+
+func TestSecretReader_RequiresRegion(t *testing.T) {
+	reader := awsinfra.NewSecretReader(aws.Config{Credentials: credentials.NewStaticCredentialsProvider("test", "secret", "")}, "")
+
+	_, err := reader.GetParameter(context.Background(), "/drift/teams-webhook")
+	assert.True(t, errors.IsValidationError(err))
+	assert.ErrorContains(t, err, "An AWS region is required to read /drift/teams-webhook")
+
+	_, err = reader.GetSecretValue(context.Background(), "drift/prod/webhook")
+	assert.True(t, errors.IsValidationError(err))
+}
@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// DescribeSubnets retrieves the subnets with the given IDs, keyed by ID. Subnets described
+// before are served from the cache. They are looked up with a subnet-id filter rather than
+// SubnetIds, so that IDs that no longer exist are left out instead of failing the call.
+func (s *EC2Service) DescribeSubnets(ctx context.Context, subnetIDs []string) (map[string]*model.Subnet, error) {
+	subnets := make(map[string]*model.Subnet, len(subnetIDs))
+	var missing []string
+
+	s.subnetsMu.Lock()
+	for _, id := range subnetIDs {
+		if subnet, ok := s.subnets[id]; ok {
+			subnets[id] = subnet
+		} else if id != "" {
+			missing = append(missing, id)
+		}
+	}
+	s.subnetsMu.Unlock()
+
+	if len(missing) == 0 {
+		return subnets, nil
+	}
+
+	s.logger.Debug(fmt.Sprintf("Describing %d subnets", len(missing)))
+	described := make(map[string]*model.Subnet, len(missing))
+	var nextToken *string
+	for {
+		resp, err := s.client.EC2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+			Filters:   []types.Filter{{Name: aws.String("subnet-id"), Values: missing}},
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, errors.NewOperationalError("Failed to describe subnets", err)
+		}
+
+		for _, subnet := range resp.Subnets {
+			if subnet.SubnetId == nil {
+				continue
+			}
+			described[*subnet.SubnetId] = mapSubnet(subnet)
+		}
+
+		nextToken = resp.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	s.subnetsMu.Lock()
+	if s.subnets == nil {
+		s.subnets = make(map[string]*model.Subnet)
+	}
+	for id, subnet := range described {
+		s.subnets[id] = subnet
+		subnets[id] = subnet
+	}
+	s.subnetsMu.Unlock()
+
+	return subnets, nil
+}
+
+// mapSubnet maps an EC2 subnet to our domain model
+func mapSubnet(subnet types.Subnet) *model.Subnet {
+	return &model.Subnet{
+		ID:               aws.ToString(subnet.SubnetId),
+		VPCID:            aws.ToString(subnet.VpcId),
+		AvailabilityZone: aws.ToString(subnet.AvailabilityZone),
+		Name:             mapTags(subnet.Tags)["Name"],
+	}
+}
@@ -0,0 +1,54 @@
+package aws_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+func TestEC2Service_DescribeSubnets(t *testing.T) {
+	var requested [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "text/xml")
+		switch r.Form.Get("Action") {
+		case "DescribeRegions":
+			fmt.Fprintf(w, `<DescribeRegionsResponse %s><requestId>1</requestId><regionInfo><item><regionName>us-east-1</regionName></item></regionInfo></DescribeRegionsResponse>`, ec2Namespace)
+		case "DescribeSubnets":
+			assert.Equal(t, "subnet-id", r.Form.Get("Filter.1.Name"))
+			var ids []string
+			for i := 1; r.Form.Has(fmt.Sprintf("Filter.1.Value.%d", i)); i++ {
+				ids = append(ids, r.Form.Get(fmt.Sprintf("Filter.1.Value.%d", i)))
+			}
+			requested = append(requested, ids)
+
+			// subnet-gone no longer exists and is left out of the response
+			fmt.Fprintf(w, `<DescribeSubnetsResponse %s><requestId>1</requestId><subnetSet>
+<item><subnetId>subnet-a</subnetId><vpcId>vpc-1</vpcId><availabilityZone>us-east-1a</availabilityZone><tagSet><item><key>Name</key><value>app-a</value></item></tagSet></item>
+<item><subnetId>subnet-b</subnetId><vpcId>vpc-2</vpcId><availabilityZone>us-east-1b</availabilityZone></item>
+</subnetSet></DescribeSubnetsResponse>`, ec2Namespace)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	svc := newFakeEC2Service(t, server.URL)
+
+	subnets, err := svc.DescribeSubnets(context.Background(), []string{"subnet-a", "subnet-b", "subnet-gone"})
+	require.NoError(t, err)
+	assert.Equal(t, &model.Subnet{ID: "subnet-a", VPCID: "vpc-1", AvailabilityZone: "us-east-1a", Name: "app-a"}, subnets["subnet-a"])
+	assert.Equal(t, &model.Subnet{ID: "subnet-b", VPCID: "vpc-2", AvailabilityZone: "us-east-1b"}, subnets["subnet-b"])
+	assert.NotContains(t, subnets, "subnet-gone")
+
+	// Described subnets are cached; only the unknown one is looked up again
+	subnets, err = svc.DescribeSubnets(context.Background(), []string{"subnet-a", "subnet-gone"})
+	require.NoError(t, err)
+	assert.Equal(t, "app-a", subnets["subnet-a"].Name)
+	assert.Equal(t, [][]string{{"subnet-a", "subnet-b", "subnet-gone"}, {"subnet-gone"}}, requested)
+}
@@ -0,0 +1,190 @@
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// DefaultMaxInstances is the number of instances dumped when no limit is configured
+const DefaultMaxInstances = 20
+
+// RedactedValue replaces the values of attributes whose names match the deny list
+const RedactedValue = "<redacted>"
+
+// DefaultRedactKeys lists the key name fragments whose values are never written to a dump
+var DefaultRedactKeys = []string{"password", "secret", "token", "private_key", "access_key", "credential", "user_data"}
+
+// AttributeDumper writes the attribute maps each provider produced for an instance as pretty
+// JSON files, <dir>/<instance-id>.aws.json and <dir>/<instance-id>.terraform.json, to help
+// troubleshoot false drift. Only the first MaxInstances instances are dumped.
+type AttributeDumper struct {
+	dir          string
+	maxInstances int
+	redactKeys   []string
+	logger       *logging.Logger
+
+	mu          sync.Mutex
+	dumped      map[string]bool
+	limitLogged bool
+}
+
+// NewAttributeDumper creates a dumper writing to dir. A non-positive maxInstances uses
+// DefaultMaxInstances and nil redactKeys uses DefaultRedactKeys.
+func NewAttributeDumper(dir string, maxInstances int, redactKeys []string, logger *logging.Logger) *AttributeDumper {
+	if maxInstances <= 0 {
+		maxInstances = DefaultMaxInstances
+	}
+	if redactKeys == nil {
+		redactKeys = DefaultRedactKeys
+	}
+
+	lowered := make([]string, len(redactKeys))
+	for i, key := range redactKeys {
+		lowered[i] = strings.ToLower(key)
+	}
+
+	return &AttributeDumper{
+		dir:          dir,
+		maxInstances: maxInstances,
+		redactKeys:   lowered,
+		logger:       logger.WithField("component", "attribute-dump"),
+		dumped:       make(map[string]bool),
+	}
+}
+
+// DumpAttributes writes the attributes of the AWS and Terraform instances. Either instance
+// may be nil. Instances past the limit are skipped.
+func (d *AttributeDumper) DumpAttributes(instanceID string, aws, terraform *model.Instance) error {
+	if !d.reserve(instanceID) {
+		return nil
+	}
+
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to create debug dump directory %s", d.dir), err)
+	}
+
+	name := sanitizeFileName(instanceID)
+	if aws != nil {
+		if err := d.write(filepath.Join(d.dir, name+".aws.json"), instanceAttributes(aws)); err != nil {
+			return err
+		}
+	}
+	if terraform != nil {
+		if err := d.write(filepath.Join(d.dir, name+".terraform.json"), instanceAttributes(terraform)); err != nil {
+			return err
+		}
+	}
+
+	d.logger.Debug(fmt.Sprintf("Dumped attributes for instance %s to %s", instanceID, d.dir))
+	return nil
+}
+
+// reserve reports whether the instance may be dumped, counting it against the limit
+func (d *AttributeDumper) reserve(instanceID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.dumped[instanceID] {
+		return true
+	}
+	if len(d.dumped) >= d.maxInstances {
+		if !d.limitLogged {
+			d.logger.Info(fmt.Sprintf("Debug dump limit of %d instances reached, skipping the rest", d.maxInstances))
+			d.limitLogged = true
+		}
+		return false
+	}
+
+	d.dumped[instanceID] = true
+	return true
+}
+
+// write redacts and writes an attribute map as indented JSON
+func (d *AttributeDumper) write(path string, attributes map[string]interface{}) error {
+	data, err := json.MarshalIndent(d.redact(attributes), "", "  ")
+	if err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to encode debug dump %s", path), err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to write debug dump %s", path), err)
+	}
+	return nil
+}
+
+// redact returns a copy of val with the values of denied keys replaced, at any depth
+func (d *AttributeDumper) redact(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if d.denied(key) {
+				out[key] = RedactedValue
+				continue
+			}
+			out[key] = d.redact(item)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if d.denied(key) {
+				out[key] = RedactedValue
+				continue
+			}
+			out[key] = item
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = d.redact(item)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = d.redact(item)
+		}
+		return out
+	default:
+		return val
+	}
+}
+
+// denied reports whether a key name contains any of the deny list fragments
+func (d *AttributeDumper) denied(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range d.redactKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// instanceAttributes returns the attributes of an instance as the comparator sees them,
+// including the instance type kept outside the attribute map
+func instanceAttributes(instance *model.Instance) map[string]interface{} {
+	attributes := make(map[string]interface{}, len(instance.Attributes)+1)
+	for key, val := range instance.Attributes {
+		attributes[key] = val
+	}
+	if _, ok := attributes["instance_type"]; !ok && instance.InstanceType != "" {
+		attributes["instance_type"] = instance.InstanceType
+	}
+	return attributes
+}
+
+// sanitizeFileName keeps instance IDs from escaping the dump directory
+func sanitizeFileName(id string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(id)
+}
@@ -0,0 +1,128 @@
+package dump
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+func readDump(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var attributes map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &attributes))
+	return attributes
+}
+
+func TestDumpAttributes_WritesBothProviders(t *testing.T) {
+	dir := t.TempDir()
+	dumper := NewAttributeDumper(dir, 0, nil, logging.New())
+
+	aws := model.NewInstance("i-123", map[string]interface{}{"ami": "ami-1"}, model.OriginAWS)
+	aws.InstanceType = "t2.micro"
+	terraform := model.NewInstance("i-123", map[string]interface{}{"ami": "ami-2", "instance_type": "t2.large"}, model.OriginTerraform)
+
+	require.NoError(t, dumper.DumpAttributes("i-123", aws, terraform))
+
+	assert.Equal(t, map[string]interface{}{"ami": "ami-1", "instance_type": "t2.micro"}, readDump(t, filepath.Join(dir, "i-123.aws.json")))
+	assert.Equal(t, map[string]interface{}{"ami": "ami-2", "instance_type": "t2.large"}, readDump(t, filepath.Join(dir, "i-123.terraform.json")))
+
+	info, err := os.Stat(filepath.Join(dir, "i-123.aws.json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestDumpAttributes_MissingInstance(t *testing.T) {
+	dir := t.TempDir()
+	dumper := NewAttributeDumper(dir, 0, nil, logging.New())
+
+	terraform := model.NewInstance("i-123", map[string]interface{}{"ami": "ami-2"}, model.OriginTerraform)
+	require.NoError(t, dumper.DumpAttributes("i-123", nil, terraform))
+
+	assert.FileExists(t, filepath.Join(dir, "i-123.terraform.json"))
+	assert.NoFileExists(t, filepath.Join(dir, "i-123.aws.json"))
+}
+
+func TestDumpAttributes_Redacts(t *testing.T) {
+	dir := t.TempDir()
+	dumper := NewAttributeDumper(dir, 0, nil, logging.New())
+
+	aws := model.NewInstance("i-123", map[string]interface{}{
+		"ami":       "ami-1",
+		"user_data": "#!/bin/bash\nexport DB_PASSWORD=hunter2",
+		"tags":      map[string]string{"Name": "web", "ApiToken": "abc"},
+		"metadata_options": []map[string]interface{}{
+			{"http_tokens": "required", "http_endpoint": "enabled"},
+		},
+		"credentials": map[string]interface{}{"AccessKeyId": "AKIA"},
+	}, model.OriginAWS)
+
+	require.NoError(t, dumper.DumpAttributes("i-123", aws, nil))
+
+	attributes := readDump(t, filepath.Join(dir, "i-123.aws.json"))
+	assert.Equal(t, "ami-1", attributes["ami"])
+	assert.Equal(t, RedactedValue, attributes["user_data"])
+	assert.Equal(t, RedactedValue, attributes["credentials"])
+	assert.Equal(t, map[string]interface{}{"Name": "web", "ApiToken": RedactedValue}, attributes["tags"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"http_tokens": RedactedValue, "http_endpoint": "enabled"},
+	}, attributes["metadata_options"])
+
+	// The instance itself is left untouched
+	assert.Equal(t, "abc", aws.Attributes["tags"].(map[string]string)["ApiToken"])
+}
+
+func TestDumpAttributes_CustomRedactKeys(t *testing.T) {
+	dir := t.TempDir()
+	dumper := NewAttributeDumper(dir, 0, []string{"Owner"}, logging.New())
+
+	aws := model.NewInstance("i-123", map[string]interface{}{"owner_email": "a@example.com", "user_data": "echo"}, model.OriginAWS)
+	require.NoError(t, dumper.DumpAttributes("i-123", aws, nil))
+
+	attributes := readDump(t, filepath.Join(dir, "i-123.aws.json"))
+	assert.Equal(t, RedactedValue, attributes["owner_email"])
+	assert.Equal(t, "echo", attributes["user_data"])
+}
+
+func TestDumpAttributes_Limit(t *testing.T) {
+	dir := t.TempDir()
+	dumper := NewAttributeDumper(dir, 2, nil, logging.New())
+
+	for _, id := range []string{"i-1", "i-2", "i-3", "i-1"} {
+		instance := model.NewInstance(id, map[string]interface{}{"ami": "ami-1"}, model.OriginAWS)
+		require.NoError(t, dumper.DumpAttributes(id, instance, nil))
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"i-1.aws.json", "i-2.aws.json"}, names)
+}
+
+func TestDumpAttributes_CreatesDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "dump")
+	dumper := NewAttributeDumper(dir, 0, nil, logging.New())
+
+	instance := model.NewInstance("i-123", map[string]interface{}{"ami": "ami-1"}, model.OriginAWS)
+	require.NoError(t, dumper.DumpAttributes("i-123", instance, nil))
+
+	assert.FileExists(t, filepath.Join(dir, "i-123.aws.json"))
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	assert.Equal(t, "i-123", sanitizeFileName("i-123"))
+	assert.Equal(t, "__etc_passwd", sanitizeFileName("../etc/passwd"))
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// FileDigestStore buffers notification digests in a JSON file so pending results survive a
+// restart and can be flushed by another process, e.g. detect --flush-digests. The file is
+// read on every call and replaced atomically on every change. Drifted values are restored
+// as plain JSON values.
+type FileDigestStore struct {
+	path string
+
+	// mu serializes read-modify-write cycles within the process
+	mu sync.Mutex
+
+	logger *logging.Logger
+}
+
+// NewFileDigestStore creates a digest store backed by the file at path. The file and its
+// directory are created on the first buffered result.
+func NewFileDigestStore(path string, logger *logging.Logger) *FileDigestStore {
+	return &FileDigestStore{
+		path:   path,
+		logger: logger.WithField("component", "file-digest-store"),
+	}
+}
+
+// Path returns the file digests are buffered in
+func (s *FileDigestStore) Path() string {
+	return s.path
+}
+
+// storedDigest is a digest as written to the file
+type storedDigest struct {
+	Since   time.Time            `json:"since"`
+	Results []*model.DriftResult `json:"results"`
+}
+
+// AppendDigest buffers results for the digest with the given key
+func (s *FileDigestStore) AppendDigest(ctx context.Context, key string, results []*model.DriftResult, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	digests, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	digest, ok := digests[key]
+	if !ok {
+		digest = &storedDigest{Since: at}
+		digests[key] = digest
+	}
+	digest.Results = append(digest.Results, results...)
+
+	return s.save(digests)
+}
+
+// PendingDigest returns the buffered results for a digest and when the oldest was buffered
+func (s *FileDigestStore) PendingDigest(ctx context.Context, key string) ([]*model.DriftResult, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	digests, err := s.load()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	digest, ok := digests[key]
+	if !ok {
+		return nil, time.Time{}, nil
+	}
+	return digest.Results, digest.Since, nil
+}
+
+// ClearDigest discards the buffered results for a digest
+func (s *FileDigestStore) ClearDigest(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	digests, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := digests[key]; !ok {
+		return nil
+	}
+
+	delete(digests, key)
+	return s.save(digests)
+}
+
+// load reads the buffered digests; a missing file holds none
+func (s *FileDigestStore) load() (map[string]*storedDigest, error) {
+	digests := make(map[string]*storedDigest)
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return digests, nil
+	}
+	if err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to read digest file %s", s.path), err)
+	}
+
+	if err := json.Unmarshal(data, &digests); err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to parse digest file %s", s.path), err)
+	}
+	return digests, nil
+}
+
+// save replaces the digest file with digests, writing a temporary file first so a crash
+// never leaves a partial file behind
+func (s *FileDigestStore) save(digests map[string]*storedDigest) error {
+	data, err := json.Marshal(digests)
+	if err != nil {
+		return errors.NewOperationalError("Failed to marshal digests", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to create digest directory %s", dir), err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to write digest file %s", s.path), err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.NewOperationalError(fmt.Sprintf("Failed to write digest file %s", s.path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to write digest file %s", s.path), err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to replace digest file %s", s.path), err)
+	}
+
+	s.logger.Debug(fmt.Sprintf("Saved %d pending digests to %s", len(digests), s.path))
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+func TestFileDigestStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state", "digests.json")
+	since := time.Date(2024, 4, 22, 0, 0, 0, 0, time.UTC)
+
+	// Nothing is pending before the file exists
+	store := NewFileDigestStore(path, logging.New())
+	pending, at, err := store.PendingDigest(ctx, "teams")
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	assert.True(t, at.IsZero())
+
+	result := model.NewDriftResult("i-12345", model.OriginTerraform)
+	result.AddDriftedAttribute("instance_type", "t2.micro", "t2.small")
+	require.NoError(t, store.AppendDigest(ctx, "teams", []*model.DriftResult{result}, since))
+	require.NoError(t, store.AppendDigest(ctx, "teams", []*model.DriftResult{model.NewDriftResult("i-67890", model.OriginTerraform)}, since.Add(time.Hour)))
+	require.NoError(t, store.AppendDigest(ctx, "other", []*model.DriftResult{result}, since))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Another store on the same file sees the buffered results and the oldest time
+	reopened := NewFileDigestStore(path, logging.New())
+	pending, at, err = reopened.PendingDigest(ctx, "teams")
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "i-12345", pending[0].ResourceID)
+	assert.Equal(t, "t2.small", pending[0].DriftedAttributes["instance_type"].TargetValue)
+	assert.True(t, since.Equal(at))
+
+	// Clearing a digest leaves the others
+	require.NoError(t, reopened.ClearDigest(ctx, "teams"))
+	pending, _, err = store.PendingDigest(ctx, "teams")
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	pending, _, err = store.PendingDigest(ctx, "other")
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
+
+func TestFileDigestStore_CorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "digests.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0600))
+
+	_, _, err := NewFileDigestStore(path, logging.New()).PendingDigest(context.Background(), "teams")
+	assert.ErrorContains(t, err, "Failed to parse digest file")
+}
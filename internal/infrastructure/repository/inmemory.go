@@ -3,8 +3,11 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
@@ -18,19 +21,32 @@ type InMemoryDriftRepository struct {
 	// instanceResults is a map of instance ID to result IDs
 	instanceResults map[string][]string
 
+	// digests is a map of digest key to the results buffered for it
+	digests map[string]*pendingDigest
+
 	// mutex for thread safety
 	mu sync.RWMutex
 
 	// logger
 	logger *logging.Logger
+
+	// clock stamps results saved without a timestamp
+	clock clock.Clock
 }
 
 // NewInMemoryDriftRepository creates a new in-memory drift repository
 func NewInMemoryDriftRepository(logger *logging.Logger) *InMemoryDriftRepository {
+	return NewInMemoryDriftRepositoryWithClock(logger, clock.Real())
+}
+
+// NewInMemoryDriftRepositoryWithClock creates a new in-memory drift repository using the given clock
+func NewInMemoryDriftRepositoryWithClock(logger *logging.Logger, clk clock.Clock) *InMemoryDriftRepository {
 	return &InMemoryDriftRepository{
 		results:         make(map[string]*model.DriftResult),
 		instanceResults: make(map[string][]string),
+		digests:         make(map[string]*pendingDigest),
 		logger:          logger.WithField("component", "inmemory-drift-repo"),
+		clock:           clock.OrReal(clk),
 	}
 }
 
@@ -39,6 +55,10 @@ func (r *InMemoryDriftRepository) SaveDriftResult(ctx context.Context, result *m
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if result.Timestamp.IsZero() {
+		result.Timestamp = r.clock.Now()
+	}
+
 	// Store the result
 	r.results[result.ID] = result
 
@@ -104,6 +124,94 @@ func (r *InMemoryDriftRepository) ListDriftResults(ctx context.Context) ([]*mode
 	return results, nil
 }
 
+// ListDriftResultsWithDrift retrieves the most recent result of each instance whose most recent
+// result has drift, sorted by instance ID. Instances compared in several Terraform workspaces
+// have a most recent result per workspace.
+func (r *InMemoryDriftRepository) ListDriftResultsWithDrift(ctx context.Context) ([]*model.DriftResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []*model.DriftResult
+	for _, resultIDs := range r.instanceResults {
+		// Results are appended as they are saved, so a later one wins a timestamp tie
+		latest := make(map[string]*model.DriftResult)
+		var workspaces []string
+		for _, id := range resultIDs {
+			result, ok := r.results[id]
+			if !ok {
+				continue
+			}
+			current, seen := latest[result.Workspace]
+			if !seen {
+				workspaces = append(workspaces, result.Workspace)
+			}
+			if !seen || !result.Timestamp.Before(current.Timestamp) {
+				latest[result.Workspace] = result
+			}
+		}
+
+		for _, workspace := range workspaces {
+			if result := latest[workspace]; result.HasDrift {
+				results = append(results, result)
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].ResourceID != results[j].ResourceID {
+			return results[i].ResourceID < results[j].ResourceID
+		}
+		return results[i].Workspace < results[j].Workspace
+	})
+
+	return results, nil
+}
+
+// pendingDigest holds results buffered for a notification digest
+type pendingDigest struct {
+	since   time.Time
+	results []*model.DriftResult
+}
+
+// AppendDigest buffers results for the digest with the given key
+func (r *InMemoryDriftRepository) AppendDigest(ctx context.Context, key string, results []*model.DriftResult, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	digest, ok := r.digests[key]
+	if !ok {
+		digest = &pendingDigest{since: at}
+		r.digests[key] = digest
+	}
+	digest.results = append(digest.results, results...)
+
+	return nil
+}
+
+// PendingDigest returns the buffered results for a digest and when the oldest was buffered
+func (r *InMemoryDriftRepository) PendingDigest(ctx context.Context, key string) ([]*model.DriftResult, time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	digest, ok := r.digests[key]
+	if !ok {
+		return nil, time.Time{}, nil
+	}
+
+	results := make([]*model.DriftResult, len(digest.results))
+	copy(results, digest.results)
+	return results, digest.since, nil
+}
+
+// ClearDigest discards the buffered results for a digest
+func (r *InMemoryDriftRepository) ClearDigest(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.digests, key)
+	return nil
+}
+
 // ClearResults clears all results
 func (r *InMemoryDriftRepository) ClearResults() {
 	r.mu.Lock()
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 )
@@ -236,3 +238,67 @@ func TestInMemoryDriftRepository_MultipleResultsPerInstance(t *testing.T) {
 		ids[result.ID] = true
 	}
 }
+
+func TestInMemoryDriftRepository_StampsMissingTimestamps(t *testing.T) {
+	now := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	repo := NewInMemoryDriftRepositoryWithClock(logging.New(), clock.NewFake(now))
+	ctx := context.Background()
+
+	unstamped := &model.DriftResult{ID: "r-1", ResourceID: "i-12345"}
+	require.NoError(t, repo.SaveDriftResult(ctx, unstamped))
+
+	stamped := model.NewDriftResultAt("i-12345", model.OriginTerraform, now.Add(-time.Hour))
+	require.NoError(t, repo.SaveDriftResult(ctx, stamped))
+
+	got, err := repo.GetDriftResult(ctx, "r-1")
+	require.NoError(t, err)
+	require.Equal(t, now, got.Timestamp)
+
+	got, err = repo.GetDriftResult(ctx, stamped.ID)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(-time.Hour), got.Timestamp)
+}
+
+func TestInMemoryDriftRepository_ListDriftResultsWithDrift(t *testing.T) {
+	repo := NewInMemoryDriftRepository(logging.New())
+	ctx := context.Background()
+	earlier := time.Date(2024, 4, 22, 16, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	save := func(instanceID, workspace string, at time.Time, drifted bool) *model.DriftResult {
+		result := model.NewDriftResultAt(instanceID, model.OriginTerraform, at)
+		result.Workspace = workspace
+		if drifted {
+			result.AddDriftedAttribute("instance_type", "t3.micro", "t3.large")
+		}
+		require.NoError(t, repo.SaveDriftResult(ctx, result))
+		return result
+	}
+
+	// Drift that has since been fixed is not reported
+	save("i-fixed", "", earlier, true)
+	save("i-fixed", "", later, false)
+
+	// New drift is
+	save("i-new", "", earlier, false)
+	newDrift := save("i-new", "", later, true)
+
+	// The most recent result wins even when saved first
+	outOfOrder := save("i-late", "", later, true)
+	save("i-late", "", earlier, false)
+
+	save("i-clean", "", later, false)
+
+	// Each workspace has its own most recent result
+	dev := save("i-shared", "dev", later, true)
+	save("i-shared", "prod", later, false)
+
+	results, err := repo.ListDriftResultsWithDrift(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []*model.DriftResult{outOfOrder, newDrift, dev}, results)
+
+	repo.ClearResults()
+	results, err = repo.ListDriftResultsWithDrift(ctx)
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
@@ -1,131 +1,91 @@
 package terraform
 
 import (
-	"context"
 	"fmt"
-	"os"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
-	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
-	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 )
 
-// Client provides access to Terraform configuration and state
-type Client struct {
-	stateParser *StateParser
-	hclParser   *HCLParser
-	logger      *logging.Logger
-	stateFile   string
-	hclDir      string
-	useHCL      bool
-}
-
-// ClientConfig holds configuration for the Terraform client
+// ClientConfig holds configuration for the Terraform providers
 type ClientConfig struct {
+	// StateFile is a local path or a file://, s3://, gs://, http:// or https:// URI
 	StateFile string
 	HCLDir    string
-	UseHCL    bool
-}
 
-// NewClient creates a new Terraform client
-func NewClient(cfg ClientConfig, logger *logging.Logger) (*Client, error) {
-	logger = logger.WithField("component", "terraform-client")
+	// SOPSAgeKeyFile is the age key used to decrypt SOPS-encrypted state files
+	SOPSAgeKeyFile string
 
-	// Validate configuration
-	if cfg.UseHCL {
-		if cfg.HCLDir == "" {
-			return nil, errors.NewValidationError("HCL directory must be specified when UseHCL is true")
-		}
+	// UseTagsAll compares tags_all from state files instead of tags when present
+	UseTagsAll bool
 
-		// Check if the directory exists
-		info, err := os.Stat(cfg.HCLDir)
-		if err != nil {
-			return nil, errors.NewOperationalError(fmt.Sprintf("HCL directory %s does not exist", cfg.HCLDir), err)
-		}
+	// IncludeTainted compares tainted instances instead of skipping them
+	IncludeTainted bool
 
-		if !info.IsDir() {
-			return nil, errors.NewValidationError(fmt.Sprintf("%s is not a directory", cfg.HCLDir))
-		}
-	} else {
-		if cfg.StateFile == "" {
-			return nil, errors.NewValidationError("State file must be specified when UseHCL is false")
-		}
+	// AllowUnsupported parses states written by a newer Terraform than supported best-effort,
+	// with a warning, instead of failing
+	AllowUnsupported bool
 
-		// Check if the file exists
-		_, err := os.Stat(cfg.StateFile)
-		if err != nil {
-			return nil, errors.NewOperationalError(fmt.Sprintf("State file %s does not exist", cfg.StateFile), err)
-		}
-	}
+	// TFCWorkspace reads state from the Terraform Cloud workspace with this ID instead of StateFile
+	TFCWorkspace string
 
-	return &Client{
-		stateParser: NewStateParser(logger),
-		hclParser:   NewHCLParser(logger),
-		logger:      logger,
-		stateFile:   cfg.StateFile,
-		hclDir:      cfg.HCLDir,
-		useHCL:      cfg.UseHCL,
-	}, nil
-}
+	// TFCToken is the Terraform Cloud API token used to read TFCWorkspace
+	TFCToken string
 
-// GetInstance retrieves instance configuration by ID
-func (c *Client) GetInstance(ctx context.Context, instanceID string) (*model.Instance, error) {
-	c.logger.Info(fmt.Sprintf("Retrieving instance %s from Terraform", instanceID))
+	// TFCAddress is the Terraform Cloud or Enterprise address (defaults to DefaultTFCAddress)
+	TFCAddress string
 
-	if c.useHCL {
-		// When using HCL, we can't look up by instance ID directly
-		// since the ID is only known after Terraform applies the configuration
-		// Instead, we get all instances and try to match by name
-		instances, err := c.ListInstances(ctx)
-		if err != nil {
-			return nil, err
-		}
+	// HTTPAuth authenticates state read from http:// and https:// URIs
+	HTTPAuth HTTPStateAuth
 
-		for _, instance := range instances {
-			// Try to match by ID (which might be resource name in HCL mode)
-			if instance.ID == instanceID {
-				return instance, nil
-			}
+	// S3Config is the AWS configuration state is read from s3:// URIs with; its region is the
+	// region of the state bucket
+	S3Config aws.Config
 
-			// Try to match by any attribute that might contain the instance ID
-			if resourceName, ok := instance.Attributes["resource_name"].(string); ok && resourceName == instanceID {
-				return instance, nil
-			}
-		}
+	// S3Endpoint overrides the S3 endpoint, e.g. for LocalStack
+	S3Endpoint string
 
-		return nil, errors.NewNotFoundError("EC2 Instance", instanceID)
-	} else {
-		return c.stateParser.GetInstanceByIDFromStateFile(ctx, c.stateFile, instanceID)
-	}
-}
+	// S3UsePathStyle addresses the state bucket path-style; always on with S3Endpoint
+	S3UsePathStyle bool
 
-// ListInstances retrieves all available instances
-func (c *Client) ListInstances(ctx context.Context) ([]*model.Instance, error) {
-	c.logger.Info("Listing instances from Terraform")
+	// Workspaces reads the state of each named workspace of the StateFile backend instead of
+	// StateFile itself, annotating instances with their workspace
+	Workspaces []string
 
-	if c.useHCL {
-		return c.hclParser.ParseHCLDir(ctx, c.hclDir)
-	} else {
-		return c.stateParser.GetInstancesFromStateFile(ctx, c.stateFile)
-	}
-}
+	// WorkspaceKeyPrefix is the S3 backend's workspace_key_prefix (defaults to DefaultWorkspaceKeyPrefix)
+	WorkspaceKeyPrefix string
 
-// GetSourceType returns the source type for this client
-func (c *Client) GetSourceType() model.ResourceOrigin {
-	return model.OriginTerraform
-}
+	// CacheState reuses the instances parsed from a local or S3 state file while its
+	// modification time and size, or ETag, are unchanged
+	CacheState bool
 
-// IsUsingHCL returns whether the client is using HCL or state files
-func (c *Client) IsUsingHCL() bool {
-	return c.useHCL
-}
+	// ReadRetries retries reading a local state file that fails with a transient IO error
+	ReadRetries int
 
-// GetStateFile returns the state file path
-func (c *Client) GetStateFile() string {
-	return c.stateFile
+	// ParameterResolver looks up AMIs that HCL reads from SSM parameters; without one they are unknown
+	ParameterResolver ParameterResolver
 }
 
-// GetHCLDir returns the HCL directory path
-func (c *Client) GetHCLDir() string {
-	return c.hclDir
+// newStateFetcher selects the fetcher for the scheme of the state file location
+func newStateFetcher(cfg ClientConfig) (StateFetcher, error) {
+	scheme := StateScheme(cfg.StateFile)
+
+	switch scheme {
+	case SchemeFile:
+		return LocalStateFetcher{Retries: cfg.ReadRetries}, nil
+	case SchemeHTTP, SchemeHTTPS:
+		return NewHTTPStateFetcher(cfg.HTTPAuth), nil
+	case SchemeS3:
+		if _, _, err := parseBucketURI(cfg.StateFile, SchemeS3); err != nil {
+			return nil, err
+		}
+		return NewS3StateFetcher(cfg.S3Config, cfg.S3Endpoint, cfg.S3UsePathStyle), nil
+	case SchemeGCS:
+		if _, _, err := parseBucketURI(cfg.StateFile, SchemeGCS); err != nil {
+			return nil, err
+		}
+		return NewGCSStateFetcher(nil), nil
+	default:
+		return nil, errors.NewValidationError(fmt.Sprintf("Unsupported state location scheme %q in %s (supported: local paths, file://, s3://, gs://, http:// and https://)", scheme, redactURI(cfg.StateFile)))
+	}
 }
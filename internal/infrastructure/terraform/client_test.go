@@ -7,58 +7,53 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/terraform"
 )
 
-func TestNewClient_StateFileSuccess(t *testing.T) {
+func TestNewStateProvider_StateFileSuccess(t *testing.T) {
 	logger := logging.New()
 	tempFile, err := os.CreateTemp(".", "test-*.tfstate")
 	assert.NoError(t, err)
 	defer os.Remove(tempFile.Name())
 
-	client, err := terraform.NewClient(terraform.ClientConfig{
+	provider, err := terraform.NewStateProvider(terraform.ClientConfig{
 		StateFile: tempFile.Name(),
-		UseHCL:    false,
 	}, logger)
 
 	assert.NoError(t, err)
-	assert.NotNil(t, client)
-	assert.Equal(t, tempFile.Name(), client.GetStateFile())
-	assert.False(t, client.IsUsingHCL())
+	assert.NotNil(t, provider)
+	assert.Equal(t, tempFile.Name(), provider.GetStateFile())
 }
 
-func TestNewClient_HCLDirSuccess(t *testing.T) {
+func TestNewHCLProvider_HCLDirSuccess(t *testing.T) {
 	logger := logging.New()
 	tempDir := t.TempDir()
 
-	client, err := terraform.NewClient(terraform.ClientConfig{
+	provider, err := terraform.NewHCLProvider(terraform.ClientConfig{
 		HCLDir: tempDir,
-		UseHCL: true,
 	}, logger)
 
 	assert.NoError(t, err)
-	assert.NotNil(t, client)
-	assert.Equal(t, tempDir, client.GetHCLDir())
-	assert.True(t, client.IsUsingHCL())
+	assert.NotNil(t, provider)
+	assert.Equal(t, tempDir, provider.GetHCLDir())
 }
 
-func TestNewClient_MissingStateFile(t *testing.T) {
+func TestNewStateProvider_MissingStateFile(t *testing.T) {
 	logger := logging.New()
 
-	_, err := terraform.NewClient(terraform.ClientConfig{
+	_, err := terraform.NewStateProvider(terraform.ClientConfig{
 		StateFile: "nonexistent.tfstate",
-		UseHCL:    false,
 	}, logger)
 
 	assert.Error(t, err)
 }
 
-func TestNewClient_MissingHCLDir(t *testing.T) {
+func TestNewHCLProvider_MissingHCLDir(t *testing.T) {
 	logger := logging.New()
 
-	_, err := terraform.NewClient(terraform.ClientConfig{
+	_, err := terraform.NewHCLProvider(terraform.ClientConfig{
 		HCLDir: "nonexistent-dir",
-		UseHCL: true,
 	}, logger)
 
 	assert.Error(t, err)
@@ -68,23 +63,21 @@ func TestGetSourceType(t *testing.T) {
 	logger := logging.New()
 	tempDir := t.TempDir()
 
-	client, err := terraform.NewClient(terraform.ClientConfig{
+	provider, err := terraform.NewHCLProvider(terraform.ClientConfig{
 		HCLDir: tempDir,
-		UseHCL: true,
 	}, logger)
 	assert.NoError(t, err)
-	assert.Equal(t, "terraform", string(client.GetSourceType()))
+	assert.Equal(t, "terraform", string(provider.GetSourceType()))
 }
 
 func TestListInstances_HCL(t *testing.T) {
 	logger := logging.New()
-	client, err := terraform.NewClient(terraform.ClientConfig{
+	provider, err := terraform.NewHCLProvider(terraform.ClientConfig{
 		HCLDir: "testdata",
-		UseHCL: true,
 	}, logger)
 	assert.NoError(t, err)
 
-	instances, err := client.ListInstances(context.Background())
+	instances, err := provider.ListInstances(context.Background())
 	assert.NoError(t, err)
 	assert.Len(t, instances, 1)
 	assert.Equal(t, "tf-aws_instance-web", instances[0].ID)
@@ -92,13 +85,12 @@ func TestListInstances_HCL(t *testing.T) {
 
 func TestListInstances_StateFile(t *testing.T) {
 	logger := logging.New()
-	client, err := terraform.NewClient(terraform.ClientConfig{
+	provider, err := terraform.NewStateProvider(terraform.ClientConfig{
 		StateFile: "./testdata/test.tfstate",
-		UseHCL:    false,
 	}, logger)
 	assert.NoError(t, err)
 
-	instances, err := client.ListInstances(context.Background())
+	instances, err := provider.ListInstances(context.Background())
 	assert.NoError(t, err)
 	assert.Len(t, instances, 1)
 	assert.Equal(t, "i-1234567890abcdef0", instances[0].ID)
@@ -106,13 +98,53 @@ func TestListInstances_StateFile(t *testing.T) {
 
 func TestGetInstance_StateFile(t *testing.T) {
 	logger := logging.New()
-	client, err := terraform.NewClient(terraform.ClientConfig{
+	provider, err := terraform.NewStateProvider(terraform.ClientConfig{
 		StateFile: "./testdata/test.tfstate",
-		UseHCL:    false,
 	}, logger)
 	assert.NoError(t, err)
 
-	instance, err := client.GetInstance(context.Background(), "i-1234567890abcdef0")
+	instance, err := provider.GetInstance(context.Background(), "i-1234567890abcdef0")
 	assert.NoError(t, err)
 	assert.Equal(t, "i-1234567890abcdef0", instance.ID)
 }
+
+func TestAddressMoves(t *testing.T) {
+	logger := logging.New()
+
+	// State refactored from aws_instance.web into module.web, with the configuration's moved blocks
+	stateProvider, err := terraform.NewStateProvider(terraform.ClientConfig{
+		StateFile: "./testdata/moved/terraform.tfstate",
+		HCLDir:    "./testdata/moved",
+	}, logger)
+	assert.NoError(t, err)
+
+	moves, err := stateProvider.AddressMoves(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, model.AddressMoves{
+		"aws_instance.web":    "module.web.aws_instance.this",
+		"aws_instance.legacy": "aws_instance.app",
+	}, moves)
+
+	instances, err := stateProvider.ListInstances(context.Background())
+	assert.NoError(t, err)
+	addresses := make(map[string][]string)
+	for _, instance := range instances {
+		addresses[instance.ID] = moves.Former(instance.ResourceAddress)
+	}
+	assert.Equal(t, []string{"aws_instance.web"}, addresses["i-0aaaaaaaaaaaaaaa1"])
+	assert.Equal(t, []string{"aws_instance.legacy"}, addresses["i-0bbbbbbbbbbbbbbb1"])
+
+	// Without a configuration, state doesn't tell what moved
+	stateProvider, err = terraform.NewStateProvider(terraform.ClientConfig{StateFile: "./testdata/moved/terraform.tfstate"}, logger)
+	assert.NoError(t, err)
+	moves, err = stateProvider.AddressMoves(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, moves)
+
+	// HCL instances are still found by the name they had before a rename
+	hclProvider, err := terraform.NewHCLProvider(terraform.ClientConfig{HCLDir: "./testdata/moved"}, logger)
+	assert.NoError(t, err)
+	instance, err := hclProvider.GetInstance(context.Background(), "legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, "aws_instance.app", instance.ResourceAddress)
+}
@@ -0,0 +1,219 @@
+package terraform
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// State location schemes. Locations without a scheme are local paths.
+const (
+	SchemeFile  = "file"
+	SchemeS3    = "s3"
+	SchemeGCS   = "gs"
+	SchemeHTTP  = "http"
+	SchemeHTTPS = "https"
+)
+
+// DefaultStateFetchTimeout bounds each request for remote state
+const DefaultStateFetchTimeout = 60 * time.Second
+
+// StateFetcher reads raw Terraform state from a location
+type StateFetcher interface {
+	// Fetch returns the state stored at uri
+	Fetch(ctx context.Context, uri string) ([]byte, error)
+}
+
+// StateVersioner is implemented by fetchers that can tell whether state changed without
+// downloading it, e.g. from a file's modification time and size or an object's ETag
+type StateVersioner interface {
+	// Version returns an opaque value that changes whenever the state at uri changes
+	Version(ctx context.Context, uri string) (string, error)
+}
+
+// StateScheme returns the scheme of a state location, SchemeFile for plain paths
+func StateScheme(location string) string {
+	i := strings.Index(location, "://")
+	if i <= 0 {
+		return SchemeFile
+	}
+	return strings.ToLower(location[:i])
+}
+
+// DefaultStateReadRetryBackoff is the delay before retrying a failed local state read; it
+// doubles on every retry
+const DefaultStateReadRetryBackoff = 200 * time.Millisecond
+
+// transientIOErrors are the errors a read can fail with on networked filesystems such as EFS
+// or NFS and then succeed when retried
+var transientIOErrors = []syscall.Errno{syscall.EIO, syscall.EAGAIN, syscall.EINTR, syscall.EBUSY, syscall.ESTALE, syscall.ETIMEDOUT}
+
+// LocalStateFetcher reads state from the local filesystem, from a path or a file:// URI
+type LocalStateFetcher struct {
+	// Retries is how many times a read failing with a transient IO error is retried
+	Retries int
+
+	// RetryBackoff is the delay before the first retry (defaults to DefaultStateReadRetryBackoff)
+	RetryBackoff time.Duration
+
+	// readFile reads the file; os.ReadFile when nil
+	readFile func(path string) ([]byte, error)
+}
+
+// Fetch reads the state file, retrying transient IO errors with exponential backoff
+func (f LocalStateFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	path := localStatePath(uri)
+
+	readFile := f.readFile
+	if readFile == nil {
+		readFile = os.ReadFile
+	}
+	backoff := f.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultStateReadRetryBackoff
+	}
+
+	data, err := readFile(path)
+	for attempt := 1; err != nil && attempt <= f.Retries && isTransientIOError(err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, errors.NewOperationalError(fmt.Sprintf("Reading Terraform state file %s cancelled", path), ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		data, err = readFile(path)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NewOperationalError(fmt.Sprintf("State file %s does not exist", path), err)
+		}
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to read Terraform state file: %s", path), err)
+	}
+	return data, nil
+}
+
+// isTransientIOError reports whether a failed read may succeed when retried. Missing files and
+// permission errors are permanent.
+func isTransientIOError(err error) bool {
+	for _, errno := range transientIOErrors {
+		if stderrors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
+// Version returns the file's modification time and size
+func (LocalStateFetcher) Version(ctx context.Context, uri string) (string, error) {
+	path := localStatePath(uri)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", errors.NewOperationalError(fmt.Sprintf("Failed to stat Terraform state file: %s", path), err)
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+// localStatePath strips the file:// scheme from a local state location
+func localStatePath(location string) string {
+	if StateScheme(location) == SchemeFile {
+		return strings.TrimPrefix(location, "file://")
+	}
+	return location
+}
+
+// HTTPStateAuth holds the credentials sent to an HTTP state backend. A token is sent as a
+// bearer token and takes precedence over basic auth.
+type HTTPStateAuth struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// HTTPStateFetcher reads state from an http:// or https:// URI, such as Terraform's HTTP backend
+type HTTPStateFetcher struct {
+	client *http.Client
+	auth   HTTPStateAuth
+}
+
+// NewHTTPStateFetcher creates a fetcher that authenticates with auth
+func NewHTTPStateFetcher(auth HTTPStateAuth) *HTTPStateFetcher {
+	return &HTTPStateFetcher{
+		client: &http.Client{Timeout: DefaultStateFetchTimeout},
+		auth:   auth,
+	}
+}
+
+// Fetch downloads the state
+func (f *HTTPStateFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	location := redactURI(uri)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid state URL %s: %v", location, err))
+	}
+	switch {
+	case f.auth.Token != "":
+		req.Header.Set("Authorization", "Bearer "+f.auth.Token)
+	case f.auth.Username != "":
+		req.SetBasicAuth(f.auth.Username, f.auth.Password)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to reach state backend %s", location), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, errors.NewOperationalError(fmt.Sprintf("State backend %s rejected the credentials (HTTP %d); check terraform.http_username/http_password or terraform.http_token", location, resp.StatusCode), nil)
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent:
+		return nil, errors.NewOperationalError(fmt.Sprintf("State backend %s has no state (HTTP %d)", location, resp.StatusCode), nil)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, errors.NewOperationalError(fmt.Sprintf("Reading state from %s failed with HTTP %d", location, resp.StatusCode), nil)
+	}
+
+	return readStateBody(resp.Body, location)
+}
+
+// readStateBody reads a remote state response body
+func readStateBody(body io.Reader, location string) ([]byte, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to read state from %s", location), err)
+	}
+	return data, nil
+}
+
+// redactURI hides any password in a URI so it can be logged
+func redactURI(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	return parsed.Redacted()
+}
+
+// parseBucketURI splits a scheme://bucket/key URI into its bucket and object key
+func parseBucketURI(uri, scheme string) (string, string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != scheme || parsed.Host == "" {
+		return "", "", errors.NewValidationError(fmt.Sprintf("Invalid state location %q: expected %s://bucket/key", uri, scheme))
+	}
+
+	key := strings.TrimPrefix(parsed.Path, "/")
+	if key == "" {
+		return "", "", errors.NewValidationError(fmt.Sprintf("State location %q has no object key: expected %s://bucket/key", uri, scheme))
+	}
+	return parsed.Host, key, nil
+}
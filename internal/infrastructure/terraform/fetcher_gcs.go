@@ -0,0 +1,95 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"golang.org/x/oauth2"
+)
+
+// defaultGCSEndpoint is the Cloud Storage JSON API address
+const defaultGCSEndpoint = "https://storage.googleapis.com"
+
+// GCSStateFetcher reads state from a gs://bucket/object URI through the Cloud Storage JSON API
+type GCSStateFetcher struct {
+	client   *http.Client
+	endpoint string
+
+	// mu guards tokens, which are found on the first fetch when none were given
+	mu     sync.Mutex
+	tokens oauth2.TokenSource
+}
+
+// NewGCSStateFetcher creates a fetcher authenticating with tokens. Nil tokens use Google
+// application default credentials, found when state is first read.
+func NewGCSStateFetcher(tokens oauth2.TokenSource) *GCSStateFetcher {
+	return &GCSStateFetcher{
+		client:   &http.Client{Timeout: DefaultStateFetchTimeout},
+		tokens:   tokens,
+		endpoint: defaultGCSEndpoint,
+	}
+}
+
+// Fetch downloads the state object
+func (f *GCSStateFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	bucket, object, err := parseBucketURI(uri, SchemeGCS)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := f.tokenSource()
+	if err != nil {
+		return nil, err
+	}
+	token, err := tokens.Token()
+	if err != nil {
+		return nil, googleTokenError(err)
+	}
+
+	endpoint := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", strings.TrimRight(f.endpoint, "/"), url.PathEscape(bucket), url.PathEscape(object))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to build request for %s", uri), err)
+	}
+	token.SetAuthHeader(req)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to reach Cloud Storage for %s", uri), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, errors.NewOperationalError(fmt.Sprintf("Cloud Storage rejected the Google credentials reading %s; check the application default credentials", uri), nil)
+	case resp.StatusCode == http.StatusForbidden:
+		return nil, errors.NewOperationalError(fmt.Sprintf("Access denied reading %s; the Google credentials need storage.objects.get on bucket %s", uri, bucket), nil)
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errors.NewOperationalError(fmt.Sprintf("State object %s does not exist", uri), nil)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, errors.NewOperationalError(fmt.Sprintf("Reading state from %s failed with HTTP %d", uri, resp.StatusCode), nil)
+	}
+
+	return readStateBody(resp.Body, uri)
+}
+
+// tokenSource returns the fetcher's token source, finding application default credentials
+// the first time
+func (f *GCSStateFetcher) tokenSource() (oauth2.TokenSource, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.tokens == nil {
+		tokens, err := NewGoogleDefaultCredentials()
+		if err != nil {
+			return nil, err
+		}
+		f.tokens = tokens
+	}
+	return f.tokens, nil
+}
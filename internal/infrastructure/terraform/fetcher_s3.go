@@ -0,0 +1,137 @@
+package terraform
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// defaultS3Region is used when neither terraform.s3_region nor aws.region is set
+const defaultS3Region = "us-east-1"
+
+// S3StateFetcher reads state from an s3://bucket/key URI with the AWS SDK, which picks the
+// endpoint for the bucket's partition (including China and GovCloud), addresses buckets
+// with dots in their name path-style and retries throttled and failed requests
+type S3StateFetcher struct {
+	client   *s3.Client
+	region   string
+	endpoint string
+	signed   bool
+}
+
+// NewS3StateFetcher creates a fetcher for buckets in cfg.Region, authenticating with
+// cfg.Credentials. A custom endpoint (e.g. LocalStack) is always addressed path-style.
+func NewS3StateFetcher(cfg aws.Config, endpoint string, usePathStyle bool) *S3StateFetcher {
+	if cfg.Region == "" {
+		cfg.Region = defaultS3Region
+	}
+	endpoint = strings.TrimRight(endpoint, "/")
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = usePathStyle || endpoint != ""
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+
+	return &S3StateFetcher{
+		client:   client,
+		region:   cfg.Region,
+		endpoint: endpoint,
+		signed:   cfg.Credentials != nil,
+	}
+}
+
+// Fetch downloads the state object
+func (f *S3StateFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	bucket, key, err := f.object(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultStateFetchTimeout)
+	defer cancel()
+
+	out, err := f.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, f.requestError(uri, bucket, err)
+	}
+	defer out.Body.Close()
+
+	return readStateBody(out.Body, uri)
+}
+
+// Version returns the object's ETag, read with a HEAD request so unchanged state isn't downloaded
+func (f *S3StateFetcher) Version(ctx context.Context, uri string) (string, error) {
+	bucket, key, err := f.object(uri)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultStateFetchTimeout)
+	defer cancel()
+
+	out, err := f.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return "", f.requestError(uri, bucket, err)
+	}
+	return aws.ToString(out.ETag), nil
+}
+
+// object splits uri into bucket and key, checking the fetcher can sign requests for it
+func (f *S3StateFetcher) object(uri string) (string, string, error) {
+	bucket, key, err := parseBucketURI(uri, SchemeS3)
+	if err != nil {
+		return "", "", err
+	}
+	if !f.signed {
+		return "", "", errors.NewValidationError(fmt.Sprintf("AWS credentials are required to read state from %s", uri))
+	}
+	if f.endpoint != "" {
+		if base, err := url.Parse(f.endpoint); err != nil || base.Host == "" {
+			return "", "", errors.NewValidationError(fmt.Sprintf("Invalid S3 endpoint %q", f.endpoint))
+		}
+	}
+	return bucket, key, nil
+}
+
+// requestError turns a failed S3 request into an error naming the likely cause
+func (f *S3StateFetcher) requestError(uri, bucket string, err error) error {
+	code := ""
+	var apiErr smithy.APIError
+	if stderrors.As(err, &apiErr) {
+		code = apiErr.ErrorCode()
+	}
+	status := 0
+	var respErr *awshttp.ResponseError
+	if stderrors.As(err, &respErr) {
+		status = respErr.HTTPStatusCode()
+	}
+
+	var message string
+	switch {
+	case status == http.StatusMovedPermanently || code == "PermanentRedirect" || code == "AuthorizationHeaderMalformed":
+		message = fmt.Sprintf("Bucket %s is not in region %s; set terraform.s3_region to the bucket's region", bucket, f.region)
+	case code == "NoSuchBucket":
+		message = fmt.Sprintf("Bucket %s does not exist", bucket)
+	case code == "NoSuchKey" || code == "NotFound" || status == http.StatusNotFound:
+		message = fmt.Sprintf("State object %s does not exist", uri)
+	case status == http.StatusForbidden:
+		message = fmt.Sprintf("Access denied reading %s; check the AWS credentials and the bucket policy", uri)
+	case status != 0:
+		message = fmt.Sprintf("Reading state from %s failed with HTTP %d", uri, status)
+	default:
+		message = fmt.Sprintf("Failed to reach S3 for %s", uri)
+	}
+
+	return errors.NewOperationalError(message, err)
+}
@@ -0,0 +1,509 @@
+package terraform
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"golang.org/x/oauth2"
+)
+
+const testStateJSON = `{"version":4,"resources":[{"mode":"managed","type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-remote","instance_type":"t3.micro"}}]}]}`
+
+func TestStateScheme(t *testing.T) {
+	assert.Equal(t, SchemeFile, StateScheme("terraform/terraform.tfstate"))
+	assert.Equal(t, SchemeFile, StateScheme("/abs/terraform.tfstate"))
+	assert.Equal(t, SchemeFile, StateScheme("file:///abs/terraform.tfstate"))
+	assert.Equal(t, SchemeS3, StateScheme("S3://bucket/key"))
+	assert.Equal(t, SchemeGCS, StateScheme("gs://bucket/object"))
+	assert.Equal(t, SchemeHTTPS, StateScheme("https://state.example.com/web"))
+}
+
+func TestLocalStateFetcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(testStateJSON), 0600))
+
+	data, err := LocalStateFetcher{}.Fetch(context.Background(), path)
+	require.NoError(t, err)
+	assert.JSONEq(t, testStateJSON, string(data))
+
+	data, err = LocalStateFetcher{}.Fetch(context.Background(), "file://"+path)
+	require.NoError(t, err)
+	assert.JSONEq(t, testStateJSON, string(data))
+
+	_, err = LocalStateFetcher{}.Fetch(context.Background(), filepath.Join(t.TempDir(), "missing.tfstate"))
+	assert.True(t, errors.IsOperationalError(err))
+	assert.ErrorContains(t, err, "does not exist")
+}
+
+// flakyReader fails the first reads with an error, then reads the file
+type flakyReader struct {
+	failures int
+	err      error
+	reads    int
+}
+
+func (r *flakyReader) readFile(path string) ([]byte, error) {
+	r.reads++
+	if r.reads <= r.failures {
+		return nil, &os.PathError{Op: "read", Path: path, Err: r.err}
+	}
+	return os.ReadFile(path)
+}
+
+func TestLocalStateFetcher_Retries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(testStateJSON), 0600))
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		reader := &flakyReader{failures: 2, err: syscall.EIO}
+		fetcher := LocalStateFetcher{Retries: 3, RetryBackoff: time.Millisecond, readFile: reader.readFile}
+
+		data, err := fetcher.Fetch(context.Background(), path)
+		require.NoError(t, err)
+		assert.JSONEq(t, testStateJSON, string(data))
+		assert.Equal(t, 3, reader.reads)
+	})
+
+	t.Run("retries run out", func(t *testing.T) {
+		reader := &flakyReader{failures: 5, err: syscall.ESTALE}
+		fetcher := LocalStateFetcher{Retries: 2, RetryBackoff: time.Millisecond, readFile: reader.readFile}
+
+		_, err := fetcher.Fetch(context.Background(), path)
+		assert.True(t, errors.IsOperationalError(err))
+		assert.Equal(t, 3, reader.reads)
+	})
+
+	t.Run("missing files and permission errors are not retried", func(t *testing.T) {
+		for _, permanent := range []error{syscall.ENOENT, syscall.EACCES} {
+			reader := &flakyReader{failures: 5, err: permanent}
+			fetcher := LocalStateFetcher{Retries: 3, RetryBackoff: time.Millisecond, readFile: reader.readFile}
+
+			_, err := fetcher.Fetch(context.Background(), path)
+			assert.Error(t, err)
+			assert.Equal(t, 1, reader.reads)
+		}
+	})
+
+	t.Run("cancellation stops retrying", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		reader := &flakyReader{failures: 5, err: syscall.EIO}
+		fetcher := LocalStateFetcher{Retries: 3, RetryBackoff: time.Hour, readFile: reader.readFile}
+
+		_, err := fetcher.Fetch(ctx, path)
+		assert.ErrorContains(t, err, "cancelled")
+		assert.Equal(t, 1, reader.reads)
+	})
+}
+
+func TestHTTPStateFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, basic := r.BasicAuth()
+		switch {
+		case r.Header.Get("Authorization") == "Bearer tok":
+		case basic && user == "drift" && pass == "s3cret":
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/state/web" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, testStateJSON)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		auth    HTTPStateAuth
+		path    string
+		wantErr string
+	}{
+		{name: "basic auth", auth: HTTPStateAuth{Username: "drift", Password: "s3cret"}, path: "/state/web"},
+		{name: "bearer token", auth: HTTPStateAuth{Token: "tok", Username: "ignored"}, path: "/state/web"},
+		{name: "wrong password", auth: HTTPStateAuth{Username: "drift", Password: "nope"}, path: "/state/web", wantErr: "rejected the credentials (HTTP 401)"},
+		{name: "no credentials", path: "/state/web", wantErr: "rejected the credentials"},
+		{name: "no state", auth: HTTPStateAuth{Token: "tok"}, path: "/state/db", wantErr: "has no state (HTTP 404)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := NewHTTPStateFetcher(tt.auth).Fetch(context.Background(), server.URL+tt.path)
+			if tt.wantErr != "" {
+				assert.True(t, errors.IsOperationalError(err))
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, testStateJSON, string(data))
+		})
+	}
+}
+
+func TestHTTPStateFetcher_RedactsURLPassword(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	uri := strings.Replace(server.URL, "http://", "http://drift:hunter2@", 1) + "/state"
+	_, err := NewHTTPStateFetcher(HTTPStateAuth{}).Fetch(context.Background(), uri)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+// testS3Config is the AWS configuration of a caller with the given access key in region
+func testS3Config(accessKey, region string) aws.Config {
+	return aws.Config{
+		Region:      region,
+		Credentials: credentials.NewStaticCredentialsProvider(accessKey, "secret", ""),
+		Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+	}
+}
+
+// fakeS3 serves s3://states/env/prod/terraform.tfstate path-style and checks requests are SigV4 signed
+func fakeS3(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDTEST/") {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+			return
+		}
+		assert.Contains(t, auth, "/eu-west-1/s3/aws4_request")
+
+		switch r.URL.Path {
+		case "/states/env/prod/terraform.tfstate":
+			w.Header().Set("ETag", `"prod-etag"`)
+			fmt.Fprint(w, testStateJSON)
+		case "/other-region/terraform.tfstate":
+			w.WriteHeader(http.StatusMovedPermanently)
+			fmt.Fprint(w, `<Error><Code>PermanentRedirect</Code><Message>The bucket you are attempting to access must be addressed using the specified endpoint.</Message></Error>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestS3StateFetcher(t *testing.T) {
+	server := fakeS3(t)
+	fetcher := NewS3StateFetcher(testS3Config("AKIDTEST", "eu-west-1"), server.URL, false)
+
+	data, err := fetcher.Fetch(context.Background(), "s3://states/env/prod/terraform.tfstate")
+	require.NoError(t, err)
+	assert.JSONEq(t, testStateJSON, string(data))
+
+	_, err = fetcher.Fetch(context.Background(), "s3://states/env/dev/terraform.tfstate")
+	assert.True(t, errors.IsOperationalError(err))
+	assert.ErrorContains(t, err, "State object s3://states/env/dev/terraform.tfstate does not exist")
+
+	_, err = fetcher.Fetch(context.Background(), "s3://other-region/terraform.tfstate")
+	assert.ErrorContains(t, err, "set terraform.s3_region")
+
+	denied := NewS3StateFetcher(testS3Config("AKIDOTHER", "eu-west-1"), server.URL, false)
+	_, err = denied.Fetch(context.Background(), "s3://states/env/prod/terraform.tfstate")
+	assert.True(t, errors.IsOperationalError(err))
+	assert.ErrorContains(t, err, "Access denied reading s3://states/env/prod/terraform.tfstate")
+}
+
+func TestS3StateFetcher_InvalidURI(t *testing.T) {
+	fetcher := NewS3StateFetcher(testS3Config("AKIDTEST", ""), "", false)
+
+	_, err := fetcher.Fetch(context.Background(), "s3://bucket-only")
+	assert.True(t, errors.IsValidationError(err))
+	assert.ErrorContains(t, err, "has no object key")
+
+	_, err = NewS3StateFetcher(aws.Config{}, "", false).Fetch(context.Background(), "s3://bucket/key")
+	assert.True(t, errors.IsValidationError(err))
+}
+
+// recordingS3 answers every request with testStateJSON and records the URLs requested
+type recordingS3 struct {
+	urls []string
+}
+
+func (c *recordingS3) Do(req *http.Request) (*http.Response, error) {
+	c.urls = append(c.urls, req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(testStateJSON)),
+		Request:    req,
+	}, nil
+}
+
+func TestS3StateFetcher_Addressing(t *testing.T) {
+	tests := []struct {
+		name      string
+		region    string
+		bucket    string
+		endpoint  string
+		pathStyle bool
+		want      string
+	}{
+		{name: "default region", bucket: "states", want: "https://states.s3.us-east-1.amazonaws.com/prod/terraform.tfstate"},
+		{name: "virtual hosted", region: "eu-west-1", bucket: "states", want: "https://states.s3.eu-west-1.amazonaws.com/prod/terraform.tfstate"},
+		{name: "dotted bucket", region: "eu-west-1", bucket: "acme.states", want: "https://s3.eu-west-1.amazonaws.com/acme.states/prod/terraform.tfstate"},
+		{name: "path style", region: "eu-west-1", bucket: "states", pathStyle: true, want: "https://s3.eu-west-1.amazonaws.com/states/prod/terraform.tfstate"},
+		{name: "china", region: "cn-north-1", bucket: "states", want: "https://states.s3.cn-north-1.amazonaws.com.cn/prod/terraform.tfstate"},
+		{name: "govcloud", region: "us-gov-west-1", bucket: "states", want: "https://states.s3.us-gov-west-1.amazonaws.com/prod/terraform.tfstate"},
+		{name: "custom endpoint", region: "eu-west-1", bucket: "states", endpoint: "http://localhost:4566/", want: "http://localhost:4566/states/prod/terraform.tfstate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &recordingS3{}
+			cfg := testS3Config("AKIDTEST", tt.region)
+			cfg.HTTPClient = client
+
+			_, err := NewS3StateFetcher(cfg, tt.endpoint, tt.pathStyle).Fetch(context.Background(), "s3://"+tt.bucket+"/prod/terraform.tfstate")
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.want}, client.urls)
+		})
+	}
+}
+
+func TestS3StateFetcher_Retries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`)
+			return
+		}
+		fmt.Fprint(w, testStateJSON)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := testS3Config("AKIDTEST", "eu-west-1")
+	cfg.Retryer = nil
+	data, err := NewS3StateFetcher(cfg, server.URL, false).Fetch(context.Background(), "s3://states/env/prod/terraform.tfstate")
+	require.NoError(t, err)
+	assert.JSONEq(t, testStateJSON, string(data))
+	assert.Equal(t, 2, attempts)
+}
+
+// staticTokens always returns the access token
+func staticTokens(token string) oauth2.TokenSource {
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+}
+
+func TestGCSStateFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ya29.test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "media", r.URL.Query().Get("alt"))
+
+		switch r.URL.EscapedPath() {
+		case "/storage/v1/b/states/o/env%2Fprod%2Fdefault.tfstate":
+			fmt.Fprint(w, testStateJSON)
+		case "/storage/v1/b/locked/o/default.tfstate":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewGCSStateFetcher(staticTokens("ya29.test"))
+	fetcher.endpoint = server.URL
+
+	data, err := fetcher.Fetch(context.Background(), "gs://states/env/prod/default.tfstate")
+	require.NoError(t, err)
+	assert.JSONEq(t, testStateJSON, string(data))
+
+	_, err = fetcher.Fetch(context.Background(), "gs://states/env/dev/default.tfstate")
+	assert.ErrorContains(t, err, "State object gs://states/env/dev/default.tfstate does not exist")
+
+	_, err = fetcher.Fetch(context.Background(), "gs://locked/default.tfstate")
+	assert.ErrorContains(t, err, "storage.objects.get on bucket locked")
+
+	expired := NewGCSStateFetcher(staticTokens("ya29.expired"))
+	expired.endpoint = server.URL
+	_, err = expired.Fetch(context.Background(), "gs://states/env/prod/default.tfstate")
+	assert.True(t, errors.IsOperationalError(err))
+	assert.ErrorContains(t, err, "rejected the Google credentials")
+}
+
+func writeCredentialsFile(t *testing.T, creds map[string]string) {
+	t.Helper()
+	data, err := json.Marshal(creds)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+}
+
+func TestGoogleDefaultCredentials_ServiceAccount(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+
+		// The assertion is an RS256 JWT signed with the service account key
+		parts := strings.Split(r.Form.Get("assertion"), ".")
+		require.Len(t, parts, 3)
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+		claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		assert.Contains(t, string(claims), `"iss":"drift@project.iam.gserviceaccount.com"`)
+		assert.Contains(t, string(claims), googleStorageScope)
+
+		fmt.Fprint(w, `{"access_token":"ya29.sa","expires_in":3600,"token_type":"Bearer"}`)
+	}))
+	defer server.Close()
+
+	writeCredentialsFile(t, map[string]string{
+		"type":         "service_account",
+		"client_email": "drift@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    server.URL,
+	})
+
+	creds, err := NewGoogleDefaultCredentials()
+	require.NoError(t, err)
+	token, err := creds.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ya29.sa", token.AccessToken)
+
+	// The token is cached until it nears expiry
+	_, err = creds.Token()
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func TestGoogleDefaultCredentials_AuthorizedUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.Form.Get("refresh_token") != "1//refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assert.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"ya29.user","expires_in":3600}`)
+	}))
+	defer server.Close()
+
+	writeCredentialsFile(t, map[string]string{
+		"type":          "authorized_user",
+		"client_id":     "client",
+		"client_secret": "secret",
+		"refresh_token": "1//refresh",
+		"token_uri":     server.URL,
+	})
+	creds, err := NewGoogleDefaultCredentials()
+	require.NoError(t, err)
+	token, err := creds.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ya29.user", token.AccessToken)
+
+	// A rejected refresh token fails the state read, naming the status
+	writeCredentialsFile(t, map[string]string{"type": "authorized_user", "refresh_token": "revoked", "token_uri": server.URL})
+	_, err = NewGCSStateFetcher(nil).Fetch(context.Background(), "gs://states/default.tfstate")
+	assert.True(t, errors.IsOperationalError(err))
+	assert.ErrorContains(t, err, "rejected the Google credentials (HTTP 400)")
+
+	writeCredentialsFile(t, map[string]string{"type": "unknown"})
+	_, err = NewGoogleDefaultCredentials()
+	assert.True(t, errors.IsOperationalError(err))
+	assert.ErrorContains(t, err, "No Google application default credentials found")
+}
+
+func TestGoogleDefaultCredentials_MetadataServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		w.Header().Set("Metadata-Flavor", "Google")
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			assert.Equal(t, googleStorageScope, r.URL.Query().Get("scopes"))
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"ya29.gce","expires_in":3600,"token_type":"Bearer"}`)
+		case "/computeMetadata/v1/project/project-id":
+			fmt.Fprint(w, "drift-project")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+
+	creds, err := NewGoogleDefaultCredentials()
+	require.NoError(t, err)
+	token, err := creds.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ya29.gce", token.AccessToken)
+}
+
+func TestNewStateProvider_RemoteStateFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testStateJSON)
+	}))
+	defer server.Close()
+
+	provider, err := NewStateProvider(ClientConfig{StateFile: server.URL + "/state/web"}, logging.New())
+	require.NoError(t, err)
+
+	instances, err := provider.ListInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "i-remote", instances[0].ID)
+}
+
+func TestNewStateProvider_StateLocationValidation(t *testing.T) {
+	_, err := NewStateProvider(ClientConfig{StateFile: "ftp://example.com/terraform.tfstate"}, logging.New())
+	assert.True(t, errors.IsValidationError(err))
+	assert.ErrorContains(t, err, `Unsupported state location scheme "ftp"`)
+
+	_, err = NewStateProvider(ClientConfig{StateFile: "s3://states"}, logging.New())
+	assert.True(t, errors.IsValidationError(err))
+
+	_, err = NewStateProvider(ClientConfig{StateFile: "gs:///default.tfstate"}, logging.New())
+	assert.True(t, errors.IsValidationError(err))
+}
@@ -0,0 +1,36 @@
+package terraform
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// googleStorageScope is the OAuth2 scope requested for reading state objects
+const googleStorageScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+// NewGoogleDefaultCredentials finds Google application default credentials with Google's
+// OAuth2 library: the file named by GOOGLE_APPLICATION_CREDENTIALS, then gcloud's application
+// default credentials, then the metadata server on Google Cloud. Tokens are cached until
+// shortly before they expire.
+func NewGoogleDefaultCredentials() (oauth2.TokenSource, error) {
+	// The token source refreshes with this context long after the caller's request is done
+	creds, err := google.FindDefaultCredentials(context.Background(), googleStorageScope)
+	if err != nil {
+		return nil, errors.NewOperationalError("No Google application default credentials found; set GOOGLE_APPLICATION_CREDENTIALS or run `gcloud auth application-default login`", err)
+	}
+	return creds.TokenSource, nil
+}
+
+// googleTokenError explains why an access token could not be obtained
+func googleTokenError(err error) error {
+	var retrieveErr *oauth2.RetrieveError
+	if stderrors.As(err, &retrieveErr) && retrieveErr.Response != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Google token endpoint rejected the Google credentials (HTTP %d)", retrieveErr.Response.StatusCode), err)
+	}
+	return errors.NewOperationalError("Failed to obtain a Google access token", err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
@@ -16,7 +17,8 @@ import (
 
 // HCLParser parses Terraform HCL configuration files
 type HCLParser struct {
-	logger *logging.Logger
+	logger   *logging.Logger
+	resolver ParameterResolver
 }
 
 // NewHCLParser creates a new Terraform HCL parser
@@ -26,6 +28,70 @@ func NewHCLParser(logger *logging.Logger) *HCLParser {
 	}
 }
 
+// SetParameterResolver sets the resolver used to look up AMIs read from SSM parameters. Without
+// one, those AMIs are unknown.
+func (p *HCLParser) SetParameterResolver(resolver ParameterResolver) {
+	p.resolver = resolver
+}
+
+// hclFile is what one or more Terraform files declare
+type hclFile struct {
+	instances []*model.Instance
+
+	// eipInstances names the instances that Elastic IPs are associated with
+	eipInstances map[string]bool
+
+	// instanceStates maps instance names to the state aws_ec2_instance_state keeps them in
+	instanceStates map[string]string
+
+	// ssmParameters maps aws_ssm_parameter data source names to the parameter they read
+	ssmParameters map[string]string
+
+	// amiReferences maps instance IDs to how their ami is read from SSM
+	amiReferences map[string]amiReference
+
+	// moves maps the former addresses that moved blocks declare to the current ones
+	moves model.AddressMoves
+}
+
+// amiReference is an ami read from SSM, either through an aws_ssm_parameter data source or
+// directly with a resolve:ssm: value
+type amiReference struct {
+	dataSource string
+	parameter  string
+}
+
+// newHCLFile creates an empty hclFile
+func newHCLFile() *hclFile {
+	return &hclFile{
+		eipInstances:   make(map[string]bool),
+		instanceStates: make(map[string]string),
+		ssmParameters:  make(map[string]string),
+		amiReferences:  make(map[string]amiReference),
+		moves:          make(model.AddressMoves),
+	}
+}
+
+// merge adds what another file declares, which data sources and Elastic IPs may refer across
+func (f *hclFile) merge(other *hclFile) {
+	f.instances = append(f.instances, other.instances...)
+	for name := range other.eipInstances {
+		f.eipInstances[name] = true
+	}
+	for name, state := range other.instanceStates {
+		f.instanceStates[name] = state
+	}
+	for name, parameter := range other.ssmParameters {
+		f.ssmParameters[name] = parameter
+	}
+	for id, ref := range other.amiReferences {
+		f.amiReferences[id] = ref
+	}
+	for from, to := range other.moves {
+		f.moves[from] = to
+	}
+}
+
 // TerraformConfig represents the structure of Terraform configuration
 type TerraformConfig struct {
 	Resources []TerraformConfigResource `hcl:"resource,block"`
@@ -38,9 +104,10 @@ type TerraformConfigResource struct {
 	Attributes hcl.Body `hcl:",remain"`
 }
 
-// ParseHCLDir parses all .tf files in a directory
+// ParseHCLDir parses all .tf files in a directory. Per-file progress is logged at debug level
+// with a single summary at info, and cancellation is checked between files.
 func (p *HCLParser) ParseHCLDir(ctx context.Context, dirPath string) ([]*model.Instance, error) {
-	p.logger.Info(fmt.Sprintf("Parsing Terraform HCL files in directory: %s", dirPath))
+	p.logger.Debug(fmt.Sprintf("Parsing Terraform HCL files in directory: %s", dirPath))
 
 	// Get all .tf files in the directory
 	files, err := filepath.Glob(filepath.Join(dirPath, "*.tf"))
@@ -48,31 +115,56 @@ func (p *HCLParser) ParseHCLDir(ctx context.Context, dirPath string) ([]*model.I
 		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to list Terraform files in %s", dirPath), err)
 	}
 
-	p.logger.Info(fmt.Sprintf("Found %d Terraform files in directory", len(files)))
+	p.logger.Debug(fmt.Sprintf("Found %d Terraform files in directory", len(files)))
 	if len(files) == 0 {
 		return nil, errors.NewOperationalError(fmt.Sprintf("No Terraform files found in %s", dirPath), nil)
 	}
 
-	var instances []*model.Instance
+	parsed := newHCLFile()
+	skipped := 0
 
 	// Process each file
 	for _, file := range files {
-		fileInstances, err := p.ParseHCLFile(ctx, file)
+		if err := ctx.Err(); err != nil {
+			return nil, errors.NewOperationalError(fmt.Sprintf("Parsing Terraform files in %s cancelled", dirPath), err)
+		}
+
+		fileParsed, err := p.parseHCLFile(file)
 		if err != nil {
 			p.logger.Warn(fmt.Sprintf("Error parsing file %s: %v", file, err))
+			skipped++
 			continue
 		}
 
-		instances = append(instances, fileInstances...)
+		parsed.merge(fileParsed)
 	}
 
-	p.logger.Info(fmt.Sprintf("Found %d EC2 instances in Terraform configuration", len(instances)))
-	return instances, nil
+	// Elastic IPs, instance states and data sources may be declared in a different file than the instance
+	markEIPInstances(parsed.instances, parsed.eipInstances)
+	applyInstanceStates(parsed.instances, parsed.instanceStates)
+	p.resolveAMIs(ctx, parsed)
+
+	p.logger.Info(fmt.Sprintf("Found %d EC2 instances in %d Terraform files in %s (%d skipped)", len(parsed.instances), len(files)-skipped, dirPath, skipped))
+	return parsed.instances, nil
 }
 
 // ParseHCLFile parses a single Terraform HCL file
 func (p *HCLParser) ParseHCLFile(ctx context.Context, filePath string) ([]*model.Instance, error) {
-	p.logger.Info("Parsing Terraform HCL file: %s", filePath)
+	parsed, err := p.parseHCLFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	markEIPInstances(parsed.instances, parsed.eipInstances)
+	applyInstanceStates(parsed.instances, parsed.instanceStates)
+	p.resolveAMIs(ctx, parsed)
+	return parsed.instances, nil
+}
+
+// parseHCLFile parses the aws_instance resources of a file, along with the Elastic IPs and
+// SSM parameter data sources they may refer to
+func (p *HCLParser) parseHCLFile(filePath string) (*hclFile, error) {
+	p.logger.Debug(fmt.Sprintf("Parsing Terraform HCL file: %s", filePath))
 
 	// Create a new parser
 	parser := hclparse.NewParser()
@@ -83,13 +175,22 @@ func (p *HCLParser) ParseHCLFile(ctx context.Context, filePath string) ([]*model
 		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to parse HCL in %s", filePath), diags)
 	}
 
-	// Define a struct to hold the configuration
+	// Define a struct to hold the configuration; variables, outputs and other blocks are ignored
 	type ResourceConfig struct {
 		Resources []struct {
 			Type string   `hcl:"type,label"`
 			Name string   `hcl:"name,label"`
 			Body hcl.Body `hcl:",remain"`
 		} `hcl:"resource,block"`
+		Data []struct {
+			Type string   `hcl:"type,label"`
+			Name string   `hcl:"name,label"`
+			Body hcl.Body `hcl:",remain"`
+		} `hcl:"data,block"`
+		Moved []struct {
+			Body hcl.Body `hcl:",remain"`
+		} `hcl:"moved,block"`
+		Remain hcl.Body `hcl:",remain"`
 	}
 
 	var config ResourceConfig
@@ -100,33 +201,252 @@ func (p *HCLParser) ParseHCLFile(ctx context.Context, filePath string) ([]*model
 		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to decode HCL in %s", filePath), diags)
 	}
 
-	var instances []*model.Instance
+	parsed := newHCLFile()
+
+	for _, data := range config.Data {
+		if data.Type != "aws_ssm_parameter" {
+			continue
+		}
+		if parameter, ok := literalAttribute(data.Body, "name"); ok {
+			parsed.ssmParameters[data.Name] = parameter
+		}
+	}
+
+	for _, moved := range config.Moved {
+		from, to, err := movedAddresses(moved.Body)
+		if err != nil {
+			p.logger.Warn(fmt.Sprintf("Ignoring moved block in %s: %v", filePath, err))
+			continue
+		}
+		parsed.moves[from] = to
+	}
 
 	// Process each resource
 	for _, resource := range config.Resources {
+		if resource.Type == "aws_eip" || resource.Type == "aws_eip_association" {
+			for _, name := range eipInstanceNames(resource.Type, resource.Body) {
+				parsed.eipInstances[name] = true
+			}
+			continue
+		}
+
+		if resource.Type == "aws_ec2_instance_state" {
+			state, ok := literalAttribute(resource.Body, "state")
+			if !ok {
+				continue
+			}
+			for _, name := range referencedInstanceNames(resource.Body, "instance_id") {
+				parsed.instanceStates[name] = state
+			}
+			continue
+		}
+
 		// Only process aws_instance resources
 		if resource.Type == "aws_instance" {
 			// Extract attributes from the resource body
 			attrs, err := p.extractAttributes(resource.Body)
 			if err != nil {
-				p.logger.Warn("Failed to extract attributes from resource %s: %v", resource.Name, err)
+				p.logger.Warn(fmt.Sprintf("Failed to extract attributes from resource %s: %v", resource.Name, err))
 				continue
 			}
 
+			attrs[model.AttributeInstanceLifecycle] = instanceLifecycle(attrs)
+			normalizePlacement(attrs)
+			normalizePublicIP(attrs)
+			normalizeSecondaryPrivateIPs(attrs)
+
 			// Add resource metadata
 			attrs["resource_name"] = resource.Name
 			attrs["resource_type"] = resource.Type
 
 			// Generate ID
-			id := fmt.Sprintf("tf-%s-%s", resource.Type, resource.Name)
+			id := fmt.Sprintf("%s%s-%s", model.PseudoIDPrefix, resource.Type, resource.Name)
 
 			// Create instance
 			instance := model.NewInstance(id, attrs, model.OriginTerraform)
-			instances = append(instances, instance)
+			instance.ResourceAddress = resource.Type + "." + resource.Name
+
+			// An explicitly declared private IP is meaningful; an allocated one is not
+			if _, ok := attrs["private_ip"]; ok {
+				instance.MarkStatic("private_ip")
+			}
+
+			if ref, ok := ssmAMIReference(resource.Body, attrs["ami"]); ok {
+				parsed.amiReferences[id] = ref
+			}
+
+			parsed.instances = append(parsed.instances, instance)
+		}
+	}
+
+	return parsed, nil
+}
+
+// literalAttribute returns the value of a string attribute that doesn't reference anything
+func literalAttribute(body hcl.Body, name string) (string, bool) {
+	content, _, diags := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: name}},
+	})
+	if diags.HasErrors() {
+		return "", false
+	}
+
+	attr, ok := content.Attributes[name]
+	if !ok {
+		return "", false
+	}
+
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+// ssmAMIReference returns how an instance's ami is read from SSM: a resolve:ssm: value, or a
+// reference to the value of an aws_ssm_parameter data source (possibly wrapped in a function
+// such as nonsensitive)
+func ssmAMIReference(body hcl.Body, ami interface{}) (amiReference, bool) {
+	if value, ok := ami.(string); ok {
+		if parameter, ok := strings.CutPrefix(value, ssmResolvePrefix); ok && parameter != "" {
+			return amiReference{parameter: parameter}, true
+		}
+		return amiReference{}, false
+	}
+
+	content, _, diags := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "ami"}},
+	})
+	if diags.HasErrors() {
+		return amiReference{}, false
+	}
+
+	attr, ok := content.Attributes["ami"]
+	if !ok {
+		return amiReference{}, false
+	}
+
+	traversals := attr.Expr.Variables()
+	if len(traversals) != 1 || len(traversals[0]) < 4 || traversals[0].RootName() != "data" {
+		return amiReference{}, false
+	}
+
+	var steps []string
+	for _, step := range traversals[0][1:4] {
+		attrStep, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			return amiReference{}, false
+		}
+		steps = append(steps, attrStep.Name)
+	}
+	if steps[0] != "aws_ssm_parameter" || (steps[2] != "value" && steps[2] != "insecure_value") {
+		return amiReference{}, false
+	}
+
+	return amiReference{dataSource: steps[1]}, true
+}
+
+// resolveAMIs replaces AMIs read from SSM parameters with the parameter's current value, which
+// is what the instance was launched with. AMIs that can't be resolved are unknown. Each
+// parameter is looked up once per parse, since many instances usually share one.
+func (p *HCLParser) resolveAMIs(ctx context.Context, parsed *hclFile) {
+	resolved := make(map[string]string)
+	for _, instance := range parsed.instances {
+		ref, ok := parsed.amiReferences[instance.ID]
+		if !ok {
+			continue
+		}
+
+		parameter := ref.parameter
+		if parameter == "" {
+			parameter, ok = parsed.ssmParameters[ref.dataSource]
+			if !ok {
+				p.logger.Debug(fmt.Sprintf("AMI of %s reads data source aws_ssm_parameter.%s, which has no literal name", instance.ID, ref.dataSource))
+				continue
+			}
+		}
+
+		if p.resolver == nil {
+			instance.Attributes["ami"] = model.UnknownValue{Reason: fmt.Sprintf("read from SSM parameter %s", parameter)}
+			continue
+		}
+
+		ami, ok := resolved[parameter]
+		if ok {
+			instance.Attributes["ami"] = ami
+			continue
+		}
+
+		ami, err := p.resolver.GetParameter(ctx, parameter)
+		if err != nil {
+			p.logger.Warn(fmt.Sprintf("Failed to resolve the AMI of %s: %v", instance.ID, err))
+			instance.Attributes["ami"] = model.UnknownValue{Reason: fmt.Sprintf("failed to resolve SSM parameter %s", parameter)}
+			continue
+		}
+
+		p.logger.Debug(fmt.Sprintf("Resolved the AMI of %s from SSM parameter %s: %s", instance.ID, parameter, ami))
+		instance.Attributes["ami"] = ami
+		resolved[parameter] = ami
+	}
+}
+
+// eipInstanceNames returns the names of the aws_instance resources an aws_eip or
+// aws_eip_association references (e.g. instance = aws_instance.web.id)
+func eipInstanceNames(resourceType string, body hcl.Body) []string {
+	attrName := "instance"
+	if resourceType == "aws_eip_association" {
+		attrName = "instance_id"
+	}
+	return referencedInstanceNames(body, attrName)
+}
+
+// referencedInstanceNames returns the names of the aws_instance resources an attribute refers to
+func referencedInstanceNames(body hcl.Body, attrName string) []string {
+	content, _, diags := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: attrName}},
+	})
+	if diags.HasErrors() {
+		return nil
+	}
+
+	attr, ok := content.Attributes[attrName]
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, traversal := range attr.Expr.Variables() {
+		if len(traversal) < 2 || traversal.RootName() != "aws_instance" {
+			continue
+		}
+		if step, ok := traversal[1].(hcl.TraverseAttr); ok {
+			names = append(names, step.Name)
 		}
 	}
 
-	return instances, nil
+	return names
+}
+
+// applyInstanceStates sets instance_state to the state an aws_ec2_instance_state resource keeps
+// the instance in. Terraform launches instances running, so that is expected otherwise.
+func applyInstanceStates(instances []*model.Instance, states map[string]string) {
+	for _, instance := range instances {
+		name, _ := instance.Attributes["resource_name"].(string)
+		if state, ok := states[name]; ok {
+			instance.Attributes[model.AttributeInstanceState] = state
+		} else {
+			instance.Attributes[model.AttributeInstanceState] = model.InstanceStateRunning
+		}
+	}
+}
+
+// markEIPInstances marks public_ip as static on instances with an associated Elastic IP
+func markEIPInstances(instances []*model.Instance, eips map[string]bool) {
+	for _, instance := range instances {
+		if name, ok := instance.Attributes["resource_name"].(string); ok && eips[name] {
+			instance.MarkStatic("public_ip")
+		}
+	}
 }
 
 // extractInstanceFromResource extracts an EC2 instance from a Terraform resource
@@ -138,13 +458,15 @@ func (p *HCLParser) extractInstanceFromResource(resource TerraformConfigResource
 	}
 
 	// Generate a pseudo-ID since the real ID won't be known until Terraform applies the configuration
-	id := fmt.Sprintf("tf-%s-%s", resource.Type, resource.Name)
+	id := fmt.Sprintf("%s%s-%s", model.PseudoIDPrefix, resource.Type, resource.Name)
 
 	// Add resource name and type to attributes
 	attrs["resource_name"] = resource.Name
 	attrs["resource_type"] = resource.Type
 
-	return model.NewInstance(id, attrs, model.OriginTerraform), nil
+	instance := model.NewInstance(id, attrs, model.OriginTerraform)
+	instance.ResourceAddress = resource.Type + "." + resource.Name
+	return instance, nil
 }
 
 // extractAttributes extracts attributes from HCL body
@@ -157,6 +479,8 @@ func (p *HCLParser) extractAttributes(body hcl.Body) (map[string]interface{}, er
 			{Name: "subnet_id", Required: false},
 			{Name: "vpc_security_group_ids", Required: false},
 			{Name: "key_name", Required: false},
+			{Name: "private_ip", Required: false},
+			{Name: model.AttributeSecondaryPrivateIPs, Required: false},
 			{Name: "availability_zone", Required: false},
 			{Name: "tags", Required: false},
 			{Name: "ebs_optimized", Required: false},
@@ -164,12 +488,20 @@ func (p *HCLParser) extractAttributes(body hcl.Body) (map[string]interface{}, er
 			{Name: "iam_instance_profile", Required: false},
 			{Name: "user_data", Required: false},
 			{Name: "user_data_base64", Required: false},
+			{Name: "tenancy", Required: false},
+			{Name: "host_id", Required: false},
+			{Name: "associate_public_ip_address", Required: false},
+			{Name: model.AttributeDisableAPITermination, Required: false},
 		},
 		Blocks: []hcl.BlockHeaderSchema{
 			{Type: "ebs_block_device"},
 			{Type: "root_block_device"},
 			{Type: "network_interface"},
 			{Type: "timeouts"},
+			{Type: attributeMarketOptions},
+			{Type: "launch_template"},
+			{Type: blockLifecycle},
+			{Type: "dynamic", LabelNames: []string{"name"}},
 		},
 	}
 
@@ -190,7 +522,13 @@ func (p *HCLParser) extractAttributes(body hcl.Body) (map[string]interface{}, er
 		// Evaluate the expression
 		value, diags := attr.Expr.Value(evalCtx)
 		if diags.HasErrors() {
-			p.logger.Warn("Failed to evaluate attribute %s: %v", name, diags.Error())
+			// References to variables or other resources can't be resolved statically
+			if unknown, ok := unresolvedReference(attr.Expr); ok {
+				p.logger.Debug(fmt.Sprintf("Attribute %s is unknown: %s", name, unknown.Reason))
+				attrs[name] = unknown
+				continue
+			}
+			p.logger.Warn(fmt.Sprintf("Failed to evaluate attribute %s: %v", name, diags.Error()))
 			continue
 		}
 
@@ -202,10 +540,35 @@ func (p *HCLParser) extractAttributes(body hcl.Body) (map[string]interface{}, er
 	for _, block := range content.Blocks {
 		blockType := block.Type
 
+		// Dynamic blocks are generated from for_each at plan time, so their content is unknown
+		if blockType == "dynamic" {
+			name := block.Labels[0]
+			p.logger.Debug(fmt.Sprintf("Block %s is unknown: generated by a dynamic block", name))
+			attrs[name] = model.UnknownValue{Reason: fmt.Sprintf("generated by dynamic \"%s\" block", name)}
+			continue
+		}
+
+		// A static block can't be merged with blocks of the same type generated dynamically
+		if model.IsUnknown(attrs[blockType]) {
+			continue
+		}
+
+		// Only prevent_destroy is compared, against AWS's disable_api_termination
+		if blockType == blockLifecycle {
+			attrs[model.AttributePreventDestroy] = preventDestroy(block)
+			continue
+		}
+
+		// Only the market type is compared, as instance_lifecycle
+		if blockType == attributeMarketOptions {
+			attrs[blockType] = []interface{}{p.extractMarketOptions(block)}
+			continue
+		}
+
 		// Process the block content recursively
 		blockAttrs, err := p.extractBlockAttributes(block)
 		if err != nil {
-			p.logger.Warn("Failed to extract attributes from block %s: %v", blockType, err)
+			p.logger.Warn(fmt.Sprintf("Failed to extract attributes from block %s: %v", blockType, err))
 			continue
 		}
 
@@ -224,9 +587,42 @@ func (p *HCLParser) extractAttributes(body hcl.Body) (map[string]interface{}, er
 		}
 	}
 
+	if _, ok := attrs[model.AttributePreventDestroy]; !ok {
+		attrs[model.AttributePreventDestroy] = false
+	}
+
 	return attrs, nil
 }
 
+// extractMarketOptions extracts the market type of an instance_market_options block, ignoring
+// spot_options and other settings
+func (p *HCLParser) extractMarketOptions(block *hcl.Block) map[string]interface{} {
+	attrs := make(map[string]interface{})
+
+	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "market_type"}},
+	})
+	if diags.HasErrors() {
+		p.logger.Warn(fmt.Sprintf("Failed to extract attributes from block %s: %v", block.Type, diags.Error()))
+		return attrs
+	}
+
+	attr, ok := content.Attributes["market_type"]
+	if !ok {
+		return attrs
+	}
+
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		if unknown, ok := unresolvedReference(attr.Expr); ok {
+			attrs["market_type"] = unknown
+		}
+		return attrs
+	}
+	attrs["market_type"] = convertCtyValue(value)
+	return attrs
+}
+
 // extractBlockAttributes extracts attributes from an HCL block
 func (p *HCLParser) extractBlockAttributes(block *hcl.Block) (map[string]interface{}, error) {
 	// Extract all attributes from the block
@@ -244,6 +640,12 @@ func (p *HCLParser) extractBlockAttributes(block *hcl.Block) (map[string]interfa
 			{Name: "encrypted", Required: false},
 			{Name: "kms_key_id", Required: false},
 			{Name: "snapshot_id", Required: false},
+			{Name: "network_interface_id", Required: false},
+			{Name: "device_index", Required: false},
+			{Name: "network_card_index", Required: false},
+			{Name: "id", Required: false},
+			{Name: "name", Required: false},
+			{Name: "version", Required: false},
 			// Add other attributes as needed for different block types
 		},
 		// Allow for nested blocks if needed
@@ -264,7 +666,12 @@ func (p *HCLParser) extractBlockAttributes(block *hcl.Block) (map[string]interfa
 		// Evaluate the expression
 		value, diags := attr.Expr.Value(evalCtx)
 		if diags.HasErrors() {
-			p.logger.Warn("Failed to evaluate block attribute %s: %v", name, diags.Error())
+			if unknown, ok := unresolvedReference(attr.Expr); ok {
+				p.logger.Debug(fmt.Sprintf("Block attribute %s is unknown: %s", name, unknown.Reason))
+				attrs[name] = unknown
+				continue
+			}
+			p.logger.Warn(fmt.Sprintf("Failed to evaluate block attribute %s: %v", name, diags.Error()))
 			continue
 		}
 
@@ -275,6 +682,39 @@ func (p *HCLParser) extractBlockAttributes(block *hcl.Block) (map[string]interfa
 	return attrs, nil
 }
 
+// unresolvedReference returns an unknown value describing the references in an expression
+// that could not be evaluated (e.g. var.x, aws_security_group.app[*].id)
+func unresolvedReference(expr hcl.Expression) (model.UnknownValue, bool) {
+	traversals := expr.Variables()
+	if len(traversals) == 0 {
+		return model.UnknownValue{}, false
+	}
+
+	refs := make([]string, 0, len(traversals))
+	for _, traversal := range traversals {
+		refs = append(refs, traversalString(traversal))
+	}
+
+	return model.UnknownValue{Reason: fmt.Sprintf("unresolved reference to %s", strings.Join(refs, ", "))}, true
+}
+
+// traversalString renders the static part of a traversal (e.g. aws_security_group.app)
+func traversalString(traversal hcl.Traversal) string {
+	var sb strings.Builder
+	for _, step := range traversal {
+		switch t := step.(type) {
+		case hcl.TraverseRoot:
+			sb.WriteString(t.Name)
+		case hcl.TraverseAttr:
+			sb.WriteString(".")
+			sb.WriteString(t.Name)
+		default:
+			return sb.String()
+		}
+	}
+	return sb.String()
+}
+
 // convertCtyValue converts a cty.Value to a Go value
 func convertCtyValue(value cty.Value) interface{} {
 	// Handle null values
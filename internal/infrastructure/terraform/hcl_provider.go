@@ -0,0 +1,125 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// HCLProvider reads instances from the HCL configuration in a directory. Configurations don't
+// record instance IDs, so instances are identified by their resource name.
+type HCLProvider struct {
+	hclParser *HCLParser
+	logger    *logging.Logger
+	hclDir    string
+}
+
+// NewHCLProvider creates a provider reading the HCL directory of cfg
+func NewHCLProvider(cfg ClientConfig, logger *logging.Logger) (*HCLProvider, error) {
+	logger = logger.WithField("component", "terraform-hcl")
+
+	// Validate configuration
+	if cfg.HCLDir == "" {
+		return nil, errors.NewValidationError("HCL directory must be specified when UseHCL is true")
+	}
+
+	// Check if the directory exists
+	info, err := os.Stat(cfg.HCLDir)
+	if err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("HCL directory %s does not exist", cfg.HCLDir), err)
+	}
+
+	if !info.IsDir() {
+		return nil, errors.NewValidationError(fmt.Sprintf("%s is not a directory", cfg.HCLDir))
+	}
+
+	if len(cfg.Workspaces) > 0 {
+		return nil, errors.NewValidationError("Terraform workspaces can only be read from a state file backend, not from HCL or Terraform Cloud")
+	}
+
+	hclParser := NewHCLParser(logger)
+	hclParser.SetParameterResolver(cfg.ParameterResolver)
+
+	return &HCLProvider{
+		hclParser: hclParser,
+		logger:    logger,
+		hclDir:    cfg.HCLDir,
+	}, nil
+}
+
+// GetInstance retrieves instance configuration by ID or resource name
+func (p *HCLProvider) GetInstance(ctx context.Context, instanceID string) (*model.Instance, error) {
+	p.logger.Info(fmt.Sprintf("Retrieving instance %s from Terraform", instanceID))
+
+	// The ID is only known after Terraform applies the configuration, so all instances are
+	// read and matched by ID or resource name
+	instances, err := p.ListInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instance := range instances {
+		if instance.ID == instanceID {
+			return instance, nil
+		}
+		if resourceName, ok := instance.Attributes["resource_name"].(string); ok && resourceName == instanceID {
+			return instance, nil
+		}
+	}
+
+	// A resource renamed by a moved block is still found by its former name
+	moves, err := p.AddressMoves(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if address := moves.Current("aws_instance." + instanceID); address != "aws_instance."+instanceID {
+		for _, instance := range instances {
+			if instance.ResourceAddress == address {
+				p.logger.Info(fmt.Sprintf("Resource aws_instance.%s was moved to %s", instanceID, address))
+				return instance, nil
+			}
+		}
+	}
+
+	return nil, errors.NewNotFoundError("EC2 Instance", instanceID)
+}
+
+// ListInstances retrieves all available instances
+func (p *HCLProvider) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	p.logger.Info("Listing instances from Terraform")
+	return p.hclParser.ParseHCLDir(ctx, p.hclDir)
+}
+
+// AddressMoves returns the moves declared by the moved blocks of the configuration
+func (p *HCLProvider) AddressMoves(ctx context.Context) (model.AddressMoves, error) {
+	return p.hclParser.ParseMovedBlocks(ctx, p.hclDir)
+}
+
+// StreamInstances emits the instances of the configuration, which is read in full first
+func (p *HCLProvider) StreamInstances(ctx context.Context, emit func(*model.Instance) error) error {
+	instances, err := p.ListInstances(ctx)
+	if err != nil {
+		return err
+	}
+	return emitAll(instances, emit)
+}
+
+// ListManagedResourceIDs fails, since only state files carry the IDs of the resources
+// Terraform manages
+func (p *HCLProvider) ListManagedResourceIDs(ctx context.Context) (map[string]bool, error) {
+	return nil, errors.NewValidationError("Orphan detection requires a Terraform state file; HCL configurations do not record resource IDs")
+}
+
+// GetSourceType returns the source type for this provider
+func (p *HCLProvider) GetSourceType() model.ResourceOrigin {
+	return model.OriginTerraform
+}
+
+// GetHCLDir returns the HCL directory path
+func (p *HCLProvider) GetHCLDir() string {
+	return p.hclDir
+}
@@ -0,0 +1,317 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+func TestHCLParser_UnknownAttributes(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	instances, err := parser.ParseHCLFile(context.Background(), "testdata/unknown/main.tf")
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	byName := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byName[instance.Attributes["resource_name"].(string)] = instance
+	}
+
+	// Dynamic blocks are recorded as unknown instead of being dropped
+	dynamic := byName["dynamic_volumes"]
+	require.NotNil(t, dynamic)
+	assert.Equal(t, "t2.micro", dynamic.InstanceType)
+	ebs, ok := dynamic.GetAttribute("ebs_block_device")
+	assert.True(t, ok)
+	assert.True(t, model.IsUnknown(ebs))
+	assert.Contains(t, ebs.(model.UnknownValue).Reason, "dynamic")
+
+	// Nested paths below an unknown block are unknown as well
+	size, ok := dynamic.GetAttribute("ebs_block_device.0.volume_size")
+	assert.True(t, ok)
+	assert.True(t, model.IsUnknown(size))
+
+	// Splat references are recorded as unknown
+	splat := byName["splat_sgs"]
+	require.NotNil(t, splat)
+	sgs, ok := splat.GetAttribute("vpc_security_group_ids")
+	assert.True(t, ok)
+	assert.True(t, model.IsUnknown(sgs))
+	assert.Contains(t, sgs.(model.UnknownValue).Reason, "aws_security_group.app")
+
+	// Unresolved references inside static blocks keep the block and mark only the attribute
+	rootSize, ok := splat.GetAttribute("root_block_device.0.volume_size")
+	assert.True(t, ok)
+	assert.True(t, model.IsUnknown(rootSize))
+	rootType, ok := splat.GetAttribute("root_block_device.0.volume_type")
+	assert.True(t, ok)
+	assert.Equal(t, "gp3", rootType)
+}
+
+func TestHCLParser_StaticAddresses(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	instances, err := parser.ParseHCLDir(context.Background(), "testdata/addresses")
+	require.NoError(t, err)
+	require.Len(t, instances, 3)
+
+	byName := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byName[instance.Attributes["resource_name"].(string)] = instance
+	}
+
+	// A declared private_ip is static
+	assert.True(t, byName["fixed"].IsStatic("private_ip"))
+	assert.False(t, byName["fixed"].IsStatic("public_ip"))
+
+	// The EIP association lives in another file
+	assert.True(t, byName["elastic"].IsStatic("public_ip"))
+	assert.False(t, byName["elastic"].IsStatic("private_ip"))
+
+	assert.Empty(t, byName["dynamic"].StaticAttributes)
+}
+
+func TestHCLParser_ParseHCLDirLogsSummary(t *testing.T) {
+	var buf bytes.Buffer
+	parser := NewHCLParser(logging.NewLogger(logging.LogConfig{Level: logging.Info, Output: &buf}))
+
+	_, err := parser.ParseHCLDir(context.Background(), "testdata/addresses")
+	require.NoError(t, err)
+
+	// Per-file progress is debug output; only the summary is logged at info
+	assert.Equal(t, 1, strings.Count(buf.String(), "[INFO]"), buf.String())
+	assert.Contains(t, buf.String(), "Found 3 EC2 instances")
+}
+
+func TestHCLParser_ParseHCLDirCancelled(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	instances, err := parser.ParseHCLDir(ctx, "testdata/addresses")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, instances)
+}
+
+// fakeParameterResolver serves SSM parameters from a map and counts lookups
+type fakeParameterResolver struct {
+	values  map[string]string
+	lookups int
+}
+
+func (r *fakeParameterResolver) GetParameter(ctx context.Context, name string) (string, error) {
+	r.lookups++
+	value, ok := r.values[name]
+	if !ok {
+		return "", errors.NewOperationalError(fmt.Sprintf("SSM parameter %s does not exist", name), nil)
+	}
+	return value, nil
+}
+
+func TestHCLParser_ResolvesSSMAMIs(t *testing.T) {
+	resolver := &fakeParameterResolver{values: map[string]string{
+		"/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64": "ami-0a1b2c3d4e5f67890",
+	}}
+	parser := NewHCLParser(logging.New())
+	parser.SetParameterResolver(resolver)
+
+	// Data sources are declared in a different file than the instances
+	instances, err := parser.ParseHCLDir(context.Background(), "testdata/ssm")
+	require.NoError(t, err)
+	require.Len(t, instances, 6)
+
+	byName := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byName[instance.Attributes["resource_name"].(string)] = instance
+	}
+
+	assert.Equal(t, "ami-0a1b2c3d4e5f67890", byName["data_source"].Attributes["ami"])
+	assert.Equal(t, "ami-0a1b2c3d4e5f67890", byName["nonsensitive"].Attributes["ami"])
+	assert.Equal(t, "ami-0a1b2c3d4e5f67890", byName["resolve"].Attributes["ami"])
+	assert.Equal(t, "ami-0c55b159cbfafe1f0", byName["literal"].Attributes["ami"])
+
+	// A failed lookup leaves the AMI unknown rather than reporting drift
+	missing := byName["missing"].Attributes["ami"]
+	assert.True(t, model.IsUnknown(missing))
+	assert.Contains(t, missing.(model.UnknownValue).Reason, "/images/golden")
+
+	// A parameter name that is itself a reference can't be looked up
+	assert.True(t, model.IsUnknown(byName["dynamic_name"].Attributes["ami"]))
+
+	// Each parameter is looked up once
+	assert.Equal(t, 2, resolver.lookups)
+}
+
+func TestHCLParser_SSMAMIsWithoutResolver(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	instances, err := parser.ParseHCLDir(context.Background(), "testdata/ssm")
+	require.NoError(t, err)
+
+	for _, instance := range instances {
+		if instance.Attributes["resource_name"] == "literal" {
+			continue
+		}
+		ami := instance.Attributes["ami"]
+		assert.True(t, model.IsUnknown(ami), instance.ID)
+	}
+}
+
+func TestHCLParser_InstanceState(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	instances, err := parser.ParseHCLDir(context.Background(), "testdata/instance_state_hcl")
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	byName := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byName[instance.Attributes["resource_name"].(string)] = instance
+	}
+
+	// Instances are expected to be running unless aws_ec2_instance_state says otherwise
+	assert.Equal(t, model.InstanceStateRunning, byName["web"].Attributes[model.AttributeInstanceState])
+	assert.Equal(t, model.InstanceStateStopped, byName["batch"].Attributes[model.AttributeInstanceState])
+}
+
+func TestHCLParser_InstanceLifecycle(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	instances, err := parser.ParseHCLFile(context.Background(), "testdata/lifecycle_hcl/main.tf")
+	require.NoError(t, err)
+	require.Len(t, instances, 3)
+
+	byName := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byName[instance.Attributes["resource_name"].(string)] = instance
+	}
+
+	assert.Equal(t, model.InstanceLifecycleSpot, byName["spot"].Attributes[model.AttributeInstanceLifecycle])
+	assert.Equal(t, model.InstanceLifecycleOnDemand, byName["on_demand"].Attributes[model.AttributeInstanceLifecycle])
+	assert.True(t, model.IsUnknown(byName["variable"].Attributes[model.AttributeInstanceLifecycle]))
+}
+
+func TestHCLParser_Placement(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	instances, err := parser.ParseHCLFile(context.Background(), "testdata/placement_hcl/main.tf")
+	require.NoError(t, err)
+	require.Len(t, instances, 3)
+
+	byName := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byName[instance.Attributes["resource_name"].(string)] = instance
+	}
+
+	assert.Equal(t, "h-0123456789abcdef0", byName["pinned"].Attributes[model.AttributeHostID])
+	assert.Equal(t, model.AffinityDefault, byName["pinned"].Attributes[model.AttributeAffinity])
+
+	// The host of an auto-placed instance is only known once it runs
+	assert.Equal(t, model.TenancyHost, byName["auto_placed"].Attributes[model.AttributeTenancy])
+	assert.True(t, model.IsUnknown(byName["auto_placed"].Attributes[model.AttributeHostID]))
+
+	assert.Equal(t, model.TenancyDefault, byName["shared"].Attributes[model.AttributeTenancy])
+	assert.NotContains(t, byName["shared"].Attributes, model.AttributeHostID)
+}
+
+func TestHCLParser_PublicIP(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	instances, err := parser.ParseHCLFile(context.Background(), "testdata/public_ip_hcl/main.tf")
+	require.NoError(t, err)
+	require.Len(t, instances, 4)
+
+	byName := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byName[instance.Attributes["resource_name"].(string)] = instance
+	}
+
+	assert.Equal(t, true, byName["public"].Attributes[model.AttributeAssociatePublicIPAddress])
+	assert.Equal(t, false, byName["private"].Attributes[model.AttributeAssociatePublicIPAddress])
+
+	// Left to the subnet or the network interface, the setting can't be read from the configuration
+	assert.Equal(t, model.UnknownValue{Reason: "defaults to the subnet's map_public_ip_on_launch"}, byName["subnet_default"].Attributes[model.AttributeAssociatePublicIPAddress])
+	assert.Equal(t, model.UnknownValue{Reason: "set by the attached network interface"}, byName["attached"].Attributes[model.AttributeAssociatePublicIPAddress])
+}
+
+func TestHCLParser_SecondaryPrivateIPs(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	instances, err := parser.ParseHCLFile(context.Background(), "testdata/secondary_ips_hcl/main.tf")
+	require.NoError(t, err)
+	require.Len(t, instances, 3)
+
+	byName := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byName[instance.Attributes["resource_name"].(string)] = instance
+	}
+
+	assert.ElementsMatch(t, []interface{}{"10.0.1.11", "10.0.1.12"}, byName["multi_ip"].Attributes[model.AttributeSecondaryPrivateIPs])
+	assert.NotContains(t, byName["single_ip"].Attributes, model.AttributeSecondaryPrivateIPs)
+
+	// The attached interface resource assigns the addresses
+	assert.Equal(t, model.UnknownValue{Reason: "assigned by the attached network interface"}, byName["attached"].Attributes[model.AttributeSecondaryPrivateIPs])
+}
+
+func TestHCLParser_TerminationProtection(t *testing.T) {
+	parser := NewHCLParser(logging.New())
+
+	instances, err := parser.ParseHCLFile(context.Background(), "testdata/termination_hcl/main.tf")
+	require.NoError(t, err)
+	require.Len(t, instances, 4)
+
+	byName := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byName[instance.Attributes["resource_name"].(string)] = instance
+	}
+
+	assert.Equal(t, true, byName["protected"].Attributes[model.AttributeDisableAPITermination])
+	assert.Equal(t, true, byName["protected"].Attributes[model.AttributePreventDestroy])
+	assert.Equal(t, true, byName["terraform_only"].Attributes[model.AttributePreventDestroy])
+	assert.NotContains(t, byName["terraform_only"].Attributes, model.AttributeDisableAPITermination)
+
+	// Without prevent_destroy, in a lifecycle block or at all, destroy isn't prevented
+	assert.Equal(t, false, byName["unprotected"].Attributes[model.AttributePreventDestroy])
+	assert.Equal(t, false, byName["no_lifecycle"].Attributes[model.AttributePreventDestroy])
+	assert.NotContains(t, byName["unprotected"].Attributes, "lifecycle")
+}
+
+func TestHCLParser_MovedBlocks(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`
+moved {
+  from = aws_instance.web[0]
+  to   = module.web["blue"].aws_instance.this
+}
+
+moved {
+  from = module.legacy
+  to   = module.app
+}
+
+# Not an address, so ignored
+moved {
+  from = "aws_instance.db"
+  to   = aws_instance.database
+}
+`), 0o644))
+
+	moves, err := NewHCLParser(logging.New()).ParseMovedBlocks(context.Background(), dir)
+	require.NoError(t, err)
+	assert.Equal(t, model.AddressMoves{
+		"aws_instance.web[0]": `module.web["blue"].aws_instance.this`,
+		"module.legacy":       "module.app",
+	}, moves)
+}
@@ -0,0 +1,36 @@
+package terraform
+
+import "github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+
+// attributeMarketOptions is the aws_instance block that requests spot capacity
+const attributeMarketOptions = "instance_market_options"
+
+// instanceLifecycle derives the comparable instance_lifecycle of a Terraform instance from the
+// instance_lifecycle recorded in state, or else the market type of instance_market_options.
+// Without either the instance is on-demand.
+func instanceLifecycle(attrs map[string]interface{}) interface{} {
+	if lifecycle, ok := attrs[model.AttributeInstanceLifecycle].(string); ok && lifecycle != "" {
+		return model.NormalizeInstanceLifecycle(lifecycle)
+	}
+
+	options, ok := attrs[attributeMarketOptions].([]interface{})
+	if !ok || len(options) == 0 {
+		return model.InstanceLifecycleOnDemand
+	}
+
+	option, ok := options[0].(map[string]interface{})
+	if !ok {
+		return model.InstanceLifecycleOnDemand
+	}
+
+	marketType := option["market_type"]
+	if model.IsUnknown(marketType) {
+		return marketType
+	}
+	if value, ok := marketType.(string); ok && value != "" {
+		return model.NormalizeInstanceLifecycle(value)
+	}
+
+	// market_type defaults to spot, the only reason to declare the block before capacity blocks
+	return model.InstanceLifecycleSpot
+}
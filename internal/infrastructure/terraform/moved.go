@@ -0,0 +1,82 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// movedAddresses returns the from and to addresses of a moved block
+func movedAddresses(body hcl.Body) (string, string, error) {
+	content, diags := body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "from", Required: true}, {Name: "to", Required: true}},
+	})
+	if diags.HasErrors() {
+		return "", "", diags
+	}
+
+	addresses := make([]string, 0, 2)
+	for _, name := range []string{"from", "to"} {
+		traversal, diags := hcl.AbsTraversalForExpr(content.Attributes[name].Expr)
+		if diags.HasErrors() {
+			return "", "", diags
+		}
+		addresses = append(addresses, addressString(traversal))
+	}
+	return addresses[0], addresses[1], nil
+}
+
+// addressString renders a traversal as a resource address, index keys included, e.g.
+// module.web.aws_instance.this[0] or aws_instance.app["web"]
+func addressString(traversal hcl.Traversal) string {
+	var sb strings.Builder
+	for _, step := range traversal {
+		switch t := step.(type) {
+		case hcl.TraverseRoot:
+			sb.WriteString(t.Name)
+		case hcl.TraverseAttr:
+			sb.WriteString(".")
+			sb.WriteString(t.Name)
+		case hcl.TraverseIndex:
+			switch t.Key.Type() {
+			case cty.Number:
+				fmt.Fprintf(&sb, "[%s]", t.Key.AsBigFloat().Text('f', -1))
+			case cty.String:
+				fmt.Fprintf(&sb, "[%q]", t.Key.AsString())
+			}
+		}
+	}
+	return sb.String()
+}
+
+// ParseMovedBlocks reads the moved blocks of the .tf files in a directory, mapping the former
+// addresses of resources and modules to their current ones
+func (p *HCLParser) ParseMovedBlocks(ctx context.Context, dirPath string) (model.AddressMoves, error) {
+	files, err := filepath.Glob(filepath.Join(dirPath, "*.tf"))
+	if err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to list Terraform files in %s", dirPath), err)
+	}
+
+	moves := make(model.AddressMoves)
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.NewOperationalError(fmt.Sprintf("Parsing Terraform files in %s cancelled", dirPath), err)
+		}
+
+		parsed, err := p.parseHCLFile(file)
+		if err != nil {
+			p.logger.Warn(fmt.Sprintf("Error parsing file %s: %v", file, err))
+			continue
+		}
+		for from, to := range parsed.moves {
+			moves[from] = to
+		}
+	}
+	return moves, nil
+}
@@ -0,0 +1,31 @@
+package terraform
+
+import "github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+
+// normalizePlacement aligns the placement of a Terraform instance with what EC2 reports:
+// tenancy defaults to default, an empty host_id means no dedicated host, and instances on a
+// dedicated host have the default affinity since aws_instance can't declare another. A host
+// picked by auto-placement or an affinity from a launch template can't be known.
+func normalizePlacement(attrs map[string]interface{}) {
+	tenancy, _ := attrs[model.AttributeTenancy].(string)
+	if !model.IsUnknown(attrs[model.AttributeTenancy]) {
+		tenancy = model.NormalizeTenancy(tenancy)
+		attrs[model.AttributeTenancy] = tenancy
+	}
+
+	if hostID, ok := attrs[model.AttributeHostID].(string); ok && hostID == "" {
+		delete(attrs, model.AttributeHostID)
+	}
+	if tenancy != model.TenancyHost {
+		return
+	}
+
+	if _, ok := attrs[model.AttributeHostID]; !ok {
+		attrs[model.AttributeHostID] = model.UnknownValue{Reason: "dedicated host assigned by auto-placement"}
+	}
+	if templates, ok := attrs["launch_template"].([]interface{}); ok && len(templates) > 0 {
+		attrs[model.AttributeAffinity] = model.UnknownValue{Reason: "affinity may be set by the launch template"}
+		return
+	}
+	attrs[model.AttributeAffinity] = model.AffinityDefault
+}
@@ -0,0 +1,16 @@
+package terraform
+
+import "github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+
+// normalizeSecondaryPrivateIPs marks secondary_private_ips of a configured instance unknown when
+// a network interface is attached explicitly and the instance doesn't declare them, since the
+// interface resource assigns its addresses then. The state records the applied addresses, so it
+// needs no normalization.
+func normalizeSecondaryPrivateIPs(attrs map[string]interface{}) {
+	if _, declared := attrs[model.AttributeSecondaryPrivateIPs]; declared {
+		return
+	}
+	if _, ok := attrs["network_interface"]; ok {
+		attrs[model.AttributeSecondaryPrivateIPs] = model.UnknownValue{Reason: "assigned by the attached network interface"}
+	}
+}
@@ -0,0 +1,17 @@
+package terraform
+
+import "github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+
+// normalizePublicIP marks associate_public_ip_address of a configured instance unknown when
+// the configuration leaves it to something else: the network interface when one is attached
+// explicitly, or the subnet's map_public_ip_on_launch when it isn't set. The state records the
+// applied value, so it needs no normalization.
+func normalizePublicIP(attrs map[string]interface{}) {
+	if _, ok := attrs["network_interface"]; ok {
+		attrs[model.AttributeAssociatePublicIPAddress] = model.UnknownValue{Reason: "set by the attached network interface"}
+		return
+	}
+	if _, ok := attrs[model.AttributeAssociatePublicIPAddress]; !ok {
+		attrs[model.AttributeAssociatePublicIPAddress] = model.UnknownValue{Reason: "defaults to the subnet's map_public_ip_on_launch"}
+	}
+}
@@ -0,0 +1,136 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/getsops/sops/v3/decrypt"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// sopsAgeKeyFileEnv is the variable the SOPS age key source reads the key file path from
+const sopsAgeKeyFileEnv = "SOPS_AGE_KEY_FILE"
+
+// sopsSuffixes are the file suffixes that mark a state file as SOPS-encrypted
+var sopsSuffixes = []string{".enc", ".sops"}
+
+// sopsEnvMu serialises decryptions, since a configured age key file is handed to the
+// SOPS library through the environment
+var sopsEnvMu sync.Mutex
+
+// isSOPSEncrypted reports whether a state file is SOPS-encrypted, either by its
+// suffix or by the presence of the SOPS metadata block
+func isSOPSEncrypted(filePath string, data []byte) bool {
+	ext := filepath.Ext(filePath)
+	for _, suffix := range sopsSuffixes {
+		if ext == suffix {
+			return true
+		}
+	}
+
+	keys, ok := topLevelKeys(data)
+	return ok && keys["sops"]
+}
+
+// topLevelKeys returns the keys of a JSON object without decoding their values, so
+// large states are only scanned once. It reports false when data is not an object.
+func topLevelKeys(data []byte) (map[string]bool, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+
+	keys := make(map[string]bool)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, false
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		keys[key] = true
+	}
+	return keys, true
+}
+
+// sopsFormat returns the SOPS store the file was encrypted with. State that was
+// encrypted as an opaque file is stored as a "data" blob next to the metadata.
+func sopsFormat(data []byte) string {
+	keys, ok := topLevelKeys(data)
+	if !ok {
+		return "binary"
+	}
+	for key := range keys {
+		if key != "sops" && key != "data" {
+			return "json"
+		}
+	}
+	return "binary"
+}
+
+// sopsKeySource describes where SOPS will find the age key, for error messages
+func sopsKeySource(ageKeyFile string) string {
+	switch {
+	case ageKeyFile != "":
+		return fmt.Sprintf("terraform.sops_age_key_file (%s)", ageKeyFile)
+	case os.Getenv("SOPS_AGE_KEY") != "":
+		return "SOPS_AGE_KEY environment variable"
+	case os.Getenv(sopsAgeKeyFileEnv) != "":
+		return fmt.Sprintf("SOPS_AGE_KEY_FILE environment variable (%s)", os.Getenv(sopsAgeKeyFileEnv))
+	default:
+		return "default SOPS age key location"
+	}
+}
+
+// decryptSOPS decrypts a SOPS-encrypted state file in memory with the SOPS library.
+// The plaintext is never written to disk.
+func decryptSOPS(filePath string, data []byte, ageKeyFile string) ([]byte, error) {
+	if StateScheme(filePath) != SchemeFile {
+		filePath = redactURI(filePath)
+	}
+
+	plaintext, err := decryptWithAgeKeyFile(data, sopsFormat(data), ageKeyFile)
+	if err != nil {
+		return nil, errors.NewOperationalError(
+			fmt.Sprintf("Failed to decrypt SOPS-encrypted state file %s using key from %s", filePath, sopsKeySource(ageKeyFile)),
+			err,
+		)
+	}
+	return plaintext, nil
+}
+
+// decryptWithAgeKeyFile runs the SOPS decryption with SOPS_AGE_KEY_FILE pointed at the
+// configured key file, restoring the previous value afterwards. Without a configured
+// file SOPS falls back to SOPS_AGE_KEY, SOPS_AGE_KEY_FILE and its default location.
+func decryptWithAgeKeyFile(data []byte, format string, ageKeyFile string) ([]byte, error) {
+	sopsEnvMu.Lock()
+	defer sopsEnvMu.Unlock()
+
+	if ageKeyFile == "" {
+		return decrypt.Data(data, format)
+	}
+
+	previous, set := os.LookupEnv(sopsAgeKeyFileEnv)
+	if err := os.Setenv(sopsAgeKeyFileEnv, ageKeyFile); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if set {
+			os.Setenv(sopsAgeKeyFileEnv, previous)
+		} else {
+			os.Unsetenv(sopsAgeKeyFileEnv)
+		}
+	}()
+
+	return decrypt.Data(data, format)
+}
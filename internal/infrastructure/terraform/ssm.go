@@ -0,0 +1,15 @@
+package terraform
+
+import (
+	"context"
+)
+
+// ssmResolvePrefix is the ami value EC2 resolves from an SSM parameter at launch
+const ssmResolvePrefix = "resolve:ssm:"
+
+// ParameterResolver looks up the value of an SSM parameter, e.g. the reader configuration
+// references are resolved with
+type ParameterResolver interface {
+	// GetParameter returns the value of the named parameter
+	GetParameter(ctx context.Context, name string) (string, error)
+}
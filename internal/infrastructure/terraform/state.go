@@ -11,7 +11,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"strings"
+	"sync"
 
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
@@ -20,46 +21,121 @@ import (
 
 // StateParser parses Terraform state files
 type StateParser struct {
-	logger *logging.Logger
+	logger         *logging.Logger
+	fetcher        StateFetcher
+	sopsAgeKeyFile string
+	useTagsAll     bool
+	includeTainted bool
+
+	// allowUnsupported parses states newer than the supported versions best-effort
+	allowUnsupported bool
+
+	// terraformVersion is the Terraform version that wrote the last state parsed
+	versionMu        sync.Mutex
+	terraformVersion string
+
+	// cache holds instances parsed from unchanged state files; nil disables caching
+	cache *stateCache
 }
 
-// NewStateParser creates a new Terraform state parser
+// NewStateParser creates a new Terraform state parser reading local state files
 func NewStateParser(logger *logging.Logger) *StateParser {
 	return &StateParser{
-		logger: logger.WithField("component", "terraform-state"),
+		logger:  logger.WithField("component", "terraform-state"),
+		fetcher: LocalStateFetcher{},
 	}
 }
 
-// ParseStateFile parses a Terraform state file
+// ParseStateFile parses a Terraform state file, read from a local path or a URI the parser's
+// fetcher supports
 func (p *StateParser) ParseStateFile(ctx context.Context, filePath string) (*model.TFState, error) {
-	p.logger.Info(fmt.Sprintf("Parsing Terraform state file: %s", filePath))
+	p.logger.Debug(fmt.Sprintf("Parsing Terraform state file: %s", redactURI(filePath)))
 
-	// Read the state file
-	stateData, err := os.ReadFile(filePath)
+	stateData, err := p.readStateFile(ctx, filePath)
 	if err != nil {
-		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to read Terraform state file: %s", filePath), err)
+		return nil, err
 	}
 
 	// Parse the state file
+	state, err := p.ParseState(stateData)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Debug(fmt.Sprintf("Successfully parsed Terraform state file with %d resources", len(state.Resources)))
+	return state, nil
+}
+
+// readStateFile fetches a state file, decrypting SOPS-encrypted copies in memory
+func (p *StateParser) readStateFile(ctx context.Context, filePath string) ([]byte, error) {
+	stateData, err := p.fetcher.Fetch(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSOPSEncrypted(filePath, stateData) {
+		p.logger.Debug(fmt.Sprintf("Decrypting SOPS-encrypted state file: %s", redactURI(filePath)))
+		return decryptSOPS(filePath, stateData, p.sopsAgeKeyFile)
+	}
+	return stateData, nil
+}
+
+// ParseState decodes Terraform state JSON, wherever it was read from
+func (p *StateParser) ParseState(data []byte) (*model.TFState, error) {
 	var state model.TFState
-	if err := json.Unmarshal(stateData, &state); err != nil {
+	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, errors.NewOperationalError("Failed to parse Terraform state JSON", err)
 	}
-
-	p.logger.Info(fmt.Sprintf("Successfully parsed Terraform state file with %d resources", len(state.Resources)))
+	if err := p.checkStateVersion(state.Version, state.TerraformVersion, unknownStateFields(data)); err != nil {
+		return nil, err
+	}
+	p.recordTerraformVersion(state.TerraformVersion)
 	return &state, nil
 }
 
-// GetEC2InstancesFromState extracts EC2 instances from a Terraform state
-func (p *StateParser) GetEC2InstancesFromState(state *model.TFState) ([]*model.Instance, error) {
-	p.logger.Info("Extracting EC2 instances from Terraform state")
+// SetFetcher sets how state files are read
+func (p *StateParser) SetFetcher(fetcher StateFetcher) {
+	p.fetcher = fetcher
+}
+
+// SetSOPSAgeKeyFile sets the age key file used to decrypt SOPS-encrypted state files
+func (p *StateParser) SetSOPSAgeKeyFile(keyFile string) {
+	p.sopsAgeKeyFile = keyFile
+}
+
+// SetUseTagsAll sets whether tags are read from tags_all, which AWS provider v4+ records with
+// the provider's default_tags merged in, so default tags aren't reported as drift
+func (p *StateParser) SetUseTagsAll(useTagsAll bool) {
+	p.useTagsAll = useTagsAll
+}
+
+// SetIncludeTainted sets whether tainted instances, which Terraform will replace on the next
+// apply, are compared
+func (p *StateParser) SetIncludeTainted(includeTainted bool) {
+	p.includeTainted = includeTainted
+}
+
+// GetEC2InstancesFromState extracts EC2 instances from a Terraform state, checking for
+// cancellation between resources
+func (p *StateParser) GetEC2InstancesFromState(ctx context.Context, state *model.TFState) ([]*model.Instance, error) {
+	p.logger.Debug("Extracting EC2 instances from Terraform state")
 
 	var instances []*model.Instance
+	eips := eipInstanceIDs(state)
+	desiredStates := desiredInstanceStates(state)
 
 	// Find all aws_instance resources
 	for _, resource := range state.Resources {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.NewOperationalError("Extracting EC2 instances from Terraform state cancelled", err)
+		}
+
 		if resource.Type == "aws_instance" {
 			for _, instance := range resource.Instances {
+				if p.skipInstance(resource, instance) {
+					continue
+				}
+
 				// Create a domain model instance from the Terraform instance
 				domainInstance, err := p.mapToInstance(resource, instance)
 				if err != nil {
@@ -67,25 +143,59 @@ func (p *StateParser) GetEC2InstancesFromState(state *model.TFState) ([]*model.I
 					continue
 				}
 
+				decorateInstance(domainInstance, eips, desiredStates)
+
 				instances = append(instances, domainInstance)
 			}
 		}
 	}
 
-	p.logger.Info(fmt.Sprintf("Found %d EC2 instances in Terraform state", len(instances)))
+	instances = p.dedupeInstances(instances)
+
+	p.logger.Info(fmt.Sprintf("Found %d EC2 instances in Terraform state with %d resources", len(instances), len(state.Resources)))
 	return instances, nil
 }
 
+// dedupeInstances keeps the first instance of each ID. Later resources tracking the same
+// instance are dropped with a warning and their addresses recorded on the instance kept, so
+// that the broken state is reported rather than one of the resources silently winning.
+func (p *StateParser) dedupeInstances(instances []*model.Instance) []*model.Instance {
+	kept := make(map[string]*model.Instance, len(instances))
+	deduped := instances[:0]
+	for _, instance := range instances {
+		first, ok := kept[instance.ID]
+		if !ok {
+			kept[instance.ID] = instance
+			deduped = append(deduped, instance)
+			continue
+		}
+		first.DuplicateAddresses = append(first.DuplicateAddresses, instance.ResourceAddress)
+	}
+
+	for _, instance := range deduped {
+		if len(instance.DuplicateAddresses) > 0 {
+			p.warnDuplicate(instance.ID, instance.ResourceAddress, instance.DuplicateAddresses)
+		}
+	}
+	return deduped
+}
+
+// warnDuplicate logs that several resources in the state track the same instance
+func (p *StateParser) warnDuplicate(id, kept string, duplicates []string) {
+	p.logger.Warn(fmt.Sprintf("Instance %s is tracked by several Terraform resources: %s, %s; only %s is compared. Remove the extra resources from the state, e.g. with terraform state rm",
+		id, kept, strings.Join(duplicates, ", "), kept))
+}
+
 // GetEC2InstanceByID gets an EC2 instance by ID from a Terraform state
 func (p *StateParser) GetEC2InstanceByID(state *model.TFState, instanceID string) (*model.Instance, error) {
-	p.logger.Info(fmt.Sprintf("Looking for EC2 instance %s in Terraform state", instanceID))
+	p.logger.Debug(fmt.Sprintf("Looking for EC2 instance %s in Terraform state", instanceID))
 
 	// Find the instance with the specified ID
 	for _, resource := range state.Resources {
 		if resource.Type == "aws_instance" {
 			for _, instance := range resource.Instances {
 				id, ok := instance.Attributes["id"].(string)
-				if !ok {
+				if !ok || p.skipInstance(resource, instance) {
 					continue
 				}
 
@@ -96,6 +206,8 @@ func (p *StateParser) GetEC2InstanceByID(state *model.TFState, instanceID string
 						return nil, errors.NewOperationalError(fmt.Sprintf("Failed to map Terraform instance %s", instanceID), err)
 					}
 
+					decorateInstance(domainInstance, eipInstanceIDs(state), desiredInstanceStates(state))
+
 					return domainInstance, nil
 				}
 			}
@@ -105,6 +217,137 @@ func (p *StateParser) GetEC2InstanceByID(state *model.TFState, instanceID string
 	return nil, errors.NewNotFoundError("EC2 Instance", instanceID)
 }
 
+// tfStatusTainted is the status Terraform records on instances it will replace
+const tfStatusTainted = "tainted"
+
+// skipInstance reports whether a resource instance is left out of the comparison. Deposed
+// objects are being replaced by create_before_destroy, tainted instances will be replaced on
+// the next apply unless included, and instances without attributes were never created.
+func (p *StateParser) skipInstance(resource model.TFResource, instance model.TFResourceInstance) bool {
+	switch {
+	case instance.Deposed != "":
+		p.logger.Debug(fmt.Sprintf("Skipping deposed object %s of Terraform instance %s", instance.Deposed, resource.Name))
+		return true
+	case instance.Status == tfStatusTainted && !p.includeTainted:
+		p.logger.Debug(fmt.Sprintf("Skipping tainted Terraform instance %s", resource.Name))
+		return true
+	case instance.Attributes == nil:
+		p.logger.Debug(fmt.Sprintf("Skipping Terraform instance %s with no attributes", resource.Name))
+		return true
+	}
+	return false
+}
+
+// eipInstanceIDs returns the IDs of instances an Elastic IP is associated with,
+// either directly on aws_eip or through aws_eip_association
+func eipInstanceIDs(state *model.TFState) map[string]bool {
+	result := make(map[string]bool)
+	for _, resource := range state.Resources {
+		collectEIPInstanceIDs(resource, result)
+	}
+	return result
+}
+
+// collectEIPInstanceIDs adds the instances an aws_eip or aws_eip_association resource is
+// associated with to result
+func collectEIPInstanceIDs(resource model.TFResource, result map[string]bool) {
+	var key string
+	switch resource.Type {
+	case "aws_eip":
+		key = "instance"
+	case "aws_eip_association":
+		key = "instance_id"
+	default:
+		return
+	}
+
+	for _, instance := range resource.Instances {
+		if id, ok := instance.Attributes[key].(string); ok && id != "" {
+			result[id] = true
+		}
+	}
+}
+
+// desiredInstanceStates returns the state aws_ec2_instance_state resources keep instances in,
+// by instance ID
+func desiredInstanceStates(state *model.TFState) map[string]string {
+	result := make(map[string]string)
+	for _, resource := range state.Resources {
+		collectDesiredInstanceStates(resource, result)
+	}
+	return result
+}
+
+// collectDesiredInstanceStates adds the state an aws_ec2_instance_state resource keeps its
+// instance in to result
+func collectDesiredInstanceStates(resource model.TFResource, result map[string]string) {
+	if resource.Type != "aws_ec2_instance_state" {
+		return
+	}
+
+	for _, instance := range resource.Instances {
+		id, _ := instance.Attributes["instance_id"].(string)
+		desired, _ := instance.Attributes["state"].(string)
+		if id != "" && desired != "" {
+			result[id] = desired
+		}
+	}
+}
+
+// decorateInstance applies what other resources in the state say about an instance: a static
+// public IP from an Elastic IP, and the desired state from aws_ec2_instance_state. The private
+// IP recorded in the state is static too, since it stays with the instance across stops and
+// starts.
+func decorateInstance(instance *model.Instance, eips map[string]bool, desiredStates map[string]string) {
+	if eips[instance.ID] {
+		instance.MarkStatic("public_ip")
+	}
+	if ip, ok := instance.Attributes["private_ip"].(string); ok && ip != "" {
+		instance.MarkStatic("private_ip")
+	}
+	applyDesiredInstanceState(instance, desiredStates)
+}
+
+// applyDesiredInstanceState sets instance_state to the state an aws_ec2_instance_state resource
+// declares for the instance. Otherwise the state recorded at the last refresh is expected.
+func applyDesiredInstanceState(instance *model.Instance, desiredStates map[string]string) {
+	if desired, ok := desiredStates[instance.ID]; ok {
+		instance.Attributes[model.AttributeInstanceState] = desired
+		return
+	}
+	if recorded, ok := instance.Attributes[model.AttributeInstanceState].(string); ok {
+		instance.Attributes[model.AttributeInstanceState] = model.NormalizeInstanceState(recorded)
+	}
+}
+
+// managedResourceTypes maps resource types checked for orphans to the attribute holding their AWS ID
+var managedResourceTypes = map[string]string{
+	model.ResourceTypeVolume:           "id",
+	model.ResourceTypeNetworkInterface: "id",
+	model.ResourceTypeElasticIP:        "id",
+}
+
+// GetManagedResourceIDs returns the AWS IDs of volumes, network interfaces and Elastic IPs
+// that are declared as resources of their own in the state
+func (p *StateParser) GetManagedResourceIDs(state *model.TFState) map[string]bool {
+	result := make(map[string]bool)
+
+	for _, resource := range state.Resources {
+		key, ok := managedResourceTypes[resource.Type]
+		if !ok || resource.Mode == "data" {
+			continue
+		}
+
+		for _, instance := range resource.Instances {
+			if id, ok := instance.Attributes[key].(string); ok && id != "" {
+				result[id] = true
+			}
+		}
+	}
+
+	return result
+}
+
 // mapToInstance maps a Terraform instance to a domain model instance
 func (p *StateParser) mapToInstance(resource model.TFResource, tfInstance model.TFResourceInstance) (*model.Instance, error) {
 	// Extract instance ID
@@ -129,9 +372,44 @@ func (p *StateParser) mapToInstance(resource model.TFResource, tfInstance model.
 	// Normalize attribute names (Terraform uses underscores, AWS might use camelCase)
 	normalizedAttrs := p.normalizeAttributes(attributes)
 
-	return model.NewInstance(id, normalizedAttrs, model.OriginTerraform), nil
+	normalizedAttrs[model.AttributeInstanceLifecycle] = instanceLifecycle(normalizedAttrs)
+	normalizePlacement(normalizedAttrs)
+	markPreventDestroyUnknown(normalizedAttrs)
+
+	// AWS reports every tag on the instance, including the provider's default_tags
+	tagsAll, hasTagsAll := normalizedAttrs[attributeTagsAll].(map[string]interface{})
+	if p.useTagsAll && hasTagsAll {
+		normalizedAttrs["tags"] = tagsAll
+	}
+
+	instance := model.NewInstance(id, normalizedAttrs, model.OriginTerraform)
+	instance.ResourceAddress = resourceAddress(resource, tfInstance)
+	if p.useTagsAll && hasTagsAll {
+		instance.SetAttributeSource("tags", attributeTagsAll)
+	}
+	return instance, nil
 }
 
+// resourceAddress returns the Terraform address of a resource instance, such as
+// module.app.aws_instance.web[0] or aws_instance.web["blue"]
+func resourceAddress(resource model.TFResource, tfInstance model.TFResourceInstance) string {
+	address := resource.Type + "." + resource.Name
+	if resource.Module != "" {
+		address = resource.Module + "." + address
+	}
+
+	switch key := tfInstance.IndexKey.(type) {
+	case float64:
+		address += fmt.Sprintf("[%d]", int(key))
+	case string:
+		address += fmt.Sprintf("[%q]", key)
+	}
+	return address
+}
+
+// attributeTagsAll is the Terraform attribute holding resource tags merged with default_tags
+const attributeTagsAll = "tags_all"
+
 // normalizeAttributes normalizes attribute names and values
 func (p *StateParser) normalizeAttributes(attrs map[string]interface{}) map[string]interface{} {
 	result := make(map[string]interface{})
@@ -158,10 +436,11 @@ func (p *StateParser) normalizeAttributes(attrs map[string]interface{}) map[stri
 		case "tags":
 			// Terraform stores tags as a map
 			result[key] = v
-		case "ebs_block_device":
-			// Process EBS block devices
+		case model.AttributeEBSBlockDevice, model.AttributeRootBlockDevice:
+			// Reduce block devices to the settings EC2 reports, so the root volume and
+			// additional volumes compare against the devices AWS attaches
 			if list, ok := v.([]interface{}); ok {
-				result[key] = p.processEBSBlockDevices(list)
+				result[key] = model.NormalizeBlockDevices(p.processEBSBlockDevices(list))
 			} else {
 				result[key] = v
 			}
@@ -195,6 +474,11 @@ func (p *StateParser) processEBSBlockDevices(devices []interface{}) []map[string
 
 // GetInstancesFromStateFile parses a Terraform state file and extracts EC2 instances
 func (p *StateParser) GetInstancesFromStateFile(ctx context.Context, filePath string) ([]*model.Instance, error) {
+	version := p.stateVersion(ctx, filePath)
+	if instances, ok := p.cachedInstances(filePath, version); ok {
+		return instances, nil
+	}
+
 	// Parse the state file
 	state, err := p.ParseStateFile(ctx, filePath)
 	if err != nil {
@@ -202,7 +486,24 @@ func (p *StateParser) GetInstancesFromStateFile(ctx context.Context, filePath st
 	}
 
 	// Extract EC2 instances
-	return p.GetEC2InstancesFromState(state)
+	instances, err := p.GetEC2InstancesFromState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cache.put(filePath, version, instances)
+	return instances, nil
+}
+
+// GetManagedResourceIDsFromStateFile parses a Terraform state file and returns the IDs of the
+// non-instance resources it manages
+func (p *StateParser) GetManagedResourceIDsFromStateFile(ctx context.Context, filePath string) (map[string]bool, error) {
+	state, err := p.ParseStateFile(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.GetManagedResourceIDs(state), nil
 }
 
 // GetInstanceByIDFromStateFile gets an EC2 instance by ID from a Terraform state file
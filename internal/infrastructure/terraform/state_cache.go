@@ -0,0 +1,110 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// stateCache keeps the instances parsed from each state file with the version of the file they
+// were parsed from, so scheduled runs can skip parsing state that hasn't changed
+type stateCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedState
+}
+
+// cachedState is the instances parsed from one version of a state file
+type cachedState struct {
+	version   string
+	instances []*model.Instance
+}
+
+func newStateCache() *stateCache {
+	return &stateCache{entries: make(map[string]cachedState)}
+}
+
+// get returns the instances cached for uri when they were parsed from version
+func (c *stateCache) get(uri, version string) ([]*model.Instance, bool) {
+	if c == nil || version == "" {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[uri]
+	if !ok || entry.version != version {
+		return nil, false
+	}
+	return append([]*model.Instance(nil), entry.instances...), true
+}
+
+// put caches the instances parsed from version of uri
+func (c *stateCache) put(uri, version string, instances []*model.Instance) {
+	if c == nil || version == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[uri] = cachedState{version: version, instances: append([]*model.Instance(nil), instances...)}
+}
+
+// clear drops every cached state file
+func (c *stateCache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cachedState)
+}
+
+// SetCacheEnabled sets whether instances parsed from state files are reused while the file is
+// unchanged. Only fetchers implementing StateVersioner are cached.
+func (p *StateParser) SetCacheEnabled(enabled bool) {
+	if !enabled {
+		p.cache = nil
+		return
+	}
+	if p.cache == nil {
+		p.cache = newStateCache()
+	}
+}
+
+// InvalidateCache drops the cached instances, so the next read parses the state again
+func (p *StateParser) InvalidateCache() {
+	p.cache.clear()
+}
+
+// stateVersion returns the version of the state at filePath, or "" when it isn't cached
+func (p *StateParser) stateVersion(ctx context.Context, filePath string) string {
+	if p.cache == nil {
+		return ""
+	}
+
+	versioner, ok := p.fetcher.(StateVersioner)
+	if !ok {
+		return ""
+	}
+
+	version, err := versioner.Version(ctx, filePath)
+	if err != nil {
+		// Reading the state reports the error properly
+		p.logger.Debug(fmt.Sprintf("Failed to check whether state file %s changed: %v", redactURI(filePath), err))
+		return ""
+	}
+	return version
+}
+
+// cachedInstances returns the instances parsed from the current version of filePath, if cached
+func (p *StateParser) cachedInstances(filePath, version string) ([]*model.Instance, bool) {
+	instances, ok := p.cache.get(filePath, version)
+	if ok {
+		p.logger.Info(fmt.Sprintf("State file %s is unchanged, using %d cached EC2 instances", redactURI(filePath), len(instances)))
+	}
+	return instances, ok
+}
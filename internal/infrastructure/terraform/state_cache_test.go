@@ -0,0 +1,119 @@
+package terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// countingFetcher counts the state downloads of the local fetcher it wraps
+type countingFetcher struct {
+	LocalStateFetcher
+	fetches int
+}
+
+func (f *countingFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	f.fetches++
+	return f.LocalStateFetcher.Fetch(ctx, uri)
+}
+
+// staticFetcher serves the same state for every location and can't tell whether it changed
+type staticFetcher string
+
+func (f staticFetcher) Fetch(ctx context.Context, uri string) ([]byte, error) {
+	return []byte(f), nil
+}
+
+func writeState(t *testing.T, path, instanceType string) {
+	t.Helper()
+	state := strings.Replace(testStateJSON, `"t3.micro"`, `"`+instanceType+`"`, 1)
+	require.NoError(t, os.WriteFile(path, []byte(state), 0600))
+}
+
+func instanceTypes(instances []*model.Instance) []string {
+	var types []string
+	for _, instance := range instances {
+		types = append(types, instance.InstanceType)
+	}
+	return types
+}
+
+func TestStateParser_CachesUnchangedStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	writeState(t, path, "t3.micro")
+
+	fetcher := &countingFetcher{}
+	parser := NewStateParser(logging.New())
+	parser.SetFetcher(fetcher)
+	parser.SetCacheEnabled(true)
+	ctx := context.Background()
+
+	first, err := parser.GetInstancesFromStateFile(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetcher.fetches)
+
+	// An unchanged file is served from the cache, for listing and streaming alike
+	second, err := parser.GetInstancesFromStateFile(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetcher.fetches)
+	assert.Equal(t, first, second)
+
+	var streamed []*model.Instance
+	require.NoError(t, parser.StreamInstancesFromStateFile(ctx, path, func(instance *model.Instance) error {
+		streamed = append(streamed, instance)
+		return nil
+	}))
+	assert.Equal(t, 1, fetcher.fetches)
+	assert.Equal(t, first, streamed)
+
+	// A changed file is parsed again
+	writeState(t, path, "t3.large")
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	third, err := parser.GetInstancesFromStateFile(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetcher.fetches)
+	assert.Contains(t, instanceTypes(third), "t3.large")
+
+	parser.InvalidateCache()
+	_, err = parser.GetInstancesFromStateFile(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, fetcher.fetches)
+
+	// Without the cache every read parses the file
+	parser.SetCacheEnabled(false)
+	_, err = parser.GetInstancesFromStateFile(ctx, path)
+	require.NoError(t, err)
+	_, err = parser.GetInstancesFromStateFile(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, 5, fetcher.fetches)
+}
+
+func TestStateParser_CacheSkipsFetchersWithoutVersions(t *testing.T) {
+	parser := NewStateParser(logging.New())
+	parser.SetFetcher(staticFetcher(testStateJSON))
+	parser.SetCacheEnabled(true)
+
+	_, err := parser.GetInstancesFromStateFile(context.Background(), "https://state.example.com/prod")
+	require.NoError(t, err)
+	assert.Empty(t, parser.cache.entries)
+}
+
+func TestS3StateFetcher_Version(t *testing.T) {
+	server := fakeS3(t)
+	fetcher := NewS3StateFetcher(testS3Config("AKIDTEST", "eu-west-1"), server.URL, false)
+
+	version, err := fetcher.Version(context.Background(), "s3://states/env/prod/terraform.tfstate")
+	require.NoError(t, err)
+	assert.Equal(t, `"prod-etag"`, version)
+
+	_, err = fetcher.Version(context.Background(), "s3://states/env/dev/terraform.tfstate")
+	assert.ErrorContains(t, err, "does not exist")
+}
@@ -0,0 +1,214 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// StateProvider reads instances from Terraform state: a state file, the state files of
+// several workspaces, or a Terraform Cloud workspace
+type StateProvider struct {
+	stateParser *StateParser
+	tfcParser   *TFCStateParser
+	logger      *logging.Logger
+	stateFile   string
+
+	// workspaces are the state locations of the workspaces read instead of stateFile, if any
+	workspaces []workspaceLocation
+
+	// hclDir is the configuration whose moved blocks apply to the state's addresses, if any
+	hclDir string
+}
+
+// NewStateProvider creates a provider reading the state file or Terraform Cloud workspace of cfg
+func NewStateProvider(cfg ClientConfig, logger *logging.Logger) (*StateProvider, error) {
+	logger = logger.WithField("component", "terraform-state")
+
+	// Validate configuration
+	if cfg.TFCWorkspace == "" {
+		if cfg.StateFile == "" {
+			return nil, errors.NewValidationError("State file must be specified when UseHCL is false")
+		}
+
+		// Check if a local file exists; remote state is checked when it is read
+		if StateScheme(cfg.StateFile) == SchemeFile && len(cfg.Workspaces) == 0 {
+			path := localStatePath(cfg.StateFile)
+			if _, err := os.Stat(path); err != nil {
+				return nil, errors.NewOperationalError(fmt.Sprintf("State file %s does not exist", path), err)
+			}
+		}
+	}
+
+	var workspaces []workspaceLocation
+	if len(cfg.Workspaces) > 0 {
+		if cfg.TFCWorkspace != "" {
+			return nil, errors.NewValidationError("Terraform workspaces can only be read from a state file backend, not from HCL or Terraform Cloud")
+		}
+
+		var err error
+		workspaces, err = workspaceLocations(cfg.StateFile, cfg.Workspaces, cfg.WorkspaceKeyPrefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, location := range workspaces {
+			if StateScheme(location.stateFile) != SchemeFile {
+				continue
+			}
+			path := localStatePath(location.stateFile)
+			if _, err := os.Stat(path); err != nil {
+				return nil, errors.NewOperationalError(fmt.Sprintf("State file %s of workspace %s does not exist", path, location.workspace), err)
+			}
+		}
+	}
+
+	stateParser := NewStateParser(logger)
+	stateParser.SetSOPSAgeKeyFile(cfg.SOPSAgeKeyFile)
+	stateParser.SetUseTagsAll(cfg.UseTagsAll)
+	stateParser.SetIncludeTainted(cfg.IncludeTainted)
+	stateParser.SetAllowUnsupportedState(cfg.AllowUnsupported)
+	stateParser.SetCacheEnabled(cfg.CacheState)
+
+	var tfcParser *TFCStateParser
+	if cfg.TFCWorkspace != "" {
+		if cfg.StateFile != "" {
+			logger.Warn(fmt.Sprintf("Both a state file and Terraform Cloud workspace %s are configured, reading state from Terraform Cloud", cfg.TFCWorkspace))
+		}
+		var err error
+		tfcParser, err = NewTFCStateParser(cfg.TFCAddress, cfg.TFCWorkspace, cfg.TFCToken, stateParser, logger)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		fetcher, err := newStateFetcher(cfg)
+		if err != nil {
+			return nil, err
+		}
+		stateParser.SetFetcher(fetcher)
+	}
+
+	return &StateProvider{
+		stateParser: stateParser,
+		tfcParser:   tfcParser,
+		logger:      logger,
+		stateFile:   cfg.StateFile,
+		workspaces:  workspaces,
+		hclDir:      cfg.HCLDir,
+	}, nil
+}
+
+// GetInstance retrieves instance configuration by ID
+func (p *StateProvider) GetInstance(ctx context.Context, instanceID string) (*model.Instance, error) {
+	p.logger.Info(fmt.Sprintf("Retrieving instance %s from Terraform", instanceID))
+
+	if p.tfcParser != nil {
+		return p.tfcParser.GetInstanceByID(ctx, instanceID)
+	}
+	if len(p.workspaces) > 0 {
+		return p.getWorkspaceInstance(ctx, instanceID)
+	}
+	return p.stateParser.GetInstanceByIDFromStateFile(ctx, p.stateFile, instanceID)
+}
+
+// ListInstances retrieves all available instances
+func (p *StateProvider) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	p.logger.Info("Listing instances from Terraform")
+
+	if p.tfcParser != nil {
+		return p.tfcParser.GetInstances(ctx)
+	}
+	if len(p.workspaces) > 0 {
+		return p.listWorkspaceInstances(ctx)
+	}
+	return p.stateParser.GetInstancesFromStateFile(ctx, p.stateFile)
+}
+
+// StreamInstances emits instances as they are read. State files are decoded resource by
+// resource; Terraform Cloud workspaces are read in full first.
+func (p *StateProvider) StreamInstances(ctx context.Context, emit func(*model.Instance) error) error {
+	if p.tfcParser != nil {
+		instances, err := p.ListInstances(ctx)
+		if err != nil {
+			return err
+		}
+		return emitAll(instances, emit)
+	}
+	if len(p.workspaces) > 0 {
+		return p.streamWorkspaceInstances(ctx, true, emit)
+	}
+
+	p.logger.Info("Streaming instances from Terraform state")
+	return p.stateParser.StreamInstancesFromStateFile(ctx, p.stateFile, emit)
+}
+
+// SetCacheEnabled sets whether instances parsed from unchanged state files are reused
+func (p *StateProvider) SetCacheEnabled(enabled bool) {
+	p.stateParser.SetCacheEnabled(enabled)
+}
+
+// InvalidateCache drops instances cached from unchanged state files, so the next run parses
+// the state again
+func (p *StateProvider) InvalidateCache() {
+	p.logger.Debug("Invalidating cached Terraform state")
+	p.stateParser.InvalidateCache()
+}
+
+// ListManagedResourceIDs returns the IDs of the volumes, network interfaces and Elastic IPs
+// managed by Terraform
+func (p *StateProvider) ListManagedResourceIDs(ctx context.Context) (map[string]bool, error) {
+	p.logger.Info("Listing managed resources from Terraform state")
+	if p.tfcParser != nil {
+		return p.tfcParser.GetManagedResourceIDs(ctx)
+	}
+	if len(p.workspaces) > 0 {
+		return p.listWorkspaceManagedResourceIDs(ctx)
+	}
+	return p.stateParser.GetManagedResourceIDsFromStateFile(ctx, p.stateFile)
+}
+
+// AddressMoves returns the moves declared by the moved blocks of the configuration in the
+// HCL directory. State only records current addresses, so without one nothing is known to
+// have moved.
+func (p *StateProvider) AddressMoves(ctx context.Context) (model.AddressMoves, error) {
+	if p.hclDir == "" {
+		return nil, nil
+	}
+	return NewHCLParser(p.logger).ParseMovedBlocks(ctx, p.hclDir)
+}
+
+// TerraformVersion returns the Terraform version that wrote the last state read
+func (p *StateProvider) TerraformVersion() string {
+	return p.stateParser.TerraformVersion()
+}
+
+// GetSourceType returns the source type for this provider
+func (p *StateProvider) GetSourceType() model.ResourceOrigin {
+	return model.OriginTerraform
+}
+
+// GetStateFile returns the state file path
+func (p *StateProvider) GetStateFile() string {
+	return p.stateFile
+}
+
+// GetTFCWorkspace returns the Terraform Cloud workspace state is read from, if any
+func (p *StateProvider) GetTFCWorkspace() string {
+	if p.tfcParser == nil {
+		return ""
+	}
+	return p.tfcParser.GetWorkspaceID()
+}
+
+// emitAll emits instances that were read in full
+func emitAll(instances []*model.Instance, emit func(*model.Instance) error) error {
+	for _, instance := range instances {
+		if err := emit(instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
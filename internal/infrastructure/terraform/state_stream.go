@@ -0,0 +1,259 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// StreamInstancesFromStateFile reads a Terraform state file and emits its EC2 instances as
+// resources are decoded, rather than after the whole state has been unmarshalled.
+//
+// Terraform writes resources sorted by module, so the instances of a module are emitted once
+// the next module starts, together with the Elastic IPs and aws_ec2_instance_state resources
+// of that module. Decorations from a later module can't be applied to instances already
+// emitted and are logged instead.
+func (p *StateParser) StreamInstancesFromStateFile(ctx context.Context, filePath string, emit func(*model.Instance) error) error {
+	version := p.stateVersion(ctx, filePath)
+	if instances, ok := p.cachedInstances(filePath, version); ok {
+		for _, instance := range instances {
+			if err := emit(instance); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	p.logger.Debug(fmt.Sprintf("Streaming Terraform state file: %s", redactURI(filePath)))
+
+	stateData, err := p.readStateFile(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	var instances []*model.Instance
+	err = p.StreamInstancesFromState(ctx, stateData, func(instance *model.Instance) error {
+		instances = append(instances, instance)
+		return emit(instance)
+	})
+	if err != nil {
+		return err
+	}
+
+	p.cache.put(filePath, version, instances)
+	return nil
+}
+
+// StreamInstancesFromState decodes Terraform state JSON resource by resource and emits its EC2
+// instances
+func (p *StateParser) StreamInstancesFromState(ctx context.Context, data []byte, emit func(*model.Instance) error) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	stream := &stateStream{
+		parser:        p,
+		emit:          emit,
+		eips:          make(map[string]bool),
+		desiredStates: make(map[string]string),
+		emitted:       make(map[string]bool),
+		addresses:     make(map[string]string),
+	}
+
+	// Terraform writes the version before the resources, so the state is gated before any
+	// instance is emitted; unknown fields after the resources are checked at the end
+	var version int
+	var terraformVersion string
+	var unknown []string
+	checked := -1 // the number of unknown fields when the state was last checked
+
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return errors.NewOperationalError("Failed to parse Terraform state JSON", err)
+		}
+
+		var value interface{} = &json.RawMessage{}
+		switch key {
+		case "resources":
+			if err := p.checkStateVersion(version, terraformVersion, unknown); err != nil {
+				return err
+			}
+			checked = len(unknown)
+			if err := stream.readResources(ctx, decoder); err != nil {
+				return err
+			}
+			continue
+		case "version":
+			value = &version
+		case "terraform_version":
+			value = &terraformVersion
+		default:
+			if field, ok := key.(string); ok && !knownStateFields[field] {
+				unknown = append(unknown, field)
+			}
+		}
+		if err := decoder.Decode(value); err != nil {
+			return errors.NewOperationalError("Failed to parse Terraform state JSON", err)
+		}
+	}
+	if checked != len(unknown) {
+		if err := p.checkStateVersion(version, terraformVersion, unknown); err != nil {
+			return err
+		}
+	}
+	p.recordTerraformVersion(terraformVersion)
+
+	if err := stream.flush(); err != nil {
+		return err
+	}
+	stream.warnLateDecorations()
+
+	p.logger.Info(fmt.Sprintf("Streamed %d EC2 instances from Terraform state with %d resources", len(stream.emitted), stream.resources))
+	return nil
+}
+
+// stateStream tracks the instances of the current module until they can be emitted
+type stateStream struct {
+	parser *StateParser
+	emit   func(*model.Instance) error
+
+	module    string
+	pending   []*model.Instance
+	resources int
+
+	eips          map[string]bool
+	desiredStates map[string]string
+	emitted       map[string]bool
+
+	// addresses maps emitted instance IDs to their resource address, to name duplicates
+	addresses map[string]string
+
+	// late lists decorations read after their instance was emitted
+	late []string
+}
+
+// readResources decodes the resources array one resource at a time
+func (s *stateStream) readResources(ctx context.Context, decoder *json.Decoder) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return errors.NewOperationalError("Failed to parse Terraform state JSON", err)
+	}
+	// A null resources list has no resources to read
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return errors.NewOperationalError("Failed to parse Terraform state JSON", fmt.Errorf("resources is not a list"))
+	}
+
+	for decoder.More() {
+		if err := ctx.Err(); err != nil {
+			return errors.NewOperationalError("Streaming EC2 instances from Terraform state cancelled", err)
+		}
+
+		var resource model.TFResource
+		if err := decoder.Decode(&resource); err != nil {
+			return errors.NewOperationalError("Failed to parse Terraform state JSON", err)
+		}
+		s.resources++
+
+		if resource.Module != s.module {
+			if err := s.flush(); err != nil {
+				return err
+			}
+			s.module = resource.Module
+		}
+
+		s.add(resource)
+	}
+
+	_, err = decoder.Token()
+	if err != nil {
+		return errors.NewOperationalError("Failed to parse Terraform state JSON", err)
+	}
+	return nil
+}
+
+// add records a decoded resource: instances are held until their module is complete
+func (s *stateStream) add(resource model.TFResource) {
+	eips := make(map[string]bool)
+	collectEIPInstanceIDs(resource, eips)
+	for id := range eips {
+		s.eips[id] = true
+		if s.emitted[id] {
+			s.late = append(s.late, fmt.Sprintf("Elastic IP of %s", id))
+		}
+	}
+
+	desiredStates := make(map[string]string)
+	collectDesiredInstanceStates(resource, desiredStates)
+	for id, state := range desiredStates {
+		s.desiredStates[id] = state
+		if s.emitted[id] {
+			s.late = append(s.late, fmt.Sprintf("aws_ec2_instance_state of %s", id))
+		}
+	}
+
+	if resource.Type != "aws_instance" {
+		return
+	}
+
+	for _, instance := range resource.Instances {
+		if s.parser.skipInstance(resource, instance) {
+			continue
+		}
+
+		domainInstance, err := s.parser.mapToInstance(resource, instance)
+		if err != nil {
+			s.parser.logger.Warn(fmt.Sprintf("Failed to map Terraform instance %s: %v", resource.Name, err))
+			continue
+		}
+		s.pending = append(s.pending, domainInstance)
+	}
+}
+
+// flush emits the instances of the current module. Duplicates of an instance in the same
+// module are recorded on it; an instance already emitted by an earlier module can no longer
+// be changed, so a later duplicate is only dropped with a warning.
+func (s *stateStream) flush() error {
+	for _, instance := range s.parser.dedupeInstances(s.pending) {
+		if s.emitted[instance.ID] {
+			s.parser.warnDuplicate(instance.ID, s.addresses[instance.ID], append([]string{instance.ResourceAddress}, instance.DuplicateAddresses...))
+			continue
+		}
+		decorateInstance(instance, s.eips, s.desiredStates)
+		s.emitted[instance.ID] = true
+		s.addresses[instance.ID] = instance.ResourceAddress
+		if err := s.emit(instance); err != nil {
+			return err
+		}
+	}
+	s.pending = nil
+	return nil
+}
+
+// warnLateDecorations logs Elastic IPs and desired states that were only read after the
+// instance they apply to had been emitted
+func (s *stateStream) warnLateDecorations() {
+	for _, decoration := range s.late {
+		s.parser.logger.Warn(fmt.Sprintf("Ignoring %s: it is declared in a later module than the instance", decoration))
+	}
+}
+
+// expectDelim reads the next JSON token and checks it is the given delimiter
+func expectDelim(decoder *json.Decoder, want json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return errors.NewOperationalError("Failed to parse Terraform state JSON", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return errors.NewOperationalError("Failed to parse Terraform state JSON", fmt.Errorf("expected %q, found %v", want, token))
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+func collectStream(t *testing.T, parser *StateParser, data []byte) ([]*model.Instance, error) {
+	t.Helper()
+	var instances []*model.Instance
+	err := parser.StreamInstancesFromState(context.Background(), data, func(instance *model.Instance) error {
+		instances = append(instances, instance)
+		return nil
+	})
+	return instances, err
+}
+
+func TestStateParser_StreamMatchesParse(t *testing.T) {
+	for _, file := range []string{"testdata/test.tfstate", "testdata/instance_state/terraform.tfstate", "testdata/lifecycle/terraform.tfstate", "testdata/default_tags/terraform.tfstate"} {
+		t.Run(file, func(t *testing.T) {
+			parser := NewStateParser(logging.New())
+
+			want, err := parser.GetInstancesFromStateFile(context.Background(), file)
+			require.NoError(t, err)
+
+			var got []*model.Instance
+			err = parser.StreamInstancesFromStateFile(context.Background(), file, func(instance *model.Instance) error {
+				got = append(got, instance)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestStateParser_StreamEmitsModulesAsTheyAreDecoded(t *testing.T) {
+	state := model.TFState{
+		Version: 4,
+		Resources: []model.TFResource{
+			{
+				Type:      "aws_instance",
+				Name:      "web",
+				Instances: []model.TFResourceInstance{{Attributes: map[string]interface{}{"id": "i-root", "public_ip": "52.1.1.1"}}},
+			},
+			{
+				Type:      "aws_eip",
+				Name:      "web",
+				Instances: []model.TFResourceInstance{{Attributes: map[string]interface{}{"instance": "i-root"}}},
+			},
+			{
+				Module:    "module.app",
+				Type:      "aws_instance",
+				Name:      "app",
+				Instances: []model.TFResourceInstance{{Attributes: map[string]interface{}{"id": "i-app"}}},
+			},
+		},
+	}
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+
+	parser := NewStateParser(logging.New())
+	instances, err := collectStream(t, parser, data)
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	assert.Equal(t, "i-root", instances[0].ID)
+	assert.True(t, instances[0].IsStatic("public_ip"))
+
+	// The root module is emitted once the next module starts, before the rest of the state is
+	// decoded: a malformed later resource only fails the stream after that
+	truncated := data[:bytes.Index(data, []byte(`],"outputs"`))]
+	instances, err = collectStream(t, parser, append(truncated, []byte(`,{"type":`)...))
+	assert.Error(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "i-root", instances[0].ID)
+
+	// An error from emit stops the stream
+	stop := fmt.Errorf("stop")
+	err = parser.StreamInstancesFromState(context.Background(), data, func(*model.Instance) error { return stop })
+	assert.ErrorIs(t, err, stop)
+}
+
+func TestStateParser_StreamWarnsAboutLateDecorations(t *testing.T) {
+	state := model.TFState{
+		Version: 4,
+		Resources: []model.TFResource{
+			{
+				Type:      "aws_instance",
+				Name:      "web",
+				Instances: []model.TFResourceInstance{{Attributes: map[string]interface{}{"id": "i-root"}}},
+			},
+			{
+				Module:    "module.eip",
+				Type:      "aws_eip",
+				Name:      "web",
+				Instances: []model.TFResourceInstance{{Attributes: map[string]interface{}{"instance": "i-root"}}},
+			},
+		},
+	}
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	parser := NewStateParser(logging.NewLogger(logging.LogConfig{Level: logging.Info, Output: &buf}))
+	instances, err := collectStream(t, parser, data)
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.False(t, instances[0].IsStatic("public_ip"))
+	assert.Contains(t, buf.String(), "Ignoring Elastic IP of i-root")
+}
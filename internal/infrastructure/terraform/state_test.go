@@ -1,13 +1,18 @@
 package terraform
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 )
@@ -165,7 +170,7 @@ func TestStateParser_GetEC2InstancesFromState(t *testing.T) {
 	parser := NewStateParser(logging.New())
 
 	// Test extracting EC2 instances
-	instances, err := parser.GetEC2InstancesFromState(sampleState)
+	instances, err := parser.GetEC2InstancesFromState(context.Background(), sampleState)
 	assert.NoError(t, err)
 	assert.Len(t, instances, 2) // Should find two aws_instance resources
 
@@ -215,7 +220,7 @@ func TestStateParser_GetEC2InstancesFromState(t *testing.T) {
 		},
 	}
 
-	instances, err = parser.GetEC2InstancesFromState(emptyState)
+	instances, err = parser.GetEC2InstancesFromState(context.Background(), emptyState)
 	assert.NoError(t, err)
 	assert.Len(t, instances, 0) // Should find no aws_instance resources
 }
@@ -367,6 +372,14 @@ func TestStateParser_NormalizeAttributes(t *testing.T) {
 				"volume_type": "gp2",
 			},
 		},
+		"root_block_device": []interface{}{
+			map[string]interface{}{
+				"device_name":           "/dev/xvda",
+				"volume_id":             "vol-root",
+				"delete_on_termination": true,
+				"volume_size":           float64(8),
+			},
+		},
 	}
 
 	// Normalize attributes
@@ -386,13 +399,9 @@ func TestStateParser_NormalizeAttributes(t *testing.T) {
 	// Check tags
 	assert.Equal(t, attrs["tags"], normalized["tags"])
 
-	// Check EBS block devices
-	ebsDevices, ok := normalized["ebs_block_device"].([]map[string]interface{})
-	assert.True(t, ok)
-	assert.Len(t, ebsDevices, 1)
-	assert.Equal(t, "/dev/sdf", ebsDevices[0]["device_name"])
-	assert.Equal(t, float64(10), ebsDevices[0]["volume_size"])
-	assert.Equal(t, "gp2", ebsDevices[0]["volume_type"])
+	// Check EBS block devices, reduced to the settings EC2 reports
+	assert.Equal(t, []interface{}{map[string]interface{}{"device_name": "/dev/sdf"}}, normalized["ebs_block_device"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"device_name": "/dev/xvda", "volume_id": "vol-root", "delete_on_termination": true}}, normalized["root_block_device"])
 }
 
 func TestStateParser_ProcessEBSBlockDevices(t *testing.T) {
@@ -563,3 +572,397 @@ func TestStateParser_GetInstanceByIDFromStateFile(t *testing.T) {
 	_, err = parser.GetInstanceByIDFromStateFile(context.Background(), "non-existent.tfstate", "i-12345")
 	assert.Error(t, err)
 }
+
+func TestStateParser_ParseStateFile_SOPS(t *testing.T) {
+	// Fixtures are encrypted with the test-only age key in testdata/sops/age.txt; the .enc
+	// copy was encrypted as an opaque file, the other one as JSON
+	for _, name := range []string{"terraform.tfstate", "terraform.tfstate.enc"} {
+		t.Run(name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			encrypted := filepath.Join(tempDir, name)
+			data, err := os.ReadFile(filepath.Join("testdata", "sops", name))
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(encrypted, data, 0644))
+
+			t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(tempDir, "missing.txt"))
+			parser := NewStateParser(logging.New())
+			parser.SetSOPSAgeKeyFile(filepath.Join("testdata", "sops", "age.txt"))
+
+			instances, err := parser.GetInstancesFromStateFile(context.Background(), encrypted)
+			require.NoError(t, err)
+			assert.Len(t, instances, 1)
+			assert.Equal(t, "i-sops", instances[0].ID)
+
+			// The configured key file is only set for the duration of the decryption
+			assert.Equal(t, filepath.Join(tempDir, "missing.txt"), os.Getenv("SOPS_AGE_KEY_FILE"))
+
+			// Plaintext is never written next to the encrypted copy
+			entries, err := os.ReadDir(tempDir)
+			assert.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestStateParser_ParseStateFile_SOPSFailure(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Detected by the metadata block rather than the suffix
+	encrypted := filepath.Join(tempDir, "terraform.tfstate")
+	data, err := os.ReadFile(filepath.Join("testdata", "sops", "terraform.tfstate"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(encrypted, data, 0644))
+
+	parser := NewStateParser(logging.New())
+	parser.SetSOPSAgeKeyFile("/keys/age.txt")
+
+	_, err = parser.ParseStateFile(context.Background(), encrypted)
+	assert.True(t, errors.IsOperationalError(err))
+	assert.ErrorContains(t, err, encrypted)
+	assert.ErrorContains(t, err, "terraform.sops_age_key_file (/keys/age.txt)")
+}
+
+func TestIsSOPSEncrypted(t *testing.T) {
+	assert.True(t, isSOPSEncrypted("state.tfstate.enc", nil))
+	assert.True(t, isSOPSEncrypted("state.tfstate.sops", nil))
+	assert.True(t, isSOPSEncrypted("state.tfstate", []byte(`{"resources":[{"sops":1}],"sops":{}}`)))
+	assert.False(t, isSOPSEncrypted("state.tfstate", []byte(`{"version":4,"resources":[{"sops":{}}]}`)))
+	assert.False(t, isSOPSEncrypted("state.tfstate", []byte(`not json`)))
+}
+
+func TestSOPSFormat(t *testing.T) {
+	assert.Equal(t, "binary", sopsFormat([]byte(`{"data":"ENC[...]","sops":{}}`)))
+	assert.Equal(t, "json", sopsFormat([]byte(`{"version":"ENC[...]","resources":[],"sops":{}}`)))
+}
+
+func TestStateParser_EIPMarksPublicIPStatic(t *testing.T) {
+	state := &model.TFState{
+		Resources: []model.TFResource{
+			{
+				Type: "aws_instance",
+				Name: "web",
+				Instances: []model.TFResourceInstance{
+					{Attributes: map[string]interface{}{"id": "i-eip", "public_ip": "52.1.1.1"}},
+					{Attributes: map[string]interface{}{"id": "i-assoc", "public_ip": "52.1.1.2"}},
+					{Attributes: map[string]interface{}{"id": "i-dynamic", "public_ip": "3.3.3.3"}},
+				},
+			},
+			{
+				Type:      "aws_eip",
+				Name:      "web",
+				Instances: []model.TFResourceInstance{{Attributes: map[string]interface{}{"instance": "i-eip"}}},
+			},
+			{
+				Type:      "aws_eip_association",
+				Name:      "web",
+				Instances: []model.TFResourceInstance{{Attributes: map[string]interface{}{"instance_id": "i-assoc"}}},
+			},
+		},
+	}
+
+	parser := NewStateParser(logging.New())
+	instances, err := parser.GetEC2InstancesFromState(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 3)
+
+	static := make(map[string]bool)
+	for _, instance := range instances {
+		static[instance.ID] = instance.IsStatic("public_ip")
+	}
+	assert.Equal(t, map[string]bool{"i-eip": true, "i-assoc": true, "i-dynamic": false}, static)
+
+	instance, err := parser.GetEC2InstanceByID(state, "i-assoc")
+	assert.NoError(t, err)
+	assert.True(t, instance.IsStatic("public_ip"))
+}
+
+func TestStateParser_PrivateIPIsStatic(t *testing.T) {
+	state := &model.TFState{
+		Resources: []model.TFResource{
+			{
+				Type: "aws_instance",
+				Name: "web",
+				Instances: []model.TFResourceInstance{
+					{Attributes: map[string]interface{}{"id": "i-recorded", "private_ip": "10.0.1.10"}},
+					{Attributes: map[string]interface{}{"id": "i-pending", "private_ip": ""}},
+				},
+			},
+		},
+	}
+
+	parser := NewStateParser(logging.New())
+	instances, err := parser.GetEC2InstancesFromState(context.Background(), state)
+	assert.NoError(t, err)
+
+	static := make(map[string]bool)
+	for _, instance := range instances {
+		static[instance.ID] = instance.IsStatic("private_ip")
+	}
+	assert.Equal(t, map[string]bool{"i-recorded": true, "i-pending": false}, static)
+
+	instance, err := parser.GetEC2InstanceByID(state, "i-recorded")
+	assert.NoError(t, err)
+	assert.True(t, instance.IsStatic("private_ip"))
+
+	// Compared under static_ips_only, unlike the dynamic public IP
+	aws := model.NewInstance("i-recorded", map[string]interface{}{"private_ip": "10.0.1.11", "public_ip": "3.3.3.3"}, model.OriginAWS)
+	assert.Equal(t, map[string]string{"public_ip": "public_ip is not bound to an Elastic IP"},
+		model.DynamicAddressAttributes(instance, aws, []string{"private_ip", "public_ip"}))
+}
+
+func TestStateParser_GetManagedResourceIDs(t *testing.T) {
+	state := &model.TFState{
+		Resources: []model.TFResource{
+			{Mode: "managed", Type: "aws_ebs_volume", Name: "data", Instances: []model.TFResourceInstance{
+				{Attributes: map[string]interface{}{"id": "vol-123"}},
+			}},
+			{Mode: "managed", Type: "aws_eip", Name: "web", Instances: []model.TFResourceInstance{
+				{Attributes: map[string]interface{}{"id": "eipalloc-123", "instance": "i-123"}},
+			}},
+			{Mode: "data", Type: "aws_network_interface", Name: "lookup", Instances: []model.TFResourceInstance{
+				{Attributes: map[string]interface{}{"id": "eni-external"}},
+			}},
+			{Mode: "managed", Type: "aws_instance", Name: "web", Instances: []model.TFResourceInstance{
+				{Attributes: map[string]interface{}{"id": "i-123"}},
+			}},
+		},
+	}
+
+	parser := NewStateParser(logging.New())
+	assert.Equal(t, map[string]bool{"vol-123": true, "eipalloc-123": true}, parser.GetManagedResourceIDs(state))
+}
+
+func TestStateParser_DefaultTagsInTagsAll(t *testing.T) {
+	stateFile := filepath.Join("testdata", "default_tags", "terraform.tfstate")
+	awsInstance := model.NewInstance("i-0a1b2c3d4e5f60718", map[string]interface{}{
+		"tags": map[string]interface{}{"Name": "web", "Environment": "production", "ManagedBy": "terraform"},
+	}, model.OriginAWS)
+
+	// Default tags only appear in tags_all, so comparing tags flags them as drift
+	parser := NewStateParser(logging.New())
+	instances, err := parser.GetInstancesFromStateFile(context.Background(), stateFile)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1)
+	assert.Empty(t, instances[0].AttributeSource("tags"))
+	assert.Contains(t, model.CompareAttributes(instances[0], awsInstance, []string{"tags"}), "tags")
+
+	parser.SetUseTagsAll(true)
+	instances, err = parser.GetInstancesFromStateFile(context.Background(), stateFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "tags_all", instances[0].AttributeSource("tags.Environment"))
+	assert.Empty(t, model.CompareAttributes(instances[0], awsInstance, []string{"tags"}))
+
+	// Drift on tags records that tags_all was compared
+	delete(awsInstance.Attributes["tags"].(map[string]interface{}), "ManagedBy")
+	drifts := model.CompareAttributes(instances[0], awsInstance, []string{"tags"})
+	assert.Equal(t, "tags_all", drifts["tags"].TerraformAttribute)
+}
+
+func TestStateParser_LogsSummary(t *testing.T) {
+	var buf bytes.Buffer
+	parser := NewStateParser(logging.NewLogger(logging.LogConfig{Level: logging.Info, Output: &buf}))
+
+	instances, err := parser.GetInstancesFromStateFile(context.Background(), "testdata/test.tfstate")
+	assert.NoError(t, err)
+
+	// Parsing progress is debug output; only the summary is logged at info
+	assert.Equal(t, 1, strings.Count(buf.String(), "[INFO]"), buf.String())
+	assert.Contains(t, buf.String(), fmt.Sprintf("Found %d EC2 instances", len(instances)))
+}
+
+func TestStateParser_GetEC2InstancesFromStateCancelled(t *testing.T) {
+	parser := NewStateParser(logging.New())
+
+	state, err := parser.ParseStateFile(context.Background(), "testdata/test.tfstate")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	instances, err := parser.GetEC2InstancesFromState(ctx, state)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, instances)
+}
+
+func TestStateParser_SkipsDeposedAndTaintedInstances(t *testing.T) {
+	var buf bytes.Buffer
+	parser := NewStateParser(logging.NewLogger(logging.LogConfig{Level: logging.Debug, Output: &buf}))
+
+	state, err := parser.ParseStateFile(context.Background(), filepath.Join("testdata", "deposed", "terraform.tfstate"))
+	assert.NoError(t, err)
+
+	// Only the current object of web is compared; skipped instances are not warnings
+	instances, err := parser.GetEC2InstancesFromState(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1)
+	assert.Equal(t, "i-0aaaaaaaaaaaaaaa1", instances[0].ID)
+	assert.NotContains(t, buf.String(), "[WARN]")
+	assert.Contains(t, buf.String(), "Skipping deposed object 4f1c2a9e of Terraform instance web")
+	assert.Contains(t, buf.String(), "Skipping tainted Terraform instance worker")
+	assert.Contains(t, buf.String(), "Skipping Terraform instance pending with no attributes")
+
+	_, err = parser.GetEC2InstanceByID(state, "i-0aaaaaaaaaaaaaaa0")
+	assert.True(t, errors.IsNotFoundError(err))
+
+	parser.SetIncludeTainted(true)
+	instances, err = parser.GetEC2InstancesFromState(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+	assert.Equal(t, "i-0bbbbbbbbbbbbbbb1", instances[1].ID)
+
+	instance, err := parser.GetEC2InstanceByID(state, "i-0bbbbbbbbbbbbbbb1")
+	assert.NoError(t, err)
+	assert.Equal(t, "t3.medium", instance.Attributes["instance_type"])
+}
+
+func TestStateParser_DuplicateInstanceIDs(t *testing.T) {
+	var buf bytes.Buffer
+	parser := NewStateParser(logging.NewLogger(logging.LogConfig{Level: logging.Info, Output: &buf}))
+
+	state, err := parser.ParseStateFile(context.Background(), filepath.Join("testdata", "duplicates", "terraform.tfstate"))
+	require.NoError(t, err)
+
+	// The first resource tracking an instance is kept and names the others
+	instances, err := parser.GetEC2InstancesFromState(context.Background(), state)
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	assert.Equal(t, "i-0aaaaaaaaaaaaaaa1", instances[0].ID)
+	assert.Equal(t, "aws_instance.web", instances[0].ResourceAddress)
+	assert.Equal(t, "t3.small", instances[0].Attributes["instance_type"])
+	assert.Equal(t, []string{"aws_instance.web_imported", "module.legacy.aws_instance.web"}, instances[0].DuplicateAddresses)
+	assert.Empty(t, instances[1].DuplicateAddresses)
+	assert.Contains(t, buf.String(), "Instance i-0aaaaaaaaaaaaaaa1 is tracked by several Terraform resources: aws_instance.web, aws_instance.web_imported, module.legacy.aws_instance.web; only aws_instance.web is compared")
+
+	// Streaming keeps the same instance; the duplicate in a later module is only warned about
+	buf.Reset()
+	data, err := os.ReadFile(filepath.Join("testdata", "duplicates", "terraform.tfstate"))
+	require.NoError(t, err)
+	streamed, err := collectStream(t, parser, data)
+	require.NoError(t, err)
+	require.Len(t, streamed, 2)
+	assert.Equal(t, "aws_instance.web", streamed[0].ResourceAddress)
+	assert.Equal(t, []string{"aws_instance.web_imported"}, streamed[0].DuplicateAddresses)
+	assert.Contains(t, buf.String(), "Instance i-0aaaaaaaaaaaaaaa1 is tracked by several Terraform resources: aws_instance.web, module.legacy.aws_instance.web")
+}
+
+func TestStateParser_InstanceState(t *testing.T) {
+	parser := NewStateParser(logging.New())
+
+	state, err := parser.ParseStateFile(context.Background(), filepath.Join("testdata", "instance_state", "terraform.tfstate"))
+	assert.NoError(t, err)
+
+	instances, err := parser.GetEC2InstancesFromState(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 3)
+
+	byID := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byID[instance.ID] = instance
+	}
+
+	// The state recorded at the last refresh is expected
+	assert.Equal(t, model.InstanceStateRunning, byID["i-0aaaaaaaaaaaaaaa1"].Attributes[model.AttributeInstanceState])
+
+	// aws_ec2_instance_state declares the desired state
+	assert.Equal(t, model.InstanceStateStopped, byID["i-0bbbbbbbbbbbbbbb1"].Attributes[model.AttributeInstanceState])
+
+	// Transitional states are normalized
+	assert.Equal(t, model.InstanceStateStopped, byID["i-0ccccccccccccccc1"].Attributes[model.AttributeInstanceState])
+
+	instance, err := parser.GetEC2InstanceByID(state, "i-0bbbbbbbbbbbbbbb1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.InstanceStateStopped, instance.Attributes[model.AttributeInstanceState])
+}
+
+func TestStateParser_InstanceLifecycle(t *testing.T) {
+	parser := NewStateParser(logging.New())
+
+	state, err := parser.ParseStateFile(context.Background(), filepath.Join("testdata", "lifecycle", "terraform.tfstate"))
+	assert.NoError(t, err)
+
+	instances, err := parser.GetEC2InstancesFromState(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 3)
+
+	byID := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byID[instance.ID] = instance
+	}
+
+	assert.Equal(t, model.InstanceLifecycleSpot, byID["i-0aaaaaaaaaaaaaaa1"].Attributes[model.AttributeInstanceLifecycle])
+	assert.Equal(t, "sir-abcd1234", byID["i-0aaaaaaaaaaaaaaa1"].Attributes[model.AttributeSpotInstanceRequestID])
+	assert.Equal(t, model.InstanceLifecycleOnDemand, byID["i-0bbbbbbbbbbbbbbb1"].Attributes[model.AttributeInstanceLifecycle])
+
+	// Older states without instance_lifecycle fall back to the market options
+	assert.Equal(t, model.InstanceLifecycleSpot, byID["i-0ccccccccccccccc1"].Attributes[model.AttributeInstanceLifecycle])
+}
+
+func TestStateParser_Placement(t *testing.T) {
+	parser := NewStateParser(logging.New())
+
+	state, err := parser.ParseStateFile(context.Background(), filepath.Join("testdata", "placement", "terraform.tfstate"))
+	assert.NoError(t, err)
+
+	instances, err := parser.GetEC2InstancesFromState(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+
+	byID := make(map[string]*model.Instance)
+	for _, instance := range instances {
+		byID[instance.ID] = instance
+	}
+
+	licensed := byID["i-0aaaaaaaaaaaaaaa1"].Attributes
+	assert.Equal(t, model.TenancyHost, licensed[model.AttributeTenancy])
+	assert.Equal(t, "h-0123456789abcdef0", licensed[model.AttributeHostID])
+	assert.Equal(t, model.AffinityDefault, licensed[model.AttributeAffinity])
+
+	// An empty host_id means the instance isn't on a dedicated host
+	shared := byID["i-0bbbbbbbbbbbbbbb1"].Attributes
+	assert.Equal(t, model.TenancyDefault, shared[model.AttributeTenancy])
+	assert.NotContains(t, shared, model.AttributeHostID)
+	assert.NotContains(t, shared, model.AttributeAffinity)
+}
+
+func TestStateParser_TerminationProtection(t *testing.T) {
+	parser := NewStateParser(logging.New())
+
+	state, err := parser.ParseState([]byte(`{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "instances": [
+        {"attributes": {"id": "i-0aaaaaaaaaaaaaaa1", "disable_api_termination": true}}
+      ]
+    }
+  ]
+}`))
+	require.NoError(t, err)
+
+	instances, err := parser.GetEC2InstancesFromState(context.Background(), state)
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+
+	// The applied disable_api_termination is recorded, but lifecycle meta-arguments aren't
+	attrs := instances[0].Attributes
+	assert.Equal(t, true, attrs[model.AttributeDisableAPITermination])
+	assert.Equal(t, model.UnknownValue{Reason: "lifecycle prevent_destroy is not recorded in Terraform state"}, attrs[model.AttributePreventDestroy])
+}
+
+func TestResourceAddress(t *testing.T) {
+	resource := model.TFResource{Type: "aws_instance", Name: "web"}
+	assert.Equal(t, "aws_instance.web", resourceAddress(resource, model.TFResourceInstance{}))
+	assert.Equal(t, "aws_instance.web[2]", resourceAddress(resource, model.TFResourceInstance{IndexKey: float64(2)}))
+
+	resource.Module = "module.app[0]"
+	assert.Equal(t, `module.app[0].aws_instance.web["blue"]`, resourceAddress(resource, model.TFResourceInstance{IndexKey: "blue"}))
+
+	// Instances read from state carry their address
+	parser := NewStateParser(logging.New())
+	instance, err := parser.mapToInstance(resource, model.TFResourceInstance{IndexKey: "blue", Attributes: map[string]interface{}{"id": "i-1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `module.app[0].aws_instance.web["blue"]`, instance.ResourceAddress)
+}
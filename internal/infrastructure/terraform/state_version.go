@@ -0,0 +1,111 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// Range of state format versions the parser reads. Version 4 is written by Terraform 0.12 and
+// later; older versions nest resources under modules and aren't read at all.
+const (
+	MinSupportedStateVersion = 4
+	MaxSupportedStateVersion = 4
+)
+
+// knownStateFields are the top-level fields of a version 4 state
+var knownStateFields = map[string]bool{
+	"version":           true,
+	"terraform_version": true,
+	"serial":            true,
+	"lineage":           true,
+	"outputs":           true,
+	"resources":         true,
+	"check_results":     true,
+}
+
+// SetAllowUnsupportedState sets whether states newer than the parser supports, or with
+// top-level fields it doesn't know, are parsed best-effort with a warning instead of failing
+func (p *StateParser) SetAllowUnsupportedState(allow bool) {
+	p.allowUnsupported = allow
+}
+
+// TerraformVersion returns the Terraform version that wrote the last state parsed, or "" when
+// none was parsed or the state doesn't record it
+func (p *StateParser) TerraformVersion() string {
+	p.versionMu.Lock()
+	defer p.versionMu.Unlock()
+	return p.terraformVersion
+}
+
+// recordTerraformVersion remembers the Terraform version of a parsed state
+func (p *StateParser) recordTerraformVersion(version string) {
+	p.versionMu.Lock()
+	defer p.versionMu.Unlock()
+	p.terraformVersion = version
+}
+
+// checkStateVersion gates a state on its format version and top-level fields. Older versions
+// always fail; newer versions and unknown fields fail unless unsupported states are allowed,
+// in which case they are parsed best-effort with a warning.
+func (p *StateParser) checkStateVersion(version int, terraformVersion string, unknownFields []string) error {
+	writtenBy := ""
+	if terraformVersion != "" {
+		writtenBy = fmt.Sprintf(" (written by Terraform %s)", terraformVersion)
+	}
+	supported := supportedStateVersions()
+
+	if version < MinSupportedStateVersion {
+		return errors.NewValidationError(fmt.Sprintf("Terraform state format version %d%s is not supported; supported versions: %s. Upgrade the state with Terraform 0.12 or later", version, writtenBy, supported)).
+			WithContext("state_version", version).
+			WithContext("terraform_version", terraformVersion)
+	}
+
+	var problems []string
+	if version > MaxSupportedStateVersion {
+		problems = append(problems, fmt.Sprintf("format version %d%s is newer than the supported versions (%s)", version, writtenBy, supported))
+	}
+	if len(unknownFields) > 0 {
+		problems = append(problems, fmt.Sprintf("has unknown top-level fields %s%s", strings.Join(unknownFields, ", "), writtenBy))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	message := "Terraform state " + strings.Join(problems, " and ")
+	if p.allowUnsupported {
+		p.logger.Warn(fmt.Sprintf("%s; parsing it best-effort because terraform.allow_unsupported_state is set, results may be incomplete", message))
+		return nil
+	}
+	return errors.NewValidationError(message+"; set terraform.allow_unsupported_state: true to parse it best-effort").
+		WithContext("state_version", version).
+		WithContext("terraform_version", terraformVersion)
+}
+
+// unknownStateFields returns the top-level fields of state JSON that a version 4 state doesn't have
+func unknownStateFields(data []byte) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	var unknown []string
+	for field := range fields {
+		if !knownStateFields[field] {
+			unknown = append(unknown, field)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// supportedStateVersions describes the supported range of state format versions
+func supportedStateVersions() string {
+	if MinSupportedStateVersion == MaxSupportedStateVersion {
+		return fmt.Sprint(MinSupportedStateVersion)
+	}
+	return fmt.Sprintf("%d to %d", MinSupportedStateVersion, MaxSupportedStateVersion)
+}
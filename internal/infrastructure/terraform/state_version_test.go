@@ -0,0 +1,114 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+)
+
+func TestStateParser_StateVersionGating(t *testing.T) {
+	const resources = `"resources":[{"mode":"managed","type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-1","instance_type":"t3.micro"}}]}]`
+
+	tests := []struct {
+		name      string
+		state     string
+		supported bool // parsed without allow_unsupported_state
+		allowed   bool // parsed with allow_unsupported_state
+		message   string
+	}{
+		{
+			name:      "supported",
+			state:     `{"version":4,"terraform_version":"1.9.5","serial":3,"lineage":"abc","outputs":{},` + resources + `,"check_results":null}`,
+			supported: true,
+			allowed:   true,
+		},
+		{
+			name:    "newer version",
+			state:   `{"version":5,"terraform_version":"2.0.0",` + resources + `}`,
+			allowed: true,
+			message: "format version 5 (written by Terraform 2.0.0) is newer than the supported versions (4)",
+		},
+		{
+			name:    "unknown top-level field",
+			state:   `{"version":4,"terraform_version":"1.20.0","ephemeral":{},` + resources + `}`,
+			allowed: true,
+			message: "unknown top-level fields ephemeral",
+		},
+		{
+			name:    "unknown field after resources",
+			state:   `{"version":4,"terraform_version":"1.20.0",` + resources + `,"stacks":[]}`,
+			allowed: true,
+			message: "unknown top-level fields stacks",
+		},
+		{
+			name:    "older version",
+			state:   `{"version":3,"terraform_version":"0.11.14","modules":[]}`,
+			message: "format version 3 (written by Terraform 0.11.14) is not supported; supported versions: 4",
+		},
+		{
+			name:    "missing version",
+			state:   `{` + resources + `}`,
+			message: "format version 0 is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, allow := range []bool{false, true} {
+			want := tt.supported || allow && tt.allowed
+			parse := func(t *testing.T, parser *StateParser) error {
+				_, err := parser.ParseState([]byte(tt.state))
+				return err
+			}
+			stream := func(t *testing.T, parser *StateParser) error {
+				_, err := collectStream(t, parser, []byte(tt.state))
+				return err
+			}
+
+			for mode, run := range map[string]func(*testing.T, *StateParser) error{"parse": parse, "stream": stream} {
+				t.Run(tt.name+"/"+mode, func(t *testing.T) {
+					var buf bytes.Buffer
+					parser := NewStateParser(logging.NewLogger(logging.LogConfig{Level: logging.Info, Output: &buf}))
+					parser.SetAllowUnsupportedState(allow)
+
+					err := run(t, parser)
+					if want {
+						require.NoError(t, err)
+						if !tt.supported {
+							assert.Contains(t, buf.String(), "best-effort")
+							assert.Contains(t, buf.String(), tt.message)
+						}
+						return
+					}
+
+					require.Error(t, err)
+					assert.True(t, errors.IsValidationError(err))
+					assert.Contains(t, err.Error(), tt.message)
+					if tt.allowed {
+						assert.Contains(t, err.Error(), "terraform.allow_unsupported_state")
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestStateParser_TerraformVersion(t *testing.T) {
+	parser := NewStateParser(logging.New())
+	assert.Empty(t, parser.TerraformVersion())
+
+	_, err := parser.ParseStateFile(context.Background(), "testdata/test.tfstate")
+	require.NoError(t, err)
+	assert.Equal(t, "1.4.6", parser.TerraformVersion())
+
+	_, err = collectStream(t, parser, []byte(`{"version":4,"terraform_version":"1.9.5","resources":[]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "1.9.5", parser.TerraformVersion())
+
+	provider := &StateProvider{stateParser: parser}
+	assert.Equal(t, "1.9.5", provider.TerraformVersion())
+}
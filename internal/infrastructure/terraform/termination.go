@@ -0,0 +1,38 @@
+package terraform
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// blockLifecycle is the meta-argument block that holds prevent_destroy
+const blockLifecycle = "lifecycle"
+
+// preventDestroy returns the prevent_destroy of a lifecycle block. Terraform requires a literal,
+// so anything else is reported as unknown rather than guessed; without the argument destroy
+// isn't prevented.
+func preventDestroy(block *hcl.Block) interface{} {
+	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: model.AttributePreventDestroy}},
+	})
+	if diags.HasErrors() {
+		return model.UnknownValue{Reason: "lifecycle block could not be read"}
+	}
+
+	attr, ok := content.Attributes[model.AttributePreventDestroy]
+	if !ok {
+		return false
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.Bool {
+		return model.UnknownValue{Reason: "prevent_destroy is not a literal boolean"}
+	}
+	return value.True()
+}
+
+// markPreventDestroyUnknown records that state can't tell whether a resource prevents destroy,
+// since lifecycle meta-arguments only exist in the configuration
+func markPreventDestroyUnknown(attrs map[string]interface{}) {
+	attrs[model.AttributePreventDestroy] = model.UnknownValue{Reason: "lifecycle prevent_destroy is not recorded in Terraform state"}
+}
@@ -0,0 +1,8 @@
+resource "aws_eip" "elastic" {
+  domain = "vpc"
+}
+
+resource "aws_eip_association" "elastic" {
+  instance_id   = aws_instance.elastic.id
+  allocation_id = aws_eip.elastic.id
+}
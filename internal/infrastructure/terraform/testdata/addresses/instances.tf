@@ -0,0 +1,15 @@
+resource "aws_instance" "fixed" {
+  ami           = "ami-12345"
+  instance_type = "t3.micro"
+  private_ip    = "10.0.1.10"
+}
+
+resource "aws_instance" "elastic" {
+  ami           = "ami-12345"
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "dynamic" {
+  ami           = "ami-12345"
+  instance_type = "t3.micro"
+}
@@ -0,0 +1,9 @@
+resource "aws_instance" "web" {
+  ami           = "ami-12345"
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "batch" {
+  ami           = "ami-12345"
+  instance_type = "c6i.large"
+}
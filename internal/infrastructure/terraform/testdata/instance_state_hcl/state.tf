@@ -0,0 +1,4 @@
+resource "aws_ec2_instance_state" "batch" {
+  instance_id = aws_instance.batch.id
+  state       = "stopped"
+}
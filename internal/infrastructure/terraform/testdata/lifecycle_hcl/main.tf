@@ -0,0 +1,27 @@
+resource "aws_instance" "spot" {
+  ami           = "ami-12345"
+  instance_type = "c6i.large"
+
+  instance_market_options {
+    market_type = "spot"
+
+    spot_options {
+      max_price          = "0.05"
+      spot_instance_type = "one-time"
+    }
+  }
+}
+
+resource "aws_instance" "on_demand" {
+  ami           = "ami-12345"
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "variable" {
+  ami           = "ami-12345"
+  instance_type = "t3.micro"
+
+  instance_market_options {
+    market_type = var.market_type
+  }
+}
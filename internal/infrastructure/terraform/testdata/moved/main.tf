@@ -0,0 +1,20 @@
+module "web" {
+  source = "./modules/web"
+}
+
+# aws_instance.web was refactored into the web module
+moved {
+  from = aws_instance.web
+  to   = module.web.aws_instance.this
+}
+
+# aws_instance.legacy was renamed
+moved {
+  from = aws_instance.legacy
+  to   = aws_instance.app
+}
+
+resource "aws_instance" "app" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.small"
+}
@@ -0,0 +1,17 @@
+resource "aws_instance" "pinned" {
+  ami           = "ami-12345"
+  instance_type = "m5.large"
+  tenancy       = "host"
+  host_id       = "h-0123456789abcdef0"
+}
+
+resource "aws_instance" "auto_placed" {
+  ami           = "ami-12345"
+  instance_type = "m5.large"
+  tenancy       = "host"
+}
+
+resource "aws_instance" "shared" {
+  ami           = "ami-12345"
+  instance_type = "t3.micro"
+}
@@ -0,0 +1,26 @@
+resource "aws_instance" "public" {
+  ami                         = "ami-0123456789abcdef0"
+  instance_type               = "t3.micro"
+  associate_public_ip_address = true
+}
+
+resource "aws_instance" "private" {
+  ami                         = "ami-0123456789abcdef0"
+  instance_type               = "t3.micro"
+  associate_public_ip_address = false
+}
+
+resource "aws_instance" "subnet_default" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "attached" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.micro"
+
+  network_interface {
+    network_interface_id = "eni-0123456789abcdef0"
+    device_index         = 0
+  }
+}
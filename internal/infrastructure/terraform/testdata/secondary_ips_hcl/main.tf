@@ -0,0 +1,21 @@
+resource "aws_instance" "multi_ip" {
+  ami                   = "ami-0123456789abcdef0"
+  instance_type         = "t3.micro"
+  private_ip            = "10.0.1.10"
+  secondary_private_ips = ["10.0.1.12", "10.0.1.11"]
+}
+
+resource "aws_instance" "single_ip" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "attached" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.micro"
+
+  network_interface {
+    network_interface_id = "eni-0123456789abcdef0"
+    device_index         = 0
+  }
+}
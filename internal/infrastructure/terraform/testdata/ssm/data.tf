@@ -0,0 +1,16 @@
+variable "ami_parameter" {
+  type    = string
+  default = "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64"
+}
+
+data "aws_ssm_parameter" "al2023" {
+  name = "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64"
+}
+
+data "aws_ssm_parameter" "golden" {
+  name = "/images/golden"
+}
+
+data "aws_ssm_parameter" "dynamic" {
+  name = var.ami_parameter
+}
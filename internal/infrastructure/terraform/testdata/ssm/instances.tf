@@ -0,0 +1,29 @@
+resource "aws_instance" "data_source" {
+  ami           = data.aws_ssm_parameter.al2023.value
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "nonsensitive" {
+  ami           = nonsensitive(data.aws_ssm_parameter.al2023.value)
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "resolve" {
+  ami           = "resolve:ssm:/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64"
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "missing" {
+  ami           = data.aws_ssm_parameter.golden.value
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "dynamic_name" {
+  ami           = data.aws_ssm_parameter.dynamic.value
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "literal" {
+  ami           = "ami-0c55b159cbfafe1f0"
+  instance_type = "t3.micro"
+}
@@ -0,0 +1,33 @@
+resource "aws_instance" "protected" {
+  ami                     = "ami-0123456789abcdef0"
+  instance_type           = "t3.micro"
+  disable_api_termination = true
+
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+
+resource "aws_instance" "terraform_only" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.micro"
+
+  lifecycle {
+    prevent_destroy       = true
+    create_before_destroy = true
+  }
+}
+
+resource "aws_instance" "unprotected" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.micro"
+
+  lifecycle {
+    ignore_changes = [tags]
+  }
+}
+
+resource "aws_instance" "no_lifecycle" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.micro"
+}
@@ -0,0 +1,28 @@
+resource "aws_security_group" "app" {
+  count = 2
+  name  = "app-${count.index}"
+}
+
+resource "aws_instance" "dynamic_volumes" {
+  ami           = "ami-0c55b159cbfafe1f0"
+  instance_type = "t2.micro"
+
+  dynamic "ebs_block_device" {
+    for_each = var.volumes
+    content {
+      device_name = ebs_block_device.value.device_name
+      volume_size = ebs_block_device.value.size
+    }
+  }
+}
+
+resource "aws_instance" "splat_sgs" {
+  ami                    = "ami-0c55b159cbfafe1f0"
+  instance_type          = "t3.micro"
+  vpc_security_group_ids = aws_security_group.app[*].id
+
+  root_block_device {
+    volume_size = var.root_size
+    volume_type = "gp3"
+  }
+}
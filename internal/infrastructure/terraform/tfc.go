@@ -0,0 +1,170 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// DefaultTFCAddress is the Terraform Cloud API address used when none is configured
+const DefaultTFCAddress = "https://app.terraform.io"
+
+// DefaultTFCTimeout bounds each request to the Terraform Cloud API
+const DefaultTFCTimeout = 30 * time.Second
+
+// TFCStateParser reads the current state version of a Terraform Cloud (or Enterprise)
+// workspace through the TFC API and decodes it like a local state file
+type TFCStateParser struct {
+	address     string
+	workspaceID string
+	token       string
+	client      *http.Client
+	stateParser *StateParser
+	logger      *logging.Logger
+}
+
+// tfcStateVersion is the part of the current-state-version API response the parser needs
+type tfcStateVersion struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Serial      int    `json:"serial"`
+			DownloadURL string `json:"hosted-state-download-url"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// NewTFCStateParser creates a parser for the workspace. An empty address uses DefaultTFCAddress.
+// The state is decoded and mapped to instances by stateParser.
+func NewTFCStateParser(address, workspaceID, token string, stateParser *StateParser, logger *logging.Logger) (*TFCStateParser, error) {
+	if workspaceID == "" {
+		return nil, errors.NewValidationError("Terraform Cloud workspace ID cannot be empty")
+	}
+	if token == "" {
+		return nil, errors.NewValidationError(fmt.Sprintf("A Terraform Cloud token is required to read workspace %s", workspaceID))
+	}
+
+	if address == "" {
+		address = DefaultTFCAddress
+	}
+	parsed, err := url.Parse(address)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid Terraform Cloud address %q", address))
+	}
+
+	return &TFCStateParser{
+		address:     strings.TrimRight(address, "/"),
+		workspaceID: workspaceID,
+		token:       token,
+		client:      &http.Client{Timeout: DefaultTFCTimeout},
+		stateParser: stateParser,
+		logger:      logger.WithField("component", "terraform-cloud"),
+	}, nil
+}
+
+// FetchState downloads and decodes the current state version of the workspace
+func (p *TFCStateParser) FetchState(ctx context.Context) (*model.TFState, error) {
+	p.logger.Debug(fmt.Sprintf("Fetching current state version of Terraform Cloud workspace %s", p.workspaceID))
+
+	endpoint := fmt.Sprintf("%s/api/v2/workspaces/%s/current-state-version", p.address, url.PathEscape(p.workspaceID))
+	body, err := p.get(ctx, endpoint, "application/vnd.api+json")
+	if err != nil {
+		return nil, err
+	}
+
+	var version tfcStateVersion
+	if err := json.Unmarshal(body, &version); err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to parse Terraform Cloud state version of workspace %s", p.workspaceID), err)
+	}
+	if version.Data.Attributes.DownloadURL == "" {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Terraform Cloud state version of workspace %s has no download URL", p.workspaceID), nil)
+	}
+
+	stateData, err := p.get(ctx, version.Data.Attributes.DownloadURL, "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := p.stateParser.ParseState(stateData)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Debug(fmt.Sprintf("Fetched state version %s (serial %d) with %d resources", version.Data.ID, version.Data.Attributes.Serial, len(state.Resources)))
+	return state, nil
+}
+
+// GetInstances fetches the workspace state and extracts its EC2 instances
+func (p *TFCStateParser) GetInstances(ctx context.Context) ([]*model.Instance, error) {
+	state, err := p.FetchState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.stateParser.GetEC2InstancesFromState(ctx, state)
+}
+
+// GetInstanceByID fetches the workspace state and returns the EC2 instance with the ID
+func (p *TFCStateParser) GetInstanceByID(ctx context.Context, instanceID string) (*model.Instance, error) {
+	state, err := p.FetchState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.stateParser.GetEC2InstanceByID(state, instanceID)
+}
+
+// GetManagedResourceIDs fetches the workspace state and returns the IDs of the non-instance
+// resources it manages
+func (p *TFCStateParser) GetManagedResourceIDs(ctx context.Context) (map[string]bool, error) {
+	state, err := p.FetchState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.stateParser.GetManagedResourceIDs(state), nil
+}
+
+// GetWorkspaceID returns the workspace the state is read from
+func (p *TFCStateParser) GetWorkspaceID() string {
+	return p.workspaceID
+}
+
+// get performs an authenticated GET and returns the response body. The token is never
+// included in errors.
+func (p *TFCStateParser) get(ctx context.Context, endpoint, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errors.NewOperationalError("Failed to build Terraform Cloud request", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Accept", accept)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to reach Terraform Cloud for workspace %s", p.workspaceID), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, errors.NewOperationalError(fmt.Sprintf("Terraform Cloud rejected the token for workspace %s (HTTP %d); check terraform.tfc_token", p.workspaceID, resp.StatusCode), nil)
+	case resp.StatusCode == http.StatusNotFound:
+		// TFC answers 404 rather than 403 for workspaces the token can't see
+		return nil, errors.NewOperationalError(fmt.Sprintf("Terraform Cloud workspace %s has no state or is not readable with this token (HTTP 404)", p.workspaceID), nil)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, errors.NewOperationalError(fmt.Sprintf("Terraform Cloud request for workspace %s failed with HTTP %d", p.workspaceID, resp.StatusCode), nil)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.NewOperationalError(fmt.Sprintf("Failed to read Terraform Cloud response for workspace %s", p.workspaceID), err)
+	}
+	return body, nil
+}
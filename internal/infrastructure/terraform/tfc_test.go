@@ -0,0 +1,155 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+)
+
+const testTFCToken = "tfc-test-token"
+
+// fakeTFC serves the current state version of ws-123 and its state download, rejecting
+// requests without the test token
+func fakeTFC(t *testing.T, state []byte) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testTFCToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errors":[{"status":"401","title":"unauthorized"}]}`)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v2/workspaces/ws-123/current-state-version":
+			assert.Equal(t, "application/vnd.api+json", r.Header.Get("Accept"))
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			fmt.Fprintf(w, `{"data":{"id":"sv-1","type":"state-versions","attributes":{"serial":7,"hosted-state-download-url":"%s/archivist/sv-1"}}}`, server.URL)
+		case "/archivist/sv-1":
+			w.Write(state)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testState(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/test.tfstate")
+	require.NoError(t, err)
+	return data
+}
+
+func newTestTFCParser(t *testing.T, address, workspace, token string) *TFCStateParser {
+	t.Helper()
+	logger := logging.New()
+	parser, err := NewTFCStateParser(address, workspace, token, NewStateParser(logger), logger)
+	require.NoError(t, err)
+	return parser
+}
+
+func TestTFCStateParser_FetchState(t *testing.T) {
+	server := fakeTFC(t, testState(t))
+	parser := newTestTFCParser(t, server.URL+"/", "ws-123", testTFCToken)
+
+	state, err := parser.FetchState(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, state.Resources)
+
+	instances, err := parser.GetInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "i-1234567890abcdef0", instances[0].ID)
+
+	instance, err := parser.GetInstanceByID(context.Background(), "i-1234567890abcdef0")
+	require.NoError(t, err)
+	assert.Equal(t, "i-1234567890abcdef0", instance.ID)
+}
+
+func TestTFCStateParser_AuthFailure(t *testing.T) {
+	server := fakeTFC(t, testState(t))
+	parser := newTestTFCParser(t, server.URL, "ws-123", "wrong-token")
+
+	_, err := parser.FetchState(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.IsOperationalError(err))
+	assert.Contains(t, err.Error(), "rejected the token")
+	assert.NotContains(t, err.Error(), "wrong-token")
+}
+
+func TestTFCStateParser_UnknownWorkspace(t *testing.T) {
+	server := fakeTFC(t, testState(t))
+	parser := newTestTFCParser(t, server.URL, "ws-missing", testTFCToken)
+
+	_, err := parser.FetchState(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.IsOperationalError(err))
+	assert.Contains(t, err.Error(), "ws-missing")
+}
+
+func TestTFCStateParser_InvalidState(t *testing.T) {
+	server := fakeTFC(t, []byte("not json"))
+	parser := newTestTFCParser(t, server.URL, "ws-123", testTFCToken)
+
+	_, err := parser.FetchState(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.IsOperationalError(err))
+}
+
+func TestTFCStateParser_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	parser := newTestTFCParser(t, server.URL, "ws-123", testTFCToken)
+
+	_, err := parser.FetchState(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.IsOperationalError(err))
+	assert.Contains(t, err.Error(), "503")
+}
+
+func TestNewTFCStateParser_Validation(t *testing.T) {
+	logger := logging.New()
+	stateParser := NewStateParser(logger)
+
+	_, err := NewTFCStateParser("", "", testTFCToken, stateParser, logger)
+	assert.True(t, errors.IsValidationError(err))
+
+	_, err = NewTFCStateParser("", "ws-123", "", stateParser, logger)
+	assert.True(t, errors.IsValidationError(err))
+
+	_, err = NewTFCStateParser("app.terraform.io", "ws-123", testTFCToken, stateParser, logger)
+	assert.True(t, errors.IsValidationError(err))
+
+	parser, err := NewTFCStateParser("", "ws-123", testTFCToken, stateParser, logger)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTFCAddress, parser.address)
+}
+
+func TestNewStateProvider_TFCWorkspace(t *testing.T) {
+	server := fakeTFC(t, testState(t))
+
+	provider, err := NewStateProvider(ClientConfig{
+		TFCWorkspace: "ws-123",
+		TFCToken:     testTFCToken,
+		TFCAddress:   server.URL,
+	}, logging.New())
+	require.NoError(t, err)
+	assert.Equal(t, "ws-123", provider.GetTFCWorkspace())
+
+	instances, err := provider.ListInstances(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, instances, 1)
+}
@@ -0,0 +1,177 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+const (
+	// DefaultWorkspace is the workspace Terraform uses when none is selected. Its state is the
+	// configured state file itself.
+	DefaultWorkspace = "default"
+
+	// DefaultWorkspaceKeyPrefix is the S3 backend's default workspace_key_prefix
+	DefaultWorkspaceKeyPrefix = "env:"
+)
+
+// WorkspaceStateLocation returns where the backend of stateFile, the default workspace's state,
+// keeps the state of another workspace:
+//   - s3://bucket/key: s3://bucket/<keyPrefix>/<workspace>/key
+//   - gs://bucket/prefix/default.tfstate: gs://bucket/prefix/<workspace>.tfstate
+//   - dir/terraform.tfstate: dir/terraform.tfstate.d/<workspace>/terraform.tfstate
+//
+// HTTP backends don't support workspaces.
+func WorkspaceStateLocation(stateFile, workspace, keyPrefix string) (string, error) {
+	if workspace == "" || workspace == DefaultWorkspace {
+		return stateFile, nil
+	}
+	if strings.Contains(workspace, "/") {
+		return "", errors.NewValidationError(fmt.Sprintf("Invalid Terraform workspace name %q", workspace))
+	}
+
+	switch scheme := StateScheme(stateFile); scheme {
+	case SchemeFile:
+		statePath := localStatePath(stateFile)
+		location := filepath.Join(filepath.Dir(statePath), "terraform.tfstate.d", workspace, filepath.Base(statePath))
+		if strings.HasPrefix(stateFile, "file://") {
+			location = "file://" + location
+		}
+		return location, nil
+	case SchemeS3:
+		bucket, key, err := parseBucketURI(stateFile, SchemeS3)
+		if err != nil {
+			return "", err
+		}
+		if keyPrefix == "" {
+			keyPrefix = DefaultWorkspaceKeyPrefix
+		}
+		return fmt.Sprintf("s3://%s/%s", bucket, path.Join(keyPrefix, workspace, key)), nil
+	case SchemeGCS:
+		bucket, key, err := parseBucketURI(stateFile, SchemeGCS)
+		if err != nil {
+			return "", err
+		}
+		if path.Base(key) != DefaultWorkspace+".tfstate" {
+			return "", errors.NewValidationError(fmt.Sprintf("GCS state location %s must name the default workspace's state (<prefix>/default.tfstate) to read other workspaces", stateFile))
+		}
+		return fmt.Sprintf("gs://%s/%s", bucket, path.Join(path.Dir(key), workspace+".tfstate")), nil
+	default:
+		return "", errors.NewValidationError(fmt.Sprintf("Terraform workspaces are not supported for %s state locations", scheme))
+	}
+}
+
+// workspaceLocation is the state location of one workspace
+type workspaceLocation struct {
+	workspace string
+	stateFile string
+}
+
+// workspaceLocations resolves the state location of each workspace
+func workspaceLocations(stateFile string, workspaces []string, keyPrefix string) ([]workspaceLocation, error) {
+	locations := make([]workspaceLocation, 0, len(workspaces))
+	seen := make(map[string]bool)
+	for _, workspace := range workspaces {
+		if seen[workspace] {
+			continue
+		}
+		seen[workspace] = true
+
+		location, err := WorkspaceStateLocation(stateFile, workspace, keyPrefix)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, workspaceLocation{workspace: workspace, stateFile: location})
+	}
+	return locations, nil
+}
+
+// listWorkspaceInstances reads the instances of every workspace, annotated with their workspace
+func (p *StateProvider) listWorkspaceInstances(ctx context.Context) ([]*model.Instance, error) {
+	var instances []*model.Instance
+	err := p.streamWorkspaceInstances(ctx, false, func(instance *model.Instance) error {
+		instances = append(instances, instance)
+		return nil
+	})
+	return instances, err
+}
+
+// streamWorkspaceInstances emits the instances of every workspace in turn, annotated with
+// their workspace. State files are decoded resource by resource when stream is set.
+func (p *StateProvider) streamWorkspaceInstances(ctx context.Context, stream bool, emit func(*model.Instance) error) error {
+	for _, location := range p.workspaces {
+		annotate := func(instance *model.Instance) error {
+			instance.Workspace = location.workspace
+			return emit(instance)
+		}
+
+		p.logger.Info(fmt.Sprintf("Reading instances of Terraform workspace %s", location.workspace))
+		if stream {
+			if err := p.stateParser.StreamInstancesFromStateFile(ctx, location.stateFile, annotate); err != nil {
+				return errors.NewOperationalError(fmt.Sprintf("Failed to read Terraform workspace %s", location.workspace), err)
+			}
+			continue
+		}
+
+		instances, err := p.stateParser.GetInstancesFromStateFile(ctx, location.stateFile)
+		if err != nil {
+			return errors.NewOperationalError(fmt.Sprintf("Failed to read Terraform workspace %s", location.workspace), err)
+		}
+		for _, instance := range instances {
+			if err := annotate(instance); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getWorkspaceInstance looks an instance up in each workspace in turn
+func (p *StateProvider) getWorkspaceInstance(ctx context.Context, instanceID string) (*model.Instance, error) {
+	for _, location := range p.workspaces {
+		instance, err := p.stateParser.GetInstanceByIDFromStateFile(ctx, location.stateFile, instanceID)
+		if errors.IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.NewOperationalError(fmt.Sprintf("Failed to read Terraform workspace %s", location.workspace), err)
+		}
+		instance.Workspace = location.workspace
+		return instance, nil
+	}
+	return nil, errors.NewNotFoundError("EC2 Instance", instanceID)
+}
+
+// listWorkspaceManagedResourceIDs returns the resources managed in any of the workspaces
+func (p *StateProvider) listWorkspaceManagedResourceIDs(ctx context.Context) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	for _, location := range p.workspaces {
+		managed, err := p.stateParser.GetManagedResourceIDsFromStateFile(ctx, location.stateFile)
+		if err != nil {
+			return nil, errors.NewOperationalError(fmt.Sprintf("Failed to read Terraform workspace %s", location.workspace), err)
+		}
+		for id := range managed {
+			ids[id] = true
+		}
+	}
+	return ids, nil
+}
+
+// Workspaces returns the Terraform workspaces instances are read from, or nil when only the
+// configured state file is read
+func (p *StateProvider) Workspaces() []string {
+	if len(p.workspaces) == 0 {
+		return nil
+	}
+
+	workspaces := make([]string, 0, len(p.workspaces))
+	for _, location := range p.workspaces {
+		workspaces = append(workspaces, location.workspace)
+	}
+	return workspaces
+}
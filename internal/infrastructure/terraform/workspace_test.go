@@ -0,0 +1,109 @@
+package terraform_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/terraform"
+)
+
+func TestWorkspaceStateLocation(t *testing.T) {
+	tests := []struct {
+		name      string
+		stateFile string
+		workspace string
+		keyPrefix string
+		want      string
+		wantErr   string
+	}{
+		{name: "default workspace", stateFile: "s3://states/app/terraform.tfstate", workspace: "default", want: "s3://states/app/terraform.tfstate"},
+		{name: "s3", stateFile: "s3://states/app/terraform.tfstate", workspace: "prod", want: "s3://states/env:/prod/app/terraform.tfstate"},
+		{name: "s3 key prefix", stateFile: "s3://states/app/terraform.tfstate", workspace: "prod", keyPrefix: "workspaces", want: "s3://states/workspaces/prod/app/terraform.tfstate"},
+		{name: "gcs", stateFile: "gs://states/app/default.tfstate", workspace: "prod", want: "gs://states/app/prod.tfstate"},
+		{name: "gcs without default state", stateFile: "gs://states/app/terraform.tfstate", workspace: "prod", wantErr: "default.tfstate"},
+		{name: "local", stateFile: "infra/terraform.tfstate", workspace: "dev", want: filepath.Join("infra", "terraform.tfstate.d", "dev", "terraform.tfstate")},
+		{name: "file uri", stateFile: "file:///infra/terraform.tfstate", workspace: "dev", want: "file:///infra/terraform.tfstate.d/dev/terraform.tfstate"},
+		{name: "http", stateFile: "https://state.example.com/app", workspace: "dev", wantErr: "not supported for https"},
+		{name: "invalid name", stateFile: "s3://states/app/terraform.tfstate", workspace: "a/b", wantErr: "Invalid Terraform workspace name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := terraform.WorkspaceStateLocation(tt.stateFile, tt.workspace, tt.keyPrefix)
+			if tt.wantErr != "" {
+				assert.True(t, errors.IsValidationError(err))
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func writeWorkspaceState(t *testing.T, dir, workspace, instanceID string) {
+	t.Helper()
+	data, err := os.ReadFile("testdata/test.tfstate")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "terraform.tfstate.d", workspace, "terraform.tfstate")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(strings.ReplaceAll(string(data), "i-1234567890abcdef0", instanceID)), 0600))
+}
+
+func TestStateProvider_Workspaces(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkspaceState(t, dir, "dev", "i-dev")
+	writeWorkspaceState(t, dir, "prod", "i-prod")
+
+	// The default workspace's state doesn't need to exist
+	provider, err := terraform.NewStateProvider(terraform.ClientConfig{
+		StateFile:  filepath.Join(dir, "terraform.tfstate"),
+		Workspaces: []string{"dev", "prod"},
+	}, logging.New())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod"}, provider.Workspaces())
+
+	instances, err := provider.ListInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	assert.Equal(t, "i-dev", instances[0].ID)
+	assert.Equal(t, "dev", instances[0].Workspace)
+	assert.Equal(t, "i-prod", instances[1].ID)
+	assert.Equal(t, "prod", instances[1].Workspace)
+
+	var streamed []*model.Instance
+	require.NoError(t, provider.StreamInstances(context.Background(), func(instance *model.Instance) error {
+		streamed = append(streamed, instance)
+		return nil
+	}))
+	assert.Equal(t, instances, streamed)
+
+	instance, err := provider.GetInstance(context.Background(), "i-prod")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", instance.Workspace)
+
+	_, err = provider.GetInstance(context.Background(), "i-missing")
+	assert.True(t, errors.IsNotFoundError(err))
+
+	// Every workspace's state must exist
+	_, err = terraform.NewStateProvider(terraform.ClientConfig{
+		StateFile:  filepath.Join(dir, "terraform.tfstate"),
+		Workspaces: []string{"dev", "stage"},
+	}, logging.New())
+	assert.ErrorContains(t, err, "of workspace stage does not exist")
+
+	_, err = terraform.NewHCLProvider(terraform.ClientConfig{
+		HCLDir:     "testdata",
+		Workspaces: []string{"dev"},
+	}, logging.New())
+	assert.True(t, errors.IsValidationError(err))
+}
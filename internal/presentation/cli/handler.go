@@ -2,20 +2,27 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/schedule"
 	"github.com/victor-devv/ec2-drift-detector/internal/config"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"github.com/victor-devv/ec2-drift-detector/internal/factory"
+	"github.com/victor-devv/ec2-drift-detector/internal/presentation/health"
 	"github.com/victor-devv/ec2-drift-detector/internal/presentation/reporter"
 )
 
@@ -28,12 +35,70 @@ type Handler struct {
 	errorHandler *errors.ErrorHandler
 	rootCmd      *cobra.Command
 	ctx          context.Context
+	options      HandlerOptions
+}
+
+// HandlerOptions controls how the root command is presented, so the CLI can be
+// embedded under a different binary name or as a subcommand of a larger tool
+type HandlerOptions struct {
+	// Name is the root command name
+	Name string
+
+	// Short is the one-line description shown in help output
+	Short string
+
+	// Long is the full description shown in help output
+	Long string
+
+	// Version is reported by the server status endpoint
+	Version string
+
+	// ErrorHandler reports command failures; shared with the caller so --error-format also
+	// applies to errors returned from Execute (nil creates one)
+	ErrorHandler *errors.ErrorHandler
+
+	// AWSProviderFactory rebuilds the AWS provider when flags such as --aws-profile change the
+	// AWS client configuration after startup (nil keeps the provider created at startup)
+	AWSProviderFactory func(ctx context.Context, cfg *config.Config) (service.InstanceProvider, error)
+}
+
+// DefaultHandlerOptions returns the options for the standalone drift-detector binary
+func DefaultHandlerOptions() HandlerOptions {
+	return HandlerOptions{
+		Name:    "drift-detector",
+		Short:   "Terraform drift detector",
+		Long:    "A tool to detect drift between AWS EC2 instances and Terraform configurations",
+		Version: "dev",
+	}
 }
 
 // NewHandler creates a new CLI handler
 func NewHandler(ctx context.Context, application service.DriftDetectorProvider, configLoader *config.ConfigLoader, cfg *config.Config, logger *logging.Logger) *Handler {
+	return NewHandlerWithOptions(ctx, application, configLoader, cfg, logger, DefaultHandlerOptions())
+}
+
+// NewHandlerWithOptions creates a new CLI handler with a custom root command name and descriptions.
+// Empty options fall back to the defaults.
+func NewHandlerWithOptions(ctx context.Context, application service.DriftDetectorProvider, configLoader *config.ConfigLoader, cfg *config.Config, logger *logging.Logger, options HandlerOptions) *Handler {
+	defaults := DefaultHandlerOptions()
+	if options.Name == "" {
+		options.Name = defaults.Name
+	}
+	if options.Short == "" {
+		options.Short = defaults.Short
+	}
+	if options.Long == "" {
+		options.Long = defaults.Long
+	}
+	if options.Version == "" {
+		options.Version = defaults.Version
+	}
+
 	logger = logger.WithField("component", "cli-handler")
-	errorHandler := errors.NewErrorHandler(logger)
+	errorHandler := options.ErrorHandler
+	if errorHandler == nil {
+		errorHandler = errors.NewErrorHandler(logger)
+	}
 
 	h := &Handler{
 		app:          application,
@@ -42,6 +107,7 @@ func NewHandler(ctx context.Context, application service.DriftDetectorProvider,
 		config:       cfg,
 		errorHandler: errorHandler,
 		ctx:          ctx,
+		options:      options,
 	}
 
 	h.initCommands()
@@ -51,16 +117,25 @@ func NewHandler(ctx context.Context, application service.DriftDetectorProvider,
 // initCommands initializes CLI commands
 func (h *Handler) initCommands() {
 	rootCmd := &cobra.Command{
-		Use:   "drift-detector",
-		Short: "Terraform drift detector",
-		Long:  "A tool to detect drift between AWS EC2 instances and Terraform configurations",
+		Use:   h.options.Name,
+		Short: h.options.Short,
+		Long:  h.options.Long,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// Apply the error format first so config errors below are reported in it
+			if value, _ := cmd.Flags().GetString("error-format"); value != "" {
+				format, err := errors.ParseErrorFormat(value)
+				if err != nil {
+					h.errorHandler.HandleWithExit(err)
+				}
+				h.errorHandler.SetFormat(format)
+			}
+
 			// Update configuration from CLI flags
 			cliOpts := make(map[string]interface{})
 
 			// Get flags from all commands
 			cmd.Flags().Visit(func(f *pflag.Flag) {
-				cliOpts[f.Name] = f.Value.String()
+				cliOpts[f.Name] = flagValue(cmd.Flags(), f)
 			})
 
 			// Update configuration
@@ -72,30 +147,91 @@ func (h *Handler) initCommands() {
 				h.errorHandler.HandleWithExit(err)
 			}
 
+			// The AWS client is created before flags are parsed, so rebuild it for a new profile
+			// or source
+			if rebuildAWSProvider(cliOpts) && h.options.AWSProviderFactory != nil {
+				provider, err := h.options.AWSProviderFactory(h.ctx, h.config)
+				if err != nil {
+					h.errorHandler.HandleWithExit(err)
+				}
+				h.app.SetAWSProvider(provider)
+			}
+
 			// Update service configuration
-			h.updateServiceConfig()
+			if err := h.updateServiceConfig(); err != nil {
+				h.errorHandler.HandleWithExit(err)
+			}
 		},
 	}
 
 	// Add global flags
 	rootCmd.PersistentFlags().String("log-level", "INFO", "Log level (DEBUG, INFO, WARN, ERROR)")
-	rootCmd.PersistentFlags().StringP("state-file", "s", "", "Terraform state file path")
+	rootCmd.PersistentFlags().StringP("state-file", "s", "", "Terraform state file path or s3://, gs://, https:// URI")
 	rootCmd.PersistentFlags().String("hcl-dir", "", "Terraform HCL directory path")
+	rootCmd.PersistentFlags().Bool("include-tainted", false, "Compare tainted Terraform instances instead of skipping them")
+	rootCmd.PersistentFlags().Bool("resolve-ssm-ami", false, "Resolve AMIs that HCL reads from SSM parameters")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Parse the Terraform state on every run instead of reusing it while unchanged")
 	rootCmd.PersistentFlags().String("source-of-truth", "terraform", "Source of truth (aws or terraform)")
 	rootCmd.PersistentFlags().StringSliceP("attributes", "a", nil, "Attributes to check for drift")
+	rootCmd.PersistentFlags().StringSlice("attributes-preset", nil, "Named attribute bundles to check, merged with --attributes (security, compute, tags or detector.attribute_presets)")
 	rootCmd.PersistentFlags().IntP("parallel-checks", "p", 0, "Number of parallel checks to run")
-	rootCmd.PersistentFlags().StringP("output", "o", "", "Output format (json, console, or both)")
-	rootCmd.PersistentFlags().StringP("output-file", "f", "", "Output file for JSON (defaults to stdout)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Overall timeout of a run, as a duration such as 30s or 2m (0 keeps detector.timeout_seconds)")
+	rootCmd.PersistentFlags().Bool("parallel-providers", false, "Check instances as AWS and Terraform stream them instead of fetching all instances before pairing")
+	rootCmd.PersistentFlags().StringP("output", "o", "", "Output format (json, console, both, markdown, teams, or metricsfile), replacing the reporters list")
+	rootCmd.PersistentFlags().StringP("output-file", "f", "", "Output file for JSON (defaults to stdout), replacing the reporters list")
 	rootCmd.PersistentFlags().String("schedule-expression", "", "Cron expression for scheduled drift checks")
+	rootCmd.PersistentFlags().String("aws-profile", "", "AWS shared config profile to use")
+	rootCmd.PersistentFlags().String("aws-source", "", "Where AWS instances are read from (ec2 or config for AWS Config's recorded configuration)")
+	rootCmd.PersistentFlags().String("as-of", "", "Point in time to read AWS Config's recorded configuration at, as an RFC 3339 timestamp or a date (with --aws-source config)")
+	rootCmd.PersistentFlags().String("error-format", string(errors.FormatText), "Error output format (text or json)")
+	rootCmd.PersistentFlags().String("debug-dump-dir", "", "Directory to dump the compared attributes of the first instances to")
 
 	// Add commands
 	h.addDetectCommand(rootCmd)
+	h.addReportCommand(rootCmd)
+	h.addShowCommand(rootCmd)
+	h.addBenchmarkCommand(rootCmd)
+	h.addTerraformCommand(rootCmd)
 	h.addServerCommand(rootCmd)
 	h.addConfigCommand(rootCmd)
 
 	h.rootCmd = rootCmd
 }
 
+// rebuildAWSProvider reports whether flags changed how the AWS provider is created
+func rebuildAWSProvider(cliOpts map[string]interface{}) bool {
+	for _, name := range []string{"aws-profile", "aws-source", "as-of"} {
+		if _, ok := cliOpts[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue returns the typed value of a flag, so UpdateConfig receives slices and numbers
+// rather than their string forms
+func flagValue(flags *pflag.FlagSet, f *pflag.Flag) interface{} {
+	switch f.Value.Type() {
+	case "stringSlice":
+		if val, err := flags.GetStringSlice(f.Name); err == nil {
+			return val
+		}
+	case "int":
+		if val, err := flags.GetInt(f.Name); err == nil {
+			return val
+		}
+	case "duration":
+		if val, err := flags.GetDuration(f.Name); err == nil {
+			return val
+		}
+	case "bool":
+		if val, err := flags.GetBool(f.Name); err == nil {
+			return val
+		}
+	}
+	return f.Value.String()
+}
+
 // addDetectCommand adds the detect command
 func (h *Handler) addDetectCommand(rootCmd *cobra.Command) {
 	detectCmd := &cobra.Command{
@@ -104,25 +240,357 @@ func (h *Handler) addDetectCommand(rootCmd *cobra.Command) {
 		Long:  "Detect drift between AWS EC2 instances and Terraform configurations",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx, cancel := context.WithTimeout(h.ctx, time.Duration(h.config.GetTimeout())*time.Second)
+			runCtx, cancelRun := h.runContext()
+			defer cancelRun()
+
+			ctx, cancel := context.WithTimeout(runCtx, h.config.GetTimeout())
 			defer cancel()
 
+			if flush, _ := cmd.Flags().GetBool("flush-digests"); flush {
+				h.logger.Info("Flushing pending notification digests")
+				return h.app.FlushDigests(ctx)
+			}
+
+			if len(args) > 0 && len(h.config.GetResourceFilter()) > 0 {
+				return errors.NewValidationError("--resource cannot be combined with an instance ID")
+			}
+
+			explain, _ := cmd.Flags().GetBool("explain")
+			if explain && len(args) == 0 {
+				return errors.NewValidationError("--explain needs an instance ID")
+			}
+
 			if len(args) > 0 {
 				// Detect drift for a specific instance
 				instanceID := args[0]
 				h.logger.Info(fmt.Sprintf("Detecting drift for instance %s", instanceID))
-				return h.app.DetectAndReportDrift(ctx, instanceID, h.config.GetAttributes())
+				if !explain {
+					return h.app.DetectAndReportDrift(ctx, instanceID, h.config.GetAttributes())
+				}
+
+				trace := model.NewCompareTrace()
+				if err := h.app.DetectAndReportDrift(service.WithCompareTrace(ctx, trace), instanceID, h.config.GetAttributes()); err != nil {
+					return err
+				}
+				printExplain(cmd.OutOrStdout(), trace)
+				return nil
 			}
 
 			// Detect drift for all instances
 			h.logger.Info("Detecting drift for all instances")
-			return h.app.DetectAndReportDriftForAll(ctx, h.config.GetAttributes())
+			summary, err := h.app.RunDriftCheck(ctx, h.config.GetAttributes())
+			if summary != nil {
+				h.logger.Info(summary.String())
+
+				// Reporters have run; stderr keeps the line out of JSON piped from stdout
+				if summaryLine, _ := cmd.Flags().GetBool("summary-line"); summaryLine {
+					fmt.Fprintln(cmd.ErrOrStderr(), summary.SummaryLine())
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			if failOnDrift, _ := cmd.Flags().GetBool("fail-on-drift"); failOnDrift && summary.HasFindings() {
+				// Findings are an outcome, not a usage mistake
+				cmd.SilenceUsage = true
+				return errors.NewDriftDetectedError(fmt.Sprintf("%d of %d instances drifted, %d with policy violations",
+					summary.DriftedCount, summary.TotalInstances, summary.PolicyViolationCount))
+			}
+			return nil
 		},
 	}
 
+	detectCmd.Flags().Bool("error-on-empty", false, "Exit with an error when no instances are found in AWS or Terraform")
+	detectCmd.Flags().Bool("user-data-diff", false, "Include a unified diff when user data differs")
+	detectCmd.Flags().Bool("suggest-remediation", false, "Suggest Terraform or AWS CLI commands, or configuration changes, that resolve each drift")
+	detectCmd.Flags().Bool("flush-digests", false, "Send pending notification digests now instead of detecting drift")
+	detectCmd.Flags().Bool("fail-on-drift", false, "Exit with code 2 when drift or policy violations are found across all instances")
+	detectCmd.Flags().StringSlice("resource", nil, "Only check the Terraform resources at these addresses or globs, e.g. module.web.* or aws_instance.app[2]")
+	detectCmd.Flags().Bool("summary-line", false, "Print a one-line drift summary to stderr after all instances are reported")
+	detectCmd.Flags().Bool("explain", false, "Print how each attribute of the instance was resolved, normalized and compared")
+
 	rootCmd.AddCommand(detectCmd)
 }
 
+// addReportCommand adds the report command
+func (h *Handler) addReportCommand(rootCmd *cobra.Command) {
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Render stored drift results without detecting",
+		Long:  "Render drift results stored in the repository through the chosen reporter, without re-running detection",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			sinceFlag, _ := cmd.Flags().GetString("since")
+
+			since, err := parseSince(sinceFlag, time.Now())
+			if err != nil {
+				return err
+			}
+
+			r, err := h.reporterForFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(h.ctx, h.config.GetTimeout())
+			defer cancel()
+
+			h.logger.Info(fmt.Sprintf("Rendering stored drift results as %s", format))
+			return h.app.ReportStoredResults(ctx, since, []service.Reporter{r})
+		},
+	}
+
+	reportCmd.Flags().String("format", config.ReporterTypeConsole, "Report format (console, json or markdown)")
+	reportCmd.Flags().String("since", "", "Only include results stored since a duration ago (e.g. 24h) or an RFC3339 timestamp")
+
+	rootCmd.AddCommand(reportCmd)
+}
+
+// addShowCommand adds the show command
+func (h *Handler) addShowCommand(rootCmd *cobra.Command) {
+	showCmd := &cobra.Command{
+		Use:   "show [instance-id]",
+		Short: "Show a stored drift result in detail",
+		Long:  "Show a drift result stored in the repository, by its result ID or the latest result of an instance, without re-running detection",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			id, _ := cmd.Flags().GetString("result-id")
+
+			switch {
+			case len(args) > 0 && id != "":
+				return errors.NewValidationError("Pass either an instance ID or --result-id, not both")
+			case len(args) > 0:
+				id = args[0]
+			case id == "":
+				return errors.NewValidationError("An instance ID or --result-id is required")
+			}
+
+			r, err := h.reporterForFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(h.ctx, h.config.GetTimeout())
+			defer cancel()
+
+			return h.app.ShowStoredResult(ctx, id, []service.Reporter{r})
+		},
+	}
+
+	showCmd.Flags().String("result-id", "", "ID of the stored result to show, as listed in reports")
+	showCmd.Flags().String("format", config.ReporterTypeConsole, "Report format (console, json or markdown)")
+
+	rootCmd.AddCommand(showCmd)
+}
+
+// addBenchmarkCommand adds the benchmark command
+func (h *Handler) addBenchmarkCommand(rootCmd *cobra.Command) {
+	benchmarkCmd := &cobra.Command{
+		Use:   "benchmark",
+		Short: "Measure detection at several concurrency levels and suggest settings",
+		Long:  "Check a random sample of instances at several concurrency levels, measuring wall time, API errors, throttling and p95 per-instance latency, and suggest parallel_checks and timeouts. Results are discarded: nothing is saved or reported.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sample, _ := cmd.Flags().GetInt("sample")
+			levels, _ := cmd.Flags().GetIntSlice("levels")
+
+			report, err := h.app.Benchmark(h.ctx, service.BenchmarkOptions{
+				SampleSize:     sample,
+				Levels:         levels,
+				AttributePaths: h.config.GetAttributes(),
+			})
+			if err != nil {
+				return err
+			}
+
+			printBenchmark(cmd.OutOrStdout(), report)
+			return nil
+		},
+	}
+
+	benchmarkCmd.Flags().Int("sample", 50, "Number of randomly chosen instances checked at each level")
+	benchmarkCmd.Flags().IntSlice("levels", service.DefaultBenchmarkLevels, "Parallel checks to measure, in order")
+
+	rootCmd.AddCommand(benchmarkCmd)
+}
+
+// printBenchmark writes the measurements of each level and the suggested settings
+func printBenchmark(w io.Writer, report *model.BenchmarkReport) {
+	fmt.Fprintf(w, "Benchmarked %d of %d instances\n\n", report.SampleSize, report.TotalInstances)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PARALLEL CHECKS\tWALL TIME\tERRORS\tTHROTTLED\tP95 LATENCY\t")
+	for _, level := range report.Levels {
+		marker := ""
+		if level.ParallelChecks == report.ParallelChecks {
+			marker = "recommended"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\t%s\n", level.ParallelChecks, level.WallTime.Round(time.Millisecond),
+			level.Errors, level.Throttles, level.P95Latency.Round(time.Millisecond), marker)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nSuggested configuration:")
+	fmt.Fprintln(w, "detector:")
+	fmt.Fprintf(w, "  parallel_checks: %d\n", report.ParallelChecks)
+	fmt.Fprintf(w, "  aws_timeout_seconds: %d\n", int(report.AWSTimeout.Seconds()))
+	fmt.Fprintf(w, "  timeout_seconds: %d\n", int(report.Timeout.Seconds()))
+}
+
+// addTerraformCommand adds the terraform command
+func (h *Handler) addTerraformCommand(rootCmd *cobra.Command) {
+	terraformCmd := &cobra.Command{
+		Use:   "terraform",
+		Short: "Inspect the Terraform side of drift detection",
+		Long:  "Inspect the configured Terraform state or HCL without calling AWS",
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"inspect"},
+		Short:   "List the instances found in the Terraform state or HCL",
+		Long:    "Parse the configured Terraform state or HCL directory and list the instances it declares, to check that it can be read before AWS credentials are set up. Only the Terraform provider is used: EC2 is never called, though a state in S3 is still read with the AWS credentials.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, err := factory.NewInstanceProviderFactory(h.logger).CreateTerraformProvider(h.config)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(h.ctx, h.config.GetTimeout())
+			defer cancel()
+
+			instances, err := provider.ListInstances(ctx)
+			if err != nil {
+				return err
+			}
+
+			printTerraformInstances(cmd.OutOrStdout(), instances)
+			return nil
+		},
+	}
+
+	terraformCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(terraformCmd)
+}
+
+// printTerraformInstances writes the ID, type, address and Name tag of each instance, sorted by
+// address
+func printTerraformInstances(w io.Writer, instances []*model.Instance) {
+	sorted := append([]*model.Instance(nil), instances...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].ResourceAddress != sorted[j].ResourceAddress {
+			return sorted[i].ResourceAddress < sorted[j].ResourceAddress
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	orDash := func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return value
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTYPE\tADDRESS\tNAME")
+	for _, instance := range sorted {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", orDash(instance.ID), orDash(instance.InstanceType), orDash(instance.ResourceAddress), orDash(instance.NameTag()))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nFound %d instances\n", len(instances))
+}
+
+// printExplain writes how each attribute of a traced comparison was compared
+func printExplain(w io.Writer, trace *model.CompareTrace) {
+	sourceOrigin, targetOrigin := trace.Origins()
+	opts := trace.Options()
+	fmt.Fprintf(w, "\nComparison of %s (source) with %s (target)\n", sourceOrigin, targetOrigin)
+	fmt.Fprintf(w, "Options: empty_equals_absent=%t trim_tag_values=%t ignore_tag_case=%t user_data_hash=%t\n",
+		opts.EmptyEqualsAbsent, opts.TrimTagValues, opts.IgnoreTagCase, opts.UserDataHash)
+	if len(opts.StrictPresencePaths) > 0 {
+		fmt.Fprintf(w, "Strict presence: %s\n", strings.Join(opts.StrictPresencePaths, ", "))
+	}
+	if len(opts.Comparators) > 0 {
+		fmt.Fprintf(w, "Custom comparators: %s\n", strings.Join(opts.Comparators, ", "))
+	}
+
+	for _, record := range trace.Records() {
+		fmt.Fprintf(w, "\n%s: %s\n", record.Path, strings.ToUpper(record.Verdict))
+		if len(record.Aliases) > 0 {
+			fmt.Fprintf(w, "  aliases:    %s\n", strings.Join(record.Aliases, ", "))
+		}
+		if record.Verdict == model.TraceSkipped && record.SourcePath == "" {
+			fmt.Fprintf(w, "  reason:     %s\n", record.Reason)
+			continue
+		}
+		fmt.Fprintf(w, "  %-11s %s = %s\n", string(sourceOrigin)+":", record.SourcePath, explainValue(record.SourceValue, record.SourceFound))
+		fmt.Fprintf(w, "  %-11s %s = %s\n", string(targetOrigin)+":", record.TargetPath, explainValue(record.TargetValue, record.TargetFound))
+		for _, normalization := range record.Normalizations {
+			fmt.Fprintf(w, "  normalized: %s\n", normalization)
+		}
+		if record.SourceNormalized != nil || record.TargetNormalized != nil {
+			fmt.Fprintf(w, "  compared:   %s vs %s\n", explainValue(record.SourceNormalized, true), explainValue(record.TargetNormalized, true))
+		}
+		if record.Comparer != "" {
+			fmt.Fprintf(w, "  comparer:   %s\n", record.Comparer)
+		}
+		if record.Reason != "" {
+			fmt.Fprintf(w, "  reason:     %s\n", record.Reason)
+		}
+	}
+}
+
+// explainValue formats a traced value as JSON, so strings show their whitespace
+func explainValue(value interface{}, found bool) string {
+	if !found {
+		return "(absent)"
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
+
+// reporterForFormat creates a reporter for a single report format, writing where the
+// configured reporter of that format does
+func (h *Handler) reporterForFormat(format string) (service.Reporter, error) {
+	switch format {
+	case config.ReporterTypeConsole, config.ReporterTypeJSON, config.ReporterTypeMarkdown:
+	default:
+		return nil, errors.NewValidationError(fmt.Sprintf("Unsupported report format %q (supported: console, json, markdown)", format))
+	}
+
+	rc := config.ReporterConfig{Type: format, OutputFile: h.config.GetOutputFile(), PrettyPrint: h.config.GetPrettyPrint()}
+	for _, configured := range h.config.GetReporters() {
+		if configured.Type == format {
+			rc = configured
+			break
+		}
+	}
+	return factory.NewReporterFactory(h.logger).CreateReporter(h.config, rc)
+}
+
+// parseSince parses a --since value as a duration before now or an RFC3339 timestamp.
+// An empty value returns the zero time, which includes every stored result.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.NewValidationError(fmt.Sprintf("Invalid --since value %q: expected a duration (e.g. 24h) or an RFC3339 timestamp", value))
+}
+
 // addServerCommand adds the server command
 func (h *Handler) addServerCommand(rootCmd *cobra.Command) {
 	serverCmd := &cobra.Command{
@@ -132,6 +600,15 @@ func (h *Handler) addServerCommand(rootCmd *cobra.Command) {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			h.logger.Info("Starting drift detector server")
 
+			// Start the health endpoints before the scheduler so probes see the server coming up
+			var healthServer *health.Server
+			if port := h.config.GetHealthPort(); port > 0 {
+				healthServer = health.NewServer(port, h.app, h.config.Validate, h.options.Version, h.config.GetReadinessInterval(), h.logger)
+				if err := healthServer.Start(); err != nil {
+					return err
+				}
+			}
+
 			// Start the scheduler
 			if err := h.app.StartScheduler(h.ctx); err != nil {
 				return err
@@ -146,6 +623,15 @@ func (h *Handler) addServerCommand(rootCmd *cobra.Command) {
 
 			// Stop the scheduler
 			h.app.StopScheduler()
+
+			if healthServer != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := healthServer.Stop(ctx); err != nil {
+					h.logger.Error(fmt.Sprintf("Failed to stop health server: %v", err))
+				}
+			}
+
 			h.logger.Info("Drift detector server stopped")
 
 			return nil
@@ -174,27 +660,64 @@ func (h *Handler) addConfigCommand(rootCmd *cobra.Command) {
 			fmt.Println("======================")
 			fmt.Printf("Source of Truth: %s\n", h.config.GetSourceOfTruth())
 			fmt.Printf("Attributes: %s\n", strings.Join(h.config.GetAttributes(), ", "))
-			fmt.Printf("Parallel Checks: %d\n", h.config.GetParallelChecks())
-			fmt.Printf("Timeout: %d seconds\n", h.config.GetTimeout())
-			reporterType := h.config.GetReporterType()
-			fmt.Printf("Reporter Type: %s\n", reporterType)
-
-			if reporterType == "json" || reporterType == "both" {
-				fmt.Printf("Output File: %s\n", h.config.GetOutputFile())
-				fmt.Printf("Pretty Print: %v\n", h.config.GetPrettyPrint())
+			if max := h.config.GetMaxParallelChecks(); max > 0 {
+				fmt.Printf("Parallel Checks: %d (max %d)\n", h.config.GetParallelChecks(), max)
+			} else {
+				fmt.Printf("Parallel Checks: %d\n", h.config.GetParallelChecks())
+			}
+			fmt.Printf("Timeout: %s\n", h.config.GetTimeout())
+			for i, rc := range h.config.GetReporters() {
+				// Output files come from reporters[i] or, without a reporters list, reporter.output_file
+				outputFile := h.config.Redacted(fmt.Sprintf("reporters[%d].output_file", i), h.config.Redacted("reporter.output_file", rc.OutputFile))
+				fmt.Printf("Reporter: %s\n", rc.Type)
+				switch rc.Type {
+				case config.ReporterTypeJSON, config.ReporterTypeMarkdown:
+					fmt.Printf("  Output File: %s\n", outputFile)
+					fmt.Printf("  Pretty Print: %v\n", rc.PrettyPrint)
+				case config.ReporterTypeMetrics:
+					fmt.Printf("  Output File: %s\n", outputFile)
+				case config.ReporterTypeTeams:
+					fmt.Printf("  Teams Max Instances: %d\n", h.config.GetTeamsMaxInstances())
+				}
 			}
 
 			if cronExpression := h.config.GetScheduleExpression(); cronExpression != "" {
 				fmt.Printf("Schedule Expression: %s\n", cronExpression)
+				if sched, err := schedule.Parse(cronExpression); err != nil {
+					fmt.Printf("Next Runs: %v\n", err)
+				} else {
+					fmt.Println("Next Runs:")
+					for _, run := range schedule.NextRuns(sched, time.Now(), schedule.PreviewCount) {
+						fmt.Printf("  %s\n", schedule.FormatRun(run))
+					}
+				}
 			}
 
 			fmt.Printf("Log Level: %s\n", h.config.GetLogLevel())
 			fmt.Printf("AWS Region: %s\n", h.config.GetAWSRegion())
+			if h.config.GetAWSSource() == config.AWSSourceConfig {
+				if asOf := h.config.GetAWSAsOf(); !asOf.IsZero() {
+					fmt.Printf("AWS Source: AWS Config as of %s\n", asOf.UTC().Format(time.RFC3339))
+				} else {
+					fmt.Printf("AWS Source: AWS Config\n")
+				}
+			}
 
 			if h.config.GetUseHCL() {
-				fmt.Printf("Terraform HCL Directory: %s\n", h.config.GetHCLDir())
+				fmt.Printf("Terraform HCL Directory: %s\n", h.config.Redacted("terraform.hcl_dir", h.config.GetHCLDir()))
+			} else if workspace := h.config.GetTFCWorkspace(); workspace != "" {
+				fmt.Printf("Terraform Cloud Workspace: %s (%s)\n", h.config.Redacted("terraform.tfc_workspace", workspace), h.config.Redacted("terraform.tfc_address", h.config.GetTFCAddress()))
 			} else {
-				fmt.Printf("Terraform State File: %s\n", h.config.GetStateFile())
+				fmt.Printf("Terraform State File: %s\n", h.config.Redacted("terraform.state_file", h.config.GetStateFile()))
+			}
+
+			// Values resolved from references are never printed, only where they came from
+			if keys := h.config.SecretReferenceKeys(); len(keys) > 0 {
+				references := h.config.SecretReferences()
+				fmt.Println("Resolved References:")
+				for _, key := range keys {
+					fmt.Printf("  %s: %s\n", key, references[key])
+				}
 			}
 
 			return nil
@@ -217,21 +740,107 @@ func (h *Handler) addConfigCommand(rootCmd *cobra.Command) {
 			// Update the reference
 			h.config = config
 
+			// Parse the state again on the next run rather than trusting the cache
+			h.app.InvalidateStateCache()
+
 			// Update service configuration
-			h.updateServiceConfig()
+			if err := h.updateServiceConfig(); err != nil {
+				return err
+			}
 
 			h.logger.Info("Configuration reloaded successfully")
 			return nil
 		},
 	}
 
+	// Add set subcommand
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a configuration value in the config file",
+		Long:  fmt.Sprintf("Validate and write a configuration value to the config file.\nSupported keys: %s", strings.Join(config.ConfigKeys(), ", ")),
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, _ := cmd.Flags().GetString("file")
+			allowSecret, _ := cmd.Flags().GetBool("allow-secret")
+
+			change, err := h.configLoader.SetValue(file, args[0], args[1], allowSecret)
+			if err != nil {
+				return err
+			}
+
+			fmt.Print(change.Diff())
+			return nil
+		},
+	}
+	setCmd.Flags().String("file", "", "Config file to update (defaults to the loaded config file)")
+	setCmd.Flags().Bool("allow-secret", false, "Allow writing secret values in plaintext")
+
+	// Add unset subcommand
+	unsetCmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a configuration value from the config file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, _ := cmd.Flags().GetString("file")
+
+			change, err := h.configLoader.UnsetValue(file, args[0])
+			if err != nil {
+				return err
+			}
+			if change.NotSet {
+				fmt.Printf("%s is not set in %s\n", change.Key, change.File)
+				return nil
+			}
+
+			fmt.Print(change.Diff())
+			return nil
+		},
+	}
+	unsetCmd.Flags().String("file", "", "Config file to update (defaults to the loaded config file)")
+
+	// Add template subcommand
+	templateCmd := &cobra.Command{
+		Use:       "template <name>",
+		Short:     "Print a built-in report template",
+		Long:      fmt.Sprintf("Print a built-in report template to copy and customize, then set reporter.<name>.template to the copy.\nTemplates: %s", strings.Join(reporter.TemplateNames(), ", ")),
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: reporter.TemplateNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source, err := reporter.BuiltinTemplate(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), source)
+			return nil
+		},
+	}
+
+	// Add schema subcommand
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the configuration reference",
+		Long:  "Print every configuration key with its type, default, environment variable, description and constraints, as Markdown or as JSON for tooling.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			return config.WriteSchema(cmd.OutOrStdout(), format)
+		},
+	}
+	schemaCmd.Flags().String("format", config.SchemaFormatMarkdown, "Output format: markdown or json")
+
 	configCmd.AddCommand(showCmd)
 	configCmd.AddCommand(reloadCmd)
+	configCmd.AddCommand(setCmd)
+	configCmd.AddCommand(unsetCmd)
+	configCmd.AddCommand(templateCmd)
+	configCmd.AddCommand(schemaCmd)
 	rootCmd.AddCommand(configCmd)
 }
 
-// updateServiceConfig updates service configuration from the config object
-func (h *Handler) updateServiceConfig() {
+// updateServiceConfig updates service configuration from the config object. It fails when the
+// configured reporters can't be created, e.g. for an unknown type or a template that doesn't load.
+func (h *Handler) updateServiceConfig() error {
 	// Update drift detector configuration
 	detector := h.app
 
@@ -239,43 +848,97 @@ func (h *Handler) updateServiceConfig() {
 	detector.SetSourceOfTruth(sourceOfTruth)
 	detector.SetAttributePaths(h.config.GetAttributes())
 	detector.SetParallelChecks(h.config.GetParallelChecks())
-	detector.SetTimeout(time.Duration(h.config.GetTimeout()) * time.Second)
+	detector.SetTimeout(h.config.GetTimeout())
+	detector.SetAWSTimeout(h.config.GetAWSTimeout())
+	detector.SetTerraformTimeout(h.config.GetTerraformTimeout())
 	detector.SetScheduleExpression(h.config.GetScheduleExpression())
-
-	// Update reporters based on configuration
-	var reporters []service.Reporter
-
-	switch h.config.GetReporterType() {
-	case "console":
-		reporters = append(reporters, reporter.NewConsoleReporter(h.logger))
-	case "json":
-		reporters = append(reporters, reporter.NewJSONReporter(h.logger, h.config.GetOutputFile()))
-	case "both":
-		reporters = append(reporters, reporter.NewConsoleReporter(h.logger))
-		reporters = append(reporters, reporter.NewJSONReporter(h.logger, h.config.GetOutputFile()))
-	default:
-		h.logger.Warn("Unknown reporter type: %s, using console reporter", h.config.GetReporterType())
-		reporters = append(reporters, reporter.NewConsoleReporter(h.logger))
+	detector.SetAbortAfterErrors(h.config.GetAbortAfterErrors())
+	detector.SetParallelProviders(h.config.GetParallelProviders())
+	detector.SetStateCacheEnabled(h.config.GetCacheState())
+	detector.SetErrorOnEmpty(h.config.GetErrorOnEmpty())
+	detector.SetMinInstances(h.config.GetMinInstances())
+	detector.SetStaticIPsOnly(h.config.GetStaticIPsOnly())
+	detector.SetSourceDeclaredOnly(h.config.GetSourceDeclaredOnly())
+	detector.SetCheckOrphans(h.config.GetCheckOrphans())
+	detector.SetAttributeDumper(factory.NewDriftDetectorFactory(h.logger).CreateAttributeDumper(h.config))
+	detector.SetCompareOptions(model.CompareOptions{
+		EmptyEqualsAbsent:   h.config.GetEmptyEqualsAbsent(),
+		StrictPresencePaths: h.config.GetStrictPresencePaths(),
+		TrimTagValues:       h.config.GetTrimTagValues(),
+		IgnoreTagCase:       h.config.GetIgnoreTagCase(),
+		StoreValues:         h.config.GetStoreValues(),
+		StoreValuesMaxBytes: h.config.GetStoreValuesMaxBytes(),
+		UserDataHash:        h.config.GetUserDataHash(),
+		UserDataDiff:        h.config.GetUserDataDiff(),
+	})
+	detector.SetPolicies(h.config.GetPolicies())
+	detector.SetAllowedInstanceTypes(h.config.GetAllowedInstanceTypes())
+	detector.SetEnvironmentTag(h.config.GetEnvironmentTag())
+	detector.SetStrictAccountCheck(h.config.GetStrictAccountCheck())
+	detector.SetSuggestRemediation(h.config.GetSuggestRemediation())
+	detector.SetEnrichNetworkContext(h.config.GetEnrichNetworkContext())
+	detector.SetCompareTerminationProtection(h.config.GetCompareTerminationProtection())
+	detector.SetMemoize(h.config.GetMemoize())
+	detector.SetHashPrefilter(h.config.GetHashPrefilter())
+	detector.SetDriftAgeLookback(h.config.GetDriftAgeLookback())
+	detector.SetIncludeSnapshots(h.config.GetIncludeSnapshots())
+	detector.SetResourceFilter(h.config.GetResourceFilter())
+	detector.SetDigestOptions(service.DigestOptions{
+		Interval:          h.config.GetDigestInterval(),
+		ImmediateSeverity: h.config.GetDigestImmediateSeverity(),
+		Store:             factory.NewRepositoryFactory(h.logger).CreateDigestStore(h.config),
+	})
+
+	// Swap in the reporters the configuration describes, built the same way as at startup
+	reporters, err := factory.NewReporterFactory(h.logger).CreateReporters(h.config)
+	if err != nil {
+		return err
 	}
-
 	detector.SetReporters(reporters)
+	return nil
+}
+
+// runContext returns the context for a one-shot run. It outlives a shutdown signal by the
+// configured grace period, so a run that is nearly done can still report its results.
+func (h *Handler) runContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(h.ctx))
+	stop := context.AfterFunc(h.ctx, func() {
+		time.AfterFunc(h.config.GetShutdownGracePeriod(), cancel)
+	})
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
 }
 
-// Execute executes the root command
+// Execute executes the root command. When ctx is cancelled by a shutdown signal the command gets
+// the configured grace period to finish, and its own result is returned if it does.
 func (h *Handler) Execute(ctx context.Context) error {
-	done := make(chan struct{})
+	done := make(chan error, 1)
 
 	go func() {
-		defer close(done)
-		h.rootCmd.Execute()
+		done <- h.rootCmd.Execute()
 	}()
 
 	select {
+	case err := <-done:
+		return err
 	case <-ctx.Done():
-		h.logger.Warn("Received interrupt signal, exiting...")
+	}
+
+	grace := h.config.GetShutdownGracePeriod()
+	h.logger.Warn(fmt.Sprintf("Received interrupt signal, waiting up to %s for the command to finish", grace))
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		h.logger.Warn("Shutdown grace period expired, exiting...")
 		return ctx.Err()
-	case <-done:
-		return nil
 	}
 }
 
@@ -1,23 +1,46 @@
 package cli_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/config"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"github.com/victor-devv/ec2-drift-detector/internal/factory"
+	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/aws"
 	"github.com/victor-devv/ec2-drift-detector/internal/presentation/cli"
+	"github.com/victor-devv/ec2-drift-detector/internal/presentation/reporter"
 )
 
 type mockDriftService struct {
 	schedulerStarted bool
+	reportedSince    time.Time
+	reportReporters  []service.Reporter
+	shownID          string
+	awsProvider      service.InstanceProvider
+	runSummary       *model.RunSummary
+	stateCache       bool
+	benchmark        service.BenchmarkOptions
+	timeout          time.Duration
+	runDeadline      time.Time
+	resourceFilter   []string
+	reporters        []service.Reporter
 }
 
 func (m *mockDriftService) DetectAndReportDrift(ctx context.Context, id string, attrs []string) error {
+	if trace := service.CompareTraceFrom(ctx); trace != nil {
+		trace.Skip("ami", "ami is read from a data source")
+	}
 	return nil
 }
 func (m *mockDriftService) DetectAndReportDriftForAll(ctx context.Context, attrs []string) error {
@@ -31,6 +54,13 @@ func (m *mockDriftService) StopScheduler() {}
 func (m *mockDriftService) RunScheduledDriftCheck(ctx context.Context) error {
 	return nil
 }
+func (m *mockDriftService) RunDriftCheck(ctx context.Context, attrs []string) (*model.RunSummary, error) {
+	m.runDeadline, _ = ctx.Deadline()
+	if m.runSummary == nil {
+		return &model.RunSummary{}, nil
+	}
+	return m.runSummary, nil
+}
 func (m *mockDriftService) DetectDrift(ctx context.Context, src, tgt *model.Instance, attrs []string) (*model.DriftResult, error) {
 	return nil, nil
 }
@@ -40,17 +70,101 @@ func (m *mockDriftService) DetectDriftByID(ctx context.Context, id string, attrs
 func (m *mockDriftService) DetectDriftForAll(ctx context.Context, attrs []string) ([]*model.DriftResult, error) {
 	return nil, nil
 }
-func (m *mockDriftService) SetSourceOfTruth(t model.ResourceOrigin) {}
-func (m *mockDriftService) SetAttributePaths(p []string)            {}
-func (m *mockDriftService) SetParallelChecks(c int)                 {}
-func (m *mockDriftService) SetTimeout(d time.Duration)              {}
-func (m *mockDriftService) SetScheduleExpression(e string)          {}
-func (m *mockDriftService) SetReporters(r []service.Reporter)       {}
-func (m *mockDriftService) GetAttributePaths() []string             { return nil }
-func (m *mockDriftService) GetSourceOfTruth() model.ResourceOrigin  { return "aws" }
-func (m *mockDriftService) GetParallelChecks() int                  { return 1 }
-func (m *mockDriftService) GetTimeout() time.Duration               { return 1 }
-func (m *mockDriftService) GetScheduleExpression() string           { return "" }
+func (m *mockDriftService) SetSourceOfTruth(t model.ResourceOrigin)  {}
+func (m *mockDriftService) SetAttributePaths(p []string)             {}
+func (m *mockDriftService) SetParallelChecks(c int)                  {}
+func (m *mockDriftService) SetTimeout(d time.Duration)               { m.timeout = d }
+func (m *mockDriftService) SetAWSTimeout(d time.Duration)            {}
+func (m *mockDriftService) SetTerraformTimeout(d time.Duration)      {}
+func (m *mockDriftService) SetScheduleExpression(e string)           {}
+func (m *mockDriftService) SetAbortAfterErrors(n int)                {}
+func (m *mockDriftService) SetParallelProviders(b bool)              {}
+func (m *mockDriftService) SetErrorOnEmpty(b bool)                   {}
+func (m *mockDriftService) SetMinInstances(n int)                    {}
+func (m *mockDriftService) SetStaticIPsOnly(b bool)                  {}
+func (m *mockDriftService) SetSourceDeclaredOnly(b bool)             {}
+func (m *mockDriftService) SetCheckOrphans(b bool)                   {}
+func (m *mockDriftService) SetCompareOptions(o model.CompareOptions) {}
+func (m *mockDriftService) SetDigestOptions(o service.DigestOptions) {}
+func (m *mockDriftService) GetDigestOptions() service.DigestOptions {
+	return service.DigestOptions{}
+}
+func (m *mockDriftService) SetPolicies(p []model.Policy)                 {}
+func (m *mockDriftService) SetAllowedInstanceTypes(t []string)           {}
+func (m *mockDriftService) GetAllowedInstanceTypes() []string            { return nil }
+func (m *mockDriftService) SetEnvironmentTag(tag string)                 {}
+func (m *mockDriftService) GetEnvironmentTag() string                    { return "" }
+func (m *mockDriftService) SetStrictAccountCheck(strict bool)            {}
+func (m *mockDriftService) GetStrictAccountCheck() bool                  { return false }
+func (m *mockDriftService) SetSuggestRemediation(suggest bool)           {}
+func (m *mockDriftService) GetSuggestRemediation() bool                  { return false }
+func (m *mockDriftService) SetEnrichNetworkContext(enrich bool)          {}
+func (m *mockDriftService) GetEnrichNetworkContext() bool                { return false }
+func (m *mockDriftService) SetCompareTerminationProtection(compare bool) {}
+func (m *mockDriftService) GetCompareTerminationProtection() bool        { return false }
+func (m *mockDriftService) SetMemoize(memoize bool)                      {}
+func (m *mockDriftService) GetMemoize() bool                             { return false }
+func (m *mockDriftService) SetHashPrefilter(prefilter bool)              {}
+func (m *mockDriftService) GetHashPrefilter() bool                       { return false }
+func (m *mockDriftService) SetDriftAgeLookback(lookback int)             {}
+func (m *mockDriftService) GetDriftAgeLookback() int                     { return 0 }
+func (m *mockDriftService) SetIncludeSnapshots(include bool)             {}
+func (m *mockDriftService) GetIncludeSnapshots() bool                    { return false }
+func (m *mockDriftService) SetResourceFilter(patterns []string)          { m.resourceFilter = patterns }
+func (m *mockDriftService) GetResourceFilter() []string                  { return m.resourceFilter }
+func (m *mockDriftService) GetPolicies() []model.Policy                  { return nil }
+func (m *mockDriftService) FlushDigests(ctx context.Context) error       { return nil }
+func (m *mockDriftService) Benchmark(ctx context.Context, options service.BenchmarkOptions) (*model.BenchmarkReport, error) {
+	m.benchmark = options
+	report := &model.BenchmarkReport{TotalInstances: 100, SampleSize: options.SampleSize}
+	for _, level := range options.Levels {
+		report.Levels = append(report.Levels, model.BenchmarkLevel{ParallelChecks: level, Instances: options.SampleSize, WallTime: time.Second})
+	}
+	report.Recommend()
+	return report, nil
+}
+func (m *mockDriftService) SetAWSProvider(p service.InstanceProvider) {
+	m.awsProvider = p
+}
+func (m *mockDriftService) SetAttributeDumper(d service.AttributeDumper) {}
+func (m *mockDriftService) SetStateCacheEnabled(enabled bool) {
+	m.stateCache = enabled
+}
+func (m *mockDriftService) InvalidateStateCache() {}
+func (m *mockDriftService) ShowStoredResult(ctx context.Context, id string, r []service.Reporter) error {
+	m.shownID = id
+	m.reportReporters = r
+	return nil
+}
+func (m *mockDriftService) ReportStoredResults(ctx context.Context, since time.Time, r []service.Reporter) error {
+	m.reportedSince = since
+	m.reportReporters = r
+	return nil
+}
+func (m *mockDriftService) SetReporters(r []service.Reporter)      { m.reporters = r }
+func (m *mockDriftService) GetAttributePaths() []string            { return nil }
+func (m *mockDriftService) GetSourceOfTruth() model.ResourceOrigin { return "aws" }
+func (m *mockDriftService) GetParallelChecks() int                 { return 1 }
+func (m *mockDriftService) GetTimeout() time.Duration              { return 1 }
+func (m *mockDriftService) GetAWSTimeout() time.Duration           { return 0 }
+func (m *mockDriftService) GetTerraformTimeout() time.Duration     { return 0 }
+func (m *mockDriftService) GetScheduleExpression() string          { return "" }
+func (m *mockDriftService) GetAbortAfterErrors() int               { return 0 }
+func (m *mockDriftService) GetParallelProviders() bool             { return false }
+func (m *mockDriftService) GetErrorOnEmpty() bool                  { return false }
+func (m *mockDriftService) GetMinInstances() int                   { return 0 }
+func (m *mockDriftService) GetStaticIPsOnly() bool                 { return true }
+func (m *mockDriftService) GetSourceDeclaredOnly() bool            { return false }
+func (m *mockDriftService) GetCheckOrphans() bool                  { return false }
+func (m *mockDriftService) GetCompareOptions() model.CompareOptions {
+	return model.CompareOptions{}
+}
+func (m *mockDriftService) GetSchedulerStatus() model.SchedulerStatus {
+	return model.SchedulerStatus{}
+}
+func (m *mockDriftService) CheckProviders(ctx context.Context) error { return nil }
+func (m *mockDriftService) RegisterComparator(path string, fn func(a, b interface{}) bool) {
+}
 
 func TestNewHandlerInitialization(t *testing.T) {
 	logger := logging.New()
@@ -138,3 +252,560 @@ func TestConfigShowCommandAdded(t *testing.T) {
 	assert.NotNil(t, configCmd)
 	assert.Equal(t, "show", configCmd.Use)
 }
+
+func TestNewHandlerWithOptions_CustomName(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+
+	h := cli.NewHandlerWithOptions(context.Background(), &mockDriftService{}, nil, cfg, logger, cli.HandlerOptions{
+		Name:  "drift",
+		Short: "Check EC2 drift",
+	})
+
+	cmd := h.GetRootCommand()
+	assert.Equal(t, "drift", cmd.Use)
+	assert.Equal(t, "Check EC2 drift", cmd.Short)
+	assert.Equal(t, cli.DefaultHandlerOptions().Long, cmd.Long)
+
+	// Subcommands are unaffected by the root name
+	detectCmd, _, err := cmd.Find([]string{"detect"})
+	assert.NoError(t, err)
+	assert.Equal(t, "detect [instance-id]", detectCmd.Use)
+}
+
+func TestNewHandler_DefaultName(t *testing.T) {
+	h := cli.NewHandler(context.Background(), &mockDriftService{}, nil, &config.Config{}, logging.New())
+	assert.Equal(t, "drift-detector", h.GetRootCommand().Use)
+}
+
+func TestReportCommand(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	mockService := &mockDriftService{}
+	h := cli.NewHandler(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger)
+	cmd := h.GetRootCommand()
+
+	cmd.SetArgs([]string{"report", "--format", "json", "--since", "2024-04-01T00:00:00Z"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), mockService.reportedSince.UTC())
+	assert.Len(t, mockService.reportReporters, 1)
+
+	cmd.SetArgs([]string{"report", "--format", "html"})
+	assert.Error(t, cmd.Execute())
+
+	cmd.SetArgs([]string{"show", "--result-id", "i-123-1713802845000000000-1a2b3c4d"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "i-123-1713802845000000000-1a2b3c4d", mockService.shownID)
+
+	cmd.SetArgs([]string{"show", "i-456", "--result-id", ""})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "i-456", mockService.shownID)
+
+	cmd.SetArgs([]string{"report", "--since", "yesterday"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestErrorFormatFlag(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	// The shared error handler picks up the format so errors returned from Execute use it too
+	errorHandler := errors.NewErrorHandler(logger)
+	h := cli.NewHandlerWithOptions(context.Background(), &mockDriftService{}, config.NewConfigLoader(logger, "."), cfg, logger, cli.HandlerOptions{
+		ErrorHandler: errorHandler,
+	})
+
+	cmd := h.GetRootCommand()
+	cmd.SetArgs([]string{"report", "--error-format", "json"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, errors.FormatJSON, errorHandler.Format())
+}
+
+// mockInstanceProvider stands in for a rebuilt AWS provider
+type mockInstanceProvider struct{}
+
+func (m *mockInstanceProvider) GetInstance(ctx context.Context, id string) (*model.Instance, error) {
+	return nil, nil
+}
+func (m *mockInstanceProvider) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	return nil, nil
+}
+
+func TestAWSProfileFlag(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	var clientConfig aws.ClientConfig
+	provider := &mockInstanceProvider{}
+	mockService := &mockDriftService{}
+	h := cli.NewHandlerWithOptions(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger, cli.HandlerOptions{
+		AWSProviderFactory: func(ctx context.Context, cfg *config.Config) (service.InstanceProvider, error) {
+			clientConfig = factory.NewInstanceProviderFactory(logger).AWSClientConfig(cfg)
+			return provider, nil
+		},
+	})
+
+	cmd := h.GetRootCommand()
+	cmd.SetArgs([]string{"report", "--aws-profile", "staging"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "staging", cfg.GetAWSProfile())
+	assert.Equal(t, "staging", clientConfig.Profile)
+	assert.Same(t, provider, mockService.awsProvider)
+}
+
+func TestAWSSourceFlags(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	rebuilt := 0
+	provider := &mockInstanceProvider{}
+	mockService := &mockDriftService{}
+	h := cli.NewHandlerWithOptions(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger, cli.HandlerOptions{
+		AWSProviderFactory: func(ctx context.Context, cfg *config.Config) (service.InstanceProvider, error) {
+			rebuilt++
+			return provider, nil
+		},
+	})
+
+	cmd := h.GetRootCommand()
+	cmd.SetArgs([]string{"report", "--aws-source", "config", "--as-of", "2024-05-01"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, config.AWSSourceConfig, cfg.GetAWSSource())
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), cfg.GetAWSAsOf())
+	assert.Equal(t, 1, rebuilt)
+	assert.Same(t, provider, mockService.awsProvider)
+}
+
+func TestDetectFailOnDrift(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	mockService := &mockDriftService{runSummary: &model.RunSummary{TotalInstances: 3, PolicyViolationCount: 1}}
+	h := cli.NewHandler(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger)
+
+	cmd := h.GetRootCommand()
+	cmd.SetArgs([]string{"detect"})
+	assert.NoError(t, cmd.Execute())
+
+	// Policy violations fail the run as well as drift
+	cmd.SetArgs([]string{"detect", "--fail-on-drift"})
+	err := cmd.Execute()
+	assert.Equal(t, errors.ExitCodeDrift, errors.ExitCode(err))
+}
+
+func TestDetectSummaryLine(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("json")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	mockService := &mockDriftService{runSummary: &model.RunSummary{TotalInstances: 200, DriftedCount: 12, DriftedAttributes: 36}}
+	h := cli.NewHandler(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger)
+
+	var stdout, stderr bytes.Buffer
+	cmd := h.GetRootCommand()
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+
+	// Off by default
+	cmd.SetArgs([]string{"detect"})
+	assert.NoError(t, cmd.Execute())
+	assert.Empty(t, stderr.String())
+
+	cmd.SetArgs([]string{"detect", "--summary-line"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "DRIFT: 12/200 instances drifted (36 attributes)\n", stderr.String())
+	assert.Empty(t, stdout.String())
+}
+
+func TestBenchmarkCommand(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	mockService := &mockDriftService{}
+	h := cli.NewHandler(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger)
+
+	var stdout bytes.Buffer
+	cmd := h.GetRootCommand()
+	cmd.SetOut(&stdout)
+
+	cmd.SetArgs([]string{"benchmark", "--sample", "20", "--levels", "4,8"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, service.BenchmarkOptions{SampleSize: 20, Levels: []int{4, 8}, AttributePaths: []string{"instance_type"}}, mockService.benchmark)
+
+	output := stdout.String()
+	assert.Contains(t, output, "Benchmarked 20 of 100 instances")
+	assert.Contains(t, output, "P95 LATENCY")
+	assert.Contains(t, output, "recommended")
+	assert.Contains(t, output, "  parallel_checks: 4\n")
+	assert.Contains(t, output, "  timeout_seconds: 60\n")
+}
+
+func TestAttributesAndParallelChecksFlags(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	h := cli.NewHandler(context.Background(), &mockDriftService{}, config.NewConfigLoader(logger, "."), cfg, logger)
+
+	cmd := h.GetRootCommand()
+	cmd.SetArgs([]string{"detect", "--attributes", "instance_type, ami,instance_type", "-a", "tags", "--parallel-checks", "4"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, []string{"instance_type", "ami", "tags"}, cfg.GetAttributes())
+	assert.Equal(t, 4, cfg.GetParallelChecks())
+}
+
+func TestAttributesPresetFlag(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	h := cli.NewHandler(context.Background(), &mockDriftService{}, config.NewConfigLoader(logger, "."), cfg, logger)
+
+	cmd := h.GetRootCommand()
+	cmd.SetArgs([]string{"detect", "--attributes-preset", "tags", "-a", "subnet_id"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, []string{"subnet_id", "tags"}, cfg.GetAttributes())
+}
+
+func TestTimeoutFlag(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	mockService := &mockDriftService{}
+	h := cli.NewHandler(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger)
+	cmd := h.GetRootCommand()
+
+	// Without the flag the configured timeout bounds the run
+	start := time.Now()
+	cmd.SetArgs([]string{"detect"})
+	assert.NoError(t, cmd.Execute())
+	assert.WithinDuration(t, start.Add(5*time.Second), mockService.runDeadline, time.Second)
+	assert.Equal(t, 5*time.Second, mockService.timeout)
+
+	start = time.Now()
+	cmd.SetArgs([]string{"detect", "--timeout", "2m"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, 2*time.Minute, cfg.GetTimeout())
+	assert.Equal(t, 2*time.Minute, mockService.timeout)
+	assert.WithinDuration(t, start.Add(2*time.Minute), mockService.runDeadline, time.Second)
+
+	// Sub-second timeouts reach the run and the service as given
+	start = time.Now()
+	cmd.SetArgs([]string{"detect", "--timeout", "1500ms"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, 1500*time.Millisecond, mockService.timeout)
+	assert.WithinDuration(t, start.Add(1500*time.Millisecond), mockService.runDeadline, 500*time.Millisecond)
+
+	// Bare numbers are ambiguous and rejected
+	cmd.SetArgs([]string{"detect", "--timeout", "30"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestConfigShow_Timeout(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(90 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("mock.tfstate")
+
+	h := cli.NewHandler(context.Background(), &mockDriftService{}, config.NewConfigLoader(logger, "."), cfg, logger)
+	cmd := h.GetRootCommand()
+
+	// config show prints to stdout
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	os.Stdout = w
+	cmd.SetArgs([]string{"config", "show"})
+	execErr := cmd.Execute()
+	os.Stdout = stdout
+	assert.NoError(t, w.Close())
+	assert.NoError(t, execErr)
+
+	output, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "Timeout: 1m30s\n")
+}
+
+func TestNoCacheFlag(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+	cfg.SetCacheState(true)
+
+	mockService := &mockDriftService{}
+	h := cli.NewHandler(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger)
+
+	cmd := h.GetRootCommand()
+	cmd.SetArgs([]string{"detect"})
+	assert.NoError(t, cmd.Execute())
+	assert.True(t, mockService.stateCache)
+
+	cmd.SetArgs([]string{"detect", "--no-cache"})
+	assert.NoError(t, cmd.Execute())
+	assert.False(t, cfg.GetCacheState())
+	assert.False(t, mockService.stateCache)
+}
+
+func TestConfigTemplate(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	h := cli.NewHandler(context.Background(), &mockDriftService{}, config.NewConfigLoader(logger, "."), cfg, logger)
+
+	var stdout bytes.Buffer
+	cmd := h.GetRootCommand()
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{"config", "template", "markdown"})
+	assert.NoError(t, cmd.Execute())
+	builtin, err := reporter.BuiltinTemplate(reporter.TemplateMarkdown)
+	assert.NoError(t, err)
+	assert.Equal(t, builtin, stdout.String())
+
+	cmd.SetArgs([]string{"config", "template", "html"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestConfigSchema(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	h := cli.NewHandler(context.Background(), &mockDriftService{}, config.NewConfigLoader(logger, "."), cfg, logger)
+
+	var stdout bytes.Buffer
+	cmd := h.GetRootCommand()
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{"config", "schema"})
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "| `detector.timeout_seconds` | int | `60` | `DRIFT_DETECTOR_TIMEOUT_SECONDS` |")
+
+	stdout.Reset()
+	cmd.SetArgs([]string{"config", "schema", "--format", "json"})
+	assert.NoError(t, cmd.Execute())
+	var entries []config.SchemaEntry
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &entries))
+	assert.Len(t, entries, len(config.Schema()))
+
+	cmd.SetArgs([]string{"config", "schema", "--format", "yaml"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestResourceFlag(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	mockService := &mockDriftService{}
+	h := cli.NewHandler(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger)
+	cmd := h.GetRootCommand()
+
+	cmd.SetArgs([]string{"detect", "--resource", "module.web.*", "--resource", "aws_instance.app[2]"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, []string{"module.web.*", "aws_instance.app[2]"}, cfg.GetResourceFilter())
+	assert.Equal(t, []string{"module.web.*", "aws_instance.app[2]"}, mockService.resourceFilter)
+
+	// An instance ID already names the one instance to check
+	cmd.SetArgs([]string{"detect", "i-123", "--resource", "aws_instance.app"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestDetectBuildsConfiguredReporters(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("both")
+	cfg.SetOutputFile(filepath.Join(t.TempDir(), "report.json"))
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	mockService := &mockDriftService{}
+	h := cli.NewHandler(context.Background(), mockService, config.NewConfigLoader(logger, "."), cfg, logger)
+	cmd := h.GetRootCommand()
+	cmd.SetArgs([]string{"detect"})
+	assert.NoError(t, cmd.Execute())
+
+	// The handler builds the same reporters as startup does for the configuration
+	if assert.Len(t, mockService.reporters, 2) {
+		assert.IsType(t, &reporter.ConsoleReporter{}, mockService.reporters[0])
+		assert.IsType(t, &reporter.JSONReporter{}, mockService.reporters[1])
+	}
+}
+
+func TestDetectExplain(t *testing.T) {
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"ami"})
+	cfg.SetSourceOfTruth("aws")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(5 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+
+	h := cli.NewHandler(context.Background(), &mockDriftService{}, config.NewConfigLoader(logger, "."), cfg, logger)
+
+	var stdout bytes.Buffer
+	cmd := h.GetRootCommand()
+	cmd.SetOut(&stdout)
+
+	cmd.SetArgs([]string{"detect", "i-123", "--explain"})
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "ami: SKIPPED\n")
+	assert.Contains(t, stdout.String(), "  reason:     ami is read from a data source\n")
+
+	// Explaining every instance would bury the one in question
+	cmd.SetArgs([]string{"detect", "--explain"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestTerraformList(t *testing.T) {
+	logger := logging.New()
+	newHandler := func() *cli.Handler {
+		cfg := &config.Config{}
+		cfg.SetReporterType("console")
+		cfg.SetAttributes([]string{"instance_type"})
+		cfg.SetSourceOfTruth("terraform")
+		cfg.SetParallelChecks(1)
+		cfg.SetTimeout(5 * time.Second)
+		cfg.SetAWSRegion("us-east-1")
+		cfg.SetStateFile("terraform.tfstate")
+		return cli.NewHandler(context.Background(), &mockDriftService{}, config.NewConfigLoader(logger, "."), cfg, logger)
+	}
+
+	t.Run("state", func(t *testing.T) {
+		var stdout bytes.Buffer
+		cmd := newHandler().GetRootCommand()
+		cmd.SetOut(&stdout)
+		cmd.SetArgs([]string{"terraform", "list", "--state-file", "../../infrastructure/terraform/testdata/instance_state/terraform.tfstate"})
+		assert.NoError(t, cmd.Execute())
+
+		out := stdout.String()
+		assert.Regexp(t, `i-0aaaaaaaaaaaaaaa1\s+t3.small\s+aws_instance.web`, out)
+		assert.Regexp(t, `i-0bbbbbbbbbbbbbbb1\s+c6i.large\s+aws_instance.batch`, out)
+		assert.Contains(t, out, "\nFound ")
+	})
+
+	t.Run("hcl", func(t *testing.T) {
+		var stdout bytes.Buffer
+		cmd := newHandler().GetRootCommand()
+		cmd.SetOut(&stdout)
+		cmd.SetArgs([]string{"terraform", "inspect", "--hcl-dir", "../../infrastructure/terraform/testdata/instance_state_hcl"})
+		assert.NoError(t, cmd.Execute())
+
+		out := stdout.String()
+		assert.Regexp(t, `t3.micro\s+aws_instance.web`, out)
+		assert.Regexp(t, `c6i.large\s+aws_instance.batch`, out)
+	})
+
+	t.Run("unreadable state", func(t *testing.T) {
+		cmd := newHandler().GetRootCommand()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs([]string{"terraform", "list", "--state-file", filepath.Join(t.TempDir(), "missing.tfstate")})
+		assert.Error(t, cmd.Execute())
+	})
+}
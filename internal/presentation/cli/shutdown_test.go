@@ -0,0 +1,154 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/app"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/config"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"github.com/victor-devv/ec2-drift-detector/internal/infrastructure/repository"
+	"github.com/victor-devv/ec2-drift-detector/internal/presentation/cli"
+)
+
+// slowReporter stands in for a webhook that is still being written to when the pod is told to stop
+type slowReporter struct {
+	delay   time.Duration
+	started chan struct{}
+
+	mu       sync.Mutex
+	reported []*model.DriftResult
+}
+
+func newSlowReporter(delay time.Duration) *slowReporter {
+	return &slowReporter{delay: delay, started: make(chan struct{})}
+}
+
+func (r *slowReporter) ReportDrift(result *model.DriftResult) error {
+	return r.ReportMultipleDrifts([]*model.DriftResult{result})
+}
+
+func (r *slowReporter) ReportMultipleDrifts(results []*model.DriftResult) error {
+	close(r.started)
+	time.Sleep(r.delay)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reported = append(r.reported, results...)
+	return nil
+}
+
+func (r *slowReporter) Reported() []*model.DriftResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reported
+}
+
+// fixedReporters keeps the test reporter in place when the CLI applies the reporter config
+type fixedReporters struct {
+	*app.DriftDetectorService
+}
+
+func (fixedReporters) SetReporters([]service.Reporter) {}
+
+type staticProvider struct {
+	instances []*model.Instance
+}
+
+func (p *staticProvider) GetInstance(ctx context.Context, id string) (*model.Instance, error) {
+	for _, instance := range p.instances {
+		if instance.ID == id {
+			return instance, nil
+		}
+	}
+	return nil, errors.NewNotFoundError("Instance", id)
+}
+
+func (p *staticProvider) ListInstances(ctx context.Context) ([]*model.Instance, error) {
+	return p.instances, nil
+}
+
+func (p *staticProvider) StreamInstances(ctx context.Context, emit func(*model.Instance) error) error {
+	for _, instance := range p.instances {
+		if err := emit(instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runDetectWithSIGTERM runs `detect --fail-on-drift` on one drifted instance and sends SIGTERM
+// to the process once the reporter starts writing
+func runDetectWithSIGTERM(t *testing.T, grace time.Duration, reporter *slowReporter) error {
+	t.Helper()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	logger := logging.New()
+	cfg := &config.Config{}
+	cfg.SetReporterType("console")
+	cfg.SetAttributes([]string{"instance_type"})
+	cfg.SetSourceOfTruth("terraform")
+	cfg.SetParallelChecks(1)
+	cfg.SetTimeout(10 * time.Second)
+	cfg.SetAWSRegion("us-east-1")
+	cfg.SetStateFile("terraform.tfstate")
+	cfg.SetShutdownGracePeriod(grace)
+
+	detector := app.NewDriftDetectorService(
+		&staticProvider{instances: []*model.Instance{model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.large"}, model.OriginAWS)}},
+		&staticProvider{instances: []*model.Instance{model.NewInstance("i-1", map[string]interface{}{"instance_type": "t2.micro"}, model.OriginTerraform)}},
+		repository.NewInMemoryDriftRepository(logger),
+		[]service.Reporter{reporter},
+		service.DriftDetectorConfig{
+			SourceOfTruth:  model.OriginTerraform,
+			AttributePaths: []string{"instance_type"},
+			Timeout:        10 * time.Second,
+			ParallelChecks: 1,
+		},
+		logger,
+	)
+
+	h := cli.NewHandler(ctx, fixedReporters{detector}, config.NewConfigLoader(logger, "."), cfg, logger)
+	h.GetRootCommand().SetArgs([]string{"detect", "--fail-on-drift"})
+
+	go func() {
+		<-reporter.started
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	}()
+
+	return h.Execute(ctx)
+}
+
+func TestExecute_SIGTERMWaitsForReporters(t *testing.T) {
+	reporter := newSlowReporter(200 * time.Millisecond)
+
+	err := runDetectWithSIGTERM(t, 5*time.Second, reporter)
+
+	// The run finished within the grace period, so its own outcome decides the exit code
+	require.Error(t, err)
+	assert.Equal(t, errors.ExitCodeDrift, errors.ExitCode(err))
+	assert.Len(t, reporter.Reported(), 1)
+}
+
+func TestExecute_SIGTERMGracePeriodExpires(t *testing.T) {
+	reporter := newSlowReporter(2 * time.Second)
+
+	start := time.Now()
+	err := runDetectWithSIGTERM(t, 50*time.Millisecond, reporter)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), reporter.delay)
+	assert.Empty(t, reporter.Reported())
+}
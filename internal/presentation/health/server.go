@@ -0,0 +1,196 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"golang.org/x/sync/singleflight"
+)
+
+// readinessCheckTimeout bounds a readiness check, which runs independently of the probe that
+// started it
+const readinessCheckTimeout = 30 * time.Second
+
+// Server exposes liveness, readiness and status endpoints for the drift detector server
+type Server struct {
+	app               service.DriftDetectorProvider
+	validateConfig    func() error
+	version           string
+	readinessInterval time.Duration
+	logger            *logging.Logger
+
+	httpServer *http.Server
+	listener   net.Listener
+
+	// Cached readiness check result. The check itself runs outside mu, since it lists instances
+	// from both providers; checks shares one in-flight check between concurrent probes.
+	mu        sync.Mutex
+	checkedAt time.Time
+	readyErr  error
+	checks    singleflight.Group
+}
+
+// StatusResponse is the body returned by the /status endpoint
+type StatusResponse struct {
+	Version string `json:"version"`
+
+	// Ready is the result of the last readiness check, taken at ReadyCheckedAt; it is false
+	// until /readyz has been probed
+	Ready          bool       `json:"ready"`
+	ReadyCheckedAt *time.Time `json:"ready_checked_at,omitempty"`
+
+	Scheduler model.SchedulerStatus `json:"scheduler"`
+}
+
+// NewServer creates a health server listening on the given port. Readiness validates the
+// configuration and checks that providers are reachable, at most once per readinessInterval.
+func NewServer(port int, app service.DriftDetectorProvider, validateConfig func() error, version string, readinessInterval time.Duration, logger *logging.Logger) *Server {
+	s := &Server{
+		app:               app,
+		validateConfig:    validateConfig,
+		version:           version,
+		readinessInterval: readinessInterval,
+		logger:            logger.WithField("component", "health-server"),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.HandleFunc("/status", s.handleStatus)
+
+	s.httpServer = &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	return s
+}
+
+// Start binds the listener and serves requests in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to start health server on %s", s.httpServer.Addr), err)
+	}
+	s.listener = listener
+
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.logger.Error(fmt.Sprintf("Health server stopped unexpectedly: %v", err))
+		}
+	}()
+
+	s.logger.Info(fmt.Sprintf("Health server listening on %s", listener.Addr()))
+	return nil
+}
+
+// Stop gracefully shuts the server down
+func (s *Server) Stop(ctx context.Context) error {
+	s.logger.Info("Stopping health server")
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		return errors.NewOperationalError("Failed to stop health server", err)
+	}
+	return nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.httpServer.Addr
+	}
+	return s.listener.Addr().String()
+}
+
+// handleHealthz reports that the process is up
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz reports whether the configuration is valid and the providers are reachable
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if err := s.checkReady(r.Context()); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// handleStatus reports the scheduler state, last run summary and version, with the result of
+// the last readiness check. It doesn't run the check, so polling it never contacts providers.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := StatusResponse{
+		Version:   s.version,
+		Scheduler: s.app.GetSchedulerStatus(),
+	}
+
+	s.mu.Lock()
+	if !s.checkedAt.IsZero() {
+		checkedAt := s.checkedAt
+		status.Ready = s.readyErr == nil
+		status.ReadyCheckedAt = &checkedAt
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// checkReady runs the readiness check, reusing the cached result within the readiness interval.
+// The check runs on its own context, so a probe that gives up early neither cancels the check
+// other probes share nor leaves its cancellation cached as the result; a check that is cut
+// short is never cached either.
+func (s *Server) checkReady(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.checkedAt.IsZero() && time.Since(s.checkedAt) < s.readinessInterval {
+		err := s.readyErr
+		s.mu.Unlock()
+		return err
+	}
+	s.mu.Unlock()
+
+	check := s.checks.DoChan("ready", func() (interface{}, error) {
+		checkCtx, cancel := context.WithTimeout(context.Background(), readinessCheckTimeout)
+		defer cancel()
+
+		err := s.validateConfig()
+		if err == nil {
+			err = s.app.CheckProviders(checkCtx)
+		}
+		if err != nil {
+			s.logger.Warn(fmt.Sprintf("Readiness check failed: %v", err))
+		}
+		if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		s.readyErr = err
+		s.checkedAt = time.Now()
+		s.mu.Unlock()
+		return nil, err
+	})
+
+	select {
+	case result := <-check:
+		return result.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,185 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/service"
+	"github.com/victor-devv/ec2-drift-detector/internal/presentation/health"
+)
+
+type stubDetector struct {
+	service.DriftDetectorProvider
+	providerErr error
+	checks      atomic.Int32
+	status      model.SchedulerStatus
+
+	// checking, when set, is signalled as a check starts, which then waits for release
+	checking chan struct{}
+	release  chan struct{}
+}
+
+func (s *stubDetector) CheckProviders(ctx context.Context) error {
+	s.checks.Add(1)
+	if s.checking != nil {
+		s.checking <- struct{}{}
+		<-s.release
+	}
+	return s.providerErr
+}
+
+func (s *stubDetector) GetSchedulerStatus() model.SchedulerStatus {
+	return s.status
+}
+
+func startServer(t *testing.T, detector *stubDetector, validate func() error) string {
+	server := health.NewServer(0, detector, validate, "1.2.3", time.Minute, logging.New())
+	require.NoError(t, server.Start())
+	t.Cleanup(func() { server.Stop(context.Background()) })
+	return "http://" + server.Addr()
+}
+
+func get(t *testing.T, url string) (int, map[string]interface{}) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp.StatusCode, body
+}
+
+func TestServer_Healthz(t *testing.T) {
+	base := startServer(t, &stubDetector{}, func() error { return nil })
+
+	code, body := get(t, base+"/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+}
+
+func TestServer_ReadyzCachesCheck(t *testing.T) {
+	detector := &stubDetector{}
+	base := startServer(t, detector, func() error { return nil })
+
+	code, _ := get(t, base+"/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = get(t, base+"/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	// Providers are only contacted once within the readiness interval
+	assert.Equal(t, int32(1), detector.checks.Load())
+}
+
+func TestServer_ReadyzChecksOutsideLock(t *testing.T) {
+	detector := &stubDetector{checking: make(chan struct{}), release: make(chan struct{})}
+	base := startServer(t, detector, func() error { return nil })
+
+	done := make(chan int)
+	go func() {
+		resp, err := http.Get(base + "/readyz")
+		if err != nil {
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+	<-detector.checking
+
+	// /status answers while the providers are being listed, without waiting for the check
+	code, body := get(t, base+"/status")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, body["ready"])
+
+	close(detector.release)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, int32(1), detector.checks.Load())
+}
+
+func TestServer_ReadyzOutlivesProbe(t *testing.T) {
+	detector := &stubDetector{checking: make(chan struct{}, 1), release: make(chan struct{})}
+	base := startServer(t, detector, func() error { return nil })
+
+	// The probe gives up while the providers are still being listed
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	_, err := client.Get(base + "/readyz")
+	require.Error(t, err)
+	<-detector.checking
+
+	// The check carries on and its result, not the probe's timeout, answers the next probe
+	close(detector.release)
+	code, _ := get(t, base+"/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int32(1), detector.checks.Load())
+}
+
+func TestServer_ReadyzDoesNotCacheTimeouts(t *testing.T) {
+	detector := &stubDetector{providerErr: fmt.Errorf("listing instances: %w", context.DeadlineExceeded)}
+	base := startServer(t, detector, func() error { return nil })
+
+	code, _ := get(t, base+"/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	// A check that was cut short is run again by the next probe
+	detector.providerErr = nil
+	code, _ = get(t, base+"/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int32(2), detector.checks.Load())
+}
+
+func TestServer_ReadyzFailures(t *testing.T) {
+	base := startServer(t, &stubDetector{}, func() error { return errors.New("state file missing") })
+	code, body := get(t, base+"/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body["error"], "state file missing")
+
+	detector := &stubDetector{providerErr: errors.New("AWS unreachable")}
+	base = startServer(t, detector, func() error { return nil })
+	code, body = get(t, base+"/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body["error"], "AWS unreachable")
+}
+
+func TestServer_Status(t *testing.T) {
+	next := time.Date(2026, 1, 1, 6, 0, 0, 0, time.UTC)
+	detector := &stubDetector{status: model.SchedulerStatus{
+		Running:            true,
+		ScheduleExpression: "0 */6 * * *",
+		NextRun:            &next,
+		LastRun:            &model.RunSummary{TotalInstances: 4, DriftedCount: 1},
+	}}
+	base := startServer(t, detector, func() error { return nil })
+
+	// Status reports the last readiness check without running one
+	_, body := get(t, base+"/status")
+	assert.Equal(t, false, body["ready"])
+	assert.NotContains(t, body, "ready_checked_at")
+	assert.Zero(t, detector.checks.Load())
+
+	code, _ := get(t, base+"/readyz")
+	require.Equal(t, http.StatusOK, code)
+
+	resp, err := http.Get(base + "/status")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var status health.StatusResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.Equal(t, "1.2.3", status.Version)
+	assert.True(t, status.Ready)
+	assert.NotNil(t, status.ReadyCheckedAt)
+	assert.True(t, status.Scheduler.Running)
+	assert.True(t, next.Equal(*status.Scheduler.NextRun))
+	assert.Equal(t, 4, status.Scheduler.LastRun.TotalInstances)
+	assert.Equal(t, 1, status.Scheduler.LastRun.DriftedCount)
+}
@@ -0,0 +1,115 @@
+package reporter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// CleanReportStatus is the status of a clean report
+const CleanReportStatus = "clean"
+
+// CleanReport confirms that a run found no drift: when it ran, which instances it checked and
+// which attributes it compared. Digest is the SHA-256 of the report without Digest and
+// Signature, and Signature its HMAC-SHA256 when a signing key is configured.
+type CleanReport struct {
+	Status         string          `json:"status"`
+	Timestamp      time.Time       `json:"timestamp"`
+	TotalInstances int             `json:"total_instances"`
+	Attributes     []string        `json:"attributes"`
+	Instances      []CleanInstance `json:"instances"`
+
+	Digest    string `json:"digest"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// CleanInstance is an instance a clean run checked
+type CleanInstance struct {
+	ResourceID      string    `json:"resource_id"`
+	ResourceAddress string    `json:"resource_address,omitempty"`
+	AccountID       string    `json:"account_id,omitempty"`
+	Workspace       string    `json:"workspace,omitempty"`
+	CheckedAt       time.Time `json:"checked_at"`
+}
+
+// NewCleanReport returns the clean report of a run's results, signed with signingKey unless it
+// is empty. It returns nil when any result has drift or violates a policy.
+func NewCleanReport(results []*model.DriftResult, attributes []string, timestamp time.Time, signingKey string) (*CleanReport, error) {
+	report := &CleanReport{
+		Status:         CleanReportStatus,
+		Timestamp:      timestamp,
+		TotalInstances: len(results),
+		Attributes:     append([]string{}, attributes...),
+		Instances:      make([]CleanInstance, 0, len(results)),
+	}
+	for _, result := range results {
+		if result.HasDrift || result.HasPolicyViolations() {
+			return nil, nil
+		}
+		report.Instances = append(report.Instances, CleanInstance{
+			ResourceID:      result.ResourceID,
+			ResourceAddress: result.ResourceAddress,
+			AccountID:       result.AccountID,
+			Workspace:       result.Workspace,
+			CheckedAt:       result.Timestamp,
+		})
+	}
+
+	payload, err := report.payload()
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(payload)
+	report.Digest = hex.EncodeToString(digest[:])
+	if signingKey != "" {
+		report.Signature = sign(payload, signingKey)
+	}
+	return report, nil
+}
+
+// Verify reports whether the report is unchanged since it was written: its digest matches and,
+// given the signing key, so does its signature
+func (r *CleanReport) Verify(signingKey string) bool {
+	payload, err := r.payload()
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256(payload)
+	if !hmac.Equal([]byte(r.Digest), []byte(hex.EncodeToString(digest[:]))) {
+		return false
+	}
+	if signingKey == "" {
+		return true
+	}
+	return hmac.Equal([]byte(r.Signature), []byte(sign(payload, signingKey)))
+}
+
+// payload returns the compact JSON the digest and signature are computed over
+func (r *CleanReport) payload() ([]byte, error) {
+	unsigned := *r
+	unsigned.Digest = ""
+	unsigned.Signature = ""
+	return json.Marshal(&unsigned)
+}
+
+// sign returns the hex HMAC-SHA256 of payload
+func sign(payload []byte, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// cleanReportFile derives the clean report path from the drift report path
+func cleanReportFile(outputFile string) string {
+	if outputFile == "" || outputFile == "stdout" {
+		return ""
+	}
+	ext := filepath.Ext(outputFile)
+	return strings.TrimSuffix(outputFile, ext) + "_clean" + ext
+}
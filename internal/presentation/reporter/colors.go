@@ -0,0 +1,74 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// ansiReset ends any ANSI color
+const ansiReset = "\033[0m"
+
+// ansiColors are the colors drifted attributes can be shown in, by name; none leaves them plain
+var ansiColors = map[string]string{
+	"red":     "\033[31m",
+	"green":   "\033[32m",
+	"yellow":  "\033[33m",
+	"blue":    "\033[34m",
+	"magenta": "\033[35m",
+	"cyan":    "\033[36m",
+	"white":   "\033[37m",
+	"bold":    "\033[1m",
+	"dim":     "\033[2m",
+	"none":    "",
+}
+
+// defaultSeverityColors show high severity drift in red, medium in yellow and low dimmed
+var defaultSeverityColors = map[string]string{
+	SeverityHigh:   "red",
+	SeverityMedium: "yellow",
+	SeverityLow:    "dim",
+}
+
+// ColorNames returns the names of the colors severities can be shown in, sorted
+func ColorNames() []string {
+	names := make([]string, 0, len(ansiColors))
+	for name := range ansiColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateSeverityColors checks that colors maps severities to known colors, as set in
+// reporter.console.severity_colors
+func ValidateSeverityColors(colors map[string]string) error {
+	for severity, color := range colors {
+		if _, ok := defaultSeverityColors[strings.ToLower(severity)]; !ok {
+			return errors.NewValidationError(fmt.Sprintf("Invalid severity %q in reporter.console.severity_colors: expected %s, %s or %s", severity, SeverityHigh, SeverityMedium, SeverityLow))
+		}
+		if _, ok := ansiColors[strings.ToLower(color)]; !ok {
+			return errors.NewValidationError(fmt.Sprintf("Invalid color %q for %s severity in reporter.console.severity_colors: expected one of %s", color, severity, strings.Join(ColorNames(), ", ")))
+		}
+	}
+	return nil
+}
+
+// severityCodes returns the ANSI codes of each severity, with colors overriding the defaults.
+// Unknown severities and colors are ignored; see ValidateSeverityColors.
+func severityCodes(colors map[string]string) map[string]string {
+	codes := make(map[string]string, len(defaultSeverityColors))
+	for severity, color := range defaultSeverityColors {
+		codes[severity] = ansiColors[color]
+	}
+	for severity, color := range colors {
+		severity = strings.ToLower(severity)
+		code, ok := ansiColors[strings.ToLower(color)]
+		if _, known := defaultSeverityColors[severity]; known && ok {
+			codes[severity] = code
+		}
+	}
+	return codes
+}
@@ -2,26 +2,49 @@ package reporter
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/pkg/comparator"
 )
 
 // ConsoleReporter is an implementation of the Reporter interface that reports to the console
 type ConsoleReporter struct {
-	logger  *logging.Logger
-	colored bool
+	logger   *logging.Logger
+	options  ReporterOptions
+	colored  bool
+	template *template.Template
+	location *time.Location
+	out      io.Writer
+
+	// severityColors are the ANSI codes drifted attributes are shown in, by severity
+	severityColors map[string]string
+}
+
+// NewConsoleReporter creates a new console reporter using the built-in run report template
+func NewConsoleReporter(logger *logging.Logger, options ReporterOptions) *ConsoleReporter {
+	return NewConsoleReporterWithTemplate(logger, options, mustLoadBuiltinTemplate(TemplateConsole))
 }
 
-// NewConsoleReporter creates a new console reporter
-func NewConsoleReporter(logger *logging.Logger) *ConsoleReporter {
+// NewConsoleReporterWithTemplate creates a new console reporter rendering run reports with tmpl,
+// as returned by LoadTemplate. Reports always go to stdout, whatever options.OutputFile names.
+// Color is disabled when the NO_COLOR environment variable is set.
+func NewConsoleReporterWithTemplate(logger *logging.Logger, options ReporterOptions, tmpl *template.Template) *ConsoleReporter {
 	return &ConsoleReporter{
-		logger:  logger.WithField("component", "console-reporter"),
-		colored: true,
+		logger:         logger.WithField("component", "console-reporter"),
+		options:        options,
+		colored:        os.Getenv("NO_COLOR") == "",
+		template:       tmpl,
+		location:       time.Local,
+		out:            os.Stdout,
+		severityColors: severityCodes(nil),
 	}
 }
 
@@ -32,11 +55,39 @@ func (r *ConsoleReporter) ReportDrift(result *model.DriftResult) error {
 	fmt.Println(r.formatHeader("Drift Detection Report"))
 	fmt.Println()
 	fmt.Printf("Instance ID: %s\n", result.ResourceID)
+	fmt.Printf("Result ID: %s\n", result.ID)
+	if result.NameDrifted() {
+		fmt.Printf("Name: %s\n", r.formatError(fmt.Sprintf("%s → %s", result.SourceName, result.TargetName)))
+	} else if name := result.Name(); name != "" {
+		fmt.Printf("Name: %s\n", name)
+	}
 	fmt.Printf("Source Type: %s\n", result.SourceType)
-	fmt.Printf("Timestamp: %s\n", result.Timestamp.Format(time.RFC3339))
+	fmt.Printf("Timestamp: %s\n", result.Timestamp.In(r.location).Format(time.RFC3339))
+	if len(result.DuplicateAddresses) > 0 {
+		fmt.Println(r.formatWarning(fmt.Sprintf("Also tracked by Terraform resources %s; only %s was compared", strings.Join(result.DuplicateAddresses, ", "), result.ResourceAddress)))
+	}
 	fmt.Printf("Has Drift: %s\n", r.formatBool(result.HasDrift))
+	if result.Unmanaged {
+		fmt.Println(r.formatWarning("Unmanaged: exists in AWS but no Terraform resource manages it"))
+	}
 	fmt.Println()
 
+	if len(result.SkippedAttributes) > 0 {
+		fmt.Println(r.formatWarning("Skipped Attributes (unknown values):"))
+		for path, reason := range result.SkippedAttributes {
+			fmt.Printf("  %s: %s\n", path, reason)
+		}
+		fmt.Println()
+	}
+
+	if result.HasPolicyViolations() {
+		fmt.Println(r.formatError("Policy Violations:"))
+		for _, violation := range result.PolicyViolations {
+			fmt.Printf("  %s\n", violation)
+		}
+		fmt.Println()
+	}
+
 	if !result.HasDrift {
 		fmt.Println(r.formatSuccess("No drift detected."))
 		return nil
@@ -45,72 +96,107 @@ func (r *ConsoleReporter) ReportDrift(result *model.DriftResult) error {
 	fmt.Println(r.formatHeader("Drifted Attributes"))
 	fmt.Println()
 
-	// Create a tabwriter for aligned output
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Attribute\tSource Value\tTarget Value")
-	fmt.Fprintln(w, "---------\t------------\t------------")
+	desiredLabel, currentLabel := result.ValueLabels()
+	fmt.Printf("Attribute: %s (desired) => %s (current)\n", desiredLabel, currentLabel)
+	paths := make([]string, 0, len(result.DriftedAttributes))
+	for path := range result.DriftedAttributes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		entry := result.DriftedAttributes[path].DiffEntry()
+		entry.Path = path
+		if from := result.DriftedAttributes[path].TerraformAttribute; from != "" {
+			entry.Path = fmt.Sprintf("%s (from %s)", path, from)
+		}
+		drift := result.DriftedAttributes[path]
+		fmt.Printf("  %s%s\n", r.formatDrift(entry, model.AttributeSeverity(path, drift)), r.driftAge(drift))
+	}
+	fmt.Println()
 
+	// Attributes compared by hash carry a diff when verbose output is enabled
 	for path, drift := range result.DriftedAttributes {
-		fmt.Fprintf(w, "%s\t%v\t%v\n", path, drift.SourceValue, drift.TargetValue)
+		if drift.Diff != "" {
+			fmt.Printf("Diff for %s:\n%s\n", path, drift.Diff)
+		}
+	}
+
+	// Subnet drift carries the subnets on either side when network context is enriched
+	if drift, ok := result.DriftedAttributes[model.AttributeSubnetID]; ok && drift.Network != nil {
+		message := fmt.Sprintf("%s %s", model.AttributeSubnetID, drift.Network)
+		if drift.Network.CrossVPC {
+			message = r.formatError(message)
+		}
+		fmt.Println(message)
+		fmt.Println()
+	}
+
+	// Termination protection drift explains how the two protections differ
+	if drift, ok := result.DriftedAttributes[model.AttributeTerminationProtection]; ok && drift.Protection != nil {
+		fmt.Printf("%s: %s\n\n", model.AttributeTerminationProtection, drift.Protection.Note)
 	}
-	w.Flush()
-	fmt.Println()
 
 	return nil
 }
 
-// ReportMultipleDrifts reports multiple drift detection results
+// ReportMultipleDrifts renders the run report template with the results and their attribute
+// summary
 func (r *ConsoleReporter) ReportMultipleDrifts(results []*model.DriftResult) error {
 	r.logger.Info(fmt.Sprintf("Reporting drift for %d instances", len(results)))
+	summary := model.SummarizeAttributes(results, model.AttributeSampleSize)
 
-	fmt.Println(r.formatHeader("Drift Detection Summary"))
-	fmt.Println()
-	fmt.Printf("Number of Instances: %d\n", len(results))
-
-	// Count instances with drift
-	var driftCount int
-	for _, result := range results {
-		if result.HasDrift {
-			driftCount++
-		}
+	// Tab-separated cells in the template are aligned into columns
+	w := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	if err := renderTemplate(w, r.template, NewReportView(results, summary, time.Now()).In(r.location), r.colored); err != nil {
+		return err
 	}
+	return w.Flush()
+}
+
+// ReportOrphans reports AWS resources that no Terraform instance references
+func (r *ConsoleReporter) ReportOrphans(orphans []*model.OrphanResult) error {
+	r.logger.Info(fmt.Sprintf("Reporting %d orphaned resources", len(orphans)))
 
-	fmt.Printf("Instances with Drift: %s (%d/%d)\n", r.formatBool(driftCount > 0), driftCount, len(results))
+	fmt.Println(r.formatHeader("Orphaned Resources"))
 	fmt.Println()
 
-	if driftCount == 0 {
-		fmt.Println(r.formatSuccess("No drift detected in any instance."))
+	if len(orphans) == 0 {
+		fmt.Println(r.formatSuccess("No orphaned resources found."))
+		fmt.Println()
 		return nil
 	}
 
-	fmt.Println(r.formatHeader("Instances with Drift"))
-	fmt.Println()
-
-	// Create a tabwriter for aligned output
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Instance ID\tDrifted Attributes\tTimestamp")
-	fmt.Fprintln(w, "-----------\t------------------\t---------")
-
-	for _, result := range results {
-		if result.HasDrift {
-			attrs := make([]string, 0, len(result.DriftedAttributes))
-			for path := range result.DriftedAttributes {
-				attrs = append(attrs, path)
-			}
-			attrsStr := strings.Join(attrs, ", ")
-			fmt.Fprintf(w, "%s\t%s\t%s\n", result.ResourceID, attrsStr, result.Timestamp.Format(time.RFC3339))
+	fmt.Fprintln(w, "Type\tResource\tReason")
+	fmt.Fprintln(w, "----\t--------\t------")
+	for _, orphan := range orphans {
+		label := orphan.ResourceID
+		if orphan.Name != "" {
+			label = fmt.Sprintf("%s (%s)", orphan.Name, orphan.ResourceID)
 		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", orphan.ResourceType, label, orphan.Reason)
 	}
 	w.Flush()
 	fmt.Println()
 
-	// Prompt to show details
-	fmt.Println("Use 'drift-detector show <instance-id>' to see detailed drift information for a specific instance.")
-	fmt.Println()
-
 	return nil
 }
 
+// formatDrift renders a drifted attribute with the colored diff formatter, wrapped in the color
+// of its severity, e.g. high severity drift in red, or plain when color is disabled
+func (r *ConsoleReporter) formatDrift(entry comparator.DiffEntry, severity string) string {
+	if !r.colored {
+		return comparator.PlainFormatter{}.FormatDiff(entry)
+	}
+	row := comparator.ColorFormatter{}.FormatDiff(entry)
+	code, ok := r.severityColors[severity]
+	if !ok || code == "" {
+		return row
+	}
+	// Resume the severity color after each part the formatter colors on its own
+	return code + strings.ReplaceAll(row, ansiReset, ansiReset+code) + ansiReset
+}
+
 // formatHeader formats a header string
 func (r *ConsoleReporter) formatHeader(text string) string {
 	if r.colored {
@@ -150,6 +236,18 @@ func (r *ConsoleReporter) formatError(text string) string {
 	return text
 }
 
+// driftAge describes how long a drift has lasted, e.g. " (drifting 45d, since 2024-05-01)", or
+// is empty when drift age isn't tracked
+func (r *ConsoleReporter) driftAge(drift model.AttributeDrift) string {
+	if drift.FirstDetected.IsZero() {
+		return ""
+	}
+	if drift.DriftAge < time.Minute {
+		return " (new)"
+	}
+	return fmt.Sprintf(" (drifting %s, since %s)", drift.AgeLabel(), drift.FirstDetected.In(r.location).Format(time.DateOnly))
+}
+
 // formatWarning formats a warning message
 func (r *ConsoleReporter) formatWarning(text string) string {
 	if r.colored {
@@ -158,6 +256,16 @@ func (r *ConsoleReporter) formatWarning(text string) string {
 	return text
 }
 
+// SetLocation sets the time zone timestamps are shown in
+func (r *ConsoleReporter) SetLocation(loc *time.Location) {
+	r.location = loc
+}
+
+// Options returns the options the reporter was created with
+func (r *ConsoleReporter) Options() ReporterOptions {
+	return r.options
+}
+
 // IsColorEnabled returns whether color is enabled
 func (r *ConsoleReporter) IsColorEnabled() bool {
 	return r.colored
@@ -167,3 +275,9 @@ func (r *ConsoleReporter) IsColorEnabled() bool {
 func (r *ConsoleReporter) SetColorEnabled(enabled bool) {
 	r.colored = enabled
 }
+
+// SetSeverityColors sets the colors drifted attributes are shown in by severity, e.g.
+// {"low": "none"}, overriding the defaults of high in red, medium in yellow and low dimmed
+func (r *ConsoleReporter) SetSeverityColors(colors map[string]string) {
+	r.severityColors = severityCodes(colors)
+}
@@ -6,11 +6,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/pkg/comparator"
 )
 
 func TestConsoleReporter_ReportDrift(t *testing.T) {
 	// Create a console reporter without color for consistent testing
-	reporter := NewConsoleReporter(logging.New())
+	reporter := NewConsoleReporter(logging.New(), ReporterOptions{})
 
 	// Create a drift result with no drift
 	result := model.NewDriftResult("i-12345", model.OriginTerraform)
@@ -38,7 +39,7 @@ func TestConsoleReporter_ReportDrift(t *testing.T) {
 
 func TestConsoleReporter_ReportMultipleDrifts(t *testing.T) {
 	// Create a console reporter
-	reporter := NewConsoleReporter(logging.New())
+	reporter := NewConsoleReporter(logging.New(), ReporterOptions{})
 
 	// Create multiple drift results
 	results := []*model.DriftResult{
@@ -70,8 +71,8 @@ func TestConsoleReporter_ReportMultipleDrifts(t *testing.T) {
 
 func TestConsoleReporter_Format(t *testing.T) {
 	// Create reporters with and without color
-	plainReporter := NewConsoleReporter(logging.New())
-	colorReporter := NewConsoleReporter(logging.New())
+	plainReporter := NewConsoleReporter(logging.New(), ReporterOptions{})
+	colorReporter := NewConsoleReporter(logging.New(), ReporterOptions{})
 
 	// Test formatHeader
 	plainHeader := plainReporter.formatHeader("Test Header")
@@ -114,3 +115,52 @@ func TestConsoleReporter_Format(t *testing.T) {
 	assert.Contains(t, colorWarning, "Warning")
 	assert.Contains(t, colorWarning, "\033[") // Contains ANSI color codes
 }
+
+func TestConsoleReporter_ReportsStoredValueForms(t *testing.T) {
+	reporter := NewConsoleReporter(logging.New(), ReporterOptions{})
+
+	result := model.NewDriftResult("i-12345", model.OriginTerraform)
+	result.AddDriftedAttribute("user_data",
+		model.TruncatedValue{Type: "string", Value: "#!/bin/bash", Size: 4096, Truncated: true},
+		model.HashedValue{Type: "string", SHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"})
+
+	assert.NoError(t, reporter.ReportDrift(result))
+	assert.NoError(t, reporter.ReportMultipleDrifts([]*model.DriftResult{result}))
+}
+
+func TestConsoleReporter_SeverityColors(t *testing.T) {
+	reporter := NewConsoleReporter(logging.New(), ReporterOptions{})
+	reporter.SetColorEnabled(true)
+	entry := model.NewAttributeDrift("ami", "ami-1", "ami-2").DiffEntry()
+
+	// Each severity has its own color around the colored diff: the path in bold, the source in
+	// green and the target in red
+	row := func(code string) string {
+		return code + "\033[1mami\033[0m" + code + ": \033[32mami-1\033[0m" + code + " => \033[31mami-2\033[0m" + code + "\033[0m"
+	}
+	assert.Equal(t, row("\033[31m"), reporter.formatDrift(entry, SeverityHigh))
+	assert.Equal(t, row("\033[33m"), reporter.formatDrift(entry, SeverityMedium))
+	assert.Equal(t, row("\033[2m"), reporter.formatDrift(entry, SeverityLow))
+
+	// Configured colors override the defaults they name, in any case
+	reporter.SetSeverityColors(map[string]string{"medium": "Cyan", "low": "none"})
+	assert.Equal(t, row("\033[31m"), reporter.formatDrift(entry, SeverityHigh))
+	assert.Equal(t, row("\033[36m"), reporter.formatDrift(entry, SeverityMedium))
+	assert.Equal(t, comparator.ColorFormatter{}.FormatDiff(entry), reporter.formatDrift(entry, SeverityLow))
+
+	// Without color every severity is plain
+	reporter.SetColorEnabled(false)
+	assert.Equal(t, "ami: ami-1 => ami-2", reporter.formatDrift(entry, SeverityHigh))
+}
+
+func TestConsoleReporter_NoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, NewConsoleReporter(logging.New(), ReporterOptions{}).IsColorEnabled())
+}
+
+func TestValidateSeverityColors(t *testing.T) {
+	assert.NoError(t, ValidateSeverityColors(nil))
+	assert.NoError(t, ValidateSeverityColors(map[string]string{"high": "magenta", "LOW": "Dim"}))
+	assert.ErrorContains(t, ValidateSeverityColors(map[string]string{"critical": "red"}), `Invalid severity "critical"`)
+	assert.ErrorContains(t, ValidateSeverityColors(map[string]string{"high": "orange"}), `Invalid color "orange" for high severity`)
+}
@@ -0,0 +1,130 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+)
+
+// Default HTTP sender settings
+const (
+	DefaultHTTPTimeout      = 10 * time.Second
+	DefaultHTTPMaxRetries   = 3
+	DefaultHTTPRetryBackoff = time.Second
+)
+
+// HTTPSenderOptions controls how reporters that push to HTTP endpoints deliver payloads
+type HTTPSenderOptions struct {
+	// Timeout bounds each request attempt
+	Timeout time.Duration
+
+	// MaxRetries is the number of retries after the first attempt for transient failures
+	// (network errors, 429 and 5xx responses)
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry; it doubles on every retry
+	RetryBackoff time.Duration
+
+	// ProxyURL routes requests through a proxy. When empty the HTTP(S)_PROXY environment
+	// variables are honoured.
+	ProxyURL string
+}
+
+// HTTPSender posts JSON payloads with retries and proxy support. It is shared by the
+// webhook-based reporters.
+type HTTPSender struct {
+	client  *http.Client
+	options HTTPSenderOptions
+	logger  *logging.Logger
+}
+
+// NewHTTPSender creates a new HTTP sender, filling in defaults for unset options
+func NewHTTPSender(logger *logging.Logger, options HTTPSenderOptions) (*HTTPSender, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultHTTPTimeout
+	}
+	if options.MaxRetries < 0 {
+		options.MaxRetries = 0
+	}
+	if options.RetryBackoff <= 0 {
+		options.RetryBackoff = DefaultHTTPRetryBackoff
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if options.ProxyURL != "" {
+		proxyURL, err := url.Parse(options.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, errors.NewValidationError(fmt.Sprintf("Invalid proxy URL %q", options.ProxyURL))
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+
+	return &HTTPSender{
+		client:  &http.Client{Transport: transport, Timeout: options.Timeout},
+		options: options,
+		logger:  logger.WithField("component", "http-sender"),
+	}, nil
+}
+
+// PostJSON posts the payload to the endpoint, retrying transient failures with
+// exponential backoff
+func (s *HTTPSender) PostJSON(ctx context.Context, endpoint string, payload []byte) error {
+	backoff := s.options.RetryBackoff
+
+	var lastErr error
+	for attempt := 0; attempt <= s.options.MaxRetries; attempt++ {
+		if attempt > 0 {
+			s.logger.Warn(fmt.Sprintf("Retrying request in %s (attempt %d of %d): %v", backoff, attempt+1, s.options.MaxRetries+1, lastErr))
+			select {
+			case <-ctx.Done():
+				return errors.NewOperationalError("Request cancelled before retry", ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		retry, err := s.post(ctx, endpoint, payload)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+
+	return errors.NewOperationalError("Failed to post payload", lastErr)
+}
+
+// post makes a single request attempt and reports whether a failure is worth retrying
+func (s *HTTPSender) post(ctx context.Context, endpoint string, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
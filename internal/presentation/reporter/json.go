@@ -1,23 +1,43 @@
 package reporter
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/pkg/comparator"
 	"github.com/victor-devv/ec2-drift-detector/pkg/utils"
 )
 
 // JSONReporter is an implementation of the Reporter interface that reports to JSON files
 type JSONReporter struct {
-	logger      *logging.Logger
-	outputFile  string
-	prettyPrint bool
+	logger  *logging.Logger
+	options ReporterOptions
+	clock   clock.Clock
+
+	// maxResultsPerFile splits reports with more results across several files; 0 never splits
+	maxResultsPerFile int
+
+	// maxBytes caps the size of each report file, dropping the results that don't fit; 0 never
+	// truncates
+	maxBytes int
+
+	// cleanReport writes a clean report for runs without drift, listing attributes as compared
+	// and signed with signingKey unless it is empty
+	cleanReport bool
+	attributes  []string
+	signingKey  string
 }
 
 // JSONReport represents the structure of a JSON report
@@ -26,17 +46,125 @@ type JSONReport struct {
 	TotalInstances int                  `json:"total_instances"`
 	DriftedCount   int                  `json:"drifted_count"`
 	Results        []*model.DriftResult `json:"results"`
+
+	// UnmanagedCount is the number of instances in AWS no Terraform resource manages; they are
+	// left out of DriftedCount
+	UnmanagedCount int `json:"unmanaged_count,omitempty"`
+
+	// Accounts aggregates results per AWS account when scanning multiple accounts
+	Accounts map[string]model.AccountSummary `json:"accounts,omitempty"`
+
+	// Workspaces aggregates results per Terraform workspace when comparing several workspaces
+	Workspaces map[string]model.WorkspaceSummary `json:"workspaces,omitempty"`
+
+	// AttributeSummary aggregates drift per attribute across all instances
+	AttributeSummary []model.AttributeSummary `json:"attribute_summary,omitempty"`
+
+	// Part and Parts number the files of a report split by reporter.json.max_results_per_file
+	Part  int `json:"part,omitempty"`
+	Parts int `json:"parts,omitempty"`
+
+	// Truncated is set when results were left out to keep the report within reporter.max_bytes;
+	// the counts and summaries above still cover every result
+	Truncated      bool `json:"truncated,omitempty"`
+	OmittedResults int  `json:"omitted_results,omitempty"`
 }
 
-// NewJSONReporter creates a new JSON reporter
-func NewJSONReporter(logger *logging.Logger, outputFile string) *JSONReporter {
-	if outputFile != "" {
-		outputFile = utils.AppendUniqueSuffix(outputFile)
+// MarshalJSON encodes the report as it is written, with each result's drifted attributes also
+// rendered as diffs
+func (r JSONReport) MarshalJSON() ([]byte, error) {
+	// plain has the report's fields without this method
+	type plain JSONReport
+	header := plain(r)
+	header.Results = []*model.DriftResult{}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]jsonResult, 0, len(r.Results))
+	for _, result := range r.Results {
+		results = append(results, newJSONResult(result))
+	}
+	encoded, err := json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+
+	// Results precede every field whose values could hold the placeholder
+	head, tail, ok := bytes.Cut(data, []byte(`"results":[]`))
+	if !ok {
+		return nil, fmt.Errorf("results missing from the encoded report")
+	}
+	out := append(append(append([]byte{}, head...), `"results":`...), encoded...)
+	return append(out, tail...), nil
+}
+
+// jsonResult is a result as written to JSON reports. Diffs lists its drifted attributes in path
+// order as comparator.JSONFormatter renders them, the structured counterpart of the rows the
+// console reporter prints.
+type jsonResult struct {
+	*model.DriftResult
+	Diffs []json.RawMessage `json:"diffs,omitempty"`
+}
+
+// newJSONResult renders the diffs of a result for a JSON report
+func newJSONResult(result *model.DriftResult) jsonResult {
+	paths := make([]string, 0, len(result.DriftedAttributes))
+	for path := range result.DriftedAttributes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	formatter := comparator.JSONFormatter{}
+	diffs := make([]json.RawMessage, 0, len(paths))
+	for _, path := range paths {
+		diffs = append(diffs, json.RawMessage(formatter.FormatDiff(result.DriftedAttributes[path].DiffEntry())))
+	}
+	return jsonResult{DriftResult: result, Diffs: diffs}
+}
+
+// JSONManifest lists the files a split report was written to, with the run's aggregate counts
+type JSONManifest struct {
+	Timestamp      time.Time                         `json:"timestamp"`
+	TotalInstances int                               `json:"total_instances"`
+	DriftedCount   int                               `json:"drifted_count"`
+	UnmanagedCount int                               `json:"unmanaged_count,omitempty"`
+	Accounts       map[string]model.AccountSummary   `json:"accounts,omitempty"`
+	Workspaces     map[string]model.WorkspaceSummary `json:"workspaces,omitempty"`
+	Parts          []JSONManifestPart                `json:"parts"`
+}
+
+// JSONManifestPart is a file of a split report
+type JSONManifestPart struct {
+	File         string `json:"file"`
+	Results      int    `json:"results"`
+	DriftedCount int    `json:"drifted_count"`
+}
+
+// OrphanReport represents the structure of a JSON orphaned resources report
+type OrphanReport struct {
+	Timestamp    time.Time             `json:"timestamp"`
+	TotalOrphans int                   `json:"total_orphans"`
+	Orphans      []*model.OrphanResult `json:"orphans"`
+}
+
+// NewJSONReporter creates a new JSON reporter writing to options.OutputFile
+func NewJSONReporter(logger *logging.Logger, options ReporterOptions) *JSONReporter {
+	return NewJSONReporterWithClock(logger, options, clock.Real())
+}
+
+// NewJSONReporterWithClock creates a new JSON reporter that takes report timestamps
+// and the output file suffix from the given clock
+func NewJSONReporterWithClock(logger *logging.Logger, options ReporterOptions, clk clock.Clock) *JSONReporter {
+	clk = clock.OrReal(clk)
+	if options.OutputFile != "" {
+		options.OutputFile = utils.AppendTimestampSuffix(options.OutputFile, clk.Now())
 	}
 	return &JSONReporter{
-		logger:      logger.WithField("component", "json-reporter"),
-		outputFile:  outputFile,
-		prettyPrint: true,
+		logger:  logger.WithField("component", "json-reporter"),
+		options: options,
+		clock:   clk,
 	}
 }
 
@@ -46,9 +174,10 @@ func (r *JSONReporter) ReportDrift(result *model.DriftResult) error {
 
 	// Create a report with a single result
 	report := &JSONReport{
-		Timestamp:      time.Now(),
+		Timestamp:      r.clock.Now(),
 		TotalInstances: 1,
-		DriftedCount:   boolToInt(result.HasDrift),
+		DriftedCount:   boolToInt(result.IsManagedDrift()),
+		UnmanagedCount: boolToInt(result.Unmanaged),
 		Results:        []*model.DriftResult{result},
 	}
 
@@ -56,91 +185,419 @@ func (r *JSONReporter) ReportDrift(result *model.DriftResult) error {
 	return r.writeReport(report)
 }
 
-// ReportMultipleDrifts reports multiple drift detection results
+// ReportMultipleDrifts reports multiple drift detection results with their attribute summary
 func (r *JSONReporter) ReportMultipleDrifts(results []*model.DriftResult) error {
 	r.logger.Info(fmt.Sprintf("Reporting drift for %d instances to JSON file", len(results)))
+	summary := model.SummarizeAttributes(results, model.AttributeSampleSize)
 
-	// Count instances with drift
-	var driftCount int
+	// Count instances with drift, apart from unmanaged instances
+	var driftCount, unmanagedCount int
 	for _, result := range results {
-		if result.HasDrift {
-			driftCount++
-		}
+		driftCount += boolToInt(result.IsManagedDrift())
+		unmanagedCount += boolToInt(result.Unmanaged)
 	}
 
 	// Create a report with multiple results
 	report := &JSONReport{
-		Timestamp:      time.Now(),
+		Timestamp:      r.clock.Now(),
 		TotalInstances: len(results),
 		DriftedCount:   driftCount,
+		UnmanagedCount: unmanagedCount,
 		Results:        results,
+		Accounts:       model.SummarizeByAccount(results),
+		Workspaces:     model.SummarizeByWorkspace(results),
+
+		AttributeSummary: summary,
 	}
 
 	// Write the report to the output file
-	return r.writeReport(report)
+	if err := r.writeReport(report); err != nil {
+		return err
+	}
+	return r.writeCleanReport(results, report.Timestamp)
+}
+
+// writeCleanReport writes a clean report next to the drift report when enabled and none of the
+// results has drift
+func (r *JSONReporter) writeCleanReport(results []*model.DriftResult, timestamp time.Time) error {
+	if !r.cleanReport {
+		return nil
+	}
+
+	clean, err := NewCleanReport(results, r.attributes, timestamp, r.signingKey)
+	if err != nil {
+		return errors.NewOperationalError("Failed to create clean report", err)
+	}
+	if clean == nil {
+		return nil
+	}
+
+	r.logger.Info(fmt.Sprintf("No drift found in %d instances, writing clean report", len(results)))
+	return r.writeJSON(clean, cleanReportFile(r.options.OutputFile))
+}
+
+// ReportOrphans writes orphaned resources to a report next to the drift report
+func (r *JSONReporter) ReportOrphans(orphans []*model.OrphanResult) error {
+	r.logger.Info(fmt.Sprintf("Reporting %d orphaned resources to JSON file", len(orphans)))
+
+	if orphans == nil {
+		orphans = []*model.OrphanResult{}
+	}
+
+	report := &OrphanReport{
+		Timestamp:    r.clock.Now(),
+		TotalOrphans: len(orphans),
+		Orphans:      orphans,
+	}
+
+	return r.writeJSON(report, orphanReportFile(r.options.OutputFile))
+}
+
+// orphanReportFile derives the orphan report path from the drift report path
+func orphanReportFile(outputFile string) string {
+	if outputFile == "" || outputFile == "stdout" {
+		return ""
+	}
+	ext := filepath.Ext(outputFile)
+	return strings.TrimSuffix(outputFile, ext) + "_orphans" + ext
 }
 
-// writeReport writes a report to the output file
+// writeReport writes a report to the output file, split across several files when it has
+// more results than maxResultsPerFile
 func (r *JSONReporter) writeReport(report *JSONReport) error {
-	if r.outputFile != "" {
+	if r.options.OutputFile == "stdout" {
+		r.options.OutputFile = ""
+	}
+
+	split := r.maxResultsPerFile > 0 && len(report.Results) > r.maxResultsPerFile
+	if split && r.options.OutputFile == "" {
+		r.logger.Debug(fmt.Sprintf("Writing all %d results to stdout; reports are only split across files", len(report.Results)))
+		split = false
+	}
+
+	var err error
+	if split {
+		err = r.writeParts(report)
+	} else if report, err = r.truncateReport(report); err == nil {
+		err = r.writeJSON(report, r.options.OutputFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.options.OutputFile == "" {
+		r.options.OutputFile = "stdout"
+	}
+	return nil
+}
+
+// writeParts writes a report's results to files of at most maxResultsPerFile results, each
+// with the run's header, and a manifest listing them
+func (r *JSONReporter) writeParts(report *JSONReport) error {
+	count := (len(report.Results) + r.maxResultsPerFile - 1) / r.maxResultsPerFile
+	manifest := &JSONManifest{
+		Timestamp:      report.Timestamp,
+		TotalInstances: report.TotalInstances,
+		DriftedCount:   report.DriftedCount,
+		UnmanagedCount: report.UnmanagedCount,
+		Accounts:       report.Accounts,
+		Workspaces:     report.Workspaces,
+		Parts:          make([]JSONManifestPart, 0, count),
+	}
+
+	for i := 0; i < count; i++ {
+		start := i * r.maxResultsPerFile
+		end := min(start+r.maxResultsPerFile, len(report.Results))
+
+		part := *report
+		part.Results = report.Results[start:end]
+		part.Part = i + 1
+		part.Parts = count
+		written, err := r.truncateReport(&part)
+		if err != nil {
+			return err
+		}
+
+		file := partFile(r.options.OutputFile, i+1)
+		if err := r.writeJSON(written, file); err != nil {
+			return err
+		}
+
+		drifted := 0
+		for _, result := range written.Results {
+			drifted += boolToInt(result.IsManagedDrift())
+		}
+		manifest.Parts = append(manifest.Parts, JSONManifestPart{
+			File:         filepath.Base(file),
+			Results:      len(written.Results),
+			DriftedCount: drifted,
+		})
+	}
+
+	return r.writeJSON(manifest, manifestFile(r.options.OutputFile))
+}
+
+// partFile derives the path of a report part from the report path, e.g. report-001.json
+func partFile(outputFile string, part int) string {
+	ext := filepath.Ext(outputFile)
+	return fmt.Sprintf("%s-%03d%s", strings.TrimSuffix(outputFile, ext), part, ext)
+}
+
+// manifestFile derives the path of a split report's manifest from the report path
+func manifestFile(outputFile string) string {
+	ext := filepath.Ext(outputFile)
+	return strings.TrimSuffix(outputFile, ext) + "-manifest" + ext
+}
+
+// writeJSON encodes a report and writes it to outputFile, or stdout when outputFile is empty
+func (r *JSONReporter) writeJSON(report interface{}, outputFile string) error {
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if outputFile != "" {
 		// Create the output directory if it doesn't exist
-		dir := filepath.Dir(r.outputFile)
+		dir := filepath.Dir(outputFile)
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return errors.NewOperationalError(fmt.Sprintf("Failed to create output directory %s", dir), err)
 		}
+
+		var err error
+		file, err = os.Create(outputFile)
+		if err != nil {
+			return errors.NewOperationalError(fmt.Sprintf("Failed to write report to %s", outputFile), err)
+		}
+		defer file.Close()
+		out = file
+	} else {
+		outputFile = "stdout"
 	}
 
-	// Encode the report to JSON
-	var data []byte
+	w := bufio.NewWriter(out)
 	var err error
-	if r.prettyPrint {
-		data, err = json.MarshalIndent(report, "", "  ")
+	if drift, ok := report.(*JSONReport); ok {
+		err = r.encodeReport(w, drift)
 	} else {
-		data, err = json.Marshal(report)
+		err = r.newEncoder(w, "").Encode(report)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil && file != nil {
+		err = file.Close()
 	}
 	if err != nil {
-		return errors.NewOperationalError("Failed to marshal report to JSON", err)
+		return errors.NewOperationalError(fmt.Sprintf("Failed to write report to %s", outputFile), err)
 	}
 
-	if r.outputFile != "" {
-		// Write the report to the output file
-		if err := os.WriteFile(r.outputFile, data, 0644); err != nil {
-			return errors.NewOperationalError(fmt.Sprintf("Failed to write report to %s", r.outputFile), err)
+	r.logger.Info(fmt.Sprintf("Successfully written report to %s", outputFile))
+	return nil
+}
+
+// truncateReport keeps a report within maxBytes by leaving out the results past the last one
+// that fits, flagging the report as truncated. The report is returned as-is when it fits or
+// maxBytes is 0.
+func (r *JSONReporter) truncateReport(report *JSONReport) (*JSONReport, error) {
+	if r.maxBytes <= 0 {
+		return report, nil
+	}
+
+	head, tail, err := r.encodeHeader(report)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make([]int, len(report.Results))
+	total := len(head) + len(tail) + len(r.resultsEnd(len(report.Results)))
+	encoder := r.newResultEncoder()
+	for i, result := range report.Results {
+		item, err := encoder.encode(i, result)
+		if err != nil {
+			return nil, err
 		}
+		sizes[i] = len(item)
+		total += sizes[i]
+	}
+	if total <= r.maxBytes {
+		return report, nil
+	}
+
+	// Size the header as if every result were omitted, which is at least as long as the
+	// header of any cut
+	truncated := *report
+	truncated.Truncated = true
+	truncated.OmittedResults = len(report.Results)
+	head, tail, err = r.encodeHeader(&truncated)
+	if err != nil {
+		return nil, err
+	}
+	size := len(head) + len(tail)
+	kept := 0
+	for kept < len(sizes) && size+sizes[kept]+len(r.resultsEnd(kept+1)) <= r.maxBytes {
+		size += sizes[kept]
+		kept++
+	}
+
+	truncated.Results = report.Results[:kept]
+	truncated.OmittedResults = len(report.Results) - kept
+	if kept == 0 && size > r.maxBytes {
+		r.logger.Warn(fmt.Sprintf("Report summary alone is larger than %d bytes, writing it without results", r.maxBytes))
 	} else {
-		_, err := os.Stdout.Write(data)
+		r.logger.Warn(fmt.Sprintf("Report is larger than %d bytes, writing %d of %d results", r.maxBytes, kept, len(report.Results)))
+	}
+	return &truncated, nil
+}
+
+// encodeReport streams a drift report to w: the header is encoded once and the results one
+// at a time, so that the report of a large fleet is never held in memory as a whole. The
+// output is the same as encoding the report in one go.
+func (r *JSONReporter) encodeReport(w io.Writer, report *JSONReport) error {
+	head, tail, err := r.encodeHeader(report)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+
+	encoder := r.newResultEncoder()
+	for i, result := range report.Results {
+		item, err := encoder.encode(i, result)
 		if err != nil {
-			return errors.NewOperationalError("Failed to write report to stdout", err)
+			return err
 		}
-		fmt.Println()
+		if _, err := w.Write(item); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, r.resultsEnd(len(report.Results))); err != nil {
+		return err
 	}
 
-	if r.outputFile == "" {
-		r.outputFile = "stdout"
+	_, err = w.Write(tail)
+	return err
+}
+
+// encodeHeader encodes a drift report without its results, split around the results array:
+// head ends with its opening bracket and tail starts with its closing one
+func (r *JSONReporter) encodeHeader(report *JSONReport) (head, tail []byte, err error) {
+	header := *report
+	header.Results = []*model.DriftResult{}
+
+	var buf bytes.Buffer
+	if err := r.newEncoder(&buf, "").Encode(&header); err != nil {
+		return nil, nil, err
+	}
+	placeholder := `"results":[]`
+	if r.options.PrettyPrint {
+		placeholder = `"results": []`
+	}
+	// Results precede every field whose values could hold the placeholder
+	head, tail, ok := bytes.Cut(buf.Bytes(), []byte(placeholder))
+	if !ok {
+		return nil, nil, fmt.Errorf("results missing from the encoded report header")
 	}
+	head = append(head, placeholder[:len(placeholder)-1]...)
+	return head, append([]byte("]"), tail...), nil
+}
 
-	r.logger.Info(fmt.Sprintf("Successfully written report to %s", r.outputFile))
-	return nil
+// resultsEnd returns what separates the last of count results from the closing bracket
+func (r *JSONReporter) resultsEnd(count int) string {
+	if r.options.PrettyPrint && count > 0 {
+		return "\n  "
+	}
+	return ""
+}
+
+// resultEncoder encodes the results of a drift report one at a time, reusing its buffer
+type resultEncoder struct {
+	buf         bytes.Buffer
+	encoder     *json.Encoder
+	prettyPrint bool
+}
+
+// newResultEncoder returns an encoder for the results of the reporter's drift reports
+func (r *JSONReporter) newResultEncoder() *resultEncoder {
+	e := &resultEncoder{prettyPrint: r.options.PrettyPrint}
+	e.encoder = r.newEncoder(&e.buf, "    ")
+	return e
+}
+
+// encode encodes the result at index i of the results array, with the separator before it.
+// The returned bytes are only valid until the next call.
+func (e *resultEncoder) encode(i int, result *model.DriftResult) ([]byte, error) {
+	e.buf.Reset()
+	if i > 0 {
+		e.buf.WriteByte(',')
+	}
+	if e.prettyPrint {
+		e.buf.WriteString("\n    ")
+	}
+	if err := e.encoder.Encode(newJSONResult(result)); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(e.buf.Bytes(), []byte("\n")), nil
+}
+
+// newEncoder returns an encoder that indents with the given prefix when pretty printing
+func (r *JSONReporter) newEncoder(w io.Writer, prefix string) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	if r.options.PrettyPrint {
+		encoder.SetIndent(prefix, "  ")
+	}
+	return encoder
 }
 
 // GetOutputFile returns the output file path
 func (r *JSONReporter) GetOutputFile() string {
-	return r.outputFile
+	return r.options.OutputFile
 }
 
 // SetOutputFile sets the output file path
 func (r *JSONReporter) SetOutputFile(outputFile string) {
-	r.outputFile = outputFile
+	r.options.OutputFile = outputFile
+}
+
+// Options returns the options the reporter was created with
+func (r *JSONReporter) Options() ReporterOptions {
+	return r.options
 }
 
 // IsPrettyPrint returns whether to use pretty printing
 func (r *JSONReporter) IsPrettyPrint() bool {
-	return r.prettyPrint
+	return r.options.PrettyPrint
 }
 
 // SetPrettyPrint sets whether to use pretty printing
 func (r *JSONReporter) SetPrettyPrint(prettyPrint bool) {
-	r.prettyPrint = prettyPrint
+	r.options.PrettyPrint = prettyPrint
+}
+
+// GetMaxResultsPerFile returns the number of results above which reports are split across files
+func (r *JSONReporter) GetMaxResultsPerFile() int {
+	return r.maxResultsPerFile
+}
+
+// SetMaxResultsPerFile sets the number of results above which reports are split across
+// files; 0 never splits
+func (r *JSONReporter) SetMaxResultsPerFile(maxResults int) {
+	r.maxResultsPerFile = maxResults
+}
+
+// GetMaxBytes returns the size past which report files are truncated
+func (r *JSONReporter) GetMaxBytes() int {
+	return r.maxBytes
+}
+
+// SetMaxBytes sets the size past which report files are truncated, keeping the summary and as
+// many results as fit; 0 never truncates
+func (r *JSONReporter) SetMaxBytes(maxBytes int) {
+	r.maxBytes = maxBytes
+}
+
+// EnableCleanReport makes the reporter confirm runs without drift in a clean report next to
+// the drift report, listing the compared attributes and signed with signingKey unless it is empty
+func (r *JSONReporter) EnableCleanReport(attributes []string, signingKey string) {
+	r.cleanReport = true
+	r.attributes = attributes
+	r.signingKey = signingKey
 }
 
 // boolToInt converts a boolean to an integer (1 for true, 0 for false)
@@ -1,27 +1,28 @@
 package reporter
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
 	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
 	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+	"github.com/victor-devv/ec2-drift-detector/pkg/comparator"
 )
 
 func TestJSONReporter_ReportDrift(t *testing.T) {
 	// Create a temporary directory for test files
-	tempDir, err := os.MkdirTemp("", "json-reporter-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	tempDir := t.TempDir()
 
 	// Create a JSON reporter
-	outputFile := "report.json"
-	reporter := NewJSONReporter(logging.New(), outputFile)
+	outputFile := filepath.Join(tempDir, "report.json")
+	reporter := NewJSONReporter(logging.New(), ReporterOptions{OutputFile: outputFile, PrettyPrint: true})
 
 	// Create a drift result with drift
 	result := model.NewDriftResult("i-12345", model.OriginTerraform)
@@ -29,7 +30,7 @@ func TestJSONReporter_ReportDrift(t *testing.T) {
 	result.AddDriftedAttribute("ami", "ami-12345", "ami-67890")
 
 	// Test reporting
-	err = reporter.ReportDrift(result)
+	err := reporter.ReportDrift(result)
 	assert.NoError(t, err)
 
 	// // Read the file and verify its contents
@@ -50,15 +51,11 @@ func TestJSONReporter_ReportDrift(t *testing.T) {
 
 func TestJSONReporter_ReportMultipleDrifts(t *testing.T) {
 	// Create a temporary directory for test files
-	tempDir, err := os.MkdirTemp("", "json-reporter-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	tempDir := t.TempDir()
 
 	// Create a JSON reporter with pretty print disabled
 	outputFile := filepath.Join(tempDir, "report.json")
-	reporter := NewJSONReporter(logging.New(), outputFile)
+	reporter := NewJSONReporter(logging.New(), ReporterOptions{OutputFile: outputFile, PrettyPrint: true})
 
 	// Create multiple drift results
 	results := []*model.DriftResult{
@@ -75,7 +72,7 @@ func TestJSONReporter_ReportMultipleDrifts(t *testing.T) {
 	}
 
 	// Test reporting multiple results
-	err = reporter.ReportMultipleDrifts(results)
+	err := reporter.ReportMultipleDrifts(results)
 	assert.NoError(t, err)
 
 	// // Read the file and verify its contents
@@ -93,7 +90,7 @@ func TestJSONReporter_ReportMultipleDrifts(t *testing.T) {
 
 // func TestJSONReporter_Getters(t *testing.T) {
 // 	// Create a JSON reporter
-// 	reporter := NewJSONReporter(logging.New(), "test.json")
+// 	reporter := NewJSONReporter(logging.New(), ReporterOptions{OutputFile: "test.json", PrettyPrint: true})
 
 // 	// Test getters
 // 	assert.Equal(t, "test.json", reporter.GetOutputFile())
@@ -109,15 +106,11 @@ func TestJSONReporter_ReportMultipleDrifts(t *testing.T) {
 
 func TestJSONReporter_WriteReport(t *testing.T) {
 	// Create a temporary directory for test files
-	tempDir, err := os.MkdirTemp("", "json-reporter-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	tempDir := t.TempDir()
 
 	// Create a test file path with invalid permissions
 	invalidDir := filepath.Join(tempDir, "invalid")
-	err = os.Mkdir(invalidDir, 0400) // Read-only directory
+	err := os.Mkdir(invalidDir, 0400) // Read-only directory
 	if err != nil {
 		t.Fatalf("Failed to create invalid dir: %v", err)
 	}
@@ -125,7 +118,7 @@ func TestJSONReporter_WriteReport(t *testing.T) {
 	// Try to create a report in a read-only directory
 	if os.Geteuid() != 0 { // Skip this test if running as root
 		outputFile := filepath.Join(invalidDir, "report.json")
-		reporter := NewJSONReporter(logging.New(), outputFile)
+		reporter := NewJSONReporter(logging.New(), ReporterOptions{OutputFile: outputFile, PrettyPrint: true})
 
 		// Create a simple report
 		report := &JSONReport{
@@ -140,3 +133,311 @@ func TestJSONReporter_WriteReport(t *testing.T) {
 		assert.Error(t, err)
 	}
 }
+
+func TestJSONReporter_AttributeSummary(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	reporter := NewJSONReporter(logging.New(), ReporterOptions{PrettyPrint: true})
+	reporter.SetOutputFile(outputFile)
+
+	var results []*model.DriftResult
+	for _, id := range []string{"i-1", "i-2", "i-3"} {
+		r := model.NewDriftResult(id, model.OriginTerraform)
+		r.AddDriftedAttribute("tags.CostCenter", "finance", "ops")
+		results = append(results, r)
+	}
+
+	err := reporter.ReportMultipleDrifts(results)
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(outputFile)
+	assert.NoError(t, err)
+
+	var report JSONReport
+	assert.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, []model.AttributeSummary{
+		{Path: "tags.CostCenter", DriftedInstances: 3, SampleValues: []interface{}{"ops"}},
+	}, report.AttributeSummary)
+}
+
+func TestJSONReporter_Diffs(t *testing.T) {
+	reporter := NewJSONReporter(logging.New(), ReporterOptions{OutputFile: filepath.Join(t.TempDir(), "report.json")})
+
+	drifted := model.NewDriftResult("i-1", model.OriginTerraform)
+	drifted.AddDriftedAttribute("tags.Name", "web", nil)
+	drifted.AddDriftedAttribute("instance_type", "t2.micro", "t2.small")
+	assert.NoError(t, reporter.ReportMultipleDrifts([]*model.DriftResult{drifted, model.NewDriftResult("i-2", model.OriginTerraform)}))
+
+	data, err := os.ReadFile(reporter.GetOutputFile())
+	require.NoError(t, err)
+
+	var report struct {
+		Results []struct {
+			Diffs []json.RawMessage `json:"diffs"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Results, 2)
+
+	// Drifted attributes are rendered by the JSON diff formatter, in path order
+	require.Len(t, report.Results[0].Diffs, 2)
+	assert.JSONEq(t, comparator.JSONFormatter{}.FormatDiff(drifted.DriftedAttributes["instance_type"].DiffEntry()), string(report.Results[0].Diffs[0]))
+	assert.JSONEq(t, `{"path":"tags.Name","source_value":"web","target_value":null}`, string(report.Results[0].Diffs[1]))
+	assert.Empty(t, report.Results[1].Diffs)
+}
+
+func TestJSONReporter_UsesClock(t *testing.T) {
+	now := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	dir := t.TempDir()
+
+	reporter := NewJSONReporterWithClock(logging.New(), ReporterOptions{OutputFile: filepath.Join(dir, "report.json"), PrettyPrint: true}, clock.NewFake(now))
+	assert.Equal(t, filepath.Join(dir, "report_20240422_162045.json"), reporter.GetOutputFile())
+
+	result := model.NewDriftResultAt("i-12345", model.OriginTerraform, now)
+	assert.NoError(t, reporter.ReportMultipleDrifts([]*model.DriftResult{result}))
+
+	data, err := os.ReadFile(reporter.GetOutputFile())
+	assert.NoError(t, err)
+
+	var report JSONReport
+	assert.NoError(t, json.Unmarshal(data, &report))
+	assert.True(t, now.Equal(report.Timestamp))
+	assert.True(t, now.Equal(report.Results[0].Timestamp))
+}
+
+func TestJSONReporter_StreamsSameOutput(t *testing.T) {
+	now := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	var results []*model.DriftResult
+	for _, id := range []string{"i-1", "i-2"} {
+		r := model.NewDriftResultAt(id, model.OriginTerraform, now)
+		r.AddDriftedAttribute("instance_type", "t2.micro", "<t2.small>")
+		results = append(results, r)
+	}
+	report := &JSONReport{Timestamp: now, TotalInstances: 2, DriftedCount: 2, Results: results,
+		AttributeSummary: model.SummarizeAttributes(results, model.AttributeSampleSize)}
+
+	for _, pretty := range []bool{true, false} {
+		for _, report := range []*JSONReport{report, {Timestamp: now, Results: []*model.DriftResult{}}} {
+			reporter := NewJSONReporter(logging.New(), ReporterOptions{PrettyPrint: true})
+			reporter.SetPrettyPrint(pretty)
+
+			var buf bytes.Buffer
+			assert.NoError(t, reporter.encodeReport(&buf, report))
+
+			want, err := json.Marshal(report)
+			if pretty {
+				want, err = json.MarshalIndent(report, "", "  ")
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, string(want)+"\n", buf.String())
+		}
+	}
+}
+
+func TestJSONReporter_MaxResultsPerFile(t *testing.T) {
+	now := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	dir := t.TempDir()
+
+	var results []*model.DriftResult
+	for _, id := range []string{"i-1", "i-2", "i-3", "i-4", "i-5"} {
+		r := model.NewDriftResultAt(id, model.OriginTerraform, now)
+		if id != "i-3" {
+			r.AddDriftedAttribute("instance_type", "t2.micro", "t2.small")
+		}
+		results = append(results, r)
+	}
+
+	reporter := NewJSONReporterWithClock(logging.New(), ReporterOptions{OutputFile: filepath.Join(dir, "report.json"), PrettyPrint: true}, clock.NewFake(now))
+	reporter.SetMaxResultsPerFile(2)
+	assert.NoError(t, reporter.ReportMultipleDrifts(results))
+
+	_, err := os.Stat(filepath.Join(dir, "report_20240422_162045.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	var manifest JSONManifest
+	data, err := os.ReadFile(filepath.Join(dir, "report_20240422_162045-manifest.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, 5, manifest.TotalInstances)
+	assert.Equal(t, 4, manifest.DriftedCount)
+	assert.Equal(t, []JSONManifestPart{
+		{File: "report_20240422_162045-001.json", Results: 2, DriftedCount: 2},
+		{File: "report_20240422_162045-002.json", Results: 2, DriftedCount: 1},
+		{File: "report_20240422_162045-003.json", Results: 1, DriftedCount: 1},
+	}, manifest.Parts)
+
+	var ids []string
+	for i, part := range manifest.Parts {
+		var report JSONReport
+		data, err := os.ReadFile(filepath.Join(dir, part.File))
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(data, &report))
+
+		// Every part carries the run's header
+		assert.Equal(t, i+1, report.Part)
+		assert.Equal(t, 3, report.Parts)
+		assert.Equal(t, 5, report.TotalInstances)
+		assert.Equal(t, 4, report.DriftedCount)
+		assert.True(t, now.Equal(report.Timestamp))
+		for _, result := range report.Results {
+			ids = append(ids, result.ResourceID)
+		}
+	}
+	assert.Equal(t, []string{"i-1", "i-2", "i-3", "i-4", "i-5"}, ids)
+
+	// Reports within the limit are written to a single file without part numbers
+	reporter = NewJSONReporter(logging.New(), ReporterOptions{PrettyPrint: true})
+	reporter.SetOutputFile(filepath.Join(dir, "small.json"))
+	reporter.SetMaxResultsPerFile(5)
+	assert.NoError(t, reporter.ReportMultipleDrifts(results))
+
+	data, err = os.ReadFile(filepath.Join(dir, "small.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), `"part"`)
+	var report JSONReport
+	assert.NoError(t, json.Unmarshal(data, &report))
+	assert.Len(t, report.Results, 5)
+}
+
+func TestJSONReporter_MaxBytes(t *testing.T) {
+	now := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	var results []*model.DriftResult
+	for _, id := range []string{"i-1", "i-2", "i-3", "i-4", "i-5"} {
+		r := model.NewDriftResultAt(id, model.OriginTerraform, now)
+		r.AddDriftedAttribute("instance_type", "t2.micro", "t2.small")
+		results = append(results, r)
+	}
+
+	for _, pretty := range []bool{true, false} {
+		dir := t.TempDir()
+		write := func(name string, maxBytes int) (JSONReport, []byte) {
+			reporter := NewJSONReporterWithClock(logging.New(), ReporterOptions{PrettyPrint: pretty}, clock.NewFake(now))
+			reporter.SetOutputFile(filepath.Join(dir, name))
+			reporter.SetMaxBytes(maxBytes)
+			assert.NoError(t, reporter.ReportMultipleDrifts(results))
+
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			assert.NoError(t, err)
+			var report JSONReport
+			assert.NoError(t, json.Unmarshal(data, &report))
+			return report, data
+		}
+
+		// A report that fits exactly is written whole
+		_, full := write("full.json", 0)
+		report, data := write("exact.json", len(full))
+		assert.Equal(t, string(full), string(data))
+		assert.False(t, report.Truncated)
+		assert.NotContains(t, string(data), `"truncated"`)
+
+		// One byte less drops the results past the last one that fits, keeping the summary
+		report, data = write("truncated.json", len(full)-1)
+		assert.LessOrEqual(t, len(data), len(full)-1)
+		assert.True(t, report.Truncated)
+		assert.Len(t, report.Results, 4)
+		assert.Equal(t, 1, report.OmittedResults)
+		assert.Equal(t, "i-4", report.Results[3].ResourceID)
+		assert.Equal(t, 5, report.TotalInstances)
+		assert.Equal(t, 5, report.DriftedCount)
+		assert.Equal(t, 5, report.AttributeSummary[0].DriftedInstances)
+
+		// A limit below the summary still writes the summary
+		report, _ = write("summary.json", 10)
+		assert.True(t, report.Truncated)
+		assert.Empty(t, report.Results)
+		assert.Equal(t, 5, report.OmittedResults)
+		assert.Equal(t, 5, report.TotalInstances)
+	}
+}
+
+func TestJSONReporter_MaxBytesPerPart(t *testing.T) {
+	now := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	dir := t.TempDir()
+
+	var results []*model.DriftResult
+	for _, id := range []string{"i-1", "i-2", "i-3", "i-4"} {
+		r := model.NewDriftResultAt(id, model.OriginTerraform, now)
+		r.AddDriftedAttribute("instance_type", "t2.micro", "t2.small")
+		results = append(results, r)
+	}
+
+	reporter := NewJSONReporterWithClock(logging.New(), ReporterOptions{OutputFile: filepath.Join(dir, "report.json")}, clock.NewFake(now))
+	reporter.SetMaxResultsPerFile(2)
+	reporter.SetMaxBytes(10)
+	assert.NoError(t, reporter.ReportMultipleDrifts(results))
+
+	// Each part is truncated on its own and the manifest counts what was written
+	var manifest JSONManifest
+	data, err := os.ReadFile(filepath.Join(dir, "report_20240422_162045-manifest.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, 4, manifest.TotalInstances)
+	assert.Equal(t, []JSONManifestPart{
+		{File: "report_20240422_162045-001.json", Results: 0, DriftedCount: 0},
+		{File: "report_20240422_162045-002.json", Results: 0, DriftedCount: 0},
+	}, manifest.Parts)
+
+	var part JSONReport
+	data, err = os.ReadFile(filepath.Join(dir, manifest.Parts[1].File))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &part))
+	assert.True(t, part.Truncated)
+	assert.Equal(t, 2, part.OmittedResults)
+}
+
+func TestJSONReporter_CleanReport(t *testing.T) {
+	now := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	dir := t.TempDir()
+
+	var results []*model.DriftResult
+	for _, id := range []string{"i-1", "i-2", "i-3"} {
+		result := model.NewDriftResultAt(id, model.OriginTerraform, now)
+		result.ResourceAddress = "aws_instance.web[\"" + id + "\"]"
+		results = append(results, result)
+	}
+
+	reporter := NewJSONReporterWithClock(logging.New(), ReporterOptions{OutputFile: filepath.Join(dir, "report.json"), PrettyPrint: true}, clock.NewFake(now))
+	reporter.EnableCleanReport([]string{"instance_type", "tags"}, "s3cret")
+	assert.NoError(t, reporter.ReportMultipleDrifts(results))
+
+	// The drift report is written as usual, with zero drifted instances
+	var report JSONReport
+	data, err := os.ReadFile(filepath.Join(dir, "report_20240422_162045.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, 0, report.DriftedCount)
+
+	var clean CleanReport
+	data, err = os.ReadFile(filepath.Join(dir, "report_20240422_162045_clean.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &clean))
+
+	assert.Equal(t, CleanReportStatus, clean.Status)
+	assert.True(t, now.Equal(clean.Timestamp))
+	assert.Equal(t, 3, clean.TotalInstances)
+	assert.Equal(t, []string{"instance_type", "tags"}, clean.Attributes)
+	if assert.Len(t, clean.Instances, 3) {
+		assert.Equal(t, "i-1", clean.Instances[0].ResourceID)
+		assert.Equal(t, `aws_instance.web["i-1"]`, clean.Instances[0].ResourceAddress)
+	}
+	assert.NotEmpty(t, clean.Signature)
+	assert.True(t, clean.Verify("s3cret"))
+	assert.False(t, clean.Verify("other"))
+
+	// Tampering breaks the digest
+	clean.Instances = clean.Instances[1:]
+	assert.False(t, clean.Verify(""))
+}
+
+func TestJSONReporter_CleanReportOnlyWithoutDrift(t *testing.T) {
+	dir := t.TempDir()
+	result := model.NewDriftResult("i-1", model.OriginTerraform)
+	result.AddDriftedAttribute("instance_type", "t2.micro", "t2.small")
+
+	reporter := NewJSONReporter(logging.New(), ReporterOptions{PrettyPrint: true})
+	reporter.SetOutputFile(filepath.Join(dir, "report.json"))
+	reporter.EnableCleanReport([]string{"instance_type"}, "")
+	assert.NoError(t, reporter.ReportMultipleDrifts([]*model.DriftResult{result}))
+
+	_, err := os.Stat(filepath.Join(dir, "report_clean.json"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,89 @@
+package reporter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// MarkdownReporter is an implementation of the Reporter interface that renders a Markdown
+// report, e.g. for a pull request comment or wiki page
+type MarkdownReporter struct {
+	logger   *logging.Logger
+	options  ReporterOptions
+	template *template.Template
+	location *time.Location
+	clock    clock.Clock
+}
+
+// NewMarkdownReporter creates a Markdown reporter writing to options.OutputFile, or stdout when
+// it is empty. A nil tmpl uses the built-in template.
+func NewMarkdownReporter(logger *logging.Logger, options ReporterOptions, tmpl *template.Template) *MarkdownReporter {
+	if tmpl == nil {
+		tmpl = mustLoadBuiltinTemplate(TemplateMarkdown)
+	}
+	return &MarkdownReporter{
+		logger:   logger.WithField("component", "markdown-reporter"),
+		options:  options,
+		template: tmpl,
+		location: time.Local,
+		clock:    clock.Real(),
+	}
+}
+
+// ReportDrift reports a single drift detection result
+func (r *MarkdownReporter) ReportDrift(result *model.DriftResult) error {
+	return r.ReportMultipleDrifts([]*model.DriftResult{result})
+}
+
+// ReportMultipleDrifts renders the results and their attribute summary
+func (r *MarkdownReporter) ReportMultipleDrifts(results []*model.DriftResult) error {
+	r.logger.Info(fmt.Sprintf("Reporting drift for %d instances as Markdown", len(results)))
+	summary := model.SummarizeAttributes(results, model.AttributeSampleSize)
+
+	var buf bytes.Buffer
+	if err := renderTemplate(&buf, r.template, NewReportView(results, summary, r.clock.Now()).In(r.location), false); err != nil {
+		return err
+	}
+
+	if r.options.OutputFile == "" || r.options.OutputFile == "stdout" {
+		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+			return errors.NewOperationalError("Failed to write report to stdout", err)
+		}
+		return nil
+	}
+
+	dir := filepath.Dir(r.options.OutputFile)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to create output directory %s", dir), err)
+	}
+	if err := os.WriteFile(r.options.OutputFile, buf.Bytes(), 0644); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to write report to %s", r.options.OutputFile), err)
+	}
+
+	r.logger.Info(fmt.Sprintf("Successfully written report to %s", r.options.OutputFile))
+	return nil
+}
+
+// SetLocation sets the time zone timestamps are shown in
+func (r *MarkdownReporter) SetLocation(loc *time.Location) {
+	r.location = loc
+}
+
+// Options returns the options the reporter was created with
+func (r *MarkdownReporter) Options() ReporterOptions {
+	return r.options
+}
+
+// GetOutputFile returns the output file path
+func (r *MarkdownReporter) GetOutputFile() string {
+	return r.options.OutputFile
+}
@@ -0,0 +1,161 @@
+package reporter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// Metric families written by the metrics file reporter
+const (
+	MetricInstancesDrifted = "ec2_drift_instances_drifted"
+	MetricInstancesTotal   = "ec2_drift_instances_total"
+	MetricAttributeDrifted = "ec2_drift_attribute_drifted_instances"
+	MetricLastRunTimestamp = "ec2_drift_last_run_timestamp_seconds"
+)
+
+// MetricsFileReporter is an implementation of the Reporter interface that writes the metrics of
+// each run in the Prometheus text format, for node_exporter's textfile collector to expose.
+// Every run replaces the whole file, so attributes that no longer drift drop out rather than
+// keeping their last value.
+type MetricsFileReporter struct {
+	logger  *logging.Logger
+	options ReporterOptions
+	clock   clock.Clock
+}
+
+// NewMetricsFileReporter creates a metrics file reporter writing to options.OutputFile, or
+// stdout when it is empty
+func NewMetricsFileReporter(logger *logging.Logger, options ReporterOptions) *MetricsFileReporter {
+	return &MetricsFileReporter{
+		logger:  logger.WithField("component", "metrics-file-reporter"),
+		options: options,
+		clock:   clock.Real(),
+	}
+}
+
+// ReportDrift reports a single drift detection result
+func (r *MetricsFileReporter) ReportDrift(result *model.DriftResult) error {
+	return r.ReportMultipleDrifts([]*model.DriftResult{result})
+}
+
+// ReportMultipleDrifts writes the metrics of the results
+func (r *MetricsFileReporter) ReportMultipleDrifts(results []*model.DriftResult) error {
+	r.logger.Info(fmt.Sprintf("Writing drift metrics for %d instances", len(results)))
+
+	var buf bytes.Buffer
+	r.writeMetrics(&buf, results)
+
+	if r.options.OutputFile == "" || r.options.OutputFile == "stdout" {
+		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+			return errors.NewOperationalError("Failed to write metrics to stdout", err)
+		}
+		return nil
+	}
+
+	if err := writeFileAtomic(r.options.OutputFile, buf.Bytes()); err != nil {
+		return err
+	}
+
+	r.logger.Info(fmt.Sprintf("Successfully written metrics to %s", r.options.OutputFile))
+	return nil
+}
+
+// writeMetrics renders the metric families of the results
+func (r *MetricsFileReporter) writeMetrics(buf *bytes.Buffer, results []*model.DriftResult) {
+	drifted := 0
+	byAttribute := make(map[string]int)
+	for _, result := range results {
+		if !result.HasDrift {
+			continue
+		}
+		drifted++
+		for path := range result.DriftedAttributes {
+			byAttribute[path]++
+		}
+	}
+
+	writeGauge(buf, MetricInstancesDrifted, "Instances with drift in the last run.", float64(drifted))
+	writeGauge(buf, MetricInstancesTotal, "Instances checked in the last run.", float64(len(results)))
+
+	writeFamily(buf, MetricAttributeDrifted, "Instances drifted on each attribute in the last run.")
+	attributes := make([]string, 0, len(byAttribute))
+	for path := range byAttribute {
+		attributes = append(attributes, path)
+	}
+	sort.Strings(attributes)
+	for _, path := range attributes {
+		fmt.Fprintf(buf, "%s{attribute=\"%s\"} %d\n", MetricAttributeDrifted, escapeLabelValue(path), byAttribute[path])
+	}
+
+	now := r.clock.Now()
+	writeGauge(buf, MetricLastRunTimestamp, "Unix time the last run finished.", float64(now.UnixNano())/1e9)
+}
+
+// Options returns the options the reporter was created with
+func (r *MetricsFileReporter) Options() ReporterOptions {
+	return r.options
+}
+
+// GetOutputFile returns the output file path
+func (r *MetricsFileReporter) GetOutputFile() string {
+	return r.options.OutputFile
+}
+
+// writeFamily writes the HELP and TYPE lines of a gauge family
+func writeFamily(buf *bytes.Buffer, name, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s gauge\n", name)
+}
+
+// writeGauge writes a gauge family with a single unlabelled sample
+func writeGauge(buf *bytes.Buffer, name, help string, value float64) {
+	writeFamily(buf, name, help)
+	fmt.Fprintf(buf, "%s %s\n", name, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// escapeLabelValue escapes a label value as the text format requires
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the same directory, so
+// that readers such as the textfile collector never see a partly written file
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to create output directory %s", dir), err)
+	}
+
+	// The collector only reads *.prom files, so the temporary file is never picked up
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to create temporary file for %s", path), err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.NewOperationalError(fmt.Sprintf("Failed to write metrics to %s", tmp.Name()), err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return errors.NewOperationalError(fmt.Sprintf("Failed to set permissions of %s", tmp.Name()), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to write metrics to %s", tmp.Name()), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to write metrics to %s", path), err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+func TestMetricsFileReporter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "textfile", "ec2_drift.prom")
+	reporter := NewMetricsFileReporter(logging.New(), ReporterOptions{OutputFile: path})
+	reporter.clock = clock.NewFake(time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC))
+
+	results := []*model.DriftResult{
+		{ResourceID: "i-1", HasDrift: true, DriftedAttributes: map[string]model.AttributeDrift{
+			"instance_type": {Path: "instance_type"},
+			"tags.Name":     {Path: "tags.Name"},
+		}},
+		{ResourceID: "i-2", HasDrift: true, DriftedAttributes: map[string]model.AttributeDrift{
+			"instance_type": {Path: "instance_type"},
+		}},
+		{ResourceID: "i-3"},
+	}
+	require.NoError(t, reporter.ReportMultipleDrifts(results))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP ec2_drift_instances_drifted Instances with drift in the last run.
+# TYPE ec2_drift_instances_drifted gauge
+ec2_drift_instances_drifted 2
+# HELP ec2_drift_instances_total Instances checked in the last run.
+# TYPE ec2_drift_instances_total gauge
+ec2_drift_instances_total 3
+# HELP ec2_drift_attribute_drifted_instances Instances drifted on each attribute in the last run.
+# TYPE ec2_drift_attribute_drifted_instances gauge
+ec2_drift_attribute_drifted_instances{attribute="instance_type"} 2
+ec2_drift_attribute_drifted_instances{attribute="tags.Name"} 1
+# HELP ec2_drift_last_run_timestamp_seconds Unix time the last run finished.
+# TYPE ec2_drift_last_run_timestamp_seconds gauge
+ec2_drift_last_run_timestamp_seconds 1746093600
+`, string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	// Resolved drift is dropped rather than left at its last value
+	require.NoError(t, reporter.ReportMultipleDrifts([]*model.DriftResult{{ResourceID: "i-1"}}))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "ec2_drift_instances_drifted 0\n")
+	assert.NotContains(t, string(data), `attribute="instance_type"`)
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `tags.a\"b\\c\n`, escapeLabelValue("tags.a\"b\\c\n"))
+}
@@ -0,0 +1,28 @@
+package reporter
+
+import (
+	"context"
+	"time"
+)
+
+// ReporterOptions are the settings shared by every reporter, so that they are set once for
+// whichever reporters a run uses. Reporters ignore the options that don't apply to them.
+type ReporterOptions struct {
+	// OutputFile is the file reports are written to; empty or "stdout" writes to stdout
+	OutputFile string
+
+	// PrettyPrint indents structured output such as JSON; when false it is written compactly
+	PrettyPrint bool
+
+	// Timeout bounds how long delivering a report may take, e.g. posting it to a webhook;
+	// 0 leaves it unbounded
+	Timeout time.Duration
+}
+
+// context returns a context that expires after the timeout, if there is one
+func (o ReporterOptions) context() (context.Context, context.CancelFunc) {
+	if o.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), o.Timeout)
+}
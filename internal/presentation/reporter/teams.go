@@ -0,0 +1,242 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+const (
+	// TeamsMaxPayloadBytes is the largest message Teams incoming webhooks accept
+	TeamsMaxPayloadBytes = 28 * 1024
+
+	// DefaultTeamsMaxInstances is the default number of drifted instances detailed in a card
+	DefaultTeamsMaxInstances = 10
+
+	// teamsMaxValueLength caps each rendered attribute value, in characters, so one instance cannot
+	// fill the card
+	teamsMaxValueLength = 200
+)
+
+// TeamsOptions configures the Teams reporter
+type TeamsOptions struct {
+	// WebhookURL is the Teams incoming webhook to post to
+	WebhookURL string
+
+	// MaxInstances caps the number of drifted instances detailed in the card
+	MaxInstances int
+
+	// ReportURL links the card to the full report, when one is published
+	ReportURL string
+}
+
+// TeamsReporter is an implementation of the Reporter interface that posts Adaptive Cards
+// to a Microsoft Teams incoming webhook
+type TeamsReporter struct {
+	logger  *logging.Logger
+	options ReporterOptions
+	sender  *HTTPSender
+	teams   TeamsOptions
+}
+
+// NewTeamsReporter creates a new Teams reporter that delivers cards through sender, giving up
+// on a card after options.Timeout
+func NewTeamsReporter(logger *logging.Logger, options ReporterOptions, sender *HTTPSender, teams TeamsOptions) *TeamsReporter {
+	if teams.MaxInstances <= 0 {
+		teams.MaxInstances = DefaultTeamsMaxInstances
+	}
+	return &TeamsReporter{
+		logger:  logger.WithField("component", "teams-reporter"),
+		options: options,
+		sender:  sender,
+		teams:   teams,
+	}
+}
+
+// Options returns the options the reporter was created with
+func (r *TeamsReporter) Options() ReporterOptions {
+	return r.options
+}
+
+// NotificationChannel returns the digest key for the Teams channel
+func (r *TeamsReporter) NotificationChannel() string {
+	return "teams"
+}
+
+// ReportDrift reports a single drift detection result
+func (r *TeamsReporter) ReportDrift(result *model.DriftResult) error {
+	return r.ReportMultipleDrifts([]*model.DriftResult{result})
+}
+
+// ReportMultipleDrifts posts a summary card with a section per drifted instance
+func (r *TeamsReporter) ReportMultipleDrifts(results []*model.DriftResult) error {
+	r.logger.Info(fmt.Sprintf("Reporting drift for %d instances to Teams", len(results)))
+
+	payload, err := r.buildPayload(results)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := r.options.context()
+	defer cancel()
+	if err := r.sender.PostJSON(ctx, r.teams.WebhookURL, payload); err != nil {
+		return errors.NewOperationalError("Failed to send Teams notification", err)
+	}
+
+	r.logger.Info("Successfully sent Teams notification")
+	return nil
+}
+
+// teamsMessage is the envelope Teams expects for Adaptive Cards
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     adaptiveCard `json:"content"`
+}
+
+type adaptiveCard struct {
+	Schema  string           `json:"$schema"`
+	Type    string           `json:"type"`
+	Version string           `json:"version"`
+	Body    []interface{}    `json:"body"`
+	Actions []adaptiveAction `json:"actions,omitempty"`
+}
+
+type adaptiveTextBlock struct {
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	Size      string `json:"size,omitempty"`
+	Weight    string `json:"weight,omitempty"`
+	Separator bool   `json:"separator,omitempty"`
+	Wrap      bool   `json:"wrap"`
+}
+
+type adaptiveFactSet struct {
+	Type  string         `json:"type"`
+	Facts []adaptiveFact `json:"facts"`
+}
+
+type adaptiveFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type adaptiveAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// buildPayload renders the card, dropping instance sections until it fits the Teams limit
+func (r *TeamsReporter) buildPayload(results []*model.DriftResult) ([]byte, error) {
+	var drifted []*model.DriftResult
+	var errored int
+	for _, result := range results {
+		if result.HasDrift {
+			drifted = append(drifted, result)
+		}
+		if len(result.SkippedAttributes) > 0 {
+			errored++
+		}
+	}
+	sort.Slice(drifted, func(i, j int) bool {
+		return drifted[i].ResourceID < drifted[j].ResourceID
+	})
+
+	shown := min(len(drifted), r.teams.MaxInstances)
+	for {
+		data, err := json.Marshal(r.buildMessage(len(results), errored, drifted, shown))
+		if err != nil {
+			return nil, errors.NewOperationalError("Failed to marshal Teams card", err)
+		}
+		if len(data) <= TeamsMaxPayloadBytes || shown == 0 {
+			return data, nil
+		}
+		r.logger.Debug(fmt.Sprintf("Teams card is %d bytes, dropping instance detail", len(data)))
+		shown--
+	}
+}
+
+// buildMessage renders the summary facts and the first shown drifted instances
+func (r *TeamsReporter) buildMessage(total, errored int, drifted []*model.DriftResult, shown int) teamsMessage {
+	body := []interface{}{
+		adaptiveTextBlock{Type: "TextBlock", Text: "EC2 Drift Report", Size: "Large", Weight: "Bolder", Wrap: true},
+		adaptiveFactSet{Type: "FactSet", Facts: []adaptiveFact{
+			{Title: "Total instances", Value: fmt.Sprintf("%d", total)},
+			{Title: "Drifted", Value: fmt.Sprintf("%d", len(drifted))},
+			{Title: "Errors", Value: fmt.Sprintf("%d", errored)},
+		}},
+	}
+
+	for _, result := range drifted[:shown] {
+		body = append(body, adaptiveTextBlock{Type: "TextBlock", Text: instanceTitle(result), Weight: "Bolder", Separator: true, Wrap: true})
+		body = append(body, adaptiveFactSet{Type: "FactSet", Facts: driftFacts(result)})
+	}
+
+	if omitted := len(drifted) - shown; omitted > 0 {
+		body = append(body, adaptiveTextBlock{Type: "TextBlock", Text: fmt.Sprintf("%d more drifted instances not shown", omitted), Separator: true, Wrap: true})
+	}
+
+	card := adaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body:    body,
+	}
+	if r.teams.ReportURL != "" {
+		card.Actions = []adaptiveAction{{Type: "Action.OpenUrl", Title: "View full report", URL: r.teams.ReportURL}}
+	}
+
+	return teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     card,
+		}},
+	}
+}
+
+// instanceTitle names an instance section by ID, Name tag, account and workspace
+func instanceTitle(result *model.DriftResult) string {
+	title := result.ResourceID
+	if result.SourceName != "" {
+		title += fmt.Sprintf(" (%s)", result.SourceName)
+	}
+	if result.AccountID != "" {
+		title += fmt.Sprintf(" [%s]", result.AccountID)
+	}
+	if result.Workspace != "" {
+		title += fmt.Sprintf(" [workspace %s]", result.Workspace)
+	}
+	return title
+}
+
+// driftFacts lists the drifted attributes of a result, sorted by path
+func driftFacts(result *model.DriftResult) []adaptiveFact {
+	paths := make([]string, 0, len(result.DriftedAttributes))
+	for path := range result.DriftedAttributes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	desiredOrigin, currentOrigin := result.SourceType.Name(), result.SourceType.Other().Name()
+	facts := make([]adaptiveFact, 0, len(paths))
+	for _, path := range paths {
+		drift := result.DriftedAttributes[path]
+		desired, current := drift.DesiredAndCurrent()
+		value := fmt.Sprintf("%s: %v → %s: %v", desiredOrigin, desired, currentOrigin, current)
+		if runes := []rune(value); len(runes) > teamsMaxValueLength {
+			value = string(runes[:teamsMaxValueLength]) + "…"
+		}
+		facts = append(facts, adaptiveFact{Title: path, Value: value})
+	}
+	return facts
+}
@@ -0,0 +1,175 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+func newTestSender(t *testing.T) *HTTPSender {
+	sender, err := NewHTTPSender(logging.New(), HTTPSenderOptions{MaxRetries: 2, RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+	return sender
+}
+
+func TestTeamsReporter_PostsAdaptiveCard(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	drifted := model.NewDriftResult("i-1", model.OriginTerraform)
+	drifted.AddDriftedAttribute("instance_type", "t2.micro", "t2.large")
+	clean := model.NewDriftResult("i-2", model.OriginTerraform)
+
+	reporter := NewTeamsReporter(logging.New(), ReporterOptions{}, newTestSender(t), TeamsOptions{
+		WebhookURL: server.URL,
+		ReportURL:  "https://reports.example.com/latest",
+	})
+	require.NoError(t, reporter.ReportMultipleDrifts([]*model.DriftResult{drifted, clean}))
+
+	data, _ := json.Marshal(received)
+	payload := string(data)
+	assert.Contains(t, payload, "application/vnd.microsoft.card.adaptive")
+	assert.Contains(t, payload, `{"title":"Total instances","value":"2"}`)
+	assert.Contains(t, payload, `{"title":"Drifted","value":"1"}`)
+	assert.Contains(t, payload, `{"title":"instance_type","value":"Terraform: t2.micro → AWS: t2.large"}`)
+	assert.Contains(t, payload, "https://reports.example.com/latest")
+	assert.NotContains(t, payload, "i-2")
+	assert.Equal(t, "teams", reporter.NotificationChannel())
+}
+
+func TestTeamsReporter_CardStructure(t *testing.T) {
+	var message teamsMessage
+	var raw struct {
+		Attachments []struct {
+			Content struct {
+				Body []map[string]interface{} `json:"body"`
+			} `json:"content"`
+		} `json:"attachments"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &message))
+		require.NoError(t, json.Unmarshal(body, &raw))
+	}))
+	defer server.Close()
+
+	drifted := model.NewDriftResult("i-1", model.OriginTerraform)
+	drifted.SourceName = "web"
+	drifted.AddDriftedAttribute("tags.Env", "prod", "staging")
+	drifted.AddDriftedAttribute("instance_type", "t2.micro", "t2.large")
+
+	reporter := NewTeamsReporter(logging.New(), ReporterOptions{}, newTestSender(t), TeamsOptions{WebhookURL: server.URL})
+	require.NoError(t, reporter.ReportDrift(drifted))
+
+	// One Adaptive Card attachment in the message envelope
+	assert.Equal(t, "message", message.Type)
+	require.Len(t, message.Attachments, 1)
+	assert.Equal(t, "application/vnd.microsoft.card.adaptive", message.Attachments[0].ContentType)
+	card := message.Attachments[0].Content
+	assert.Equal(t, "AdaptiveCard", card.Type)
+	assert.Equal(t, "1.4", card.Version)
+	assert.Equal(t, "http://adaptivecards.io/schemas/adaptive-card.json", card.Schema)
+	assert.Empty(t, card.Actions)
+
+	// Title, summary facts, then a heading and facts per drifted instance
+	body := raw.Attachments[0].Content.Body
+	require.Len(t, body, 4)
+	assert.Equal(t, "TextBlock", body[0]["type"])
+	assert.Equal(t, "EC2 Drift Report", body[0]["text"])
+	assert.Equal(t, "FactSet", body[1]["type"])
+	assert.Equal(t, "i-1 (web)", body[2]["text"])
+	assert.Equal(t, true, body[2]["separator"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"title": "instance_type", "value": "Terraform: t2.micro → AWS: t2.large"},
+		map[string]interface{}{"title": "tags.Env", "value": "Terraform: prod → AWS: staging"},
+	}, body[3]["facts"])
+}
+
+func TestTeamsReporter_TruncatesToPayloadLimit(t *testing.T) {
+	var size int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		size = len(body)
+	}))
+	defer server.Close()
+
+	var results []*model.DriftResult
+	for i := 0; i < 50; i++ {
+		result := model.NewDriftResult(fmt.Sprintf("i-%03d", i), model.OriginTerraform)
+		for j := 0; j < 20; j++ {
+			result.AddDriftedAttribute(fmt.Sprintf("tags.key%d", j), strings.Repeat("a", 100), strings.Repeat("b", 100))
+		}
+		results = append(results, result)
+	}
+
+	reporter := NewTeamsReporter(logging.New(), ReporterOptions{}, newTestSender(t), TeamsOptions{WebhookURL: server.URL, MaxInstances: 50})
+	payload, err := reporter.buildPayload(results)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(payload), TeamsMaxPayloadBytes)
+	assert.Contains(t, string(payload), "more drifted instances not shown")
+
+	require.NoError(t, reporter.ReportMultipleDrifts(results))
+	assert.Equal(t, len(payload), size)
+}
+
+func TestDriftFacts_TruncatesOnRuneBoundaries(t *testing.T) {
+	result := model.NewDriftResult("i-1", model.OriginTerraform)
+	result.AddDriftedAttribute("tags.Team", strings.Repeat("é", 150), strings.Repeat("日本", 150))
+
+	facts := driftFacts(result)
+	require.Len(t, facts, 1)
+	value := facts[0].Value
+	assert.True(t, utf8.ValidString(value))
+	assert.Equal(t, teamsMaxValueLength+1, utf8.RuneCountInString(value))
+	assert.True(t, strings.HasSuffix(value, "日…") || strings.HasSuffix(value, "本…"))
+}
+
+func TestHTTPSender_RetriesTransientFailures(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	require.NoError(t, newTestSender(t).PostJSON(t.Context(), server.URL, []byte(`{}`)))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestHTTPSender_DoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		http.Error(w, "bad webhook", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := newTestSender(t).PostJSON(t.Context(), server.URL, []byte(`{}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad webhook")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	_, err = NewHTTPSender(logging.New(), HTTPSenderOptions{ProxyURL: "not a url"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,171 @@
+package reporter
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// Report templates that can be overridden
+const (
+	TemplateConsole  = "console"
+	TemplateMarkdown = "markdown"
+)
+
+// builtinTemplates holds the default report templates, which users can copy and customize
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// TemplateNames returns the names of the report templates that can be overridden
+func TemplateNames() []string {
+	return []string{TemplateConsole, TemplateMarkdown}
+}
+
+// BuiltinTemplate returns the source of a built-in report template
+func BuiltinTemplate(name string) (string, error) {
+	data, err := builtinTemplates.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		return "", errors.NewNotFoundError("Report template", name)
+	}
+	return string(data), nil
+}
+
+// LoadTemplate parses the report template at path, or the built-in template when path is
+// empty. The template is also executed against a sample report so that references to fields
+// the view doesn't have fail here rather than mid-run.
+func LoadTemplate(name, path string) (*template.Template, error) {
+	source, err := BuiltinTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.NewValidationError(fmt.Sprintf("Failed to read %s template %s: %v", name, path, err))
+		}
+		source = string(data)
+	} else {
+		path = "built-in"
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs(false)).Parse(source)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid %s template %s: %v", name, path, err))
+	}
+
+	if err := tmpl.Execute(io.Discard, sampleReportView()); err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid %s template %s: %v", name, path, err))
+	}
+
+	return tmpl, nil
+}
+
+// ValidateTemplateFile checks that a template override parses and renders
+func ValidateTemplateFile(name, path string) error {
+	if path == "" {
+		return nil
+	}
+	_, err := LoadTemplate(name, path)
+	return err
+}
+
+// mustLoadBuiltinTemplate parses a built-in template, which is covered by tests
+func mustLoadBuiltinTemplate(name string) *template.Template {
+	tmpl, err := LoadTemplate(name, "")
+	if err != nil {
+		panic(err)
+	}
+	return tmpl
+}
+
+// renderTemplate executes a report template, coloring console output when colored is set
+func renderTemplate(w io.Writer, tmpl *template.Template, view ReportView, colored bool) error {
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return errors.NewSystemError("Failed to clone report template", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Funcs(templateFuncs(colored)).Execute(&buf, view); err != nil {
+		return errors.NewOperationalError(fmt.Sprintf("Failed to render %s template", tmpl.Name()), err)
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return errors.NewOperationalError("Failed to write report", err)
+	}
+	return nil
+}
+
+// templateFuncs are the functions available to report templates
+func templateFuncs(colored bool) template.FuncMap {
+	color := func(code string) func(string) string {
+		return func(text string) string {
+			if !colored {
+				return text
+			}
+			return fmt.Sprintf("\033[%sm%s\033[0m", code, text)
+		}
+	}
+
+	return template.FuncMap{
+		"header": func(text string) string {
+			return color("1;36")(fmt.Sprintf("=== %s ===", text))
+		},
+		"success": color("1;32"),
+		"warning": color("1;33"),
+		"danger":  color("1;31"),
+		"yesno": func(value bool) string {
+			if value {
+				return color("1;31")("Yes")
+			}
+			return color("1;32")("No")
+		},
+		"join": func(items []string, sep string) string {
+			return strings.Join(items, sep)
+		},
+		"rfc3339": func(t time.Time) string {
+			return t.Format(time.RFC3339)
+		},
+		"indent": func(spaces int, text string) string {
+			pad := strings.Repeat(" ", spaces)
+			return pad + strings.ReplaceAll(text, "\n", "\n"+pad)
+		},
+		"mdcell": func(text string) string {
+			text = strings.ReplaceAll(text, "|", `\|`)
+			return strings.ReplaceAll(text, "\n", "<br>")
+		},
+	}
+}
+
+// sampleReportView is a report with every section populated, used to check templates
+func sampleReportView() ReportView {
+	drifted := model.NewDriftResult("i-0123456789abcdef0", model.OriginTerraform)
+	drifted.AccountID = "111111111111"
+	drifted.Workspace = "prod"
+	drifted.SetNames("web", "web")
+	drifted.AddDriftedAttribute("instance_type", "t3.micro", "t3.large")
+	drifted.SetSkippedAttributes(map[string]string{"ami": "unknown value"})
+	drifted.SetPolicyViolations([]model.PolicyViolation{{Path: "age_days", Operator: model.PolicyOperatorLessThan, Expected: 90, Actual: 120}})
+	drifted.ResourceAddress = "aws_instance.web"
+	drifted.Remediation = []model.RemediationStep{
+		{Description: "Review and apply the Terraform configuration of aws_instance.web", Command: "terraform plan -target=aws_instance.web"},
+		{Description: "Update aws_instance.web in the Terraform configuration with the values in AWS", Snippet: "resource \"aws_instance\" \"web\" {\n  instance_type = \"t3.large\"\n}"},
+	}
+
+	clean := model.NewDriftResult("i-0fedcba9876543210", model.OriginTerraform)
+	clean.AccountID = "222222222222"
+	clean.Workspace = "dev"
+
+	results := []*model.DriftResult{drifted, clean}
+	return NewReportView(results, model.SummarizeAttributes(results, model.AttributeSampleSize), time.Now())
+}
@@ -0,0 +1,422 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/clock"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+	"github.com/victor-devv/ec2-drift-detector/internal/common/logging"
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+func writeTemplate(t *testing.T, source string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "report.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(source), 0600))
+	return path
+}
+
+func testResults() []*model.DriftResult {
+	drifted := model.NewDriftResult("i-1", model.OriginTerraform)
+	drifted.SetNames("web", "web")
+	drifted.AddDriftedAttribute("vpc_security_group_ids", "[sg-1]", "[sg-1 sg-2]")
+	drifted.AddDriftedAttribute("tags.Env", "prod", "staging|qa")
+	drifted.AddDriftedAttribute("instance_type", "t3.micro", "t3.large")
+
+	return []*model.DriftResult{drifted, model.NewDriftResult("i-2", model.OriginTerraform)}
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	for _, name := range TemplateNames() {
+		source, err := BuiltinTemplate(name)
+		require.NoError(t, err)
+		assert.NotEmpty(t, source)
+
+		_, err = LoadTemplate(name, "")
+		assert.NoError(t, err, name)
+	}
+
+	_, err := BuiltinTemplate("html")
+	assert.True(t, errors.IsNotFoundError(err))
+}
+
+func TestLoadTemplate_Override(t *testing.T) {
+	path := writeTemplate(t, `{{range .Drifted}}{{.ID}}:{{range .Drifts}} {{.Path}}={{.Severity}}{{end}}{{end}}`)
+
+	tmpl, err := LoadTemplate(TemplateMarkdown, path)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, renderTemplate(&buf, tmpl, NewReportView(testResults(), nil, time.Now()), false))
+	assert.Equal(t, "i-1: instance_type=medium tags.Env=low vpc_security_group_ids=high", buf.String())
+}
+
+func TestLoadTemplate_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{name: "syntax", source: `{{range .Results}}`, wantErr: "unexpected EOF"},
+		{name: "unknown function", source: `{{shout .TotalInstances}}`, wantErr: `function "shout" not defined`},
+		{name: "unknown field", source: `{{range .Results}}{{.InstanceID}}{{end}}`, wantErr: "can't evaluate field InstanceID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTemplate(t, tt.source)
+
+			_, err := LoadTemplate(TemplateConsole, path)
+			assert.True(t, errors.IsValidationError(err))
+			assert.ErrorContains(t, err, "Invalid console template "+path)
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, err, ValidateTemplateFile(TemplateConsole, path))
+		})
+	}
+
+	_, err := LoadTemplate(TemplateConsole, filepath.Join(t.TempDir(), "missing.tmpl"))
+	assert.True(t, errors.IsValidationError(err))
+	assert.NoError(t, ValidateTemplateFile(TemplateConsole, ""))
+}
+
+func TestConsoleReporter_BuiltinTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewConsoleReporter(logging.New(), ReporterOptions{})
+	reporter.SetColorEnabled(false)
+	reporter.out = &buf
+
+	require.NoError(t, reporter.ReportMultipleDrifts(testResults()))
+	output := buf.String()
+	assert.True(t, strings.HasPrefix(output, "=== Drift Detection Summary ===\n\nNumber of Instances: 2\nInstances with Drift: Yes (1/2)\n"), output)
+	assert.Contains(t, output, "=== Top Drifted Attributes ===")
+	assert.Contains(t, output, "instance_type           1          t3.large\n")
+	assert.Contains(t, output, "web (i-1)  instance_type, tags.Env, vpc_security_group_ids")
+	assert.NotContains(t, output, "i-2")
+
+	buf.Reset()
+	require.NoError(t, reporter.ReportMultipleDrifts(testResults()[1:]))
+	assert.Equal(t, "=== Drift Detection Summary ===\n\nNumber of Instances: 1\nInstances with Drift: No (0/1)\n\nNo drift detected in any instance.\n", buf.String())
+
+	// Colors are applied when enabled
+	buf.Reset()
+	reporter.SetColorEnabled(true)
+	require.NoError(t, reporter.ReportMultipleDrifts(testResults()[1:]))
+	assert.Contains(t, buf.String(), "\033[1;36m=== Drift Detection Summary ===\033[0m")
+}
+
+func TestReports_UnmanagedInstances(t *testing.T) {
+	unmanaged := model.NewDriftResult("i-3", model.OriginAWS)
+	unmanaged.SetNames("legacy", "legacy")
+	unmanaged.AddDriftedAttribute(model.AttributeExists, true, false)
+	unmanaged.Unmanaged = true
+	results := append(testResults(), unmanaged)
+
+	view := NewReportView(results, nil, time.Now())
+	assert.Equal(t, 1, view.DriftedCount)
+	assert.Equal(t, 1, view.UnmanagedCount)
+	require.Len(t, view.Unmanaged, 1)
+	assert.Equal(t, "i-3", view.Unmanaged[0].ID)
+	require.Len(t, view.Drifted, 1)
+	assert.Equal(t, "i-1", view.Drifted[0].ID)
+
+	var buf bytes.Buffer
+	console := NewConsoleReporter(logging.New(), ReporterOptions{})
+	console.SetColorEnabled(false)
+	console.out = &buf
+	require.NoError(t, console.ReportMultipleDrifts(results))
+	output := buf.String()
+	assert.Contains(t, output, "Instances with Drift: Yes (1/3)\nUnmanaged Instances: 1\n")
+	assert.Contains(t, output, "=== Unmanaged Instances ===")
+	assert.Contains(t, output, "legacy (i-3)")
+
+	outputFile := filepath.Join(t.TempDir(), "drift.md")
+	markdown := NewMarkdownReporter(logging.New(), ReporterOptions{OutputFile: outputFile}, nil)
+	require.NoError(t, markdown.ReportMultipleDrifts(results))
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "## Unmanaged instances")
+	assert.Contains(t, string(data), "| legacy (i-3) | `"+unmanaged.ID+"` |")
+}
+
+func TestReports_DriftAges(t *testing.T) {
+	results := testResults()
+	earlier := model.NewDriftResultAt("i-1", model.OriginTerraform, results[0].Timestamp.Add(-45*24*time.Hour))
+	earlier.AddDriftedAttribute("instance_type", "t3.micro", "t3.large")
+	results[0].SetDriftAges([]*model.DriftResult{earlier}, 30)
+
+	view := NewReportView(results, nil, time.Now())
+	require.Len(t, view.Drifted, 1)
+	assert.Equal(t, "45d", view.Drifted[0].DriftAge)
+	for _, drift := range view.Drifted[0].Drifts {
+		if drift.Path == "instance_type" {
+			assert.Equal(t, "45d", drift.Age)
+			assert.Equal(t, earlier.Timestamp, drift.FirstDetected)
+		} else {
+			assert.Equal(t, "new", drift.Age)
+		}
+	}
+
+	var buf bytes.Buffer
+	console := NewConsoleReporter(logging.New(), ReporterOptions{})
+	console.SetColorEnabled(false)
+	console.out = &buf
+	require.NoError(t, console.ReportMultipleDrifts(results))
+	assert.Contains(t, buf.String(), "web (i-1)  instance_type, tags.Env, vpc_security_group_ids  45d")
+
+	// Results without tracked ages show no age
+	buf.Reset()
+	require.NoError(t, console.ReportMultipleDrifts(testResults()))
+	assert.Contains(t, buf.String(), "web (i-1)  instance_type, tags.Env, vpc_security_group_ids  -")
+
+	// JSON reports carry the first detection and the age of each drift
+	data, err := json.Marshal(results[0].DriftedAttributes["instance_type"])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"first_detected":"`+earlier.Timestamp.Format(time.RFC3339Nano)+`"`)
+	assert.Contains(t, string(data), `"drift_age":`)
+	data, err = json.Marshal(testResults()[0].DriftedAttributes["instance_type"])
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "first_detected")
+}
+
+func TestConsoleReporter_CustomTemplate(t *testing.T) {
+	tmpl, err := LoadTemplate(TemplateConsole, writeTemplate(t, "{{header \"Drift\"}} {{.DriftedCount}}/{{.TotalInstances}}\n"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	reporter := NewConsoleReporterWithTemplate(logging.New(), ReporterOptions{}, tmpl)
+	reporter.SetColorEnabled(false)
+	reporter.out = &buf
+
+	require.NoError(t, reporter.ReportMultipleDrifts(testResults()))
+	assert.Equal(t, "=== Drift === 1/2\n", buf.String())
+}
+
+func TestMarkdownReporter(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "reports", "drift.md")
+	reporter := NewMarkdownReporter(logging.New(), ReporterOptions{OutputFile: outputFile}, nil)
+	reporter.clock = clock.NewFake(time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC))
+
+	require.NoError(t, reporter.ReportMultipleDrifts(testResults()))
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	report := string(data)
+	assert.Contains(t, report, "Generated 2025-05-01T10:00:00Z")
+	assert.Contains(t, report, "| 2 | 1 | 3 | 0 |")
+	assert.Contains(t, report, "### web (i-1)")
+	assert.Contains(t, report, "| Attribute | Severity | Terraform Value (desired) | AWS Value (current) |")
+	assert.Contains(t, report, "| `vpc_security_group_ids` | high | [sg-1] | [sg-1 sg-2] |")
+
+	// Pipes in values don't break the table
+	assert.Contains(t, report, "| `tags.Env` | low | prod | staging\\|qa |")
+}
+
+func TestMarkdownReporter_ValueLabelsFollowSourceOfTruth(t *testing.T) {
+	for _, tc := range []struct {
+		sourceOfTruth model.ResourceOrigin
+		header        string
+	}{
+		{model.OriginTerraform, "| Attribute | Severity | Terraform Value (desired) | AWS Value (current) |"},
+		{model.OriginAWS, "| Attribute | Severity | AWS Value (desired) | Terraform Value (current) |"},
+	} {
+		t.Run(string(tc.sourceOfTruth), func(t *testing.T) {
+			result := model.NewDriftResult("i-1", tc.sourceOfTruth)
+			result.AddDriftedAttribute(model.AttributeExists, false, true)
+
+			var buf bytes.Buffer
+			require.NoError(t, renderTemplate(&buf, mustLoadBuiltinTemplate(TemplateMarkdown), NewReportView([]*model.DriftResult{result}, nil, time.Now()), false))
+			assert.Contains(t, buf.String(), tc.header)
+			assert.Contains(t, buf.String(), "| `exists` | medium | false | true |")
+		})
+	}
+}
+
+func TestReporters_Workspaces(t *testing.T) {
+	prod := model.NewDriftResult("i-1", model.OriginTerraform)
+	prod.Workspace = "prod"
+	prod.SetNames("web", "web")
+	prod.AddDriftedAttribute("instance_type", "t3.micro", "t3.large")
+	dev := model.NewDriftResult("i-2", model.OriginTerraform)
+	dev.Workspace = "dev"
+	devDrifted := model.NewDriftResult("i-3", model.OriginTerraform)
+	devDrifted.Workspace = "dev"
+	devDrifted.AddDriftedAttribute("ami", "ami-1", "ami-2")
+	results := []*model.DriftResult{prod, dev, devDrifted}
+
+	view := NewReportView(results, nil, time.Now())
+	require.Len(t, view.Workspaces, 2)
+	assert.Equal(t, "dev", view.Workspaces[0].Name)
+	assert.Equal(t, 2, view.Workspaces[0].TotalInstances)
+	assert.Equal(t, 1, view.Workspaces[0].DriftedCount)
+	require.Len(t, view.Workspaces[0].Drifted, 1)
+	assert.Equal(t, "i-3", view.Workspaces[0].Drifted[0].ID)
+
+	var buf bytes.Buffer
+	console := NewConsoleReporter(logging.New(), ReporterOptions{})
+	console.SetColorEnabled(false)
+	console.out = &buf
+	require.NoError(t, console.ReportMultipleDrifts(results))
+	output := buf.String()
+	assert.Contains(t, output, "dev        2          1\n")
+	assert.Contains(t, output, "=== Instances with Drift in Workspace dev ===\n\nInstance  Drifted Attributes")
+	assert.Contains(t, output, "=== Instances with Drift in Workspace prod ===\n\nInstance   Drifted Attributes")
+	assert.Less(t, strings.Index(output, "Workspace dev ==="), strings.Index(output, "Workspace prod ==="))
+	assert.NotContains(t, output, "=== Instances with Drift ===")
+
+	outputFile := filepath.Join(t.TempDir(), "drift.md")
+	require.NoError(t, NewMarkdownReporter(logging.New(), ReporterOptions{OutputFile: outputFile}, nil).ReportMultipleDrifts(results))
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	report := string(data)
+	assert.Contains(t, report, "## Workspaces\n\n| Workspace | Instances | Drifted |\n|-----------|-----------|---------|\n| dev | 2 | 1 |\n| prod | 1 | 1 |")
+	assert.Contains(t, report, "## Drifted instances in workspace prod\n\n### web (i-1)")
+	assert.NotContains(t, report, "## Drifted instances\n")
+}
+
+func TestReporters_Timezone(t *testing.T) {
+	instant := time.Date(2025, 5, 1, 22, 30, 0, 0, time.UTC)
+	results := testResults()
+	for _, result := range results {
+		result.Timestamp = instant
+	}
+
+	tests := []struct {
+		timezone string
+		want     string
+	}{
+		{timezone: "America/New_York", want: "2025-05-01T18:30:00-04:00"},
+		{timezone: "Asia/Tokyo", want: "2025-05-02T07:30:00+09:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			loc, err := LoadLocation(tt.timezone)
+			require.NoError(t, err)
+
+			outputFile := filepath.Join(t.TempDir(), "drift.md")
+			markdown := NewMarkdownReporter(logging.New(), ReporterOptions{OutputFile: outputFile}, nil)
+			markdown.clock = clock.NewFake(instant)
+			markdown.SetLocation(loc)
+			require.NoError(t, markdown.ReportMultipleDrifts(results))
+
+			data, err := os.ReadFile(outputFile)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "Generated "+tt.want)
+
+			var buf bytes.Buffer
+			console := NewConsoleReporter(logging.New(), ReporterOptions{})
+			console.SetColorEnabled(false)
+			console.SetLocation(loc)
+			console.out = &buf
+			require.NoError(t, console.ReportMultipleDrifts(results))
+			assert.Contains(t, buf.String(), tt.want)
+
+			// Results keep the instant they were recorded at
+			assert.Equal(t, time.UTC, results[0].Timestamp.Location())
+		})
+	}
+
+	_, err := LoadLocation("Mars/Olympus_Mons")
+	assert.True(t, errors.IsValidationError(err))
+
+	loc, err := LoadLocation("")
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, loc)
+}
+
+func TestReporters_Remediation(t *testing.T) {
+	drifted := model.NewDriftResult("i-1", model.OriginTerraform)
+	drifted.SetNames("web", "web")
+	drifted.ResourceAddress = "aws_instance.web"
+	drifted.AddDriftedAttribute("instance_type", "t3.micro", "t3.large")
+	drifted.Remediation = []model.RemediationStep{
+		{Description: "Review and apply the Terraform configuration of aws_instance.web", Command: "terraform plan -target=aws_instance.web"},
+		{Description: "Update the configuration", Snippet: "resource \"aws_instance\" \"web\" {\n  instance_type = \"t3.large\"\n}"},
+	}
+	clean := model.NewDriftResult("i-2", model.OriginTerraform)
+	results := []*model.DriftResult{drifted, clean}
+
+	view := NewReportView(results, nil, time.Now())
+	require.Len(t, view.Remediation, 1)
+	assert.Equal(t, "i-1", view.Remediation[0].ID)
+
+	var buf bytes.Buffer
+	console := NewConsoleReporter(logging.New(), ReporterOptions{})
+	console.SetColorEnabled(false)
+	console.out = &buf
+	require.NoError(t, console.ReportMultipleDrifts(results))
+	assert.Contains(t, buf.String(), "=== Remediation ===\n\nweb (i-1) (aws_instance.web)\n"+
+		"  - Review and apply the Terraform configuration of aws_instance.web\n"+
+		"    $ terraform plan -target=aws_instance.web\n"+
+		"  - Update the configuration\n"+
+		"    resource \"aws_instance\" \"web\" {\n      instance_type = \"t3.large\"\n    }\n")
+
+	outputFile := filepath.Join(t.TempDir(), "drift.md")
+	require.NoError(t, NewMarkdownReporter(logging.New(), ReporterOptions{OutputFile: outputFile}, nil).ReportMultipleDrifts(results))
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "## Remediation\n\n### web (i-1) (`aws_instance.web`)\n\n"+
+		"Review and apply the Terraform configuration of aws_instance.web\n\n```sh\nterraform plan -target=aws_instance.web\n```\n\n"+
+		"Update the configuration\n\n```hcl\nresource \"aws_instance\" \"web\" {\n  instance_type = \"t3.large\"\n}\n```")
+
+	// Without suggestions there is no section
+	buf.Reset()
+	drifted.Remediation = nil
+	require.NoError(t, console.ReportMultipleDrifts(results))
+	assert.NotContains(t, buf.String(), "Remediation")
+}
+
+func TestReporters_NetworkContext(t *testing.T) {
+	result := model.NewDriftResult("i-1", model.OriginTerraform)
+	result.SetNames("web", "web")
+	drift := model.NewAttributeDrift("subnet_id", "subnet-a", "subnet-c")
+	drift.Network = model.NewNetworkContext(
+		&model.Subnet{ID: "subnet-a", VPCID: "vpc-1", AvailabilityZone: "us-east-1a"},
+		&model.Subnet{ID: "subnet-c", VPCID: "vpc-2", AvailabilityZone: "us-east-1a"},
+	)
+	drift.Severity = model.SeverityHigh
+	result.SetDriftedAttributes(map[string]model.AttributeDrift{"subnet_id": drift})
+
+	// The drift's own severity overrides the attribute's
+	view := NewReportView([]*model.DriftResult{result}, nil, time.Now())
+	require.Len(t, view.Results[0].Drifts, 1)
+	assert.Equal(t, SeverityHigh, view.Results[0].Drifts[0].Severity)
+	assert.Equal(t, SeverityMedium, model.DriftSeverity("subnet_id"))
+
+	outputFile := filepath.Join(t.TempDir(), "drift.md")
+	require.NoError(t, NewMarkdownReporter(logging.New(), ReporterOptions{OutputFile: outputFile}, nil).ReportMultipleDrifts([]*model.DriftResult{result}))
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "| `subnet_id` | high | subnet-a | subnet-c |")
+	assert.Contains(t, string(data), "_subnet_id moved to another VPC: subnet-a in vpc-1, us-east-1a -> subnet-c in vpc-2, us-east-1a; routes, peering and security groups of vpc-1 no longer apply_")
+}
+
+func TestReporters_DuplicateAddresses(t *testing.T) {
+	duplicated := model.NewDriftResult("i-1", model.OriginTerraform)
+	duplicated.SetNames("web", "web")
+	duplicated.ResourceAddress = "aws_instance.web"
+	duplicated.DuplicateAddresses = []string{"aws_instance.web_imported", "module.legacy.aws_instance.web"}
+	results := []*model.DriftResult{duplicated, model.NewDriftResult("i-2", model.OriginTerraform)}
+
+	var buf bytes.Buffer
+	console := NewConsoleReporter(logging.New(), ReporterOptions{})
+	console.SetColorEnabled(false)
+	console.out = &buf
+	require.NoError(t, console.ReportMultipleDrifts(results))
+	assert.Contains(t, buf.String(), "=== Duplicate Terraform Resources ===")
+	assert.Regexp(t, `web \(i-1\)\s+aws_instance\.web\s+aws_instance\.web_imported, module\.legacy\.aws_instance\.web`, buf.String())
+
+	outputFile := filepath.Join(t.TempDir(), "drift.md")
+	require.NoError(t, NewMarkdownReporter(logging.New(), ReporterOptions{OutputFile: outputFile}, nil).ReportMultipleDrifts(results))
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "| web (i-1) | `aws_instance.web` | aws_instance.web_imported, module.legacy.aws_instance.web |")
+}
@@ -0,0 +1,58 @@
+package reporter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/common/errors"
+)
+
+// LoadLocation resolves the IANA time zone report timestamps are shown in. An empty name keeps
+// the system time zone.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid reporter.timezone %q: expected an IANA time zone such as Europe/Berlin: %v", name, err))
+	}
+	return loc, nil
+}
+
+// In returns the view with its timestamps converted to loc
+func (v ReportView) In(loc *time.Location) ReportView {
+	if loc == nil {
+		return v
+	}
+
+	v.GeneratedAt = v.GeneratedAt.In(loc)
+	v.Results = resultsIn(v.Results, loc)
+	v.Drifted = resultsIn(v.Drifted, loc)
+	v.Remediation = resultsIn(v.Remediation, loc)
+
+	if v.Workspaces != nil {
+		workspaces := make([]WorkspaceView, len(v.Workspaces))
+		for i, workspace := range v.Workspaces {
+			workspace.Drifted = resultsIn(workspace.Drifted, loc)
+			workspaces[i] = workspace
+		}
+		v.Workspaces = workspaces
+	}
+	return v
+}
+
+// resultsIn copies results with their timestamps converted to loc
+func resultsIn(results []ResultView, loc *time.Location) []ResultView {
+	if results == nil {
+		return nil
+	}
+
+	converted := make([]ResultView, len(results))
+	for i, result := range results {
+		result.Timestamp = result.Timestamp.In(loc)
+		converted[i] = result
+	}
+	return converted
+}
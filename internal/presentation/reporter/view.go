@@ -0,0 +1,321 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/victor-devv/ec2-drift-detector/internal/domain/model"
+)
+
+// Drift severities, from the attribute that drifted
+const (
+	SeverityHigh   = model.SeverityHigh
+	SeverityMedium = model.SeverityMedium
+	SeverityLow    = model.SeverityLow
+)
+
+// ReportView is the data report templates are executed against. Results keep the order they
+// were reported in; drifts, skipped attributes, accounts and workspaces are sorted.
+type ReportView struct {
+	// GeneratedAt is when the report was rendered
+	GeneratedAt time.Time
+
+	// TotalInstances is the number of instances checked
+	TotalInstances int
+
+	// DriftedCount is the number of instances with drift, apart from unmanaged instances
+	DriftedCount int
+
+	// UnmanagedCount is the number of instances in AWS no Terraform resource manages
+	UnmanagedCount int
+
+	// DriftedAttributes is the number of drifted attributes across all instances
+	DriftedAttributes int
+
+	// ViolationCount is the number of instances that violate policies
+	ViolationCount int
+
+	// Accounts breaks the counts down per AWS account when scanning multiple accounts
+	Accounts []AccountView
+
+	// Workspaces sections the report per Terraform workspace when comparing several workspaces
+	Workspaces []WorkspaceView
+
+	// TopAttributes aggregates drift per attribute across all instances, most common first
+	TopAttributes []AttributeView
+
+	// Results has every checked instance, Drifted only the managed ones with drift and
+	// Unmanaged the instances no Terraform resource manages
+	Results   []ResultView
+	Drifted   []ResultView
+	Unmanaged []ResultView
+
+	// Remediation has the drifted instances with suggested remediation steps
+	Remediation []ResultView
+
+	// Duplicated has the instances that several Terraform resources in the state track
+	Duplicated []ResultView
+}
+
+// AccountView is the per-account breakdown of a report
+type AccountView struct {
+	AccountID      string
+	TotalInstances int
+	DriftedCount   int
+}
+
+// WorkspaceView is the section of a report for one Terraform workspace
+type WorkspaceView struct {
+	Name           string
+	TotalInstances int
+	DriftedCount   int
+
+	// Drifted has the instances with drift in this workspace
+	Drifted []ResultView
+}
+
+// AttributeView is how many instances drifted on an attribute, with sample drifted values
+type AttributeView struct {
+	Path             string
+	Severity         string
+	DriftedInstances int
+	SampleValues     []string
+}
+
+// ResultView is the outcome of checking one instance
+type ResultView struct {
+	ID         string
+	ResultID   string
+	Name       string
+	AccountID  string
+	Workspace  string
+	SourceType string
+	Timestamp  time.Time
+	HasDrift   bool
+
+	// Label is the Name tag and ID, and the account when known
+	Label string
+
+	// DesiredLabel and CurrentLabel head the desired and current values with the provider each
+	// comes from, e.g. "Terraform Value" and "AWS Value" when Terraform is the source of truth
+	DesiredLabel string
+	CurrentLabel string
+
+	// Drifts are sorted by attribute path, DriftedPaths lists the same paths
+	Drifts       []DriftView
+	DriftedPaths []string
+
+	// DriftAge is the age of the instance's oldest drift, e.g. "45d", empty when drift age
+	// isn't tracked
+	DriftAge string
+
+	// Skipped lists attributes that couldn't be compared, e.g. unknown values in HCL
+	Skipped []SkippedView
+
+	// PolicyViolations describes each policy the instance fails
+	PolicyViolations []string
+
+	// ResourceAddress is the Terraform address of the instance, when known
+	ResourceAddress string
+
+	// DuplicateAddresses are other Terraform resources tracking the instance that weren't compared
+	DuplicateAddresses []string
+
+	// Remediation are the suggested steps that resolve the drift, when requested
+	Remediation []RemediationView
+}
+
+// RemediationView is a suggested remediation step: a command, a configuration snippet or both
+type RemediationView struct {
+	Description string
+	Command     string
+	Snippet     string
+}
+
+// DriftView is one drifted attribute
+type DriftView struct {
+	Path string
+
+	// DesiredValue is the source of truth's value and CurrentValue the other side's
+	DesiredValue string
+	CurrentValue string
+
+	// SourceValue and TargetValue repeat the desired and current values for templates written
+	// before they were labeled
+	SourceValue string
+	TargetValue string
+
+	// Severity is high, medium or low
+	Severity string
+
+	// TerraformAttribute names the state attribute compared when it differs from Path, e.g. tags_all
+	TerraformAttribute string
+
+	// Diff is a unified diff for attributes compared by hash, such as user data
+	Diff string
+
+	// Network describes the subnets of a subnet_id drift when network context is enriched
+	Network string
+
+	// FirstDetected is when the drift first appeared in consecutive runs and Age how long it
+	// has lasted, e.g. "45d" or "new"; both are empty when drift age isn't tracked
+	FirstDetected time.Time
+	Age           string
+}
+
+// SkippedView is an attribute that was not compared and why
+type SkippedView struct {
+	Path   string
+	Reason string
+}
+
+// NewReportView builds the template view of a run's results
+func NewReportView(results []*model.DriftResult, summary []model.AttributeSummary, generatedAt time.Time) ReportView {
+	view := ReportView{
+		GeneratedAt:    generatedAt,
+		TotalInstances: len(results),
+		Results:        make([]ResultView, 0, len(results)),
+	}
+
+	for _, attr := range summary {
+		samples := make([]string, 0, len(attr.SampleValues))
+		for _, value := range attr.SampleValues {
+			samples = append(samples, fmt.Sprintf("%v", value))
+		}
+		view.TopAttributes = append(view.TopAttributes, AttributeView{
+			Path:             attr.Path,
+			Severity:         model.DriftSeverity(attr.Path),
+			DriftedInstances: attr.DriftedInstances,
+			SampleValues:     samples,
+		})
+	}
+
+	for _, result := range results {
+		resultView := newResultView(result)
+		view.Results = append(view.Results, resultView)
+		if result.Unmanaged {
+			view.UnmanagedCount++
+			view.Unmanaged = append(view.Unmanaged, resultView)
+		} else if result.HasDrift {
+			view.DriftedCount++
+			view.DriftedAttributes += len(resultView.Drifts)
+			view.Drifted = append(view.Drifted, resultView)
+			if len(resultView.Remediation) > 0 {
+				view.Remediation = append(view.Remediation, resultView)
+			}
+		}
+		if result.HasPolicyViolations() {
+			view.ViolationCount++
+		}
+		if len(result.DuplicateAddresses) > 0 {
+			view.Duplicated = append(view.Duplicated, resultView)
+		}
+	}
+
+	accounts := model.SummarizeByAccount(results)
+	for id, account := range accounts {
+		view.Accounts = append(view.Accounts, AccountView{AccountID: id, TotalInstances: account.TotalInstances, DriftedCount: account.DriftedCount})
+	}
+	sort.Slice(view.Accounts, func(i, j int) bool {
+		return view.Accounts[i].AccountID < view.Accounts[j].AccountID
+	})
+
+	view.Workspaces = newWorkspaceViews(results, view.Results)
+
+	return view
+}
+
+// newWorkspaceViews sections results per workspace. Returns nil when none of the results are
+// tagged with a workspace.
+func newWorkspaceViews(results []*model.DriftResult, views []ResultView) []WorkspaceView {
+	summaries := model.SummarizeByWorkspace(results)
+	if summaries == nil {
+		return nil
+	}
+
+	workspaces := make([]WorkspaceView, 0, len(summaries))
+	index := make(map[string]int, len(summaries))
+	for name, summary := range summaries {
+		workspaces = append(workspaces, WorkspaceView{Name: name, TotalInstances: summary.TotalInstances, DriftedCount: summary.DriftedCount})
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].Name < workspaces[j].Name
+	})
+	for i, workspace := range workspaces {
+		index[workspace.Name] = i
+	}
+
+	for _, view := range views {
+		i, ok := index[view.Workspace]
+		if ok && view.HasDrift {
+			workspaces[i].Drifted = append(workspaces[i].Drifted, view)
+		}
+	}
+	return workspaces
+}
+
+// newResultView builds the template view of one instance's result
+func newResultView(result *model.DriftResult) ResultView {
+	view := ResultView{
+		ID:         result.ResourceID,
+		ResultID:   result.ID,
+		Name:       result.Name(),
+		AccountID:  result.AccountID,
+		Workspace:  result.Workspace,
+		SourceType: string(result.SourceType),
+		Timestamp:  result.Timestamp,
+		HasDrift:   result.HasDrift,
+		Label:      result.Label(),
+
+		ResourceAddress:    result.ResourceAddress,
+		DuplicateAddresses: result.DuplicateAddresses,
+	}
+	view.DesiredLabel, view.CurrentLabel = result.ValueLabels()
+	if oldest, ok := result.OldestDrift(); ok {
+		view.DriftAge = oldest.AgeLabel()
+	}
+
+	for path, drift := range result.DriftedAttributes {
+		desired, current := drift.DesiredAndCurrent()
+		driftView := DriftView{
+			Path:               path,
+			DesiredValue:       fmt.Sprintf("%v", desired),
+			CurrentValue:       fmt.Sprintf("%v", current),
+			SourceValue:        fmt.Sprintf("%v", drift.SourceValue),
+			TargetValue:        fmt.Sprintf("%v", drift.TargetValue),
+			Severity:           model.AttributeSeverity(path, drift),
+			TerraformAttribute: drift.TerraformAttribute,
+			Diff:               drift.Diff,
+			FirstDetected:      drift.FirstDetected,
+			Age:                drift.AgeLabel(),
+		}
+		if drift.Network != nil {
+			driftView.Network = drift.Network.String()
+		}
+		view.Drifts = append(view.Drifts, driftView)
+	}
+	sort.Slice(view.Drifts, func(i, j int) bool {
+		return view.Drifts[i].Path < view.Drifts[j].Path
+	})
+	for _, drift := range view.Drifts {
+		view.DriftedPaths = append(view.DriftedPaths, drift.Path)
+	}
+
+	for path, reason := range result.SkippedAttributes {
+		view.Skipped = append(view.Skipped, SkippedView{Path: path, Reason: reason})
+	}
+	sort.Slice(view.Skipped, func(i, j int) bool {
+		return view.Skipped[i].Path < view.Skipped[j].Path
+	})
+
+	for _, violation := range result.PolicyViolations {
+		view.PolicyViolations = append(view.PolicyViolations, violation.String())
+	}
+
+	for _, step := range result.Remediation {
+		view.Remediation = append(view.Remediation, RemediationView{Description: step.Description, Command: step.Command, Snippet: step.Snippet})
+	}
+
+	return view
+}
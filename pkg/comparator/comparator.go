@@ -1,7 +1,6 @@
 package comparator
 
 import (
-	"fmt"
 	"reflect"
 	"strings"
 	"sync"
@@ -20,6 +19,37 @@ type Comparator struct {
 	
 	// TrimWhitespace indicates whether to trim whitespace in string comparisons
 	TrimWhitespace bool
+
+	// EmptyEqualsAbsent treats empty strings, slices and maps as equal to nil or missing values
+	EmptyEqualsAbsent bool
+
+	// MaxValueBytes caps the serialized size of values kept on diff entries (0 uses
+	// DefaultMaxValueBytes)
+	MaxValueBytes int
+
+	// Comparators override how the values at exact paths are compared
+	Comparators map[string]EqualFunc
+}
+
+// EqualFunc reports whether two values of an attribute are equivalent
+type EqualFunc func(a, b interface{}) bool
+
+// RegisterComparator sets the function the values at path are compared with, replacing the
+// default comparison for that path. Map values with a comparator are compared as a whole
+// rather than key by key.
+func (c *Comparator) RegisterComparator(path string, fn EqualFunc) {
+	if c.Comparators == nil {
+		c.Comparators = make(map[string]EqualFunc)
+	}
+	c.Comparators[path] = fn
+}
+
+// equalAt compares the values at path with the comparator registered for it, if any
+func (c *Comparator) equalAt(path string, a, b interface{}) bool {
+	if equal, ok := c.Comparators[path]; ok && equal != nil {
+		return equal(a, b)
+	}
+	return c.areEqual(a, b)
 }
 
 // DiffEntry represents a difference between two values
@@ -35,6 +65,19 @@ type DiffEntry struct {
 	
 	// Changed indicates whether the values are different
 	Changed bool
+
+	// SourceValueType and TargetValueType are the original Go types of values that had to be
+	// converted to JSON-safe types or truncated, and empty otherwise
+	SourceValueType string
+	TargetValueType string
+}
+
+// newDiff returns the diff entry for a changed path, with both values sanitized
+func (c *Comparator) newDiff(path string, source, target interface{}) DiffEntry {
+	entry := DiffEntry{Path: path, Changed: true}
+	entry.SourceValue, entry.SourceValueType = Sanitize(source, c.MaxValueBytes)
+	entry.TargetValue, entry.TargetValueType = Sanitize(target, c.MaxValueBytes)
+	return entry
 }
 
 // NewComparator creates a new comparator with default settings
@@ -67,27 +110,21 @@ func (c *Comparator) Compare(source, target interface{}, paths []string) map[str
 				return
 			}
 
+			if c.EmptyEqualsAbsent && IsEmpty(sourceVal) && IsEmpty(targetVal) {
+				return
+			}
+
 			if !sourceExists || !targetExists {
 				resultMutex.Lock()
-				result[attrPath] = DiffEntry{
-					Path:        attrPath,
-					SourceValue: sourceVal,
-					TargetValue: targetVal,
-					Changed:     true,
-				}
+				result[attrPath] = c.newDiff(attrPath, sourceVal, targetVal)
 				resultMutex.Unlock()
 				return
 			}
 
 			// If both values exist, compare them
-			if !c.areEqual(sourceVal, targetVal) {
+			if !c.equalAt(attrPath, sourceVal, targetVal) {
 				resultMutex.Lock()
-				result[attrPath] = DiffEntry{
-					Path:        attrPath,
-					SourceValue: sourceVal,
-					TargetValue: targetVal,
-					Changed:     true,
-				}
+				result[attrPath] = c.newDiff(attrPath, sourceVal, targetVal)
 				resultMutex.Unlock()
 			}
 		}(path)
@@ -107,13 +144,8 @@ func (c *Comparator) CompareDeep(source, target interface{}) map[string]DiffEntr
 	
 	if !sourceIsMap || !targetIsMap {
 		// If either is not a map, compare directly
-		if !c.areEqual(source, target) {
-			result[""] = DiffEntry{
-				Path:        "",
-				SourceValue: source,
-				TargetValue: target,
-				Changed:     true,
-			}
+		if !c.equalAt("", source, target) {
+			result[""] = c.newDiff("", source, target)
 		}
 		return result
 	}
@@ -162,15 +194,17 @@ func (c *Comparator) compareRecursive(source, target map[string]interface{}, bas
 		
 		targetVal, exists := target[key]
 		if !exists {
-			result.Store(path, DiffEntry{
-				Path:        path,
-				SourceValue: sourceVal,
-				TargetValue: nil,
-				Changed:     true,
-			})
+			result.Store(path, c.newDiff(path, sourceVal, nil))
 			continue
 		}
 		
+		if _, ok := c.Comparators[path]; ok {
+			if !c.equalAt(path, sourceVal, targetVal) {
+				result.Store(path, c.newDiff(path, sourceVal, targetVal))
+			}
+			continue
+		}
+
 		// Check if both values are maps
 		sourceMapVal, sourceIsMap := c.interfaceToMap(sourceVal)
 		targetMapVal, targetIsMap := c.interfaceToMap(targetVal)
@@ -181,12 +215,7 @@ func (c *Comparator) compareRecursive(source, target map[string]interface{}, bas
 			go c.compareRecursive(sourceMapVal, targetMapVal, path, depth-1, result, wg)
 		} else if !c.areEqual(sourceVal, targetVal) {
 			// Compare non-map values
-			result.Store(path, DiffEntry{
-				Path:        path,
-				SourceValue: sourceVal,
-				TargetValue: targetVal,
-				Changed:     true,
-			})
+			result.Store(path, c.newDiff(path, sourceVal, targetVal))
 		}
 	}
 	
@@ -203,12 +232,7 @@ func (c *Comparator) compareRecursive(source, target map[string]interface{}, bas
 		}
 		
 		if _, exists := source[key]; !exists {
-			result.Store(path, DiffEntry{
-				Path:        path,
-				SourceValue: nil,
-				TargetValue: targetVal,
-				Changed:     true,
-			})
+			result.Store(path, c.newDiff(path, nil, targetVal))
 		}
 	}
 }
@@ -254,6 +278,10 @@ func (c *Comparator) areEqual(a, b interface{}) bool {
 	if a == nil && b == nil {
 		return true
 	}
+
+	if c.EmptyEqualsAbsent && IsEmpty(a) && IsEmpty(b) {
+		return true
+	}
 	
 	if a == nil || b == nil {
 		return false
@@ -284,8 +312,13 @@ func (c *Comparator) areEqual(a, b interface{}) bool {
 		if aVal.Len() != bVal.Len() {
 			return false
 		}
+
+		// Scalar elements are matched by key in linear time
+		if equal, ok := c.scalarSlicesEqual(aVal, bVal); ok {
+			return equal
+		}
 		
-		// Check if all elements are equal
+		// Otherwise check that every element has an equal element in b
 		for i := 0; i < aVal.Len(); i++ {
 			aElem := aVal.Index(i).Interface()
 			
@@ -311,6 +344,70 @@ func (c *Comparator) areEqual(a, b interface{}) bool {
 	return reflect.DeepEqual(a, b)
 }
 
+// scalarSlicesEqual compares two slices of scalars as multisets, ignoring order. It reports
+// false for ok when an element is not a scalar, so the caller can fall back to pairwise matching.
+func (c *Comparator) scalarSlicesEqual(a, b reflect.Value) (equal bool, ok bool) {
+	counts := make(map[interface{}]int, b.Len())
+	for i := 0; i < b.Len(); i++ {
+		key, ok := c.elementKey(b.Index(i).Interface())
+		if !ok {
+			return false, false
+		}
+		counts[key]++
+	}
+
+	keys := make([]interface{}, a.Len())
+	for i := range keys {
+		key, ok := c.elementKey(a.Index(i).Interface())
+		if !ok {
+			return false, false
+		}
+		keys[i] = key
+	}
+
+	for _, key := range keys {
+		if counts[key] == 0 {
+			return false, true
+		}
+		counts[key]--
+	}
+	return true, true
+}
+
+// elementKey returns the key a scalar slice element is matched by, applying the string options.
+// Keys keep the element's type, so values of different types never match, as with areEqual.
+func (c *Comparator) elementKey(elem interface{}) (interface{}, bool) {
+	switch v := elem.(type) {
+	case string:
+		if c.TrimWhitespace {
+			v = strings.TrimSpace(v)
+		}
+		if c.IgnoreCase {
+			v = strings.ToLower(v)
+		}
+		return v, true
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v, true
+	}
+	return nil, false
+}
+
+// IsEmpty reports whether a value is nil, an empty string, or an empty slice or map
+func IsEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return val.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return val.IsNil()
+	}
+	return false
+}
+
 // interfaceToMap converts an interface to a map
 func (c *Comparator) interfaceToMap(obj interface{}) (map[string]interface{}, bool) {
 	// If it's already a map, return it
@@ -368,23 +465,13 @@ func (c *Comparator) CompareFields(source, target interface{}, fields []string)
 		
 		// If either doesn't exist, mark as changed
 		if !sourceExists || !targetExists {
-			result[field] = DiffEntry{
-				Path:        field,
-				SourceValue: sourceVal,
-				TargetValue: targetVal,
-				Changed:     true,
-			}
+			result[field] = c.newDiff(field, sourceVal, targetVal)
 			continue
 		}
 		
 		// Compare the values
-		if !c.areEqual(sourceVal, targetVal) {
-			result[field] = DiffEntry{
-				Path:        field,
-				SourceValue: sourceVal,
-				TargetValue: targetVal,
-				Changed:     true,
-			}
+		if !c.equalAt(field, sourceVal, targetVal) {
+			result[field] = c.newDiff(field, sourceVal, targetVal)
 		}
 	}
 	
@@ -393,16 +480,5 @@ func (c *Comparator) CompareFields(source, target interface{}, fields []string)
 
 // FormatDiff formats a diff entry as a string
 func (c *Comparator) FormatDiff(entry DiffEntry) string {
-	sourceStr := fmt.Sprintf("%v", entry.SourceValue)
-	targetStr := fmt.Sprintf("%v", entry.TargetValue)
-	
-	if entry.SourceValue == nil {
-		sourceStr = "<nil>"
-	}
-	
-	if entry.TargetValue == nil {
-		targetStr = "<nil>"
-	}
-	
-	return fmt.Sprintf("%s: %s => %s", entry.Path, sourceStr, targetStr)
+	return PlainFormatter{}.FormatDiff(entry)
 }
@@ -1,6 +1,7 @@
 package comparator
 
 import (
+	"fmt"
 	"reflect"
 	"sync"
 	"testing"
@@ -498,3 +499,119 @@ func TestFormatDiff(t *testing.T) {
 	formatted = c.FormatDiff(diff)
 	assert.Equal(t, "email: <nil> => john@example.com", formatted)
 }
+
+func TestIsEmpty(t *testing.T) {
+	assert.True(t, IsEmpty(nil))
+	assert.True(t, IsEmpty(""))
+	assert.True(t, IsEmpty([]string{}))
+	assert.True(t, IsEmpty(map[string]interface{}{}))
+	assert.False(t, IsEmpty("x"))
+	assert.False(t, IsEmpty([]string{"sg-1"}))
+	assert.False(t, IsEmpty(0))
+	assert.False(t, IsEmpty(false))
+}
+
+func TestCompare_EmptyEqualsAbsent(t *testing.T) {
+	source := map[string]interface{}{"tags": map[string]interface{}{}, "sgs": []interface{}{}}
+	target := map[string]interface{}{"sgs": []interface{}{"sg-1"}}
+
+	c := NewComparator()
+	assert.Len(t, c.Compare(source, target, []string{"tags", "sgs"}), 2)
+
+	c.EmptyEqualsAbsent = true
+	diffs := c.Compare(source, target, []string{"tags", "sgs"})
+	assert.Len(t, diffs, 1)
+	assert.Contains(t, diffs, "sgs")
+}
+
+func TestAreEqual_ScalarSlices(t *testing.T) {
+	c := NewComparator()
+
+	// Order doesn't matter but duplicates do
+	assert.True(t, c.areEqual([]string{"sg-1", "sg-2", "sg-2"}, []string{"sg-2", "sg-1", "sg-2"}))
+	assert.False(t, c.areEqual([]string{"sg-1", "sg-1", "sg-2"}, []string{"sg-1", "sg-2", "sg-2"}))
+	assert.True(t, c.areEqual([]interface{}{1, 2, 3}, []interface{}{3, 2, 1}))
+
+	// Values of different types are not equal
+	assert.False(t, c.areEqual([]interface{}{1}, []interface{}{1.0}))
+	assert.False(t, c.areEqual([]interface{}{"1"}, []interface{}{1}))
+
+	// String options apply to elements
+	assert.False(t, c.areEqual([]string{" A", "b"}, []string{"a", "B "}))
+	c.IgnoreCase = true
+	c.TrimWhitespace = true
+	assert.True(t, c.areEqual([]string{" A", "b"}, []string{"a", "B "}))
+	c.IgnoreCase = false
+	c.TrimWhitespace = false
+
+	// Slices holding non-scalars are matched pairwise
+	maps1 := []interface{}{map[string]interface{}{"device": "/dev/sda1"}, "sg-1"}
+	maps2 := []interface{}{"sg-1", map[string]interface{}{"device": "/dev/sda1"}}
+	assert.True(t, c.areEqual(maps1, maps2))
+	assert.False(t, c.areEqual(maps1, []interface{}{"sg-1", map[string]interface{}{"device": "/dev/sdb"}}))
+}
+
+func BenchmarkAreEqual_Slices(b *testing.B) {
+	c := NewComparator()
+
+	for _, n := range []int{10, 100, 1000} {
+		scalars := make([]interface{}, n)
+		maps := make([]interface{}, n)
+		for i := range scalars {
+			scalars[i] = fmt.Sprintf("sg-%08d", i)
+			maps[i] = map[string]interface{}{"id": scalars[i]}
+		}
+
+		// Compare against the same elements in reverse order, the worst case for pairwise matching
+		reversedScalars := make([]interface{}, n)
+		reversedMaps := make([]interface{}, n)
+		for i := range scalars {
+			reversedScalars[i] = scalars[n-1-i]
+			reversedMaps[i] = maps[n-1-i]
+		}
+
+		b.Run(fmt.Sprintf("scalars/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				c.areEqual(scalars, reversedScalars)
+			}
+		})
+		b.Run(fmt.Sprintf("pairwise/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				c.areEqual(maps, reversedMaps)
+			}
+		})
+	}
+}
+
+func TestRegisterComparator(t *testing.T) {
+	// Sizes within 10% of each other are considered equal
+	roughly := func(a, b interface{}) bool {
+		x, y := a.(int), b.(int)
+		return x*10 >= y*9 && y*10 >= x*9
+	}
+
+	c := NewComparator()
+	c.RegisterComparator("disk.size", roughly)
+	c.RegisterComparator("labels", func(a, b interface{}) bool { return true })
+
+	source := map[string]interface{}{
+		"disk":   map[string]interface{}{"size": 100, "type": "gp3"},
+		"labels": map[string]interface{}{"team": "web"},
+	}
+	target := map[string]interface{}{
+		"disk":   map[string]interface{}{"size": 105, "type": "gp2"},
+		"labels": map[string]interface{}{"team": "api"},
+	}
+
+	diffs := c.Compare(source, target, []string{"disk.size", "disk.type", "labels"})
+	assert.Len(t, diffs, 1)
+	assert.Contains(t, diffs, "disk.type")
+
+	diffs = c.CompareDeep(source, target)
+	assert.Len(t, diffs, 1)
+	assert.Contains(t, diffs, "disk.type")
+
+	target["disk"].(map[string]interface{})["size"] = 150
+	diffs = c.CompareDeep(source, target)
+	assert.Contains(t, diffs, "disk.size")
+}
@@ -0,0 +1,111 @@
+package comparator
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DiffFormatter renders diff entries, so that every output shows differences the same way
+type DiffFormatter interface {
+	// FormatDiff renders a whole entry
+	FormatDiff(entry DiffEntry) string
+
+	// FormatValues renders the source and target values of an entry on their own, e.g. for
+	// table cells
+	FormatValues(entry DiffEntry) (source, target string)
+}
+
+// ANSI escape codes used by the colored formatter
+const (
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiBold  = "\033[1m"
+	ansiReset = "\033[0m"
+)
+
+// PlainFormatter renders entries as "path: source => target"
+type PlainFormatter struct{}
+
+// FormatDiff renders the entry as "path: source => target"
+func (PlainFormatter) FormatDiff(entry DiffEntry) string {
+	source, target := PlainFormatter{}.FormatValues(entry)
+	return fmt.Sprintf("%s: %s => %s", entry.Path, source, target)
+}
+
+// FormatValues renders both values with %v, and missing values as <nil>
+func (PlainFormatter) FormatValues(entry DiffEntry) (string, string) {
+	return plainValue(entry.SourceValue), plainValue(entry.TargetValue)
+}
+
+// plainValue renders a value with %v
+func plainValue(value interface{}) string {
+	if value == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// ColorFormatter renders entries like PlainFormatter for terminals: the path in bold, the
+// source value in green and the target value in red
+type ColorFormatter struct{}
+
+// FormatDiff renders the entry as "path: source => target" with ANSI colors
+func (ColorFormatter) FormatDiff(entry DiffEntry) string {
+	source, target := ColorFormatter{}.FormatValues(entry)
+	return fmt.Sprintf("%s%s%s: %s => %s", ansiBold, entry.Path, ansiReset, source, target)
+}
+
+// FormatValues renders the source value in green and the target value in red
+func (ColorFormatter) FormatValues(entry DiffEntry) (string, string) {
+	source, target := PlainFormatter{}.FormatValues(entry)
+	return ansiGreen + source + ansiReset, ansiRed + target + ansiReset
+}
+
+// JSONFormatter renders entries as compact JSON objects, with the field names drifted
+// attributes have in JSON reports
+type JSONFormatter struct{}
+
+// jsonDiff is the JSON form of a diff entry
+type jsonDiff struct {
+	Path            string      `json:"path"`
+	SourceValue     interface{} `json:"source_value"`
+	TargetValue     interface{} `json:"target_value"`
+	SourceValueType string      `json:"source_value_type,omitempty"`
+	TargetValueType string      `json:"target_value_type,omitempty"`
+}
+
+// FormatDiff renders the entry as a JSON object
+func (JSONFormatter) FormatDiff(entry DiffEntry) string {
+	data, err := json.Marshal(jsonDiff{
+		Path:            entry.Path,
+		SourceValue:     entry.SourceValue,
+		TargetValue:     entry.TargetValue,
+		SourceValueType: entry.SourceValueType,
+		TargetValueType: entry.TargetValueType,
+	})
+	if err != nil {
+		// Values that don't marshal are rendered as strings instead
+		data, _ = json.Marshal(jsonDiff{
+			Path:            entry.Path,
+			SourceValue:     plainValue(entry.SourceValue),
+			TargetValue:     plainValue(entry.TargetValue),
+			SourceValueType: fmt.Sprintf("%T", entry.SourceValue),
+			TargetValueType: fmt.Sprintf("%T", entry.TargetValue),
+		})
+	}
+	return string(data)
+}
+
+// FormatValues renders each value as JSON, e.g. strings quoted and maps as objects
+func (JSONFormatter) FormatValues(entry DiffEntry) (string, string) {
+	return jsonValue(entry.SourceValue), jsonValue(entry.TargetValue)
+}
+
+// jsonValue renders a value as JSON, or as a JSON string of its %v form when it doesn't marshal
+func jsonValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(plainValue(value))
+	}
+	return string(data)
+}
@@ -0,0 +1,55 @@
+package comparator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// formatterDiff is the diff every formatter is tested on
+var formatterDiff = DiffEntry{
+	Path:            "tags.Name",
+	SourceValue:     "web",
+	TargetValue:     nil,
+	Changed:         true,
+	SourceValueType: "string",
+}
+
+func TestPlainFormatter(t *testing.T) {
+	var formatter DiffFormatter = PlainFormatter{}
+
+	assert.Equal(t, "tags.Name: web => <nil>", formatter.FormatDiff(formatterDiff))
+
+	source, target := formatter.FormatValues(formatterDiff)
+	assert.Equal(t, "web", source)
+	assert.Equal(t, "<nil>", target)
+
+	// The comparator formats diffs the same way
+	assert.Equal(t, formatter.FormatDiff(formatterDiff), NewComparator().FormatDiff(formatterDiff))
+}
+
+func TestColorFormatter(t *testing.T) {
+	var formatter DiffFormatter = ColorFormatter{}
+
+	assert.Equal(t, "\033[1mtags.Name\033[0m: \033[32mweb\033[0m => \033[31m<nil>\033[0m", formatter.FormatDiff(formatterDiff))
+
+	source, target := formatter.FormatValues(formatterDiff)
+	assert.Equal(t, "\033[32mweb\033[0m", source)
+	assert.Equal(t, "\033[31m<nil>\033[0m", target)
+}
+
+func TestJSONFormatter(t *testing.T) {
+	var formatter DiffFormatter = JSONFormatter{}
+
+	assert.Equal(t, `{"path":"tags.Name","source_value":"web","target_value":null,"source_value_type":"string"}`, formatter.FormatDiff(formatterDiff))
+
+	source, target := formatter.FormatValues(formatterDiff)
+	assert.Equal(t, `"web"`, source)
+	assert.Equal(t, "null", target)
+
+	// Values that don't marshal fall back to their %v form
+	unmarshalable := DiffEntry{Path: "callback", SourceValue: func() {}, TargetValue: []string{"a"}}
+	assert.Contains(t, formatter.FormatDiff(unmarshalable), `"target_value":"[a]"`)
+	_, target = formatter.FormatValues(unmarshalable)
+	assert.Equal(t, `["a"]`, target)
+}
@@ -0,0 +1,144 @@
+package comparator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"unicode/utf8"
+)
+
+// DefaultMaxValueBytes is the serialized size past which sanitized values are truncated
+const DefaultMaxValueBytes = 64 * 1024
+
+// maxSanitizeDepth bounds how deep nested values are converted, so that cyclic values end
+const maxSanitizeDepth = 32
+
+// Sanitize converts a value to JSON-safe types: maps with string keys, []interface{} and
+// primitives. Maps with other key types, such as the map[interface{}]interface{} that YAML and
+// HCL parsers produce, get their keys formatted as strings; pointers are dereferenced; values
+// JSON can't encode are formatted with %v. Values already JSON-safe are returned as-is.
+// A value whose JSON form exceeds maxBytes (0 uses DefaultMaxValueBytes) is replaced by a
+// prefix of it with a truncation marker.
+//
+// valueType is the original Go type name when the value was converted or truncated, and ""
+// when it was kept.
+func Sanitize(value interface{}, maxBytes int) (safe interface{}, valueType string) {
+	if value == nil {
+		return nil, ""
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxValueBytes
+	}
+
+	safe, converted := sanitizeValue(reflect.ValueOf(value), maxSanitizeDepth)
+	if s, ok := safe.(string); ok {
+		if len(s) > maxBytes {
+			return truncateValue(s, maxBytes), fmt.Sprintf("%T", value)
+		}
+	} else if data, err := json.Marshal(safe); err != nil {
+		return fmt.Sprintf("%v", value), fmt.Sprintf("%T", value)
+	} else if len(data) > maxBytes {
+		return truncateValue(string(data), maxBytes), fmt.Sprintf("%T", value)
+	}
+
+	if converted {
+		return safe, fmt.Sprintf("%T", value)
+	}
+	return safe, ""
+}
+
+// sanitizeValue converts v to JSON-safe types, reporting whether anything had to change
+func sanitizeValue(v reflect.Value, depth int) (interface{}, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil, v.Kind() == reflect.Ptr
+		}
+		safe, converted := sanitizeValue(v.Elem(), depth)
+		return safe, converted || v.Kind() == reflect.Ptr
+
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Interface(), false
+
+	case reflect.Float32, reflect.Float64:
+		// NaN and infinities have no JSON form
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Sprintf("%v", f), true
+		}
+		return v.Interface(), false
+
+	case reflect.Map:
+		if depth <= 0 {
+			return fmt.Sprintf("%v", v.Interface()), true
+		}
+		converted := v.Type().Key().Kind() != reflect.String
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem, changed := sanitizeValue(iter.Value(), depth-1)
+			converted = converted || changed
+			m[mapKey(iter.Key())] = elem
+		}
+		if !converted {
+			return v.Interface(), false
+		}
+		return m, true
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v.Interface(), false
+		}
+		if depth <= 0 {
+			return fmt.Sprintf("%v", v.Interface()), true
+		}
+		converted := v.Kind() == reflect.Array
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			elem, changed := sanitizeValue(v.Index(i), depth-1)
+			converted = converted || changed
+			s[i] = elem
+		}
+		if !converted {
+			return v.Interface(), false
+		}
+		return s, true
+
+	case reflect.Struct:
+		// Structs marshal through their exported fields or their own MarshalJSON
+		if _, err := json.Marshal(v.Interface()); err != nil {
+			return fmt.Sprintf("%v", v.Interface()), true
+		}
+		return v.Interface(), false
+	}
+
+	// Channels, functions, complex numbers and the like have no JSON form
+	return fmt.Sprintf("%v", v.Interface()), true
+}
+
+// mapKey formats a map key as a string
+func mapKey(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	if key.Kind() == reflect.Interface && !key.IsNil() {
+		key = key.Elem()
+	}
+	return fmt.Sprintf("%v", key.Interface())
+}
+
+// truncateValue keeps the first maxBytes of a serialized value, cut on a rune boundary so it
+// stays valid UTF-8, followed by a marker with the full size
+func truncateValue(serialized string, maxBytes int) string {
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(serialized[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s… [truncated, %d bytes]", serialized[:cut], len(serialized))
+}
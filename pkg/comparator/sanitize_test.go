@@ -0,0 +1,79 @@
+package comparator
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	name := "web"
+	tests := []struct {
+		name      string
+		value     interface{}
+		expected  interface{}
+		valueType string
+	}{
+		{"nil", nil, nil, ""},
+		{"string", "t2.micro", "t2.micro", ""},
+		{"int", 42, 42, ""},
+		{"string-keyed map", map[string]interface{}{"Name": "web"}, map[string]interface{}{"Name": "web"}, ""},
+		{"string slice", []string{"a", "b"}, []string{"a", "b"}, ""},
+		{
+			"interface-keyed map",
+			map[interface{}]interface{}{"Name": "web", 1: 2},
+			map[string]interface{}{"Name": "web", "1": 2},
+			"map[interface {}]interface {}",
+		},
+		{
+			"nested interface-keyed map",
+			[]interface{}{map[string]interface{}{"tags": map[interface{}]interface{}{"Env": "prod"}}},
+			[]interface{}{map[string]interface{}{"tags": map[string]interface{}{"Env": "prod"}}},
+			"[]interface {}",
+		},
+		{"array", [2]int{1, 2}, []interface{}{1, 2}, "[2]int"},
+		{"pointer", &name, "web", "*string"},
+		{"NaN", math.NaN(), "NaN", "float64"},
+		{"function", func() {}, nil, "func()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			safe, valueType := Sanitize(tt.value, 0)
+			assert.Equal(t, tt.valueType, valueType)
+			if tt.expected != nil {
+				assert.Equal(t, tt.expected, safe)
+			}
+			_, err := json.Marshal(safe)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSanitize_Truncates(t *testing.T) {
+	// Strings are cut as-is, on a rune boundary
+	safe, valueType := Sanitize(strings.Repeat("é", 10), 5)
+	assert.Equal(t, "éé… [truncated, 20 bytes]", safe)
+	assert.Equal(t, "string", valueType)
+
+	// Other values are cut from their JSON form
+	safe, valueType = Sanitize(map[string]interface{}{"Name": "web"}, 8)
+	assert.Equal(t, `{"Name":… [truncated, 14 bytes]`, safe)
+	assert.Equal(t, "map[string]interface {}", valueType)
+}
+
+func TestCompare_SanitizesDiffValues(t *testing.T) {
+	c := NewComparator()
+	source := map[string]interface{}{"tags": map[interface{}]interface{}{"Name": "web"}}
+	target := map[string]interface{}{"tags": map[string]interface{}{"Name": "db"}}
+
+	diffs := c.Compare(source, target, []string{"tags"})
+
+	assert.Equal(t, map[string]interface{}{"Name": "web"}, diffs["tags"].SourceValue)
+	assert.Equal(t, "map[interface {}]interface {}", diffs["tags"].SourceValueType)
+	assert.Empty(t, diffs["tags"].TargetValueType)
+	assert.Equal(t, "tags: map[Name:web] => map[Name:db]", c.FormatDiff(diffs["tags"]))
+}
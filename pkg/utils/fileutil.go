@@ -10,9 +10,14 @@ import (
 
 // AppendUniqueSuffix generates a unique filename like: report_20240422_162045.json
 func AppendUniqueSuffix(filename string) string {
+	return AppendTimestampSuffix(filename, time.Now())
+}
+
+// AppendTimestampSuffix appends the given time to a filename, keeping its extension
+func AppendTimestampSuffix(filename string, t time.Time) string {
 	ext := filepath.Ext(filename)
 	name := strings.TrimSuffix(filename, ext)
 
-	timestamp := time.Now().Format("20060102_150405")
+	timestamp := t.Format("20060102_150405")
 	return fmt.Sprintf("%s_%s%s", name, timestamp, ext)
 }
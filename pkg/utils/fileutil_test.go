@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/victor-devv/ec2-drift-detector/pkg/utils"
@@ -38,3 +39,8 @@ func TestAppendUniqueSuffix_PreservesCustomExtension(t *testing.T) {
 	// Should contain original base name + suffix
 	require.True(t, strings.HasPrefix(result, "drift.report_"), "prefix missing")
 }
+
+func TestAppendTimestampSuffix_UsesGivenTime(t *testing.T) {
+	ts := time.Date(2024, 4, 22, 16, 20, 45, 0, time.UTC)
+	require.Equal(t, "report_20240422_162045.json", utils.AppendTimestampSuffix("report.json", ts))
+}